@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Audit log recording who changed what, starting with account deletion requests
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id TEXT,
+    actor_id TEXT,  -- NULL when the bot itself performed the action
+    target_user_id TEXT,
+    action TEXT NOT NULL,  -- e.g. 'forget_user'
+    reason TEXT,
+    details JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_guild_created_at ON audit_log(guild_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target_user_id ON audit_log(target_user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_audit_log_target_user_id;
+DROP INDEX IF EXISTS idx_audit_log_guild_created_at;
+DROP TABLE IF EXISTS audit_log;
+
+-- +goose StatementEnd
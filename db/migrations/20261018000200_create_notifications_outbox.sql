@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Durable queue of Discord messages; rows are written in the same transaction as the
+-- change they announce and delivered by the notification dispatcher
+CREATE TABLE IF NOT EXISTS notifications_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    dedupe_key TEXT NOT NULL UNIQUE,  -- e.g. 'achievement:<guild>:<user>:<achievement>'
+    kind TEXT NOT NULL,  -- 'achievement', 'streak' or 'session'
+    guild_id TEXT,
+    user_id TEXT,
+    channel_id TEXT NOT NULL,
+    payload JSONB NOT NULL,  -- message content and embed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_outbox_pending ON notifications_outbox(next_attempt_at) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_notifications_outbox_user_id ON notifications_outbox(user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_notifications_outbox_user_id;
+DROP INDEX IF EXISTS idx_notifications_outbox_pending;
+DROP TABLE IF EXISTS notifications_outbox;
+
+-- +goose StatementEnd
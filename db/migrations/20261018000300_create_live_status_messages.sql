@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- One pinned "who's studying now" message per guild, edited by the bot every minute
+CREATE TABLE IF NOT EXISTS live_status_messages (
+    guild_id TEXT PRIMARY KEY,
+    channel_id TEXT NOT NULL,
+    message_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS live_status_messages;
+
+-- +goose StatementEnd
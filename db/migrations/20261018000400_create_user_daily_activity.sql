@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Study time per user, guild and Manila calendar day. Sessions that cross midnight are split
+-- so each day only receives its own share.
+CREATE TABLE IF NOT EXISTS user_daily_activity (
+    user_id TEXT NOT NULL,
+    guild_id TEXT NOT NULL,
+    activity_date DATE NOT NULL,
+    study_ms BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, guild_id, activity_date)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS user_daily_activity;
+
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Weekly and monthly recaps posted to each guild, kept so past periods can be shown again
+CREATE TABLE IF NOT EXISTS recaps (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id TEXT NOT NULL,
+    period TEXT NOT NULL,  -- 'weekly' or 'monthly'
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,  -- Exclusive
+    payload JSONB NOT NULL,  -- top studiers, community total, improvers, streaks and badges
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (guild_id, period, period_start)
+);
+
+-- Users who asked for a personal recap summary by DM
+CREATE TABLE IF NOT EXISTS recap_subscriptions (
+    user_id TEXT NOT NULL,
+    guild_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, guild_id)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS recap_subscriptions;
+DROP TABLE IF EXISTS recaps;
+
+-- +goose StatementEnd
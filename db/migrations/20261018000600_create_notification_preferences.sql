@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- How each user wants to hear about streaks, achievements and sessions: 'channel', 'dm' or 'off'.
+-- Users without a row get every notification in the channel, as before.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id TEXT PRIMARY KEY,
+    streak_warnings TEXT NOT NULL DEFAULT 'channel',
+    daily_complete TEXT NOT NULL DEFAULT 'channel',
+    achievements TEXT NOT NULL DEFAULT 'channel',
+    session_summaries TEXT NOT NULL DEFAULT 'channel',
+    quiet_start_hour INTEGER,  -- Manila hour quiet hours begin; NULL when not set
+    quiet_end_hour INTEGER,  -- Manila hour they end; notifications queued in between wait until then
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (quiet_start_hour IS NULL OR quiet_start_hour BETWEEN 0 AND 23),
+    CHECK (quiet_end_hour IS NULL OR quiet_end_hour BETWEEN 0 AND 23)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS notification_preferences;
+
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Per-guild settings. Reminder times are minutes after midnight Manila time, so the
+-- default of 1200 is the old hardcoded 8:00 PM warning.
+CREATE TABLE IF NOT EXISTS guild_settings (
+    guild_id TEXT PRIMARY KEY,
+    reminder_minutes INTEGER[] NOT NULL DEFAULT '{1200}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- A user's own reminder times for a guild, replacing the guild's schedule
+CREATE TABLE IF NOT EXISTS user_reminder_settings (
+    user_id TEXT NOT NULL,
+    guild_id TEXT NOT NULL,
+    reminder_minutes INTEGER[] NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, guild_id)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS user_reminder_settings;
+DROP TABLE IF EXISTS guild_settings;
+
+-- +goose StatementEnd
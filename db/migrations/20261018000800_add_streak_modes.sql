@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- How streaks are counted in a guild:
+--   'daily'    study every calendar day (the original rules)
+--   'weekdays' study Monday to Friday; weekends neither count nor break a streak
+--   'weekly'   study weekly_goal_minutes each Sunday to Saturday week
+ALTER TABLE guild_settings
+    ADD COLUMN IF NOT EXISTS streak_mode TEXT NOT NULL DEFAULT 'daily',
+    ADD COLUMN IF NOT EXISTS weekly_goal_minutes INTEGER NOT NULL DEFAULT 300,
+    ADD CONSTRAINT guild_settings_streak_mode_check CHECK (streak_mode IN ('daily', 'weekdays', 'weekly')),
+    ADD CONSTRAINT guild_settings_weekly_goal_check CHECK (weekly_goal_minutes > 0);
+
+-- The mode a user's current streak was counted in. When a guild switches between day and
+-- week based modes the streak starts over, since the counts aren't comparable.
+ALTER TABLE user_streaks
+    ADD COLUMN IF NOT EXISTS streak_mode TEXT NOT NULL DEFAULT 'daily';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE user_streaks DROP COLUMN IF EXISTS streak_mode;
+
+ALTER TABLE guild_settings
+    DROP CONSTRAINT IF EXISTS guild_settings_weekly_goal_check,
+    DROP CONSTRAINT IF EXISTS guild_settings_streak_mode_check,
+    DROP COLUMN IF EXISTS weekly_goal_minutes,
+    DROP COLUMN IF EXISTS streak_mode;
+
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The streak a user lost at their last break and the day it broke. Studying double the daily
+-- minimum on the next day that counts restores it.
+ALTER TABLE user_streaks
+    ADD COLUMN IF NOT EXISTS previous_streak INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS streak_broken_at DATE;
+
+-- Whether broken streaks in a guild can be repaired
+ALTER TABLE guild_settings
+    ADD COLUMN IF NOT EXISTS streak_repair BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE guild_settings DROP COLUMN IF EXISTS streak_repair;
+
+ALTER TABLE user_streaks
+    DROP COLUMN IF EXISTS streak_broken_at,
+    DROP COLUMN IF EXISTS previous_streak;
+
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- What happened to each user's streak at each daily evaluation:
+--   'start'     a new streak began
+--   'increment' the streak grew by one
+--   'freeze'    the streak was held on a day that doesn't count, such as a weekend in weekdays mode
+--   'break'     the streak ended; streak_count is the length it reached
+--   'repair'    a broken streak was restored
+-- Otherwise streak_count is the count after the event. A user has at most one event per day.
+CREATE TABLE IF NOT EXISTS streak_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    guild_id TEXT NOT NULL,
+    event_date DATE NOT NULL,
+    event_type TEXT NOT NULL CHECK (event_type IN ('start', 'increment', 'freeze', 'break', 'repair')),
+    streak_count INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, guild_id, event_date)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS streak_events;
+
+-- +goose StatementEnd
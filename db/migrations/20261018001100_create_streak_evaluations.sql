@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The last Manila date each guild's daily streak evaluation completed for. Days after it that have
+-- already ended were missed, e.g. while the bot was down, and are evaluated on the next run.
+CREATE TABLE IF NOT EXISTS streak_evaluations (
+    guild_id TEXT PRIMARY KEY,
+    last_evaluated_date DATE NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS streak_evaluations;
+
+-- +goose StatementEnd
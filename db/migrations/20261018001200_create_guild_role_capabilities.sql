@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Discord roles allowed to use a bot capability in a guild, on top of members with the
+-- Administrator permission, who can use all of them:
+--   'manage_channels' pin live status messages
+--   'adjust_stats'    clean up sessions and correct study time
+--   'run_backfills'   re-run missed streak evaluations
+--   'view_audit_log'  read the audit log
+CREATE TABLE IF NOT EXISTS guild_role_capabilities (
+    guild_id TEXT NOT NULL,
+    role_id TEXT NOT NULL,
+    capability TEXT NOT NULL CHECK (capability IN ('manage_channels', 'adjust_stats', 'run_backfills', 'view_audit_log')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (guild_id, role_id, capability)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS guild_role_capabilities;
+
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Sessions added or taken away by /admin time rather than tracked in voice. Removals are stored
+-- with a negative duration_ms so the session history still adds up to user_stats.
+ALTER TABLE study_sessions ADD COLUMN IF NOT EXISTS is_manual BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE study_sessions DROP COLUMN IF EXISTS is_manual;
+
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The guild a session was tracked in, so sessions can be cleaned up one server at a time
+ALTER TABLE study_sessions ADD COLUMN IF NOT EXISTS guild_id TEXT;
+
+-- Sessions recorded before this column existed belong to the member's only server, when they have one
+UPDATE study_sessions s
+SET guild_id = g.guild_id
+FROM (
+    SELECT user_id, MIN(guild_id) AS guild_id
+    FROM user_streaks
+    GROUP BY user_id
+    HAVING COUNT(*) = 1
+) g
+WHERE s.user_id = g.user_id AND s.guild_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_study_sessions_guild_start_time ON study_sessions(guild_id, start_time);
+
+-- Sessions removed by /cleanup-sessions, copied here in the same transaction before they are deleted
+CREATE TABLE IF NOT EXISTS study_sessions_archive (
+    session_id INTEGER PRIMARY KEY,
+    user_id TEXT,
+    guild_id TEXT NOT NULL,
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time TIMESTAMPTZ NOT NULL,
+    duration_ms BIGINT,
+    is_manual BOOLEAN NOT NULL,
+    archived_by TEXT,  -- NULL when archived from the maintenance script
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_study_sessions_archive_user_id ON study_sessions_archive(user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_study_sessions_archive_user_id;
+DROP TABLE IF EXISTS study_sessions_archive;
+DROP INDEX IF EXISTS idx_study_sessions_guild_start_time;
+ALTER TABLE study_sessions DROP COLUMN IF EXISTS guild_id;
+
+-- +goose StatementEnd
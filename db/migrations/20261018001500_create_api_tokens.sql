@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Tokens for the read-only REST API, each scoped to one guild. Only a SHA-256 hash of the token
+-- is stored; the token itself is shown once, to the admin who created it.
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ  -- Revoked tokens are kept for the audit trail but never accepted
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_guild_id ON api_tokens(guild_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_api_tokens_guild_id;
+DROP TABLE IF EXISTS api_tokens;
+
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Text channel each guild's weekly and monthly recaps are posted to, set with /recap channel.
+-- Guilds without one still get recaps archived and sent by DM.
+ALTER TABLE guild_settings
+    ADD COLUMN IF NOT EXISTS recap_channel_id TEXT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE guild_settings DROP COLUMN IF EXISTS recap_channel_id;
+
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The nightly purge archives old closed sessions before deleting them, including sessions the
+-- guild_id backfill couldn't place because the member was in more than one server. Those keep a
+-- NULL guild_id: per-guild /cleanup-sessions and an admin's /forget-user never match them, but
+-- /forget-me removes them along with the rest of the member's data.
+ALTER TABLE study_sessions_archive ALTER COLUMN guild_id DROP NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM study_sessions_archive WHERE guild_id IS NULL;
+ALTER TABLE study_sessions_archive ALTER COLUMN guild_id SET NOT NULL;
+
+-- +goose StatementEnd
@@ -9,8 +9,13 @@ SELECT * FROM users
 WHERE user_id = $1;
 
 -- name: CreateStudySession :one
-INSERT INTO study_sessions (user_id, start_time)
-VALUES ($1, $2)
+INSERT INTO study_sessions (user_id, guild_id, start_time)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: CreateManualStudySession :one
+INSERT INTO study_sessions (user_id, guild_id, start_time, end_time, duration_ms, is_manual)
+VALUES ($1, $2, $3, $4, $5, TRUE)
 RETURNING *;
 
 -- name: EndStudySession :one
@@ -20,7 +25,7 @@ WHERE session_id = $1 AND end_time IS NULL
 RETURNING *;
 
 -- name: GetActiveStudySession :one
-/* ACTIVE_SESSION_QUERY_1_PARAM */ SELECT session_id, user_id, start_time, end_time, duration_ms FROM study_sessions
+/* ACTIVE_SESSION_QUERY_1_PARAM */ SELECT session_id, user_id, start_time, end_time, duration_ms, is_manual, guild_id FROM study_sessions
 WHERE user_id = $1 AND end_time IS NULL
 ORDER BY start_time DESC
 LIMIT 1;
@@ -31,13 +36,13 @@ WHERE user_id = $1;
 
 -- name: CreateOrUpdateUserStats :one
 INSERT INTO user_stats (user_id, total_study_ms, daily_study_ms, weekly_study_ms, monthly_study_ms)
-VALUES ($1, $2, $2, $2, $2)
+VALUES ($1, $2, $3, $4, $5)
 ON CONFLICT (user_id) DO UPDATE
 SET 
   total_study_ms = user_stats.total_study_ms + $2,
-  daily_study_ms = user_stats.daily_study_ms + $2,
-  weekly_study_ms = user_stats.weekly_study_ms + $2,
-  monthly_study_ms = user_stats.monthly_study_ms + $2
+  daily_study_ms = user_stats.daily_study_ms + $3,
+  weekly_study_ms = user_stats.weekly_study_ms + $4,
+  monthly_study_ms = user_stats.monthly_study_ms + $5
 RETURNING *;
 
 -- name: ResetDailyStudyTime :exec
@@ -67,9 +72,16 @@ ORDER BY
     us.total_study_ms DESC
 LIMIT 10; -- For top 10 users
 
--- name: DeleteOldStudySessions :exec
-DELETE FROM study_sessions
-WHERE start_time < $1; -- $1 will be the cutoff timestamp (e.g., 6 months ago)
+-- name: DeleteOldStudySessions :execrows
+DELETE FROM study_sessions s
+USING study_sessions_archive a
+WHERE a.session_id = s.session_id AND s.start_time < $1 AND s.end_time IS NOT NULL;
+
+-- name: ArchiveOldStudySessions :execrows
+INSERT INTO study_sessions_archive (session_id, user_id, guild_id, start_time, end_time, duration_ms, is_manual)
+SELECT session_id, user_id, guild_id, start_time, end_time, duration_ms, is_manual
+FROM study_sessions
+WHERE start_time < $1 AND end_time IS NOT NULL;
 
 -- name: DeleteAllStudySessions :exec
 DELETE FROM study_sessions;
@@ -77,14 +89,6 @@ DELETE FROM study_sessions;
 -- name: CountStudySessions :one
 SELECT COUNT(*) FROM study_sessions;
 
--- name: DeleteOldStudySessionsWithCount :one
-WITH deleted AS (
-    DELETE FROM study_sessions
-    WHERE start_time < $1
-    RETURNING session_id
-)
-SELECT COUNT(*) FROM deleted;
-
 -- Calendar Day-Based User Streaks Queries
 
 -- name: GetUserStreak :one
@@ -100,7 +104,9 @@ SELECT
     streak_incremented_today,
     warning_notified_at,
     created_at,
-    updated_at
+    updated_at,
+    previous_streak,
+    streak_broken_at
 FROM user_streaks
 WHERE user_id = $1 AND guild_id = $2;
 
@@ -165,10 +171,13 @@ SELECT
     daily_activity_minutes,
     warning_notified_at,
     created_at,
-    updated_at
+    updated_at,
+    streak_mode,
+    previous_streak,
+    streak_broken_at
 FROM user_streaks
-WHERE streak_evaluated_date IS NULL 
-   OR streak_evaluated_date < $1; -- $1 is today's date in Manila timezone
+WHERE guild_id = $1
+  AND (streak_evaluated_date IS NULL OR streak_evaluated_date < $2); -- $2 is the Manila date being evaluated
 
 -- name: UpdateUserStreakAfterEvaluation :one
 UPDATE user_streaks
@@ -176,25 +185,49 @@ SET
     current_streak_count = $3,
     max_streak_count = GREATEST(max_streak_count, $4),
     streak_evaluated_date = $5,
+    streak_incremented_today = FALSE,
     updated_at = NOW()
 WHERE user_id = $1 AND guild_id = $2
+  AND (streak_evaluated_date IS NULL OR streak_evaluated_date < $5)
 RETURNING user_id, guild_id, current_streak_count, max_streak_count, last_activity_date, streak_evaluated_date, daily_activity_minutes, activity_start_time, warning_notified_at, created_at, updated_at;
 
 -- name: GetUsersNeedingWarnings :many
 SELECT 
-    user_id, 
-    guild_id, 
-    current_streak_count, 
-    max_streak_count, 
-    last_activity_date,
-    daily_activity_minutes,
-    warning_notified_at,
-    created_at,
-    updated_at
-FROM user_streaks
-WHERE current_streak_count > 0
-  AND (last_activity_date IS NULL OR last_activity_date < $1) -- Haven't been active today
-  AND (warning_notified_at IS NULL OR DATE(warning_notified_at) < $1); -- Haven't been warned today
+    s.user_id, 
+    s.guild_id, 
+    s.current_streak_count, 
+    s.max_streak_count, 
+    s.last_activity_date,
+    s.daily_activity_minutes,
+    s.warning_notified_at,
+    s.created_at,
+    s.updated_at,
+    s.streak_mode,
+    g.reminder_minutes AS guild_reminder_minutes, -- NULL when the guild never set any
+    r.reminder_minutes AS user_reminder_minutes -- NULL unless the user set their own
+FROM user_streaks s
+LEFT JOIN guild_settings g ON g.guild_id = s.guild_id
+LEFT JOIN user_reminder_settings r ON r.user_id = s.user_id AND r.guild_id = s.guild_id
+WHERE s.current_streak_count > 0
+  AND (s.last_activity_date IS NULL OR s.last_activity_date < sqlc.arg(today) -- Haven't been active today
+       OR COALESCE(s.daily_activity_minutes, 0) < sqlc.arg(min_minutes)::int -- Or haven't done enough yet
+       OR s.streak_mode = 'weekly'); -- Weekly goals depend on the whole week
+
+-- name: SetUserStreakMode :exec
+UPDATE user_streaks
+SET
+    streak_mode = $3,
+    current_streak_count = $4,
+    updated_at = NOW()
+WHERE user_id = $1 AND guild_id = $2;
+
+-- name: UpdateStreakBreak :exec
+UPDATE user_streaks
+SET
+    previous_streak = $3,
+    streak_broken_at = $4,
+    updated_at = NOW()
+WHERE user_id = $1 AND guild_id = $2;
 
 -- name: UpdateWarningNotifiedAt :exec
 UPDATE user_streaks
@@ -212,21 +245,12 @@ SET
     updated_at = NOW()
 WHERE user_id = $1 AND guild_id = $2;
 
--- name: ResetAllStreakDailyFlags :exec
+-- name: ClearStreakIncrementedToday :exec
 UPDATE user_streaks
-SET 
+SET
     streak_incremented_today = FALSE,
-    updated_at = NOW();
-
--- name: GetUsersForStreakReset :many
-SELECT 
-    user_id, 
-    guild_id, 
-    current_streak_count
-FROM user_streaks
-WHERE current_streak_count > 0
-  AND streak_incremented_today = FALSE
-  AND (last_activity_date IS NULL OR last_activity_date < $1); -- Haven't been active today
+    updated_at = NOW()
+WHERE user_id = $1 AND guild_id = $2;
 
 -- name: ResetUserStreakCount :exec
 UPDATE user_streaks
@@ -359,7 +383,7 @@ SELECT COUNT(*) as count FROM achievements;
 -- name: GetUniqueStudyHours :one
 SELECT COUNT(DISTINCT EXTRACT(HOUR FROM start_time AT TIME ZONE 'Asia/Manila'))::integer
 FROM study_sessions
-WHERE user_id = $1;
+WHERE user_id = $1 AND NOT is_manual;
 
 -- name: HasDawnToDuskDay :one
 SELECT EXISTS(
@@ -367,7 +391,7 @@ SELECT EXISTS(
     SELECT DATE(start_time AT TIME ZONE 'Asia/Manila') as study_date,
            SUM(EXTRACT(EPOCH FROM COALESCE(end_time, NOW()) - start_time)) / 3600 as hours
     FROM study_sessions
-    WHERE user_id = $1
+    WHERE user_id = $1 AND NOT is_manual
     GROUP BY study_date
     HAVING SUM(EXTRACT(EPOCH FROM COALESCE(end_time, NOW()) - start_time)) / 3600 >= 12
   ) as daily_hours
@@ -387,3 +411,501 @@ SELECT
 FROM achievements
 WHERE requirement_type = $1
 ORDER BY requirement_value ASC;
+
+-- =============================================
+-- Account Deletion & Audit Log Queries
+-- =============================================
+
+-- name: DeleteUserAchievements :execrows
+DELETE FROM user_achievements
+WHERE user_id = $1;
+
+-- name: DeleteUserStreaks :execrows
+DELETE FROM user_streaks
+WHERE user_id = $1;
+
+-- name: DeleteUserStudySessions :execrows
+DELETE FROM study_sessions
+WHERE user_id = $1;
+
+-- name: DeleteUserArchivedStudySessions :execrows
+DELETE FROM study_sessions_archive
+WHERE user_id = $1;
+
+-- name: DeleteUserStats :execrows
+DELETE FROM user_stats
+WHERE user_id = $1;
+
+-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE user_id = $1;
+
+-- =============================================
+-- Guild-Scoped Account Deletion Queries
+-- =============================================
+
+-- name: DeleteUserGuildAchievements :execrows
+DELETE FROM user_achievements
+WHERE user_id = $1 AND guild_id = $2;
+
+-- name: DeleteUserGuildStreaks :execrows
+DELETE FROM user_streaks
+WHERE user_id = $1 AND guild_id = $2;
+
+-- name: DeleteUserGuildStreakEvents :execrows
+DELETE FROM streak_events
+WHERE user_id = $1 AND guild_id = $2;
+
+-- name: DeleteUserGuildDailyActivity :execrows
+DELETE FROM user_daily_activity
+WHERE user_id = $1 AND guild_id = $2;
+
+-- name: DeleteUserGuildStudySessions :execrows
+DELETE FROM study_sessions
+WHERE user_id = $1 AND guild_id = $2;
+
+-- name: DeleteUserGuildArchivedStudySessions :execrows
+DELETE FROM study_sessions_archive
+WHERE user_id = $1 AND guild_id = $2;
+
+-- name: DeleteUserGuildNotifications :execrows
+DELETE FROM notifications_outbox
+WHERE user_id = $1 AND guild_id = $2;
+
+-- name: DeleteUserGuildReminderSettings :execrows
+DELETE FROM user_reminder_settings
+WHERE user_id = $1 AND guild_id = $2;
+
+-- name: CreateAuditLogEntry :one
+INSERT INTO audit_log (guild_id, actor_id, target_user_id, action, reason, details)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, guild_id, actor_id, target_user_id, action, reason, details, created_at;
+
+-- name: GetGuildAuditLog :many
+SELECT id, guild_id, actor_id, target_user_id, action, reason, details, created_at
+FROM audit_log
+WHERE guild_id = sqlc.arg(guild_id)
+  AND (sqlc.narg(user_id)::TEXT IS NULL OR actor_id = sqlc.narg(user_id) OR target_user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(action)::TEXT IS NULL OR action = sqlc.narg(action))
+  AND (sqlc.narg(from_time)::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg(to_time))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: DeleteUserNotifications :execrows
+DELETE FROM notifications_outbox
+WHERE user_id = $1;
+
+-- =============================================
+-- Notification Outbox Queries
+-- =============================================
+
+-- name: EnqueueNotification :execrows
+INSERT INTO notifications_outbox (dedupe_key, kind, guild_id, user_id, channel_id, payload, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $6, COALESCE(sqlc.narg(next_attempt_at), NOW()))
+ON CONFLICT (dedupe_key) DO NOTHING;
+
+-- name: GetDueNotifications :many
+SELECT id, dedupe_key, kind, guild_id, user_id, channel_id, payload, attempts, next_attempt_at, last_error, sent_at, created_at
+FROM notifications_outbox
+WHERE sent_at IS NULL AND next_attempt_at <= $1 AND attempts < $2
+ORDER BY next_attempt_at ASC, id ASC
+LIMIT $3;
+
+-- name: MarkNotificationSent :exec
+UPDATE notifications_outbox
+SET sent_at = $2, last_error = NULL
+WHERE id = $1;
+
+-- name: MarkNotificationFailed :exec
+UPDATE notifications_outbox
+SET attempts = attempts + 1, next_attempt_at = $2, last_error = $3
+WHERE id = $1;
+
+-- Used when Discord rate limits us: the attempt doesn't count against the retry budget
+-- name: RescheduleNotification :exec
+UPDATE notifications_outbox
+SET next_attempt_at = $2
+WHERE id = $1;
+
+-- name: DeleteNotification :exec
+DELETE FROM notifications_outbox
+WHERE dedupe_key = $1;
+
+-- name: DeleteSentNotifications :execrows
+DELETE FROM notifications_outbox
+WHERE sent_at IS NOT NULL AND sent_at < $1;
+
+-- name: GetUsersWithUnnotifiedAchievements :many
+SELECT DISTINCT user_id, guild_id
+FROM user_achievements
+WHERE notified = FALSE;
+
+-- =============================================
+-- Live Status Message Queries
+-- =============================================
+
+-- name: UpsertLiveStatusMessage :exec
+INSERT INTO live_status_messages (guild_id, channel_id, message_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (guild_id) DO UPDATE SET
+    channel_id = EXCLUDED.channel_id,
+    message_id = EXCLUDED.message_id,
+    created_at = NOW();
+
+-- name: GetLiveStatusMessage :one
+SELECT guild_id, channel_id, message_id, created_at
+FROM live_status_messages
+WHERE guild_id = $1;
+
+-- name: GetLiveStatusMessages :many
+SELECT guild_id, channel_id, message_id, created_at
+FROM live_status_messages
+ORDER BY guild_id;
+
+-- name: DeleteLiveStatusMessage :execrows
+DELETE FROM live_status_messages
+WHERE guild_id = $1;
+
+-- =============================================
+-- Daily Activity Queries
+-- =============================================
+
+-- name: AddDailyActivity :exec
+INSERT INTO user_daily_activity (user_id, guild_id, activity_date, study_ms)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, guild_id, activity_date) DO UPDATE SET
+    study_ms = user_daily_activity.study_ms + EXCLUDED.study_ms;
+
+-- name: GetDailyActivity :many
+SELECT user_id, guild_id, activity_date, study_ms
+FROM user_daily_activity
+WHERE user_id = sqlc.arg(user_id) AND guild_id = sqlc.arg(guild_id)
+  AND activity_date BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date)
+ORDER BY activity_date ASC;
+
+-- name: DeleteUserDailyActivity :execrows
+DELETE FROM user_daily_activity
+WHERE user_id = $1;
+
+-- =============================================
+-- Recap Queries
+-- =============================================
+
+-- name: GetGuildStudyTotals :many
+SELECT guild_id, user_id, SUM(study_ms)::BIGINT AS study_ms
+FROM user_daily_activity
+WHERE activity_date >= sqlc.arg(from_date) AND activity_date < sqlc.arg(to_date)
+GROUP BY guild_id, user_id
+ORDER BY guild_id, study_ms DESC, user_id;
+
+-- name: GetGuildTopStreaks :many
+SELECT user_id, current_streak_count
+FROM user_streaks
+WHERE guild_id = $1 AND current_streak_count > 0
+ORDER BY current_streak_count DESC, user_id
+LIMIT $2;
+
+-- name: GetGuildAchievementsEarnedBetween :many
+SELECT ua.user_id, ua.achievement_id, a.name, a.icon
+FROM user_achievements ua
+JOIN achievements a ON a.achievement_id = ua.achievement_id
+WHERE ua.guild_id = sqlc.arg(guild_id)
+  AND ua.earned_at >= sqlc.arg(from_time) AND ua.earned_at < sqlc.arg(to_time)
+ORDER BY ua.earned_at, ua.user_id;
+
+-- name: CreateRecap :execrows
+INSERT INTO recaps (guild_id, period, period_start, period_end, payload)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (guild_id, period, period_start) DO NOTHING;
+
+-- name: GetRecap :one
+SELECT id, guild_id, period, period_start, period_end, payload, created_at
+FROM recaps
+WHERE guild_id = $1 AND period = $2
+ORDER BY period_start DESC
+LIMIT 1 OFFSET $3;
+
+-- Archived recaps that list the user anywhere in their payload, optionally in one guild only
+-- name: GetRecapsMentioningUser :many
+SELECT id, guild_id, period, period_start, period_end, payload, created_at
+FROM recaps
+WHERE jsonb_path_exists(payload, '$.** ? (@.userId == $uid)', jsonb_build_object('uid', sqlc.arg(user_id)::TEXT))
+  AND (sqlc.narg(guild_id)::TEXT IS NULL OR guild_id = sqlc.narg(guild_id))
+ORDER BY id;
+
+-- name: UpdateRecapPayload :exec
+UPDATE recaps
+SET payload = $2
+WHERE id = $1;
+
+-- name: AddRecapSubscription :exec
+INSERT INTO recap_subscriptions (user_id, guild_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id, guild_id) DO NOTHING;
+
+-- name: DeleteRecapSubscription :execrows
+DELETE FROM recap_subscriptions
+WHERE user_id = $1 AND guild_id = $2;
+
+-- name: GetRecapSubscribers :many
+SELECT user_id
+FROM recap_subscriptions
+WHERE guild_id = $1
+ORDER BY user_id;
+
+-- name: DeleteUserRecapSubscriptions :execrows
+DELETE FROM recap_subscriptions
+WHERE user_id = $1;
+
+-- =============================================
+-- Notification Preference Queries
+-- =============================================
+
+-- name: GetNotificationPreferences :one
+SELECT user_id, streak_warnings, daily_complete, achievements, session_summaries, quiet_start_hour, quiet_end_hour, updated_at
+FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: UpsertNotificationPreferences :exec
+INSERT INTO notification_preferences (user_id, streak_warnings, daily_complete, achievements, session_summaries, quiet_start_hour, quiet_end_hour)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (user_id) DO UPDATE SET
+    streak_warnings = EXCLUDED.streak_warnings,
+    daily_complete = EXCLUDED.daily_complete,
+    achievements = EXCLUDED.achievements,
+    session_summaries = EXCLUDED.session_summaries,
+    quiet_start_hour = EXCLUDED.quiet_start_hour,
+    quiet_end_hour = EXCLUDED.quiet_end_hour,
+    updated_at = NOW();
+
+-- name: DeleteUserNotificationPreferences :execrows
+DELETE FROM notification_preferences
+WHERE user_id = $1;
+
+-- =============================================
+-- Reminder Queries
+-- =============================================
+
+-- name: GetGuildSettings :one
+SELECT guild_id, reminder_minutes, updated_at, streak_mode, weekly_goal_minutes, streak_repair, recap_channel_id
+FROM guild_settings
+WHERE guild_id = $1;
+
+-- name: UpsertGuildReminderMinutes :exec
+INSERT INTO guild_settings (guild_id, reminder_minutes)
+VALUES ($1, $2)
+ON CONFLICT (guild_id) DO UPDATE SET
+    reminder_minutes = EXCLUDED.reminder_minutes,
+    updated_at = NOW();
+
+-- name: UpsertGuildStreakMode :exec
+INSERT INTO guild_settings (guild_id, streak_mode, weekly_goal_minutes, streak_repair)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (guild_id) DO UPDATE SET
+    streak_mode = EXCLUDED.streak_mode,
+    weekly_goal_minutes = EXCLUDED.weekly_goal_minutes,
+    streak_repair = EXCLUDED.streak_repair,
+    updated_at = NOW();
+
+-- name: UpsertGuildRecapChannel :exec
+INSERT INTO guild_settings (guild_id, recap_channel_id)
+VALUES ($1, $2)
+ON CONFLICT (guild_id) DO UPDATE SET
+    recap_channel_id = EXCLUDED.recap_channel_id,
+    updated_at = NOW();
+
+-- name: GetUserReminderSettings :one
+SELECT user_id, guild_id, reminder_minutes, updated_at
+FROM user_reminder_settings
+WHERE user_id = $1 AND guild_id = $2;
+
+-- name: UpsertUserReminderMinutes :exec
+INSERT INTO user_reminder_settings (user_id, guild_id, reminder_minutes)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, guild_id) DO UPDATE SET
+    reminder_minutes = EXCLUDED.reminder_minutes,
+    updated_at = NOW();
+
+-- name: ClearUserReminderMinutes :execrows
+DELETE FROM user_reminder_settings
+WHERE user_id = $1 AND guild_id = $2;
+
+-- name: DeleteUserReminderSettings :execrows
+DELETE FROM user_reminder_settings
+WHERE user_id = $1;
+
+-- =============================================
+-- Streak Event Queries
+-- =============================================
+
+-- name: CreateStreakEvent :exec
+INSERT INTO streak_events (user_id, guild_id, event_date, event_type, streak_count)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, guild_id, event_date) DO NOTHING;
+
+-- name: GetStreakEvents :many
+SELECT id, user_id, guild_id, event_date, event_type, streak_count, created_at
+FROM streak_events
+WHERE user_id = sqlc.arg(user_id) AND guild_id = sqlc.arg(guild_id)
+  AND event_date BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date)
+ORDER BY event_date ASC;
+
+-- A break a later repair undid is not an ended streak
+-- name: GetPastStreaks :many
+SELECT e.id, e.user_id, e.guild_id, e.event_date, e.event_type, e.streak_count, e.created_at
+FROM streak_events e
+WHERE e.user_id = $1 AND e.guild_id = $2 AND e.event_type = 'break'
+  AND NOT EXISTS (
+    SELECT 1 FROM streak_events r
+    WHERE r.user_id = e.user_id AND r.guild_id = e.guild_id
+      AND r.event_type = 'repair' AND r.event_date > e.event_date
+      AND NOT EXISTS (
+        SELECT 1 FROM streak_events b
+        WHERE b.user_id = e.user_id AND b.guild_id = e.guild_id
+          AND b.event_type = 'break' AND b.event_date > e.event_date AND b.event_date < r.event_date
+      )
+  )
+ORDER BY e.event_date DESC
+LIMIT $3;
+
+-- name: DeleteUserStreakEvents :execrows
+DELETE FROM streak_events
+WHERE user_id = $1;
+
+-- =============================================
+-- Streak Evaluation Queries
+-- =============================================
+
+-- name: GetGuildEvaluationDates :many
+SELECT DISTINCT s.guild_id, e.last_evaluated_date
+FROM user_streaks s
+LEFT JOIN streak_evaluations e ON e.guild_id = s.guild_id
+ORDER BY s.guild_id;
+
+-- name: SetGuildLastEvaluatedDate :exec
+INSERT INTO streak_evaluations (guild_id, last_evaluated_date)
+VALUES ($1, $2)
+ON CONFLICT (guild_id) DO UPDATE SET
+    last_evaluated_date = GREATEST(streak_evaluations.last_evaluated_date, EXCLUDED.last_evaluated_date),
+    updated_at = NOW();
+
+-- =============================================
+-- Permission Queries
+-- =============================================
+
+-- name: GetGuildRoleCapabilities :many
+SELECT guild_id, role_id, capability, created_at
+FROM guild_role_capabilities
+WHERE guild_id = $1
+ORDER BY capability, role_id;
+
+-- name: GrantRoleCapability :exec
+INSERT INTO guild_role_capabilities (guild_id, role_id, capability)
+VALUES ($1, $2, $3)
+ON CONFLICT (guild_id, role_id, capability) DO NOTHING;
+
+-- name: RevokeRoleCapability :execrows
+DELETE FROM guild_role_capabilities
+WHERE guild_id = $1 AND role_id = $2 AND capability = $3;
+
+-- =============================================
+-- Session Cleanup Queries
+-- =============================================
+
+-- name: CountGuildStudySessions :one
+SELECT
+    COUNT(*) FILTER (WHERE end_time IS NOT NULL) AS closed_sessions,
+    COUNT(*) FILTER (WHERE end_time IS NULL) AS open_sessions
+FROM study_sessions
+WHERE guild_id = $1 AND start_time < $2;
+
+-- name: ArchiveGuildStudySessions :execrows
+INSERT INTO study_sessions_archive (session_id, user_id, guild_id, start_time, end_time, duration_ms, is_manual, archived_by)
+SELECT session_id, user_id, guild_id, start_time, end_time, duration_ms, is_manual, sqlc.narg(archived_by)::TEXT
+FROM study_sessions
+WHERE guild_id = sqlc.arg(guild_id) AND start_time < sqlc.arg(before) AND end_time IS NOT NULL;
+
+-- Open sessions are never deleted, they belong to members still in voice
+-- name: DeleteGuildStudySessionsWithCount :one
+WITH deleted AS (
+    DELETE FROM study_sessions
+    WHERE guild_id = $1 AND start_time < $2 AND end_time IS NOT NULL
+    RETURNING session_id
+)
+SELECT COUNT(*) FROM deleted;
+
+-- =============================================
+-- API Token Queries
+-- =============================================
+
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (guild_id, name, token_hash, created_by)
+VALUES ($1, $2, $3, $4)
+RETURNING id, guild_id, name, token_hash, created_by, created_at, last_used_at, revoked_at;
+
+-- name: GetAPITokenByHash :one
+SELECT id, guild_id, name, token_hash, created_by, created_at, last_used_at, revoked_at
+FROM api_tokens
+WHERE token_hash = $1 AND revoked_at IS NULL;
+
+-- name: GetGuildAPITokens :many
+SELECT id, guild_id, name, token_hash, created_by, created_at, last_used_at, revoked_at
+FROM api_tokens
+WHERE guild_id = $1 AND revoked_at IS NULL
+ORDER BY created_at, id;
+
+-- name: RevokeAPIToken :execrows
+UPDATE api_tokens
+SET revoked_at = NOW()
+WHERE guild_id = $1 AND id = $2 AND revoked_at IS NULL;
+
+-- Written at most once a minute per token, so busy clients don't turn every read into a write
+-- name: TouchAPIToken :exec
+UPDATE api_tokens
+SET last_used_at = NOW()
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute');
+
+-- =============================================
+-- REST API Queries
+-- =============================================
+
+-- name: GetGuildLeaderboard :many
+SELECT a.user_id, u.username, SUM(a.study_ms)::BIGINT AS study_ms
+FROM user_daily_activity a
+LEFT JOIN users u ON u.user_id = a.user_id
+WHERE a.guild_id = sqlc.arg(guild_id)
+  AND a.activity_date >= sqlc.arg(from_date) AND a.activity_date < sqlc.arg(to_date)
+GROUP BY a.user_id, u.username
+HAVING SUM(a.study_ms) > 0
+ORDER BY study_ms DESC, a.user_id
+LIMIT sqlc.arg(page_size) OFFSET sqlc.arg(page_offset);
+
+-- name: GetUserGuildStudyTotals :one
+SELECT
+    COALESCE(SUM(study_ms), 0)::BIGINT AS total_ms,
+    COALESCE(SUM(study_ms) FILTER (WHERE activity_date >= sqlc.arg(month_start)), 0)::BIGINT AS month_ms,
+    COALESCE(SUM(study_ms) FILTER (WHERE activity_date >= sqlc.arg(week_start)), 0)::BIGINT AS week_ms,
+    COALESCE(SUM(study_ms) FILTER (WHERE activity_date >= sqlc.arg(today)), 0)::BIGINT AS today_ms,
+    COUNT(*) FILTER (WHERE study_ms > 0) AS active_days
+FROM user_daily_activity
+WHERE user_id = sqlc.arg(user_id) AND guild_id = sqlc.arg(guild_id);
+
+-- name: GetUserGuildStudySessions :many
+SELECT session_id, user_id, start_time, end_time, duration_ms, is_manual, guild_id
+FROM study_sessions
+WHERE user_id = sqlc.arg(user_id) AND guild_id = sqlc.arg(guild_id)
+ORDER BY start_time DESC, session_id DESC
+LIMIT sqlc.arg(page_size) OFFSET sqlc.arg(page_offset);
+
+-- =============================================
+-- Dashboard Queries
+-- =============================================
+
+-- name: GetGuildDailyTotals :many
+SELECT activity_date, SUM(study_ms)::BIGINT AS study_ms, COUNT(*) FILTER (WHERE study_ms > 0) AS members
+FROM user_daily_activity
+WHERE guild_id = sqlc.arg(guild_id)
+  AND activity_date >= sqlc.arg(from_date) AND activity_date < sqlc.arg(to_date)
+GROUP BY activity_date
+ORDER BY activity_date;
@@ -0,0 +1,204 @@
+// Package api serves a read-only REST API over a guild's leaderboard and its members' study data,
+// for dashboards and other tools outside Discord. Every request carries a per-guild token created
+// with /admin api-token, and responses carry an ETag so clients can poll cheaply.
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+)
+
+// Page sizes for the limit query parameter of paginated endpoints
+const (
+	DefaultPageSize = 25
+	MaxPageSize     = 100
+)
+
+// maxOffset keeps offsets well inside the int32 the queries take
+const maxOffset = 1_000_000
+
+// Server answers REST API requests from the database
+type Server struct {
+	db     database.Querier
+	tokens *service.APITokenService
+	clock  clock.Clock
+}
+
+// New creates a REST API server reading from db and checking tokens with tokens
+func New(db database.Querier, tokens *service.APITokenService) *Server {
+	return &Server{
+		db:     db,
+		tokens: tokens,
+		clock:  clock.Real{},
+	}
+}
+
+// Register adds the API's routes to mux, under /api/v1/
+func (s *Server) Register(mux *http.ServeMux) {
+	mux.Handle("GET /api/v1/guilds/{guildID}/leaderboard", s.guildHandler(s.leaderboard))
+	mux.Handle("GET /api/v1/guilds/{guildID}/users/{userID}/stats", s.guildHandler(s.userStats))
+	mux.Handle("GET /api/v1/guilds/{guildID}/users/{userID}/streak", s.guildHandler(s.userStreak))
+	mux.Handle("GET /api/v1/guilds/{guildID}/users/{userID}/achievements", s.guildHandler(s.userAchievements))
+	mux.Handle("GET /api/v1/guilds/{guildID}/users/{userID}/sessions", s.guildHandler(s.userSessions))
+}
+
+// apiError is an error response with the HTTP status to send
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string { return e.message }
+
+func errorf(status int, format string, args ...any) *apiError {
+	return &apiError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+// guildRequest is an authenticated request for one guild's data
+type guildRequest struct {
+	*http.Request
+	guildID string
+}
+
+// endpoint builds the response body for a request; errors that aren't *apiError become a 500
+type endpoint func(ctx context.Context, r guildRequest) (any, error)
+
+// guildHandler checks the request's bearer token is valid for the guild in the path, then writes
+// the endpoint's response as JSON
+func (s *Server) guildHandler(fn endpoint) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		guildID := r.PathValue("guildID")
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="lockin-bot"`)
+			writeError(w, errorf(http.StatusUnauthorized, "missing bearer token"))
+			return
+		}
+		apiToken, err := s.tokens.Authenticate(ctx, strings.TrimSpace(token))
+		if errors.Is(err, service.ErrInvalidAPIToken) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="lockin-bot", error="invalid_token"`)
+			writeError(w, errorf(http.StatusUnauthorized, "invalid or revoked token"))
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to authenticate API request", "path", r.URL.Path, "error", err)
+			writeError(w, errorf(http.StatusInternalServerError, "internal error"))
+			return
+		}
+		if apiToken.GuildID != guildID {
+			writeError(w, errorf(http.StatusForbidden, "token is not valid for this server"))
+			return
+		}
+
+		body, err := fn(ctx, guildRequest{Request: r, guildID: guildID})
+		var apiErr *apiError
+		if errors.As(err, &apiErr) {
+			writeError(w, apiErr)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "API request failed", "guild_id", guildID, "path", r.URL.Path, "error", err)
+			writeError(w, errorf(http.StatusInternalServerError, "internal error"))
+			return
+		}
+		writeJSON(w, r, body)
+	})
+}
+
+// writeJSON sends body with an ETag of its content, or 304 Not Modified if the client already has it
+func writeJSON(w http.ResponseWriter, r *http.Request, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to encode API response", "path", r.URL.Path, "error", err)
+		writeError(w, errorf(http.StatusInternalServerError, "internal error"))
+		return
+	}
+	data = append(data, '\n')
+
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, comparing weakly as RFC 9110 asks
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func writeError(w http.ResponseWriter, e *apiError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(e.status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": e.message})
+}
+
+// Pagination describes which page of a list was returned
+type Pagination struct {
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	NextOffset *int `json:"nextOffset"` // Null on the last page
+}
+
+// Page is a paginated list response
+type Page[T any] struct {
+	Data       []T        `json:"data"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// pageParams reads the limit and offset query parameters
+func pageParams(r guildRequest) (limit, offset int, err error) {
+	limit, offset = DefaultPageSize, 0
+	query := r.URL.Query()
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > MaxPageSize {
+			return 0, 0, errorf(http.StatusBadRequest, "limit must be between 1 and %d", MaxPageSize)
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 || offset > maxOffset {
+			return 0, 0, errorf(http.StatusBadRequest, "offset must be between 0 and %d", maxOffset)
+		}
+	}
+	return limit, offset, nil
+}
+
+// newPage builds a page from rows fetched with one more than limit, which tells whether another page follows
+func newPage[T any](rows []T, limit, offset int) Page[T] {
+	page := Page[T]{Data: rows, Pagination: Pagination{Limit: limit, Offset: offset}}
+	if len(rows) > limit {
+		page.Data = rows[:limit]
+		next := offset + limit
+		page.Pagination.NextOffset = &next
+	}
+	if page.Data == nil {
+		page.Data = []T{}
+	}
+	return page
+}
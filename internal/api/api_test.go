@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/database/fakedb"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGuildID = "guild-1"
+
+// newTestAPI returns a mux serving the API over an in-memory database, and a token for testGuildID
+func newTestAPI(t *testing.T) (*http.ServeMux, *fakedb.Querier, string) {
+	t.Helper()
+	db := fakedb.New()
+	tokens := service.NewAPITokenService(db, db)
+	token, _, err := tokens.CreateToken(context.Background(), testGuildID, "admin-1", "dashboard")
+	require.NoError(t, err)
+
+	s := New(db, tokens)
+	s.clock = clock.NewFake(time.Date(2026, 10, 16, 12, 0, 0, 0, service.GetManilaLocation()))
+	mux := http.NewServeMux()
+	s.Register(mux)
+	return mux, db, token
+}
+
+func get(mux *http.ServeMux, path, token string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for n := 0; n+1 < len(header); n += 2 {
+		req.Header.Set(header[n], header[n+1])
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// addStudy credits userID with minutes of study in testGuildID on day
+func addStudy(t *testing.T, db *fakedb.Querier, userID string, day time.Time, minutes int64) {
+	t.Helper()
+	require.NoError(t, db.AddDailyActivity(context.Background(), database.AddDailyActivityParams{
+		UserID:       userID,
+		GuildID:      testGuildID,
+		ActivityDate: day,
+		StudyMs:      minutes * time.Minute.Milliseconds(),
+	}))
+}
+
+func TestAPI_RejectsMissingInvalidAndOtherGuildTokens(t *testing.T) {
+	mux, db, token := newTestAPI(t)
+	tokens := service.NewAPITokenService(db, db)
+	otherToken, _, err := tokens.CreateToken(context.Background(), "guild-2", "admin-2", "other")
+	require.NoError(t, err)
+
+	rec := get(mux, "/api/v1/guilds/guild-1/leaderboard", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+
+	rec = get(mux, "/api/v1/guilds/guild-1/leaderboard", service.APITokenPrefix+"not-a-token")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = get(mux, "/api/v1/guilds/guild-1/leaderboard", otherToken)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.JSONEq(t, `{"error":"token is not valid for this server"}`, rec.Body.String())
+
+	list, err := tokens.ListTokens(context.Background(), testGuildID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.NoError(t, tokens.RevokeToken(context.Background(), testGuildID, "admin-1", list[0].ID))
+	rec = get(mux, "/api/v1/guilds/guild-1/leaderboard", token)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "revoked tokens stop working")
+}
+
+func TestAPI_LeaderboardPagesAndPeriods(t *testing.T) {
+	mux, db, token := newTestAPI(t)
+	manila := service.GetManilaLocation()
+	today := time.Date(2026, 10, 16, 0, 0, 0, 0, manila)
+	addStudy(t, db, "user-a", today, 30)
+	addStudy(t, db, "user-b", today, 60)
+	addStudy(t, db, "user-c", today.AddDate(0, -1, 0), 120) // Last month
+
+	var page Page[LeaderboardEntry]
+	rec := get(mux, "/api/v1/guilds/guild-1/leaderboard?limit=2", token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	require.Len(t, page.Data, 2)
+	assert.Equal(t, "user-c", page.Data[0].UserID)
+	assert.Equal(t, "user-b", page.Data[1].UserID)
+	assert.Equal(t, 2, page.Data[1].Rank)
+	require.NotNil(t, page.Pagination.NextOffset)
+	assert.Equal(t, 2, *page.Pagination.NextOffset)
+
+	page = Page[LeaderboardEntry]{}
+	rec = get(mux, "/api/v1/guilds/guild-1/leaderboard?limit=2&offset=2", token)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, LeaderboardEntry{Rank: 3, UserID: "user-a", StudyMs: 30 * time.Minute.Milliseconds()}, page.Data[0])
+	assert.Nil(t, page.Pagination.NextOffset)
+
+	page = Page[LeaderboardEntry]{}
+	rec = get(mux, "/api/v1/guilds/guild-1/leaderboard?period=month", token)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	require.Len(t, page.Data, 2, "last month's study isn't counted")
+
+	assert.Equal(t, http.StatusBadRequest, get(mux, "/api/v1/guilds/guild-1/leaderboard?period=year", token).Code)
+	assert.Equal(t, http.StatusBadRequest, get(mux, "/api/v1/guilds/guild-1/leaderboard?limit=500", token).Code)
+}
+
+func TestAPI_ETagReturnsNotModifiedUntilDataChanges(t *testing.T) {
+	mux, db, token := newTestAPI(t)
+	today := time.Date(2026, 10, 16, 0, 0, 0, 0, service.GetManilaLocation())
+	addStudy(t, db, "user-a", today, 30)
+
+	rec := get(mux, "/api/v1/guilds/guild-1/leaderboard", token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	rec = get(mux, "/api/v1/guilds/guild-1/leaderboard", token, "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	addStudy(t, db, "user-a", today, 5)
+	rec = get(mux, "/api/v1/guilds/guild-1/leaderboard", token, "If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+}
+
+func TestAPI_UserEndpoints(t *testing.T) {
+	mux, db, token := newTestAPI(t)
+	ctx := context.Background()
+	manila := service.GetManilaLocation()
+	today := time.Date(2026, 10, 16, 0, 0, 0, 0, manila)
+
+	// Users the bot has never seen in the guild are hidden
+	for _, endpoint := range []string{"stats", "streak", "achievements", "sessions"} {
+		rec := get(mux, "/api/v1/guilds/guild-1/users/user-a/"+endpoint, token)
+		assert.Equal(t, http.StatusNotFound, rec.Code, endpoint)
+	}
+
+	_, err := db.CreateUser(ctx, database.CreateUserParams{UserID: "user-a", Username: sql.NullString{String: "alice", Valid: true}})
+	require.NoError(t, err)
+	_, err = db.StartDailyActivity(ctx, database.StartDailyActivityParams{
+		UserID:           "user-a",
+		GuildID:          testGuildID,
+		LastActivityDate: sql.NullTime{Time: today, Valid: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateStreakImmediately(ctx, database.UpdateStreakImmediatelyParams{UserID: "user-a", GuildID: testGuildID, CurrentStreakCount: 3, MaxStreakCount: 7}))
+	addStudy(t, db, "user-a", today, 30)
+	addStudy(t, db, "user-a", time.Date(2026, 10, 1, 0, 0, 0, 0, manila), 60)
+
+	var stats UserStats
+	rec := get(mux, "/api/v1/guilds/guild-1/users/user-a/stats", token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	require.NotNil(t, stats.Username)
+	assert.Equal(t, "alice", *stats.Username)
+	assert.Equal(t, 30*time.Minute.Milliseconds(), stats.TodayMs)
+	assert.Equal(t, 90*time.Minute.Milliseconds(), stats.MonthMs)
+	assert.Equal(t, int64(2), stats.ActiveDays)
+
+	var streak UserStreak
+	rec = get(mux, "/api/v1/guilds/guild-1/users/user-a/streak", token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &streak))
+	assert.Equal(t, int32(3), streak.CurrentStreak)
+	assert.Equal(t, int32(7), streak.MaxStreak)
+	require.NotNil(t, streak.LastActivityDate)
+	assert.Equal(t, "2026-10-16", *streak.LastActivityDate)
+	assert.Empty(t, streak.PastStreaks)
+
+	_, err = db.CreateStudySession(ctx, database.CreateStudySessionParams{
+		UserID:    sql.NullString{String: "user-a", Valid: true},
+		GuildID:   sql.NullString{String: testGuildID, Valid: true},
+		StartTime: today.Add(9 * time.Hour),
+	})
+	require.NoError(t, err)
+	var sessions Page[Session]
+	rec = get(mux, "/api/v1/guilds/guild-1/users/user-a/sessions", token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sessions))
+	require.Len(t, sessions.Data, 1)
+	assert.Nil(t, sessions.Data[0].EndTime, "the session is still in progress")
+
+	rec = get(mux, "/api/v1/guilds/guild-1/users/user-a/achievements", token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data":[]}`, rec.Body.String())
+}
@@ -0,0 +1,277 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+)
+
+// Leaderboard periods, on Manila calendar days like the bot's resets
+const (
+	PeriodAll   = "all"
+	PeriodMonth = "month"
+	PeriodWeek  = "week" // Since Sunday
+	PeriodToday = "today"
+)
+
+// pastStreaksListed is how many ended streaks the streak endpoint returns
+const pastStreaksListed = 10
+
+// dateFormat is how calendar dates are written in responses
+const dateFormat = "2006-01-02"
+
+// LeaderboardEntry is one member's place on the leaderboard
+type LeaderboardEntry struct {
+	Rank     int     `json:"rank"`
+	UserID   string  `json:"userId"`
+	Username *string `json:"username"`
+	StudyMs  int64   `json:"studyMs"`
+}
+
+// UserStats is a member's study time in the guild
+type UserStats struct {
+	UserID     string  `json:"userId"`
+	Username   *string `json:"username"`
+	TodayMs    int64   `json:"todayMs"`
+	WeekMs     int64   `json:"weekMs"`
+	MonthMs    int64   `json:"monthMs"`
+	TotalMs    int64   `json:"totalMs"`
+	ActiveDays int64   `json:"activeDays"`
+}
+
+// UserStreak is a member's current and past streaks in the guild
+type UserStreak struct {
+	UserID           string       `json:"userId"`
+	CurrentStreak    int32        `json:"currentStreak"`
+	MaxStreak        int32        `json:"maxStreak"`
+	LastActivityDate *string      `json:"lastActivityDate"`
+	PastStreaks      []PastStreak `json:"pastStreaks"`
+}
+
+// PastStreak is a streak that has ended
+type PastStreak struct {
+	Length  int32  `json:"length"`
+	EndedOn string `json:"endedOn"`
+}
+
+// Achievement is a badge a member earned in the guild
+type Achievement struct {
+	AchievementID string     `json:"achievementId"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	Icon          string     `json:"icon"`
+	Category      string     `json:"category"`
+	EarnedAt      *time.Time `json:"earnedAt"`
+}
+
+// Session is one study session; EndTime and DurationMs are null while it is in progress
+type Session struct {
+	SessionID  int32      `json:"sessionId"`
+	StartTime  time.Time  `json:"startTime"`
+	EndTime    *time.Time `json:"endTime"`
+	DurationMs *int64     `json:"durationMs"`
+	Manual     bool       `json:"manual"` // Added with /admin time rather than tracked in voice
+}
+
+// leaderboard ranks the guild's members by study time over the period query parameter
+func (s *Server) leaderboard(ctx context.Context, r guildRequest) (any, error) {
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		return nil, err
+	}
+	now := s.clock.Now()
+	loc := service.GetManilaLocation()
+	var from time.Time // The zero time, for all time
+	switch period := r.URL.Query().Get("period"); period {
+	case "", PeriodAll:
+	case PeriodMonth:
+		from = service.StartOfMonth(now, loc)
+	case PeriodWeek:
+		from = service.StartOfWeek(now, loc)
+	case PeriodToday:
+		from = service.StartOfDay(now, loc)
+	default:
+		return nil, errorf(http.StatusBadRequest, "period must be %s, %s, %s or %s", PeriodAll, PeriodMonth, PeriodWeek, PeriodToday)
+	}
+
+	rows, err := s.db.GetGuildLeaderboard(ctx, database.GetGuildLeaderboardParams{
+		GuildID:    r.guildID,
+		FromDate:   from,
+		ToDate:     service.StartOfDay(now, loc).AddDate(0, 0, 1),
+		PageSize:   int32(limit + 1),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+	entries := make([]LeaderboardEntry, len(rows))
+	for i, row := range rows {
+		entries[i] = LeaderboardEntry{
+			Rank:     offset + i + 1,
+			UserID:   row.UserID,
+			Username: nullString(row.Username),
+			StudyMs:  row.StudyMs,
+		}
+	}
+	return newPage(entries, limit, offset), nil
+}
+
+// userStats returns a member's study time today, this week, this month and in total
+func (s *Server) userStats(ctx context.Context, r guildRequest) (any, error) {
+	userID := r.PathValue("userID")
+	now := s.clock.Now()
+	loc := service.GetManilaLocation()
+	totals, err := s.db.GetUserGuildStudyTotals(ctx, database.GetUserGuildStudyTotalsParams{
+		MonthStart: service.StartOfMonth(now, loc),
+		WeekStart:  service.StartOfWeek(now, loc),
+		Today:      service.StartOfDay(now, loc),
+		UserID:     userID,
+		GuildID:    r.guildID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get study totals: %w", err)
+	}
+	if totals.ActiveDays == 0 {
+		if err := s.requireMember(ctx, r.guildID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	stats := UserStats{
+		UserID:     userID,
+		TodayMs:    totals.TodayMs,
+		WeekMs:     totals.WeekMs,
+		MonthMs:    totals.MonthMs,
+		TotalMs:    totals.TotalMs,
+		ActiveDays: totals.ActiveDays,
+	}
+	user, err := s.db.GetUser(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	stats.Username = nullString(user.Username)
+	return stats, nil
+}
+
+// userStreak returns a member's streak and their most recent ended streaks
+func (s *Server) userStreak(ctx context.Context, r guildRequest) (any, error) {
+	userID := r.PathValue("userID")
+	streak, err := s.db.GetUserStreak(ctx, database.GetUserStreakParams{UserID: userID, GuildID: r.guildID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNotMember
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user streak: %w", err)
+	}
+	past, err := s.db.GetPastStreaks(ctx, database.GetPastStreaksParams{UserID: userID, GuildID: r.guildID, Limit: pastStreaksListed})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get past streaks: %w", err)
+	}
+
+	out := UserStreak{
+		UserID:        userID,
+		CurrentStreak: streak.CurrentStreakCount,
+		MaxStreak:     streak.MaxStreakCount,
+		PastStreaks:   make([]PastStreak, len(past)),
+	}
+	if streak.LastActivityDate.Valid {
+		date := streak.LastActivityDate.Time.Format(dateFormat)
+		out.LastActivityDate = &date
+	}
+	for i, e := range past {
+		out.PastStreaks[i] = PastStreak{Length: e.StreakCount, EndedOn: e.EventDate.Format(dateFormat)}
+	}
+	return out, nil
+}
+
+// userAchievements lists the badges a member earned in the guild
+func (s *Server) userAchievements(ctx context.Context, r guildRequest) (any, error) {
+	userID := r.PathValue("userID")
+	if err := s.requireMember(ctx, r.guildID, userID); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.GetUserAchievements(ctx, database.GetUserAchievementsParams{UserID: userID, GuildID: r.guildID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievements: %w", err)
+	}
+	achievements := make([]Achievement, len(rows))
+	for i, row := range rows {
+		achievements[i] = Achievement{
+			AchievementID: row.AchievementID,
+			Name:          row.Name,
+			Description:   row.Description,
+			Icon:          row.Icon,
+			Category:      row.Category,
+		}
+		if row.EarnedAt.Valid {
+			earned := row.EarnedAt.Time
+			achievements[i].EarnedAt = &earned
+		}
+	}
+	return map[string]any{"data": achievements}, nil
+}
+
+// userSessions lists a member's study sessions in the guild, newest first. Closed sessions are
+// deleted after a week, so this is recent history only.
+func (s *Server) userSessions(ctx context.Context, r guildRequest) (any, error) {
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		return nil, err
+	}
+	userID := r.PathValue("userID")
+	if err := s.requireMember(ctx, r.guildID, userID); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.GetUserGuildStudySessions(ctx, database.GetUserGuildStudySessionsParams{
+		UserID:     sql.NullString{String: userID, Valid: true},
+		GuildID:    sql.NullString{String: r.guildID, Valid: true},
+		PageSize:   int32(limit + 1),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get study sessions: %w", err)
+	}
+	sessions := make([]Session, len(rows))
+	for i, row := range rows {
+		sessions[i] = Session{SessionID: row.SessionID, StartTime: row.StartTime, Manual: row.IsManual}
+		if row.EndTime.Valid {
+			end := row.EndTime.Time
+			sessions[i].EndTime = &end
+		}
+		if row.DurationMs.Valid {
+			ms := row.DurationMs.Int64
+			sessions[i].DurationMs = &ms
+		}
+	}
+	return newPage(sessions, limit, offset), nil
+}
+
+// errNotMember is returned for users the bot has never tracked in the guild, so a token can't be
+// used to find out about members of other servers
+var errNotMember = errorf(http.StatusNotFound, "no study history for this user in this server")
+
+// requireMember returns errNotMember unless the user has a streak record in the guild, which the
+// bot creates the first time they join a tracked voice channel there
+func (s *Server) requireMember(ctx context.Context, guildID, userID string) error {
+	_, err := s.db.GetUserStreak(ctx, database.GetUserStreakParams{UserID: userID, GuildID: guildID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return errNotMember
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user streak: %w", err)
+	}
+	return nil
+}
+
+func nullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
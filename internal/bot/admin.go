@@ -0,0 +1,386 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/discord"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Bounds for the minutes option of /admin time
+var (
+	adjustMinutesMin float64 = 1
+	adjustMinutesMax float64 = service.MaxAdjustmentMinutes
+)
+
+// adjustTimeOptions are the options of /admin time add and remove
+var adjustTimeOptions = []*discordgo.ApplicationCommandOption{
+	{
+		Type:        discordgo.ApplicationCommandOptionUser,
+		Name:        "user",
+		Description: "The member whose study time to change",
+		Required:    true,
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Name:        "minutes",
+		Description: "How many minutes of study time",
+		Required:    true,
+		MinValue:    &adjustMinutesMin,
+		MaxValue:    adjustMinutesMax,
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "reason",
+		Description: "Why the time is being changed, kept in the audit log",
+		Required:    true,
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionBoolean,
+		Name:        "streak",
+		Description: "Also change today's streak minutes in this server (default false)",
+		Required:    false,
+	},
+}
+
+// auditActionChoices are the actions /admin audit can filter by
+var auditActionChoices = func() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, len(service.AuditActions))
+	for i, a := range service.AuditActions {
+		choices[i] = &discordgo.ApplicationCommandOptionChoice{Name: a, Value: a}
+	}
+	return choices
+}()
+
+// auditFilterOptions are the options of /admin audit
+var auditFilterOptions = []*discordgo.ApplicationCommandOption{
+	{
+		Type:        discordgo.ApplicationCommandOptionUser,
+		Name:        "user",
+		Description: "Only changes made by or to this member",
+		Required:    false,
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "action",
+		Description: "Only this kind of change",
+		Required:    false,
+		Choices:     auditActionChoices,
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "from",
+		Description: "Only changes on or after this day, e.g. 2026-10-01 (Manila time)",
+		Required:    false,
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "to",
+		Description: "Only changes on or before this day, e.g. 2026-10-31 (Manila time)",
+		Required:    false,
+	},
+}
+
+// handleSlashAdminCommand handles /admin. The registry has already checked the member may use the
+// subcommand: time needs Adjust stats, audit needs View audit log and api-token needs Administrator.
+func (b *Bot) handleSlashAdminCommand(s discord.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		respondEphemeral(s, i, "Choose time, audit or api-token.")
+		return
+	}
+
+	switch options[0].Name {
+	case "time":
+		b.handleAdminTime(s, i, options[0])
+	case "audit":
+		b.handleAdminAudit(s, i, options[0])
+	case "api-token":
+		b.handleAdminAPIToken(s, i, options[0])
+	default:
+		respondEphemeral(s, i, "Unknown subcommand.")
+	}
+}
+
+// handleAdminTime adds or removes study time by hand, e.g. for a session the bot missed
+func (b *Bot) handleAdminTime(s discord.Session, i *discordgo.InteractionCreate, group *discordgo.ApplicationCommandInteractionDataOption) {
+	if b.sessionService == nil {
+		b.logger.Error("SessionService not available for /admin time command")
+		respondEphemeral(s, i, "Session service is currently unavailable.")
+		return
+	}
+	if len(group.Options) == 0 {
+		respondEphemeral(s, i, "Choose add or remove.")
+		return
+	}
+	sub := group.Options[0]
+
+	req := service.TimeAdjustmentRequest{
+		GuildID: i.GuildID,
+		ActorID: interactionUserID(i),
+		Now:     b.clock.Now(),
+	}
+	for _, opt := range sub.Options {
+		switch opt.Name {
+		case "user":
+			req.UserID = opt.UserValue(nil).ID
+		case "minutes":
+			req.Minutes = int(opt.IntValue())
+		case "reason":
+			req.Reason = strings.TrimSpace(opt.StringValue())
+		case "streak":
+			req.Streak = opt.BoolValue()
+		}
+	}
+	if resolved := i.ApplicationCommandData().Resolved; resolved != nil {
+		if user, ok := resolved.Users[req.UserID]; ok {
+			req.Username = user.Username
+		}
+	}
+	if req.UserID == "" || req.Minutes <= 0 || req.Reason == "" {
+		respondEphemeral(s, i, "Choose a member, a number of minutes and a reason.")
+		return
+	}
+	if sub.Name == "remove" {
+		req.Minutes = -req.Minutes
+	}
+
+	result, err := b.sessionService.AdjustStudyTime(context.Background(), req)
+	if errors.Is(err, service.ErrNothingToRemove) {
+		respondEphemeral(s, i, fmt.Sprintf("<@%s> has no study time to remove.", req.UserID))
+		return
+	}
+	if err != nil {
+		b.logger.Error("Failed to adjust study time", "user_id", req.UserID, "guild_id", req.GuildID, "error", err)
+		respondEphemeral(s, i, "Something went wrong while changing the study time. Please try again later.")
+		return
+	}
+
+	applied := time.Duration(result.AppliedMs) * time.Millisecond
+	description := fmt.Sprintf("Added **%s** to <@%s>'s study time.", formatDuration(applied), req.UserID)
+	if applied < 0 {
+		description = fmt.Sprintf("Removed **%s** from <@%s>'s study time.", formatDuration(-applied), req.UserID)
+	}
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "Reason", Value: req.Reason},
+		{Name: "New Total", Value: formatDuration(time.Duration(result.Stats.TotalStudyMs.Int64) * time.Millisecond), Inline: true},
+	}
+	if req.Streak {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Today's Streak Activity", Value: fmt.Sprintf("%+d minutes", result.StreakMinutes), Inline: true})
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "🛠️ Study Time Adjusted",
+				Description: description,
+				Color:       0x00AAFF,
+				Fields:      fields,
+				Footer:      &discordgo.MessageEmbedFooter{Text: "Recorded in the audit log. See /admin audit."},
+			}},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.logger.Error("Failed to send /admin time response", "error", err)
+	}
+}
+
+// handleAdminAudit shows the server's most recent audit log entries matching the chosen filters
+func (b *Bot) handleAdminAudit(s discord.Session, i *discordgo.InteractionCreate, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	if b.auditService == nil {
+		b.logger.Error("AuditService not available for /admin audit command")
+		respondEphemeral(s, i, "Audit log is currently unavailable.")
+		return
+	}
+
+	var filter service.AuditFilter
+	var filters []string
+	for _, opt := range sub.Options {
+		switch opt.Name {
+		case "user":
+			filter.UserID = opt.UserValue(nil).ID
+			filters = append(filters, fmt.Sprintf("<@%s>", filter.UserID))
+		case "action":
+			filter.Action = opt.StringValue()
+			filters = append(filters, fmt.Sprintf("**%s**", filter.Action))
+		case "from", "to":
+			day, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(opt.StringValue()), service.GetManilaLocation())
+			if err != nil {
+				respondEphemeral(s, i, fmt.Sprintf("Couldn't read %q as a date. Use the format 2026-10-31.", opt.StringValue()))
+				return
+			}
+			if opt.Name == "from" {
+				filter.From = day
+				filters = append(filters, "from "+day.Format("Jan 2, 2006"))
+			} else {
+				filter.To = day.AddDate(0, 0, 1) // The whole day is included
+				filters = append(filters, "to "+day.Format("Jan 2, 2006"))
+			}
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		respondEphemeral(s, i, "The from date must be on or before the to date.")
+		return
+	}
+
+	entries, err := b.auditService.GetGuildAuditLog(context.Background(), i.GuildID, filter)
+	if err != nil {
+		b.logger.Error("Failed to load audit log", "guild_id", i.GuildID, "error", err)
+		respondEphemeral(s, i, "Something went wrong while loading the audit log. Please try again later.")
+		return
+	}
+
+	description := "Nothing has been recorded yet."
+	if len(filters) > 0 {
+		description = "No entries match these filters."
+	}
+	if len(entries) > 0 {
+		lines := make([]string, len(entries))
+		for n, e := range entries {
+			line := fmt.Sprintf("`%s` **%s**", e.CreatedAt.In(service.GetManilaLocation()).Format("Jan 2 15:04"), e.Action)
+			if e.ActorID.Valid {
+				line += fmt.Sprintf(" by <@%s>", e.ActorID.String)
+			}
+			if e.TargetUserID.Valid {
+				line += fmt.Sprintf(" for <@%s>", e.TargetUserID.String)
+			}
+			if e.Reason.Valid && e.Reason.String != "" {
+				line += fmt.Sprintf(": %s", e.Reason.String)
+			}
+			lines[n] = line
+		}
+		description = strings.Join(lines, "\n")
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "📜 Audit Log",
+				Description: description,
+				Color:       0x00AAFF,
+				Fields:      auditFilterFields(filters),
+				Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Latest %d entries, Manila time", service.AuditLogPageSize)},
+			}},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.logger.Error("Failed to send /admin audit response", "error", err)
+	}
+}
+
+// auditFilterFields shows the filters /admin audit applied, if any
+func auditFilterFields(filters []string) []*discordgo.MessageEmbedField {
+	if len(filters) == 0 {
+		return nil
+	}
+	return []*discordgo.MessageEmbedField{{Name: "Filters", Value: strings.Join(filters, ", ")}}
+}
+
+// handleAdminAPIToken creates, lists and revokes the server's REST API tokens
+func (b *Bot) handleAdminAPIToken(s discord.Session, i *discordgo.InteractionCreate, group *discordgo.ApplicationCommandInteractionDataOption) {
+	if b.apiTokenService == nil {
+		b.logger.Error("APITokenService not available for /admin api-token command")
+		respondEphemeral(s, i, "API tokens are currently unavailable.")
+		return
+	}
+	if len(group.Options) == 0 {
+		respondEphemeral(s, i, "Choose create, list or revoke.")
+		return
+	}
+	sub := group.Options[0]
+	ctx := context.Background()
+	actorID := interactionUserID(i)
+
+	switch sub.Name {
+	case "create":
+		var name string
+		for _, opt := range sub.Options {
+			if opt.Name == "name" {
+				name = opt.StringValue()
+			}
+		}
+		token, created, err := b.apiTokenService.CreateToken(ctx, i.GuildID, actorID, name)
+		if errors.Is(err, service.ErrInvalidAPITokenName) {
+			respondEphemeral(s, i, fmt.Sprintf("Give the token a name of 1 to %d characters.", service.MaxAPITokenNameLength))
+			return
+		}
+		if errors.Is(err, service.ErrTooManyAPITokens) {
+			respondEphemeral(s, i, fmt.Sprintf("This server already has %d API tokens. Revoke one with /admin api-token revoke first.", service.MaxAPITokensPerGuild))
+			return
+		}
+		if err != nil {
+			b.logger.Error("Failed to create API token", "guild_id", i.GuildID, "error", err)
+			respondEphemeral(s, i, "Something went wrong while creating the token. Please try again later.")
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("Created API token **%s** (ID %d). Copy it now, it won't be shown again:\n```\n%s\n```\nSend it as `Authorization: Bearer <token>` to `/api/v1/guilds/%s/...`.", created.Name, created.ID, token, i.GuildID))
+
+	case "list":
+		tokens, err := b.apiTokenService.ListTokens(ctx, i.GuildID)
+		if err != nil {
+			b.logger.Error("Failed to list API tokens", "guild_id", i.GuildID, "error", err)
+			respondEphemeral(s, i, "Something went wrong while loading the tokens. Please try again later.")
+			return
+		}
+		if len(tokens) == 0 {
+			respondEphemeral(s, i, "This server has no API tokens. Create one with /admin api-token create.")
+			return
+		}
+		loc := service.GetManilaLocation()
+		lines := make([]string, len(tokens))
+		for n, t := range tokens {
+			lastUsed := "never used"
+			if t.LastUsedAt.Valid {
+				lastUsed = "last used " + t.LastUsedAt.Time.In(loc).Format("Jan 2 15:04")
+			}
+			lines[n] = fmt.Sprintf("`%d` **%s**, created by <@%s> on %s, %s", t.ID, t.Name, t.CreatedBy, t.CreatedAt.In(loc).Format("Jan 2, 2006"), lastUsed)
+		}
+		respondEphemeral(s, i, "**API tokens**\n"+strings.Join(lines, "\n"))
+
+	case "revoke":
+		var tokenID int64
+		for _, opt := range sub.Options {
+			if opt.Name == "id" {
+				tokenID = opt.IntValue()
+			}
+		}
+		err := b.apiTokenService.RevokeToken(ctx, i.GuildID, actorID, tokenID)
+		if errors.Is(err, service.ErrAPITokenNotFound) {
+			respondEphemeral(s, i, fmt.Sprintf("This server has no API token with ID %d.", tokenID))
+			return
+		}
+		if err != nil {
+			b.logger.Error("Failed to revoke API token", "guild_id", i.GuildID, "token_id", tokenID, "error", err)
+			respondEphemeral(s, i, "Something went wrong while revoking the token. Please try again later.")
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("Revoked API token %d. Requests using it will now be refused.", tokenID))
+
+	default:
+		respondEphemeral(s, i, "Unknown subcommand.")
+	}
+}
+
+// recordAudit writes an audit log entry for a change a command made outside a service transaction.
+// The change has already happened, so a failure is only logged.
+func (b *Bot) recordAudit(ctx context.Context, entry service.AuditEntry) {
+	if b.auditService == nil {
+		return
+	}
+	if err := b.auditService.Record(ctx, entry); err != nil {
+		b.logger.ErrorContext(ctx, "Failed to record audit log entry", "action", entry.Action, "guild_id", entry.GuildID, "error", err)
+	}
+}
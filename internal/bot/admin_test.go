@@ -0,0 +1,346 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/commands"
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// adminTimeInteraction runs /admin time add or remove for flowUserID as a moderator
+func adminTimeInteraction(sub string, minutes int, reason string, streak bool) *discordgo.InteractionCreate {
+	i := moderatorInteraction("admin")
+	i.Member.User.ID = "moderator-1"
+	i.Data = discordgo.ApplicationCommandInteractionData{
+		Name: "admin",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+			Name: "time",
+			Type: discordgo.ApplicationCommandOptionSubCommandGroup,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+				Name: sub,
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{Name: "user", Type: discordgo.ApplicationCommandOptionUser, Value: flowUserID},
+					intOption("minutes", minutes),
+					stringOption("reason", reason),
+					boolOption("streak", streak),
+				},
+			}},
+		}},
+		Resolved: &discordgo.ApplicationCommandInteractionDataResolved{
+			Users: map[string]*discordgo.User{flowUserID: {ID: flowUserID, Username: "alice"}},
+		},
+	}
+	return i
+}
+
+func grantModerator(t *testing.T, db database.Querier, capability commands.Permission) {
+	t.Helper()
+	require.NoError(t, db.GrantRoleCapability(context.Background(), database.GrantRoleCapabilityParams{
+		GuildID:    flowGuildID,
+		RoleID:     moderatorRoleID,
+		Capability: string(capability),
+	}))
+}
+
+func TestAdminTime_AddCreditsStatsStreakAndAuditLog(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 14, 15, 0, 0, 0, service.GetManilaLocation())
+	b.clock = clock.NewFake(now)
+	db.Now = b.clock.Now
+	grantModerator(t, db, commands.PermissionAdjustStats)
+
+	b.handleInteractionCreate(session, adminTimeInteraction("add", 45, "Bot was down during our study hall", true))
+
+	resp := session.LastResponse()
+	require.Len(t, resp.Data.Embeds, 1)
+	assert.Contains(t, resp.Data.Embeds[0].Description, "Added **45m 0s**")
+	assert.Equal(t, "+45 minutes", fieldValue(resp.Data.Embeds[0], "Today's Streak Activity"))
+
+	stats, err := db.GetUserStats(ctx, flowUserID)
+	require.NoError(t, err)
+	assert.Equal(t, (45 * time.Minute).Milliseconds(), stats.TotalStudyMs.Int64)
+	assert.Equal(t, (45 * time.Minute).Milliseconds(), stats.DailyStudyMs.Int64)
+
+	sessions := db.StudySessions()
+	require.Len(t, sessions, 1)
+	assert.True(t, sessions[0].IsManual)
+	assert.True(t, sessions[0].EndTime.Valid, "a manual session is never open")
+
+	streak, err := db.GetUserStreak(ctx, database.GetUserStreakParams{UserID: flowUserID, GuildID: flowGuildID})
+	require.NoError(t, err)
+	assert.Equal(t, int32(45), streak.DailyActivityMinutes.Int32)
+
+	entries := db.AuditLog()
+	require.Len(t, entries, 1)
+	assert.Equal(t, service.AuditActionAdjustTime, entries[0].Action)
+	assert.Equal(t, "moderator-1", entries[0].ActorID.String)
+	assert.Equal(t, flowUserID, entries[0].TargetUserID.String)
+	assert.Equal(t, "Bot was down during our study hall", entries[0].Reason.String)
+
+	// Manual time doesn't count toward badges based on when sessions happen
+	hours, err := db.GetUniqueStudyHours(ctx, sql.NullString{String: flowUserID, Valid: true})
+	require.NoError(t, err)
+	assert.Zero(t, hours)
+}
+
+func TestAdminTime_WithoutStreakStillRecordsDailyActivity(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 14, 15, 0, 0, 0, service.GetManilaLocation())
+	b.clock = clock.NewFake(now)
+	grantModerator(t, db, commands.PermissionAdjustStats)
+
+	b.handleInteractionCreate(session, adminTimeInteraction("add", 30, "Studied in a call the bot can't see", false))
+
+	// Recaps and the dashboard read daily activity, so it follows the adjustment either way
+	today := service.StartOfDay(now, service.GetManilaLocation())
+	activity, err := db.GetDailyActivity(ctx, database.GetDailyActivityParams{UserID: flowUserID, GuildID: flowGuildID, FromDate: today, ToDate: today})
+	require.NoError(t, err)
+	require.Len(t, activity, 1)
+	assert.Equal(t, (30 * time.Minute).Milliseconds(), activity[0].StudyMs)
+
+	// Only the streak minutes are left alone
+	_, err = db.GetUserStreak(ctx, database.GetUserStreakParams{UserID: flowUserID, GuildID: flowGuildID})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	b.handleInteractionCreate(session, adminTimeInteraction("remove", 10, "Took a long break", false))
+	activity, err = db.GetDailyActivity(ctx, database.GetDailyActivityParams{UserID: flowUserID, GuildID: flowGuildID, FromDate: today, ToDate: today})
+	require.NoError(t, err)
+	require.Len(t, activity, 1)
+	assert.Equal(t, (20 * time.Minute).Milliseconds(), activity[0].StudyMs)
+}
+
+func TestAdminTime_RemoveStopsAtZero(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 14, 15, 0, 0, 0, service.GetManilaLocation())
+	b.clock = clock.NewFake(now)
+	grantModerator(t, db, commands.PermissionAdjustStats)
+
+	b.handleInteractionCreate(session, adminTimeInteraction("add", 20, "Missed session", true))
+	b.handleInteractionCreate(session, adminTimeInteraction("remove", 60, "Credited the wrong member", true))
+
+	resp := session.LastResponse()
+	require.Len(t, resp.Data.Embeds, 1)
+	assert.Contains(t, resp.Data.Embeds[0].Description, "Removed **20m 0s**")
+	assert.Equal(t, "-20 minutes", fieldValue(resp.Data.Embeds[0], "Today's Streak Activity"))
+
+	stats, err := db.GetUserStats(ctx, flowUserID)
+	require.NoError(t, err)
+	assert.Zero(t, stats.TotalStudyMs.Int64)
+	assert.Zero(t, stats.DailyStudyMs.Int64)
+	activity, err := db.GetDailyActivity(ctx, database.GetDailyActivityParams{
+		UserID:   flowUserID,
+		GuildID:  flowGuildID,
+		FromDate: service.StartOfDay(now, service.GetManilaLocation()),
+		ToDate:   service.StartOfDay(now, service.GetManilaLocation()),
+	})
+	require.NoError(t, err)
+	require.Len(t, activity, 1)
+	assert.Zero(t, activity[0].StudyMs)
+
+	b.handleInteractionCreate(session, adminTimeInteraction("remove", 5, "Again", false))
+	assert.Contains(t, session.LastResponse().Data.Content, "has no study time to remove")
+	assert.Len(t, db.AuditLog(), 2)
+}
+
+func TestAdminTime_RemovingTheDaysMinutesUndoesItsCompletion(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 14, 15, 0, 0, 0, service.GetManilaLocation())
+	b.clock = clock.NewFake(now)
+	db.Now = b.clock.Now
+	grantModerator(t, db, commands.PermissionAdjustStats)
+	completeKey := "streak_daily_complete:guild-1:user-1:2026-10-14"
+	hasNotice := func() bool {
+		for _, n := range db.Notifications() {
+			if n.DedupeKey == completeKey {
+				return true
+			}
+		}
+		return false
+	}
+
+	b.handleInteractionCreate(session, adminTimeInteraction("add", 20, "Missed session", true))
+	require.True(t, hasNotice(), "reaching the minimum queues the completion notice")
+	require.NoError(t, db.UpdateStreakImmediately(ctx, database.UpdateStreakImmediatelyParams{UserID: flowUserID, GuildID: flowGuildID, CurrentStreakCount: 1, MaxStreakCount: 1}))
+
+	b.handleInteractionCreate(session, adminTimeInteraction("remove", 20, "Credited the wrong member", true))
+	streak, err := db.GetUserStreak(ctx, database.GetUserStreakParams{UserID: flowUserID, GuildID: flowGuildID})
+	require.NoError(t, err)
+	assert.Zero(t, streak.DailyActivityMinutes.Int32)
+	assert.False(t, streak.StreakIncrementedToday)
+	assert.False(t, hasNotice())
+
+	// Studying again completes the day again
+	b.handleInteractionCreate(session, adminTimeInteraction("add", 15, "Studied in a call the bot can't see", true))
+	assert.True(t, hasNotice())
+}
+
+func TestAdminAudit_NeedsViewAuditLog(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	b.clock = clock.NewFake(time.Date(2026, 10, 14, 15, 0, 0, 0, service.GetManilaLocation()))
+	grantModerator(t, db, commands.PermissionAdjustStats)
+	b.handleInteractionCreate(session, adminTimeInteraction("add", 30, "Outage on Tuesday", false))
+
+	audit := moderatorInteraction("admin")
+	audit.Data = discordgo.ApplicationCommandInteractionData{
+		Name:    "admin",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "audit", Type: discordgo.ApplicationCommandOptionSubCommand}},
+	}
+	b.handleInteractionCreate(session, audit)
+	assert.Contains(t, session.LastResponse().Data.Content, "don't have permission")
+
+	grantModerator(t, db, commands.PermissionViewAuditLog)
+	b.handleInteractionCreate(session, audit)
+
+	resp := session.LastResponse()
+	require.Len(t, resp.Data.Embeds, 1)
+	assert.Contains(t, resp.Data.Embeds[0].Description, "**adjust_time** by <@moderator-1> for <@user-1>: Outage on Tuesday")
+}
+
+// adminAuditInteraction runs /admin audit as a moderator with the given filters
+func adminAuditInteraction(filters ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	i := moderatorInteraction("admin")
+	i.Data = discordgo.ApplicationCommandInteractionData{
+		Name: "admin",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+			Name:    "audit",
+			Type:    discordgo.ApplicationCommandOptionSubCommand,
+			Options: filters,
+		}},
+	}
+	return i
+}
+
+func TestAdminAudit_RecordsBotActionsAndFilters(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	manila := service.GetManilaLocation()
+	clk := clock.NewFake(time.Date(2026, 10, 15, 23, 59, 0, 0, manila))
+	b.clock = clk
+	db.Now = clk.Now
+	grantModerator(t, db, commands.PermissionViewAuditLog)
+
+	// Oct 15: the bot resets a missed streak by itself
+	streaks := service.NewStreakService(db, db, nil, b.cfg)
+	b.SetStreakService(streaks)
+	seedStreak(t, db, flowUserID, 5, time.Date(2026, 10, 14, 0, 0, 0, 0, manila))
+	streaks.EvaluateStreaksForDate(ctx, time.Date(2026, 10, 15, 0, 0, 0, 0, manila))
+
+	// Oct 16: an admin changes the server's configuration
+	clk.Set(time.Date(2026, 10, 16, 10, 0, 0, 0, manila))
+	b.handleInteractionCreate(session, permissionsInteraction("grant",
+		stringOption("capability", string(commands.PermissionAdjustStats)),
+		roleOption("role", moderatorRoleID),
+	))
+
+	entries := db.AuditLog()
+	require.Len(t, entries, 2)
+	assert.Equal(t, service.AuditActionStreakReset, entries[0].Action)
+	assert.False(t, entries[0].ActorID.Valid, "the bot made the change itself")
+	assert.Equal(t, flowUserID, entries[0].TargetUserID.String)
+	assert.JSONEq(t, `{"previousStreak":5,"date":"2026-10-15"}`, string(entries[0].Details))
+	assert.Equal(t, service.AuditActionGrantCapability, entries[1].Action)
+	assert.Equal(t, "admin-1", entries[1].ActorID.String)
+
+	description := func() string {
+		t.Helper()
+		resp := session.LastResponse()
+		require.Len(t, resp.Data.Embeds, 1)
+		return resp.Data.Embeds[0].Description
+	}
+
+	b.handleInteractionCreate(session, adminAuditInteraction(stringOption("action", service.AuditActionStreakReset)))
+	assert.Contains(t, description(), "**streak_reset** for <@user-1>: Not enough study time")
+	assert.NotContains(t, description(), "grant_capability")
+	assert.Equal(t, "**streak_reset**", fieldValue(session.LastResponse().Data.Embeds[0], "Filters"))
+
+	b.handleInteractionCreate(session, adminAuditInteraction(
+		&discordgo.ApplicationCommandInteractionDataOption{Name: "user", Type: discordgo.ApplicationCommandOptionUser, Value: "admin-1"},
+	))
+	assert.Contains(t, description(), "**grant_capability** by <@admin-1>")
+	assert.NotContains(t, description(), "streak_reset")
+
+	b.handleInteractionCreate(session, adminAuditInteraction(stringOption("from", "2026-10-15"), stringOption("to", "2026-10-15")))
+	assert.Contains(t, description(), "streak_reset")
+	assert.NotContains(t, description(), "grant_capability")
+
+	b.handleInteractionCreate(session, adminAuditInteraction(stringOption("from", "2026-10-17")))
+	assert.Equal(t, "No entries match these filters.", description())
+
+	b.handleInteractionCreate(session, adminAuditInteraction(stringOption("from", "next week")))
+	assert.Contains(t, session.LastResponse().Data.Content, "Couldn't read \"next week\" as a date")
+}
+
+// apiTokenInteraction runs /admin api-token as an administrator
+func apiTokenInteraction(sub string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	i := createTestInteraction("admin-1", "admin", flowGuildID)
+	i.Member.Permissions = discordgo.PermissionAdministrator
+	i.Data = discordgo.ApplicationCommandInteractionData{
+		Name: "admin",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+			Name: "api-token",
+			Type: discordgo.ApplicationCommandOptionSubCommandGroup,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+				Name:    sub,
+				Type:    discordgo.ApplicationCommandOptionSubCommand,
+				Options: options,
+			}},
+		}},
+	}
+	return i
+}
+
+func TestAdminAPIToken_CreateListRevoke(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	tokens := service.NewAPITokenService(db, db)
+	b.SetAPITokenService(tokens)
+
+	// Moderators with other capabilities can't create tokens
+	grantModerator(t, db, commands.PermissionViewAuditLog)
+	denied := moderatorInteraction("admin")
+	denied.Data = apiTokenInteraction("list").Data
+	b.handleInteractionCreate(session, denied)
+	assert.Contains(t, session.LastResponse().Data.Content, "don't have permission")
+
+	b.handleInteractionCreate(session, apiTokenInteraction("create", stringOption("name", "Study dashboard")))
+	resp := session.LastResponse()
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, resp.Data.Flags)
+	token := regexp.MustCompile(service.APITokenPrefix + `[A-Za-z0-9_-]+`).FindString(resp.Data.Content)
+	require.NotEmpty(t, token, "the token is shown once")
+	found, err := tokens.Authenticate(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, flowGuildID, found.GuildID)
+
+	b.handleInteractionCreate(session, apiTokenInteraction("list"))
+	content := session.LastResponse().Data.Content
+	assert.Contains(t, content, "**Study dashboard**")
+	assert.NotContains(t, content, token, "listing never shows the token again")
+
+	b.handleInteractionCreate(session, apiTokenInteraction("revoke", intOption("id", 99)))
+	assert.Contains(t, session.LastResponse().Data.Content, "no API token with ID 99")
+
+	b.handleInteractionCreate(session, apiTokenInteraction("revoke", intOption("id", int(found.ID))))
+	assert.Contains(t, session.LastResponse().Data.Content, "Revoked API token")
+	_, err = tokens.Authenticate(ctx, token)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIToken)
+
+	entries := db.AuditLog()
+	require.Len(t, entries, 2)
+	assert.Equal(t, service.AuditActionCreateAPIToken, entries[0].Action)
+	assert.Equal(t, service.AuditActionRevokeAPIToken, entries[1].Action)
+	assert.Equal(t, "admin-1", entries[1].ActorID.String)
+}
@@ -3,42 +3,77 @@ package bot
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/commands"
 	"github.com/Skufu/LockIn-Bot/internal/config"
+	"github.com/Skufu/LockIn-Bot/internal/dashboard"
 	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/discord"
+	"github.com/Skufu/LockIn-Bot/internal/logging"
+	"github.com/Skufu/LockIn-Bot/internal/metrics"
 	"github.com/Skufu/LockIn-Bot/internal/service"
 	"github.com/bwmarrin/discordgo"
 )
 
 // Bot represents the Discord bot
 type Bot struct {
-	session                *discordgo.Session
-	db                     *database.Queries
-	activeSessions         map[string]time.Time // Maps user_id to session start time
+	session                discord.Session
+	state                  *discordgo.State // Gateway state cache, used to look up voice states
+	db                     database.Querier
+	clock                  clock.Clock              // Source of session start/end times, faked when replaying recordings
+	logger                 *slog.Logger             // Structured logger; voice updates tag their lines with an event ID
+	activeSessions         map[string]activeSession // Maps user_id to the study session they're in
 	activeSessionMu        sync.Mutex
-	LoggingChannelID       string                      // Added to store the logging channel ID
-	testGuildID            string                      // Added to store the test guild ID for command registration
-	allowedVoiceChannelIDs map[string]struct{}         // For storing allowed voice channel IDs
-	cfg                    *config.Config              // Store the full config
-	streakService          *service.StreakService      // Added streak service
-	achievementService     *service.AchievementService // Added achievement service
+	LoggingChannelID       string                       // Added to store the logging channel ID
+	testGuildID            string                       // Added to store the test guild ID for command registration
+	allowedVoiceChannelIDs map[string]struct{}          // For storing allowed voice channel IDs
+	cfg                    *config.Config               // Store the full config
+	streakService          *service.StreakService       // Added streak service
+	achievementService     *service.AchievementService  // Added achievement service
+	accountService         *service.AccountService      // Handles account data deletion
+	sessionService         *service.SessionService      // Ends sessions and credits stats transactionally
+	notificationService    *service.NotificationService // Durable outbox for announcements
+	recapService           *service.RecapService        // Weekly and monthly recaps
+	auditService           *service.AuditService        // Reads the audit log for /admin audit
+	apiTokenService        *service.APITokenService     // REST API tokens for /admin api-token
+	dashboardLinks         *dashboard.Links             // Signs the web dashboard links /dashboard sends
+	registry               *commands.Registry           // Slash commands and their text aliases
+	permissions            *commands.Permissions        // Administrator and role capability checks
 
 	// Worker pool for handling voice events to prevent goroutine explosion
-	voiceEventChan chan func()
-	shutdownChan   chan struct{}
+	voiceEventChan  chan func()
+	voiceQueueDepth atomic.Int64 // Voice tasks queued or running, for /readyz and /livez
+	shutdownChan    chan struct{}
 
 	// Deduplication for voice events
 	lastVoiceEvent map[string]time.Time // Maps "userID:channelID:action" to last event time
 	voiceEventMu   sync.Mutex
+
+	// Signals the live status loop to refresh pinned "studying now" messages
+	liveStatusRefresh chan struct{}
+
+	// Optional log of every voice state update, for replaying incidents in tests
+	voiceRecorder   *VoiceRecorder
+	voiceRecorderMu sync.Mutex
+}
+
+// activeSession is a study session tracked in memory while the user is in a study voice channel
+type activeSession struct {
+	StartTime time.Time
+	GuildID   string
+	ChannelID string // Updated when the user moves between tracked channels
 }
 
 // New creates a new Discord bot instance
-func New(token string, db *database.Queries, appConfig *config.Config, allowedVCs map[string]struct{}) (*Bot, error) {
+func New(token string, db database.Querier, appConfig *config.Config, allowedVCs map[string]struct{}) (*Bot, error) {
 	// Validate the bot token format before creating a session
 	if err := validateToken(token); err != nil {
 		return nil, fmt.Errorf("token validation failed: %w", err)
@@ -50,6 +85,27 @@ func New(token string, db *database.Queries, appConfig *config.Config, allowedVC
 		return nil, err
 	}
 
+	countDiscordErrors(dg)
+	bot := newBot(dg, dg.State, db, appConfig)
+	bot.registerHandlers(dg)
+
+	// We only care about voice and guild messages
+	dg.Identify.Intents = discordgo.IntentsGuildVoiceStates | discordgo.IntentsGuildMessages | discordgo.IntentsMessageContent
+
+	// Open the websocket and begin listening
+	err = dg.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	bot.start()
+
+	return bot, nil
+}
+
+// newBot builds a Bot around a Discord client without connecting or starting any background work.
+// Tests use it with the in-memory fakes; state may be nil if voice state lookups aren't needed.
+func newBot(session discord.Session, state *discordgo.State, db database.Querier, appConfig *config.Config) *Bot {
 	// Make a copy of the allowed VCs map from config
 	currentAllowedVCs := make(map[string]struct{})
 	if appConfig.AllowedVoiceChannelIDsMap != nil {
@@ -59,46 +115,60 @@ func New(token string, db *database.Queries, appConfig *config.Config, allowedVC
 	}
 
 	bot := &Bot{
-		session:                dg,
+		session:                session,
+		state:                  state,
 		db:                     db,
-		activeSessions:         make(map[string]time.Time),
+		clock:                  clock.Real{},
+		logger:                 slog.Default(),
+		activeSessions:         make(map[string]activeSession),
 		LoggingChannelID:       appConfig.LoggingChannelID,
 		testGuildID:            appConfig.TestGuildID,
 		allowedVoiceChannelIDs: currentAllowedVCs,
 		cfg:                    appConfig,
-		streakService:          nil,
+		streakService:          nil, // Will be set later by the main application
+		achievementService:     nil, // Will be set later by the main application
 		voiceEventChan:         make(chan func()),
 		shutdownChan:           make(chan struct{}),
+		liveStatusRefresh:      make(chan struct{}, 1),
 		lastVoiceEvent:         make(map[string]time.Time),
 		voiceEventMu:           sync.Mutex{},
+		permissions:            commands.NewPermissions(db),
 	}
 
-	// Register handlers
-	dg.AddHandler(bot.handleReady)
-	dg.AddHandler(bot.handleVoiceStateUpdate)
-	dg.AddHandler(bot.handleInteractionCreate)
+	bot.registry = bot.newCommandRegistry(appConfig.CommandPrefix)
+	return bot
+}
 
-	// We only care about voice and guild messages
-	dg.Identify.Intents = discordgo.IntentsGuildVoiceStates | discordgo.IntentsGuildMessages | discordgo.IntentsMessageContent
+// registerHandlers subscribes the bot to gateway events. The handlers themselves only
+// depend on discord.Session, so the adapters here are the only place tied to discordgo.
+func (b *Bot) registerHandlers(dg *discordgo.Session) {
+	dg.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) { b.handleReady(s, r) })
+	dg.AddHandler(func(s *discordgo.Session, v *discordgo.VoiceStateUpdate) {
+		b.recordVoiceEvent(v)
+		b.handleVoiceStateUpdate(s, v)
+	})
+	dg.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) { b.handleInteractionCreate(s, i) })
+	dg.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) { b.registry.HandleMessage(s, m) })
+}
 
-	// Open the websocket and begin listening
-	err = dg.Open()
-	if err != nil {
-		return nil, err
-	}
+// start launches the bot's background workers
+func (b *Bot) start() {
+	b.reportActiveSessions()
 
 	// Start worker pool for voice events (prevents goroutine explosion)
-	go bot.voiceEventWorker()
+	go b.voiceEventWorker()
 
 	// Start session timeout checker to prevent phantom sessions
-	bot.StartSessionTimeoutChecker()
+	b.StartSessionTimeoutChecker()
 
-	return bot, nil
+	// Keep pinned "studying now" messages up to date
+	go b.liveStatusLoop()
 }
 
-// Session returns the underlying discordgo session
+// Session returns the underlying discordgo session, or nil if the bot runs on another client
 func (b *Bot) Session() *discordgo.Session {
-	return b.session
+	dg, _ := b.session.(*discordgo.Session)
+	return dg
 }
 
 // Close closes the Discord session
@@ -109,193 +179,135 @@ func (b *Bot) Close() {
 	// End all active sessions before shutting down
 	b.endAllActiveSessions()
 	b.session.Close()
+
+	b.SetVoiceRecorder(nil)
+}
+
+// SetVoiceRecorder starts recording voice state updates, closing any previous recorder. Pass nil to stop.
+func (b *Bot) SetVoiceRecorder(recorder *VoiceRecorder) {
+	b.voiceRecorderMu.Lock()
+	defer b.voiceRecorderMu.Unlock()
+
+	if b.voiceRecorder != nil {
+		if err := b.voiceRecorder.Close(); err != nil {
+			b.logger.Error("Failed to close voice event log", "error", err)
+		}
+	}
+	b.voiceRecorder = recorder
+}
+
+// recordVoiceEvent appends a voice state update to the voice event log, if recording is enabled
+func (b *Bot) recordVoiceEvent(v *discordgo.VoiceStateUpdate) {
+	b.voiceRecorderMu.Lock()
+	defer b.voiceRecorderMu.Unlock()
+
+	if b.voiceRecorder == nil {
+		return
+	}
+	if err := b.voiceRecorder.Record(v, b.clock.Now()); err != nil {
+		b.logger.Error("Failed to record voice event", "user_id", v.UserID, "guild_id", v.GuildID, "error", err)
+	}
 }
 
 // endAllActiveSessions ends all active study sessions when the bot shuts down
 func (b *Bot) endAllActiveSessions() {
 	ctx := context.Background() // Create a context for database operations
-	now := time.Now()
+	now := b.clock.Now()
 
 	b.activeSessionMu.Lock()
 	if len(b.activeSessions) == 0 {
 		b.activeSessionMu.Unlock()
-		log.Println("No active study sessions to end on shutdown.")
+		b.logger.Info("No active study sessions to end on shutdown")
 		return
 	}
 
-	activeSessions := make(map[string]time.Time, len(b.activeSessions))
-	for userID, startTime := range b.activeSessions {
-		activeSessions[userID] = startTime
+	activeSessions := make(map[string]activeSession, len(b.activeSessions))
+	for userID, active := range b.activeSessions {
+		activeSessions[userID] = active
 	}
 	b.activeSessionMu.Unlock()
 
-	log.Printf("Attempting to end %d active study session(s) on shutdown...", len(activeSessions))
-
-	for userID, startTime := range activeSessions {
-		log.Printf("Processing shutdown for user %s (session started at %v)", userID, startTime)
+	b.logger.Info("Ending active study sessions on shutdown", "count", len(activeSessions))
 
-		duration := now.Sub(startTime)
+	for userID, active := range activeSessions {
+		logger := b.logger.With("user_id", userID, "guild_id", active.GuildID)
+		logger.Debug("Ending study session on shutdown", "start_time", active.StartTime)
 
-		// Enhanced: Find ALL active sessions for this user (not just one)
-		// This handles the case where multiple sessions were created due to race conditions
-		activeDBSessions := []database.StudySession{}
-
-		// Get the primary active session
-		activeDBSession, err := b.db.GetActiveStudySession(ctx, sql.NullString{String: userID, Valid: true})
-		if err == nil {
-			activeDBSessions = append(activeDBSessions, activeDBSession)
-		} else if err != sql.ErrNoRows {
-			log.Printf("Error getting active DB session for user %s during shutdown: %v. Session may not be ended correctly.", userID, err)
-			b.activeSessionMu.Lock()
-			delete(b.activeSessions, userID)
-			b.activeSessionMu.Unlock()
-			continue // Skip to the next user
-		}
+		b.activeSessionMu.Lock()
+		delete(b.activeSessions, userID) // Remove from in-memory map before processing
+		b.activeSessionMu.Unlock()
 
-		// If no active session found, check if there are any unended sessions in general
-		// This is a safety measure for orphaned sessions
-		if len(activeDBSessions) == 0 {
-			log.Printf("No active DB session found for user %s, but they are in memory. This indicates a potential data inconsistency.", userID)
-			b.activeSessionMu.Lock()
-			delete(b.activeSessions, userID)
-			b.activeSessionMu.Unlock()
+		if b.sessionService == nil {
+			logger.Error("SessionService not available, cannot end study session on shutdown")
 			continue
 		}
 
-		// End all active sessions found for this user
-		var lastEndedSession database.StudySession
-		for _, session := range activeDBSessions {
-			endedSession, err := b.db.EndStudySession(ctx, database.EndStudySessionParams{
-				SessionID: session.SessionID,
-				EndTime:   sql.NullTime{Time: now, Valid: true},
-			})
-			if err != nil {
-				log.Printf("Error ending DB study session %d for user %s during shutdown: %v. Stats may not be updated.", session.SessionID, userID, err)
-				continue
+		result, err := b.sessionService.EndSession(ctx, service.EndSessionRequest{
+			UserID:      userID,
+			GuildID:     active.GuildID,
+			EndTime:     now,
+			AuditAction: service.AuditActionSessionShutdown,
+			Reason:      "Bot shut down",
+		})
+		if err != nil {
+			if errors.Is(err, service.ErrNoActiveSession) {
+				logger.Warn("No active DB session found for a session tracked in memory; the data may be inconsistent")
+			} else {
+				logger.Error("Failed to end study session on shutdown", "error", err)
 			}
-
-			log.Printf("Successfully ended DB session %d for user %s on shutdown. Duration: %d ms.", endedSession.SessionID, userID, endedSession.DurationMs.Int64)
-			lastEndedSession = endedSession
+			continue
 		}
 
-		// Update user stats based on the last ended session
-		if lastEndedSession.SessionID != 0 && lastEndedSession.DurationMs.Valid && lastEndedSession.DurationMs.Int64 > 0 {
-			_, err = b.db.CreateOrUpdateUserStats(ctx, database.CreateOrUpdateUserStatsParams{
-				UserID:       userID,
-				TotalStudyMs: sql.NullInt64{Int64: lastEndedSession.DurationMs.Int64, Valid: true},
-			})
-			if err != nil {
-				log.Printf("Error updating user stats for user %s during shutdown after session %d: %v", userID, lastEndedSession.SessionID, err)
-			}
-		}
+		logger.Info("Ended study session on shutdown", "session_id", result.Session.SessionID, "duration_ms", result.Session.DurationMs.Int64)
 
-		// If LoggingChannelID is set, also send a message about the shutdown-ended session
-		if b.LoggingChannelID != "" && lastEndedSession.SessionID != 0 {
+		// If LoggingChannelID is set, also queue a message about the shutdown-ended session.
+		// It is delivered on the next start if the dispatcher has already stopped.
+		if b.LoggingChannelID != "" {
 			username := userID                             // Default to UserID
 			discordUser, userErr := b.session.User(userID) // Attempt to get full user info
 			if userErr == nil && discordUser != nil {
 				username = discordUser.Username
 			}
 
-			// Use the duration from the ended DB session for consistency if available, otherwise fallback to in-memory calculation
-			finalDuration := duration // Fallback
-			if lastEndedSession.DurationMs.Valid {
-				finalDuration = time.Duration(lastEndedSession.DurationMs.Int64) * time.Millisecond
-			}
-
-			message := fmt.Sprintf("<@%s> (%s) session ended due to bot shutdown after %s.", userID, username, formatDuration(finalDuration))
-			_, sendErr := b.session.ChannelMessageSend(b.LoggingChannelID, message)
-			if sendErr != nil {
-				log.Printf("Error sending shutdown session message to Discord channel %s for user %s: %v", b.LoggingChannelID, userID, sendErr)
-			}
+			message := fmt.Sprintf("<@%s> (%s) session ended due to bot shutdown after %s.", userID, username, formatDuration(result.Duration()))
+			b.announceSessionEnd(ctx, result, userID, message)
 		}
-		b.activeSessionMu.Lock()
-		delete(b.activeSessions, userID) // Remove from in-memory map after processing
-		b.activeSessionMu.Unlock()
 	}
-	log.Println("Finished processing active sessions on shutdown.")
+	b.logger.Info("Finished ending active study sessions on shutdown")
 }
 
-func (b *Bot) handleReady(s *discordgo.Session, r *discordgo.Ready) {
-	log.Printf("Logged in as: %v#%v", s.State.User.Username, s.State.User.Discriminator)
+func (b *Bot) handleReady(s discord.Session, r *discordgo.Ready) {
+	b.logger.Info("Logged in", "username", r.User.Username+"#"+r.User.Discriminator)
 
 	guildID := b.testGuildID // Use the configured testGuildID
 
 	if guildID == "" {
-		log.Println("Registering GLOBAL slash commands...")
+		b.logger.Info("Registering global slash commands")
 	} else {
-		log.Printf("Registering slash commands for TEST guild ID: %s", guildID)
-	}
-
-	commands := []*discordgo.ApplicationCommand{
-		{
-			Name:        "stats",
-			Description: "Shows your study/voice channel time statistics.",
-		},
-		{
-			Name:        "leaderboard",
-			Description: "Shows the study time leaderboard.",
-		},
-		{
-			Name:        "help",
-			Description: "Shows available commands and information about the bot.",
-		},
-		{
-			Name:        "streak",
-			Description: "Check your current study streak!",
-		},
-		{
-			Name:        "profile",
-			Description: "View your study profile and badges.",
-			Options: []*discordgo.ApplicationCommandOption{
-				{
-					Type:        discordgo.ApplicationCommandOptionUser,
-					Name:        "user",
-					Description: "The user whose profile you want to view (optional)",
-					Required:    false,
-				},
-			},
-		},
-		{
-			Name:        "badges",
-			Description: "View all available badges and your progress.",
-		},
+		b.logger.Info("Registering slash commands for the test guild", "guild_id", guildID)
 	}
 
 	// Iterate and register commands
 	// Note: For global commands, it can take up to an hour for them to propagate.
 	// For guild-specific commands (faster registration for testing), you use:
-	// s.ApplicationCommandCreate(s.State.User.ID, "YOUR_GUILD_ID", cmd)
+	// s.ApplicationCommandCreate(r.User.ID, "YOUR_GUILD_ID", cmd)
+	commands := b.registry.Definitions()
 	registeredCommands := make([]*discordgo.ApplicationCommand, len(commands))
 	for i, cmd := range commands {
-		regCmd, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd) // Using configured guildID
+		regCmd, err := s.ApplicationCommandCreate(r.User.ID, guildID, cmd) // Using configured guildID
 		if err != nil {
-			log.Printf("Cannot create '%v' command in guild %s: %v", cmd.Name, guildID, err)
+			b.logger.Error("Failed to register command", "command", cmd.Name, "guild_id", guildID, "error", err)
 		} else {
 			registeredCommands[i] = regCmd
-			log.Printf("Registered command: %s", regCmd.Name)
+			b.logger.Debug("Registered command", "command", regCmd.Name)
 		}
 	}
 }
 
-func (b *Bot) handleInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleInteractionCreate(s discord.Session, i *discordgo.InteractionCreate) {
 	if i.Type == discordgo.InteractionApplicationCommand {
-		commandName := i.ApplicationCommandData().Name
-		switch commandName {
-		case "stats":
-			b.handleSlashStatsCommand(s, i)
-		case "leaderboard":
-			b.handleSlashLeaderboardCommand(s, i)
-		case "help":
-			b.handleSlashHelpCommand(s, i)
-		case "streak":
-			b.handleSlashStreakCommand(s, i)
-		case "profile":
-			b.handleSlashProfileCommand(s, i)
-		case "badges":
-			b.handleSlashBadgesCommand(s, i)
-		default:
-			log.Printf("Unknown command received: %s", commandName)
+		if !b.registry.HandleInteraction(s, i) {
+			b.logger.Warn("Unknown command received", "command", i.ApplicationCommandData().Name, "guild_id", i.GuildID)
 			// Direct error response - no retry needed for user errors
 			err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -305,14 +317,27 @@ func (b *Bot) handleInteractionCreate(s *discordgo.Session, i *discordgo.Interac
 				},
 			})
 			if err != nil {
-				log.Printf("Error responding to unknown command: %v", err)
+				b.logger.Error("Failed to respond to unknown command", "error", err)
 			}
 		}
 	}
+
+	if i.Type == discordgo.InteractionMessageComponent {
+		customID := i.MessageComponentData().CustomID
+		switch {
+		case strings.HasPrefix(customID, forgetConfirmPrefix), strings.HasPrefix(customID, forgetGuildConfirmPrefix),
+			strings.HasPrefix(customID, forgetCancelPrefix):
+			b.handleForgetComponent(s, i)
+		case strings.HasPrefix(customID, cleanupConfirmPrefix), customID == cleanupCancelID:
+			b.handleCleanupComponent(s, i)
+		default:
+			b.logger.Warn("Unknown component interaction received", "custom_id", customID, "guild_id", i.GuildID)
+		}
+	}
 }
 
 // handleSlashStatsCommand is the handler for the /stats slash command
-func (b *Bot) handleSlashStatsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleSlashStatsCommand(s discord.Session, i *discordgo.InteractionCreate) {
 	ctx := context.Background()
 
 	// Get user ID from interaction
@@ -327,7 +352,7 @@ func (b *Bot) handleSlashStatsCommand(s *discordgo.Session, i *discordgo.Interac
 	}
 
 	if userID == "" {
-		log.Println("Error: could not determine UserID from interaction for /stats command")
+		b.logger.Error("Could not determine the user ID from the interaction for /stats command")
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -342,7 +367,7 @@ func (b *Bot) handleSlashStatsCommand(s *discordgo.Session, i *discordgo.Interac
 	_, err := b.db.GetUser(ctx, userID)
 	if err != nil {
 		if err != sql.ErrNoRows {
-			log.Printf("Error retrieving user %s for /stats: %v", userID, err)
+			b.logger.Error("Failed to get user for /stats", "user_id", userID, "error", err)
 			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
 				Data: &discordgo.InteractionResponseData{
@@ -359,7 +384,7 @@ func (b *Bot) handleSlashStatsCommand(s *discordgo.Session, i *discordgo.Interac
 			Username: sql.NullString{String: username, Valid: true},
 		})
 		if createErr != nil {
-			log.Printf("Error creating user via /stats command: %v", createErr)
+			b.logger.Error("Failed to create user for /stats", "user_id", userID, "error", createErr)
 			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
 				Data: &discordgo.InteractionResponseData{
@@ -371,8 +396,15 @@ func (b *Bot) handleSlashStatsCommand(s *discordgo.Session, i *discordgo.Interac
 		}
 	}
 
+	// Time from a session that is still running isn't in user_stats until it ends
+	live := b.inProgressShares(userID)
+	inProgress := live.Total
+
 	// Get user stats
 	stats, err := b.db.GetUserStats(ctx, userID)
+	if err == sql.ErrNoRows && inProgress > 0 {
+		err = nil // First session ever; show it with empty stored stats
+	}
 	if err != nil {
 		if err == sql.ErrNoRows {
 			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -385,7 +417,7 @@ func (b *Bot) handleSlashStatsCommand(s *discordgo.Session, i *discordgo.Interac
 			return
 		}
 
-		log.Printf("Error getting user stats for %s: %v", userID, err)
+		b.logger.Error("Failed to get user stats", "user_id", userID, "error", err)
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -411,11 +443,11 @@ func (b *Bot) handleSlashStatsCommand(s *discordgo.Session, i *discordgo.Interac
 		monthlyMs = stats.MonthlyStudyMs.Int64
 	}
 
-	// Convert to durations
-	total := time.Duration(totalMs) * time.Millisecond
-	daily := time.Duration(dailyMs) * time.Millisecond
-	weekly := time.Duration(weeklyMs) * time.Millisecond
-	monthly := time.Duration(monthlyMs) * time.Millisecond
+	// Convert to durations, including the running session
+	total := time.Duration(totalMs)*time.Millisecond + live.Total
+	daily := time.Duration(dailyMs)*time.Millisecond + live.Day
+	weekly := time.Duration(weeklyMs)*time.Millisecond + live.Week
+	monthly := time.Duration(monthlyMs)*time.Millisecond + live.Month
 
 	// Create stats embed
 	embed := &discordgo.MessageEmbed{
@@ -431,6 +463,12 @@ func (b *Bot) handleSlashStatsCommand(s *discordgo.Session, i *discordgo.Interac
 		Timestamp: time.Now().Format(time.RFC3339),
 		Footer:    &discordgo.MessageEmbedFooter{Text: "Keep up the good work!"},
 	}
+	if inProgress > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "⏳ In Progress",
+			Value: fmt.Sprintf("%s from your current session is included above and will be saved when you leave.", formatDuration(inProgress)),
+		})
+	}
 
 	// Send response directly (no deferred response needed for simple stats)
 	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -441,19 +479,19 @@ func (b *Bot) handleSlashStatsCommand(s *discordgo.Session, i *discordgo.Interac
 	})
 
 	if err != nil {
-		log.Printf("Error sending /stats response: %v", err)
+		b.logger.Error("Failed to send /stats response", "error", err)
 		// If the direct response fails, we can't send a followup since we didn't defer
 		// This is actually better - it fails fast and clearly
 	}
 }
 
 // handleSlashLeaderboardCommand handles the /leaderboard slash command
-func (b *Bot) handleSlashLeaderboardCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleSlashLeaderboardCommand(s discord.Session, i *discordgo.InteractionCreate) {
 	ctx := context.Background()
 
 	leaderboardData, err := b.db.GetLeaderboard(ctx)
 	if err != nil {
-		log.Printf("Error fetching leaderboard data: %v", err)
+		b.logger.Error("Failed to get leaderboard", "guild_id", i.GuildID, "error", err)
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -464,7 +502,8 @@ func (b *Bot) handleSlashLeaderboardCommand(s *discordgo.Session, i *discordgo.I
 		return
 	}
 
-	if len(leaderboardData) == 0 {
+	entries := b.liveLeaderboard(ctx, leaderboardData)
+	if len(entries) == 0 {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -475,21 +514,15 @@ func (b *Bot) handleSlashLeaderboardCommand(s *discordgo.Session, i *discordgo.I
 	}
 
 	embedFields := []*discordgo.MessageEmbedField{}
-	for rank, entry := range leaderboardData {
-		username := "Unknown User"
-		if entry.Username.Valid {
-			username = entry.Username.String
-		}
-
-		durationMs := int64(0)
-		if entry.TotalStudyMs.Valid {
-			durationMs = entry.TotalStudyMs.Int64
+	for rank, entry := range entries {
+		value := fmt.Sprintf("Time Studied: %s (<@%s>)", formatDuration(entry.Total+entry.InProgress), entry.UserID)
+		if entry.InProgress > 0 {
+			value += fmt.Sprintf("\n⏳ includes %s in progress", formatDuration(entry.InProgress))
 		}
-		duration := time.Duration(durationMs) * time.Millisecond
 
 		embedFields = append(embedFields, &discordgo.MessageEmbedField{
-			Name:   fmt.Sprintf("%d. %s", rank+1, username),
-			Value:  fmt.Sprintf("Time Studied: %s (<@%s>)", formatDuration(duration), entry.UserID),
+			Name:   fmt.Sprintf("%d. %s", rank+1, entry.Username),
+			Value:  value,
 			Inline: false,
 		})
 	}
@@ -503,7 +536,8 @@ func (b *Bot) handleSlashLeaderboardCommand(s *discordgo.Session, i *discordgo.I
 		Footer:      &discordgo.MessageEmbedFooter{Text: "LockIn Bot Leaderboard"},
 	}
 
-	// After getting leaderboard data, check competition achievements for the user
+	// After getting leaderboard data, check competition achievements for the user.
+	// Ranks come from stored totals only, so running sessions can't earn rank badges early.
 	if i.Member != nil && i.Member.User != nil {
 		userID := i.Member.User.ID
 		guildID := i.GuildID
@@ -531,12 +565,12 @@ func (b *Bot) handleSlashLeaderboardCommand(s *discordgo.Session, i *discordgo.I
 		},
 	})
 	if err != nil {
-		log.Printf("Error sending /leaderboard response: %v", err)
+		b.logger.Error("Failed to send /leaderboard response", "error", err)
 	}
 }
 
 // handleSlashHelpCommand handles the /help slash command
-func (b *Bot) handleSlashHelpCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleSlashHelpCommand(s discord.Session, i *discordgo.InteractionCreate) {
 	embed := &discordgo.MessageEmbed{
 		Title:       "LockIn Bot Help",
 		Description: "Hi there! I'm LockIn Bot. I track time spent in voice channels and help you stay focused.",
@@ -550,14 +584,44 @@ func (b *Bot) handleSlashHelpCommand(s *discordgo.Session, i *discordgo.Interact
 				Name:  "`/leaderboard`",
 				Value: "Displays the top users by voice channel time.",
 			},
+			{
+				Name:  "`/now`",
+				Value: "Shows who is studying right now, for how long, and their total for today.",
+			},
+			{
+				Name:  "`/dashboard`",
+				Value: "DMs you a link to this server's web dashboard: the leaderboard, who's studying now and your profile with badges and a heatmap.",
+			},
+			{
+				Name:  "`/recap`",
+				Value: "Shows this server's weekly or monthly recap. Use `/recap dm` to get your personal summary by DM.",
+			},
+			{
+				Name:  "`/notifications`",
+				Value: "Choose channel, DM or off for streak warnings, daily completion, badges and session summaries, and set quiet hours.",
+			},
+			{
+				Name:  "`/reminders`",
+				Value: "Shows or changes when you're warned that your streak is at risk, e.g. `/reminders set times:18:00, 22:00`.",
+			},
 			{
 				Name:  "`/help`",
 				Value: "Shows this help message.",
 			},
+			{
+				Name:  "`/forget-me`",
+				Value: "Permanently deletes all of your study data (asks for confirmation first).",
+			},
 		},
 		Timestamp: time.Now().Format(time.RFC3339),
 		Footer:    &discordgo.MessageEmbedFooter{Text: "LockIn Bot"},
 	}
+	if aliases := b.registry.Aliases(); len(aliases) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Text Commands",
+			Value: fmt.Sprintf("`%s` also work as chat messages.", strings.Join(aliases, "`, `")),
+		})
+	}
 
 	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -566,7 +630,7 @@ func (b *Bot) handleSlashHelpCommand(s *discordgo.Session, i *discordgo.Interact
 		},
 	})
 	if err != nil {
-		log.Printf("Error sending /help response: %v", err)
+		b.logger.Error("Failed to send /help response", "error", err)
 	}
 }
 
@@ -590,28 +654,19 @@ func formatDuration(d time.Duration) string {
 }
 
 // handleVoiceStateUpdate is called when a user's voice state changes
-func (b *Bot) handleVoiceStateUpdate(s *discordgo.Session, v *discordgo.VoiceStateUpdate) {
+func (b *Bot) handleVoiceStateUpdate(s discord.Session, v *discordgo.VoiceStateUpdate) {
+	// Every log line from here through ending the session and updating streaks shares this event ID
+	ctx := logging.WithEventID(context.Background(), logging.NewEventID())
+	logger := b.logger.With("user_id", v.UserID, "guild_id", v.GuildID)
+	metrics.VoiceEvents.Inc()
+
 	// Deduplication: prevent processing duplicate events within 2 seconds
-	if b.isDuplicateVoiceEvent(v) {
-		log.Printf("Skipping duplicate voice event for user %s", v.UserID)
+	if b.isDuplicateVoiceEvent(ctx, v) {
+		metrics.VoiceEventsDeduplicated.Inc()
+		logger.DebugContext(ctx, "Skipping duplicate voice event")
 		return
 	}
 
-	// Check if user left a tracked voice channel - handle this synchronously first
-	userLeftTrackedChannel := (v.BeforeUpdate != nil && v.BeforeUpdate.ChannelID != "") &&
-		(v.ChannelID == "" || v.ChannelID != v.BeforeUpdate.ChannelID)
-
-	// --- Streak Service Integration --- Process voice LEAVE synchronously FIRST
-	if b.streakService != nil && userLeftTrackedChannel {
-		// Handle voice leave synchronously to ensure StreakService gets session timing
-		// before Bot clears the session data
-		ctx := context.Background()
-		err := b.streakService.HandleVoiceLeave(ctx, v.UserID, v.GuildID)
-		if err != nil {
-			log.Printf("Error in StreakService.HandleVoiceLeave for user %s: %v", v.UserID, err)
-		}
-	}
-
 	// --- Streak Service Integration --- Process voice JOIN asynchronously
 	if b.streakService != nil {
 		// Check if user joined a tracked voice channel
@@ -620,22 +675,21 @@ func (b *Bot) handleVoiceStateUpdate(s *discordgo.Session, v *discordgo.VoiceSta
 
 		if userJoinedTrackedChannel {
 			// Queue the join task asynchronously (no timing concerns for joins)
-			b.voiceEventChan <- func() {
-				ctx := context.Background()
+			b.queueVoiceTask(func() {
 				err := b.streakService.HandleVoiceJoin(ctx, v.UserID, v.GuildID, v.ChannelID)
 				if err != nil {
-					log.Printf("Error in StreakService.HandleVoiceJoin for user %s: %v", v.UserID, err)
+					logger.ErrorContext(ctx, "Failed to handle voice join for streaks", "error", err)
 				}
-			}
+			})
 		}
 	} else if b.streakService == nil {
-		log.Println("Warning: StreakService is not initialized in Bot, skipping streak handling for VoiceStateUpdate.")
+		logger.WarnContext(ctx, "StreakService is not initialized, skipping streak handling for voice state update")
 	}
 
 	// Existing Study Session Logic - Bot handles database session management
 	user, err := s.User(v.UserID)
 	if err != nil {
-		log.Printf("Error getting user %s for study session logic: %v", v.UserID, err)
+		logger.WarnContext(ctx, "Failed to get user for study session logic", "error", err)
 		// Depending on how critical user object is, you might return or proceed cautiously
 	}
 
@@ -667,35 +721,43 @@ func (b *Bot) handleVoiceStateUpdate(s *discordgo.Session, v *discordgo.VoiceSta
 	if userJoinedNewChannel {
 		if newChannelIsTracked {
 			if !userWasInTrackedSession { // Started new session in a tracked channel
-				log.Printf("User %s joined tracked VC %s. Starting study session.", v.UserID, v.ChannelID)
-				b.handleUserJoinedStudySession(s, v, user)
+				logger.InfoContext(ctx, "Joined tracked voice channel, starting study session", "channel_id", v.ChannelID)
+				b.handleUserJoinedStudySession(ctx, s, v, user)
 			} else if oldChannelWasTracked && v.BeforeUpdate.ChannelID != v.ChannelID {
 				// Moved between two tracked VCs - current study session logic might implicitly handle this by not ending/restarting.
-				log.Printf("User %s moved between tracked VCs (%s -> %s). Study session continues.", v.UserID, v.BeforeUpdate.ChannelID, v.ChannelID)
+				logger.InfoContext(ctx, "Moved between tracked voice channels, study session continues",
+					"from_channel_id", v.BeforeUpdate.ChannelID, "channel_id", v.ChannelID)
+				b.activeSessionMu.Lock()
+				if active, ok := b.activeSessions[v.UserID]; ok {
+					active.ChannelID = v.ChannelID
+					b.activeSessions[v.UserID] = active
+				}
+				b.activeSessionMu.Unlock()
+				b.requestLiveStatusRefresh()
 			} else if !oldChannelWasTracked { // Moved from untracked to tracked
-				log.Printf("User %s moved from untracked to tracked VC %s. Starting study session.", v.UserID, v.ChannelID)
-				b.handleUserJoinedStudySession(s, v, user)
+				logger.InfoContext(ctx, "Moved to a tracked voice channel, starting study session", "channel_id", v.ChannelID)
+				b.handleUserJoinedStudySession(ctx, s, v, user)
 			}
 		} else { // Joined/moved to an untracked channel
 			if userWasInTrackedSession { // Was in a tracked channel, now in untracked: end session
-				log.Printf("User %s moved from tracked to untracked VC %s. Ending study session.", v.UserID, v.ChannelID)
-				b.handleUserLeftStudySession(s, v, user) // Pass v, it has BeforeUpdate for context
+				logger.InfoContext(ctx, "Moved to an untracked voice channel, ending study session", "channel_id", v.ChannelID)
+				b.handleUserLeftStudySession(ctx, s, v, user) // Pass v, it has BeforeUpdate for context
 			}
 		}
 	} else if completelyLeftVoice {
 		if userWasInTrackedSession && oldChannelWasTracked { // Left from a tracked channel
-			log.Printf("User %s left tracked VC %s. Ending study session.", v.UserID, v.BeforeUpdate.ChannelID)
-			b.handleUserLeftStudySession(s, v, user)
+			logger.InfoContext(ctx, "Left tracked voice channel, ending study session", "channel_id", v.BeforeUpdate.ChannelID)
+			b.handleUserLeftStudySession(ctx, s, v, user)
 		}
 	}
 }
 
 // isDuplicateVoiceEvent checks if this voice event is a duplicate within the last 3 seconds
-func (b *Bot) isDuplicateVoiceEvent(v *discordgo.VoiceStateUpdate) bool {
+func (b *Bot) isDuplicateVoiceEvent(ctx context.Context, v *discordgo.VoiceStateUpdate) bool {
 	b.voiceEventMu.Lock()
 	defer b.voiceEventMu.Unlock()
 
-	now := time.Now()
+	now := b.clock.Now()
 	dedupeWindow := 3 * time.Second // Increased from 2 to 3 seconds
 
 	// Create more specific event keys for better deduplication
@@ -722,8 +784,8 @@ func (b *Bot) isDuplicateVoiceEvent(v *discordgo.VoiceStateUpdate) bool {
 	for _, key := range eventKeys {
 		if lastTime, exists := b.lastVoiceEvent[key]; exists {
 			if now.Sub(lastTime) < dedupeWindow {
-				log.Printf("Duplicate voice event detected for key: %s (last: %v, now: %v, diff: %v)",
-					key, lastTime, now, now.Sub(lastTime))
+				b.logger.DebugContext(ctx, "Duplicate voice event detected",
+					"user_id", v.UserID, "guild_id", v.GuildID, "key", key, "since_last", now.Sub(lastTime))
 				return true // Duplicate event
 			}
 		}
@@ -746,44 +808,45 @@ func (b *Bot) isDuplicateVoiceEvent(v *discordgo.VoiceStateUpdate) bool {
 }
 
 // handleUserJoinedStudySession handles when a user joins a tracked voice channel
-func (b *Bot) handleUserJoinedStudySession(s *discordgo.Session, v *discordgo.VoiceStateUpdate, user *discordgo.User) {
-	ctx := context.Background()
-	now := time.Now() // Define 'now' for consistent timing
+func (b *Bot) handleUserJoinedStudySession(ctx context.Context, s discord.Session, v *discordgo.VoiceStateUpdate, user *discordgo.User) {
+	now := b.clock.Now() // Define 'now' for consistent timing
+	logger := b.logger.With("user_id", v.UserID, "guild_id", v.GuildID)
 
 	b.activeSessionMu.Lock()
 	defer b.activeSessionMu.Unlock()
 
 	// Enhanced race condition protection: Check if user already has a recent active session
-	if existingStartTime, exists := b.activeSessions[v.UserID]; exists {
-		timeSinceStart := now.Sub(existingStartTime)
+	if existing, exists := b.activeSessions[v.UserID]; exists {
+		timeSinceStart := now.Sub(existing.StartTime)
 		// If the user joined very recently (within 10 seconds), this is likely a duplicate event
 		if timeSinceStart < 10*time.Second {
-			log.Printf("User %s already has a very recent session (started %v ago). Skipping duplicate session creation.", v.UserID, timeSinceStart)
+			logger.DebugContext(ctx, "Skipping duplicate session creation for a very recent session", "since_start", timeSinceStart)
 			return
 		}
 		// If it's been longer than 10 seconds, this might be a legitimate new session
-		log.Printf("User %s was already in local activeSessions map for %v. This might be a legitimate channel switch. Proceeding with session update.", v.UserID, timeSinceStart)
+		logger.InfoContext(ctx, "Already tracking a session in memory, treating this as a channel switch", "since_start", timeSinceStart)
 	}
 
 	// Check for and end any pre-existing active session for this user in the DB
 	existingDBSession, err := b.db.GetActiveStudySession(ctx, sql.NullString{String: v.UserID, Valid: true})
 	if err == nil { // An active session exists in the DB
-		log.Printf("User %s has an existing active DB session %d started at %v. Ending it with current time %v before starting new one.", v.UserID, existingDBSession.SessionID, existingDBSession.StartTime, now)
+		logger.WarnContext(ctx, "Ending an existing active DB session before starting a new one",
+			"session_id", existingDBSession.SessionID, "start_time", existingDBSession.StartTime)
 		_, endErr := b.db.EndStudySession(ctx, database.EndStudySessionParams{
 			SessionID: existingDBSession.SessionID,
 			EndTime:   sql.NullTime{Time: now, Valid: true}, // End it with current time
 		})
 		if endErr != nil {
-			log.Printf("Error auto-ending pre-existing DB session %d for user %s: %v", existingDBSession.SessionID, v.UserID, endErr)
+			logger.ErrorContext(ctx, "Failed to end existing DB session", "session_id", existingDBSession.SessionID, "error", endErr)
 			return // Don't create new session if we can't clean up the old one
 		}
 	} else if err != sql.ErrNoRows { // Log unexpected errors from GetActiveStudySession
-		log.Printf("Error checking for existing active DB session for user %s: %v", v.UserID, err)
+		logger.ErrorContext(ctx, "Failed to check for an existing active DB session", "error", err)
 		// For now, we'll proceed to attempt creating a new session.
 	}
 
 	// Update/set the in-memory tracker BEFORE creating DB session
-	b.activeSessions[v.UserID] = now
+	b.activeSessions[v.UserID] = activeSession{StartTime: now, GuildID: v.GuildID, ChannelID: v.ChannelID}
 
 	// Create DB user if they don't exist
 	dbUserParams := database.CreateUserParams{UserID: v.UserID}
@@ -795,7 +858,7 @@ func (b *Bot) handleUserJoinedStudySession(s *discordgo.Session, v *discordgo.Vo
 		if fetchErr == nil && fetchedUser != nil {
 			dbUserParams.Username = sql.NullString{String: fetchedUser.Username, Valid: true}
 		} else {
-			log.Printf("Could not fetch user object for %s in handleUserJoinedStudySession (UserID: %s), using placeholder username. Fetch error: %v", v.UserID, v.UserID, fetchErr)
+			logger.WarnContext(ctx, "Could not fetch user, using a placeholder username", "error", fetchErr)
 			// Use a placeholder to satisfy NOT NULL constraints if username is mandatory, or ensure DB schema allows NULL
 			dbUserParams.Username = sql.NullString{String: "UnknownUser-" + v.UserID, Valid: true} // Ensure length is not an issue
 			if len(v.UserID) > 6 {                                                                 // make sure UserID has at least 6 chars to slice
@@ -806,25 +869,27 @@ func (b *Bot) handleUserJoinedStudySession(s *discordgo.Session, v *discordgo.Vo
 	_, createErr := b.db.CreateUser(ctx, dbUserParams)
 	if createErr != nil {
 		// Log error, but don't necessarily block session creation if user already exists and this is just an update failing
-		log.Printf("Error creating/updating user %s for study session (params: %+v): %v", v.UserID, dbUserParams, createErr)
+		logger.ErrorContext(ctx, "Failed to create or update user for study session", "username", dbUserParams.Username.String, "error", createErr)
 	}
 
 	// Create the new study session in the DB
 	session, err := b.db.CreateStudySession(ctx, database.CreateStudySessionParams{
 		UserID:    sql.NullString{String: v.UserID, Valid: true},
+		GuildID:   sql.NullString{String: v.GuildID, Valid: v.GuildID != ""},
 		StartTime: now, // Use the 'now' from the beginning of this function call
 	})
 	if err != nil {
-		log.Printf("Error creating new study session for user %s: %v", v.UserID, err)
+		logger.ErrorContext(ctx, "Failed to create study session", "error", err)
 		// If DB creation fails, remove from activeSessions to maintain consistency
 		delete(b.activeSessions, v.UserID)
 	} else {
-		log.Printf("Started study session %d for user %s in VC %s at %v", session.SessionID, v.UserID, v.ChannelID, now)
+		logger.InfoContext(ctx, "Started study session", "session_id", session.SessionID, "channel_id", v.ChannelID, "start_time", now)
+		b.requestLiveStatusRefresh()
 	}
 }
 
 // handleUserLeftStudySession handles when a user leaves a tracked voice channel
-func (b *Bot) handleUserLeftStudySession(_ *discordgo.Session, v *discordgo.VoiceStateUpdate, user *discordgo.User) {
+func (b *Bot) handleUserLeftStudySession(ctx context.Context, _ discord.Session, v *discordgo.VoiceStateUpdate, user *discordgo.User) {
 	userID := ""
 	username := ""
 	if user != nil {
@@ -835,7 +900,7 @@ func (b *Bot) handleUserLeftStudySession(_ *discordgo.Session, v *discordgo.Voic
 		userID = v.UserID
 	}
 	if userID == "" {
-		log.Println("Unable to end study session: missing user ID")
+		b.logger.ErrorContext(ctx, "Unable to end study session: missing user ID")
 		return
 	}
 	if username == "" {
@@ -846,95 +911,80 @@ func (b *Bot) handleUserLeftStudySession(_ *discordgo.Session, v *discordgo.Voic
 	defer b.activeSessionMu.Unlock()
 
 	// Check if the user has an active session in memory
-	startTime, ok := b.activeSessions[userID]
+	active, ok := b.activeSessions[userID]
 	if !ok {
-		// log.Printf("User %s left voice channel %s but had no active session in memory.", user.Username, v.BeforeUpdate.ChannelID)
 		return // No active session for this user in memory
 	}
 
-	duration := time.Since(startTime)
-	log.Printf("User %s (%s) left voice channel. Study session ended. Duration: %s", username, userID, formatDuration(duration))
+	guildID := active.GuildID
+	if guildID == "" && v != nil {
+		guildID = v.GuildID
+	}
+	logger := b.logger.With("user_id", userID, "guild_id", guildID)
 
-	// Get the active study session from the database
-	ctx := context.Background()
-	activeDBSession, err := b.db.GetActiveStudySession(ctx, sql.NullString{String: userID, Valid: true})
-	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Printf("No active DB session found for user %s when ending session. This is likely a race condition or duplicate event.", userID)
-		} else {
-			log.Printf("Error getting active DB session for user %s: %v", userID, err)
-		}
-		// Still attempt to remove from in-memory map
-		delete(b.activeSessions, userID)
+	now := b.clock.Now()
+	duration := now.Sub(active.StartTime)
+	logger.InfoContext(ctx, "Left voice channel, ending study session", "username", username, "duration", formatDuration(duration))
+
+	if b.sessionService == nil {
+		// Keep the session in memory so the next leave, timeout or shutdown can still end it
+		logger.ErrorContext(ctx, "SessionService not available, cannot end study session")
 		return
 	}
 
-	// End the study session in the database
-	// Ensure we are passing sql.NullTime for EndTime
-	endedSession, err := b.db.EndStudySession(ctx, database.EndStudySessionParams{
-		SessionID: activeDBSession.SessionID,
-		EndTime:   sql.NullTime{Time: time.Now(), Valid: true},
-		// DurationMs is now calculated by the query
+	// End the session, update stats and streak activity in one transaction
+	result, err := b.sessionService.EndSession(ctx, service.EndSessionRequest{
+		UserID:  userID,
+		GuildID: guildID,
+		EndTime: now,
 	})
-	if err != nil {
-		log.Printf("Error ending study session %d for user %s in DB: %v", activeDBSession.SessionID, userID, err)
-		// Still attempt to remove from in-memory map
-		delete(b.activeSessions, userID)
+	if err != nil && !errors.Is(err, service.ErrNoActiveSession) {
+		// Keep the session in memory so the next leave, timeout or shutdown retries ending it
+		logger.ErrorContext(ctx, "Failed to end study session", "error", err)
 		return
 	}
 
-	log.Printf("Ended DB session %d for user %s. DB Duration: %d ms.", endedSession.SessionID, userID, endedSession.DurationMs.Int64)
+	// The database is the source of truth from here on
+	delete(b.activeSessions, userID)
+	b.requestLiveStatusRefresh()
 
-	// Update user stats
-	if endedSession.DurationMs.Valid && endedSession.DurationMs.Int64 > 0 {
-		_, err = b.db.CreateOrUpdateUserStats(ctx, database.CreateOrUpdateUserStatsParams{
-			UserID:       userID,
-			TotalStudyMs: sql.NullInt64{Int64: endedSession.DurationMs.Int64, Valid: true}, // Pass as sql.NullInt64
-			// Daily, weekly, monthly are also updated by this query based on the same amount
-		})
-		if err != nil {
-			log.Printf("Error updating user stats for user %s after session %d: %v", userID, endedSession.SessionID, err)
-		} else {
-			log.Printf("Successfully updated stats for user %s after session %d.", userID, endedSession.SessionID)
-		}
+	if err != nil {
+		logger.WarnContext(ctx, "No active DB session found when ending session, likely a race or duplicate event")
+		return
 	}
 
-	// Send study time announcement to logging channel if configured
-	if b.LoggingChannelID != "" && endedSession.DurationMs.Valid && endedSession.DurationMs.Int64 > 0 {
-		durationForMessage := time.Duration(endedSession.DurationMs.Int64) * time.Millisecond
-		formattedDuration := formatDuration(durationForMessage)
-		message := fmt.Sprintf("<@%s> has spent %s studying!", userID, formattedDuration)
-		_, err = b.session.ChannelMessageSend(b.LoggingChannelID, message)
-		if err != nil {
-			log.Printf("Error sending study time announcement to Discord channel %s for user %s: %v", b.LoggingChannelID, userID, err)
-		}
+	// Announce the study time in the logging channel, or by DM if the user prefers
+	if result.Duration() > 0 {
+		message := fmt.Sprintf("<@%s> has spent %s studying!", userID, formatDuration(result.Duration()))
+		b.announceSessionEnd(ctx, result, userID, message)
 	}
+}
 
-	// Check for achievements after session ends
-	if b.achievementService != nil && endedSession.DurationMs.Valid && endedSession.DurationMs.Int64 > 0 {
-		guildID := v.GuildID // Use actual guild from voice state
-
-		// Get total hours for duration achievements
-		stats, err := b.db.GetUserStats(ctx, userID)
-		if err == nil && stats.TotalStudyMs.Valid {
-			totalHours := float64(stats.TotalStudyMs.Int64) / 1000 / 60 / 60
-			sessionHours := float64(endedSession.DurationMs.Int64) / 1000 / 60 / 60
-
-			// Check duration achievements
-			go b.achievementService.CheckDurationAchievements(ctx, userID, guildID, totalHours, sessionHours)
-
-			// Check time-based achievements (Early Bird, Night Owl, etc.)
-			go b.achievementService.CheckTimeBasedAchievements(ctx, userID, guildID, startTime)
+// announceSessionEnd queues a message about an ended session for the logging channel, or the
+// user's DMs if they asked for session summaries there. Without a notification service it
+// falls back to sending to the logging channel directly.
+func (b *Bot) announceSessionEnd(ctx context.Context, result *service.EndSessionResult, userID, message string) {
+	if b.notificationService == nil {
+		if b.LoggingChannelID == "" {
+			return
 		}
-
-		// Check global_citizen (12 unique hours) and dawn_to_dusk (12 hours in one day)
-		go b.achievementService.CheckGlobalCitizen(ctx, userID, guildID)
-		go b.achievementService.CheckDawnToDusk(ctx, userID, guildID)
+		if _, err := b.session.ChannelMessageSend(b.LoggingChannelID, message); err != nil {
+			b.logger.ErrorContext(ctx, "Failed to send session message", "user_id", userID, "channel_id", b.LoggingChannelID, "error", err)
+		}
+		return
 	}
 
-	// Remove user from active sessions map
-	delete(b.activeSessions, userID)
-	log.Printf("User %s removed from active session map.", userID)
+	err := b.notificationService.Enqueue(ctx, service.Notification{
+		DedupeKey:  fmt.Sprintf("session_end:%d", result.Session.SessionID),
+		Kind:       service.NotificationKindSession,
+		UserID:     userID,
+		ChannelID:  b.LoggingChannelID,
+		Content:    message,
+		Preference: service.PreferenceSessionSummaries,
+	})
+	if err != nil {
+		b.logger.ErrorContext(ctx, "Failed to queue session message", "user_id", userID, "session_id", result.Session.SessionID, "error", err)
+	}
 }
 
 func (b *Bot) SetStreakService(ss *service.StreakService) {
@@ -942,9 +992,9 @@ func (b *Bot) SetStreakService(ss *service.StreakService) {
 }
 
 // handleSlashStreakCommand handles the /streak slash command
-func (b *Bot) handleSlashStreakCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleSlashStreakCommand(s discord.Session, i *discordgo.InteractionCreate) {
 	if b.streakService == nil {
-		log.Println("Error: StreakService not available for /streak command")
+		b.logger.Error("StreakService not available for /streak command")
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -963,7 +1013,7 @@ func (b *Bot) handleSlashStreakCommand(s *discordgo.Session, i *discordgo.Intera
 	}
 
 	if userID == "" {
-		log.Println("Error: could not determine UserID from interaction for /streak command")
+		b.logger.Error("Could not determine the user ID from the interaction for /streak command")
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -986,9 +1036,15 @@ func (b *Bot) handleSlashStreakCommand(s *discordgo.Session, i *discordgo.Intera
 		return
 	}
 
-	embed, err := b.streakService.GetUserStreakInfoEmbed(context.Background(), userID, guildID)
+	var embed *discordgo.MessageEmbed
+	var err error
+	if options := i.ApplicationCommandData().Options; len(options) > 0 && options[0].Name == "calendar" {
+		embed, err = b.streakService.GetStreakCalendarEmbed(context.Background(), userID, guildID, b.clock.Now())
+	} else {
+		embed, err = b.streakService.GetUserStreakInfoEmbed(context.Background(), userID, guildID)
+	}
 	if err != nil {
-		log.Printf("Error getting streak info for user %s in guild %s: %v", userID, guildID, err)
+		b.logger.Error("Failed to get streak info", "user_id", userID, "guild_id", guildID, "error", err)
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -1006,7 +1062,7 @@ func (b *Bot) handleSlashStreakCommand(s *discordgo.Session, i *discordgo.Intera
 		},
 	})
 	if err != nil {
-		log.Printf("Error sending /streak response: %v", err)
+		b.logger.Error("Failed to send /streak response", "error", err)
 	}
 }
 
@@ -1014,10 +1070,49 @@ func (b *Bot) SetAchievementService(as *service.AchievementService) {
 	b.achievementService = as
 }
 
+// SetSessionService sets the service used to end study sessions
+func (b *Bot) SetSessionService(ss *service.SessionService) {
+	b.sessionService = ss
+}
+
+// SetNotificationService sets the outbox used for session announcements
+func (b *Bot) SetNotificationService(ns *service.NotificationService) {
+	b.notificationService = ns
+}
+
+func (b *Bot) SetAccountService(as *service.AccountService) {
+	b.accountService = as
+}
+
+// SetRecapService sets the service that builds and archives weekly and monthly recaps
+func (b *Bot) SetRecapService(rs *service.RecapService) {
+	b.recapService = rs
+}
+
+// SetAuditService sets the service used to read the audit log
+func (b *Bot) SetAuditService(as *service.AuditService) {
+	b.auditService = as
+}
+
+// SetAPITokenService sets the service that manages REST API tokens
+func (b *Bot) SetAPITokenService(ts *service.APITokenService) {
+	b.apiTokenService = ts
+}
+
+// SetDashboardLinks sets what signs web dashboard links; without it /dashboard says the dashboard isn't set up
+func (b *Bot) SetDashboardLinks(links *dashboard.Links) {
+	b.dashboardLinks = links
+}
+
+// SetLogger sets the structured logger used by the bot and the schedulers it creates
+func (b *Bot) SetLogger(logger *slog.Logger) {
+	b.logger = logger
+}
+
 // handleSlashProfileCommand handles the /profile slash command
-func (b *Bot) handleSlashProfileCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleSlashProfileCommand(s discord.Session, i *discordgo.InteractionCreate) {
 	if b.achievementService == nil {
-		log.Println("Error: AchievementService not available for /profile command")
+		b.logger.Error("AchievementService not available for /profile command")
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -1036,8 +1131,10 @@ func (b *Bot) handleSlashProfileCommand(s *discordgo.Session, i *discordgo.Inter
 	options := i.ApplicationCommandData().Options
 	for _, opt := range options {
 		if opt.Name == "user" && opt.Type == discordgo.ApplicationCommandOptionUser {
-			targetUserID = opt.UserValue(s).ID
-			targetUsername = opt.UserValue(s).Username
+			targetUserID = opt.UserValue(nil).ID
+			if user, err := s.User(targetUserID); err == nil {
+				targetUsername = user.Username
+			}
 		}
 	}
 
@@ -1068,7 +1165,7 @@ func (b *Bot) handleSlashProfileCommand(s *discordgo.Session, i *discordgo.Inter
 	// Get profile data
 	profile, err := b.achievementService.GetUserProfile(ctx, targetUserID, guildID)
 	if err != nil {
-		log.Printf("Error getting profile for user %s: %v", targetUserID, err)
+		b.logger.Error("Failed to get profile", "user_id", targetUserID, "error", err)
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -1094,11 +1191,13 @@ func (b *Bot) handleSlashProfileCommand(s *discordgo.Session, i *discordgo.Inter
 		}
 	}
 
-	// Format total study time
+	// Time from a session that is still running isn't in user_stats until it ends
+	inProgress := b.inProgressShares(targetUserID).Total
+
+	// Format total study time, including the running session
 	totalStudyTime := "0h 0m"
-	if stats.TotalStudyMs.Valid {
-		totalMs := stats.TotalStudyMs.Int64
-		total := time.Duration(totalMs) * time.Millisecond
+	if stats.TotalStudyMs.Valid || inProgress > 0 {
+		total := time.Duration(stats.TotalStudyMs.Int64)*time.Millisecond + inProgress
 		totalStudyTime = formatDuration(total)
 	}
 
@@ -1122,6 +1221,12 @@ func (b *Bot) handleSlashProfileCommand(s *discordgo.Session, i *discordgo.Inter
 		Timestamp: time.Now().Format(time.RFC3339),
 		Footer:    &discordgo.MessageEmbedFooter{Text: "Use /badges to see all available badges"},
 	}
+	if inProgress > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "⏳ In Progress",
+			Value: fmt.Sprintf("%s from the current session is included above and will be saved when it ends.", formatDuration(inProgress)),
+		})
+	}
 
 	// Get user avatar if possible
 	discordUser, err := s.User(targetUserID)
@@ -1140,9 +1245,9 @@ func (b *Bot) handleSlashProfileCommand(s *discordgo.Session, i *discordgo.Inter
 }
 
 // handleSlashBadgesCommand handles the /badges slash command
-func (b *Bot) handleSlashBadgesCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (b *Bot) handleSlashBadgesCommand(s discord.Session, i *discordgo.InteractionCreate) {
 	if b.achievementService == nil {
-		log.Println("Error: AchievementService not available for /badges command")
+		b.logger.Error("AchievementService not available for /badges command")
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -1178,7 +1283,7 @@ func (b *Bot) handleSlashBadgesCommand(s *discordgo.Session, i *discordgo.Intera
 	// Get all achievements with progress
 	achievements, err := b.achievementService.GetAllAchievementsWithProgress(ctx, userID, guildID)
 	if err != nil {
-		log.Printf("Error getting achievements for user %s: %v", userID, err)
+		b.logger.Error("Failed to get achievements", "user_id", userID, "error", err)
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -1261,18 +1366,37 @@ func (b *Bot) voiceEventWorker() {
 		select {
 		case task := <-b.voiceEventChan:
 			task()
+			b.voiceQueueDepth.Add(-1)
 		case <-b.shutdownChan:
 			return
 		}
 	}
 }
 
-// GetSessionStartTime returns the start time for a user's session (for StreakService)
+// queueVoiceTask hands task to the voice event worker, waiting while it is busy
+func (b *Bot) queueVoiceTask(task func()) {
+	b.voiceQueueDepth.Add(1)
+	b.voiceEventChan <- task
+}
+
+// GetSessionStartTime returns the start time for a user's session in any guild
 func (b *Bot) GetSessionStartTime(userID string) (time.Time, bool) {
 	b.activeSessionMu.Lock()
 	defer b.activeSessionMu.Unlock()
-	startTime, exists := b.activeSessions[userID]
-	return startTime, exists
+	active, exists := b.activeSessions[userID]
+	return active.StartTime, exists
+}
+
+// GetGuildSessionStartTime returns the start time for a user's session if it is in guildID (for
+// StreakService, whose streaks are kept per guild)
+func (b *Bot) GetGuildSessionStartTime(userID, guildID string) (time.Time, bool) {
+	b.activeSessionMu.Lock()
+	defer b.activeSessionMu.Unlock()
+	active, exists := b.activeSessions[userID]
+	if !exists || active.GuildID != guildID {
+		return time.Time{}, false
+	}
+	return active.StartTime, true
 }
 
 // MonitorConnection starts a goroutine to monitor Discord connection health
@@ -1300,14 +1424,14 @@ func (b *Bot) checkConnectionHealth() {
 	// Try a simple API call to test if token is still valid
 	_, err := b.session.User("@me")
 	if err != nil {
-		log.Printf("🚨 CRITICAL: Discord connection health check failed: %v", err)
+		b.logger.Error("Discord connection health check failed", "error", err)
 
 		// Check if this is an authentication error (token expired/invalid)
 		if b.isTokenError(err) {
-			log.Printf("🔴 TOKEN EXPIRED/INVALID: %v", err)
+			b.logger.Error("Discord token expired or invalid", "error", err)
 			b.handleTokenExpiration()
 		} else {
-			log.Printf("⚠️  Network or temporary Discord API error: %v", err)
+			b.logger.Warn("Network or temporary Discord API error", "error", err)
 		}
 	}
 }
@@ -1343,14 +1467,8 @@ func (b *Bot) isTokenError(err error) bool {
 
 // handleTokenExpiration handles the critical case when Discord token expires
 func (b *Bot) handleTokenExpiration() {
-	log.Printf("🔴🔴🔴 CRITICAL ALERT: Discord token has expired or been revoked!")
-	log.Printf("📋 ACTION REQUIRED:")
-	log.Printf("1. Go to Discord Developer Portal: https://discord.com/developers/applications")
-	log.Printf("2. Select your bot application")
-	log.Printf("3. Go to 'Bot' section")
-	log.Printf("4. Click 'Reset Token' to generate a new token")
-	log.Printf("5. Update DISCORD_TOKEN environment variable")
-	log.Printf("6. Restart the bot")
+	b.logger.Error("Discord token has expired or been revoked. Reset the token in the Discord Developer Portal " +
+		"(https://discord.com/developers/applications, Bot section), update DISCORD_TOKEN and restart the bot")
 
 	// Try to send alert to logging channel if possible
 	if b.LoggingChannelID != "" {
@@ -1366,11 +1484,10 @@ func (b *Bot) handleTokenExpiration() {
 
 		_, err := b.session.ChannelMessageSend(b.LoggingChannelID, alertMessage)
 		if err != nil {
-			log.Printf("Failed to send token expiration alert to Discord: %v", err)
+			b.logger.Error("Failed to send token expiration alert to Discord", "error", err)
 		}
 	}
 
 	// Log the issue but don't automatically shutdown - let the main process decide
-	log.Printf("⚠️  Token expired but continuing to run for manual intervention")
-	log.Printf("⚠️  Bot functionality will be limited until token is renewed")
+	b.logger.Warn("Token expired but continuing to run for manual intervention; bot functionality is limited until it is renewed")
 }
@@ -9,188 +9,43 @@ import (
 
 	"github.com/Skufu/LockIn-Bot/internal/config"
 	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/database/fakedb"
+	"github.com/Skufu/LockIn-Bot/internal/discord/fakediscord"
 	"github.com/bwmarrin/discordgo"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
-// MockQuerier implements the database.Querier interface for testing
-type MockQuerier struct {
-	mock.Mock
-}
-
-func (m *MockQuerier) GetUser(ctx context.Context, userID string) (database.User, error) {
-	args := m.Called(ctx, userID)
-	return args.Get(0).(database.User), args.Error(1)
-}
-
-func (m *MockQuerier) CreateUser(ctx context.Context, params database.CreateUserParams) (database.User, error) {
-	args := m.Called(ctx, params)
-	return args.Get(0).(database.User), args.Error(1)
-}
-
-func (m *MockQuerier) GetUserStats(ctx context.Context, userID string) (database.UserStat, error) {
-	args := m.Called(ctx, userID)
-	return args.Get(0).(database.UserStat), args.Error(1)
-}
-
-func (m *MockQuerier) GetLeaderboard(ctx context.Context) ([]database.GetLeaderboardRow, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]database.GetLeaderboardRow), args.Error(1)
-}
-
-func (m *MockQuerier) CreateStudySession(ctx context.Context, params database.CreateStudySessionParams) (database.StudySession, error) {
-	args := m.Called(ctx, params)
-	return args.Get(0).(database.StudySession), args.Error(1)
-}
-
-func (m *MockQuerier) GetActiveStudySession(ctx context.Context, userID sql.NullString) (database.StudySession, error) {
-	args := m.Called(ctx, userID)
-	return args.Get(0).(database.StudySession), args.Error(1)
-}
-
-func (m *MockQuerier) EndStudySession(ctx context.Context, params database.EndStudySessionParams) (database.StudySession, error) {
-	args := m.Called(ctx, params)
-	return args.Get(0).(database.StudySession), args.Error(1)
-}
-
-func (m *MockQuerier) CreateOrUpdateUserStats(ctx context.Context, params database.CreateOrUpdateUserStatsParams) (database.UserStat, error) {
-	args := m.Called(ctx, params)
-	return args.Get(0).(database.UserStat), args.Error(1)
-}
-
-// Implement remaining Querier interface methods (stubs for testing)
-func (m *MockQuerier) DeleteOldStudySessions(ctx context.Context, startTime time.Time) error {
-	args := m.Called(ctx, startTime)
-	return args.Error(0)
-}
-
-func (m *MockQuerier) GetUserStreak(ctx context.Context, arg database.GetUserStreakParams) (database.GetUserStreakRow, error) {
-	args := m.Called(ctx, arg)
-	return args.Get(0).(database.GetUserStreakRow), args.Error(1)
-}
-
-func (m *MockQuerier) GetUsersForDailyEvaluation(ctx context.Context, streakEvaluatedDate sql.NullTime) ([]database.GetUsersForDailyEvaluationRow, error) {
-	args := m.Called(ctx, streakEvaluatedDate)
-	return args.Get(0).([]database.GetUsersForDailyEvaluationRow), args.Error(1)
-}
-
-func (m *MockQuerier) GetUsersForStreakReset(ctx context.Context, lastActivityDate sql.NullTime) ([]database.GetUsersForStreakResetRow, error) {
-	args := m.Called(ctx, lastActivityDate)
-	return args.Get(0).([]database.GetUsersForStreakResetRow), args.Error(1)
-}
-
-func (m *MockQuerier) GetUsersNeedingWarnings(ctx context.Context, lastActivityDate sql.NullTime) ([]database.GetUsersNeedingWarningsRow, error) {
-	args := m.Called(ctx, lastActivityDate)
-	return args.Get(0).([]database.GetUsersNeedingWarningsRow), args.Error(1)
-}
-
-func (m *MockQuerier) HasActivityForDate(ctx context.Context, arg database.HasActivityForDateParams) (bool, error) {
-	args := m.Called(ctx, arg)
-	return args.Bool(0), args.Error(1)
-}
-
-func (m *MockQuerier) ResetAllStreakDailyFlags(ctx context.Context) error {
-	args := m.Called(ctx)
-	return args.Error(0)
-}
-
-func (m *MockQuerier) ResetDailyStudyTime(ctx context.Context) error {
-	args := m.Called(ctx)
-	return args.Error(0)
-}
-
-func (m *MockQuerier) ResetMonthlyStudyTime(ctx context.Context) error {
-	args := m.Called(ctx)
-	return args.Error(0)
-}
-
-func (m *MockQuerier) ResetUserStreakCount(ctx context.Context, arg database.ResetUserStreakCountParams) error {
-	args := m.Called(ctx, arg)
-	return args.Error(0)
-}
-
-func (m *MockQuerier) ResetWeeklyStudyTime(ctx context.Context) error {
-	args := m.Called(ctx)
-	return args.Error(0)
-}
-
-func (m *MockQuerier) StartDailyActivity(ctx context.Context, arg database.StartDailyActivityParams) (database.StartDailyActivityRow, error) {
-	args := m.Called(ctx, arg)
-	return args.Get(0).(database.StartDailyActivityRow), args.Error(1)
-}
-
-func (m *MockQuerier) UpdateDailyActivityMinutes(ctx context.Context, arg database.UpdateDailyActivityMinutesParams) error {
-	args := m.Called(ctx, arg)
-	return args.Error(0)
-}
-
-func (m *MockQuerier) UpdateStreakImmediately(ctx context.Context, arg database.UpdateStreakImmediatelyParams) error {
-	args := m.Called(ctx, arg)
-	return args.Error(0)
-}
-
-func (m *MockQuerier) UpdateUserStreakAfterEvaluation(ctx context.Context, arg database.UpdateUserStreakAfterEvaluationParams) (database.UpdateUserStreakAfterEvaluationRow, error) {
-	args := m.Called(ctx, arg)
-	return args.Get(0).(database.UpdateUserStreakAfterEvaluationRow), args.Error(1)
-}
-
-func (m *MockQuerier) UpdateWarningNotifiedAt(ctx context.Context, arg database.UpdateWarningNotifiedAtParams) error {
-	args := m.Called(ctx, arg)
-	return args.Error(0)
-}
-
-// DiscordSessionInterface defines the interface for Discord session operations
-type DiscordSessionInterface interface {
-	InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse) error
-	User(userID string) (*discordgo.User, error)
-}
-
-// MockDiscordSession implements DiscordSessionInterface for testing
-type MockDiscordSession struct {
-	mock.Mock
-}
-
-func (m *MockDiscordSession) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse) error {
-	args := m.Called(interaction, resp)
-	return args.Error(0)
-}
-
-func (m *MockDiscordSession) User(userID string) (*discordgo.User, error) {
-	args := m.Called(userID)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*discordgo.User), args.Error(1)
-}
-
-// Helper function to create a test bot instance with mocks
-// Note: The Bot struct uses *database.Queries (concrete type), so we can't directly inject mocks.
-// Instead, we test the handlers by verifying the mock expectations are set up correctly
-// and test the business logic through integration-style tests.
-func createTestBot(t *testing.T) (*Bot, *MockQuerier, *MockDiscordSession) {
+// createTestBot builds a bot on the in-memory fakes
+func createTestBot(t *testing.T) (*Bot, *fakedb.Querier, *fakediscord.Session) {
 	cfg := &config.Config{
 		LoggingChannelID:          "test-logging-channel",
 		TestGuildID:               "test-guild",
 		AllowedVoiceChannelIDsMap: map[string]struct{}{"test-vc": {}},
 	}
 
-	mockDB := new(MockQuerier)
-	mockSession := new(MockDiscordSession)
-
-	bot := &Bot{
-		session:                nil,
-		activeSessions:         make(map[string]time.Time),
-		LoggingChannelID:       cfg.LoggingChannelID,
-		testGuildID:            cfg.TestGuildID,
-		allowedVoiceChannelIDs: cfg.AllowedVoiceChannelIDsMap,
-		cfg:                    cfg,
-		streakService:          nil,
-		voiceEventChan:         make(chan func()),
-		shutdownChan:           make(chan struct{}),
-	}
+	db := fakedb.New()
+	fakeSession := fakediscord.New()
+
+	bot := newBot(fakeSession, fakeSession.State, db, cfg)
 
-	return bot, mockDB, mockSession
+	return bot, db, fakeSession
+}
+
+// seedStats records a user with the given totals, as if their sessions had ended
+func seedStats(t *testing.T, db *fakedb.Querier, userID, username string, total, daily time.Duration) {
+	t.Helper()
+	ctx := context.Background()
+	_, err := db.CreateUser(ctx, database.CreateUserParams{UserID: userID, Username: sql.NullString{String: username, Valid: true}})
+	require.NoError(t, err)
+	_, err = db.CreateOrUpdateUserStats(ctx, database.CreateOrUpdateUserStatsParams{
+		UserID:         userID,
+		TotalStudyMs:   sql.NullInt64{Int64: total.Milliseconds(), Valid: true},
+		DailyStudyMs:   sql.NullInt64{Int64: daily.Milliseconds(), Valid: true},
+		WeeklyStudyMs:  sql.NullInt64{Int64: total.Milliseconds(), Valid: true},
+		MonthlyStudyMs: sql.NullInt64{Int64: total.Milliseconds(), Valid: true},
+	})
+	require.NoError(t, err)
 }
 
 // Helper function to create a test interaction
@@ -256,141 +111,81 @@ func TestFormatDuration(t *testing.T) {
 }
 
 func TestHandleSlashStatsCommand_Success(t *testing.T) {
-	_, mockDB, _ := createTestBot(t)
-
-	userID := "test-user-123"
-	username := "testuser"
-
-	// Mock database responses
-	mockDB.On("GetUser", mock.Anything, userID).Return(database.User{
-		UserID:   userID,
-		Username: sql.NullString{String: username, Valid: true},
-	}, nil)
-
-	mockDB.On("GetUserStats", mock.Anything, userID).Return(database.UserStat{
-		UserID:         userID,
-		TotalStudyMs:   sql.NullInt64{Int64: 7200000, Valid: true}, // 2 hours
-		DailyStudyMs:   sql.NullInt64{Int64: 3600000, Valid: true}, // 1 hour
-		WeeklyStudyMs:  sql.NullInt64{Int64: 5400000, Valid: true}, // 1.5 hours
-		MonthlyStudyMs: sql.NullInt64{Int64: 7200000, Valid: true}, // 2 hours
-	}, nil)
-
-	// Test the actual bot method - we'll verify behavior through logs/state
-	// since we can't easily mock the session calls in the actual method
-	ctx := context.Background()
-
-	// Verify the database queries work as expected
-	user, err := mockDB.GetUser(ctx, userID)
-	assert.NoError(t, err)
-	assert.Equal(t, userID, user.UserID)
+	bot, db, session := createTestBot(t)
+	seedStats(t, db, "test-user-123", "testuser", 2*time.Hour, time.Hour)
 
-	stats, err := mockDB.GetUserStats(ctx, userID)
-	assert.NoError(t, err)
-	assert.Equal(t, int64(7200000), stats.TotalStudyMs.Int64)
+	bot.handleSlashStatsCommand(session, createTestInteraction("test-user-123", "testuser", "test-guild"))
 
-	// Verify all mocks were called as expected
-	mockDB.AssertExpectations(t)
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	require.Len(t, resp.Data.Embeds, 1)
+	embed := resp.Data.Embeds[0]
+	assert.Equal(t, "Study Stats for testuser", embed.Title)
+	assert.Equal(t, "2h 0m 0s", fieldValue(embed, "Total Study Time"))
+	assert.Equal(t, "1h 0m 0s", fieldValue(embed, "Today"))
 }
 
 func TestHandleSlashStatsCommand_UserNotFound_CreatesUser(t *testing.T) {
-	_, mockDB, _ := createTestBot(t)
-
-	userID := "new-user-123"
-	username := "newuser"
-
-	// Mock user not found, then successful creation
-	mockDB.On("GetUser", mock.Anything, userID).Return(database.User{}, sql.ErrNoRows)
-	mockDB.On("CreateUser", mock.Anything, mock.MatchedBy(func(params database.CreateUserParams) bool {
-		return params.UserID == userID && params.Username.String == username
-	})).Return(database.User{
-		UserID:   userID,
-		Username: sql.NullString{String: username, Valid: true},
-	}, nil)
-	// Note: GetUserStats is NOT mocked here because the test doesn't call the actual handler
-	// If we were calling handleSlashStatsCommand, we would need:
-	// mockDB.On("GetUserStats", mock.Anything, userID).Return(database.UserStat{}, sql.ErrNoRows)
-
-	// Test database interaction - simulate the flow that would happen in the handler
-	// Step 1: Try to get user (returns not found)
-	_, err := mockDB.GetUser(context.Background(), userID)
-	assert.Equal(t, sql.ErrNoRows, err)
-
-	// Step 2: Create the user
-	user, err := mockDB.CreateUser(context.Background(), database.CreateUserParams{
-		UserID:   userID,
-		Username: sql.NullString{String: username, Valid: true},
-	})
-	assert.NoError(t, err)
-	assert.Equal(t, userID, user.UserID)
+	bot, db, session := createTestBot(t)
+
+	bot.handleSlashStatsCommand(session, createTestInteraction("new-user-123", "newuser", "test-guild"))
 
-	// Verify the mocked database operations were called as expected
-	mockDB.AssertExpectations(t)
+	user, err := db.GetUser(context.Background(), "new-user-123")
+	require.NoError(t, err)
+	assert.Equal(t, "newuser", user.Username.String)
+	assert.Contains(t, session.LastResponse().Data.Content, "You haven't studied yet!")
 }
 
 func TestHandleSlashStatsCommand_InvalidUserID(t *testing.T) {
-	_, mockDB, _ := createTestBot(t)
+	bot, _, session := createTestBot(t)
+	i := createTestInteraction("", "", "test-guild")
+	i.Member = nil
 
-	// Verify that with no user info, database shouldn't be called
-	// This is a logic test - the actual command handler would return early
+	bot.handleSlashStatsCommand(session, i)
 
-	// Database should not be called for invalid user interactions
-	mockDB.AssertNotCalled(t, "GetUser")
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	assert.Equal(t, "Error: Could not identify user.", resp.Data.Content)
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, resp.Data.Flags)
 }
 
 func TestHandleSlashLeaderboardCommand_Success(t *testing.T) {
-	_, mockDB, _ := createTestBot(t)
-
-	// Mock leaderboard data
-	leaderboardData := []database.GetLeaderboardRow{
-		{
-			UserID:       "user1",
-			Username:     sql.NullString{String: "TopUser", Valid: true},
-			TotalStudyMs: sql.NullInt64{Int64: 10800000, Valid: true}, // 3 hours
-		},
-		{
-			UserID:       "user2",
-			Username:     sql.NullString{String: "SecondUser", Valid: true},
-			TotalStudyMs: sql.NullInt64{Int64: 7200000, Valid: true}, // 2 hours
-		},
-	}
+	bot, db, session := createTestBot(t)
+	seedStats(t, db, "user1", "TopUser", 3*time.Hour, 0)
+	seedStats(t, db, "user2", "SecondUser", 2*time.Hour, 0)
 
-	mockDB.On("GetLeaderboard", mock.Anything).Return(leaderboardData, nil)
+	bot.handleSlashLeaderboardCommand(session, createTestInteraction("viewer", "viewer", "test-guild"))
 
-	// Test database query
-	data, err := mockDB.GetLeaderboard(context.Background())
-	assert.NoError(t, err)
-	assert.Len(t, data, 2)
-	assert.Equal(t, "TopUser", data[0].Username.String)
-
-	// Verify all mocks were called as expected
-	mockDB.AssertExpectations(t)
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	require.Len(t, resp.Data.Embeds, 1)
+	fields := resp.Data.Embeds[0].Fields
+	require.Len(t, fields, 2)
+	assert.Equal(t, "1. TopUser", fields[0].Name)
+	assert.Equal(t, "Time Studied: 3h 0m 0s (<@user1>)", fields[0].Value)
+	assert.Equal(t, "2. SecondUser", fields[1].Name)
 }
 
 func TestHandleSlashLeaderboardCommand_EmptyLeaderboard(t *testing.T) {
-	_, mockDB, _ := createTestBot(t)
-
-	// Mock empty leaderboard
-	mockDB.On("GetLeaderboard", mock.Anything).Return([]database.GetLeaderboardRow{}, nil)
+	bot, _, session := createTestBot(t)
 
-	// Test database query
-	data, err := mockDB.GetLeaderboard(context.Background())
-	assert.NoError(t, err)
-	assert.Len(t, data, 0)
+	bot.handleSlashLeaderboardCommand(session, createTestInteraction("viewer", "viewer", "test-guild"))
 
-	// Verify all mocks were called as expected
-	mockDB.AssertExpectations(t)
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	assert.Empty(t, resp.Data.Embeds)
+	assert.Contains(t, resp.Data.Content, "No one is on the leaderboard yet!")
 }
 
 func TestHandleSlashHelpCommand_Success(t *testing.T) {
-	bot, mockDB, _ := createTestBot(t)
+	bot, _, session := createTestBot(t)
 
-	// Help command doesn't use database, just verify bot state
-	assert.NotNil(t, bot)
-	assert.Equal(t, "test-logging-channel", bot.LoggingChannelID)
+	bot.handleSlashHelpCommand(session, createTestInteraction("test-user-123", "testuser", "test-guild"))
 
-	// Database should not be called for help command
-	mockDB.AssertNotCalled(t, "GetUser")
-	mockDB.AssertNotCalled(t, "GetUserStats")
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	require.Len(t, resp.Data.Embeds, 1)
+	assert.Equal(t, "LockIn Bot Help", resp.Data.Embeds[0].Title)
 }
 
 func TestGetSessionStartTime(t *testing.T) {
@@ -405,7 +200,7 @@ func TestGetSessionStartTime(t *testing.T) {
 	assert.True(t, startTime.IsZero())
 
 	// Add user to active sessions
-	bot.activeSessions[userID] = expectedTime
+	bot.activeSessions[userID] = activeSession{StartTime: expectedTime}
 
 	// Test when user has active session
 	startTime, exists = bot.GetSessionStartTime(userID)
@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/commands"
+	"github.com/Skufu/LockIn-Bot/internal/discord"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Custom IDs for the session cleanup confirmation buttons.
+// The cutoff is appended to the confirm prefix as a Unix timestamp.
+const (
+	cleanupConfirmPrefix = "cleanup_confirm:"
+	cleanupCancelID      = "cleanup_cancel"
+)
+
+// Bounds for the older_than_days option of /cleanup-sessions
+var (
+	cleanupDaysMin float64 = 0
+	cleanupDaysMax float64 = 3650
+)
+
+// handleSlashCleanupSessionsCommand shows how many of the server's sessions a cleanup would remove
+// and asks for confirmation. Nothing is changed until the confirm button is clicked.
+func (b *Bot) handleSlashCleanupSessionsCommand(s discord.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+	if b.sessionService == nil {
+		b.logger.Error("SessionService not available for /cleanup-sessions command")
+		respondEphemeral(s, i, "Session service is currently unavailable.")
+		return
+	}
+
+	days := 0
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "older_than_days" {
+			days = int(opt.IntValue())
+		}
+	}
+	// Truncated to the second so the cutoff survives the round trip through the button's custom ID
+	before := b.clock.Now().AddDate(0, 0, -days).Truncate(time.Second)
+
+	preview, err := b.sessionService.PreviewCleanup(context.Background(), i.GuildID, before)
+	if err != nil {
+		b.logger.Error("Failed to preview session cleanup", "guild_id", i.GuildID, "error", err)
+		respondEphemeral(s, i, "Something went wrong while counting study sessions. Please try again later.")
+		return
+	}
+
+	kept := ""
+	if preview.OpenSessions > 0 {
+		kept = fmt.Sprintf("\n• **%d** sessions still in progress will be kept", preview.OpenSessions)
+	}
+	if preview.ClosedSessions == 0 {
+		respondEphemeral(s, i, fmt.Sprintf("Nothing to clean up: no finished sessions in this server started before <t:%d:f>.%s", before.Unix(), kept))
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("🧹 Study sessions in this server that started before <t:%d:f>:\n\n"+
+				"• **%d** finished sessions will be copied to the archive and then deleted%s\n\n"+
+				"User statistics and streaks are not changed. Continue?",
+				before.Unix(), preview.ClosedSessions, kept),
+			Flags: discordgo.MessageFlagsEphemeral,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{
+							Label:    "Archive and delete",
+							Style:    discordgo.DangerButton,
+							CustomID: cleanupConfirmPrefix + strconv.FormatInt(before.Unix(), 10),
+						},
+						discordgo.Button{
+							Label:    "Cancel",
+							Style:    discordgo.SecondaryButton,
+							CustomID: cleanupCancelID,
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		b.logger.Error("Failed to send cleanup confirmation", "guild_id", i.GuildID, "error", err)
+	}
+}
+
+// handleCleanupComponent handles clicks on the session cleanup confirmation buttons
+func (b *Bot) handleCleanupComponent(s discord.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+	if customID == cleanupCancelID {
+		updateComponentMessage(s, i, "Cancelled. Nothing was deleted.")
+		return
+	}
+
+	// Re-checked here because the permission could have changed since the command was run
+	if i.GuildID == "" || !b.memberCan(i, commands.PermissionAdjustStats) {
+		updateComponentMessage(s, i, "You don't have permission to clean up study sessions.")
+		return
+	}
+
+	unix, err := strconv.ParseInt(strings.TrimPrefix(customID, cleanupConfirmPrefix), 10, 64)
+	if err != nil {
+		b.logger.Warn("Invalid cleanup button", "custom_id", customID, "error", err)
+		updateComponentMessage(s, i, "This button is no longer valid. Nothing was deleted.")
+		return
+	}
+
+	if b.sessionService == nil {
+		b.logger.Error("SessionService not available for cleanup confirmation")
+		updateComponentMessage(s, i, "Session service is currently unavailable. Nothing was deleted.")
+		return
+	}
+
+	result, err := b.sessionService.CleanupSessions(context.Background(), service.CleanupRequest{
+		GuildID: i.GuildID,
+		ActorID: interactionUserID(i),
+		Before:  time.Unix(unix, 0),
+	})
+	if err != nil {
+		b.logger.Error("Failed to clean up study sessions", "guild_id", i.GuildID, "error", err)
+		updateComponentMessage(s, i, "Something went wrong while cleaning up. Nothing was deleted, please try again later.")
+		return
+	}
+
+	message := fmt.Sprintf("✅ Archived and deleted **%d** study sessions.", result.Deleted)
+	if result.OpenSessions > 0 {
+		message += fmt.Sprintf(" **%d** sessions still in progress were kept.", result.OpenSessions)
+	}
+	updateComponentMessage(s, i, message)
+}
@@ -0,0 +1,162 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/commands"
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/database/fakedb"
+	"github.com/Skufu/LockIn-Bot/internal/discord/fakediscord"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cleanupInteraction runs /cleanup-sessions as a moderator
+func cleanupInteraction(olderThanDays int) *discordgo.InteractionCreate {
+	i := moderatorInteraction("cleanup-sessions")
+	i.Data = discordgo.ApplicationCommandInteractionData{
+		Name:    "cleanup-sessions",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{intOption("older_than_days", olderThanDays)},
+	}
+	return i
+}
+
+// clickButton clicks the button at index n of the last response's first row, as the moderator
+func clickButton(t *testing.T, session *fakediscord.Session, n int) *discordgo.InteractionCreate {
+	t.Helper()
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	require.NotEmpty(t, resp.Data.Components, "the response has buttons")
+	button := resp.Data.Components[0].(discordgo.ActionsRow).Components[n].(discordgo.Button)
+
+	i := moderatorInteraction("")
+	i.Type = discordgo.InteractionMessageComponent
+	i.Data = discordgo.MessageComponentInteractionData{CustomID: button.CustomID, ComponentType: discordgo.ButtonComponent}
+	return i
+}
+
+// addGuildSession records a session in the guild; a zero end leaves it open and an empty
+// guild leaves it unscoped, like sessions recorded before guild scoping
+func addGuildSession(t *testing.T, db *fakedb.Querier, userID, guildID string, start, end time.Time) {
+	t.Helper()
+	ctx := context.Background()
+	s, err := db.CreateStudySession(ctx, database.CreateStudySessionParams{
+		UserID:    sql.NullString{String: userID, Valid: true},
+		GuildID:   sql.NullString{String: guildID, Valid: guildID != ""},
+		StartTime: start,
+	})
+	require.NoError(t, err)
+	if !end.IsZero() {
+		_, err = db.EndStudySession(ctx, database.EndStudySessionParams{
+			SessionID: s.SessionID,
+			EndTime:   sql.NullTime{Time: end, Valid: true},
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestCleanupSessions_PreviewThenArchiveAndDelete(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	now := time.Date(2026, 10, 14, 15, 0, 0, 0, service.GetManilaLocation())
+	b.clock = clock.NewFake(now)
+	db.Now = b.clock.Now
+	grantModerator(t, db, commands.PermissionAdjustStats)
+
+	addGuildSession(t, db, flowUserID, flowGuildID, now.AddDate(0, 0, -10), now.AddDate(0, 0, -10).Add(time.Hour))
+	addGuildSession(t, db, "user-2", flowGuildID, now.AddDate(0, 0, -1), now.AddDate(0, 0, -1).Add(time.Hour))
+	addGuildSession(t, db, "user-3", flowGuildID, now.AddDate(0, 0, -7), time.Time{})
+	addGuildSession(t, db, flowUserID, "guild-other", now.AddDate(0, 0, -10), now.AddDate(0, 0, -10).Add(time.Hour))
+
+	b.handleInteractionCreate(session, cleanupInteraction(0))
+	content := session.LastResponse().Data.Content
+	assert.Contains(t, content, "**2** finished sessions will be copied to the archive")
+	assert.Contains(t, content, "**1** sessions still in progress will be kept")
+	require.Len(t, db.StudySessions(), 4, "the preview changes nothing")
+
+	b.handleInteractionCreate(session, cleanupInteraction(5))
+	assert.Contains(t, session.LastResponse().Data.Content, "**1** finished sessions")
+
+	b.handleInteractionCreate(session, clickButton(t, session, 0))
+	assert.Contains(t, session.LastResponse().Data.Content, "Archived and deleted **1** study sessions")
+
+	sessions := db.StudySessions()
+	require.Len(t, sessions, 3)
+	for _, s := range sessions {
+		assert.False(t, s.GuildID.String == flowGuildID && s.StartTime.Before(now.AddDate(0, 0, -9)), "the old session was deleted")
+	}
+	archived := db.ArchivedStudySessions()
+	require.Len(t, archived, 1)
+	assert.Equal(t, flowUserID, archived[0].UserID.String)
+	assert.Equal(t, flowGuildID, archived[0].GuildID.String)
+	assert.Equal(t, int64(time.Hour/time.Millisecond), archived[0].DurationMs.Int64)
+	assert.Equal(t, flowUserID, archived[0].ArchivedBy.String)
+
+	entries := db.AuditLog()
+	require.NotEmpty(t, entries)
+	last := entries[len(entries)-1]
+	assert.Equal(t, service.AuditActionCleanupSessions, last.Action)
+	assert.Equal(t, flowGuildID, last.GuildID.String)
+}
+
+func TestPurgeOldSessions_ArchivesFinishedSessionsInEveryGuild(t *testing.T) {
+	b, db, _ := createFlowBot(t)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 14, 15, 0, 0, 0, service.GetManilaLocation())
+	old := now.AddDate(0, 0, -10)
+
+	addGuildSession(t, db, flowUserID, flowGuildID, old, old.Add(time.Hour))
+	addGuildSession(t, db, flowUserID, "", old, old.Add(time.Hour))
+	addGuildSession(t, db, "user-2", "guild-other", old, time.Time{})
+	addGuildSession(t, db, "user-3", flowGuildID, now.AddDate(0, 0, -1), now.AddDate(0, 0, -1).Add(time.Hour))
+
+	result, err := b.sessionService.PurgeOldSessions(ctx, now.AddDate(0, 0, -7))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Archived)
+	assert.Equal(t, int64(2), result.Deleted)
+
+	remaining := db.StudySessions()
+	require.Len(t, remaining, 2)
+	assert.False(t, remaining[0].EndTime.Valid, "the open session is kept")
+	archived := db.ArchivedStudySessions()
+	require.Len(t, archived, 2)
+	assert.False(t, archived[1].GuildID.Valid, "sessions without a guild are archived too")
+	assert.False(t, archived[1].ArchivedBy.Valid)
+
+	// /forget-me still reaches the unscoped archived session
+	_, err = service.NewAccountService(db).ForgetUser(ctx, service.ForgetUserRequest{TargetUserID: flowUserID, ActorID: flowUserID})
+	require.NoError(t, err)
+	assert.Empty(t, db.ArchivedStudySessions())
+}
+
+func TestCleanupSessions_ConfirmRechecksPermission(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	grantModerator(t, db, commands.PermissionAdjustStats)
+	start := time.Now().Add(-2 * time.Hour)
+	addGuildSession(t, db, flowUserID, flowGuildID, start, start.Add(time.Hour))
+
+	b.handleInteractionCreate(session, cleanupInteraction(0))
+	confirm := clickButton(t, session, 0)
+	cancel := clickButton(t, session, 1)
+
+	b.handleInteractionCreate(session, cancel)
+	assert.Equal(t, "Cancelled. Nothing was deleted.", session.LastResponse().Data.Content)
+
+	_, err := db.RevokeRoleCapability(ctx, database.RevokeRoleCapabilityParams{
+		GuildID:    flowGuildID,
+		RoleID:     moderatorRoleID,
+		Capability: string(commands.PermissionAdjustStats),
+	})
+	require.NoError(t, err)
+
+	b.handleInteractionCreate(session, confirm)
+	assert.Contains(t, session.LastResponse().Data.Content, "don't have permission")
+	assert.Len(t, db.StudySessions(), 1)
+	assert.Empty(t, db.ArchivedStudySessions())
+}
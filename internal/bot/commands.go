@@ -0,0 +1,477 @@
+package bot
+
+import (
+	"github.com/Skufu/LockIn-Bot/internal/commands"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+)
+
+// newCommandRegistry declares every command the bot offers: its slash command, text alias,
+// required permission and handler. handleReady registers the slash commands from it.
+func (b *Bot) newCommandRegistry(prefix string) *commands.Registry {
+	r := commands.NewRegistry(prefix, b.permissions)
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "stats",
+			Description: "Shows your study/voice channel time statistics.",
+		},
+		Alias:   "study",
+		Handler: b.handleSlashStatsCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "leaderboard",
+			Description: "Shows the study time leaderboard.",
+		},
+		Alias:   "leaderboard",
+		Handler: b.handleSlashLeaderboardCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "help",
+			Description: "Shows available commands and information about the bot.",
+		},
+		Alias:   "help",
+		Handler: b.handleSlashHelpCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "streak",
+			Description: "Check your current study streak!",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "show",
+					Description: "Show your current streak and today's progress.",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "calendar",
+					Description: "Show this month's streak days and your past streaks.",
+				},
+			},
+		},
+		Alias:   "streak",
+		Handler: b.handleSlashStreakCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "profile",
+			Description: "View your study profile and badges.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "The user whose profile you want to view (optional)",
+					Required:    false,
+				},
+			},
+		},
+		Handler: b.handleSlashProfileCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "badges",
+			Description: "View all available badges and your progress.",
+		},
+		Alias:   "badges",
+		Handler: b.handleSlashBadgesCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "now",
+			Description: "See who is studying right now.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "pin",
+					Description: "Moderator: pin a live copy here that updates every minute (false to stop updating it)",
+					Required:    false,
+				},
+			},
+		},
+		Handler: b.handleSlashNowCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "dashboard",
+			Description: "Get a private link to this server's web dashboard by DM.",
+		},
+		Handler: b.handleSlashDashboardCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "recap",
+			Description: "Weekly and monthly study recaps.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "view",
+					Description: "Show an archived recap for this server.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "period",
+							Description: "Weekly or monthly recap",
+							Required:    true,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Weekly", Value: service.RecapPeriodWeekly},
+								{Name: "Monthly", Value: service.RecapPeriodMonthly},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "ago",
+							Description: "How many recaps back to go (0 is the latest)",
+							Required:    false,
+							MinValue:    &recapMinAgo,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "dm",
+					Description: "Get a personal summary by DM whenever a recap is posted.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Whether to receive personal recap DMs",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "channel",
+					Description: "Moderator: post this server's recaps in this channel.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Post recaps here (false to stop posting them)",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		Subcommands: map[string]commands.Permission{
+			"channel": commands.PermissionManageChannels,
+		},
+		Handler: b.handleSlashRecapCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "notifications",
+			Description: "Choose where you get notified, or set quiet hours. Run without options to see your settings.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "streak_warnings",
+					Description: "Evening reminders that your streak is at risk",
+					Required:    false,
+					Choices:     deliveryChoices,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "daily_complete",
+					Description: "Messages when you finish today's activity",
+					Required:    false,
+					Choices:     deliveryChoices,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "achievements",
+					Description: "Badge unlock announcements",
+					Required:    false,
+					Choices:     deliveryChoices,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "session_summaries",
+					Description: "Study time posted when you leave voice",
+					Required:    false,
+					Choices:     deliveryChoices,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "quiet_start",
+					Description: "Hour quiet hours start (0-23, Manila time)",
+					Required:    false,
+					MinValue:    &quietHourMin,
+					MaxValue:    quietHourMax,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "quiet_end",
+					Description: "Hour quiet hours end (0-23, Manila time)",
+					Required:    false,
+					MinValue:    &quietHourMin,
+					MaxValue:    quietHourMax,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "clear_quiet_hours",
+					Description: "Turn quiet hours off",
+					Required:    false,
+				},
+			},
+		},
+		Handler: b.handleSlashNotificationsCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "reminders",
+			Description: "Choose when you're reminded that your streak is at risk.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "show",
+					Description: "Show your reminder times and the server's.",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "set",
+					Description: "Use your own reminder times in this server.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "times",
+							Description: "24-hour Manila times separated by commas, e.g. 18:00, 22:00",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "reset",
+					Description: "Go back to the server's reminder times.",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "server",
+					Description: "Admin: set the reminder times for everyone in this server.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "times",
+							Description: "24-hour Manila times separated by commas, e.g. 18:00, 22:00",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		Subcommands: map[string]commands.Permission{
+			"server": commands.PermissionAdmin,
+		},
+		Handler: b.handleSlashRemindersCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "streak-mode",
+			Description: "Admin: choose how streaks are counted in this server.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "Daily, weekdays only, or a weekly study goal",
+					Required:    false,
+					Choices:     streakModeChoices,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "weekly_hours",
+					Description: "Hours to study each week in weekly mode (default 5)",
+					Required:    false,
+					MinValue:    &weeklyHoursMin,
+					MaxValue:    weeklyHoursMax,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "repair",
+					Description: "Let members repair a broken daily streak by studying double the next day",
+					Required:    false,
+				},
+			},
+		},
+		Permission: commands.PermissionAdmin,
+		Handler:    b.handleSlashStreakModeCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "evaluate-streaks",
+			Description: "Moderator: evaluate streaks for any days this server missed.",
+		},
+		Permission: commands.PermissionRunBackfills,
+		Handler:    b.handleSlashEvaluateStreaksCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "forget-me",
+			Description: "Permanently delete all of your study data from LockIn Bot.",
+		},
+		Handler: b.handleSlashForgetMeCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "forget-user",
+			Description: "Admin: permanently delete a user ID's study data in this server.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "user_id",
+					Description: "The Discord user ID whose data should be deleted",
+					Required:    true,
+				},
+			},
+		},
+		Permission: commands.PermissionAdmin,
+		Handler:    b.handleSlashForgetUserCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "cleanup-sessions",
+			Description: "Moderator: archive and delete this server's finished study sessions. Stats are kept.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "older_than_days",
+					Description: "Only sessions that started at least this many days ago (default 0, every finished session)",
+					Required:    false,
+					MinValue:    &cleanupDaysMin,
+					MaxValue:    cleanupDaysMax,
+				},
+			},
+		},
+		Permission: commands.PermissionAdjustStats,
+		Handler:    b.handleSlashCleanupSessionsCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "admin",
+			Description: "Moderator: correct study time, review the audit log and manage API tokens.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "time",
+					Description: "Credit or take away study time the bot got wrong.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "add",
+							Description: "Credit study time, e.g. for a session the bot missed.",
+							Options:     adjustTimeOptions,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "remove",
+							Description: "Take study time away.",
+							Options:     adjustTimeOptions,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "audit",
+					Description: "Show the latest changes made in this server.",
+					Options:     auditFilterOptions,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "api-token",
+					Description: "Manage tokens for the server's REST API.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "create",
+							Description: "Create a token. It is shown only once.",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionString,
+									Name:        "name",
+									Description: "What the token is for, e.g. study dashboard",
+									Required:    true,
+									MaxLength:   service.MaxAPITokenNameLength,
+								},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "list",
+							Description: "Show this server's tokens.",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "revoke",
+							Description: "Stop a token from working.",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionInteger,
+									Name:        "id",
+									Description: "The token's ID, from /admin api-token list",
+									Required:    true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Permission: commands.PermissionAdmin,
+		Subcommands: map[string]commands.Permission{
+			"time":      commands.PermissionAdjustStats,
+			"audit":     commands.PermissionViewAuditLog,
+			"api-token": commands.PermissionAdmin,
+		},
+		Handler: b.handleSlashAdminCommand,
+	})
+
+	r.Register(commands.Command{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "permissions",
+			Description: "Admin: choose which roles can use moderator commands.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "grant",
+					Description: "Let a role use a capability.",
+					Options:     capabilityOptions,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "revoke",
+					Description: "Take a capability away from a role.",
+					Options:     capabilityOptions,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show which roles have each capability.",
+				},
+			},
+		},
+		Permission: commands.PermissionAdmin,
+		Handler:    b.handleSlashPermissionsCommand,
+	})
+
+	return r
+}
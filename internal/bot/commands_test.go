@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func chatMessage(userID, content string) *discordgo.MessageCreate {
+	return &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        "message-in",
+		ChannelID: "text-channel",
+		GuildID:   flowGuildID,
+		Content:   content,
+		Author:    &discordgo.User{ID: userID, Username: "alice"},
+		Member:    &discordgo.Member{},
+	}}
+}
+
+func TestHandleReady_RegistersEveryCommandInTheRegistry(t *testing.T) {
+	b, _, session := createFlowBot(t)
+
+	b.handleReady(session, &discordgo.Ready{User: &discordgo.User{ID: "app-id", Username: "LockIn"}})
+
+	registered := make(map[string]*discordgo.ApplicationCommand)
+	for _, cmd := range session.Commands() {
+		registered[cmd.Name] = cmd
+	}
+	assert.Len(t, registered, len(b.registry.Definitions()))
+	for _, name := range []string{"stats", "streak", "streak-mode", "forget-user", "cleanup-sessions"} {
+		assert.Contains(t, registered, name)
+	}
+	require.NotNil(t, registered["streak-mode"].DefaultMemberPermissions, "admin commands are hidden from members")
+	assert.Equal(t, int64(discordgo.PermissionAdministrator), *registered["streak-mode"].DefaultMemberPermissions)
+	assert.Nil(t, registered["stats"].DefaultMemberPermissions)
+	assert.Nil(t, registered["cleanup-sessions"].DefaultMemberPermissions, "roles can be granted moderator commands")
+}
+
+func TestTextCommand_RunsTheSlashHandler(t *testing.T) {
+	b, _, session := createFlowBot(t)
+	b.registry = b.newCommandRegistry("!")
+
+	b.registry.HandleMessage(session, chatMessage(flowUserID, "!HELP please"))
+
+	messages := session.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "text-channel", messages[0].ChannelID)
+	require.Len(t, messages[0].Embeds, 1)
+	assert.Equal(t, "LockIn Bot Help", messages[0].Embeds[0].Title)
+	assert.Contains(t, fieldValue(messages[0].Embeds[0], "Text Commands"), "`!study`")
+
+	// Not a command, an unknown alias, or sent by a bot: nothing happens
+	b.registry.HandleMessage(session, chatMessage(flowUserID, "help"))
+	b.registry.HandleMessage(session, chatMessage(flowUserID, "!unknown"))
+	botMessage := chatMessage("other-bot", "!help")
+	botMessage.Author.Bot = true
+	b.registry.HandleMessage(session, botMessage)
+	assert.Len(t, session.Messages(), 1)
+}
+
+func TestTextCommands_OffWithoutPrefix(t *testing.T) {
+	b, _, session := createFlowBot(t)
+	b.registry = b.newCommandRegistry("")
+
+	b.registry.HandleMessage(session, chatMessage(flowUserID, "!help"))
+
+	assert.Empty(t, session.Messages())
+	assert.Empty(t, b.registry.Aliases())
+}
+
+func TestAdminCommand_DeniedBeforeItsHandlerRuns(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	_, err := db.CreateStudySession(ctx, database.CreateStudySessionParams{
+		UserID:    sql.NullString{String: flowUserID, Valid: true},
+		StartTime: time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+
+	i := createTestInteraction(flowUserID, "alice", flowGuildID)
+	i.Data = discordgo.ApplicationCommandInteractionData{Name: "cleanup-sessions"}
+	b.handleInteractionCreate(session, i)
+
+	assert.Contains(t, session.LastResponse().Data.Content, "don't have permission")
+	_, err = db.GetActiveStudySession(ctx, sql.NullString{String: flowUserID, Valid: true})
+	assert.NoError(t, err, "the session was not deleted")
+}
@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/Skufu/LockIn-Bot/internal/dashboard"
+	"github.com/Skufu/LockIn-Bot/internal/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleSlashDashboardCommand handles /dashboard by DMing the member a sign-in link to the server's
+// web dashboard. The link is sent privately because anyone holding it can sign in until it expires.
+func (b *Bot) handleSlashDashboardCommand(s discord.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+	if b.dashboardLinks == nil {
+		respondEphemeral(s, i, "The web dashboard isn't set up for this bot.")
+		return
+	}
+	userID := interactionUserID(i)
+	link := b.dashboardLinks.Link(i.GuildID, userID, b.clock.Now())
+
+	channel, err := s.UserChannelCreate(userID)
+	if err == nil {
+		_, err = s.ChannelMessageSendComplex(channel.ID, &discordgo.MessageSend{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "📊 Your Study Dashboard",
+				URL:         link,
+				Description: fmt.Sprintf("[Open the dashboard](%s) to see the leaderboard, who's studying now and your profile.", link),
+				Color:       0x00AAFF,
+				Footer: &discordgo.MessageEmbedFooter{
+					Text: fmt.Sprintf("The link works for %d minutes and signs you in for %d hours. Don't share it.", int(dashboard.LinkLifetime.Minutes()), int(dashboard.SessionLifetime.Hours())),
+				},
+			}},
+		})
+	}
+	if err != nil {
+		b.logger.Warn("Failed to DM dashboard link", "user_id", userID, "guild_id", i.GuildID, "error", err)
+		respondEphemeral(s, i, "I couldn't DM you. Allow direct messages from server members and try again.")
+		return
+	}
+	respondEphemeral(s, i, "I've sent you a link to the dashboard by DM.")
+}
+
+// DashboardActiveSessions lists the sessions the bot is tracking in guildID, for the dashboard's
+// "Studying now" list to match /now
+func (b *Bot) DashboardActiveSessions(guildID string) []dashboard.ActiveSession {
+	b.activeSessionMu.Lock()
+	defer b.activeSessionMu.Unlock()
+
+	var sessions []dashboard.ActiveSession
+	for userID, active := range b.activeSessions {
+		if active.GuildID == guildID {
+			sessions = append(sessions, dashboard.ActiveSession{UserID: userID, StartTime: active.StartTime})
+		}
+	}
+	return sessions
+}
@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/dashboard"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dashboardInteraction() *discordgo.InteractionCreate {
+	i := createTestInteraction(flowUserID, "alice", flowGuildID)
+	i.Data = discordgo.ApplicationCommandInteractionData{Name: "dashboard"}
+	return i
+}
+
+func TestDashboardCommand_DMsASignedLink(t *testing.T) {
+	b, _, session := createFlowBot(t)
+	b.clock = clock.NewFake(time.Date(2026, 10, 16, 20, 0, 0, 0, service.GetManilaLocation()))
+
+	b.handleInteractionCreate(session, dashboardInteraction())
+	assert.Contains(t, session.LastResponse().Data.Content, "isn't set up")
+	assert.Empty(t, session.Messages())
+
+	links := dashboard.NewLinks("https://lockin.example.com", "0123456789abcdef0123456789abcdef")
+	b.SetDashboardLinks(links)
+	b.handleInteractionCreate(session, dashboardInteraction())
+
+	resp := session.LastResponse()
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, resp.Data.Flags)
+	assert.Contains(t, resp.Data.Content, "sent you a link")
+	assert.NotContains(t, resp.Data.Content, "https://", "the link is only sent by DM")
+
+	messages := session.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "dm-"+flowUserID, messages[0].ChannelID)
+	require.Len(t, messages[0].Embeds, 1)
+	link := messages[0].Embeds[0].URL
+	assert.Equal(t, links.Link(flowGuildID, flowUserID, b.clock.Now()), link)
+	assert.True(t, strings.HasPrefix(link, "https://lockin.example.com/dashboard/login?token="))
+}
+
+func TestDashboardCommand_ExplainsWhenDMsAreClosed(t *testing.T) {
+	b, _, session := createFlowBot(t)
+	b.SetDashboardLinks(dashboard.NewLinks("https://lockin.example.com", "0123456789abcdef0123456789abcdef"))
+	session.Errors["ChannelMessageSend"] = errors.New("HTTP 403 Forbidden, Cannot send messages to this user")
+
+	b.handleInteractionCreate(session, dashboardInteraction())
+	assert.Contains(t, session.LastResponse().Data.Content, "couldn't DM you")
+}
+
+func TestDashboardActiveSessions_MatchesTrackedSessionsInGuild(t *testing.T) {
+	b, _, session := createFlowBot(t)
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	b.activeSessions["user-elsewhere"] = activeSession{StartTime: b.clock.Now(), GuildID: "guild-2", ChannelID: "other-vc"}
+
+	sessions := b.DashboardActiveSessions(flowGuildID)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, flowUserID, sessions[0].UserID)
+	assert.Equal(t, b.activeSessions[flowUserID].StartTime, sessions[0].StartTime)
+}
@@ -0,0 +1,202 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Skufu/LockIn-Bot/internal/commands"
+	"github.com/Skufu/LockIn-Bot/internal/discord"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Custom ID prefixes for the account deletion confirmation buttons.
+// The target user ID is appended after the prefix.
+const (
+	forgetConfirmPrefix      = "forget_confirm:"       // /forget-me: the user's data in every server
+	forgetGuildConfirmPrefix = "forget_guild_confirm:" // /forget-user: the user's data in this server
+	forgetCancelPrefix       = "forget_cancel:"
+)
+
+// handleSlashForgetMeCommand handles the /forget-me slash command
+func (b *Bot) handleSlashForgetMeCommand(s discord.Session, i *discordgo.InteractionCreate) {
+	userID := interactionUserID(i)
+	if userID == "" {
+		b.logger.Error("Could not determine the user ID from the interaction for /forget-me command")
+		respondEphemeral(s, i, "Error: Could not identify user.")
+		return
+	}
+
+	b.respondWithForgetConfirmation(s, i, forgetConfirmPrefix, userID,
+		"⚠️ This will permanently delete **all** of your LockIn data: study sessions, stats, streaks and badges in every server.\n\nThis cannot be undone. Are you sure?")
+}
+
+// handleSlashForgetUserCommand handles the admin-only /forget-user slash command. Admins only manage
+// their own server, so it deletes the user's data in this server; only /forget-me deletes everything.
+func (b *Bot) handleSlashForgetUserCommand(s discord.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	targetUserID := ""
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "user_id" {
+			targetUserID = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	if !isSnowflake(targetUserID) {
+		respondEphemeral(s, i, "Please provide a valid Discord user ID (numbers only).")
+		return
+	}
+
+	b.respondWithForgetConfirmation(s, i, forgetGuildConfirmPrefix, targetUserID,
+		fmt.Sprintf("⚠️ This will permanently delete the LockIn data for <@%s> (`%s`) in this server: study sessions, daily activity, streaks, badges and reminders. Their data in other servers and their overall stats are kept.\n\nThis cannot be undone. Are you sure?", targetUserID, targetUserID))
+}
+
+// respondWithForgetConfirmation sends an ephemeral message with confirm/cancel buttons. confirmPrefix
+// picks what the confirm button deletes.
+func (b *Bot) respondWithForgetConfirmation(s discord.Session, i *discordgo.InteractionCreate, confirmPrefix, targetUserID, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{
+							Label:    "Delete",
+							Style:    discordgo.DangerButton,
+							CustomID: confirmPrefix + targetUserID,
+						},
+						discordgo.Button{
+							Label:    "Cancel",
+							Style:    discordgo.SecondaryButton,
+							CustomID: forgetCancelPrefix + targetUserID,
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		b.logger.Error("Failed to send forget confirmation", "user_id", targetUserID, "error", err)
+	}
+}
+
+// handleForgetComponent handles clicks on the account deletion confirmation buttons
+func (b *Bot) handleForgetComponent(s discord.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+	actorID := interactionUserID(i)
+
+	if strings.HasPrefix(customID, forgetCancelPrefix) {
+		updateComponentMessage(s, i, "Cancelled. Nothing was deleted.")
+		return
+	}
+
+	// /forget-me deletes the user's own data everywhere; /forget-user only this server's, and
+	// needs an administrator. Re-checked here because the permission could have changed since
+	// the command was run.
+	targetUserID, guildOnly := strings.CutPrefix(customID, forgetGuildConfirmPrefix)
+	if !guildOnly {
+		targetUserID = strings.TrimPrefix(customID, forgetConfirmPrefix)
+	}
+	allowed := actorID != "" && actorID == targetUserID
+	if guildOnly {
+		allowed = actorID != "" && i.GuildID != "" && b.memberCan(i, commands.PermissionAdmin)
+	}
+	if !allowed {
+		updateComponentMessage(s, i, "You don't have permission to delete this user's data.")
+		return
+	}
+
+	if b.accountService == nil {
+		b.logger.Error("AccountService not available for forget confirmation")
+		updateComponentMessage(s, i, "Account service is currently unavailable. Nothing was deleted.")
+		return
+	}
+
+	// Drop any in-memory session first so the leave handler doesn't recreate stats for a deleted user
+	b.activeSessionMu.Lock()
+	if active, ok := b.activeSessions[targetUserID]; ok && (!guildOnly || active.GuildID == i.GuildID) {
+		delete(b.activeSessions, targetUserID)
+	}
+	b.activeSessionMu.Unlock()
+
+	result, err := b.accountService.ForgetUser(context.Background(), service.ForgetUserRequest{
+		TargetUserID: targetUserID,
+		ActorID:      actorID,
+		GuildID:      i.GuildID,
+		GuildOnly:    guildOnly,
+	})
+	if err != nil {
+		b.logger.Error("Failed to delete user data", "user_id", targetUserID, "actor_id", actorID, "error", err)
+		updateComponentMessage(s, i, "Something went wrong while deleting the data. Nothing was deleted, please try again later.")
+		return
+	}
+
+	message := "✅ All of your LockIn data has been deleted."
+	if guildOnly {
+		message = fmt.Sprintf("✅ LockIn data for `%s` in this server has been deleted.", targetUserID)
+	}
+	if result.Total() == 0 {
+		message += " (There was no stored data to remove.)"
+	}
+	updateComponentMessage(s, i, message)
+}
+
+// updateComponentMessage replaces the message a button was attached to, removing its buttons
+func updateComponentMessage(s discord.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		slog.Error("Failed to update component message", "error", err)
+	}
+}
+
+// respondEphemeral sends a simple ephemeral text response
+func respondEphemeral(s discord.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error("Failed to send ephemeral response", "error", err)
+	}
+}
+
+// interactionUserID returns the ID of the user who triggered an interaction (guild or DM)
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// isSnowflake reports whether id looks like a Discord snowflake ID
+func isSnowflake(id string) bool {
+	if len(id) < 15 || len(id) > 21 {
+		return false
+	}
+	for _, ch := range id {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
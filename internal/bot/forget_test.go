@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/discord/fakediscord"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const forgetTargetID = "123456789012345678"
+
+// adminInteraction runs a command as a member with the Administrator permission
+func adminInteraction(name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	i := createTestInteraction("admin-1", "admin", flowGuildID)
+	i.Member.Permissions = discordgo.PermissionAdministrator
+	i.Data = discordgo.ApplicationCommandInteractionData{Name: name, Options: options}
+	return i
+}
+
+// confirmForget clicks the confirm button of the last forget prompt as member
+func confirmForget(t *testing.T, b *Bot, session *fakediscord.Session, member *discordgo.Member) {
+	t.Helper()
+	i := clickButton(t, session, 0)
+	i.Member = member
+	b.handleInteractionCreate(session, i)
+}
+
+func TestForgetUser_AdminOnlyDeletesThisServersData(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	b.SetAccountService(service.NewAccountService(db))
+	now := time.Now()
+
+	for _, guildID := range []string{flowGuildID, "guild-2"} {
+		_, err := db.StartDailyActivity(ctx, database.StartDailyActivityParams{
+			UserID:           forgetTargetID,
+			GuildID:          guildID,
+			LastActivityDate: sql.NullTime{Time: now, Valid: true},
+		})
+		require.NoError(t, err)
+		addGuildSession(t, db, forgetTargetID, guildID, now.Add(-2*time.Hour), now.Add(-time.Hour))
+	}
+	_, err := db.CreateOrUpdateUserStats(ctx, database.CreateOrUpdateUserStatsParams{
+		UserID:       forgetTargetID,
+		TotalStudyMs: sql.NullInt64{Int64: (2 * time.Hour).Milliseconds(), Valid: true},
+	})
+	require.NoError(t, err)
+
+	b.handleInteractionCreate(session, adminInteraction("forget-user", stringOption("user_id", forgetTargetID)))
+	assert.Contains(t, session.LastResponse().Data.Content, "in this server")
+
+	// A member who isn't an administrator can't use the admin's button
+	member := &discordgo.Member{User: &discordgo.User{ID: "user-2"}}
+	confirmForget(t, b, session, member)
+	assert.Contains(t, session.LastResponse().Data.Content, "don't have permission")
+	_, err = db.GetUserStreak(ctx, database.GetUserStreakParams{UserID: forgetTargetID, GuildID: flowGuildID})
+	require.NoError(t, err)
+
+	b.handleInteractionCreate(session, adminInteraction("forget-user", stringOption("user_id", forgetTargetID)))
+	admin := &discordgo.Member{User: &discordgo.User{ID: "admin-1"}, Permissions: discordgo.PermissionAdministrator}
+	confirmForget(t, b, session, admin)
+	assert.Contains(t, session.LastResponse().Data.Content, "in this server has been deleted")
+
+	_, err = db.GetUserStreak(ctx, database.GetUserStreakParams{UserID: forgetTargetID, GuildID: flowGuildID})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = db.GetUserStreak(ctx, database.GetUserStreakParams{UserID: forgetTargetID, GuildID: "guild-2"})
+	assert.NoError(t, err, "other servers' data is kept")
+	sessions := db.StudySessions()
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "guild-2", sessions[0].GuildID.String)
+	_, err = db.GetUserStats(ctx, forgetTargetID)
+	assert.NoError(t, err, "global stats are kept")
+}
+
+func TestForgetMe_DeletesEverything(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	b.SetAccountService(service.NewAccountService(db))
+	now := time.Now()
+	for _, guildID := range []string{flowGuildID, "guild-2"} {
+		addGuildSession(t, db, flowUserID, guildID, now.Add(-2*time.Hour), now.Add(-time.Hour))
+	}
+
+	i := createTestInteraction(flowUserID, "alice", flowGuildID)
+	i.Data = discordgo.ApplicationCommandInteractionData{Name: "forget-me"}
+	b.handleInteractionCreate(session, i)
+	confirmForget(t, b, session, i.Member)
+
+	assert.Contains(t, session.LastResponse().Data.Content, "All of your LockIn data has been deleted")
+	assert.Empty(t, db.StudySessions())
+}
@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/health"
+)
+
+const (
+	// gatewayStaleAfter is how long without a heartbeat ACK before the gateway counts as down.
+	// Discord asks for a heartbeat about every 41 seconds, so this allows a couple to be missed.
+	gatewayStaleAfter = 2 * time.Minute
+
+	// gatewaySlowLatency is the heartbeat round trip above which the gateway counts as degraded
+	gatewaySlowLatency = time.Second
+
+	// Voice tasks waiting on the worker before it counts as backed up, or as stuck
+	voiceQueueDegradedDepth = 10
+	voiceQueueDownDepth     = 100
+)
+
+// GatewayHealth reports whether the Discord gateway connection is up, from its heartbeats
+func (b *Bot) GatewayHealth(_ context.Context) health.Result {
+	dg := b.Session()
+	if dg == nil {
+		return health.Down("no Discord gateway session")
+	}
+
+	dg.RLock()
+	ready := dg.DataReady
+	lastAck := dg.LastHeartbeatAck
+	latency := dg.HeartbeatLatency()
+	dg.RUnlock()
+
+	if !ready {
+		return health.Down("gateway not connected")
+	}
+	if since := b.clock.Now().Sub(lastAck); since > gatewayStaleAfter {
+		return health.Down(fmt.Sprintf("no heartbeat ACK for %s", since.Round(time.Second)))
+	}
+	detail := fmt.Sprintf("heartbeat latency %s", latency.Round(time.Millisecond))
+	if latency > gatewaySlowLatency {
+		return health.Degraded(detail)
+	}
+	return health.OK(detail)
+}
+
+// VoiceQueueHealth reports how many voice tasks are waiting on the voice event worker
+func (b *Bot) VoiceQueueHealth(_ context.Context) health.Result {
+	depth := b.voiceQueueDepth.Load()
+	detail := fmt.Sprintf("%d tasks queued", depth)
+	switch {
+	case depth >= voiceQueueDownDepth:
+		return health.Down(detail)
+	case depth >= voiceQueueDegradedDepth:
+		return health.Degraded(detail)
+	}
+	return health.OK(detail)
+}
@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/health"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGatewayHealth(t *testing.T) {
+	b, _, _ := createFlowBot(t)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 14, 13, 0, 0, 0, time.UTC)
+	b.clock = clock.NewFake(now)
+
+	assert.Equal(t, health.StatusDown, b.GatewayHealth(ctx).Status, "the fake client has no gateway")
+
+	dg := &discordgo.Session{}
+	b.session = dg
+	assert.Equal(t, health.Down("gateway not connected"), b.GatewayHealth(ctx))
+
+	dg.DataReady = true
+	dg.LastHeartbeatSent = now.Add(-10 * time.Second)
+	dg.LastHeartbeatAck = dg.LastHeartbeatSent.Add(80 * time.Millisecond)
+	assert.Equal(t, health.OK("heartbeat latency 80ms"), b.GatewayHealth(ctx))
+
+	dg.LastHeartbeatAck = dg.LastHeartbeatSent.Add(3 * time.Second)
+	assert.Equal(t, health.StatusDegraded, b.GatewayHealth(ctx).Status)
+
+	dg.LastHeartbeatSent = now.Add(-5 * time.Minute)
+	dg.LastHeartbeatAck = dg.LastHeartbeatSent.Add(80 * time.Millisecond)
+	assert.Equal(t, health.StatusDown, b.GatewayHealth(ctx).Status, "no ACK for longer than a few heartbeats")
+}
+
+func TestVoiceQueueHealth_TracksTasksUntilTheWorkerRunsThem(t *testing.T) {
+	b, _, _ := createFlowBot(t)
+	ctx := context.Background()
+	assert.Equal(t, health.OK("0 tasks queued"), b.VoiceQueueHealth(ctx))
+
+	b.voiceQueueDepth.Store(voiceQueueDegradedDepth)
+	assert.Equal(t, health.StatusDegraded, b.VoiceQueueHealth(ctx).Status)
+	b.voiceQueueDepth.Store(voiceQueueDownDepth)
+	assert.Equal(t, health.StatusDown, b.VoiceQueueHealth(ctx).Status)
+	b.voiceQueueDepth.Store(0)
+
+	release := make(chan struct{})
+	go b.voiceEventWorker()
+	t.Cleanup(func() { close(b.shutdownChan) })
+	b.queueVoiceTask(func() { <-release })
+	assert.Equal(t, int64(1), b.voiceQueueDepth.Load(), "a running task still counts")
+	close(release)
+	assert.Eventually(t, func() bool { return b.voiceQueueDepth.Load() == 0 }, time.Second, time.Millisecond)
+}
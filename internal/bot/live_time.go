@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+)
+
+// leaderboardSize is how many users /leaderboard shows, matching the GetLeaderboard query's limit
+const leaderboardSize = 10
+
+// inProgressTime returns how long the user's current study session has been running.
+// That time is only written to user_stats when the session ends, so views add it on top.
+func (b *Bot) inProgressTime(userID string) time.Duration {
+	start, ok := b.GetSessionStartTime(userID)
+	if !ok {
+		return 0
+	}
+	if elapsed := b.clock.Now().Sub(start); elapsed > 0 {
+		return elapsed
+	}
+	return 0
+}
+
+// inProgressShares splits the user's running session like SessionService does when it ends, so
+// today's, this week's and this month's totals only gain the part studied inside each period
+func (b *Bot) inProgressShares(userID string) service.StudyShares {
+	start, ok := b.GetSessionStartTime(userID)
+	if !ok {
+		return service.StudyShares{}
+	}
+	return service.SessionShares(start, b.clock.Now(), service.GetManilaLocation())
+}
+
+// leaderboardEntry is one /leaderboard row with stored and running time kept apart for labelling
+type leaderboardEntry struct {
+	UserID     string
+	Username   string
+	Total      time.Duration // Stored in user_stats
+	InProgress time.Duration // From a session that hasn't ended yet
+}
+
+// liveLeaderboard merges running sessions into the stored leaderboard. Users currently studying
+// who aren't in the stored top 10 are looked up so they can climb in while their session runs.
+func (b *Bot) liveLeaderboard(ctx context.Context, rows []database.GetLeaderboardRow) []leaderboardEntry {
+	entries := make([]leaderboardEntry, 0, len(rows))
+	seen := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		username := "Unknown User"
+		if row.Username.Valid {
+			username = row.Username.String
+		}
+		entries = append(entries, leaderboardEntry{
+			UserID:     row.UserID,
+			Username:   username,
+			Total:      time.Duration(row.TotalStudyMs.Int64) * time.Millisecond,
+			InProgress: b.inProgressTime(row.UserID),
+		})
+		seen[row.UserID] = true
+	}
+
+	b.activeSessionMu.Lock()
+	var studying []string
+	for userID := range b.activeSessions {
+		if !seen[userID] {
+			studying = append(studying, userID)
+		}
+	}
+	b.activeSessionMu.Unlock()
+
+	for _, userID := range studying {
+		inProgress := b.inProgressTime(userID)
+		if inProgress <= 0 {
+			continue
+		}
+		entry := leaderboardEntry{UserID: userID, Username: "Unknown User", InProgress: inProgress}
+		if user, err := b.db.GetUser(ctx, userID); err == nil && user.Username.Valid {
+			entry.Username = user.Username.String
+		}
+		stats, err := b.db.GetUserStats(ctx, userID)
+		if err == nil {
+			entry.Total = time.Duration(stats.TotalStudyMs.Int64) * time.Millisecond
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			b.logger.ErrorContext(ctx, "Failed to get stats for live leaderboard", "user_id", userID, "error", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Total+entries[i].InProgress > entries[j].Total+entries[j].InProgress
+	})
+	if len(entries) > leaderboardSize {
+		entries = entries[:leaderboardSize]
+	}
+	return entries
+}
@@ -0,0 +1,174 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fieldValue returns the value of the embed field with the given name, or "" if there is none
+func fieldValue(embed *discordgo.MessageEmbed, name string) string {
+	for _, f := range embed.Fields {
+		if f.Name == name {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+func TestHandleSlashStatsCommand_IncludesSessionInProgress(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC))
+	b.clock = clk
+	db.Now = clk.Now
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	clk.Advance(3 * time.Hour)
+
+	b.handleSlashStatsCommand(session, createTestInteraction(flowUserID, "alice", flowGuildID))
+
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	require.Len(t, resp.Data.Embeds, 1)
+	embed := resp.Data.Embeds[0]
+	assert.Equal(t, "3h 0m 0s", fieldValue(embed, "Today"))
+	assert.Equal(t, "3h 0m 0s", fieldValue(embed, "Total Study Time"))
+	assert.Contains(t, fieldValue(embed, "⏳ In Progress"), "3h 0m 0s")
+
+	// Nothing partial is written to user_stats
+	_, err := db.GetUserStats(ctx, flowUserID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestHandleSlashProfileCommand_IncludesSessionInProgress(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC))
+	b.clock = clk
+	db.Now = clk.Now
+
+	_, err := db.CreateOrUpdateUserStats(ctx, database.CreateOrUpdateUserStatsParams{
+		UserID:       flowUserID,
+		TotalStudyMs: sql.NullInt64{Int64: time.Hour.Milliseconds(), Valid: true},
+	})
+	require.NoError(t, err)
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	clk.Advance(3 * time.Hour)
+
+	i := createTestInteraction(flowUserID, "alice", flowGuildID)
+	i.Data = discordgo.ApplicationCommandInteractionData{Name: "profile"}
+	b.handleSlashProfileCommand(session, i)
+
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	require.Len(t, resp.Data.Embeds, 1)
+	embed := resp.Data.Embeds[0]
+	assert.Equal(t, "4h 0m 0s", fieldValue(embed, "⏱️ Total Study Time"))
+	assert.Contains(t, fieldValue(embed, "⏳ In Progress"), "3h 0m 0s")
+
+	// Nothing partial is written to user_stats
+	stats, err := db.GetUserStats(ctx, flowUserID)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour.Milliseconds(), stats.TotalStudyMs.Int64)
+}
+
+func TestHandleSlashLeaderboardCommand_RanksSessionsInProgress(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC))
+	b.clock = clk
+	db.Now = clk.Now
+
+	_, err := db.CreateUser(ctx, database.CreateUserParams{UserID: "user-2", Username: sql.NullString{String: "bob", Valid: true}})
+	require.NoError(t, err)
+	_, err = db.CreateOrUpdateUserStats(ctx, database.CreateOrUpdateUserStatsParams{
+		UserID:       "user-2",
+		TotalStudyMs: sql.NullInt64{Int64: (2 * time.Hour).Milliseconds(), Valid: true},
+	})
+	require.NoError(t, err)
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	clk.Advance(3 * time.Hour)
+
+	b.handleSlashLeaderboardCommand(session, createTestInteraction("user-2", "bob", flowGuildID))
+
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	require.Len(t, resp.Data.Embeds, 1)
+	fields := resp.Data.Embeds[0].Fields
+	require.Len(t, fields, 2)
+	assert.Equal(t, "1. alice", fields[0].Name)
+	assert.Equal(t, "Time Studied: 3h 0m 0s (<@user-1>)\n⏳ includes 3h 0m 0s in progress", fields[0].Value)
+	assert.Equal(t, "2. bob", fields[1].Name)
+	assert.Equal(t, "Time Studied: 2h 0m 0s (<@user-2>)", fields[1].Value)
+}
+
+func TestHandleSlashStreakCommand_IncludesSessionInProgress(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 10, 14, 16, 15, 0, 0, time.UTC)) // 00:15 in Manila
+	b.clock = clk
+	db.Now = clk.Now
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	clk.Advance(30 * time.Minute)
+
+	// Set after joining so the join doesn't wait on the voice event worker
+	streakService := service.NewStreakService(db, db, nil, b.cfg)
+	streakService.SetBot(b)
+	streakService.SetClock(clk)
+	b.SetStreakService(streakService)
+
+	_, err := db.StartDailyActivity(ctx, database.StartDailyActivityParams{
+		UserID:            flowUserID,
+		GuildID:           flowGuildID,
+		LastActivityDate:  sql.NullTime{Time: service.StartOfDay(clk.Now(), service.GetManilaLocation()), Valid: true},
+		ActivityStartTime: sql.NullTime{Time: clk.Now(), Valid: true},
+	})
+	require.NoError(t, err)
+
+	b.handleSlashStreakCommand(session, createTestInteraction(flowUserID, "alice", flowGuildID))
+
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	require.Len(t, resp.Data.Embeds, 1)
+	assert.Equal(t, "30/1 minutes ✅\n⏳ includes 30 in progress", fieldValue(resp.Data.Embeds[0], "Today's Activity"))
+}
+
+func TestHandleSlashStreakCommand_IgnoresSessionInAnotherGuild(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	backdateSession(t, b, db, flowUserID, 30*time.Minute)
+
+	streakService := service.NewStreakService(db, db, nil, b.cfg)
+	streakService.SetBot(b)
+	b.SetStreakService(streakService)
+
+	// The user has a streak in guild-2 too, but is studying in guild-1
+	_, err := db.StartDailyActivity(ctx, database.StartDailyActivityParams{
+		UserID:            flowUserID,
+		GuildID:           "guild-2",
+		LastActivityDate:  sql.NullTime{Time: service.GetTodayManilaDate(), Valid: true},
+		ActivityStartTime: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	require.NoError(t, err)
+
+	b.handleSlashStreakCommand(session, createTestInteraction(flowUserID, "alice", "guild-2"))
+
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	require.Len(t, resp.Data.Embeds, 1)
+	assert.Equal(t, "0/1 minutes", fieldValue(resp.Data.Embeds[0], "Today's Activity"))
+}
@@ -0,0 +1,45 @@
+package bot
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Skufu/LockIn-Bot/internal/metrics"
+	"github.com/bwmarrin/discordgo"
+)
+
+// discordErrorCounter counts failed Discord REST API requests in metrics.DiscordAPIErrors.
+// It sits under every request the session makes, including the ones made by services.
+type discordErrorCounter struct {
+	next http.RoundTripper
+}
+
+func (t discordErrorCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		metrics.DiscordAPIErrors.With("network").Inc()
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		metrics.DiscordAPIErrors.With(strconv.Itoa(resp.StatusCode)).Inc()
+	}
+	return resp, nil
+}
+
+// countDiscordErrors makes the session's HTTP client report failed requests
+func countDiscordErrors(dg *discordgo.Session) {
+	next := dg.Client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	dg.Client.Transport = discordErrorCounter{next: next}
+}
+
+// reportActiveSessions points the active sessions gauge at this bot's in-memory tracker
+func (b *Bot) reportActiveSessions() {
+	metrics.ActiveSessions.SetFunc(func() float64 {
+		b.activeSessionMu.Lock()
+		defer b.activeSessionMu.Unlock()
+		return float64(len(b.activeSessions))
+	})
+}
@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Skufu/LockIn-Bot/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestDiscordErrorCounter_CountsFailuresByStatus(t *testing.T) {
+	status := http.StatusOK
+	counter := discordErrorCounter{next: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		if status == 0 {
+			return nil, errors.New("connection reset")
+		}
+		rec := httptest.NewRecorder()
+		rec.WriteHeader(status)
+		return rec.Result(), nil
+	})}
+	tooMany := metrics.DiscordAPIErrors.With("429").Value()
+	network := metrics.DiscordAPIErrors.With("network").Value()
+	ok := metrics.DiscordAPIErrors.With("200").Value()
+
+	req := httptest.NewRequest(http.MethodGet, "https://discord.com/api/v9/users/@me", nil)
+	for _, status = range []int{http.StatusOK, http.StatusTooManyRequests, 0} {
+		resp, err := counter.RoundTrip(req)
+		if status == 0 {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, status, resp.StatusCode)
+	}
+
+	assert.Equal(t, tooMany+1, metrics.DiscordAPIErrors.With("429").Value())
+	assert.Equal(t, network+1, metrics.DiscordAPIErrors.With("network").Value())
+	assert.Equal(t, ok, metrics.DiscordAPIErrors.With("200").Value(), "successful requests aren't errors")
+}
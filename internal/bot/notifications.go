@@ -0,0 +1,167 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Skufu/LockIn-Bot/internal/discord"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Bounds for the quiet hour options of /notifications
+var (
+	quietHourMin float64 = 0
+	quietHourMax float64 = 23
+)
+
+// deliveryChoices are the options offered for each notification topic
+var deliveryChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: "Channel", Value: service.DeliveryChannel},
+	{Name: "DM", Value: service.DeliveryDM},
+	{Name: "Off", Value: service.DeliveryOff},
+}
+
+// notificationTopics lists the /notifications topic options in display order
+var notificationTopics = []struct {
+	option string
+	label  string
+}{
+	{service.PreferenceStreakWarnings, "⚠️ Streak warnings"},
+	{service.PreferenceDailyComplete, "✅ Daily completion"},
+	{service.PreferenceAchievements, "🏆 Achievements"},
+	{service.PreferenceSessionSummaries, "⏱️ Session summaries"},
+}
+
+// handleSlashNotificationsCommand shows or updates the user's notification preferences
+func (b *Bot) handleSlashNotificationsCommand(s discord.Session, i *discordgo.InteractionCreate) {
+	if b.notificationService == nil {
+		b.logger.Error("NotificationService not available for /notifications command")
+		respondEphemeral(s, i, "Notification settings are currently unavailable.")
+		return
+	}
+
+	ctx := context.Background()
+	userID := interactionUserID(i)
+	prefs, err := b.notificationService.Preferences(ctx, userID)
+	if err != nil {
+		b.logger.Error("Failed to load notification preferences", "user_id", userID, "error", err)
+		respondEphemeral(s, i, "Something went wrong while loading your settings. Please try again later.")
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	if len(options) > 0 {
+		if msg := applyNotificationOptions(&prefs, options); msg != "" {
+			respondEphemeral(s, i, msg)
+			return
+		}
+		if err := b.notificationService.SetPreferences(ctx, userID, prefs); err != nil {
+			b.logger.Error("Failed to save notification preferences", "user_id", userID, "error", err)
+			respondEphemeral(s, i, "Something went wrong while saving your settings. Please try again later.")
+			return
+		}
+	}
+
+	content := ""
+	if len(options) > 0 {
+		content = "Your notification settings were saved."
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Embeds:  []*discordgo.MessageEmbed{notificationPreferencesEmbed(prefs)},
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.logger.Error("Failed to send /notifications response", "error", err)
+	}
+}
+
+// applyNotificationOptions copies the command options onto prefs. It returns a message for
+// the user if the options can't be applied together.
+func applyNotificationOptions(prefs *service.NotificationPreferences, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	var start, end *int
+	clearQuiet := false
+
+	for _, opt := range options {
+		switch opt.Name {
+		case "quiet_start":
+			h := int(opt.IntValue())
+			start = &h
+		case "quiet_end":
+			h := int(opt.IntValue())
+			end = &h
+		case "clear_quiet_hours":
+			clearQuiet = opt.BoolValue()
+		default:
+			if err := prefs.SetDelivery(opt.Name, opt.StringValue()); err != nil {
+				return "Unknown notification option."
+			}
+		}
+	}
+
+	if clearQuiet {
+		if start != nil || end != nil {
+			return "Choose either new quiet hours or `clear_quiet_hours`, not both."
+		}
+		prefs.QuietStartHour, prefs.QuietEndHour = nil, nil
+		return ""
+	}
+	if start == nil && end == nil {
+		return ""
+	}
+
+	// Changing one end of existing quiet hours keeps the other
+	if start == nil {
+		start = prefs.QuietStartHour
+	}
+	if end == nil {
+		end = prefs.QuietEndHour
+	}
+	if start == nil || end == nil {
+		return "Please set both `quiet_start` and `quiet_end` to turn on quiet hours."
+	}
+	if *start == *end {
+		return "Quiet hours need different start and end hours."
+	}
+	prefs.QuietStartHour, prefs.QuietEndHour = start, end
+	return ""
+}
+
+// notificationPreferencesEmbed shows a user's current notification settings
+func notificationPreferencesEmbed(prefs service.NotificationPreferences) *discordgo.MessageEmbed {
+	var sb strings.Builder
+	for _, topic := range notificationTopics {
+		sb.WriteString(fmt.Sprintf("%s: **%s**\n", topic.label, deliveryLabel(prefs.Delivery(topic.option))))
+	}
+
+	quiet := "Off"
+	if prefs.QuietStartHour != nil && prefs.QuietEndHour != nil {
+		quiet = fmt.Sprintf("%02d:00 – %02d:00 Manila time. Notifications wait until they end.", *prefs.QuietStartHour, *prefs.QuietEndHour)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "🔔 Your Notifications",
+		Description: sb.String(),
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "🌙 Quiet hours", Value: quiet},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "DMs need to be open for this server."},
+	}
+}
+
+func deliveryLabel(delivery string) string {
+	switch delivery {
+	case service.DeliveryDM:
+		return "DM"
+	case service.DeliveryOff:
+		return "Off"
+	default:
+		return "Channel"
+	}
+}
@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// notificationsInteraction builds a /notifications interaction with the given options
+func notificationsInteraction(userID string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	i := createTestInteraction(userID, "alice", flowGuildID)
+	i.Data = discordgo.ApplicationCommandInteractionData{Name: "notifications", Options: options}
+	return i
+}
+
+func stringOption(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+}
+
+func intOption(name string, value int) *discordgo.ApplicationCommandInteractionDataOption {
+	// Discord sends numbers as JSON, so they arrive as float64
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionInteger, Value: float64(value)}
+}
+
+func boolOption(name string, value bool) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionBoolean, Value: value}
+}
+
+func TestVoiceFlow_NotificationPreferencesRouteAnnouncements(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	clk := clock.NewFake(time.Date(2026, 10, 14, 13, 0, 0, 0, service.GetManilaLocation()))
+	b.clock = clk
+	db.Now = clk.Now
+
+	b.handleSlashNotificationsCommand(session, notificationsInteraction(flowUserID,
+		stringOption(service.PreferenceAchievements, service.DeliveryDM),
+		stringOption(service.PreferenceSessionSummaries, service.DeliveryOff),
+		stringOption(service.PreferenceDailyComplete, service.DeliveryOff),
+	))
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, resp.Data.Flags)
+	require.Len(t, resp.Data.Embeds, 1)
+	assert.Contains(t, resp.Data.Embeds[0].Description, "🏆 Achievements: **DM**")
+	assert.Contains(t, resp.Data.Embeds[0].Description, "⚠️ Streak warnings: **Channel**")
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	clk.Advance(90 * time.Minute)
+	b.handleVoiceStateUpdate(session, leaveEvent(flowUserID, flowChannelID))
+
+	// The badge goes to DMs, and nothing is queued for the topics that are off
+	keys := dedupeKeys(db.Notifications())
+	assert.NotContains(t, keys, "session_end:1")
+	assert.NotContains(t, keys, "streak_daily_complete:guild-1:user-1:2026-10-14")
+	require.Contains(t, keys, "achievement:guild-1:user-1:getting_started")
+	for _, n := range db.Notifications() {
+		if n.DedupeKey == "achievement:guild-1:user-1:getting_started" {
+			var payload map[string]any
+			require.NoError(t, json.Unmarshal(n.Payload, &payload))
+			assert.Equal(t, true, payload["directMessage"])
+		}
+	}
+
+	// Turning badges off marks them notified so they aren't queued again on startup
+	b.handleSlashNotificationsCommand(session, notificationsInteraction(flowUserID,
+		stringOption(service.PreferenceAchievements, service.DeliveryOff),
+	))
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	clk.Advance(10 * time.Hour)
+	b.handleVoiceStateUpdate(session, leaveEvent(flowUserID, flowChannelID))
+
+	earned := db.UserAchievements(flowUserID)
+	require.Greater(t, len(earned), 1)
+	for _, ua := range earned {
+		if ua.AchievementID == "getting_started" {
+			continue
+		}
+		assert.True(t, ua.Notified.Bool, ua.AchievementID)
+		assert.NotContains(t, dedupeKeys(db.Notifications()), "achievement:guild-1:user-1:"+ua.AchievementID)
+	}
+}
+
+func TestHandleSlashNotificationsCommand_QuietHours(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+
+	// One end alone can't turn quiet hours on
+	b.handleSlashNotificationsCommand(session, notificationsInteraction(flowUserID, intOption("quiet_start", 22)))
+	assert.Contains(t, session.LastResponse().Data.Content, "set both")
+
+	b.handleSlashNotificationsCommand(session, notificationsInteraction(flowUserID, intOption("quiet_start", 22), intOption("quiet_end", 7)))
+	prefs, err := b.notificationService.Preferences(ctx, flowUserID)
+	require.NoError(t, err)
+	require.NotNil(t, prefs.QuietStartHour)
+	assert.Equal(t, 22, *prefs.QuietStartHour)
+	assert.Equal(t, 7, *prefs.QuietEndHour)
+
+	// Later, only the end is moved
+	b.handleSlashNotificationsCommand(session, notificationsInteraction(flowUserID, intOption("quiet_end", 6)))
+	row, err := db.GetNotificationPreferences(ctx, flowUserID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(22), row.QuietStartHour.Int32)
+	assert.Equal(t, int32(6), row.QuietEndHour.Int32)
+
+	// Running it without options just shows the settings
+	b.handleSlashNotificationsCommand(session, notificationsInteraction(flowUserID))
+	resp := session.LastResponse()
+	require.Len(t, resp.Data.Embeds, 1)
+	assert.Contains(t, fieldValue(resp.Data.Embeds[0], "🌙 Quiet hours"), "22:00 – 06:00")
+
+	b.handleSlashNotificationsCommand(session, notificationsInteraction(flowUserID, &discordgo.ApplicationCommandInteractionDataOption{
+		Name: "clear_quiet_hours", Type: discordgo.ApplicationCommandOptionBoolean, Value: true,
+	}))
+	prefs, err = b.notificationService.Preferences(ctx, flowUserID)
+	require.NoError(t, err)
+	assert.Nil(t, prefs.QuietStartHour)
+}
@@ -0,0 +1,263 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/commands"
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/discord"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+)
+
+// liveStatusInterval is how often pinned live status messages are refreshed
+const liveStatusInterval = time.Minute
+
+// maxNowEntries caps how many studiers are listed so the embed stays under Discord's size limit
+const maxNowEntries = 25
+
+// nowEntry is one user studying right now
+type nowEntry struct {
+	UserID    string
+	ChannelID string
+	Elapsed   time.Duration
+	Today     time.Duration // Today's stored total plus the running session's share of today
+}
+
+// studyingNow lists everyone with an active session in the guild, longest session first
+func (b *Bot) studyingNow(ctx context.Context, guildID string) []nowEntry {
+	now := b.clock.Now()
+	today := func(start time.Time) time.Duration {
+		return service.SessionShares(start, now, service.GetManilaLocation()).Day
+	}
+
+	b.activeSessionMu.Lock()
+	var entries []nowEntry
+	for userID, active := range b.activeSessions {
+		if active.GuildID != guildID {
+			continue
+		}
+		entries = append(entries, nowEntry{UserID: userID, ChannelID: active.ChannelID, Elapsed: now.Sub(active.StartTime), Today: today(active.StartTime)})
+	}
+	b.activeSessionMu.Unlock()
+
+	for i := range entries {
+		stats, err := b.db.GetUserStats(ctx, entries[i].UserID)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				b.logger.ErrorContext(ctx, "Failed to get stats for /now", "user_id", entries[i].UserID, "guild_id", guildID, "error", err)
+			}
+			continue
+		}
+		entries[i].Today += time.Duration(stats.DailyStudyMs.Int64) * time.Millisecond
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Elapsed != entries[j].Elapsed {
+			return entries[i].Elapsed > entries[j].Elapsed
+		}
+		return entries[i].UserID < entries[j].UserID
+	})
+	return entries
+}
+
+// buildNowEmbed renders who is studying in a guild right now
+func (b *Bot) buildNowEmbed(ctx context.Context, guildID string) *discordgo.MessageEmbed {
+	entries := b.studyingNow(ctx, guildID)
+
+	description := "Nobody is studying right now. Join a study channel to get started!"
+	if len(entries) > 0 {
+		var lines []string
+		for i, e := range entries {
+			if i == maxNowEntries {
+				lines = append(lines, fmt.Sprintf("…and %d more", len(entries)-maxNowEntries))
+				break
+			}
+			lines = append(lines, fmt.Sprintf("<@%s> in <#%s> · **%s** (today: %s)", e.UserID, e.ChannelID, formatDuration(e.Elapsed), formatDuration(e.Today)))
+		}
+		description = strings.Join(lines, "\n")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "📚 Studying Now",
+		Description: description,
+		Color:       0x00AAFF,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d studying · LockIn Bot", len(entries))},
+		Timestamp:   b.clock.Now().Format(time.RFC3339),
+	}
+}
+
+// handleSlashNowCommand handles the /now slash command. Members who can manage channels can pass pin
+// to keep a live copy pinned in the channel.
+func (b *Bot) handleSlashNowCommand(s discord.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name != "pin" {
+			continue
+		}
+		if !b.memberCan(i, commands.PermissionManageChannels) {
+			respondEphemeral(s, i, "You don't have permission to manage the live status message.")
+			return
+		}
+		if opt.BoolValue() {
+			b.pinLiveStatus(s, i)
+		} else {
+			b.unpinLiveStatus(s, i)
+		}
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{b.buildNowEmbed(context.Background(), i.GuildID)},
+		},
+	})
+	if err != nil {
+		b.logger.Error("Failed to send /now response", "error", err)
+	}
+}
+
+// pinLiveStatus posts a live status message in the interaction's channel, pins it and replaces the guild's previous one
+func (b *Bot) pinLiveStatus(s discord.Session, i *discordgo.InteractionCreate) {
+	ctx := context.Background()
+
+	msg, err := s.ChannelMessageSendComplex(i.ChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{b.buildNowEmbed(ctx, i.GuildID)},
+	})
+	if err != nil {
+		b.logger.Error("Failed to post live status message", "guild_id", i.GuildID, "channel_id", i.ChannelID, "error", err)
+		respondEphemeral(s, i, "I couldn't post in this channel. Check that I can send messages here.")
+		return
+	}
+	if err := s.ChannelMessagePin(msg.ChannelID, msg.ID); err != nil {
+		// The message still updates, it just isn't pinned
+		b.logger.Error("Failed to pin live status message", "guild_id", i.GuildID, "message_id", msg.ID, "channel_id", msg.ChannelID, "error", err)
+	}
+
+	if previous, err := b.db.GetLiveStatusMessage(ctx, i.GuildID); err == nil {
+		if err := s.ChannelMessageUnpin(previous.ChannelID, previous.MessageID); err != nil {
+			b.logger.Warn("Could not unpin previous live status message", "guild_id", i.GuildID, "message_id", previous.MessageID, "error", err)
+		}
+	}
+
+	err = b.db.UpsertLiveStatusMessage(ctx, database.UpsertLiveStatusMessageParams{
+		GuildID:   i.GuildID,
+		ChannelID: msg.ChannelID,
+		MessageID: msg.ID,
+	})
+	if err != nil {
+		b.logger.Error("Failed to save live status message", "guild_id", i.GuildID, "error", err)
+		respondEphemeral(s, i, "Something went wrong while saving the live status message. Please try again later.")
+		return
+	}
+
+	b.recordAudit(ctx, service.AuditEntry{
+		GuildID: i.GuildID,
+		ActorID: interactionUserID(i),
+		Action:  service.AuditActionPinLiveStatus,
+		Details: map[string]any{"channelId": msg.ChannelID, "messageId": msg.ID},
+	})
+	respondEphemeral(s, i, "📌 Pinned a live status message. It updates every minute and whenever someone joins or leaves.")
+}
+
+// unpinLiveStatus stops updating the guild's live status message and unpins it
+func (b *Bot) unpinLiveStatus(s discord.Session, i *discordgo.InteractionCreate) {
+	ctx := context.Background()
+
+	previous, err := b.db.GetLiveStatusMessage(ctx, i.GuildID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondEphemeral(s, i, "There is no live status message in this server.")
+		return
+	}
+	if err == nil {
+		_, err = b.db.DeleteLiveStatusMessage(ctx, i.GuildID)
+	}
+	if err != nil {
+		b.logger.Error("Failed to remove live status message", "guild_id", i.GuildID, "error", err)
+		respondEphemeral(s, i, "Something went wrong while removing the live status message. Please try again later.")
+		return
+	}
+
+	if err := s.ChannelMessageUnpin(previous.ChannelID, previous.MessageID); err != nil {
+		b.logger.Warn("Could not unpin live status message", "guild_id", i.GuildID, "message_id", previous.MessageID, "error", err)
+	}
+	b.recordAudit(ctx, service.AuditEntry{
+		GuildID: i.GuildID,
+		ActorID: interactionUserID(i),
+		Action:  service.AuditActionUnpinLiveStatus,
+		Details: map[string]any{"channelId": previous.ChannelID, "messageId": previous.MessageID},
+	})
+	respondEphemeral(s, i, "The live status message will no longer be updated.")
+}
+
+// requestLiveStatusRefresh asks the live status loop to refresh soon, e.g. after a join or leave.
+// Requests made while one is already pending are merged.
+func (b *Bot) requestLiveStatusRefresh() {
+	select {
+	case b.liveStatusRefresh <- struct{}{}:
+	default:
+	}
+}
+
+// liveStatusLoop refreshes pinned live status messages every minute and on request until shutdown
+func (b *Bot) liveStatusLoop() {
+	ticker := time.NewTicker(liveStatusInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.liveStatusRefresh:
+		case <-b.shutdownChan:
+			return
+		}
+		b.refreshLiveStatusMessages(context.Background())
+	}
+}
+
+// refreshLiveStatusMessages edits every guild's live status message. Messages that were deleted
+// in Discord are forgotten so they aren't retried forever.
+func (b *Bot) refreshLiveStatusMessages(ctx context.Context) {
+	messages, err := b.db.GetLiveStatusMessages(ctx)
+	if err != nil {
+		b.logger.ErrorContext(ctx, "Failed to load live status messages", "error", err)
+		return
+	}
+
+	for _, m := range messages {
+		embeds := []*discordgo.MessageEmbed{b.buildNowEmbed(ctx, m.GuildID)}
+		edit := discordgo.NewMessageEdit(m.ChannelID, m.MessageID)
+		edit.Embeds = &embeds
+
+		if _, err := b.session.ChannelMessageEditComplex(edit); err != nil {
+			if isUnknownMessageError(err) {
+				b.logger.InfoContext(ctx, "Live status message no longer exists, removing it", "guild_id", m.GuildID, "message_id", m.MessageID)
+				if _, delErr := b.db.DeleteLiveStatusMessage(ctx, m.GuildID); delErr != nil {
+					b.logger.ErrorContext(ctx, "Failed to remove live status message", "guild_id", m.GuildID, "error", delErr)
+				}
+				continue
+			}
+			b.logger.ErrorContext(ctx, "Failed to update live status message", "guild_id", m.GuildID, "message_id", m.MessageID, "error", err)
+		}
+	}
+}
+
+// isUnknownMessageError reports whether Discord rejected a request because the message or its channel is gone
+func isUnknownMessageError(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Message == nil {
+		return false
+	}
+	return restErr.Message.Code == discordgo.ErrCodeUnknownMessage || restErr.Message.Code == discordgo.ErrCodeUnknownChannel
+}
@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nowInteraction builds a /now interaction in the flow guild, optionally with the pin option
+func nowInteraction(userID string, admin bool, pin *bool) *discordgo.InteractionCreate {
+	i := createTestInteraction(userID, "alice", flowGuildID)
+	i.ChannelID = "text-channel"
+	if admin {
+		i.Member.Permissions = discordgo.PermissionAdministrator
+	}
+	data := discordgo.ApplicationCommandInteractionData{Name: "now"}
+	if pin != nil {
+		data.Options = []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "pin", Type: discordgo.ApplicationCommandOptionBoolean, Value: *pin},
+		}
+	}
+	i.Data = data
+	return i
+}
+
+func TestHandleSlashNowCommand_ListsActiveStudiersInGuild(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC))
+	b.clock = clk
+	db.Now = clk.Now
+
+	// An earlier 30 minute session today
+	_, err := db.CreateOrUpdateUserStats(ctx, database.CreateOrUpdateUserStatsParams{
+		UserID:       flowUserID,
+		TotalStudyMs: sql.NullInt64{Int64: (30 * time.Minute).Milliseconds(), Valid: true},
+		DailyStudyMs: sql.NullInt64{Int64: (30 * time.Minute).Milliseconds(), Valid: true},
+	})
+	require.NoError(t, err)
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	other := joinEvent("user-2", flowChannelID)
+	other.GuildID = "guild-2"
+	b.handleVoiceStateUpdate(session, other)
+	clk.Advance(20 * time.Minute)
+
+	b.handleSlashNowCommand(session, nowInteraction(flowUserID, false, nil))
+
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	require.Len(t, resp.Data.Embeds, 1)
+	embed := resp.Data.Embeds[0]
+	assert.Equal(t, "<@user-1> in <#study-vc> · **20m 0s** (today: 50m 0s)", embed.Description)
+	assert.Equal(t, "1 studying · LockIn Bot", embed.Footer.Text)
+}
+
+func TestHandleSlashNowCommand_PinRequiresAdmin(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	pin := true
+
+	b.handleSlashNowCommand(session, nowInteraction(flowUserID, false, &pin))
+
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Data.Content, "permission")
+	assert.Empty(t, session.Messages())
+	_, err := db.GetLiveStatusMessage(context.Background(), flowGuildID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestLiveStatusMessage_PinnedAndRefreshedUntilDeleted(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	pin := true
+
+	b.handleSlashNowCommand(session, nowInteraction(flowUserID, true, &pin))
+
+	stored, err := db.GetLiveStatusMessage(ctx, flowGuildID)
+	require.NoError(t, err)
+	assert.Equal(t, "text-channel", stored.ChannelID)
+	assert.True(t, session.Pinned(stored.MessageID))
+	require.Len(t, session.Messages(), 1)
+	assert.Contains(t, session.Messages()[0].Embeds[0].Description, "Nobody is studying")
+
+	// A join shows up on the next refresh
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	b.refreshLiveStatusMessages(ctx)
+	assert.Contains(t, session.Messages()[0].Embeds[0].Description, "<@user-1> in <#study-vc>")
+
+	// Once the message is deleted in Discord the bot stops trying to edit it
+	session.DeleteMessage(stored.MessageID)
+	b.refreshLiveStatusMessages(ctx)
+	_, err = db.GetLiveStatusMessage(ctx, flowGuildID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,157 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Skufu/LockIn-Bot/internal/commands"
+	"github.com/Skufu/LockIn-Bot/internal/discord"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+)
+
+// capabilityChoices are the capabilities /permissions can grant
+var capabilityChoices = func() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, len(commands.Capabilities))
+	for i, c := range commands.Capabilities {
+		choices[i] = &discordgo.ApplicationCommandOptionChoice{Name: c.Label(), Value: string(c)}
+	}
+	return choices
+}()
+
+// capabilityOptions are the options of /permissions grant and revoke
+var capabilityOptions = []*discordgo.ApplicationCommandOption{
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "capability",
+		Description: "What the role can do",
+		Required:    true,
+		Choices:     capabilityChoices,
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionRole,
+		Name:        "role",
+		Description: "The Discord role",
+		Required:    true,
+	},
+}
+
+// handleSlashPermissionsCommand lets admins choose which roles can use each moderator capability
+func (b *Bot) handleSlashPermissionsCommand(s discord.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		respondEphemeral(s, i, "Choose grant, revoke or list.")
+		return
+	}
+	sub := options[0]
+	ctx := context.Background()
+
+	if sub.Name == "list" {
+		b.respondPermissionsList(ctx, s, i)
+		return
+	}
+
+	var capability commands.Permission
+	var roleID string
+	for _, opt := range sub.Options {
+		switch opt.Name {
+		case "capability":
+			capability = commands.Permission(opt.StringValue())
+		case "role":
+			roleID = opt.RoleValue(nil, "").ID
+		}
+	}
+	if !capability.IsCapability() || roleID == "" {
+		respondEphemeral(s, i, "Choose a capability and a role.")
+		return
+	}
+
+	switch sub.Name {
+	case "grant":
+		if err := b.permissions.Grant(ctx, i.GuildID, roleID, capability); err != nil {
+			b.logger.Error("Failed to grant capability", "guild_id", i.GuildID, "capability", capability, "role_id", roleID, "error", err)
+			respondEphemeral(s, i, "Something went wrong while saving the permission. Please try again later.")
+			return
+		}
+		b.logger.Info("Granted capability", "guild_id", i.GuildID, "capability", capability, "role_id", roleID)
+		b.recordAudit(ctx, service.AuditEntry{
+			GuildID: i.GuildID,
+			ActorID: interactionUserID(i),
+			Action:  service.AuditActionGrantCapability,
+			Details: map[string]any{"capability": string(capability), "roleId": roleID},
+		})
+		respondEphemeral(s, i, fmt.Sprintf("✅ <@&%s> can now use **%s**.", roleID, capability.Label()))
+	case "revoke":
+		revoked, err := b.permissions.Revoke(ctx, i.GuildID, roleID, capability)
+		if err != nil {
+			b.logger.Error("Failed to revoke capability", "guild_id", i.GuildID, "capability", capability, "role_id", roleID, "error", err)
+			respondEphemeral(s, i, "Something went wrong while saving the permission. Please try again later.")
+			return
+		}
+		if !revoked {
+			respondEphemeral(s, i, fmt.Sprintf("<@&%s> didn't have **%s**.", roleID, capability.Label()))
+			return
+		}
+		b.logger.Info("Revoked capability", "guild_id", i.GuildID, "capability", capability, "role_id", roleID)
+		b.recordAudit(ctx, service.AuditEntry{
+			GuildID: i.GuildID,
+			ActorID: interactionUserID(i),
+			Action:  service.AuditActionRevokeCapability,
+			Details: map[string]any{"capability": string(capability), "roleId": roleID},
+		})
+		respondEphemeral(s, i, fmt.Sprintf("✅ <@&%s> can no longer use **%s**.", roleID, capability.Label()))
+	default:
+		respondEphemeral(s, i, "Unknown subcommand.")
+	}
+}
+
+// respondPermissionsList shows the roles granted each capability in the guild
+func (b *Bot) respondPermissionsList(ctx context.Context, s discord.Session, i *discordgo.InteractionCreate) {
+	roles, err := b.permissions.Roles(ctx, i.GuildID)
+	if err != nil {
+		b.logger.Error("Failed to load permissions", "guild_id", i.GuildID, "error", err)
+		respondEphemeral(s, i, "Something went wrong while loading permissions. Please try again later.")
+		return
+	}
+
+	fields := make([]*discordgo.MessageEmbedField, len(commands.Capabilities))
+	for n, c := range commands.Capabilities {
+		value := "Administrators only"
+		if ids := roles[c]; len(ids) > 0 {
+			value = "<@&" + strings.Join(ids, ">, <@&") + ">"
+		}
+		fields[n] = &discordgo.MessageEmbedField{Name: c.Label(), Value: value}
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "🛡️ Permissions",
+				Description: "Roles that can use each moderator capability. Administrators can use all of them.",
+				Color:       0x00AAFF,
+				Fields:      fields,
+			}},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.logger.Error("Failed to send /permissions response", "error", err)
+	}
+}
+
+// memberCan reports whether the member who triggered i may use perm. A failed check is logged
+// and treated as not allowed.
+func (b *Bot) memberCan(i *discordgo.InteractionCreate, perm commands.Permission) bool {
+	allowed, err := b.permissions.Allowed(context.Background(), i.GuildID, i.Member, perm)
+	if err != nil {
+		b.logger.Error("Failed to check permission", "guild_id", i.GuildID, "permission", perm, "error", err)
+		return false
+	}
+	return allowed
+}
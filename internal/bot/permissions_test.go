@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/commands"
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const moderatorRoleID = "role-moderator"
+
+func roleOption(name, roleID string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionRole, Value: roleID}
+}
+
+// permissionsInteraction runs /permissions as a server administrator
+func permissionsInteraction(sub string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	i := createTestInteraction("admin-1", "admin", flowGuildID)
+	i.Member.Permissions = discordgo.PermissionAdministrator
+	i.Data = discordgo.ApplicationCommandInteractionData{
+		Name: "permissions",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+			Name:    sub,
+			Type:    discordgo.ApplicationCommandOptionSubCommand,
+			Options: options,
+		}},
+	}
+	return i
+}
+
+// moderatorInteraction runs a command as a member with the moderator role and no Discord permissions
+func moderatorInteraction(name string) *discordgo.InteractionCreate {
+	i := createTestInteraction(flowUserID, "alice", flowGuildID)
+	i.Member.Roles = []string{"role-other", moderatorRoleID}
+	i.Data = discordgo.ApplicationCommandInteractionData{Name: name}
+	return i
+}
+
+func TestPermissions_GrantedRoleCanRunModeratorCommand(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	studying := func() bool {
+		_, err := db.GetActiveStudySession(ctx, sql.NullString{String: flowUserID, Valid: true})
+		return err == nil
+	}
+	_, err := db.CreateStudySession(ctx, database.CreateStudySessionParams{
+		UserID:    sql.NullString{String: flowUserID, Valid: true},
+		GuildID:   sql.NullString{String: flowGuildID, Valid: true},
+		StartTime: time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+
+	b.handleInteractionCreate(session, moderatorInteraction("cleanup-sessions"))
+	assert.Contains(t, session.LastResponse().Data.Content, "don't have permission")
+	require.True(t, studying())
+
+	b.handleInteractionCreate(session, permissionsInteraction("grant",
+		stringOption("capability", string(commands.PermissionAdjustStats)),
+		roleOption("role", moderatorRoleID),
+	))
+	assert.Contains(t, session.LastResponse().Data.Content, "can now use **Adjust stats**")
+
+	b.handleInteractionCreate(session, moderatorInteraction("cleanup-sessions"))
+	assert.Contains(t, session.LastResponse().Data.Content, "**1** sessions still in progress will be kept")
+	assert.True(t, studying(), "open sessions are never cleaned up")
+
+	// The grant covers only that capability, and the admin-only configuration stays admin-only
+	b.handleInteractionCreate(session, moderatorInteraction("evaluate-streaks"))
+	assert.Contains(t, session.LastResponse().Data.Content, "don't have permission")
+	b.handleInteractionCreate(session, moderatorInteraction("permissions"))
+	assert.Contains(t, session.LastResponse().Data.Content, "don't have permission")
+
+	b.handleInteractionCreate(session, permissionsInteraction("revoke",
+		stringOption("capability", string(commands.PermissionAdjustStats)),
+		roleOption("role", moderatorRoleID),
+	))
+	assert.Contains(t, session.LastResponse().Data.Content, "can no longer use")
+	b.handleInteractionCreate(session, moderatorInteraction("cleanup-sessions"))
+	assert.Contains(t, session.LastResponse().Data.Content, "don't have permission")
+}
+
+func TestPermissions_ListShowsGrantedRoles(t *testing.T) {
+	b, _, session := createFlowBot(t)
+	for _, roleID := range []string{moderatorRoleID, "role-helper"} {
+		b.handleInteractionCreate(session, permissionsInteraction("grant",
+			stringOption("capability", string(commands.PermissionRunBackfills)),
+			roleOption("role", roleID),
+		))
+	}
+
+	b.handleInteractionCreate(session, permissionsInteraction("list"))
+
+	resp := session.LastResponse()
+	require.Len(t, resp.Data.Embeds, 1)
+	embed := resp.Data.Embeds[0]
+	assert.Equal(t, "<@&role-helper>, <@&role-moderator>", fieldValue(embed, "Run backfills"))
+	assert.Equal(t, "Administrators only", fieldValue(embed, "View audit log"))
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, resp.Data.Flags)
+}
+
+func TestNowPin_AllowedForRolesThatManageChannels(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	pin := moderatorInteraction("now")
+	pin.Data = discordgo.ApplicationCommandInteractionData{
+		Name:    "now",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{boolOption("pin", false)},
+	}
+
+	b.handleInteractionCreate(session, pin)
+	assert.Contains(t, session.LastResponse().Data.Content, "don't have permission to manage the live status message")
+
+	require.NoError(t, db.GrantRoleCapability(context.Background(), database.GrantRoleCapabilityParams{
+		GuildID:    flowGuildID,
+		RoleID:     moderatorRoleID,
+		Capability: string(commands.PermissionManageChannels),
+	}))
+	b.handleInteractionCreate(session, pin)
+	assert.NotContains(t, session.LastResponse().Data.Content, "don't have permission")
+}
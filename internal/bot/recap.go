@@ -0,0 +1,130 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Skufu/LockIn-Bot/internal/discord"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+)
+
+// recapMinAgo stops /recap view from being asked for a recap in the future
+var recapMinAgo float64 = 0
+
+// handleSlashRecapCommand handles /recap view, /recap dm and /recap channel
+func (b *Bot) handleSlashRecapCommand(s discord.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+	if b.recapService == nil {
+		b.logger.Error("RecapService not available for /recap command")
+		respondEphemeral(s, i, "Recaps are currently unavailable.")
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		respondEphemeral(s, i, "Please choose `/recap view` or `/recap dm`.")
+		return
+	}
+
+	sub := options[0]
+	switch sub.Name {
+	case "view":
+		b.handleRecapView(s, i, sub.Options)
+	case "dm":
+		b.handleRecapDM(s, i, sub.Options)
+	case "channel":
+		b.handleRecapChannel(s, i, sub.Options)
+	default:
+		respondEphemeral(s, i, "Unknown recap option.")
+	}
+}
+
+// handleRecapView shows an archived recap for the guild
+func (b *Bot) handleRecapView(s discord.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	period := service.RecapPeriodWeekly
+	ago := 0
+	for _, opt := range options {
+		switch opt.Name {
+		case "period":
+			period = opt.StringValue()
+		case "ago":
+			ago = int(opt.IntValue())
+		}
+	}
+
+	report, err := b.recapService.GetRecap(context.Background(), i.GuildID, period, ago)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondEphemeral(s, i, "There is no "+period+" recap for this server yet. Recaps are posted when each week or month ends.")
+		return
+	}
+	if err != nil {
+		b.logger.Error("Failed to load recap", "guild_id", i.GuildID, "period", period, "error", err)
+		respondEphemeral(s, i, "Something went wrong while loading the recap. Please try again later.")
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{service.BuildRecapEmbed(report)},
+		},
+	})
+	if err != nil {
+		b.logger.Error("Failed to send /recap view response", "error", err)
+	}
+}
+
+// handleRecapDM turns the user's personal recap DMs for the guild on or off
+func (b *Bot) handleRecapDM(s discord.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	enabled := false
+	for _, opt := range options {
+		if opt.Name == "enabled" {
+			enabled = opt.BoolValue()
+		}
+	}
+
+	userID := interactionUserID(i)
+	if err := b.recapService.SetDMSubscription(context.Background(), userID, i.GuildID, enabled); err != nil {
+		b.logger.Error("Failed to update recap DMs", "user_id", userID, "guild_id", i.GuildID, "error", err)
+		respondEphemeral(s, i, "Something went wrong while saving your choice. Please try again later.")
+		return
+	}
+
+	if enabled {
+		respondEphemeral(s, i, "📬 You'll get a personal summary by DM with each weekly and monthly recap. Make sure your DMs are open for this server.")
+	} else {
+		respondEphemeral(s, i, "You won't get personal recap DMs anymore.")
+	}
+}
+
+// handleRecapChannel makes the interaction's channel the guild's recap channel, or stops posting
+// recaps. The registry has already checked the member may manage channels.
+func (b *Bot) handleRecapChannel(s discord.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	enabled := false
+	for _, opt := range options {
+		if opt.Name == "enabled" {
+			enabled = opt.BoolValue()
+		}
+	}
+
+	channelID := ""
+	if enabled {
+		channelID = i.ChannelID
+	}
+	if err := b.recapService.SetRecapChannel(context.Background(), i.GuildID, interactionUserID(i), channelID); err != nil {
+		b.logger.Error("Failed to set recap channel", "guild_id", i.GuildID, "channel_id", channelID, "error", err)
+		respondEphemeral(s, i, "Something went wrong while saving the recap channel. Please try again later.")
+		return
+	}
+
+	if enabled {
+		respondEphemeral(s, i, "📊 Weekly and monthly recaps will be posted in this channel.")
+	} else {
+		respondEphemeral(s, i, "Recaps won't be posted in a channel anymore. They're still archived for `/recap view` and sent to members who use `/recap dm`.")
+	}
+}
@@ -0,0 +1,203 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/database/fakedb"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recapInteraction builds a /recap interaction with one subcommand
+func recapInteraction(userID, sub string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	i := createTestInteraction(userID, "alice", flowGuildID)
+	i.Data = discordgo.ApplicationCommandInteractionData{
+		Name: "recap",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: sub, Type: discordgo.ApplicationCommandOptionSubCommand, Options: options},
+		},
+	}
+	return i
+}
+
+func addActivity(t *testing.T, db *fakedb.Querier, userID, guildID string, date time.Time, d time.Duration) {
+	t.Helper()
+	require.NoError(t, db.AddDailyActivity(context.Background(), database.AddDailyActivityParams{
+		UserID:       userID,
+		GuildID:      guildID,
+		ActivityDate: date,
+		StudyMs:      d.Milliseconds(),
+	}))
+}
+
+func TestRecapService_PostsArchivesAndDMsOnce(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	manila := service.GetManilaLocation()
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, manila) }
+
+	// Last week (Oct 4-10) and the week being recapped (Oct 11-17)
+	addActivity(t, db, flowUserID, flowGuildID, day(5), time.Hour)
+	addActivity(t, db, flowUserID, flowGuildID, day(12), 3*time.Hour)
+	addActivity(t, db, "user-2", flowGuildID, day(13), 2*time.Hour)
+	addActivity(t, db, "user-3", flowGuildID, day(6), time.Hour)
+	addActivity(t, db, "user-4", "guild-2", day(14), 30*time.Minute)
+
+	_, err := db.StartDailyActivity(ctx, database.StartDailyActivityParams{UserID: "user-2", GuildID: flowGuildID})
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateStreakImmediately(ctx, database.UpdateStreakImmediatelyParams{UserID: "user-2", GuildID: flowGuildID, CurrentStreakCount: 6, MaxStreakCount: 6}))
+
+	db.Now = func() time.Time { return day(15).Add(12 * time.Hour) }
+	_, err = db.AwardAchievement(ctx, database.AwardAchievementParams{UserID: flowUserID, GuildID: flowGuildID, AchievementID: "getting_started"})
+	require.NoError(t, err)
+
+	recaps := service.NewRecapService(db, db)
+	b.SetRecapService(recaps)
+	b.handleSlashRecapCommand(session, recapInteraction(flowUserID, "dm", &discordgo.ApplicationCommandInteractionDataOption{
+		Name: "enabled", Type: discordgo.ApplicationCommandOptionBoolean, Value: true,
+	}))
+
+	// Only guild-1 has picked a channel for its recaps
+	channel := recapInteraction("admin-1", "channel", &discordgo.ApplicationCommandInteractionDataOption{
+		Name: "enabled", Type: discordgo.ApplicationCommandOptionBoolean, Value: true,
+	})
+	channel.Member.Permissions = discordgo.PermissionAdministrator
+	channel.ChannelID = "recap-channel"
+	b.handleInteractionCreate(session, channel)
+	assert.Contains(t, session.LastResponse().Data.Content, "posted in this channel")
+	entries := db.AuditLog()
+	require.NotEmpty(t, entries)
+	last := entries[len(entries)-1]
+	assert.Equal(t, service.AuditActionRecapChannel, last.Action)
+	assert.Equal(t, "admin-1", last.ActorID.String)
+	assert.JSONEq(t, `{"oldChannelId":"","channelId":"recap-channel"}`, string(last.Details))
+
+	now := day(18) // Sunday midnight, when the weekly reset runs
+	created, err := recaps.PostRecaps(ctx, service.RecapPeriodWeekly, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+
+	report, err := recaps.GetRecap(ctx, flowGuildID, service.RecapPeriodWeekly, 0)
+	require.NoError(t, err)
+	assert.True(t, report.PeriodStart.Equal(day(11)))
+	assert.Equal(t, (5 * time.Hour).Milliseconds(), report.TotalStudyMs)
+	assert.Equal(t, 2, report.ActiveUsers)
+	require.Len(t, report.TopStudiers, 2)
+	assert.Equal(t, flowUserID, report.TopStudiers[0].UserID)
+	require.Len(t, report.Improvers, 1)
+	assert.Equal(t, service.RecapImprover{UserID: flowUserID, StudyMs: (3 * time.Hour).Milliseconds(), PreviousStudyMs: time.Hour.Milliseconds()}, report.Improvers[0])
+	assert.Equal(t, []service.RecapStreak{{UserID: "user-2", Days: 6}}, report.Streaks)
+	require.Len(t, report.NewBadges, 1)
+	assert.Equal(t, "getting_started", report.NewBadges[0].AchievementID)
+
+	// The guild post goes to guild-1's own channel, and the subscriber's DM is queued.
+	// guild-2's recap is archived but not posted anywhere.
+	keys := dedupeKeys(db.Notifications())
+	assert.Contains(t, keys, "recap:weekly:guild-1:2026-10-11")
+	assert.NotContains(t, keys, "recap:weekly:guild-2:2026-10-11")
+	assert.Contains(t, keys, "recap_dm:weekly:guild-1:2026-10-11:user-1")
+	for _, n := range db.Notifications() {
+		switch n.DedupeKey {
+		case "recap:weekly:guild-1:2026-10-11":
+			assert.Equal(t, "recap-channel", n.ChannelID)
+		case "recap_dm:weekly:guild-1:2026-10-11:user-1":
+			var payload map[string]any
+			require.NoError(t, json.Unmarshal(n.Payload, &payload))
+			assert.Equal(t, true, payload["directMessage"])
+		}
+	}
+	_, err = recaps.GetRecap(ctx, "guild-2", service.RecapPeriodWeekly, 0)
+	assert.NoError(t, err)
+
+	// Running again, e.g. after a restart, doesn't post twice
+	before := len(db.Notifications())
+	created, err = recaps.PostRecaps(ctx, service.RecapPeriodWeekly, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 0, created)
+	assert.Len(t, db.Notifications(), before)
+
+	// The archived recap can be viewed again
+	b.handleSlashRecapCommand(session, recapInteraction(flowUserID, "view", &discordgo.ApplicationCommandInteractionDataOption{
+		Name: "period", Type: discordgo.ApplicationCommandOptionString, Value: service.RecapPeriodWeekly,
+	}))
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	require.Len(t, resp.Data.Embeds, 1)
+	assert.Equal(t, "📊 Weekly Recap · Oct 11 – Oct 17", resp.Data.Embeds[0].Title)
+	assert.Contains(t, fieldValue(resp.Data.Embeds[0], "🏆 Top Studiers"), "🥇 <@user-1> · 3h 0m")
+}
+
+func TestForgetUser_RemovesUserFromArchivedRecaps(t *testing.T) {
+	b, db, _ := createFlowBot(t)
+	ctx := context.Background()
+	manila := service.GetManilaLocation()
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, manila) }
+
+	addActivity(t, db, flowUserID, flowGuildID, day(12), 3*time.Hour)
+	addActivity(t, db, "user-2", flowGuildID, day(13), 2*time.Hour)
+	addActivity(t, db, flowUserID, "guild-2", day(14), time.Hour)
+	recaps := service.NewRecapService(db, db)
+	b.SetRecapService(recaps)
+	_, err := recaps.PostRecaps(ctx, service.RecapPeriodWeekly, day(18))
+	require.NoError(t, err)
+
+	// An admin's /forget-user only touches their own server's recaps
+	accounts := service.NewAccountService(db)
+	result, err := accounts.ForgetUser(ctx, service.ForgetUserRequest{TargetUserID: flowUserID, ActorID: "admin-1", GuildID: "guild-2", GuildOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.Recaps)
+	guild2, err := recaps.GetRecap(ctx, "guild-2", service.RecapPeriodWeekly, 0)
+	require.NoError(t, err)
+	assert.Empty(t, guild2.TopStudiers)
+	report, err := recaps.GetRecap(ctx, flowGuildID, service.RecapPeriodWeekly, 0)
+	require.NoError(t, err)
+	assert.Len(t, report.TopStudiers, 2)
+
+	_, err = accounts.ForgetUser(ctx, service.ForgetUserRequest{TargetUserID: flowUserID, ActorID: flowUserID})
+	require.NoError(t, err)
+	report, err = recaps.GetRecap(ctx, flowGuildID, service.RecapPeriodWeekly, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []service.RecapStudier{{UserID: "user-2", StudyMs: (2 * time.Hour).Milliseconds()}}, report.TopStudiers)
+	assert.Equal(t, (5 * time.Hour).Milliseconds(), report.TotalStudyMs, "community totals are kept")
+}
+
+func TestHandleSlashRecapCommand_ViewWithoutRecap(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	b.SetRecapService(service.NewRecapService(db, db))
+
+	b.handleSlashRecapCommand(session, recapInteraction(flowUserID, "view", &discordgo.ApplicationCommandInteractionDataOption{
+		Name: "period", Type: discordgo.ApplicationCommandOptionString, Value: service.RecapPeriodMonthly,
+	}))
+
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Data.Content, "no monthly recap")
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, resp.Data.Flags)
+	_, err := db.GetRecap(context.Background(), database.GetRecapParams{GuildID: flowGuildID, Period: service.RecapPeriodMonthly})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRecapPeriodBounds(t *testing.T) {
+	manila := service.GetManilaLocation()
+	now := time.Date(2026, 11, 1, 0, 0, 30, 0, manila) // Sunday and the 1st of the month
+
+	start, end, err := service.RecapPeriodBounds(service.RecapPeriodWeekly, now)
+	require.NoError(t, err)
+	assert.True(t, start.Equal(time.Date(2026, 10, 25, 0, 0, 0, 0, manila)))
+	assert.True(t, end.Equal(time.Date(2026, 11, 1, 0, 0, 0, 0, manila)))
+
+	start, end, err = service.RecapPeriodBounds(service.RecapPeriodMonthly, now)
+	require.NoError(t, err)
+	assert.True(t, start.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, manila)))
+	assert.True(t, end.Equal(time.Date(2026, 11, 1, 0, 0, 0, 0, manila)))
+
+	_, _, err = service.RecapPeriodBounds("daily", now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Skufu/LockIn-Bot/internal/discord"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleSlashRemindersCommand handles /reminders show, set, reset and server
+func (b *Bot) handleSlashRemindersCommand(s discord.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+	if b.streakService == nil {
+		b.logger.Error("StreakService not available for /reminders command")
+		respondEphemeral(s, i, "Streak reminders are currently unavailable.")
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		respondEphemeral(s, i, "Please choose `/reminders show`, `set`, `reset` or `server`.")
+		return
+	}
+
+	ctx := context.Background()
+	userID := interactionUserID(i)
+	sub := options[0]
+
+	var err error
+	switch sub.Name {
+	case "show":
+	case "set", "server":
+		// The registry only lets administrators run server
+		var minutes []int
+		minutes, err = service.ParseReminderTimes(optionString(sub.Options, "times"))
+		if err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("Couldn't read those times: %v. Use 24-hour Manila times separated by commas, e.g. `18:00, 22:00`.", err))
+			return
+		}
+		if sub.Name == "server" {
+			if err = b.streakService.SetGuildReminderTimes(ctx, i.GuildID, minutes); err == nil {
+				b.recordAudit(ctx, service.AuditEntry{
+					GuildID: i.GuildID,
+					ActorID: userID,
+					Action:  service.AuditActionServerReminders,
+					Details: map[string]any{"times": service.FormatReminderTimes(minutes)},
+				})
+			}
+		} else {
+			err = b.streakService.SetUserReminderTimes(ctx, userID, i.GuildID, minutes)
+		}
+	case "reset":
+		err = b.streakService.ClearUserReminderTimes(ctx, userID, i.GuildID)
+	default:
+		respondEphemeral(s, i, "Unknown reminders option.")
+		return
+	}
+	if err != nil {
+		b.logger.Error("Failed to update reminder times", "user_id", userID, "guild_id", i.GuildID, "error", err)
+		respondEphemeral(s, i, "Something went wrong while saving the reminder times. Please try again later.")
+		return
+	}
+
+	schedule, err := b.streakService.GetReminderSchedule(ctx, userID, i.GuildID)
+	if err != nil {
+		b.logger.Error("Failed to load reminder times", "user_id", userID, "guild_id", i.GuildID, "error", err)
+		respondEphemeral(s, i, "Something went wrong while loading the reminder times. Please try again later.")
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{reminderScheduleEmbed(schedule)},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.logger.Error("Failed to send /reminders response", "error", err)
+	}
+}
+
+// reminderScheduleEmbed shows the reminder times that apply to a user
+func reminderScheduleEmbed(schedule service.ReminderSchedule) *discordgo.MessageEmbed {
+	yours := service.FormatReminderTimes(schedule.Minutes)
+	if !schedule.Custom {
+		yours += " (server default)"
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "⏰ Streak Reminders",
+		Description: "If you haven't studied enough to keep your streak, you'll get a reminder at each of these Manila times.",
+		Color:       0xFFA500,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Your reminders", Value: yours},
+			{Name: "Server reminders", Value: service.FormatReminderTimes(schedule.GuildMinutes)},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "Use /notifications to get reminders by DM or turn them off."},
+	}
+}
+
+// optionString returns the value of the named string option, or "" if it wasn't given
+func optionString(options []*discordgo.ApplicationCommandInteractionDataOption, name string) string {
+	for _, opt := range options {
+		if opt.Name == name {
+			return opt.StringValue()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// remindersInteraction builds a /reminders interaction with one subcommand
+func remindersInteraction(userID, sub, times string) *discordgo.InteractionCreate {
+	i := createTestInteraction(userID, "alice", flowGuildID)
+	option := &discordgo.ApplicationCommandInteractionDataOption{Name: sub, Type: discordgo.ApplicationCommandOptionSubCommand}
+	if times != "" {
+		option.Options = []*discordgo.ApplicationCommandInteractionDataOption{stringOption("times", times)}
+	}
+	i.Data = discordgo.ApplicationCommandInteractionData{
+		Name:    "reminders",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{option},
+	}
+	return i
+}
+
+// warningKeys returns the dedupe keys of queued streak warnings
+func warningKeys(rows []database.NotificationsOutbox) []string {
+	var keys []string
+	for _, key := range dedupeKeys(rows) {
+		if strings.HasPrefix(key, "streak_warning:") {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func TestStreakReminders_FollowGuildAndUserSchedules(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	manila := service.GetManilaLocation()
+	at := func(hour, minute int) time.Time { return time.Date(2026, 10, 14, hour, minute, 0, 0, manila) }
+
+	streaks := service.NewStreakService(db, db, nil, b.cfg)
+	b.SetStreakService(streaks)
+
+	for _, userID := range []string{flowUserID, "user-2"} {
+		_, err := db.StartDailyActivity(ctx, database.StartDailyActivityParams{
+			UserID:           userID,
+			GuildID:          flowGuildID,
+			LastActivityDate: sql.NullTime{Time: at(0, 0).AddDate(0, 0, -1), Valid: true},
+		})
+		require.NoError(t, err)
+		require.NoError(t, db.UpdateStreakImmediately(ctx, database.UpdateStreakImmediatelyParams{UserID: userID, GuildID: flowGuildID, CurrentStreakCount: 4, MaxStreakCount: 4}))
+	}
+
+	// Without any settings the old 8:00 PM warning still applies
+	streaks.SendDueReminders(ctx, at(18, 5))
+	assert.Empty(t, warningKeys(db.Notifications()))
+
+	admin := remindersInteraction("admin-1", "server", "22:00, 18:00")
+	admin.Member.Permissions = discordgo.PermissionAdministrator
+	b.handleInteractionCreate(session, admin)
+	b.handleSlashRemindersCommand(session, remindersInteraction("user-2", "set", "21:00"))
+	resp := session.LastResponse()
+	require.Len(t, resp.Data.Embeds, 1)
+	assert.Equal(t, "21:00", fieldValue(resp.Data.Embeds[0], "Your reminders"))
+	assert.Equal(t, "18:00, 22:00", fieldValue(resp.Data.Embeds[0], "Server reminders"))
+
+	// Each reminder time is sent once
+	streaks.SendDueReminders(ctx, at(18, 5))
+	streaks.SendDueReminders(ctx, at(18, 30))
+	assert.Equal(t, []string{"streak_warning:guild-1:user-1:2026-10-14T18:00"}, warningKeys(db.Notifications()))
+
+	streaks.SendDueReminders(ctx, at(21, 0))
+	streaks.SendDueReminders(ctx, at(22, 1))
+	assert.ElementsMatch(t, []string{
+		"streak_warning:guild-1:user-1:2026-10-14T18:00",
+		"streak_warning:guild-1:user-2:2026-10-14T21:00",
+		"streak_warning:guild-1:user-1:2026-10-14T22:00",
+	}, warningKeys(db.Notifications()))
+
+	for _, n := range db.Notifications() {
+		if n.DedupeKey == "streak_warning:guild-1:user-1:2026-10-14T22:00" {
+			assert.Contains(t, string(n.Payload), "1 more minute")
+			assert.Contains(t, string(n.Payload), "1h 59m until midnight")
+		}
+	}
+}
+
+func TestHandleSlashRemindersCommand_Validation(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	b.SetStreakService(service.NewStreakService(db, db, nil, b.cfg))
+
+	// The registry stops members who aren't administrators from changing the server's times
+	b.handleInteractionCreate(session, remindersInteraction(flowUserID, "server", "18:00"))
+	assert.Contains(t, session.LastResponse().Data.Content, "permission")
+	_, err := db.GetGuildSettings(context.Background(), flowGuildID)
+	assert.Error(t, err)
+
+	b.handleSlashRemindersCommand(session, remindersInteraction(flowUserID, "set", "6pm"))
+	assert.Contains(t, session.LastResponse().Data.Content, "Couldn't read those times")
+
+	b.handleSlashRemindersCommand(session, remindersInteraction(flowUserID, "set", "07:30"))
+	b.handleSlashRemindersCommand(session, remindersInteraction(flowUserID, "reset", ""))
+	resp := session.LastResponse()
+	require.Len(t, resp.Data.Embeds, 1)
+	assert.Equal(t, "20:00 (server default)", fieldValue(resp.Data.Embeds[0], "Your reminders"))
+}
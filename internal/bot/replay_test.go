@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/database/fakedb"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replayRecording feeds a voice event recording from testdata/voice to a bot on the in-memory
+// fakes, moving a fake clock to each event's timestamp before handling it
+func replayRecording(t *testing.T, name string, tracked ...string) *fakedb.Querier {
+	t.Helper()
+
+	file, err := os.Open(filepath.Join("testdata", "voice", name))
+	require.NoError(t, err)
+	defer file.Close()
+	records, err := ReadVoiceRecording(file)
+	require.NoError(t, err)
+	require.NotEmpty(t, records)
+
+	b, db, session := createFlowBot(t)
+	clk := clock.NewFake(records[0].At)
+	b.clock = clk
+	db.Now = clk.Now
+	for _, channelID := range tracked {
+		b.allowedVoiceChannelIDs[channelID] = struct{}{}
+	}
+
+	for _, record := range records {
+		clk.Set(record.At)
+		b.handleVoiceStateUpdate(session, record.Event())
+	}
+	return db
+}
+
+func TestReplay_Recordings(t *testing.T) {
+	tests := []struct {
+		name          string
+		recording     string
+		tracked       []string
+		wantDurations []time.Duration // Durations of ended sessions, in order
+		wantOpen      int
+		wantTotal     time.Duration
+	}{
+		{
+			name:          "join then leave",
+			recording:     "simple_session.jsonl",
+			wantDurations: []time.Duration{90 * time.Minute},
+			wantTotal:     90 * time.Minute,
+		},
+		{
+			name:          "duplicate join is ignored",
+			recording:     "duplicate_join.jsonl",
+			wantDurations: []time.Duration{45 * time.Minute},
+			wantTotal:     45 * time.Minute,
+		},
+		{
+			name:          "hopping between tracked channels keeps one session",
+			recording:     "channel_hop.jsonl",
+			tracked:       []string{"study-vc-2"},
+			wantDurations: []time.Duration{time.Hour},
+			wantOpen:      1,
+			wantTotal:     time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := replayRecording(t, tt.recording, tt.tracked...)
+
+			var durations []time.Duration
+			open := 0
+			for _, s := range db.StudySessions() {
+				if !s.EndTime.Valid {
+					open++
+					continue
+				}
+				durations = append(durations, time.Duration(s.DurationMs.Int64)*time.Millisecond)
+			}
+			assert.Equal(t, tt.wantDurations, durations)
+			assert.Equal(t, tt.wantOpen, open)
+
+			stats, err := db.GetUserStats(context.Background(), flowUserID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTotal.Milliseconds(), stats.TotalStudyMs.Int64)
+		})
+	}
+}
+
+func TestVoiceRecorder_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "voice.jsonl")
+	recorder, err := NewVoiceRecorder(path)
+	require.NoError(t, err)
+
+	at := time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC)
+	move := &discordgo.VoiceStateUpdate{
+		VoiceState:   &discordgo.VoiceState{UserID: flowUserID, GuildID: flowGuildID, ChannelID: "study-vc-2"},
+		BeforeUpdate: &discordgo.VoiceState{UserID: flowUserID, GuildID: flowGuildID, ChannelID: flowChannelID},
+	}
+	require.NoError(t, recorder.Record(joinEvent(flowUserID, flowChannelID), at))
+	require.NoError(t, recorder.Record(move, at.Add(time.Minute)))
+	require.NoError(t, recorder.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	records, err := ReadVoiceRecording(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	require.Len(t, records, 2)
+	assert.Equal(t, at, records[0].At)
+	assert.Nil(t, records[0].Event().BeforeUpdate)
+	assert.Equal(t, move.ChannelID, records[1].Event().ChannelID)
+	assert.Equal(t, flowChannelID, records[1].Event().BeforeUpdate.ChannelID)
+}
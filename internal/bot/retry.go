@@ -2,9 +2,8 @@ package bot
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
-	"sync"
 	"time"
 
 	"github.com/Skufu/LockIn-Bot/internal/config"
@@ -17,8 +16,10 @@ func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
-// connectWithRetry creates a new Bot instance with retry logic and exponential backoff
-func connectWithRetry(token string, db *database.Queries, cfg *config.Config, allowedVCs map[string]struct{}, maxRetries int) (*Bot, error) {
+// connectWithRetry creates a new Bot instance with retry logic and exponential backoff. setup, if
+// not nil, runs once the bot is built but before its gateway handlers and background workers start,
+// so the services it sets are in place for the first voice event.
+func connectWithRetry(token string, db database.Querier, cfg *config.Config, allowedVCs map[string]struct{}, maxRetries int, setup func(*Bot)) (*Bot, error) {
 	// Initial validation of token format before attempting any connections
 	if err := validateToken(token); err != nil {
 		return nil, fmt.Errorf("token validation failed: %w", err)
@@ -32,7 +33,7 @@ func connectWithRetry(token string, db *database.Queries, cfg *config.Config, al
 	maxDelay := 5 * time.Minute
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		log.Printf("Attempt %d/%d to connect to Discord", attempt, maxRetries)
+		slog.Info("Connecting to Discord", "attempt", attempt, "max_attempts", maxRetries)
 
 		// Create a new Discord session
 		dg, err := discordgo.New("Bot " + token)
@@ -40,26 +41,28 @@ func connectWithRetry(token string, db *database.Queries, cfg *config.Config, al
 			classifiedErr := classifyStartupError(err)
 
 			if classifiedErr.Type == ErrorTypePermanent {
-				log.Printf("[PERMANENT ERROR] Failed to initialize Discord session on attempt %d: %v", attempt, classifiedErr)
+				slog.Error("Permanent error creating Discord session", "attempt", attempt, "error", classifiedErr)
 				return nil, classifiedErr
 			}
 
 			lastErr = err
-			log.Printf("[RETRYABLE ERROR] Session creation failed on attempt %d: %v (classification: %s)",
-				attempt, classifiedErr, classifiedErr.getTypeString())
+			slog.Warn("Retryable error creating Discord session", "attempt", attempt,
+				"error", classifiedErr, "classification", classifiedErr.getTypeString())
 
 			if attempt == maxRetries {
 				break
 			}
 
 			nextDelay := calculateBackoffWithJitter(baseDelay, attempt, maxDelay)
-			log.Printf("Waiting %v before retry attempt %d/%d due to error: %v",
-				nextDelay, attempt+1, maxRetries, classifiedErr.Message)
+			slog.Info("Waiting before retrying", "delay", nextDelay, "next_attempt", attempt+1, "reason", classifiedErr.Message)
 
 			time.Sleep(nextDelay)
 			continue
 		}
 
+		// Count failed REST requests for /metrics
+		countDiscordErrors(dg)
+
 		// Set intents BEFORE opening the connection (critical: discordgo needs these for the gateway handshake)
 		dg.Identify.Intents = discordgo.IntentsGuildVoiceStates | discordgo.IntentsGuildMessages | discordgo.IntentsMessageContent
 
@@ -72,63 +75,43 @@ func connectWithRetry(token string, db *database.Queries, cfg *config.Config, al
 			classifiedErr := classifyStartupError(err)
 
 			if classifiedErr.Type == ErrorTypePermanent {
-				log.Printf("[PERMANENT ERROR] Failed to open Discord connection on attempt %d: %v", attempt, classifiedErr)
+				slog.Error("Permanent error opening Discord connection", "attempt", attempt, "error", classifiedErr)
 				return nil, classifiedErr
 			}
 
 			lastErr = err
-			log.Printf("[RETRYABLE ERROR] Connection failed on attempt %d: %v (classification: %s)",
-				attempt, classifiedErr, classifiedErr.getTypeString())
+			slog.Warn("Retryable error opening Discord connection", "attempt", attempt,
+				"error", classifiedErr, "classification", classifiedErr.getTypeString())
 
 			if attempt == maxRetries {
 				break
 			}
 
 			nextDelay := calculateBackoffWithJitter(baseDelay, attempt, maxDelay)
-			log.Printf("Waiting %v before retry attempt %d/%d due to error: %v",
-				nextDelay, attempt+1, maxRetries, classifiedErr.Message)
+			slog.Info("Waiting before retrying", "delay", nextDelay, "next_attempt", attempt+1, "reason", classifiedErr.Message)
 
 			time.Sleep(nextDelay)
 			continue
 		}
 
 		// Successfully connected - create the bot instance with all handlers registered
-		bot := &Bot{
-			session:                dg,
-			db:                     db,
-			activeSessions:         make(map[string]time.Time),
-			LoggingChannelID:       cfg.LoggingChannelID,
-			testGuildID:            cfg.TestGuildID,
-			allowedVoiceChannelIDs: allowedVCs,
-			cfg:                    cfg,
-			streakService:          nil, // Will be set later by the main application
-			achievementService:     nil, // Will be set later by the main application
-			voiceEventChan:         make(chan func()),
-			shutdownChan:           make(chan struct{}),
-			lastVoiceEvent:         make(map[string]time.Time),
-			voiceEventMu:           sync.Mutex{},
+		bot := newBot(dg, dg.State, db, cfg)
+		bot.allowedVoiceChannelIDs = allowedVCs
+		if setup != nil {
+			setup(bot)
 		}
-
-		// Register handlers just like in New constructor
-		dg.AddHandler(bot.handleReady)
-		dg.AddHandler(bot.handleVoiceStateUpdate)
-		dg.AddHandler(bot.handleInteractionCreate)
-
-		// Start worker pool for voice events (prevents goroutine explosion)
-		go bot.voiceEventWorker()
-
-		// Start session timeout checker to prevent phantom sessions
-		bot.StartSessionTimeoutChecker()
+		bot.registerHandlers(dg)
+		bot.start()
 
 		// At this point the bot is fully operational with registered handlers
-		log.Printf("Successfully connected to Discord on attempt %d", attempt)
+		slog.Info("Connected to Discord", "attempt", attempt)
 		return bot, nil
 	}
 
 	// If we reach here, all retry attempts have failed
 	finalClassifiedErr := classifyStartupError(lastErr)
-	log.Printf("Final failure after %d attempts. Last error (classification: %s): %v",
-		maxRetries, finalClassifiedErr.getTypeString(), finalClassifiedErr)
+	slog.Error("Failed to connect to Discord", "attempts", maxRetries,
+		"error", finalClassifiedErr, "classification", finalClassifiedErr.getTypeString())
 
 	return nil, fmt.Errorf("failed to connect to Discord after %d attempts: %w", maxRetries, finalClassifiedErr)
 }
@@ -150,6 +133,6 @@ func calculateBackoffWithJitter(baseDelay time.Duration, attempt int, maxDelay t
 }
 
 // ConnectWithRetry is an exported version of connectWithRetry for use in main application
-func ConnectWithRetry(token string, db *database.Queries, cfg *config.Config, allowedVCs map[string]struct{}, maxRetries int) (*Bot, error) {
-	return connectWithRetry(token, db, cfg, allowedVCs, maxRetries)
+func ConnectWithRetry(token string, db database.Querier, cfg *config.Config, allowedVCs map[string]struct{}, maxRetries int, setup func(*Bot)) (*Bot, error) {
+	return connectWithRetry(token, db, cfg, allowedVCs, maxRetries, setup)
 }
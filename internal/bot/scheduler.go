@@ -2,94 +2,147 @@ package bot
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 	"time"
 
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/health"
+	"github.com/Skufu/LockIn-Bot/internal/metrics"
+	"github.com/Skufu/LockIn-Bot/internal/service"
 	"github.com/robfig/cron/v3"
 )
 
 // Scheduler handles periodic tasks for the bot
 type Scheduler struct {
-	bot  *Bot
-	cron *cron.Cron
+	db       database.Querier
+	cron     *cron.Cron
+	sessions *service.SessionService // Archives old sessions before the nightly purge deletes them
+	recaps   *service.RecapService   // Optional; posts recaps before the weekly and monthly resets
+	logger   *slog.Logger
 }
 
 // NewScheduler creates a new scheduler for the bot
 func NewScheduler(bot *Bot) *Scheduler {
-	cronInstance := cron.New(cron.WithSeconds(), cron.WithLocation(time.UTC))
+	scheduler := newScheduler(bot.db, bot.sessionService, bot.recapService)
+	scheduler.logger = bot.logger
+	return scheduler
+}
+
+// newScheduler creates a scheduler that runs its jobs against db
+func newScheduler(db database.Querier, sessions *service.SessionService, recaps *service.RecapService) *Scheduler {
+	// Resets run on Manila calendar days, the same days sessions are split on when they're credited
+	cronInstance := cron.New(cron.WithSeconds(), cron.WithLocation(service.GetManilaLocation()))
 	return &Scheduler{
-		bot:  bot,
-		cron: cronInstance,
+		db:       db,
+		cron:     cronInstance,
+		sessions: sessions,
+		recaps:   recaps,
+		logger:   slog.Default(),
 	}
 }
 
+// SetLogger sets the structured logger for the scheduled jobs
+func (s *Scheduler) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
 // Start starts the scheduler
 func (s *Scheduler) Start() {
-	// Reset daily study time at midnight
-	_, err := s.cron.AddFunc("0 0 0 * * *", func() {
-		log.Println("Resetting daily study time")
-		ctx := context.Background()
-		err := s.bot.db.ResetDailyStudyTime(ctx)
+	// Reset daily study time at midnight Manila time
+	_, err := s.cron.AddFunc("0 0 0 * * *", s.job("daily_reset", func(ctx context.Context) error {
+		s.logger.Info("Resetting daily study time")
+		err := s.db.ResetDailyStudyTime(ctx)
 		if err != nil {
-			log.Printf("Error resetting daily study time: %v", err)
+			s.logger.Error("Failed to reset daily study time", "error", err)
 		}
-	})
+		return err
+	}))
 	if err != nil {
-		log.Printf("Error adding daily reset job: %v", err)
+		s.logger.Error("Failed to add daily reset job", "error", err)
 	}
 
 	// Reset weekly study time at midnight on Sunday
-	_, err = s.cron.AddFunc("0 0 0 * * 0", func() {
-		log.Println("Resetting weekly study time")
-		ctx := context.Background()
-		err := s.bot.db.ResetWeeklyStudyTime(ctx)
+	_, err = s.cron.AddFunc("0 0 0 * * 0", s.job("weekly_reset", func(ctx context.Context) error {
+		s.postRecaps(ctx, service.RecapPeriodWeekly)
+		s.logger.Info("Resetting weekly study time")
+		err := s.db.ResetWeeklyStudyTime(ctx)
 		if err != nil {
-			log.Printf("Error resetting weekly study time: %v", err)
+			s.logger.Error("Failed to reset weekly study time", "error", err)
 		}
-	})
+		return err
+	}))
 	if err != nil {
-		log.Printf("Error adding weekly reset job: %v", err)
+		s.logger.Error("Failed to add weekly reset job", "error", err)
 	}
 
 	// Reset monthly study time at midnight on the 1st of each month
-	_, err = s.cron.AddFunc("0 0 0 1 * *", func() {
-		log.Println("Resetting monthly study time")
-		ctx := context.Background()
-		err := s.bot.db.ResetMonthlyStudyTime(ctx)
+	_, err = s.cron.AddFunc("0 0 0 1 * *", s.job("monthly_reset", func(ctx context.Context) error {
+		s.postRecaps(ctx, service.RecapPeriodMonthly)
+		s.logger.Info("Resetting monthly study time")
+		err := s.db.ResetMonthlyStudyTime(ctx)
 		if err != nil {
-			log.Printf("Error resetting monthly study time: %v", err)
+			s.logger.Error("Failed to reset monthly study time", "error", err)
 		}
-	})
+		return err
+	}))
 	if err != nil {
-		log.Printf("Error adding monthly reset job: %v", err)
+		s.logger.Error("Failed to add monthly reset job", "error", err)
 	}
 
-	// Job to delete old study sessions (older than 1 week)
-	// Runs daily at 3:05 AM server time
-	_, err = s.cron.AddFunc("0 5 3 * * *", func() {
-		log.Println("Running job to delete old study sessions (older than 1 week)...")
-		ctx := context.Background()
+	// Job to archive and delete finished study sessions older than 1 week
+	// Runs daily at 3:05 AM Manila time
+	_, err = s.cron.AddFunc("0 5 3 * * *", s.job("delete_old_sessions", func(ctx context.Context) error {
+		if s.sessions == nil {
+			return fmt.Errorf("session service is not configured")
+		}
+		s.logger.Info("Deleting study sessions older than 1 week")
 		// Calculate the cutoff date (1 week ago)
 		cutoffDate := time.Now().AddDate(0, 0, -7)
 
-		err := s.bot.db.DeleteOldStudySessions(ctx, cutoffDate)
+		result, err := s.sessions.PurgeOldSessions(ctx, cutoffDate)
 		if err != nil {
-			log.Printf("Error deleting old study sessions: %v", err)
+			s.logger.Error("Failed to delete old study sessions", "error", err)
 		} else {
-			log.Println("Successfully completed job to delete old study sessions.")
+			s.logger.Info("Deleted old study sessions", "before", cutoffDate, "deleted", result.Deleted)
 		}
-	})
+		return err
+	}))
 	if err != nil {
-		log.Printf("Error adding job to delete old study sessions: %v", err)
+		s.logger.Error("Failed to add job to delete old study sessions", "error", err)
 	}
 
 	s.cron.Start()
-	log.Println("Scheduler started")
+	s.logger.Info("Scheduler started")
+}
+
+// job adapts fn to a cron job whose run time and failures are recorded under name in /metrics
+func (s *Scheduler) job(name string, fn func(ctx context.Context) error) func() {
+	return func() {
+		_ = metrics.RunJob(name, func() error { return fn(context.Background()) })
+	}
+}
+
+// postRecaps posts the recap for the period that just ended. A failure is logged and the
+// reset still runs, since recaps are built from daily activity rather than the counters.
+func (s *Scheduler) postRecaps(ctx context.Context, period string) {
+	if s.recaps == nil {
+		return
+	}
+	s.logger.InfoContext(ctx, "Posting recaps", "period", period)
+	if _, err := s.recaps.PostRecaps(ctx, period, time.Now()); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to post recaps", "period", period, "error", err)
+	}
 }
 
 // Stop stops the scheduler
 func (s *Scheduler) Stop() {
 	ctx := s.cron.Stop()
 	<-ctx.Done()
-	log.Println("Scheduler stopped")
+	s.logger.Info("Scheduler stopped")
+}
+
+// Health reports whether the reset and cleanup jobs are still being scheduled
+func (s *Scheduler) Health(_ context.Context) health.Result {
+	return health.CheckCron(s.cron, time.Now())
 }
@@ -2,12 +2,14 @@ package bot
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
-	"log"
 	"time"
 
-	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/discord"
+	"github.com/Skufu/LockIn-Bot/internal/logging"
+	"github.com/Skufu/LockIn-Bot/internal/metrics"
+	"github.com/Skufu/LockIn-Bot/internal/service"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -49,15 +51,15 @@ func (s *SessionTimeoutChecker) checkTimeoutsLoop() {
 
 // checkAndEndTimeoutSessions finds and ends sessions that have been running too long
 func (s *SessionTimeoutChecker) checkAndEndTimeoutSessions() {
-	ctx := context.Background()
-	now := time.Now()
+	ctx := logging.WithEventID(context.Background(), logging.NewEventID())
+	now := s.bot.clock.Now()
 	maxDuration := time.Duration(s.maxSessionHours) * time.Hour
 
 	s.bot.activeSessionMu.Lock()
 	var timeoutUsers []string
 
-	for userID, startTime := range s.bot.activeSessions {
-		if now.Sub(startTime) > maxDuration {
+	for userID, active := range s.bot.activeSessions {
+		if now.Sub(active.StartTime) > maxDuration {
 			timeoutUsers = append(timeoutUsers, userID)
 		}
 	}
@@ -67,7 +69,7 @@ func (s *SessionTimeoutChecker) checkAndEndTimeoutSessions() {
 		return
 	}
 
-	log.Printf("Found %d sessions that have exceeded %d hours, ending them", len(timeoutUsers), s.maxSessionHours)
+	s.bot.logger.InfoContext(ctx, "Ending sessions that exceeded the maximum length", "count", len(timeoutUsers), "max_hours", s.maxSessionHours)
 
 	for _, userID := range timeoutUsers {
 		s.endTimeoutSession(ctx, userID, now)
@@ -76,91 +78,76 @@ func (s *SessionTimeoutChecker) checkAndEndTimeoutSessions() {
 
 // endTimeoutSession ends a single timeout session
 func (s *SessionTimeoutChecker) endTimeoutSession(ctx context.Context, userID string, now time.Time) {
-	log.Printf("Ending timeout session for user %s", userID)
+	logger := s.bot.logger.With("user_id", userID)
+	logger.DebugContext(ctx, "Checking timed out session")
 
 	// Get the user object for notifications (used later for logging)
 	_, err := s.bot.session.User(userID)
 	if err != nil {
-		log.Printf("Could not fetch user %s for timeout session end: %v", userID, err)
+		logger.WarnContext(ctx, "Could not fetch user for timeout session end", "error", err)
 	}
 
 	// Check if user is actually still in a voice channel
 	isStillInVoice := s.isUserInTrackedVoiceChannel(userID)
 	if isStillInVoice {
-		log.Printf("User %s is still in a voice channel, allowing session to continue", userID)
+		logger.InfoContext(ctx, "Still in a voice channel, allowing session to continue")
 		return
 	}
 
 	// End the session
 	s.bot.activeSessionMu.Lock()
-	startTime, exists := s.bot.activeSessions[userID]
+	active, exists := s.bot.activeSessions[userID]
 	if !exists {
 		s.bot.activeSessionMu.Unlock()
 		return
 	}
 	delete(s.bot.activeSessions, userID)
 	s.bot.activeSessionMu.Unlock()
+	s.bot.requestLiveStatusRefresh()
+	logger = logger.With("guild_id", active.GuildID)
 
-	// End the database session
-	activeDBSession, err := s.bot.db.GetActiveStudySession(ctx, sql.NullString{String: userID, Valid: true})
-	if err != nil {
-		if err != sql.ErrNoRows {
-			log.Printf("Error getting active DB session for timeout user %s: %v", userID, err)
-		}
+	if s.bot.sessionService == nil {
+		logger.ErrorContext(ctx, "SessionService not available, cannot end timeout session")
 		return
 	}
 
-	endedSession, err := s.bot.db.EndStudySession(ctx, database.EndStudySessionParams{
-		SessionID: activeDBSession.SessionID,
-		EndTime:   sql.NullTime{Time: now, Valid: true},
+	// End the database session and credit stats in one transaction
+	result, err := s.bot.sessionService.EndSession(ctx, service.EndSessionRequest{
+		UserID:      userID,
+		GuildID:     active.GuildID,
+		EndTime:     now,
+		AuditAction: service.AuditActionSessionTimeout,
+		Reason:      fmt.Sprintf("Open for over %d hours with the member no longer in voice", s.maxSessionHours),
 	})
 	if err != nil {
-		log.Printf("Error ending timeout session %d for user %s: %v", activeDBSession.SessionID, userID, err)
+		if !errors.Is(err, service.ErrNoActiveSession) {
+			logger.ErrorContext(ctx, "Failed to end timeout session", "error", err)
+			// Track the session again so the next check retries ending it
+			s.bot.activeSessionMu.Lock()
+			if _, rejoined := s.bot.activeSessions[userID]; !rejoined {
+				s.bot.activeSessions[userID] = active
+			}
+			s.bot.activeSessionMu.Unlock()
+			s.bot.requestLiveStatusRefresh()
+		}
 		return
 	}
 
-	duration := now.Sub(startTime)
-	log.Printf("Ended timeout session %d for user %s. Duration: %s (DB: %d ms)",
-		endedSession.SessionID, userID, formatDuration(duration), endedSession.DurationMs.Int64)
-
-	// Update user stats
-	if endedSession.DurationMs.Valid && endedSession.DurationMs.Int64 > 0 {
-		_, err = s.bot.db.CreateOrUpdateUserStats(ctx, database.CreateOrUpdateUserStatsParams{
-			UserID:       userID,
-			TotalStudyMs: sql.NullInt64{Int64: endedSession.DurationMs.Int64, Valid: true},
-		})
-		if err != nil {
-			log.Printf("Error updating user stats for timeout user %s after session %d: %v", userID, endedSession.SessionID, err)
-		}
-	}
+	metrics.SessionsTimedOut.Inc()
+	duration := now.Sub(active.StartTime)
+	logger.InfoContext(ctx, "Ended timeout session", "session_id", result.Session.SessionID,
+		"duration", formatDuration(duration), "duration_ms", result.Session.DurationMs.Int64)
 
 	// Send notification about the ended session
-	if s.bot.LoggingChannelID != "" && endedSession.DurationMs.Valid && endedSession.DurationMs.Int64 > 0 {
-		durationForMessage := time.Duration(endedSession.DurationMs.Int64) * time.Millisecond
-		formattedDuration := formatDuration(durationForMessage)
-		message := fmt.Sprintf("⏰ <@%s> session auto-ended after %s (session cleanup)", userID, formattedDuration)
-		_, err = s.bot.session.ChannelMessageSend(s.bot.LoggingChannelID, message)
-		if err != nil {
-			log.Printf("Error sending timeout session message for user %s: %v", userID, err)
-		}
+	if result.Duration() > 0 {
+		message := fmt.Sprintf("⏰ <@%s> session auto-ended after %s (session cleanup)", userID, formatDuration(result.Duration()))
+		s.bot.announceSessionEnd(ctx, result, userID, message)
 	}
 }
 
 // isUserInTrackedVoiceChannel checks if a user is currently in any tracked voice channel
 func (s *SessionTimeoutChecker) isUserInTrackedVoiceChannel(userID string) bool {
-	// Get all guilds the bot is in
-	for _, guild := range s.bot.session.State.Guilds {
-		// Check voice states in this guild
-		for _, voiceState := range guild.VoiceStates {
-			if voiceState.UserID == userID && voiceState.ChannelID != "" {
-				// Check if this channel is tracked
-				if _, tracked := s.bot.allowedVoiceChannelIDs[voiceState.ChannelID]; tracked {
-					return true
-				}
-			}
-		}
-	}
-	return false
+	return s.bot.isUserInTrackedVoiceChannel(userID)
 }
 
 // ImprovedVoiceStateHandler contains enhanced voice state update logic
@@ -174,7 +161,7 @@ func NewImprovedVoiceStateHandler(bot *Bot) *ImprovedVoiceStateHandler {
 }
 
 // HandleVoiceStateUpdate processes voice state updates with better session management
-func (h *ImprovedVoiceStateHandler) HandleVoiceStateUpdate(s *discordgo.Session, v *discordgo.VoiceStateUpdate) {
+func (h *ImprovedVoiceStateHandler) HandleVoiceStateUpdate(s discord.Session, v *discordgo.VoiceStateUpdate) {
 	// Enhanced logging for debugging
 	beforeChannel := "none"
 	if v.BeforeUpdate != nil && v.BeforeUpdate.ChannelID != "" {
@@ -185,14 +172,16 @@ func (h *ImprovedVoiceStateHandler) HandleVoiceStateUpdate(s *discordgo.Session,
 		currentChannel = v.ChannelID
 	}
 
-	log.Printf("Voice state update: User %s moved from %s to %s", v.UserID, beforeChannel, currentChannel)
+	h.bot.logger.Debug("Voice state update", "user_id", v.UserID, "guild_id", v.GuildID,
+		"from_channel_id", beforeChannel, "channel_id", currentChannel)
 
 	// Check for potential session inconsistencies FIRST
 	h.validateSessionConsistency(v.UserID)
 
 	// Deduplication with more detailed logging
-	if h.bot.isDuplicateVoiceEvent(v) {
-		log.Printf("Skipping duplicate voice event for user %s (from %s to %s)", v.UserID, beforeChannel, currentChannel)
+	if h.bot.isDuplicateVoiceEvent(context.Background(), v) {
+		h.bot.logger.Debug("Skipping duplicate voice event", "user_id", v.UserID, "guild_id", v.GuildID,
+			"from_channel_id", beforeChannel, "channel_id", currentChannel)
 		return
 	}
 
@@ -210,17 +199,32 @@ func (h *ImprovedVoiceStateHandler) validateSessionConsistency(userID string) {
 		// User has active session - verify they're actually in a tracked channel
 		isInTrackedChannel := h.isUserInAnyTrackedChannel(userID)
 		if !isInTrackedChannel {
-			log.Printf("INCONSISTENCY: User %s has active session but not in any tracked channel. Will end session on next leave event.", userID)
+			h.bot.logger.Warn("Active session but not in any tracked channel; it will end on the next leave event", "user_id", userID)
 		}
 	}
 }
 
 // isUserInAnyTrackedChannel checks if user is in any tracked voice channel
 func (h *ImprovedVoiceStateHandler) isUserInAnyTrackedChannel(userID string) bool {
-	for _, guild := range h.bot.session.State.Guilds {
+	return h.bot.isUserInTrackedVoiceChannel(userID)
+}
+
+// isUserInTrackedVoiceChannel checks the gateway state cache for the user in any tracked voice channel
+func (b *Bot) isUserInTrackedVoiceChannel(userID string) bool {
+	if b.state == nil {
+		return false
+	}
+
+	b.state.RLock()
+	defer b.state.RUnlock()
+
+	// Get all guilds the bot is in
+	for _, guild := range b.state.Guilds {
+		// Check voice states in this guild
 		for _, voiceState := range guild.VoiceStates {
 			if voiceState.UserID == userID && voiceState.ChannelID != "" {
-				if _, tracked := h.bot.allowedVoiceChannelIDs[voiceState.ChannelID]; tracked {
+				// Check if this channel is tracked
+				if _, tracked := b.allowedVoiceChannelIDs[voiceState.ChannelID]; tracked {
 					return true
 				}
 			}
@@ -233,5 +237,5 @@ func (h *ImprovedVoiceStateHandler) isUserInAnyTrackedChannel(userID string) boo
 func (b *Bot) StartSessionTimeoutChecker() {
 	checker := NewSessionTimeoutChecker(b, 4, 10*time.Minute) // Max 4 hours, check every 10 minutes
 	checker.Start()
-	log.Println("Started session timeout checker (max 4 hours, check every 10 minutes)")
+	b.logger.Info("Started session timeout checker", "max_hours", 4, "check_interval", 10*time.Minute)
 }
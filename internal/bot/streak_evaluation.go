@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Skufu/LockIn-Bot/internal/discord"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleSlashEvaluateStreaksCommand lets members who can run backfills evaluate streaks for the
+// days their server missed, e.g. while the bot was down at 11:59 PM. Members already evaluated for
+// a day are skipped, so running it again never counts a day twice.
+func (b *Bot) handleSlashEvaluateStreaksCommand(s discord.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+	if b.streakService == nil {
+		b.logger.Error("StreakService not available for /evaluate-streaks command")
+		respondEphemeral(s, i, "Streak service is currently unavailable.")
+		return
+	}
+
+	ctx := context.Background()
+	now := b.clock.Now()
+	summary, err := b.streakService.CatchUpGuildEvaluations(ctx, i.GuildID, now)
+	if err != nil {
+		b.logger.Error("Failed to evaluate streaks", "guild_id", i.GuildID, "error", err)
+		if summary.Failed == 0 {
+			respondEphemeral(s, i, "Something went wrong while evaluating streaks. Please try again later.")
+			return
+		}
+	}
+
+	b.recordAudit(ctx, service.AuditEntry{
+		GuildID: i.GuildID,
+		ActorID: interactionUserID(i),
+		Action:  service.AuditActionEvaluateStreaks,
+		Details: map[string]any{"days": len(summary.Dates), "evaluated": summary.Evaluated, "skipped": summary.Skipped, "failed": summary.Failed},
+	})
+
+	description := "Streaks are already evaluated for every day up to yesterday."
+	if len(summary.Dates) > 0 {
+		first, last := summary.Dates[0], summary.Dates[len(summary.Dates)-1]
+		days := first.Format("Jan 2")
+		if !last.Equal(first) {
+			days += " – " + last.Format("Jan 2")
+		}
+		description = fmt.Sprintf("Evaluated %s: **%d** member evaluations run, %d already done.", days, summary.Evaluated, summary.Skipped)
+	}
+	color := 0x00AAFF
+	if summary.Failed > 0 {
+		color = 0xFFA500
+		description += fmt.Sprintf("\n\n⚠️ Evaluation stopped early (%d members failed). Run the command again to retry.", summary.Failed)
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "🧮 Streak Evaluation",
+				Description: description,
+				Color:       color,
+				Footer:      &discordgo.MessageEmbedFooter{Text: "Today is evaluated at 11:59 PM Manila time."},
+			}},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.logger.Error("Failed to send /evaluate-streaks response", "error", err)
+	}
+}
@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func evaluateStreaksInteraction() *discordgo.InteractionCreate {
+	i := createTestInteraction("admin-1", "admin", flowGuildID)
+	i.Member.Permissions = discordgo.PermissionAdministrator
+	i.Data = discordgo.ApplicationCommandInteractionData{Name: "evaluate-streaks"}
+	return i
+}
+
+func TestEvaluateStreaks_CatchesUpDaysMissedWhileDown(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	manila := service.GetManilaLocation()
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, manila) }
+
+	streaks := service.NewStreakService(db, db, nil, b.cfg)
+	b.SetStreakService(streaks)
+
+	seedStreak(t, db, flowUserID, 3, day(14))
+	seedStreak(t, db, "user-2", 3, day(14))
+	streaks.EvaluateStreaksForDate(ctx, day(14))
+	require.Equal(t, int32(4), currentStreak(t, db, flowUserID))
+
+	// The bot then missed the 11:59 PM runs on Thursday and Friday. user-1 studied both days and
+	// again on Saturday morning, so their streak row has moved on; user-2 only studied Thursday.
+	addActivity(t, db, flowUserID, flowGuildID, day(15), 30*time.Minute)
+	addActivity(t, db, flowUserID, flowGuildID, day(16), 30*time.Minute)
+	studyOn(t, db, flowUserID, day(17), 30)
+	addActivity(t, db, "user-2", flowGuildID, day(15), 30*time.Minute)
+	studyOn(t, db, "user-2", day(15), 30)
+
+	b.clock = clock.NewFake(day(17).Add(10 * time.Hour))
+	b.handleSlashEvaluateStreaksCommand(session, evaluateStreaksInteraction())
+
+	assert.Equal(t, int32(6), currentStreak(t, db, flowUserID))
+	assert.Equal(t, int32(0), currentStreak(t, db, "user-2"), "Friday was missed")
+	keys := dedupeKeys(db.Notifications())
+	assert.Contains(t, keys, "streak_evaluation:guild-1:user-2:2026-10-15")
+	assert.Contains(t, keys, "streak_evaluation:guild-1:user-2:2026-10-16")
+
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	require.Len(t, resp.Data.Embeds, 1)
+	assert.Contains(t, resp.Data.Embeds[0].Description, "Oct 15 – Oct 16")
+	assert.Contains(t, resp.Data.Embeds[0].Description, "**4** member evaluations run")
+
+	// Running it again finds nothing left to do and changes nothing
+	notifications := len(db.Notifications())
+	b.handleSlashEvaluateStreaksCommand(session, evaluateStreaksInteraction())
+	assert.Contains(t, session.LastResponse().Data.Embeds[0].Description, "already evaluated")
+	assert.Equal(t, int32(6), currentStreak(t, db, flowUserID))
+	assert.Len(t, db.Notifications(), notifications)
+
+	// Saturday itself is left for the 11:59 PM run
+	streaks.EvaluateStreaksForDate(ctx, day(17))
+	assert.Equal(t, int32(7), currentStreak(t, db, flowUserID))
+}
+
+func TestEvaluateStreaks_RerunningADayDoesNotDoubleCount(t *testing.T) {
+	b, db, _ := createFlowBot(t)
+	ctx := context.Background()
+	manila := service.GetManilaLocation()
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, manila)
+
+	streaks := service.NewStreakService(db, db, nil, b.cfg)
+	seedStreak(t, db, flowUserID, 3, day)
+
+	first := streaks.EvaluateStreaksForDate(ctx, day)
+	second := streaks.EvaluateStreaksForDate(ctx, day)
+
+	assert.Equal(t, 1, first.Evaluated)
+	assert.Equal(t, 0, second.Evaluated)
+	assert.Equal(t, int32(4), currentStreak(t, db, flowUserID))
+	events, err := db.GetStreakEvents(ctx, database.GetStreakEventsParams{UserID: flowUserID, GuildID: flowGuildID, FromDate: day, ToDate: day})
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestEvaluateStreaks_RequiresAdmin(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	b.SetStreakService(service.NewStreakService(db, db, nil, b.cfg))
+	seedStreak(t, db, flowUserID, 3, time.Date(2026, 10, 16, 0, 0, 0, 0, service.GetManilaLocation()))
+
+	i := evaluateStreaksInteraction()
+	i.Member.Permissions = 0
+	b.handleInteractionCreate(session, i)
+
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	assert.Contains(t, resp.Data.Content, "don't have permission")
+	assert.Equal(t, int32(3), currentStreak(t, db, flowUserID))
+}
@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streakCalendarInteraction builds a /streak calendar interaction
+func streakCalendarInteraction(userID string) *discordgo.InteractionCreate {
+	i := createTestInteraction(userID, "alice", flowGuildID)
+	i.Data = discordgo.ApplicationCommandInteractionData{
+		Name: "streak",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "calendar", Type: discordgo.ApplicationCommandOptionSubCommand},
+		},
+	}
+	return i
+}
+
+func TestStreakHistory_EvaluationsAreLoggedAndShownOnCalendar(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	manila := service.GetManilaLocation()
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, manila) }
+
+	streaks := service.NewStreakService(db, db, nil, b.cfg)
+	b.SetStreakService(streaks)
+	b.handleSlashStreakModeCommand(session, streakModeInteraction(service.StreakModeWeekdays, 0))
+
+	// Studied Thursday the 1st and Friday the 2nd, kept the streak over the weekend, missed Monday,
+	// repaired it on Tuesday and kept it going on Wednesday
+	studyOn(t, db, flowUserID, day(1), 30)
+	streaks.EvaluateStreaksForDate(ctx, day(1))
+	studyOn(t, db, flowUserID, day(2), 30)
+	for d := 2; d <= 5; d++ {
+		streaks.EvaluateStreaksForDate(ctx, day(d))
+	}
+	studyOn(t, db, flowUserID, day(6), 45)
+	streaks.EvaluateStreaksForDate(ctx, day(6))
+	studyOn(t, db, flowUserID, day(7), 30)
+	streaks.EvaluateStreaksForDate(ctx, day(7))
+	assert.Equal(t, int32(4), currentStreak(t, db, flowUserID))
+
+	events, err := db.GetStreakEvents(ctx, database.GetStreakEventsParams{UserID: flowUserID, GuildID: flowGuildID, FromDate: day(1), ToDate: day(31)})
+	require.NoError(t, err)
+	var types []string
+	for _, e := range events {
+		types = append(types, e.EventType)
+	}
+	assert.Equal(t, []string{"start", "increment", "freeze", "freeze", "break", "repair", "increment"}, types)
+	assert.Equal(t, int32(2), events[4].StreakCount, "a break keeps the length the streak reached")
+
+	// Evaluating the same day again doesn't log it twice
+	streaks.EvaluateStreaksForDate(ctx, day(6))
+	events, err = db.GetStreakEvents(ctx, database.GetStreakEventsParams{UserID: flowUserID, GuildID: flowGuildID, FromDate: day(1), ToDate: day(31)})
+	require.NoError(t, err)
+	assert.Len(t, events, 7)
+
+	b.clock = clock.NewFake(time.Date(2026, 10, 8, 12, 0, 0, 0, manila))
+	b.handleSlashStreakCommand(session, streakCalendarInteraction(flowUserID))
+	resp := session.LastResponse()
+	require.Len(t, resp.Data.Embeds, 1)
+	embed := resp.Data.Embeds[0]
+	assert.Equal(t, "📅 Streak Calendar: October 2026", embed.Title)
+	assert.Contains(t, embed.Description, "➖➖➖➖🟩🟩🧊\n🧊🟥🩹🟩⬛⬛⬛")
+	assert.Equal(t, "No ended streaks yet.", fieldValue(embed, "📜 Past Streaks"), "the repair undid Monday's break")
+	assert.Contains(t, fieldValue(embed, "Legend"), "repaired")
+}
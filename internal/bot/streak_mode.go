@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"context"
+
+	"github.com/Skufu/LockIn-Bot/internal/discord"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Bounds for the weekly_hours option of /streak-mode
+var (
+	weeklyHoursMin float64 = 1
+	weeklyHoursMax float64 = 80
+)
+
+// streakModeChoices are the streak modes offered by /streak-mode
+var streakModeChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: "Daily", Value: service.StreakModeDaily},
+	{Name: "Weekdays only", Value: service.StreakModeWeekdays},
+	{Name: "Weekly goal", Value: service.StreakModeWeekly},
+}
+
+// handleSlashStreakModeCommand lets admins choose how streaks are counted in their server
+func (b *Bot) handleSlashStreakModeCommand(s discord.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+	if b.streakService == nil {
+		b.logger.Error("StreakService not available for /streak-mode command")
+		respondEphemeral(s, i, "Streak service is currently unavailable.")
+		return
+	}
+
+	ctx := context.Background()
+	rules, err := b.streakService.GetStreakRules(ctx, i.GuildID)
+	if err != nil {
+		b.logger.Error("Failed to load streak rules", "guild_id", i.GuildID, "error", err)
+		respondEphemeral(s, i, "Something went wrong while loading the streak mode. Please try again later.")
+		return
+	}
+
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "mode":
+			rules.Mode = opt.StringValue()
+		case "weekly_hours":
+			rules.WeeklyGoalMinutes = int(opt.IntValue()) * 60
+		case "repair":
+			rules.Repair = opt.BoolValue()
+		}
+	}
+	if !service.IsValidStreakMode(rules.Mode) {
+		respondEphemeral(s, i, "Unknown streak mode.")
+		return
+	}
+
+	if err := b.streakService.SetStreakRules(ctx, i.GuildID, rules); err != nil {
+		b.logger.Error("Failed to save streak rules", "guild_id", i.GuildID, "error", err)
+		respondEphemeral(s, i, "Something went wrong while saving the streak mode. Please try again later.")
+		return
+	}
+	b.logger.Info("Changed streak mode", "guild_id", i.GuildID, "mode", rules.Mode, "repair", rules.Repair)
+	b.recordAudit(ctx, service.AuditEntry{
+		GuildID: i.GuildID,
+		ActorID: interactionUserID(i),
+		Action:  service.AuditActionStreakMode,
+		Details: map[string]any{"mode": rules.Mode, "weeklyGoalMinutes": rules.WeeklyGoalMinutes, "repair": rules.Repair},
+	})
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "📏 Streak Mode Updated",
+				Description: rules.Describe(),
+				Color:       0x00AAFF,
+				Footer:      &discordgo.MessageEmbedFooter{Text: "Streaks switching between days and weeks restart at the next evaluation."},
+			}},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.logger.Error("Failed to send /streak-mode response", "error", err)
+	}
+}
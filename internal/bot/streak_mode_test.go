@@ -0,0 +1,147 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/database/fakedb"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streakModeInteraction builds an admin /streak-mode interaction
+func streakModeInteraction(mode string, weeklyHours int) *discordgo.InteractionCreate {
+	i := createTestInteraction("admin-1", "admin", flowGuildID)
+	i.Member.Permissions = discordgo.PermissionAdministrator
+	options := []*discordgo.ApplicationCommandInteractionDataOption{stringOption("mode", mode)}
+	if weeklyHours > 0 {
+		options = append(options, intOption("weekly_hours", weeklyHours))
+	}
+	i.Data = discordgo.ApplicationCommandInteractionData{Name: "streak-mode", Options: options}
+	return i
+}
+
+// seedStreak gives a user a streak in the flow guild with activity on lastActive
+func seedStreak(t *testing.T, db *fakedb.Querier, userID string, count int32, lastActive time.Time) {
+	t.Helper()
+	ctx := context.Background()
+	_, err := db.StartDailyActivity(ctx, database.StartDailyActivityParams{
+		UserID:           userID,
+		GuildID:          flowGuildID,
+		LastActivityDate: sql.NullTime{Time: lastActive, Valid: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateDailyActivityMinutes(ctx, database.UpdateDailyActivityMinutesParams{
+		UserID:               userID,
+		GuildID:              flowGuildID,
+		DailyActivityMinutes: sql.NullInt32{Int32: 30, Valid: true},
+	}))
+	require.NoError(t, db.UpdateStreakImmediately(ctx, database.UpdateStreakImmediatelyParams{UserID: userID, GuildID: flowGuildID, CurrentStreakCount: count, MaxStreakCount: count}))
+}
+
+func currentStreak(t *testing.T, db *fakedb.Querier, userID string) int32 {
+	t.Helper()
+	streak, err := db.GetUserStreak(context.Background(), database.GetUserStreakParams{UserID: userID, GuildID: flowGuildID})
+	require.NoError(t, err)
+	return streak.CurrentStreakCount
+}
+
+func TestStreakMode_WeekdaysSkipWeekends(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	manila := service.GetManilaLocation()
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, manila) }
+
+	streaks := service.NewStreakService(db, db, nil, b.cfg)
+	b.SetStreakService(streaks)
+	b.handleSlashStreakModeCommand(session, streakModeInteraction(service.StreakModeWeekdays, 0))
+
+	// Active on Friday the 16th, then nothing over the weekend
+	seedStreak(t, db, flowUserID, 3, day(16))
+
+	streaks.EvaluateStreaksForDate(ctx, day(17))
+	streaks.EvaluateStreaksForDate(ctx, day(18))
+	assert.Equal(t, int32(3), currentStreak(t, db, flowUserID), "weekends don't break a weekdays streak")
+	assert.NotContains(t, dedupeKeys(db.Notifications()), "streak_evaluation:guild-1:user-1:2026-10-17")
+
+	// Missing Monday does
+	streaks.EvaluateStreaksForDate(ctx, day(19))
+	assert.Equal(t, int32(0), currentStreak(t, db, flowUserID))
+	assert.Contains(t, dedupeKeys(db.Notifications()), "streak_evaluation:guild-1:user-1:2026-10-19")
+}
+
+func TestStreakMode_WeeklyGoal(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	manila := service.GetManilaLocation()
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, manila) }
+
+	streaks := service.NewStreakService(db, db, nil, b.cfg)
+	b.SetStreakService(streaks)
+	b.handleSlashStreakModeCommand(session, streakModeInteraction(service.StreakModeWeekly, 2))
+	resp := session.LastResponse()
+	require.Len(t, resp.Data.Embeds, 1)
+	assert.Contains(t, resp.Data.Embeds[0].Description, "2h 0m each week")
+
+	// A daily streak can't carry over into weekly mode
+	seedStreak(t, db, flowUserID, 5, day(14))
+	for d, minutes := range map[int]int64{11: 60, 14: 70} {
+		require.NoError(t, db.AddDailyActivity(ctx, database.AddDailyActivityParams{
+			UserID:       flowUserID,
+			GuildID:      flowGuildID,
+			ActivityDate: day(d),
+			StudyMs:      minutes * time.Minute.Milliseconds(),
+		}))
+	}
+
+	// Only Saturday, when the week is over, counts
+	streaks.EvaluateStreaksForDate(ctx, day(14))
+	assert.Equal(t, int32(0), currentStreak(t, db, flowUserID))
+
+	streaks.EvaluateStreaksForDate(ctx, day(17))
+	assert.Equal(t, int32(1), currentStreak(t, db, flowUserID), "130 minutes meets the 2 hour goal")
+	for _, n := range db.Notifications() {
+		if n.DedupeKey == "streak_evaluation:guild-1:user-1:2026-10-17" {
+			assert.Contains(t, string(n.Payload), "**1 week** strong")
+		}
+	}
+
+	// Nothing studied the next week
+	streaks.EvaluateStreaksForDate(ctx, day(24))
+	assert.Equal(t, int32(0), currentStreak(t, db, flowUserID))
+}
+
+func TestHandleSlashStreakCommand_ExplainsRules(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	streaks := service.NewStreakService(db, db, nil, b.cfg)
+	b.SetStreakService(streaks)
+	seedStreak(t, db, flowUserID, 2, service.GetTodayManilaDate())
+
+	b.handleSlashStreakCommand(session, createTestInteraction(flowUserID, "alice", flowGuildID))
+	embed := session.LastResponse().Data.Embeds[0]
+	assert.Contains(t, fieldValue(embed, "📏 Streak Rules"), "every day")
+	assert.Equal(t, "2 days", fieldValue(embed, "Current Streak"))
+
+	b.handleSlashStreakModeCommand(session, streakModeInteraction(service.StreakModeWeekly, 0))
+	b.handleSlashStreakCommand(session, createTestInteraction(flowUserID, "alice", flowGuildID))
+	embed = session.LastResponse().Data.Embeds[0]
+	assert.Contains(t, fieldValue(embed, "📏 Streak Rules"), "5h 0m each week")
+	assert.NotEmpty(t, fieldValue(embed, "This Week's Activity"))
+}
+
+func TestHandleSlashStreakModeCommand_RequiresAdmin(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	b.SetStreakService(service.NewStreakService(db, db, nil, b.cfg))
+
+	i := streakModeInteraction(service.StreakModeWeekly, 0)
+	i.Member.Permissions = 0
+	b.handleInteractionCreate(session, i)
+	assert.Contains(t, session.LastResponse().Data.Content, "permission")
+	_, err := db.GetGuildSettings(context.Background(), flowGuildID)
+	assert.Error(t, err)
+}
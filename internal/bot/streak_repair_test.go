@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/database/fakedb"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// studyOn records minutes of streak activity for a user on date
+func studyOn(t *testing.T, db *fakedb.Querier, userID string, date time.Time, minutes int32) {
+	t.Helper()
+	ctx := context.Background()
+	_, err := db.StartDailyActivity(ctx, database.StartDailyActivityParams{
+		UserID:           userID,
+		GuildID:          flowGuildID,
+		LastActivityDate: sql.NullTime{Time: date, Valid: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateDailyActivityMinutes(ctx, database.UpdateDailyActivityMinutesParams{
+		UserID:               userID,
+		GuildID:              flowGuildID,
+		DailyActivityMinutes: sql.NullInt32{Int32: minutes, Valid: true},
+	}))
+}
+
+func TestStreakRepair_DoubleMinutesNextDayRestoresStreak(t *testing.T) {
+	b, db, _ := createFlowBot(t)
+	ctx := context.Background()
+	manila := service.GetManilaLocation()
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, manila) }
+
+	streaks := service.NewStreakService(db, db, nil, b.cfg)
+	b.SetStreakService(streaks)
+
+	// Both users studied on Wednesday and missed Thursday
+	seedStreak(t, db, flowUserID, 5, day(14))
+	seedStreak(t, db, "user-2", 5, day(14))
+	streaks.EvaluateStreaksForDate(ctx, day(15))
+
+	streak, err := db.GetUserStreak(ctx, database.GetUserStreakParams{UserID: flowUserID, GuildID: flowGuildID})
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), streak.CurrentStreakCount)
+	assert.Equal(t, int32(5), streak.PreviousStreak)
+	assert.True(t, streak.StreakBrokenAt.Time.Equal(day(15)))
+	for _, n := range db.Notifications() {
+		if n.DedupeKey == "streak_evaluation:guild-1:user-1:2026-10-15" {
+			assert.Contains(t, string(n.Payload), "Streak repair")
+			assert.Contains(t, string(n.Payload), "Friday, Oct 16")
+		}
+	}
+
+	// On Friday only user-2 studies double the minimum
+	studyOn(t, db, flowUserID, day(16), 1)
+	studyOn(t, db, "user-2", day(16), 2)
+	streaks.EvaluateStreaksForDate(ctx, day(16))
+
+	assert.Equal(t, int32(1), currentStreak(t, db, flowUserID), "the usual minimum starts a new streak")
+	assert.Equal(t, int32(6), currentStreak(t, db, "user-2"), "double the minimum restores the old streak")
+	for _, n := range db.Notifications() {
+		if n.DedupeKey == "streak_evaluation:guild-1:user-2:2026-10-16" {
+			assert.Contains(t, string(n.Payload), "Streak Repaired")
+		}
+	}
+
+	// The repair window is only the next day: missing Saturday and Sunday loses the streak for good
+	streaks.EvaluateStreaksForDate(ctx, day(17))
+	streaks.EvaluateStreaksForDate(ctx, day(18))
+	studyOn(t, db, "user-2", day(19), 2)
+	streaks.EvaluateStreaksForDate(ctx, day(19))
+	assert.Equal(t, int32(1), currentStreak(t, db, "user-2"))
+}
+
+func TestStreakRepair_CanBeTurnedOff(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	manila := service.GetManilaLocation()
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, manila) }
+
+	streaks := service.NewStreakService(db, db, nil, b.cfg)
+	b.SetStreakService(streaks)
+
+	i := streakModeInteraction(service.StreakModeDaily, 0)
+	data := i.ApplicationCommandData()
+	data.Options = append(data.Options, boolOption("repair", false))
+	i.Data = data
+	b.handleSlashStreakModeCommand(session, i)
+	assert.NotContains(t, session.LastResponse().Data.Embeds[0].Description, "repair")
+
+	seedStreak(t, db, flowUserID, 5, day(14))
+	streaks.EvaluateStreaksForDate(ctx, day(15))
+	studyOn(t, db, flowUserID, day(16), 30)
+	streaks.EvaluateStreaksForDate(ctx, day(16))
+	assert.Equal(t, int32(1), currentStreak(t, db, flowUserID))
+}
+
+func TestHandleSlashStreakCommand_ShowsRepairProgress(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	b.SetStreakService(service.NewStreakService(db, db, nil, b.cfg))
+
+	today := service.GetTodayManilaDate()
+	seedStreak(t, db, flowUserID, 0, today.AddDate(0, 0, -2))
+	require.NoError(t, db.UpdateStreakBreak(ctx, database.UpdateStreakBreakParams{
+		UserID:         flowUserID,
+		GuildID:        flowGuildID,
+		PreviousStreak: 9,
+		StreakBrokenAt: sql.NullTime{Time: today.AddDate(0, 0, -1), Valid: true},
+	}))
+
+	b.handleSlashStreakCommand(session, createTestInteraction(flowUserID, "alice", flowGuildID))
+	embed := session.LastResponse().Data.Embeds[0]
+	assert.Contains(t, embed.Description, "**9 day** streak")
+	assert.Equal(t, "0/2 minutes today", fieldValue(embed, "🩹 Streak Repair"))
+}
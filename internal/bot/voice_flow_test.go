@@ -0,0 +1,438 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/config"
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/database/fakedb"
+	"github.com/Skufu/LockIn-Bot/internal/discord/fakediscord"
+	"github.com/Skufu/LockIn-Bot/internal/logging"
+	"github.com/Skufu/LockIn-Bot/internal/metrics"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	flowGuildID   = "guild-1"
+	flowChannelID = "study-vc"
+	flowUserID    = "user-1"
+)
+
+var flowUserKey = sql.NullString{String: flowUserID, Valid: true}
+
+// createFlowBot wires a bot with real services on top of the in-memory database and Discord fakes
+func createFlowBot(t *testing.T) (*Bot, *fakedb.Querier, *fakediscord.Session) {
+	t.Helper()
+
+	cfg := &config.Config{
+		LoggingChannelID:            "log-channel",
+		AchievementChannelID:        "achievement-channel",
+		StreakNotificationChannelID: "streak-channel",
+		AllowedVoiceChannelIDsMap:   map[string]struct{}{flowChannelID: {}},
+	}
+
+	db := fakedb.New()
+	session := fakediscord.New()
+	session.AddUser(flowUserID, "alice")
+
+	b := newBot(session, session.State, db, cfg)
+
+	streakService := service.NewStreakService(db, db, nil, cfg)
+	achievementService := service.NewAchievementService(db, nil, cfg)
+	notificationService := service.NewNotificationService(db, nil)
+
+	sessionService := service.NewSessionService(db)
+	sessionService.SetStreakService(streakService)
+	sessionService.SetAchievementService(achievementService)
+	sessionService.SetNotificationService(notificationService)
+
+	b.SetAchievementService(achievementService)
+	b.SetSessionService(sessionService)
+	b.SetNotificationService(notificationService)
+	b.SetAuditService(service.NewAuditService(db))
+
+	return b, db, session
+}
+
+func joinEvent(userID, channelID string) *discordgo.VoiceStateUpdate {
+	return &discordgo.VoiceStateUpdate{
+		VoiceState: &discordgo.VoiceState{UserID: userID, GuildID: flowGuildID, ChannelID: channelID},
+	}
+}
+
+func leaveEvent(userID, channelID string) *discordgo.VoiceStateUpdate {
+	return &discordgo.VoiceStateUpdate{
+		VoiceState:   &discordgo.VoiceState{UserID: userID, GuildID: flowGuildID},
+		BeforeUpdate: &discordgo.VoiceState{UserID: userID, GuildID: flowGuildID, ChannelID: channelID},
+	}
+}
+
+// backdateSession pretends the user's open session started d ago, both in memory and in the database
+func backdateSession(t *testing.T, b *Bot, db *fakedb.Querier, userID string, d time.Duration) {
+	t.Helper()
+
+	sessions := db.StudySessions()
+	require.NotEmpty(t, sessions)
+	start := b.clock.Now().Add(-d)
+	require.NoError(t, db.SetStudySessionStart(sessions[len(sessions)-1].SessionID, start))
+
+	b.activeSessionMu.Lock()
+	active := b.activeSessions[userID]
+	active.StartTime = start
+	b.activeSessions[userID] = active
+	b.activeSessionMu.Unlock()
+}
+
+func dedupeKeys(rows []database.NotificationsOutbox) []string {
+	keys := make([]string, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.DedupeKey)
+	}
+	return keys
+}
+
+func TestVoiceFlow_JoinAndLeaveCreditsStatsStreakAndAchievements(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 10, 14, 13, 0, 0, 0, service.GetManilaLocation()))
+	b.clock = clk
+	db.Now = clk.Now
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+
+	_, err := db.GetActiveStudySession(ctx, flowUserKey)
+	require.NoError(t, err)
+	user, err := db.GetUser(ctx, flowUserID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username.String)
+
+	backdateSession(t, b, db, flowUserID, 90*time.Minute)
+	b.handleVoiceStateUpdate(session, leaveEvent(flowUserID, flowChannelID))
+
+	// The session is closed and the in-memory tracker is cleared
+	_, err = db.GetActiveStudySession(ctx, flowUserKey)
+	assert.Error(t, err)
+	_, tracked := b.GetSessionStartTime(flowUserID)
+	assert.False(t, tracked)
+
+	// Stats are credited with the session's duration
+	stats, err := db.GetUserStats(ctx, flowUserID)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, stats.TotalStudyMs.Int64, (90 * time.Minute).Milliseconds())
+	assert.Equal(t, stats.TotalStudyMs, stats.DailyStudyMs)
+
+	// Daily streak activity is recorded for the guild
+	streak, err := db.GetUserStreak(ctx, database.GetUserStreakParams{UserID: flowUserID, GuildID: flowGuildID})
+	require.NoError(t, err)
+	assert.Equal(t, int32(90), streak.DailyActivityMinutes.Int32)
+
+	// Passing one total hour earns Getting Started
+	var awarded []string
+	for _, ua := range db.UserAchievements(flowUserID) {
+		awarded = append(awarded, ua.AchievementID)
+	}
+	assert.Contains(t, awarded, "getting_started")
+
+	// Announcements are queued in the outbox rather than sent inline
+	keys := dedupeKeys(db.Notifications())
+	assert.Contains(t, keys, "achievement:guild-1:user-1:getting_started")
+	assert.Contains(t, keys, "session_end:1")
+	assert.Contains(t, keys, "streak_daily_complete:guild-1:user-1:2026-10-14")
+	assert.Empty(t, session.Messages())
+}
+
+func TestVoiceFlow_SessionAcrossMidnightSplitsDailyCredit(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	manila := service.GetManilaLocation()
+	clk := clock.NewFake(time.Date(2026, 10, 13, 23, 0, 0, 0, manila))
+	b.clock = clk
+	db.Now = clk.Now
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	clk.Advance(3 * time.Hour)
+	b.handleVoiceStateUpdate(session, leaveEvent(flowUserID, flowChannelID))
+
+	// The whole session counts toward the total, only the part after midnight toward today
+	stats, err := db.GetUserStats(ctx, flowUserID)
+	require.NoError(t, err)
+	assert.Equal(t, (3 * time.Hour).Milliseconds(), stats.TotalStudyMs.Int64)
+	assert.Equal(t, (2 * time.Hour).Milliseconds(), stats.DailyStudyMs.Int64)
+
+	// Each day keeps its own share
+	days, err := db.GetDailyActivity(ctx, database.GetDailyActivityParams{
+		UserID:   flowUserID,
+		GuildID:  flowGuildID,
+		FromDate: time.Date(2026, 10, 13, 0, 0, 0, 0, manila),
+		ToDate:   time.Date(2026, 10, 14, 0, 0, 0, 0, manila),
+	})
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	assert.Equal(t, time.Hour.Milliseconds(), days[0].StudyMs)
+	assert.Equal(t, (2 * time.Hour).Milliseconds(), days[1].StudyMs)
+
+	// Streak minutes for the new day only include its share
+	streak, err := db.GetUserStreak(ctx, database.GetUserStreakParams{UserID: flowUserID, GuildID: flowGuildID})
+	require.NoError(t, err)
+	assert.Equal(t, int32(120), streak.DailyActivityMinutes.Int32)
+}
+
+func TestVoiceFlow_FailedSessionEndLeavesNothingCredited(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	backdateSession(t, b, db, flowUserID, 2*time.Hour)
+
+	// Awarding Getting Started fails inside the transaction, after stats were already written
+	sessionService := service.NewSessionService(failingAwardTx{db})
+	sessionService.SetStreakService(service.NewStreakService(db, db, nil, b.cfg))
+	sessionService.SetAchievementService(service.NewAchievementService(db, nil, b.cfg))
+	b.SetSessionService(sessionService)
+
+	b.handleVoiceStateUpdate(session, leaveEvent(flowUserID, flowChannelID))
+
+	_, err := db.GetUserStats(ctx, flowUserID)
+	assert.Error(t, err, "stats must be rolled back with the failed transaction")
+	_, err = db.GetActiveStudySession(ctx, flowUserKey)
+	assert.NoError(t, err, "the session stays open so it can be ended again")
+	assert.Contains(t, b.activeSessions, flowUserID, "the session stays tracked so it can be ended again")
+	assert.Empty(t, db.UserAchievements(flowUserID))
+	assert.Empty(t, db.Notifications())
+}
+
+func TestVoiceFlow_FailedSessionEndKeepsSessionInMemory(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	backdateSession(t, b, db, flowUserID, 2*time.Hour)
+
+	sessionService := service.NewSessionService(failingStatsTx{db})
+	b.SetSessionService(sessionService)
+
+	b.handleVoiceStateUpdate(session, leaveEvent(flowUserID, flowChannelID))
+
+	_, err := db.GetUserStats(ctx, flowUserID)
+	assert.Error(t, err, "stats must be rolled back with the failed transaction")
+	_, err = db.GetActiveStudySession(ctx, flowUserKey)
+	assert.NoError(t, err, "the session stays open so it can be ended again")
+	assert.Contains(t, b.activeSessions, flowUserID, "the session stays tracked so it can be ended again")
+	assert.Empty(t, db.Notifications())
+}
+
+func TestVoiceFlow_LeaveBeforeSessionServiceIsSetKeepsSession(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	sessionService := b.sessionService
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	backdateSession(t, b, db, flowUserID, time.Hour)
+
+	b.SetSessionService(nil)
+	b.handleVoiceStateUpdate(session, leaveEvent(flowUserID, flowChannelID))
+	assert.Contains(t, b.activeSessions, flowUserID)
+	_, err := db.GetActiveStudySession(ctx, flowUserKey)
+	require.NoError(t, err, "the session stays open")
+
+	// Once the service is available the session can still be ended and credited
+	b.SetSessionService(sessionService)
+	b.handleUserLeftStudySession(ctx, session, leaveEvent(flowUserID, flowChannelID), nil)
+	stats, err := db.GetUserStats(ctx, flowUserID)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour.Milliseconds(), stats.TotalStudyMs.Int64)
+	assert.NotContains(t, b.activeSessions, flowUserID)
+}
+
+func TestVoiceFlow_MoveBetweenTrackedChannelsKeepsSession(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	b.allowedVoiceChannelIDs["study-vc-2"] = struct{}{}
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	move := joinEvent(flowUserID, "study-vc-2")
+	move.BeforeUpdate = &discordgo.VoiceState{UserID: flowUserID, GuildID: flowGuildID, ChannelID: flowChannelID}
+	b.handleVoiceStateUpdate(session, move)
+
+	sessions := db.StudySessions()
+	require.Len(t, sessions, 1)
+	assert.False(t, sessions[0].EndTime.Valid)
+}
+
+func TestVoiceFlow_LogsCarryOneEventIDPerVoiceUpdate(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatJSON, "debug")
+	require.NoError(t, err)
+	b.SetLogger(logger)
+	previous := slog.Default()
+	slog.SetDefault(logger) // SessionService logs through the default logger
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	backdateSession(t, b, db, flowUserID, 30*time.Minute)
+	b.handleVoiceStateUpdate(session, leaveEvent(flowUserID, flowChannelID))
+
+	records := map[string]map[string]any{}
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(line, &record))
+		records[record["msg"].(string)] = record
+	}
+
+	started := records["Started study session"]
+	require.NotNil(t, started)
+	assert.Equal(t, flowUserID, started["user_id"])
+	assert.Equal(t, flowGuildID, started["guild_id"])
+	assert.NotNil(t, started["session_id"])
+	assert.NotEmpty(t, started["event_id"])
+	assert.Equal(t, started["event_id"], records["Joined tracked voice channel, starting study session"]["event_id"])
+
+	left := records["Left voice channel, ending study session"]
+	ended := records["Ended study session"]
+	require.NotNil(t, left)
+	require.NotNil(t, ended)
+	assert.NotEmpty(t, left["event_id"])
+	assert.Equal(t, left["event_id"], ended["event_id"], "the session service logs under the leave event's ID")
+	assert.NotEqual(t, started["event_id"], left["event_id"])
+	assert.Equal(t, started["session_id"], ended["session_id"])
+}
+
+func TestVoiceFlow_CountsEventsDuplicatesAndActiveSessions(t *testing.T) {
+	b, _, session := createFlowBot(t)
+	b.reportActiveSessions()
+	events := metrics.VoiceEvents.Value()
+	duplicates := metrics.VoiceEventsDeduplicated.Value()
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+
+	assert.Equal(t, events+2, metrics.VoiceEvents.Value())
+	assert.Equal(t, duplicates+1, metrics.VoiceEventsDeduplicated.Value())
+	assert.Equal(t, float64(1), metrics.ActiveSessions.Value())
+}
+
+func TestSessionTimeoutChecker_EndsSessionsForUsersNoLongerInVoice(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 10, 14, 13, 0, 0, 0, service.GetManilaLocation()))
+	b.clock = clk
+	db.Now = clk.Now
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	backdateSession(t, b, db, flowUserID, 5*time.Hour)
+
+	timedOut := metrics.SessionsTimedOut.Value()
+	checker := NewSessionTimeoutChecker(b, 4, time.Minute)
+	checker.checkAndEndTimeoutSessions()
+
+	_, err := db.GetActiveStudySession(ctx, flowUserKey)
+	assert.Error(t, err)
+	assert.Equal(t, timedOut+1, metrics.SessionsTimedOut.Value())
+	stats, err := db.GetUserStats(ctx, flowUserID)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, stats.TotalStudyMs.Int64, (5 * time.Hour).Milliseconds())
+
+	// The guild is remembered from the join, so the streak is credited too
+	streak, err := db.GetUserStreak(ctx, database.GetUserStreakParams{UserID: flowUserID, GuildID: flowGuildID})
+	require.NoError(t, err)
+	assert.Equal(t, int32(300), streak.DailyActivityMinutes.Int32)
+
+	var ended []database.AuditLog
+	for _, e := range db.AuditLog() {
+		if e.Action == service.AuditActionSessionTimeout {
+			ended = append(ended, e)
+		}
+	}
+	require.Len(t, ended, 1, "the forced end is recorded")
+	assert.Equal(t, flowUserID, ended[0].TargetUserID.String)
+	assert.Equal(t, flowGuildID, ended[0].GuildID.String)
+	assert.False(t, ended[0].ActorID.Valid)
+}
+
+func TestSessionTimeoutChecker_KeepsSessionsForUsersStillInVoice(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+
+	b.handleVoiceStateUpdate(session, joinEvent(flowUserID, flowChannelID))
+	backdateSession(t, b, db, flowUserID, 5*time.Hour)
+	require.NoError(t, session.SetVoiceState(flowGuildID, flowUserID, flowChannelID))
+
+	checker := NewSessionTimeoutChecker(b, 4, time.Minute)
+	checker.checkAndEndTimeoutSessions()
+
+	_, err := db.GetActiveStudySession(ctx, flowUserKey)
+	assert.NoError(t, err)
+	_, tracked := b.GetSessionStartTime(flowUserID)
+	assert.True(t, tracked)
+}
+
+func TestHandleSlashStatsCommand_RespondsWithStoredStats(t *testing.T) {
+	b, db, session := createFlowBot(t)
+	ctx := context.Background()
+
+	_, err := db.CreateOrUpdateUserStats(ctx, database.CreateOrUpdateUserStatsParams{
+		UserID:       flowUserID,
+		TotalStudyMs: sql.NullInt64{Int64: (2 * time.Hour).Milliseconds(), Valid: true},
+	})
+	require.NoError(t, err)
+
+	b.handleSlashStatsCommand(session, createTestInteraction(flowUserID, "alice", flowGuildID))
+
+	resp := session.LastResponse()
+	require.NotNil(t, resp)
+	require.Len(t, resp.Data.Embeds, 1)
+	assert.Equal(t, "Study Stats for alice", resp.Data.Embeds[0].Title)
+	assert.Equal(t, "2h 0m 0s", resp.Data.Embeds[0].Fields[0].Value)
+
+	// The user row is created on first use
+	_, err = db.GetUser(ctx, flowUserID)
+	assert.NoError(t, err)
+}
+
+// failingAwardTx runs transactions on the fake database with every achievement award failing
+type failingAwardTx struct {
+	db *fakedb.Querier
+}
+
+func (tx failingAwardTx) ExecTx(ctx context.Context, fn func(database.Querier) error) error {
+	return tx.db.ExecTx(ctx, func(q database.Querier) error {
+		return fn(&failingAwardQuerier{Querier: q})
+	})
+}
+
+type failingAwardQuerier struct {
+	database.Querier
+}
+
+func (q *failingAwardQuerier) AwardAchievement(ctx context.Context, arg database.AwardAchievementParams) (database.UserAchievement, error) {
+	return database.UserAchievement{}, assert.AnError
+}
+
+// failingStatsTx runs transactions on the fake database with every stats update failing
+type failingStatsTx struct {
+	db *fakedb.Querier
+}
+
+func (tx failingStatsTx) ExecTx(ctx context.Context, fn func(database.Querier) error) error {
+	return tx.db.ExecTx(ctx, func(q database.Querier) error {
+		return fn(&failingStatsQuerier{Querier: q})
+	})
+}
+
+type failingStatsQuerier struct {
+	database.Querier
+}
+
+func (q *failingStatsQuerier) CreateOrUpdateUserStats(ctx context.Context, arg database.CreateOrUpdateUserStatsParams) (database.UserStat, error) {
+	return database.UserStat{}, assert.AnError
+}
@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// VoiceEventRecord is one recorded voice state update, stored as a line of JSON
+type VoiceEventRecord struct {
+	At              time.Time `json:"at"`
+	GuildID         string    `json:"guildId"`
+	UserID          string    `json:"userId"`
+	ChannelID       string    `json:"channelId,omitempty"`       // Empty when the user left voice
+	BeforeChannelID string    `json:"beforeChannelId,omitempty"` // Empty when the user wasn't in voice before
+}
+
+// Event rebuilds the gateway event the record was taken from
+func (r VoiceEventRecord) Event() *discordgo.VoiceStateUpdate {
+	v := &discordgo.VoiceStateUpdate{
+		VoiceState: &discordgo.VoiceState{UserID: r.UserID, GuildID: r.GuildID, ChannelID: r.ChannelID},
+	}
+	if r.BeforeChannelID != "" {
+		v.BeforeUpdate = &discordgo.VoiceState{UserID: r.UserID, GuildID: r.GuildID, ChannelID: r.BeforeChannelID}
+	}
+	return v
+}
+
+// VoiceRecorder appends voice state updates to a JSON-lines file so incidents can be replayed in tests
+type VoiceRecorder struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewVoiceRecorder opens path for appending, creating it if needed
+func NewVoiceRecorder(path string) (*VoiceRecorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open voice event log: %w", err)
+	}
+	return &VoiceRecorder{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// Record writes one voice state update received at the given time
+func (r *VoiceRecorder) Record(v *discordgo.VoiceStateUpdate, at time.Time) error {
+	record := VoiceEventRecord{At: at.UTC()}
+	if v.VoiceState != nil {
+		record.GuildID = v.GuildID
+		record.UserID = v.UserID
+		record.ChannelID = v.ChannelID
+	}
+	if v.BeforeUpdate != nil {
+		record.BeforeChannelID = v.BeforeUpdate.ChannelID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Encode(record)
+}
+
+// Close closes the log file
+func (r *VoiceRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// ReadVoiceRecording parses a recording written by VoiceRecorder. Blank lines are ignored.
+func ReadVoiceRecording(reader io.Reader) ([]VoiceEventRecord, error) {
+	var records []VoiceEventRecord
+	scanner := bufio.NewScanner(reader)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record VoiceEventRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Permission is what a member needs to run a command
+type Permission string
+
+const (
+	PermissionEveryone Permission = ""      // Anyone can run the command
+	PermissionAdmin    Permission = "admin" // Requires the Discord Administrator permission
+
+	// Capabilities a server can grant to Discord roles with /permissions. Administrators have all of them.
+	PermissionManageChannels Permission = "manage_channels" // Pin live status messages and pick the recap channel
+	PermissionAdjustStats    Permission = "adjust_stats"    // Clean up sessions and correct study time
+	PermissionRunBackfills   Permission = "run_backfills"   // Evaluate streaks for missed days
+	PermissionViewAuditLog   Permission = "view_audit_log"  // Read the audit log
+)
+
+// Capabilities lists the permissions that can be granted to roles, in the order /permissions shows them
+var Capabilities = []Permission{
+	PermissionManageChannels,
+	PermissionAdjustStats,
+	PermissionRunBackfills,
+	PermissionViewAuditLog,
+}
+
+// capabilityLabels are the names members see for each capability
+var capabilityLabels = map[Permission]string{
+	PermissionManageChannels: "Manage channels",
+	PermissionAdjustStats:    "Adjust stats",
+	PermissionRunBackfills:   "Run backfills",
+	PermissionViewAuditLog:   "View audit log",
+}
+
+// IsCapability reports whether p can be granted to a role
+func (p Permission) IsCapability() bool {
+	_, ok := capabilityLabels[p]
+	return ok
+}
+
+// Label is the capability's name as members see it
+func (p Permission) Label() string {
+	if label, ok := capabilityLabels[p]; ok {
+		return label
+	}
+	return string(p)
+}
+
+// Permissions decides who may use a permission: administrators always can, and other members can
+// use a capability when one of their roles has been granted it in the guild.
+type Permissions struct {
+	db database.Querier
+}
+
+// NewPermissions creates a Permissions backed by the guild_role_capabilities table
+func NewPermissions(db database.Querier) *Permissions {
+	return &Permissions{db: db}
+}
+
+// Allowed reports whether member may use perm in the guild
+func (p *Permissions) Allowed(ctx context.Context, guildID string, member *discordgo.Member, perm Permission) (bool, error) {
+	if perm == PermissionEveryone {
+		return true, nil
+	}
+	if member == nil {
+		return false, nil // Outside a guild there are no roles to check
+	}
+	if hasAdminPermissions(member) {
+		return true, nil
+	}
+	if !perm.IsCapability() || guildID == "" || len(member.Roles) == 0 {
+		return false, nil
+	}
+
+	granted, err := p.db.GetGuildRoleCapabilities(ctx, guildID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get role capabilities: %w", err)
+	}
+	for _, g := range granted {
+		if Permission(g.Capability) != perm {
+			continue
+		}
+		for _, roleID := range member.Roles {
+			if roleID == g.RoleID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Grant lets members with the role use the capability in the guild. Granting it again does nothing.
+func (p *Permissions) Grant(ctx context.Context, guildID, roleID string, capability Permission) error {
+	if !capability.IsCapability() {
+		return fmt.Errorf("%q can't be granted to a role", capability)
+	}
+	err := p.db.GrantRoleCapability(ctx, database.GrantRoleCapabilityParams{
+		GuildID:    guildID,
+		RoleID:     roleID,
+		Capability: string(capability),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to grant %s: %w", capability, err)
+	}
+	return nil
+}
+
+// Revoke takes the capability away from the role. It reports false if the role didn't have it.
+func (p *Permissions) Revoke(ctx context.Context, guildID, roleID string, capability Permission) (bool, error) {
+	n, err := p.db.RevokeRoleCapability(ctx, database.RevokeRoleCapabilityParams{
+		GuildID:    guildID,
+		RoleID:     roleID,
+		Capability: string(capability),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke %s: %w", capability, err)
+	}
+	return n > 0, nil
+}
+
+// Roles returns the IDs of the roles granted each capability in the guild
+func (p *Permissions) Roles(ctx context.Context, guildID string) (map[Permission][]string, error) {
+	granted, err := p.db.GetGuildRoleCapabilities(ctx, guildID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role capabilities: %w", err)
+	}
+	roles := make(map[Permission][]string)
+	for _, g := range granted {
+		perm := Permission(g.Capability)
+		roles[perm] = append(roles[perm], g.RoleID)
+	}
+	return roles, nil
+}
+
+// hasAdminPermissions checks if the member has the Administrator permission
+func hasAdminPermissions(member *discordgo.Member) bool {
+	if member == nil {
+		return false
+	}
+	return member.Permissions&discordgo.PermissionAdministrator != 0
+}
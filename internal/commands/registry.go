@@ -0,0 +1,221 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/Skufu/LockIn-Bot/internal/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Handler runs a command. Text aliases reach it as an interaction too, see Registry.HandleMessage.
+type Handler func(s discord.Session, i *discordgo.InteractionCreate)
+
+// adminPermission is the default member permission that hides admin commands from other members
+var adminPermission int64 = discordgo.PermissionAdministrator
+
+// Command is a bot command, declared once for both its slash command and its text alias
+type Command struct {
+	Definition  *discordgo.ApplicationCommand // The slash command registered with Discord
+	Alias       string                        // Optional text command, run as the prefix followed by the alias
+	Permission  Permission                    // Checked before Handler runs
+	Subcommands map[string]Permission         // Optional: checked instead of Permission for these subcommands or groups
+	Handler     Handler
+}
+
+// permissions returns every permission the command can require
+func (c *Command) permissions() []Permission {
+	perms := []Permission{c.Permission}
+	for _, p := range c.Subcommands {
+		perms = append(perms, p)
+	}
+	return perms
+}
+
+// permissionFor returns the permission needed for the subcommand i invokes
+func (c *Command) permissionFor(i *discordgo.InteractionCreate) Permission {
+	options := i.ApplicationCommandData().Options
+	if len(options) > 0 {
+		if p, ok := c.Subcommands[options[0].Name]; ok {
+			return p
+		}
+	}
+	return c.Permission
+}
+
+// Registry holds the bot's commands and routes slash commands and text aliases to them
+type Registry struct {
+	prefix      string
+	permissions *Permissions
+	commands    []*Command
+	byName      map[string]*Command
+	byAlias     map[string]*Command
+}
+
+// NewRegistry creates an empty registry whose commands are checked against permissions. Text
+// aliases are run when a message starts with prefix; an empty prefix turns them off.
+func NewRegistry(prefix string, permissions *Permissions) *Registry {
+	return &Registry{
+		prefix:      prefix,
+		permissions: permissions,
+		byName:      make(map[string]*Command),
+		byAlias:     make(map[string]*Command),
+	}
+}
+
+// Register adds a command. Like http.ServeMux it panics on a programming error: a duplicate name
+// or alias, an alias on a command that needs permissions, which messages can't carry, or an
+// unknown permission.
+func (r *Registry) Register(cmd Command) {
+	name := cmd.Definition.Name
+	if _, exists := r.byName[name]; exists {
+		panic(fmt.Sprintf("commands: duplicate command %q", name))
+	}
+	alias := strings.ToLower(cmd.Alias)
+	if alias != "" {
+		if _, exists := r.byAlias[alias]; exists {
+			panic(fmt.Sprintf("commands: duplicate alias %q", alias))
+		}
+		if cmd.Permission != PermissionEveryone || len(cmd.Subcommands) > 0 {
+			panic(fmt.Sprintf("commands: %q needs permissions, so it can't have a text alias", name))
+		}
+	}
+
+	// Commands a role can be granted stay visible, as Discord only lets members with the default
+	// member permissions see a command; run checks the member's roles instead
+	adminOnly := true
+	for _, p := range cmd.permissions() {
+		if p != PermissionEveryone && p != PermissionAdmin && !p.IsCapability() {
+			panic(fmt.Sprintf("commands: %q has unknown permission %q", name, p))
+		}
+		adminOnly = adminOnly && p == PermissionAdmin
+	}
+	if adminOnly {
+		cmd.Definition.DefaultMemberPermissions = &adminPermission
+	}
+	c := &cmd
+	r.commands = append(r.commands, c)
+	r.byName[name] = c
+	if alias != "" {
+		r.byAlias[alias] = c
+	}
+}
+
+// Definitions returns the slash commands to register with Discord, in registration order
+func (r *Registry) Definitions() []*discordgo.ApplicationCommand {
+	defs := make([]*discordgo.ApplicationCommand, len(r.commands))
+	for i, c := range r.commands {
+		defs[i] = c.Definition
+	}
+	return defs
+}
+
+// Aliases returns the text commands, prefix included, in alphabetical order
+func (r *Registry) Aliases() []string {
+	if r.prefix == "" {
+		return nil
+	}
+	aliases := make([]string, 0, len(r.byAlias))
+	for alias := range r.byAlias {
+		aliases = append(aliases, r.prefix+alias)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// HandleInteraction runs the slash command i invokes. It reports false if no such command is registered.
+func (r *Registry) HandleInteraction(s discord.Session, i *discordgo.InteractionCreate) bool {
+	cmd, ok := r.byName[i.ApplicationCommandData().Name]
+	if !ok {
+		return false
+	}
+	r.run(cmd, s, i)
+	return true
+}
+
+// HandleMessage runs the command whose text alias starts the message. The command's handler gets
+// an interaction built from the message, with no options, and its response is posted as a reply.
+func (r *Registry) HandleMessage(s discord.Session, m *discordgo.MessageCreate) {
+	if r.prefix == "" || m.Author == nil || m.Author.Bot || !strings.HasPrefix(m.Content, r.prefix) {
+		return
+	}
+	parts := strings.Fields(strings.TrimPrefix(m.Content, r.prefix))
+	if len(parts) == 0 {
+		return
+	}
+	cmd, ok := r.byAlias[strings.ToLower(parts[0])]
+	if !ok {
+		return
+	}
+
+	slog.Debug("Text command received", "command", r.prefix+parts[0], "user_id", m.Author.ID, "guild_id", m.GuildID)
+	r.run(cmd, &messageSession{Session: s, message: m}, messageInteraction(m, cmd.Definition.Name))
+}
+
+// run checks the member may use cmd and then runs it. This is the one permission check every
+// admin and moderator command goes through.
+func (r *Registry) run(cmd *Command, s discord.Session, i *discordgo.InteractionCreate) {
+	allowed, err := r.permissions.Allowed(context.Background(), i.GuildID, i.Member, cmd.permissionFor(i))
+	if err != nil {
+		slog.Error("Failed to check permissions", "command", cmd.Definition.Name, "error", err)
+		respondWithError(s, i, "Couldn't check your permissions. Please try again later.")
+		return
+	}
+	if !allowed {
+		respondWithError(s, i, "You don't have permission to use this command.")
+		return
+	}
+	cmd.Handler(s, i)
+}
+
+// messageInteraction builds the interaction a text alias for the command name is handled as
+func messageInteraction(m *discordgo.MessageCreate, name string) *discordgo.InteractionCreate {
+	interaction := &discordgo.Interaction{
+		ID:        m.ID,
+		Type:      discordgo.InteractionApplicationCommand,
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+		Data:      discordgo.ApplicationCommandInteractionData{Name: name},
+	}
+	if m.Member != nil {
+		member := *m.Member
+		member.User = m.Author // Message members leave out the user
+		interaction.Member = &member
+	} else {
+		interaction.User = m.Author
+	}
+	return &discordgo.InteractionCreate{Interaction: interaction}
+}
+
+// messageSession answers a text command: interaction responses are posted as a reply to the message
+type messageSession struct {
+	discord.Session
+	message *discordgo.MessageCreate
+}
+
+func (s *messageSession) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error {
+	if resp.Data == nil {
+		return nil
+	}
+	_, err := s.ChannelMessageSendComplex(s.message.ChannelID, &discordgo.MessageSend{
+		Content:    resp.Data.Content,
+		Embeds:     resp.Data.Embeds,
+		Components: resp.Data.Components,
+		Reference:  s.message.Reference(),
+	}, options...)
+	return err
+}
+
+// respondWithError sends an error response
+func respondWithError(s discord.Session, i *discordgo.InteractionCreate, message string) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: message,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -27,8 +29,29 @@ type Config struct {
 
 	// Fields for Achievement Feature
 	AchievementChannelID string
+
+	// Opt-in JSON-lines file that every voice state update is appended to, for replaying incidents in tests
+	VoiceEventLogPath string
+
+	// Prefix for text aliases of slash commands, e.g. "!" for !study
+	CommandPrefix string
+
+	// Minimum level to log (debug, info, warn or error) and output format (text or json)
+	LogLevel  string
+	LogFormat string
+
+	// Serve the read-only REST API under /api/v1/ on the health check server
+	APIEnabled bool
+
+	// Public URL the health check server is reachable at, and the key dashboard links are signed
+	// with. The web dashboard and /dashboard are enabled when both are set.
+	DashboardURL    string
+	DashboardSecret string
 }
 
+// MinDashboardSecretLength is the shortest DASHBOARD_SECRET accepted, so links can't be forged by guessing it
+const MinDashboardSecretLength = 32
+
 // Load reads configuration from .env file or environment variables
 func Load() (*Config, error) {
 	// First try to load .env file
@@ -56,10 +79,23 @@ func Load() (*Config, error) {
 		AllowedVoiceChannelIDsRaw:   os.Getenv("ALLOWED_VOICE_CHANNEL_IDS"),
 		StreakNotificationChannelID: os.Getenv("STREAK_NOTIFICATION_CHANNEL_ID"),
 		AchievementChannelID:        os.Getenv("ACHIEVEMENT_CHANNEL_ID"),
+		VoiceEventLogPath:           os.Getenv("VOICE_EVENT_LOG_PATH"),
+		CommandPrefix:               getEnvWithDefault("COMMAND_PREFIX", "!"),
+		LogLevel:                    getEnvWithDefault("LOG_LEVEL", "info"),
+		LogFormat:                   getEnvWithDefault("LOG_FORMAT", "text"),
+		DashboardURL:                strings.TrimRight(os.Getenv("DASHBOARD_URL"), "/"),
+		DashboardSecret:             os.Getenv("DASHBOARD_SECRET"),
 	}
 
 	config.AllowedVoiceChannelIDsMap = parseChannelIDs(config.AllowedVoiceChannelIDsRaw)
 
+	if raw := os.Getenv("API_ENABLED"); raw != "" {
+		config.APIEnabled, err = strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("API_ENABLED must be true or false, got %q", raw)
+		}
+	}
+
 	// Additional validation
 	if config.DBPassword == "" {
 		return nil, fmt.Errorf("DB_PASSWORD environment variable is required")
@@ -77,6 +113,34 @@ func Load() (*Config, error) {
 		fmt.Println("Info: ACHIEVEMENT_CHANNEL_ID environment variable is not set. Achievement announcements will be disabled.")
 	}
 
+	// One channel can't belong to every server the bot is in, so each server now picks its own
+	if os.Getenv("RECAP_CHANNEL_ID") != "" {
+		fmt.Println("Info: RECAP_CHANNEL_ID is no longer used. Set each server's recap channel with /recap channel.")
+	}
+
+	if (config.DashboardURL == "") != (config.DashboardSecret == "") {
+		return nil, fmt.Errorf("DASHBOARD_URL and DASHBOARD_SECRET must be set together")
+	}
+	if config.DashboardURL != "" {
+		if u, err := url.Parse(config.DashboardURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("DASHBOARD_URL must be an http or https URL, got %q", config.DashboardURL)
+		}
+		if len(config.DashboardSecret) < MinDashboardSecretLength {
+			return nil, fmt.Errorf("DASHBOARD_SECRET must be at least %d characters", MinDashboardSecretLength)
+		}
+		if os.Getenv("PORT") == "" {
+			fmt.Println("Warning: DASHBOARD_URL is set but PORT is not, so there is no HTTP server to serve the dashboard on.")
+		}
+	}
+
+	if config.APIEnabled && os.Getenv("PORT") == "" {
+		fmt.Println("Warning: API_ENABLED is set but PORT is not, so there is no HTTP server to serve the REST API on.")
+	}
+
+	if config.VoiceEventLogPath != "" {
+		fmt.Printf("Info: Voice state updates will be recorded to %s\n", config.VoiceEventLogPath)
+	}
+
 	if config.AllowedVoiceChannelIDsRaw != "" && len(config.AllowedVoiceChannelIDsMap) == 0 {
 		fmt.Printf("Warning: ALLOWED_VOICE_CHANNEL_IDS was set to '%s' but resulted in no valid channel IDs. No voice channels will be tracked for study time or streaks.\n", config.AllowedVoiceChannelIDsRaw)
 	} else if len(config.AllowedVoiceChannelIDsMap) > 0 {
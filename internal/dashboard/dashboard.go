@@ -0,0 +1,203 @@
+// Package dashboard serves a small server-rendered study dashboard for a guild: its leaderboard, who
+// is studying now, community totals over time, and each member's profile with badges and a heatmap.
+// Members sign in with a link the bot DMs them from /dashboard; see Links.
+package dashboard
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/database"
+)
+
+//go:embed templates static
+var files embed.FS
+
+// Each page is the layout plus its own content template
+var (
+	overviewTemplate = parsePage("templates/overview.html")
+	profileTemplate  = parsePage("templates/profile.html")
+	errorTemplate    = parsePage("templates/error.html")
+)
+
+func parsePage(content string) *template.Template {
+	return template.Must(template.ParseFS(files, "templates/layout.html", content))
+}
+
+// sessionCookie holds a signed session token. It is scoped to one guild's path, so members of
+// several servers can be signed in to each dashboard at once.
+const sessionCookie = "lockin_dashboard"
+
+// ActiveSession is a member studying in a guild right now
+type ActiveSession struct {
+	UserID    string
+	StartTime time.Time
+}
+
+// ActiveSessionsFunc lists who is studying in guildID right now
+type ActiveSessionsFunc func(guildID string) []ActiveSession
+
+// Server renders the dashboard pages from the database
+type Server struct {
+	db             database.Querier
+	links          *Links
+	clock          clock.Clock
+	activeSessions atomic.Pointer[ActiveSessionsFunc]
+}
+
+// New creates a dashboard server reading from db and checking sign-in tokens with links
+func New(db database.Querier, links *Links) *Server {
+	return &Server{
+		db:    db,
+		links: links,
+		clock: clock.Real{},
+	}
+}
+
+// SetActiveSessions sets where "Studying now" comes from. The bot's in-memory sessions are the
+// ones it is tracking, unlike open rows in the database, which a crash can leave behind. Until it
+// is set, e.g. while the bot connects, nobody is shown as studying.
+func (s *Server) SetActiveSessions(fn ActiveSessionsFunc) {
+	s.activeSessions.Store(&fn)
+}
+
+// Register adds the dashboard's routes to mux, under /dashboard/
+func (s *Server) Register(mux *http.ServeMux) {
+	static, err := fs.Sub(files, "static")
+	if err != nil {
+		panic(err) // The directory is embedded at build time
+	}
+	mux.Handle("GET /dashboard/static/", http.StripPrefix("/dashboard/static/", http.FileServerFS(static)))
+	mux.HandleFunc("GET /dashboard/login", s.login)
+	mux.Handle("GET /dashboard/guilds/{guildID}", s.guildPage(s.overview))
+	mux.Handle("GET /dashboard/guilds/{guildID}/users/{userID}", s.guildPage(s.profile))
+}
+
+// guildPath is where a guild's dashboard lives, and the path its session cookie is scoped to
+func guildPath(guildID string) string {
+	return "/dashboard/guilds/" + guildID
+}
+
+// login swaps the token from a /dashboard link for a session cookie, then redirects to the dashboard
+// so the token doesn't linger in the address bar or browser history
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	now := s.clock.Now()
+	g, err := s.links.verify(purposeLink, r.URL.Query().Get("token"), now)
+	if err != nil {
+		s.renderError(w, r, http.StatusUnauthorized, "This link has expired or isn't valid. Run /dashboard in your server to get a new one.")
+		return
+	}
+
+	session := newGrant(g.GuildID, g.UserID, now.Add(SessionLifetime))
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    s.links.sign(purposeSession, session),
+		Path:     guildPath(g.GuildID),
+		Expires:  session.Expires,
+		HttpOnly: true,
+		Secure:   s.links.secure(),
+		SameSite: http.SameSiteLaxMode,
+	})
+	slog.InfoContext(r.Context(), "Dashboard sign-in", "guild_id", g.GuildID, "user_id", g.UserID)
+	http.Redirect(w, r, guildPath(g.GuildID), http.StatusSeeOther)
+}
+
+// errNotFound makes a page respond 404 with its message
+type errNotFound string
+
+func (e errNotFound) Error() string { return string(e) }
+
+// pageFunc builds one page for a signed-in viewer, returning its template and data
+type pageFunc func(ctx context.Context, r *http.Request, viewer grant) (*template.Template, any, error)
+
+// guildPage checks the request's session cookie is for the guild in the path, then renders the page
+func (s *Server) guildPage(fn pageFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		guildID := r.PathValue("guildID")
+
+		cookie, err := r.Cookie(sessionCookie)
+		if err != nil {
+			s.renderError(w, r, http.StatusUnauthorized, "You're not signed in to this server's dashboard. Run /dashboard in the server to get a link.")
+			return
+		}
+		viewer, err := s.links.verify(purposeSession, cookie.Value, s.clock.Now())
+		if err != nil || viewer.GuildID != guildID {
+			s.renderError(w, r, http.StatusUnauthorized, "Your dashboard session has expired. Run /dashboard in the server to get a new link.")
+			return
+		}
+
+		tmpl, data, err := fn(ctx, r, viewer)
+		var notFound errNotFound
+		if errors.As(err, &notFound) {
+			s.renderError(w, r, http.StatusNotFound, notFound.Error())
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to build dashboard page", "guild_id", guildID, "path", r.URL.Path, "error", err)
+			s.renderError(w, r, http.StatusInternalServerError, "Something went wrong while loading the dashboard. Please try again later.")
+			return
+		}
+		s.render(w, r, http.StatusOK, tmpl, data)
+	})
+}
+
+// render writes a page, buffering it so a template error doesn't leave half a page behind
+func (s *Server) render(w http.ResponseWriter, r *http.Request, status int, tmpl *template.Template, data any) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to render dashboard page", "path", r.URL.Path, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h := w.Header()
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	h.Set("Cache-Control", "private, no-store")
+	h.Set("Content-Security-Policy", "default-src 'none'; style-src 'self'; img-src 'self'; base-uri 'none'; form-action 'none'; frame-ancestors 'none'")
+	h.Set("Referrer-Policy", "no-referrer")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// errorPage is the data of the error template
+type errorPage struct {
+	layout
+	Status  int
+	Message string
+}
+
+func (s *Server) renderError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	s.render(w, r, status, errorTemplate, errorPage{
+		layout:  layout{Title: http.StatusText(status), GeneratedAt: s.clock.Now()},
+		Status:  status,
+		Message: message,
+	})
+}
+
+// layout is the data every page's header and footer use
+type layout struct {
+	Title       string
+	GuildID     string // Empty on error pages, which have no navigation
+	ViewerID    string
+	GeneratedAt time.Time
+}
+
+// ProfileURL links to a member's profile in the layout's guild
+func (l layout) ProfileURL(userID string) string {
+	return guildPath(l.GuildID) + "/users/" + userID
+}
+
+// HomeURL links to the guild's overview
+func (l layout) HomeURL() string {
+	return guildPath(l.GuildID)
+}
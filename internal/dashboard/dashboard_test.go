@@ -0,0 +1,226 @@
+package dashboard
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/clock"
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/database/fakedb"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testGuildID = "guild-1"
+	testSecret  = "0123456789abcdef0123456789abcdef"
+)
+
+type testDashboard struct {
+	mux    *http.ServeMux
+	server *Server
+	db     *fakedb.Querier
+	links  *Links
+	clock  *clock.Fake
+}
+
+func newTestDashboard(t *testing.T) *testDashboard {
+	t.Helper()
+	db := fakedb.New()
+	links := NewLinks("https://lockin.example.com/", testSecret)
+	clk := clock.NewFake(time.Date(2026, 10, 16, 20, 0, 0, 0, service.GetManilaLocation()))
+	s := New(db, links)
+	s.clock = clk
+	mux := http.NewServeMux()
+	s.Register(mux)
+	return &testDashboard{mux: mux, server: s, db: db, links: links, clock: clk}
+}
+
+func (d *testDashboard) get(path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	d.mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// signIn follows a /dashboard link for userID and returns the session cookie it sets
+func (d *testDashboard) signIn(t *testing.T, userID string) *http.Cookie {
+	t.Helper()
+	link, err := url.Parse(d.links.Link(testGuildID, userID, d.clock.Now()))
+	require.NoError(t, err)
+	rec := d.get(link.RequestURI())
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/dashboard/guilds/guild-1", rec.Header().Get("Location"))
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	return cookies[0]
+}
+
+func TestLinks_RejectTamperedExpiredAndWrongPurposeTokens(t *testing.T) {
+	links := NewLinks("https://lockin.example.com", testSecret)
+	now := time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)
+	token := links.sign(purposeLink, newGrant(testGuildID, "user-1", now.Add(time.Hour)))
+
+	g, err := links.verify(purposeLink, token, now)
+	require.NoError(t, err)
+	assert.Equal(t, grant{GuildID: testGuildID, UserID: "user-1", Expires: time.Unix(now.Add(time.Hour).Unix(), 0)}, g)
+
+	_, err = links.verify(purposeSession, token, now)
+	assert.ErrorIs(t, err, errInvalidToken, "a link isn't a session")
+	_, err = links.verify(purposeLink, token, now.Add(time.Hour))
+	assert.ErrorIs(t, err, errInvalidToken, "expired")
+	_, err = links.verify(purposeLink, "guild-2"+token[len(testGuildID):], now)
+	assert.ErrorIs(t, err, errInvalidToken, "another guild")
+	_, err = NewLinks("https://lockin.example.com", "another secret").verify(purposeLink, token, now)
+	assert.ErrorIs(t, err, errInvalidToken, "another secret")
+	_, err = links.verify(purposeLink, "", now)
+	assert.ErrorIs(t, err, errInvalidToken)
+}
+
+func TestDashboard_LoginSetsGuildScopedCookie(t *testing.T) {
+	d := newTestDashboard(t)
+	cookie := d.signIn(t, "user-1")
+	assert.Equal(t, sessionCookie, cookie.Name)
+	assert.Equal(t, "/dashboard/guilds/guild-1", cookie.Path)
+	assert.True(t, cookie.HttpOnly)
+	assert.True(t, cookie.Secure, "the dashboard is served over https")
+
+	// Links stop working after LinkLifetime, but a session lasts longer
+	link, err := url.Parse(d.links.Link(testGuildID, "user-1", d.clock.Now()))
+	require.NoError(t, err)
+	d.clock.Advance(LinkLifetime)
+	assert.Equal(t, http.StatusUnauthorized, d.get(link.RequestURI()).Code)
+	assert.Equal(t, http.StatusOK, d.get("/dashboard/guilds/guild-1", cookie).Code)
+	d.clock.Advance(SessionLifetime)
+	assert.Equal(t, http.StatusUnauthorized, d.get("/dashboard/guilds/guild-1", cookie).Code)
+}
+
+func TestDashboard_PagesNeedASessionForTheirGuild(t *testing.T) {
+	d := newTestDashboard(t)
+	assert.Equal(t, http.StatusUnauthorized, d.get("/dashboard/guilds/guild-1").Code)
+
+	// A session for one server doesn't open another's dashboard
+	cookie := d.signIn(t, "user-1")
+	rec := d.get("/dashboard/guilds/guild-2", cookie)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Run /dashboard in the server")
+
+	// Nor does a link token used as a cookie
+	link, err := url.Parse(d.links.Link(testGuildID, "user-1", d.clock.Now()))
+	require.NoError(t, err)
+	forged := &http.Cookie{Name: sessionCookie, Value: link.Query().Get("token")}
+	assert.Equal(t, http.StatusUnauthorized, d.get("/dashboard/guilds/guild-1", forged).Code)
+}
+
+func TestDashboard_OverviewShowsLeaderboardStudyingNowAndTotals(t *testing.T) {
+	d := newTestDashboard(t)
+	ctx := context.Background()
+	today := service.StartOfDay(d.clock.Now(), service.GetManilaLocation())
+	_, err := d.db.CreateUser(ctx, database.CreateUserParams{UserID: "user-1", Username: sql.NullString{String: "alice", Valid: true}})
+	require.NoError(t, err)
+	_, err = d.db.CreateUser(ctx, database.CreateUserParams{UserID: "user-2", Username: sql.NullString{String: "<b>bob</b>", Valid: true}})
+	require.NoError(t, err)
+	for userID, minutes := range map[string]int64{"user-1": 90, "user-2": 45} {
+		require.NoError(t, d.db.AddDailyActivity(ctx, database.AddDailyActivityParams{
+			UserID:       userID,
+			GuildID:      testGuildID,
+			ActivityDate: today,
+			StudyMs:      minutes * time.Minute.Milliseconds(),
+		}))
+	}
+	d.server.SetActiveSessions(func(guildID string) []ActiveSession {
+		if guildID != testGuildID {
+			return nil
+		}
+		return []ActiveSession{{UserID: "user-2", StartTime: d.clock.Now().Add(-25 * time.Minute)}}
+	})
+	// An open row the bot isn't tracking, e.g. left behind by a crash, doesn't count
+	_, err = d.db.CreateStudySession(ctx, database.CreateStudySessionParams{
+		UserID:    sql.NullString{String: "user-1", Valid: true},
+		GuildID:   sql.NullString{String: testGuildID, Valid: true},
+		StartTime: d.clock.Now().Add(-5 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	rec := d.get("/dashboard/guilds/guild-1", d.signIn(t, "user-1"))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Security-Policy"), "default-src 'none'")
+	body := rec.Body.String()
+	assert.Contains(t, body, `<tr class="you"><td>1</td><td><a href="/dashboard/guilds/guild-1/users/user-1">alice</a></td><td>1h 30m</td></tr>`)
+	assert.Contains(t, body, "&lt;b&gt;bob&lt;/b&gt;</a> <span class=\"muted\">for 25m</span>", "names are escaped")
+	assert.NotContains(t, body, "alice</a> <span class=\"muted\">for", "only sessions the bot is tracking are listed")
+	assert.Contains(t, body, "<strong>2h 15m</strong> in total · 1 active days · up to 2 members in a day")
+	assert.Contains(t, body, `<rect x="377" y="0" width="10" height="120"><title>Fri Oct 16: 2h 15m by 2 members</title></rect>`)
+
+	assert.Equal(t, http.StatusNotFound, d.get("/dashboard/guilds/guild-1?period=year", d.signIn(t, "user-1")).Code)
+}
+
+func TestDashboard_ProfileShowsStatsBadgesAndHeatmap(t *testing.T) {
+	d := newTestDashboard(t)
+	ctx := context.Background()
+	manila := service.GetManilaLocation()
+	cookie := d.signIn(t, "user-1")
+
+	rec := d.get("/dashboard/guilds/guild-1/users/user-1", cookie)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "hasn&#39;t studied in this server yet")
+
+	today := service.StartOfDay(d.clock.Now(), manila)
+	_, err := d.db.StartDailyActivity(ctx, database.StartDailyActivityParams{
+		UserID:           "user-1",
+		GuildID:          testGuildID,
+		LastActivityDate: sql.NullTime{Time: today, Valid: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, d.db.UpdateStreakImmediately(ctx, database.UpdateStreakImmediatelyParams{UserID: "user-1", GuildID: testGuildID, CurrentStreakCount: 4, MaxStreakCount: 9}))
+	require.NoError(t, d.db.AddDailyActivity(ctx, database.AddDailyActivityParams{UserID: "user-1", GuildID: testGuildID, ActivityDate: today, StudyMs: (3 * time.Hour).Milliseconds()}))
+	d.db.AddAchievement(database.Achievement{AchievementID: "getting_started", Name: "Getting Started", Description: "Study for an hour", Icon: "🌱", Category: "duration"})
+	_, err = d.db.AwardAchievement(ctx, database.AwardAchievementParams{UserID: "user-1", GuildID: testGuildID, AchievementID: "getting_started"})
+	require.NoError(t, err)
+
+	rec = d.get("/dashboard/guilds/guild-1/users/user-1", cookie)
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `(you)`)
+	assert.Contains(t, body, `<span class="value">3h 00m</span><span class="label">Today</span>`)
+	assert.Contains(t, body, `<span class="value">🔥 4</span><span class="label">Streak (best 9)</span>`)
+	assert.Contains(t, body, "<strong>Getting Started</strong>")
+	assert.Contains(t, body, `<span class="day l4" title="Fri Oct 16: 3h 00m"></span>`)
+	assert.Contains(t, body, `<span class="day future"></span>`, "Saturday hasn't happened yet")
+}
+
+func TestBuildHeatmap_LevelsAndLayout(t *testing.T) {
+	manila := service.GetManilaLocation()
+	today := time.Date(2026, 10, 14, 0, 0, 0, 0, manila) // A Wednesday
+	start := service.StartOfWeek(today, manila).AddDate(0, 0, -7*(heatmapWeeks-1))
+	activity := []database.UserDailyActivity{
+		{ActivityDate: today, StudyMs: (45 * time.Minute).Milliseconds()},
+		{ActivityDate: start, StudyMs: (10 * time.Minute).Milliseconds()},
+	}
+
+	weeks := buildHeatmap(activity, start, today)
+	require.Len(t, weeks, heatmapWeeks)
+	assert.Equal(t, 1, weeks[0][0].Level)
+	last := weeks[heatmapWeeks-1]
+	assert.Equal(t, heatCell{Level: 2, Label: "Wed Oct 14: 45m"}, last[3])
+	assert.True(t, last[4].Future)
+	assert.Equal(t, 0, last[2].Level)
+}
+
+func TestDashboard_ServesEmbeddedStylesheet(t *testing.T) {
+	d := newTestDashboard(t)
+	rec := d.get("/dashboard/static/style.css")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/css")
+	assert.Equal(t, http.StatusNotFound, d.get("/dashboard/static/templates/layout.html").Code, "only static/ is served")
+	assert.Equal(t, http.StatusNotFound, d.get("/dashboard/templates/layout.html").Code)
+}
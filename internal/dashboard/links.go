@@ -0,0 +1,93 @@
+package dashboard
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// LinkLifetime is how long a link sent by /dashboard can be used to sign in
+	LinkLifetime = time.Hour
+
+	// SessionLifetime is how long the dashboard stays signed in after following a link. Sessions
+	// can't be revoked, so it is kept short: a member who leaves or is kicked loses access within it.
+	SessionLifetime = 12 * time.Hour
+)
+
+// Signatures are bound to what a token is for, so a session cookie can't be replayed as a link
+const (
+	purposeLink    = "dashboard-link"
+	purposeSession = "dashboard-session"
+)
+
+// errInvalidToken is returned for tokens that are malformed, tampered with or expired
+var errInvalidToken = errors.New("invalid or expired dashboard token")
+
+// grant is what a verified token allows: userID may view guildID's dashboard until Expires
+type grant struct {
+	GuildID string
+	UserID  string
+	Expires time.Time
+}
+
+// Links signs and checks dashboard tokens. A token is "guildID.userID.expiry.signature", signed with
+// HMAC-SHA256, so the dashboard needs no accounts, sessions table or external auth provider.
+type Links struct {
+	baseURL string
+	secret  []byte
+}
+
+// NewLinks creates Links for a dashboard served at baseURL, e.g. https://lockin.example.com
+func NewLinks(baseURL, secret string) *Links {
+	return &Links{baseURL: strings.TrimRight(baseURL, "/"), secret: []byte(secret)}
+}
+
+// Link returns a sign-in link to guildID's dashboard for userID, valid for LinkLifetime after now
+func (l *Links) Link(guildID, userID string, now time.Time) string {
+	token := l.sign(purposeLink, newGrant(guildID, userID, now.Add(LinkLifetime)))
+	return l.baseURL + "/dashboard/login?token=" + url.QueryEscape(token)
+}
+
+// secure reports whether the dashboard is served over HTTPS, so its cookie can be marked Secure
+func (l *Links) secure() bool {
+	return strings.HasPrefix(l.baseURL, "https://")
+}
+
+// newGrant truncates expires to the second, the precision tokens carry
+func newGrant(guildID, userID string, expires time.Time) grant {
+	return grant{GuildID: guildID, UserID: userID, Expires: time.Unix(expires.Unix(), 0)}
+}
+
+func (l *Links) sign(purpose string, g grant) string {
+	payload := g.GuildID + "." + g.UserID + "." + strconv.FormatInt(g.Expires.Unix(), 10)
+	return payload + "." + l.mac(purpose, payload)
+}
+
+func (l *Links) mac(purpose, payload string) string {
+	h := hmac.New(sha256.New, l.secret)
+	h.Write([]byte(purpose + ":" + payload))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// verify returns the grant in token if it was signed for purpose and hasn't expired at now
+func (l *Links) verify(purpose, token string, now time.Time) (grant, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 || parts[0] == "" || parts[1] == "" {
+		return grant{}, errInvalidToken
+	}
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(l.mac(purpose, payload))) {
+		return grant{}, errInvalidToken
+	}
+	expiry, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || !now.Before(time.Unix(expiry, 0)) {
+		return grant{}, errInvalidToken
+	}
+	return grant{GuildID: parts[0], UserID: parts[1], Expires: time.Unix(expiry, 0)}, nil
+}
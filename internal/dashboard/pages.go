@@ -0,0 +1,373 @@
+package dashboard
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/service"
+)
+
+const (
+	// leaderboardSize is how many members the overview ranks
+	leaderboardSize = 25
+
+	// totalsDays is how many days the community totals chart covers, ending today
+	totalsDays = 30
+
+	// heatmapWeeks is how many weeks a profile's heatmap covers, ending this week
+	heatmapWeeks = 26
+)
+
+// Leaderboard periods, on Manila calendar days like the bot's resets
+var periods = []struct{ Name, Label string }{
+	{"week", "This week"},
+	{"month", "This month"},
+	{"all", "All time"},
+}
+
+// overviewPage is the data of the overview template
+type overviewPage struct {
+	layout
+	Periods     []periodTab
+	Leaderboard []leaderboardRow
+	StudyingNow []studier
+	Totals      totalsChart
+}
+
+type periodTab struct {
+	Name, Label string
+	Active      bool
+}
+
+type leaderboardRow struct {
+	Rank   int
+	UserID string
+	Name   string
+	Time   string
+	IsYou  bool
+}
+
+type studier struct {
+	UserID  string
+	Name    string
+	Elapsed string
+}
+
+// totalsChart is a bar chart of the guild's study time per day, drawn as inline SVG
+type totalsChart struct {
+	Width, Height int
+	Bars          []bar
+	Total         string
+	ActiveDays    int
+	PeakMembers   int64
+}
+
+type bar struct {
+	X, Y, Width, Height int
+	Label               string
+}
+
+// Bar chart geometry, in SVG user units
+const (
+	chartHeight = 120
+	barWidth    = 10
+	barGap      = 3
+)
+
+// overview shows the guild's leaderboard for the period query parameter, who is studying now and
+// the community's study time over the last totalsDays days
+func (s *Server) overview(ctx context.Context, r *http.Request, viewer grant) (*template.Template, any, error) {
+	now := s.clock.Now()
+	loc := service.GetManilaLocation()
+	today := service.StartOfDay(now, loc)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	period := r.URL.Query().Get("period")
+	var from time.Time // The zero time, for all time
+	switch period {
+	case "", "week":
+		period = "week"
+		from = service.StartOfWeek(now, loc)
+	case "month":
+		from = service.StartOfMonth(now, loc)
+	case "all":
+	default:
+		return nil, nil, errNotFound("Unknown leaderboard period.")
+	}
+
+	page := overviewPage{layout: s.layout(viewer, "Study Dashboard")}
+	for _, p := range periods {
+		page.Periods = append(page.Periods, periodTab{Name: p.Name, Label: p.Label, Active: p.Name == period})
+	}
+
+	rows, err := s.db.GetGuildLeaderboard(ctx, database.GetGuildLeaderboardParams{
+		GuildID:    viewer.GuildID,
+		FromDate:   from,
+		ToDate:     tomorrow,
+		PageSize:   leaderboardSize,
+		PageOffset: 0,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+	for i, row := range rows {
+		page.Leaderboard = append(page.Leaderboard, leaderboardRow{
+			Rank:   i + 1,
+			UserID: row.UserID,
+			Name:   displayName(row.UserID, row.Username),
+			Time:   formatStudyTime(row.StudyMs),
+			IsYou:  row.UserID == viewer.UserID,
+		})
+	}
+
+	page.StudyingNow, err = s.studyingNow(ctx, viewer.GuildID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totals, err := s.db.GetGuildDailyTotals(ctx, database.GetGuildDailyTotalsParams{
+		GuildID:  viewer.GuildID,
+		FromDate: today.AddDate(0, 0, 1-totalsDays),
+		ToDate:   tomorrow,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get daily totals: %w", err)
+	}
+	page.Totals = buildTotalsChart(totals, today)
+	return overviewTemplate, page, nil
+}
+
+// studyingNow lists the guild's active sessions, longest first
+func (s *Server) studyingNow(ctx context.Context, guildID string, now time.Time) ([]studier, error) {
+	fn := s.activeSessions.Load()
+	if fn == nil {
+		return nil, nil
+	}
+	active := (*fn)(guildID)
+	sort.Slice(active, func(i, j int) bool {
+		if !active[i].StartTime.Equal(active[j].StartTime) {
+			return active[i].StartTime.Before(active[j].StartTime)
+		}
+		return active[i].UserID < active[j].UserID
+	})
+
+	var studiers []studier
+	for _, session := range active {
+		user, err := s.db.GetUser(ctx, session.UserID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		studiers = append(studiers, studier{
+			UserID:  session.UserID,
+			Name:    displayName(session.UserID, user.Username),
+			Elapsed: formatStudyTime(now.Sub(session.StartTime).Milliseconds()),
+		})
+	}
+	return studiers, nil
+}
+
+// buildTotalsChart draws one bar per day for the totalsDays days ending today, scaled to the busiest day
+func buildTotalsChart(rows []database.GetGuildDailyTotalsRow, today time.Time) totalsChart {
+	byDate := make(map[string]database.GetGuildDailyTotalsRow, len(rows))
+	var peakMs, totalMs int64
+	chart := totalsChart{Width: totalsDays * (barWidth + barGap), Height: chartHeight}
+	for _, row := range rows {
+		byDate[row.ActivityDate.Format(time.DateOnly)] = row
+		peakMs = max(peakMs, row.StudyMs)
+		totalMs += row.StudyMs
+		if row.StudyMs > 0 {
+			chart.ActiveDays++
+		}
+		chart.PeakMembers = max(chart.PeakMembers, row.Members)
+	}
+	chart.Total = formatStudyTime(totalMs)
+
+	for i := 0; i < totalsDays; i++ {
+		day := today.AddDate(0, 0, i+1-totalsDays)
+		row := byDate[day.Format(time.DateOnly)]
+		height := 0
+		if peakMs > 0 {
+			height = int(row.StudyMs * chartHeight / peakMs)
+		}
+		if row.StudyMs > 0 {
+			height = max(height, 1) // Every day with study shows up
+		}
+		chart.Bars = append(chart.Bars, bar{
+			X:      i * (barWidth + barGap),
+			Y:      chartHeight - height,
+			Width:  barWidth,
+			Height: height,
+			Label:  fmt.Sprintf("%s: %s by %s", day.Format("Mon Jan 2"), formatStudyTime(row.StudyMs), plural(row.Members, "member")),
+		})
+	}
+	return chart
+}
+
+// profilePage is the data of the profile template
+type profilePage struct {
+	layout
+	UserID        string
+	Name          string
+	IsYou         bool
+	Today         string
+	Week          string
+	Month         string
+	Total         string
+	ActiveDays    int64
+	CurrentStreak int32
+	MaxStreak     int32
+	Badges        []badge
+	Heatmap       [][]heatCell // One column per week, Sunday first
+}
+
+type badge struct {
+	Icon, Name, Description string
+	Earned                  string
+}
+
+// heatCell is one day of the heatmap; Level runs from 0 (no study) to 4
+type heatCell struct {
+	Level  int
+	Label  string
+	Future bool
+}
+
+// heatLevels are the study times at which a heatmap day gets darker
+var heatLevels = []time.Duration{time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour}
+
+// profile shows a member's study time, streak, badges and a heatmap of their last heatmapWeeks weeks
+func (s *Server) profile(ctx context.Context, r *http.Request, viewer grant) (*template.Template, any, error) {
+	userID := r.PathValue("userID")
+	now := s.clock.Now()
+	loc := service.GetManilaLocation()
+	today := service.StartOfDay(now, loc)
+
+	streak, err := s.db.GetUserStreak(ctx, database.GetUserStreakParams{UserID: userID, GuildID: viewer.GuildID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, errNotFound("This member hasn't studied in this server yet.")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user streak: %w", err)
+	}
+	user, err := s.db.GetUser(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	totals, err := s.db.GetUserGuildStudyTotals(ctx, database.GetUserGuildStudyTotalsParams{
+		MonthStart: service.StartOfMonth(now, loc),
+		WeekStart:  service.StartOfWeek(now, loc),
+		Today:      today,
+		UserID:     userID,
+		GuildID:    viewer.GuildID,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get study totals: %w", err)
+	}
+
+	name := displayName(userID, user.Username)
+	page := profilePage{
+		layout:        s.layout(viewer, name),
+		UserID:        userID,
+		Name:          name,
+		IsYou:         userID == viewer.UserID,
+		Today:         formatStudyTime(totals.TodayMs),
+		Week:          formatStudyTime(totals.WeekMs),
+		Month:         formatStudyTime(totals.MonthMs),
+		Total:         formatStudyTime(totals.TotalMs),
+		ActiveDays:    totals.ActiveDays,
+		CurrentStreak: streak.CurrentStreakCount,
+		MaxStreak:     streak.MaxStreakCount,
+	}
+
+	achievements, err := s.db.GetUserAchievements(ctx, database.GetUserAchievementsParams{UserID: userID, GuildID: viewer.GuildID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get achievements: %w", err)
+	}
+	for _, a := range achievements {
+		b := badge{Icon: a.Icon, Name: a.Name, Description: a.Description}
+		if a.EarnedAt.Valid {
+			b.Earned = a.EarnedAt.Time.In(loc).Format("Jan 2, 2006")
+		}
+		page.Badges = append(page.Badges, b)
+	}
+
+	start := service.StartOfWeek(now, loc).AddDate(0, 0, -7*(heatmapWeeks-1))
+	activity, err := s.db.GetDailyActivity(ctx, database.GetDailyActivityParams{
+		UserID:   userID,
+		GuildID:  viewer.GuildID,
+		FromDate: start,
+		ToDate:   today,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get daily activity: %w", err)
+	}
+	page.Heatmap = buildHeatmap(activity, start, today)
+	return profileTemplate, page, nil
+}
+
+// buildHeatmap lays out heatmapWeeks weeks of days from start, a Sunday, marking days after today as future
+func buildHeatmap(activity []database.UserDailyActivity, start, today time.Time) [][]heatCell {
+	studied := make(map[string]int64, len(activity))
+	for _, a := range activity {
+		studied[a.ActivityDate.Format(time.DateOnly)] += a.StudyMs
+	}
+
+	weeks := make([][]heatCell, heatmapWeeks)
+	for w := range weeks {
+		weeks[w] = make([]heatCell, 7)
+		for d := range weeks[w] {
+			day := start.AddDate(0, 0, w*7+d)
+			if day.After(today) {
+				weeks[w][d] = heatCell{Future: true}
+				continue
+			}
+			ms := studied[day.Format(time.DateOnly)]
+			level := 0
+			for _, threshold := range heatLevels {
+				if time.Duration(ms)*time.Millisecond >= threshold {
+					level++
+				}
+			}
+			weeks[w][d] = heatCell{Level: level, Label: day.Format("Mon Jan 2") + ": " + formatStudyTime(ms)}
+		}
+	}
+	return weeks
+}
+
+func (s *Server) layout(viewer grant, title string) layout {
+	return layout{Title: title, GuildID: viewer.GuildID, ViewerID: viewer.UserID, GeneratedAt: s.clock.Now().In(service.GetManilaLocation())}
+}
+
+// displayName is the member's stored username, or a placeholder for members the bot never saw a name for
+func displayName(userID string, username sql.NullString) string {
+	if username.Valid && username.String != "" {
+		return username.String
+	}
+	if len(userID) > 4 {
+		return "Member …" + userID[len(userID)-4:]
+	}
+	return "Member " + userID
+}
+
+// formatStudyTime formats milliseconds of study as hours and minutes, e.g. "3h 05m" or "42m"
+func formatStudyTime(ms int64) string {
+	minutes := ms / time.Minute.Milliseconds()
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
+
+func plural(n int64, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -1,9 +1,13 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/Skufu/LockIn-Bot/internal/health"
 	_ "github.com/lib/pq" // Import PostgreSQL driver
 	"github.com/pressly/goose/v3"
 )
@@ -12,10 +16,22 @@ import (
 type Connection struct {
 	db      *sql.DB
 	Querier *Queries
+	logger  *slog.Logger
 }
 
-// Connect establishes a connection to the database
-func Connect(host, port, user, password, dbname string) (*Connection, error) {
+// TxManager runs a unit of work inside a single database transaction
+type TxManager interface {
+	ExecTx(ctx context.Context, fn func(Querier) error) error
+}
+
+// Connect establishes a connection to the database. A nil logger uses slog.Default().
+func Connect(host, port, user, password, dbname string, logger *slog.Logger) (*Connection, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger = logger.With("db_host", host, "db_name", dbname)
+	startTime := time.Now()
+
 	// For Neon PostgreSQL, SSL should be enabled
 	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=require",
 		host, port, user, password, dbname)
@@ -35,7 +51,7 @@ func Connect(host, port, user, password, dbname string) (*Connection, error) {
 	_, err = db.Exec("DEALLOCATE ALL")
 	if err != nil {
 		// This is usually safe to ignore on new connections
-		fmt.Printf("Warning: Failed to clear prepared statements (this is usually safe to ignore on new connections): %v\n", err)
+		logger.Warn("Failed to clear prepared statements", "error", err)
 	}
 
 	// Force a fresh connection to clear any PostgreSQL statement cache issues
@@ -48,9 +64,11 @@ func Connect(host, port, user, password, dbname string) (*Connection, error) {
 	db.SetMaxOpenConns(25) // Limit open connections for serverless
 	db.SetMaxIdleConns(5)  // Keep some connections ready to go
 
+	logger.Info("Connected to database", "duration", time.Since(startTime))
 	return &Connection{
 		db:      db,
-		Querier: New(db),
+		Querier: New(instrument(db)),
+		logger:  logger,
 	}, nil
 }
 
@@ -62,14 +80,46 @@ func (c *Connection) MigrateUp(migrationsDir string) error {
 		return fmt.Errorf("failed to set dialect: %w", err)
 	}
 
+	goose.SetLogger(slog.NewLogLogger(c.logger.Handler(), slog.LevelInfo))
 	err = goose.Up(c.db, migrationsDir)
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	c.logger.Info("Database migrations completed", "dir", migrationsDir)
+	return nil
+}
+
+// ExecTx runs fn inside a transaction, committing if it returns nil and rolling back otherwise
+func (c *Connection) ExecTx(ctx context.Context, fn func(Querier) error) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(New(instrument(tx))); err != nil {
+		c.logger.DebugContext(ctx, "Rolling back transaction", "error", err)
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
+// Health pings the database, reporting how long the round trip took
+func (c *Connection) Health(ctx context.Context) health.Result {
+	start := time.Now()
+	if err := c.db.PingContext(ctx); err != nil {
+		return health.Down(err.Error())
+	}
+	return health.OK(fmt.Sprintf("ping %s", time.Since(start).Round(time.Millisecond)))
+}
+
 // Close closes the database connection
 func (c *Connection) Close() error {
 	return c.db.Close()
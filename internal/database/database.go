@@ -3,7 +3,7 @@ package database
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/url"
 	"time"
 )
@@ -15,7 +15,7 @@ func ConnectToDatabase(databaseURL string) (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to parse database URL: %w", err)
 	}
 
-	log.Printf("Connecting to Neon PostgreSQL database at %s...", parsedURL.Host)
+	slog.Info("Connecting to database", "db_host", parsedURL.Host)
 
 	startTime := time.Now()
 
@@ -33,11 +33,10 @@ func ConnectToDatabase(databaseURL string) (*sql.DB, error) {
 	// Clear any cached prepared statements to prevent parameter binding issues
 	_, err = db.Exec("DEALLOCATE ALL")
 	if err != nil {
-		log.Printf("Warning: Failed to clear prepared statements (this is usually safe to ignore): %v", err)
+		slog.Warn("Failed to clear prepared statements", "error", err)
 	}
 
-	duration := time.Since(startTime)
-	log.Printf("Successfully connected to database in %v", duration)
+	slog.Info("Connected to database", "db_host", parsedURL.Host, "duration", time.Since(startTime))
 
 	return db, nil
 }
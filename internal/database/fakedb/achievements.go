@@ -0,0 +1,31 @@
+package fakedb
+
+import (
+	"database/sql"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+)
+
+// defaultAchievements mirrors the catalogue seeded by the achievements migration
+var defaultAchievements = []database.Achievement{
+	{AchievementID: "first_flame", Name: "First Flame", Description: "Start your study streak journey", Icon: "🔥", Category: "streak", RequirementType: "streak_count", RequirementValue: 3, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 1, Valid: true}},
+	{AchievementID: "streak_starter", Name: "Streak Starter", Description: "Building momentum!", Icon: "⚡", Category: "streak", RequirementType: "streak_count", RequirementValue: 7, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 2, Valid: true}},
+	{AchievementID: "consistent", Name: "Consistent", Description: "Two weeks of dedication", Icon: "🌟", Category: "streak", RequirementType: "streak_count", RequirementValue: 14, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 3, Valid: true}},
+	{AchievementID: "monthly_master", Name: "Monthly Master", Description: "A full month of studying", Icon: "💫", Category: "streak", RequirementType: "streak_count", RequirementValue: 30, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 4, Valid: true}},
+	{AchievementID: "legendary", Name: "Legendary", Description: "Unstoppable dedication", Icon: "🌈", Category: "streak", RequirementType: "streak_count", RequirementValue: 100, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 5, Valid: true}},
+	{AchievementID: "early_bird", Name: "Early Bird", Description: "The early scholar catches success", Icon: "🌅", Category: "time", RequirementType: "study_before_hour", RequirementValue: 7, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 10, Valid: true}},
+	{AchievementID: "night_owl", Name: "Night Owl", Description: "Burning the midnight oil", Icon: "🦉", Category: "time", RequirementType: "study_after_hour", RequirementValue: 0, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 11, Valid: true}},
+	{AchievementID: "dawn_to_dusk", Name: "Dawn to Dusk", Description: "Study spanning sunrise to sunset", Icon: "🌙", Category: "time", RequirementType: "daily_hours", RequirementValue: 12, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 12, Valid: true}},
+	{AchievementID: "weekend_warrior", Name: "Weekend Warrior", Description: "No days off!", Icon: "☀️", Category: "time", RequirementType: "weekend_study", RequirementValue: 1, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 13, Valid: true}},
+	{AchievementID: "graveyard_shift", Name: "Graveyard Shift", Description: "Studying in the dead of night", Icon: "🎃", Category: "time", RequirementType: "study_between_hours", RequirementValue: 2, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 14, Valid: true}},
+	{AchievementID: "getting_started", Name: "Getting Started", Description: "Every journey begins with one step", Icon: "⏱️", Category: "duration", RequirementType: "total_hours", RequirementValue: 1, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 20, Valid: true}},
+	{AchievementID: "focused", Name: "Focused", Description: "Building your study habit", Icon: "🎯", Category: "duration", RequirementType: "total_hours", RequirementValue: 10, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 21, Valid: true}},
+	{AchievementID: "bookworm", Name: "Bookworm", Description: "Serious dedication to learning", Icon: "📚", Category: "duration", RequirementType: "total_hours", RequirementValue: 50, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 22, Valid: true}},
+	{AchievementID: "marathon_runner", Name: "Marathon Runner", Description: "A single legendary session", Icon: "💪", Category: "duration", RequirementType: "session_hours", RequirementValue: 5, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 23, Valid: true}},
+	{AchievementID: "century_club", Name: "Century Club", Description: "Triple digit hero", Icon: "🏆", Category: "duration", RequirementType: "total_hours", RequirementValue: 100, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 24, Valid: true}},
+	{AchievementID: "rising_star", Name: "Rising Star", Description: "Making your mark", Icon: "📈", Category: "competition", RequirementType: "leaderboard_rank", RequirementValue: 10, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 30, Valid: true}},
+	{AchievementID: "study_king", Name: "Study King", Description: "Wear the crown", Icon: "👑", Category: "competition", RequirementType: "leaderboard_rank", RequirementValue: 1, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 31, Valid: true}},
+	{AchievementID: "undefeated", Name: "Undefeated", Description: "Dominant performance", Icon: "🥇", Category: "competition", RequirementType: "rank_one_days", RequirementValue: 7, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 32, Valid: true}},
+	{AchievementID: "comeback_kid", Name: "Comeback Kid", Description: "Bounced back from a broken streak", Icon: "🎭", Category: "special", RequirementType: "streak_comeback", RequirementValue: 7, IsSecret: sql.NullBool{Bool: true, Valid: true}, SortOrder: sql.NullInt32{Int32: 40, Valid: true}},
+	{AchievementID: "global_citizen", Name: "Global Citizen", Description: "Around the clock studying", Icon: "🌍", Category: "special", RequirementType: "unique_hours", RequirementValue: 12, IsSecret: sql.NullBool{Bool: false, Valid: true}, SortOrder: sql.NullInt32{Int32: 41, Valid: true}},
+}
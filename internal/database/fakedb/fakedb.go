@@ -0,0 +1,2224 @@
+// Package fakedb provides an in-memory implementation of database.Querier for tests.
+//
+// It follows the semantics of the SQL in db/queries.sql closely enough to run the bot's
+// session, stats, streak, achievement and notification flows without Postgres.
+// ExecTx runs the function against the same store and restores a snapshot if it fails,
+// so rollbacks can be tested too.
+package fakedb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+)
+
+var (
+	_ database.Querier   = (*Querier)(nil)
+	_ database.TxManager = (*Querier)(nil)
+)
+
+// manila mirrors the 'Asia/Manila' zone used by the time-of-day queries
+var manila = func() *time.Location {
+	loc, err := time.LoadLocation("Asia/Manila")
+	if err != nil {
+		return time.FixedZone("Asia/Manila", 8*60*60)
+	}
+	return loc
+}()
+
+type streakKey struct {
+	userID  string
+	guildID string
+}
+
+type dailyActivityKey struct {
+	userID  string
+	guildID string
+	date    time.Time
+}
+
+type subscriptionKey struct {
+	userID  string
+	guildID string
+}
+
+type achievementKey struct {
+	userID        string
+	guildID       string
+	achievementID string
+}
+
+type roleCapabilityKey struct {
+	guildID    string
+	roleID     string
+	capability string
+}
+
+// tables holds every row in the fake database; it is copied wholesale for transactions
+type tables struct {
+	users            map[string]database.User
+	stats            map[string]database.UserStat
+	sessions         []database.StudySession
+	archive          []database.StudySessionsArchive
+	streaks          map[streakKey]database.UserStreak
+	achievements     map[string]database.Achievement
+	userAchievements map[achievementKey]database.UserAchievement
+	auditLog         []database.AuditLog
+	outbox           []database.NotificationsOutbox
+	liveStatus       map[string]database.LiveStatusMessage
+	dailyActivity    map[dailyActivityKey]database.UserDailyActivity
+	recaps           []database.Recap
+	recapSubscribers map[subscriptionKey]database.RecapSubscription
+	preferences      map[string]database.NotificationPreference
+	guildSettings    map[string]database.GuildSetting
+	userReminders    map[subscriptionKey]database.UserReminderSetting
+	streakEvents     []database.StreakEvent
+	evaluations      map[string]database.StreakEvaluation
+	roleCapabilities map[roleCapabilityKey]database.GuildRoleCapability
+	apiTokens        []database.ApiToken
+
+	nextSessionID     int32
+	nextAuditID       int64
+	nextOutboxID      int64
+	nextRecapID       int64
+	nextStreakEventID int64
+	nextAPITokenID    int64
+}
+
+func (t *tables) clone() *tables {
+	c := *t
+	c.users = cloneMap(t.users)
+	c.stats = cloneMap(t.stats)
+	c.sessions = append([]database.StudySession(nil), t.sessions...)
+	c.archive = append([]database.StudySessionsArchive(nil), t.archive...)
+	c.streaks = cloneMap(t.streaks)
+	c.achievements = cloneMap(t.achievements)
+	c.userAchievements = cloneMap(t.userAchievements)
+	c.auditLog = append([]database.AuditLog(nil), t.auditLog...)
+	c.outbox = append([]database.NotificationsOutbox(nil), t.outbox...)
+	c.liveStatus = cloneMap(t.liveStatus)
+	c.dailyActivity = cloneMap(t.dailyActivity)
+	c.recaps = append([]database.Recap(nil), t.recaps...)
+	c.recapSubscribers = cloneMap(t.recapSubscribers)
+	c.preferences = cloneMap(t.preferences)
+	c.guildSettings = cloneMap(t.guildSettings)
+	c.userReminders = cloneMap(t.userReminders)
+	c.streakEvents = append([]database.StreakEvent(nil), t.streakEvents...)
+	c.evaluations = cloneMap(t.evaluations)
+	c.roleCapabilities = cloneMap(t.roleCapabilities)
+	c.apiTokens = append([]database.ApiToken(nil), t.apiTokens...)
+	return &c
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// Querier is an in-memory database.Querier and database.TxManager
+type Querier struct {
+	txMu sync.Mutex // Serializes ExecTx calls
+	mu   sync.Mutex
+	data *tables
+
+	// Now is used wherever the SQL calls NOW(); it defaults to time.Now
+	Now func() time.Time
+}
+
+// New creates an empty fake database seeded with the default achievement catalogue
+func New() *Querier {
+	q := &Querier{
+		data: &tables{
+			users:            make(map[string]database.User),
+			stats:            make(map[string]database.UserStat),
+			streaks:          make(map[streakKey]database.UserStreak),
+			achievements:     make(map[string]database.Achievement),
+			userAchievements: make(map[achievementKey]database.UserAchievement),
+			liveStatus:       make(map[string]database.LiveStatusMessage),
+			dailyActivity:    make(map[dailyActivityKey]database.UserDailyActivity),
+			recapSubscribers: make(map[subscriptionKey]database.RecapSubscription),
+			preferences:      make(map[string]database.NotificationPreference),
+			guildSettings:    make(map[string]database.GuildSetting),
+			userReminders:    make(map[subscriptionKey]database.UserReminderSetting),
+			evaluations:      make(map[string]database.StreakEvaluation),
+			roleCapabilities: make(map[roleCapabilityKey]database.GuildRoleCapability),
+		},
+		Now: time.Now,
+	}
+	for _, a := range defaultAchievements {
+		q.data.achievements[a.AchievementID] = a
+	}
+	return q
+}
+
+// ExecTx runs fn against the fake database. If fn returns an error every change it made is discarded.
+// Transactions are serialized with each other, so fn must not call ExecTx itself.
+func (q *Querier) ExecTx(ctx context.Context, fn func(database.Querier) error) error {
+	q.txMu.Lock()
+	defer q.txMu.Unlock()
+
+	q.mu.Lock()
+	snapshot := q.data.clone()
+	q.mu.Unlock()
+
+	if err := fn(q); err != nil {
+		q.mu.Lock()
+		q.data = snapshot
+		q.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// --- Test helpers ---
+
+// AddAchievement adds or replaces an achievement definition
+func (q *Querier) AddAchievement(a database.Achievement) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.data.achievements[a.AchievementID] = a
+}
+
+// SetStudySessionStart moves a session's start time, e.g. to simulate a user who joined hours ago
+func (q *Querier) SetStudySessionStart(sessionID int32, start time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.data.sessions {
+		if q.data.sessions[i].SessionID == sessionID {
+			q.data.sessions[i].StartTime = start
+			return nil
+		}
+	}
+	return fmt.Errorf("fakedb: no study session %d", sessionID)
+}
+
+// StudySessions returns every study session in insertion order
+func (q *Querier) StudySessions() []database.StudySession {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]database.StudySession(nil), q.data.sessions...)
+}
+
+// ArchivedStudySessions returns every archived study session in the order it was archived
+func (q *Querier) ArchivedStudySessions() []database.StudySessionsArchive {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]database.StudySessionsArchive(nil), q.data.archive...)
+}
+
+// UserAchievements returns every awarded achievement for a user, ordered by achievement ID
+func (q *Querier) UserAchievements(userID string) []database.UserAchievement {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []database.UserAchievement
+	for k, ua := range q.data.userAchievements {
+		if k.userID == userID {
+			out = append(out, ua)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AchievementID < out[j].AchievementID })
+	return out
+}
+
+// Notifications returns every row in the notification outbox in insertion order
+func (q *Querier) Notifications() []database.NotificationsOutbox {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]database.NotificationsOutbox(nil), q.data.outbox...)
+}
+
+// AuditLog returns every audit log entry in insertion order
+func (q *Querier) AuditLog() []database.AuditLog {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]database.AuditLog(nil), q.data.auditLog...)
+}
+
+// --- Helpers mirroring SQL semantics ---
+
+// dateOf truncates t to a DATE the way Postgres does for a timestamp parameter
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func sameDate(a, b sql.NullTime) bool {
+	return a.Valid && b.Valid && dateOf(a.Time).Equal(dateOf(b.Time))
+}
+
+// dateBefore reports whether a is a non-NULL date before b
+func dateBefore(a, b sql.NullTime) bool {
+	return a.Valid && b.Valid && dateOf(a.Time).Before(dateOf(b.Time))
+}
+
+func nullInt64(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: true}
+}
+
+func (q *Querier) now() time.Time {
+	if q.Now == nil {
+		return time.Now()
+	}
+	return q.Now()
+}
+
+func (q *Querier) sessionsFor(userID sql.NullString) []database.StudySession {
+	var out []database.StudySession
+	for _, s := range q.data.sessions {
+		if userID.Valid && s.UserID.Valid && s.UserID.String == userID.String {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func achievementRow(a database.Achievement) database.GetAllAchievementsRow {
+	return database.GetAllAchievementsRow{
+		AchievementID:    a.AchievementID,
+		Name:             a.Name,
+		Description:      a.Description,
+		Icon:             a.Icon,
+		Category:         a.Category,
+		RequirementType:  a.RequirementType,
+		RequirementValue: a.RequirementValue,
+		IsSecret:         a.IsSecret,
+		SortOrder:        a.SortOrder,
+	}
+}
+
+func (q *Querier) sortedAchievements(keep func(database.Achievement) bool, less func(a, b database.Achievement) bool) []database.Achievement {
+	var out []database.Achievement
+	for _, a := range q.data.achievements {
+		if keep(a) {
+			out = append(out, a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return less(out[i], out[j]) })
+	return out
+}
+
+func bySortOrder(a, b database.Achievement) bool {
+	if a.SortOrder.Int32 != b.SortOrder.Int32 {
+		return a.SortOrder.Int32 < b.SortOrder.Int32
+	}
+	return a.AchievementID < b.AchievementID
+}
+
+func deleteWhere[K comparable, V any](m map[K]V, match func(K) bool) int64 {
+	var n int64
+	for k := range m {
+		if match(k) {
+			delete(m, k)
+			n++
+		}
+	}
+	return n
+}
+
+// --- Users ---
+
+func (q *Querier) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	user := q.data.users[arg.UserID]
+	user.UserID = arg.UserID
+	user.Username = arg.Username
+	q.data.users[arg.UserID] = user
+	return user, nil
+}
+
+func (q *Querier) GetUser(ctx context.Context, userID string) (database.User, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	user, ok := q.data.users[userID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (q *Querier) DeleteUser(ctx context.Context, userID string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return deleteWhere(q.data.users, func(id string) bool { return id == userID }), nil
+}
+
+// --- Guild-scoped account deletion ---
+
+func (q *Querier) DeleteUserGuildAchievements(ctx context.Context, arg database.DeleteUserGuildAchievementsParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return deleteWhere(q.data.userAchievements, func(k achievementKey) bool {
+		return k.userID == arg.UserID && k.guildID == arg.GuildID
+	}), nil
+}
+
+func (q *Querier) DeleteUserGuildStreaks(ctx context.Context, arg database.DeleteUserGuildStreaksParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return deleteWhere(q.data.streaks, func(k streakKey) bool { return k == streakKey{arg.UserID, arg.GuildID} }), nil
+}
+
+func (q *Querier) DeleteUserGuildStreakEvents(ctx context.Context, arg database.DeleteUserGuildStreakEventsParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	kept := q.data.streakEvents[:0]
+	var n int64
+	for _, e := range q.data.streakEvents {
+		if e.UserID == arg.UserID && e.GuildID == arg.GuildID {
+			n++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	q.data.streakEvents = kept
+	return n, nil
+}
+
+func (q *Querier) DeleteUserGuildDailyActivity(ctx context.Context, arg database.DeleteUserGuildDailyActivityParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return deleteWhere(q.data.dailyActivity, func(k dailyActivityKey) bool {
+		return k.userID == arg.UserID && k.guildID == arg.GuildID
+	}), nil
+}
+
+func (q *Querier) DeleteUserGuildStudySessions(ctx context.Context, arg database.DeleteUserGuildStudySessionsParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.deleteSessions(func(s database.StudySession) bool {
+		return arg.UserID.Valid && arg.GuildID.Valid && s.UserID == arg.UserID && s.GuildID == arg.GuildID
+	}), nil
+}
+
+func (q *Querier) DeleteUserGuildArchivedStudySessions(ctx context.Context, arg database.DeleteUserGuildArchivedStudySessionsParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	kept := q.data.archive[:0]
+	var n int64
+	for _, a := range q.data.archive {
+		if arg.UserID.Valid && arg.GuildID.Valid && a.UserID == arg.UserID && a.GuildID == arg.GuildID {
+			n++
+			continue
+		}
+		kept = append(kept, a)
+	}
+	q.data.archive = kept
+	return n, nil
+}
+
+func (q *Querier) DeleteUserGuildNotifications(ctx context.Context, arg database.DeleteUserGuildNotificationsParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.deleteNotifications(func(n database.NotificationsOutbox) bool {
+		return arg.UserID.Valid && arg.GuildID.Valid && n.UserID == arg.UserID && n.GuildID == arg.GuildID
+	}), nil
+}
+
+func (q *Querier) DeleteUserGuildReminderSettings(ctx context.Context, arg database.DeleteUserGuildReminderSettingsParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return deleteWhere(q.data.userReminders, func(k subscriptionKey) bool { return k == subscriptionKey{arg.UserID, arg.GuildID} }), nil
+}
+
+func (q *Querier) SetFeaturedBadge(ctx context.Context, arg database.SetFeaturedBadgeParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	user, ok := q.data.users[arg.UserID]
+	if !ok {
+		return nil
+	}
+	user.FeaturedBadge = arg.FeaturedBadge
+	q.data.users[arg.UserID] = user
+	return nil
+}
+
+func (q *Querier) GetUserFeaturedBadge(ctx context.Context, userID string) (database.GetUserFeaturedBadgeRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	user, ok := q.data.users[userID]
+	if !ok {
+		return database.GetUserFeaturedBadgeRow{}, sql.ErrNoRows
+	}
+	row := database.GetUserFeaturedBadgeRow{FeaturedBadge: user.FeaturedBadge}
+	if a, ok := q.data.achievements[user.FeaturedBadge.String]; ok && user.FeaturedBadge.Valid {
+		row.Name = sql.NullString{String: a.Name, Valid: true}
+		row.Description = sql.NullString{String: a.Description, Valid: true}
+		row.Icon = sql.NullString{String: a.Icon, Valid: true}
+	}
+	return row, nil
+}
+
+// --- Study sessions ---
+
+func (q *Querier) CreateStudySession(ctx context.Context, arg database.CreateStudySessionParams) (database.StudySession, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.data.nextSessionID++
+	session := database.StudySession{
+		SessionID: q.data.nextSessionID,
+		UserID:    arg.UserID,
+		GuildID:   arg.GuildID,
+		StartTime: arg.StartTime,
+	}
+	q.data.sessions = append(q.data.sessions, session)
+	return session, nil
+}
+
+func (q *Querier) CreateManualStudySession(ctx context.Context, arg database.CreateManualStudySessionParams) (database.StudySession, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.data.nextSessionID++
+	session := database.StudySession{
+		SessionID:  q.data.nextSessionID,
+		UserID:     arg.UserID,
+		GuildID:    arg.GuildID,
+		StartTime:  arg.StartTime,
+		EndTime:    arg.EndTime,
+		DurationMs: arg.DurationMs,
+		IsManual:   true,
+	}
+	q.data.sessions = append(q.data.sessions, session)
+	return session, nil
+}
+
+func (q *Querier) EndStudySession(ctx context.Context, arg database.EndStudySessionParams) (database.StudySession, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, s := range q.data.sessions {
+		if s.SessionID != arg.SessionID || s.EndTime.Valid {
+			continue
+		}
+		s.EndTime = arg.EndTime
+		s.DurationMs = nullInt64(arg.EndTime.Time.Sub(s.StartTime).Milliseconds())
+		q.data.sessions[i] = s
+		return s, nil
+	}
+	return database.StudySession{}, sql.ErrNoRows
+}
+
+func (q *Querier) GetActiveStudySession(ctx context.Context, userID sql.NullString) (database.StudySession, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var active *database.StudySession
+	for _, s := range q.sessionsFor(userID) {
+		if s.EndTime.Valid {
+			continue
+		}
+		if active == nil || s.StartTime.After(active.StartTime) {
+			s := s
+			active = &s
+		}
+	}
+	if active == nil {
+		return database.StudySession{}, sql.ErrNoRows
+	}
+	return *active, nil
+}
+
+func (q *Querier) CountStudySessions(ctx context.Context) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int64(len(q.data.sessions)), nil
+}
+
+func (q *Querier) deleteSessions(match func(database.StudySession) bool) int64 {
+	kept := q.data.sessions[:0]
+	var n int64
+	for _, s := range q.data.sessions {
+		if match(s) {
+			n++
+			continue
+		}
+		kept = append(kept, s)
+	}
+	q.data.sessions = kept
+	return n
+}
+
+func (q *Querier) DeleteAllStudySessions(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.deleteSessions(func(database.StudySession) bool { return true })
+	return nil
+}
+
+func (q *Querier) DeleteOldStudySessions(ctx context.Context, startTime time.Time) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.deleteSessions(func(s database.StudySession) bool {
+		return s.StartTime.Before(startTime) && s.EndTime.Valid && q.archived(s.SessionID)
+	}), nil
+}
+
+func (q *Querier) ArchiveOldStudySessions(ctx context.Context, startTime time.Time) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.archiveSessions(func(s database.StudySession) bool {
+		return s.StartTime.Before(startTime) && s.EndTime.Valid
+	}, sql.NullString{})
+}
+
+func (q *Querier) DeleteUserStudySessions(ctx context.Context, userID sql.NullString) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.deleteSessions(func(s database.StudySession) bool {
+		return userID.Valid && s.UserID.Valid && s.UserID.String == userID.String
+	}), nil
+}
+
+// inGuildBefore matches the sessions CountGuildStudySessions and the other cleanup queries select
+func inGuildBefore(s database.StudySession, guildID sql.NullString, before time.Time) bool {
+	return guildID.Valid && s.GuildID.Valid && s.GuildID.String == guildID.String && s.StartTime.Before(before)
+}
+
+func (q *Querier) CountGuildStudySessions(ctx context.Context, arg database.CountGuildStudySessionsParams) (database.CountGuildStudySessionsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var row database.CountGuildStudySessionsRow
+	for _, s := range q.data.sessions {
+		if !inGuildBefore(s, arg.GuildID, arg.StartTime) {
+			continue
+		}
+		if s.EndTime.Valid {
+			row.ClosedSessions++
+		} else {
+			row.OpenSessions++
+		}
+	}
+	return row, nil
+}
+
+func (q *Querier) ArchiveGuildStudySessions(ctx context.Context, arg database.ArchiveGuildStudySessionsParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.archiveSessions(func(s database.StudySession) bool {
+		return inGuildBefore(s, arg.GuildID, arg.Before) && s.EndTime.Valid
+	}, arg.ArchivedBy)
+}
+
+// archived reports whether a session has been copied to the archive. Callers hold q.mu.
+func (q *Querier) archived(sessionID int32) bool {
+	for _, a := range q.data.archive {
+		if a.SessionID == sessionID {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveSessions copies the matching sessions to the archive, failing like the primary key
+// would if one is already there. Callers hold q.mu.
+func (q *Querier) archiveSessions(match func(database.StudySession) bool, archivedBy sql.NullString) (int64, error) {
+	var n int64
+	for _, s := range q.data.sessions {
+		if !match(s) {
+			continue
+		}
+		if q.archived(s.SessionID) {
+			return 0, fmt.Errorf("fakedb: duplicate key value violates unique constraint \"study_sessions_archive_pkey\"")
+		}
+		q.data.archive = append(q.data.archive, database.StudySessionsArchive{
+			SessionID:  s.SessionID,
+			UserID:     s.UserID,
+			GuildID:    s.GuildID,
+			StartTime:  s.StartTime,
+			EndTime:    s.EndTime.Time,
+			DurationMs: s.DurationMs,
+			IsManual:   s.IsManual,
+			ArchivedBy: archivedBy,
+			ArchivedAt: q.now(),
+		})
+		n++
+	}
+	return n, nil
+}
+
+func (q *Querier) DeleteGuildStudySessionsWithCount(ctx context.Context, arg database.DeleteGuildStudySessionsWithCountParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.deleteSessions(func(s database.StudySession) bool {
+		return inGuildBefore(s, arg.GuildID, arg.StartTime) && s.EndTime.Valid
+	}), nil
+}
+
+func (q *Querier) DeleteUserArchivedStudySessions(ctx context.Context, userID sql.NullString) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	kept := q.data.archive[:0]
+	var n int64
+	for _, a := range q.data.archive {
+		if userID.Valid && a.UserID.Valid && a.UserID.String == userID.String {
+			n++
+			continue
+		}
+		kept = append(kept, a)
+	}
+	q.data.archive = kept
+	return n, nil
+}
+
+func (q *Querier) GetUniqueStudyHours(ctx context.Context, userID sql.NullString) (int32, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	hours := make(map[int]struct{})
+	for _, s := range q.sessionsFor(userID) {
+		if s.IsManual {
+			continue
+		}
+		hours[s.StartTime.In(manila).Hour()] = struct{}{}
+	}
+	return int32(len(hours)), nil
+}
+
+func (q *Querier) HasDawnToDuskDay(ctx context.Context, userID sql.NullString) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	perDay := make(map[time.Time]time.Duration)
+	for _, s := range q.sessionsFor(userID) {
+		if s.IsManual {
+			continue
+		}
+		end := q.now()
+		if s.EndTime.Valid {
+			end = s.EndTime.Time
+		}
+		perDay[dateOf(s.StartTime.In(manila))] += end.Sub(s.StartTime)
+	}
+	for _, d := range perDay {
+		if d >= 12*time.Hour {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// --- User stats ---
+
+func (q *Querier) CreateOrUpdateUserStats(ctx context.Context, arg database.CreateOrUpdateUserStatsParams) (database.UserStat, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stats, ok := q.data.stats[arg.UserID]
+	if !ok {
+		stats = database.UserStat{
+			UserID:         arg.UserID,
+			TotalStudyMs:   arg.TotalStudyMs,
+			DailyStudyMs:   arg.DailyStudyMs,
+			WeeklyStudyMs:  arg.WeeklyStudyMs,
+			MonthlyStudyMs: arg.MonthlyStudyMs,
+		}
+	} else {
+		stats.TotalStudyMs = nullInt64(stats.TotalStudyMs.Int64 + arg.TotalStudyMs.Int64)
+		stats.DailyStudyMs = nullInt64(stats.DailyStudyMs.Int64 + arg.DailyStudyMs.Int64)
+		stats.WeeklyStudyMs = nullInt64(stats.WeeklyStudyMs.Int64 + arg.WeeklyStudyMs.Int64)
+		stats.MonthlyStudyMs = nullInt64(stats.MonthlyStudyMs.Int64 + arg.MonthlyStudyMs.Int64)
+	}
+	q.data.stats[arg.UserID] = stats
+	return stats, nil
+}
+
+func (q *Querier) GetUserStats(ctx context.Context, userID string) (database.UserStat, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stats, ok := q.data.stats[userID]
+	if !ok {
+		return database.UserStat{}, sql.ErrNoRows
+	}
+	return stats, nil
+}
+
+func (q *Querier) DeleteUserStats(ctx context.Context, userID string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return deleteWhere(q.data.stats, func(id string) bool { return id == userID }), nil
+}
+
+func (q *Querier) resetStats(set func(*database.UserStat)) {
+	for id, stats := range q.data.stats {
+		set(&stats)
+		q.data.stats[id] = stats
+	}
+}
+
+func (q *Querier) ResetDailyStudyTime(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.resetStats(func(s *database.UserStat) { s.DailyStudyMs = nullInt64(0) })
+	return nil
+}
+
+func (q *Querier) ResetWeeklyStudyTime(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.resetStats(func(s *database.UserStat) { s.WeeklyStudyMs = nullInt64(0) })
+	return nil
+}
+
+func (q *Querier) ResetMonthlyStudyTime(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.resetStats(func(s *database.UserStat) { s.MonthlyStudyMs = nullInt64(0) })
+	return nil
+}
+
+func (q *Querier) GetLeaderboard(ctx context.Context) ([]database.GetLeaderboardRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var rows []database.GetLeaderboardRow
+	for id, stats := range q.data.stats {
+		user, ok := q.data.users[id]
+		if !ok || stats.TotalStudyMs.Int64 <= 0 {
+			continue
+		}
+		rows = append(rows, database.GetLeaderboardRow{
+			Username:     user.Username,
+			TotalStudyMs: stats.TotalStudyMs,
+			UserID:       id,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].TotalStudyMs.Int64 != rows[j].TotalStudyMs.Int64 {
+			return rows[i].TotalStudyMs.Int64 > rows[j].TotalStudyMs.Int64
+		}
+		return rows[i].UserID < rows[j].UserID
+	})
+	if len(rows) > 10 {
+		rows = rows[:10]
+	}
+	return rows, nil
+}
+
+// --- Streaks ---
+
+func streakRow(s database.UserStreak) database.GetUserStreakRow {
+	return database.GetUserStreakRow{
+		UserID:                 s.UserID,
+		GuildID:                s.GuildID,
+		CurrentStreakCount:     s.CurrentStreakCount,
+		MaxStreakCount:         s.MaxStreakCount,
+		LastActivityDate:       s.LastActivityDate,
+		StreakEvaluatedDate:    s.StreakEvaluatedDate,
+		DailyActivityMinutes:   s.DailyActivityMinutes,
+		ActivityStartTime:      s.ActivityStartTime,
+		StreakIncrementedToday: s.StreakIncrementedToday,
+		WarningNotifiedAt:      s.WarningNotifiedAt,
+		CreatedAt:              s.CreatedAt,
+		UpdatedAt:              s.UpdatedAt,
+		PreviousStreak:         s.PreviousStreak,
+		StreakBrokenAt:         s.StreakBrokenAt,
+	}
+}
+
+func (q *Querier) updateStreak(userID, guildID string, update func(*database.UserStreak)) {
+	key := streakKey{userID, guildID}
+	s, ok := q.data.streaks[key]
+	if !ok {
+		return
+	}
+	update(&s)
+	s.UpdatedAt = q.now()
+	q.data.streaks[key] = s
+}
+
+func (q *Querier) GetUserStreak(ctx context.Context, arg database.GetUserStreakParams) (database.GetUserStreakRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	s, ok := q.data.streaks[streakKey{arg.UserID, arg.GuildID}]
+	if !ok {
+		return database.GetUserStreakRow{}, sql.ErrNoRows
+	}
+	return streakRow(s), nil
+}
+
+func (q *Querier) HasActivityForDate(ctx context.Context, arg database.HasActivityForDateParams) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	s, ok := q.data.streaks[streakKey{arg.UserID, arg.GuildID}]
+	if !ok {
+		return false, nil
+	}
+	return sameDate(s.LastActivityDate, arg.LastActivityDate) &&
+		s.DailyActivityMinutes.Int32 >= arg.DailyActivityMinutes.Int32, nil
+}
+
+func (q *Querier) StartDailyActivity(ctx context.Context, arg database.StartDailyActivityParams) (database.StartDailyActivityRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := streakKey{arg.UserID, arg.GuildID}
+	now := q.now()
+	s, ok := q.data.streaks[key]
+	if !ok {
+		s = database.UserStreak{
+			UserID:               arg.UserID,
+			GuildID:              arg.GuildID,
+			LastActivityDate:     arg.LastActivityDate,
+			DailyActivityMinutes: sql.NullInt32{Int32: 0, Valid: true},
+			ActivityStartTime:    arg.ActivityStartTime,
+			CreatedAt:            now,
+			StreakMode:           "daily",
+		}
+	} else if !sameDate(s.LastActivityDate, arg.LastActivityDate) {
+		s.LastActivityDate = arg.LastActivityDate
+		s.DailyActivityMinutes = sql.NullInt32{Int32: 0, Valid: true}
+		s.ActivityStartTime = arg.ActivityStartTime
+		s.StreakIncrementedToday = false
+	} else if !s.ActivityStartTime.Valid {
+		s.ActivityStartTime = arg.ActivityStartTime
+	}
+	s.UpdatedAt = now
+	q.data.streaks[key] = s
+	return database.StartDailyActivityRow{
+		UserID:                 s.UserID,
+		GuildID:                s.GuildID,
+		CurrentStreakCount:     s.CurrentStreakCount,
+		MaxStreakCount:         s.MaxStreakCount,
+		LastActivityDate:       s.LastActivityDate,
+		StreakEvaluatedDate:    s.StreakEvaluatedDate,
+		DailyActivityMinutes:   s.DailyActivityMinutes,
+		ActivityStartTime:      s.ActivityStartTime,
+		StreakIncrementedToday: s.StreakIncrementedToday,
+		WarningNotifiedAt:      s.WarningNotifiedAt,
+		CreatedAt:              s.CreatedAt,
+		UpdatedAt:              s.UpdatedAt,
+	}, nil
+}
+
+func (q *Querier) UpdateDailyActivityMinutes(ctx context.Context, arg database.UpdateDailyActivityMinutesParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.updateStreak(arg.UserID, arg.GuildID, func(s *database.UserStreak) {
+		s.DailyActivityMinutes = arg.DailyActivityMinutes
+	})
+	return nil
+}
+
+func (q *Querier) GetUsersForDailyEvaluation(ctx context.Context, arg database.GetUsersForDailyEvaluationParams) ([]database.GetUsersForDailyEvaluationRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var rows []database.GetUsersForDailyEvaluationRow
+	for _, s := range q.sortedStreaks() {
+		if s.GuildID != arg.GuildID || (s.StreakEvaluatedDate.Valid && !dateBefore(s.StreakEvaluatedDate, arg.StreakEvaluatedDate)) {
+			continue
+		}
+		rows = append(rows, database.GetUsersForDailyEvaluationRow{
+			UserID:               s.UserID,
+			GuildID:              s.GuildID,
+			CurrentStreakCount:   s.CurrentStreakCount,
+			MaxStreakCount:       s.MaxStreakCount,
+			LastActivityDate:     s.LastActivityDate,
+			StreakEvaluatedDate:  s.StreakEvaluatedDate,
+			DailyActivityMinutes: s.DailyActivityMinutes,
+			WarningNotifiedAt:    s.WarningNotifiedAt,
+			CreatedAt:            s.CreatedAt,
+			UpdatedAt:            s.UpdatedAt,
+			StreakMode:           s.StreakMode,
+			PreviousStreak:       s.PreviousStreak,
+			StreakBrokenAt:       s.StreakBrokenAt,
+		})
+	}
+	return rows, nil
+}
+
+func (q *Querier) UpdateUserStreakAfterEvaluation(ctx context.Context, arg database.UpdateUserStreakAfterEvaluationParams) (database.UpdateUserStreakAfterEvaluationRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := streakKey{arg.UserID, arg.GuildID}
+	existing, ok := q.data.streaks[key]
+	if !ok || (existing.StreakEvaluatedDate.Valid && !dateBefore(existing.StreakEvaluatedDate, arg.StreakEvaluatedDate)) {
+		return database.UpdateUserStreakAfterEvaluationRow{}, sql.ErrNoRows
+	}
+	q.updateStreak(arg.UserID, arg.GuildID, func(s *database.UserStreak) {
+		s.CurrentStreakCount = arg.CurrentStreakCount
+		s.MaxStreakCount = max(s.MaxStreakCount, arg.MaxStreakCount)
+		s.StreakEvaluatedDate = arg.StreakEvaluatedDate
+		s.StreakIncrementedToday = false
+	})
+	s := q.data.streaks[key]
+	return database.UpdateUserStreakAfterEvaluationRow{
+		UserID:               s.UserID,
+		GuildID:              s.GuildID,
+		CurrentStreakCount:   s.CurrentStreakCount,
+		MaxStreakCount:       s.MaxStreakCount,
+		LastActivityDate:     s.LastActivityDate,
+		StreakEvaluatedDate:  s.StreakEvaluatedDate,
+		DailyActivityMinutes: s.DailyActivityMinutes,
+		ActivityStartTime:    s.ActivityStartTime,
+		WarningNotifiedAt:    s.WarningNotifiedAt,
+		CreatedAt:            s.CreatedAt,
+		UpdatedAt:            s.UpdatedAt,
+	}, nil
+}
+
+func (q *Querier) GetUsersNeedingWarnings(ctx context.Context, arg database.GetUsersNeedingWarningsParams) ([]database.GetUsersNeedingWarningsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var rows []database.GetUsersNeedingWarningsRow
+	for _, s := range q.sortedStreaks() {
+		if s.CurrentStreakCount <= 0 {
+			continue
+		}
+		activeToday := s.LastActivityDate.Valid && !dateBefore(s.LastActivityDate, arg.Today)
+		if activeToday && s.DailyActivityMinutes.Int32 >= arg.MinMinutes && s.StreakMode != "weekly" {
+			continue
+		}
+		rows = append(rows, database.GetUsersNeedingWarningsRow{
+			UserID:               s.UserID,
+			GuildID:              s.GuildID,
+			CurrentStreakCount:   s.CurrentStreakCount,
+			MaxStreakCount:       s.MaxStreakCount,
+			LastActivityDate:     s.LastActivityDate,
+			DailyActivityMinutes: s.DailyActivityMinutes,
+			WarningNotifiedAt:    s.WarningNotifiedAt,
+			CreatedAt:            s.CreatedAt,
+			UpdatedAt:            s.UpdatedAt,
+			StreakMode:           s.StreakMode,
+			GuildReminderMinutes: append([]int32(nil), q.data.guildSettings[s.GuildID].ReminderMinutes...),
+			UserReminderMinutes:  append([]int32(nil), q.data.userReminders[subscriptionKey{s.UserID, s.GuildID}].ReminderMinutes...),
+		})
+	}
+	return rows, nil
+}
+
+func (q *Querier) SetUserStreakMode(ctx context.Context, arg database.SetUserStreakModeParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.updateStreak(arg.UserID, arg.GuildID, func(s *database.UserStreak) {
+		s.StreakMode = arg.StreakMode
+		s.CurrentStreakCount = arg.CurrentStreakCount
+	})
+	return nil
+}
+
+func (q *Querier) UpdateStreakBreak(ctx context.Context, arg database.UpdateStreakBreakParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.updateStreak(arg.UserID, arg.GuildID, func(s *database.UserStreak) {
+		s.PreviousStreak = arg.PreviousStreak
+		s.StreakBrokenAt = arg.StreakBrokenAt
+	})
+	return nil
+}
+
+func (q *Querier) UpdateWarningNotifiedAt(ctx context.Context, arg database.UpdateWarningNotifiedAtParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.updateStreak(arg.UserID, arg.GuildID, func(s *database.UserStreak) {
+		s.WarningNotifiedAt = arg.WarningNotifiedAt
+	})
+	return nil
+}
+
+func (q *Querier) UpdateStreakImmediately(ctx context.Context, arg database.UpdateStreakImmediatelyParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.updateStreak(arg.UserID, arg.GuildID, func(s *database.UserStreak) {
+		s.CurrentStreakCount = arg.CurrentStreakCount
+		s.MaxStreakCount = max(s.MaxStreakCount, arg.MaxStreakCount)
+		s.StreakIncrementedToday = true
+	})
+	return nil
+}
+
+func (q *Querier) ResetUserStreakCount(ctx context.Context, arg database.ResetUserStreakCountParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.updateStreak(arg.UserID, arg.GuildID, func(s *database.UserStreak) {
+		s.CurrentStreakCount = 0
+	})
+	return nil
+}
+
+func (q *Querier) ClearStreakIncrementedToday(ctx context.Context, arg database.ClearStreakIncrementedTodayParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.updateStreak(arg.UserID, arg.GuildID, func(s *database.UserStreak) {
+		s.StreakIncrementedToday = false
+	})
+	return nil
+}
+
+func (q *Querier) DeleteUserStreaks(ctx context.Context, userID string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return deleteWhere(q.data.streaks, func(k streakKey) bool { return k.userID == userID }), nil
+}
+
+// sortedStreaks returns streak rows in a stable order so results don't depend on map iteration
+func (q *Querier) sortedStreaks() []database.UserStreak {
+	out := make([]database.UserStreak, 0, len(q.data.streaks))
+	for _, s := range q.data.streaks {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].GuildID != out[j].GuildID {
+			return out[i].GuildID < out[j].GuildID
+		}
+		return out[i].UserID < out[j].UserID
+	})
+	return out
+}
+
+// --- Achievements ---
+
+func (q *Querier) GetAllAchievements(ctx context.Context) ([]database.GetAllAchievementsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var rows []database.GetAllAchievementsRow
+	for _, a := range q.sortedAchievements(func(database.Achievement) bool { return true }, bySortOrder) {
+		rows = append(rows, achievementRow(a))
+	}
+	return rows, nil
+}
+
+func (q *Querier) GetAchievementByID(ctx context.Context, achievementID string) (database.GetAchievementByIDRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	a, ok := q.data.achievements[achievementID]
+	if !ok {
+		return database.GetAchievementByIDRow{}, sql.ErrNoRows
+	}
+	return database.GetAchievementByIDRow(achievementRow(a)), nil
+}
+
+func (q *Querier) GetAchievementsByCategory(ctx context.Context, category string) ([]database.GetAchievementsByCategoryRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var rows []database.GetAchievementsByCategoryRow
+	keep := func(a database.Achievement) bool { return a.Category == category }
+	for _, a := range q.sortedAchievements(keep, bySortOrder) {
+		rows = append(rows, database.GetAchievementsByCategoryRow(achievementRow(a)))
+	}
+	return rows, nil
+}
+
+func (q *Querier) GetAchievementsByRequirementType(ctx context.Context, requirementType string) ([]database.GetAchievementsByRequirementTypeRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var rows []database.GetAchievementsByRequirementTypeRow
+	keep := func(a database.Achievement) bool { return a.RequirementType == requirementType }
+	less := func(a, b database.Achievement) bool { return a.RequirementValue < b.RequirementValue }
+	for _, a := range q.sortedAchievements(keep, less) {
+		rows = append(rows, database.GetAchievementsByRequirementTypeRow(achievementRow(a)))
+	}
+	return rows, nil
+}
+
+func (q *Querier) GetTotalAchievementCount(ctx context.Context) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int64(len(q.data.achievements)), nil
+}
+
+func (q *Querier) userAchievementsFor(userID, guildID string) []database.UserAchievement {
+	var out []database.UserAchievement
+	for k, ua := range q.data.userAchievements {
+		if k.userID == userID && k.guildID == guildID {
+			out = append(out, ua)
+		}
+	}
+	return out
+}
+
+func (q *Querier) GetUserAchievements(ctx context.Context, arg database.GetUserAchievementsParams) ([]database.GetUserAchievementsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var rows []database.GetUserAchievementsRow
+	for _, ua := range q.userAchievementsFor(arg.UserID, arg.GuildID) {
+		a := q.data.achievements[ua.AchievementID]
+		rows = append(rows, database.GetUserAchievementsRow{
+			UserID:        ua.UserID,
+			GuildID:       ua.GuildID,
+			AchievementID: ua.AchievementID,
+			EarnedAt:      ua.EarnedAt,
+			Notified:      ua.Notified,
+			Name:          a.Name,
+			Description:   a.Description,
+			Icon:          a.Icon,
+			Category:      a.Category,
+			SortOrder:     a.SortOrder,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].SortOrder.Int32 < rows[j].SortOrder.Int32 })
+	return rows, nil
+}
+
+func (q *Querier) GetUserAchievementCount(ctx context.Context, arg database.GetUserAchievementCountParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int64(len(q.userAchievementsFor(arg.UserID, arg.GuildID))), nil
+}
+
+func (q *Querier) HasAchievement(ctx context.Context, arg database.HasAchievementParams) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, ok := q.data.userAchievements[achievementKey{arg.UserID, arg.GuildID, arg.AchievementID}]
+	return ok, nil
+}
+
+func (q *Querier) AwardAchievement(ctx context.Context, arg database.AwardAchievementParams) (database.UserAchievement, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.data.achievements[arg.AchievementID]; !ok {
+		return database.UserAchievement{}, fmt.Errorf("fakedb: achievement %q violates foreign key constraint", arg.AchievementID)
+	}
+	key := achievementKey{arg.UserID, arg.GuildID, arg.AchievementID}
+	if _, ok := q.data.userAchievements[key]; ok {
+		return database.UserAchievement{}, sql.ErrNoRows // ON CONFLICT DO NOTHING returns no row
+	}
+	ua := database.UserAchievement{
+		UserID:        arg.UserID,
+		GuildID:       arg.GuildID,
+		AchievementID: arg.AchievementID,
+		EarnedAt:      sql.NullTime{Time: q.now(), Valid: true},
+		Notified:      sql.NullBool{Bool: false, Valid: true},
+	}
+	q.data.userAchievements[key] = ua
+	return ua, nil
+}
+
+func (q *Querier) MarkAchievementNotified(ctx context.Context, arg database.MarkAchievementNotifiedParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := achievementKey{arg.UserID, arg.GuildID, arg.AchievementID}
+	if ua, ok := q.data.userAchievements[key]; ok {
+		ua.Notified = sql.NullBool{Bool: true, Valid: true}
+		q.data.userAchievements[key] = ua
+	}
+	return nil
+}
+
+func (q *Querier) GetUnnotifiedAchievements(ctx context.Context, arg database.GetUnnotifiedAchievementsParams) ([]database.GetUnnotifiedAchievementsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var rows []database.GetUnnotifiedAchievementsRow
+	for _, ua := range q.userAchievementsFor(arg.UserID, arg.GuildID) {
+		if ua.Notified.Bool {
+			continue
+		}
+		a := q.data.achievements[ua.AchievementID]
+		rows = append(rows, database.GetUnnotifiedAchievementsRow{
+			UserID:        ua.UserID,
+			GuildID:       ua.GuildID,
+			AchievementID: ua.AchievementID,
+			EarnedAt:      ua.EarnedAt,
+			Name:          a.Name,
+			Description:   a.Description,
+			Icon:          a.Icon,
+			Category:      a.Category,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].EarnedAt.Time.Before(rows[j].EarnedAt.Time) })
+	return rows, nil
+}
+
+func (q *Querier) GetUsersWithUnnotifiedAchievements(ctx context.Context) ([]database.GetUsersWithUnnotifiedAchievementsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	seen := make(map[streakKey]struct{})
+	var rows []database.GetUsersWithUnnotifiedAchievementsRow
+	for k, ua := range q.data.userAchievements {
+		key := streakKey{k.userID, k.guildID}
+		if _, ok := seen[key]; ok || ua.Notified.Bool {
+			continue
+		}
+		seen[key] = struct{}{}
+		rows = append(rows, database.GetUsersWithUnnotifiedAchievementsRow{UserID: k.userID, GuildID: k.guildID})
+	}
+	return rows, nil
+}
+
+func (q *Querier) DeleteUserAchievements(ctx context.Context, userID string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return deleteWhere(q.data.userAchievements, func(k achievementKey) bool { return k.userID == userID }), nil
+}
+
+// --- Audit log ---
+
+func (q *Querier) CreateAuditLogEntry(ctx context.Context, arg database.CreateAuditLogEntryParams) (database.AuditLog, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.data.nextAuditID++
+	entry := database.AuditLog{
+		ID:           q.data.nextAuditID,
+		GuildID:      arg.GuildID,
+		ActorID:      arg.ActorID,
+		TargetUserID: arg.TargetUserID,
+		Action:       arg.Action,
+		Reason:       arg.Reason,
+		Details:      arg.Details,
+		CreatedAt:    q.now(),
+	}
+	q.data.auditLog = append(q.data.auditLog, entry)
+	return entry, nil
+}
+
+func (q *Querier) GetGuildAuditLog(ctx context.Context, arg database.GetGuildAuditLogParams) ([]database.AuditLog, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var rows []database.AuditLog
+	for i := len(q.data.auditLog) - 1; i >= 0 && len(rows) < int(arg.PageSize); i-- {
+		e := q.data.auditLog[i]
+		if e.GuildID != arg.GuildID {
+			continue
+		}
+		if arg.UserID.Valid && e.ActorID != arg.UserID && e.TargetUserID != arg.UserID {
+			continue
+		}
+		if arg.Action.Valid && e.Action != arg.Action.String {
+			continue
+		}
+		if arg.FromTime.Valid && e.CreatedAt.Before(arg.FromTime.Time) {
+			continue
+		}
+		if arg.ToTime.Valid && !e.CreatedAt.Before(arg.ToTime.Time) {
+			continue
+		}
+		rows = append(rows, e)
+	}
+	return rows, nil
+}
+
+// --- Notification outbox ---
+
+func (q *Querier) EnqueueNotification(ctx context.Context, arg database.EnqueueNotificationParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, n := range q.data.outbox {
+		if n.DedupeKey == arg.DedupeKey {
+			return 0, nil
+		}
+	}
+	now := q.now()
+	nextAttempt := now
+	if arg.NextAttemptAt.Valid {
+		nextAttempt = arg.NextAttemptAt.Time
+	}
+	q.data.nextOutboxID++
+	q.data.outbox = append(q.data.outbox, database.NotificationsOutbox{
+		ID:            q.data.nextOutboxID,
+		DedupeKey:     arg.DedupeKey,
+		Kind:          arg.Kind,
+		GuildID:       arg.GuildID,
+		UserID:        arg.UserID,
+		ChannelID:     arg.ChannelID,
+		Payload:       arg.Payload,
+		NextAttemptAt: nextAttempt,
+		CreatedAt:     now,
+	})
+	return 1, nil
+}
+
+func (q *Querier) GetDueNotifications(ctx context.Context, arg database.GetDueNotificationsParams) ([]database.NotificationsOutbox, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var rows []database.NotificationsOutbox
+	for _, n := range q.data.outbox {
+		if !n.SentAt.Valid && !n.NextAttemptAt.After(arg.NextAttemptAt) && n.Attempts < arg.Attempts {
+			rows = append(rows, n)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if !rows[i].NextAttemptAt.Equal(rows[j].NextAttemptAt) {
+			return rows[i].NextAttemptAt.Before(rows[j].NextAttemptAt)
+		}
+		return rows[i].ID < rows[j].ID
+	})
+	if int(arg.Limit) < len(rows) {
+		rows = rows[:arg.Limit]
+	}
+	return rows, nil
+}
+
+func (q *Querier) updateNotification(id int64, update func(*database.NotificationsOutbox)) error {
+	for i := range q.data.outbox {
+		if q.data.outbox[i].ID == id {
+			update(&q.data.outbox[i])
+			return nil
+		}
+	}
+	return nil
+}
+
+func (q *Querier) MarkNotificationSent(ctx context.Context, arg database.MarkNotificationSentParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.updateNotification(arg.ID, func(n *database.NotificationsOutbox) {
+		n.SentAt = arg.SentAt
+		n.LastError = sql.NullString{}
+	})
+}
+
+func (q *Querier) MarkNotificationFailed(ctx context.Context, arg database.MarkNotificationFailedParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.updateNotification(arg.ID, func(n *database.NotificationsOutbox) {
+		n.Attempts++
+		n.NextAttemptAt = arg.NextAttemptAt
+		n.LastError = arg.LastError
+	})
+}
+
+func (q *Querier) RescheduleNotification(ctx context.Context, arg database.RescheduleNotificationParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.updateNotification(arg.ID, func(n *database.NotificationsOutbox) {
+		n.NextAttemptAt = arg.NextAttemptAt
+	})
+}
+
+func (q *Querier) deleteNotifications(match func(database.NotificationsOutbox) bool) int64 {
+	kept := q.data.outbox[:0]
+	var n int64
+	for _, row := range q.data.outbox {
+		if match(row) {
+			n++
+			continue
+		}
+		kept = append(kept, row)
+	}
+	q.data.outbox = kept
+	return n
+}
+
+func (q *Querier) DeleteNotification(ctx context.Context, dedupeKey string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.deleteNotifications(func(n database.NotificationsOutbox) bool { return n.DedupeKey == dedupeKey })
+	return nil
+}
+
+func (q *Querier) DeleteSentNotifications(ctx context.Context, sentAt sql.NullTime) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.deleteNotifications(func(n database.NotificationsOutbox) bool {
+		return n.SentAt.Valid && sentAt.Valid && n.SentAt.Time.Before(sentAt.Time)
+	}), nil
+}
+
+func (q *Querier) DeleteUserNotifications(ctx context.Context, userID sql.NullString) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.deleteNotifications(func(n database.NotificationsOutbox) bool {
+		return userID.Valid && n.UserID.Valid && n.UserID.String == userID.String
+	}), nil
+}
+
+// --- Live status messages ---
+
+func (q *Querier) UpsertLiveStatusMessage(ctx context.Context, arg database.UpsertLiveStatusMessageParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.data.liveStatus[arg.GuildID] = database.LiveStatusMessage{
+		GuildID:   arg.GuildID,
+		ChannelID: arg.ChannelID,
+		MessageID: arg.MessageID,
+		CreatedAt: q.now(),
+	}
+	return nil
+}
+
+func (q *Querier) GetLiveStatusMessage(ctx context.Context, guildID string) (database.LiveStatusMessage, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	m, ok := q.data.liveStatus[guildID]
+	if !ok {
+		return database.LiveStatusMessage{}, sql.ErrNoRows
+	}
+	return m, nil
+}
+
+func (q *Querier) GetLiveStatusMessages(ctx context.Context) ([]database.LiveStatusMessage, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []database.LiveStatusMessage
+	for _, m := range q.data.liveStatus {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].GuildID < out[j].GuildID })
+	return out, nil
+}
+
+func (q *Querier) DeleteLiveStatusMessage(ctx context.Context, guildID string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.data.liveStatus[guildID]; !ok {
+		return 0, nil
+	}
+	delete(q.data.liveStatus, guildID)
+	return 1, nil
+}
+
+// --- Daily activity ---
+
+func (q *Querier) AddDailyActivity(ctx context.Context, arg database.AddDailyActivityParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	date := dateOf(arg.ActivityDate)
+	key := dailyActivityKey{userID: arg.UserID, guildID: arg.GuildID, date: date}
+	row, ok := q.data.dailyActivity[key]
+	if !ok {
+		row = database.UserDailyActivity{UserID: arg.UserID, GuildID: arg.GuildID, ActivityDate: date}
+	}
+	row.StudyMs += arg.StudyMs
+	q.data.dailyActivity[key] = row
+	return nil
+}
+
+func (q *Querier) GetDailyActivity(ctx context.Context, arg database.GetDailyActivityParams) ([]database.UserDailyActivity, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	from, to := dateOf(arg.FromDate), dateOf(arg.ToDate)
+	var out []database.UserDailyActivity
+	for k, row := range q.data.dailyActivity {
+		if k.userID == arg.UserID && k.guildID == arg.GuildID && !k.date.Before(from) && !k.date.After(to) {
+			out = append(out, row)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ActivityDate.Before(out[j].ActivityDate) })
+	return out, nil
+}
+
+func (q *Querier) DeleteUserDailyActivity(ctx context.Context, userID string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var n int64
+	for k := range q.data.dailyActivity {
+		if k.userID == userID {
+			delete(q.data.dailyActivity, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// --- Recaps ---
+
+func (q *Querier) GetGuildStudyTotals(ctx context.Context, arg database.GetGuildStudyTotalsParams) ([]database.GetGuildStudyTotalsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	from, to := dateOf(arg.FromDate), dateOf(arg.ToDate)
+	totals := make(map[subscriptionKey]int64)
+	for k, row := range q.data.dailyActivity {
+		if !k.date.Before(from) && k.date.Before(to) {
+			totals[subscriptionKey{userID: k.userID, guildID: k.guildID}] += row.StudyMs
+		}
+	}
+	var out []database.GetGuildStudyTotalsRow
+	for k, ms := range totals {
+		out = append(out, database.GetGuildStudyTotalsRow{GuildID: k.guildID, UserID: k.userID, StudyMs: ms})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].GuildID != out[j].GuildID {
+			return out[i].GuildID < out[j].GuildID
+		}
+		if out[i].StudyMs != out[j].StudyMs {
+			return out[i].StudyMs > out[j].StudyMs
+		}
+		return out[i].UserID < out[j].UserID
+	})
+	return out, nil
+}
+
+func (q *Querier) GetGuildTopStreaks(ctx context.Context, arg database.GetGuildTopStreaksParams) ([]database.GetGuildTopStreaksRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []database.GetGuildTopStreaksRow
+	for k, st := range q.data.streaks {
+		if k.guildID == arg.GuildID && st.CurrentStreakCount > 0 {
+			out = append(out, database.GetGuildTopStreaksRow{UserID: k.userID, CurrentStreakCount: st.CurrentStreakCount})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CurrentStreakCount != out[j].CurrentStreakCount {
+			return out[i].CurrentStreakCount > out[j].CurrentStreakCount
+		}
+		return out[i].UserID < out[j].UserID
+	})
+	if len(out) > int(arg.Limit) {
+		out = out[:arg.Limit]
+	}
+	return out, nil
+}
+
+func (q *Querier) GetGuildAchievementsEarnedBetween(ctx context.Context, arg database.GetGuildAchievementsEarnedBetweenParams) ([]database.GetGuildAchievementsEarnedBetweenRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var earned []database.UserAchievement
+	for k, ua := range q.data.userAchievements {
+		if k.guildID != arg.GuildID || !ua.EarnedAt.Valid || !arg.FromTime.Valid || !arg.ToTime.Valid {
+			continue
+		}
+		if !ua.EarnedAt.Time.Before(arg.FromTime.Time) && ua.EarnedAt.Time.Before(arg.ToTime.Time) {
+			earned = append(earned, ua)
+		}
+	}
+	sort.Slice(earned, func(i, j int) bool {
+		if !earned[i].EarnedAt.Time.Equal(earned[j].EarnedAt.Time) {
+			return earned[i].EarnedAt.Time.Before(earned[j].EarnedAt.Time)
+		}
+		return earned[i].UserID < earned[j].UserID
+	})
+	out := make([]database.GetGuildAchievementsEarnedBetweenRow, 0, len(earned))
+	for _, ua := range earned {
+		a := q.data.achievements[ua.AchievementID]
+		out = append(out, database.GetGuildAchievementsEarnedBetweenRow{
+			UserID:        ua.UserID,
+			AchievementID: ua.AchievementID,
+			Name:          a.Name,
+			Icon:          a.Icon,
+		})
+	}
+	return out, nil
+}
+
+func (q *Querier) CreateRecap(ctx context.Context, arg database.CreateRecapParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	start := dateOf(arg.PeriodStart)
+	for _, r := range q.data.recaps {
+		if r.GuildID == arg.GuildID && r.Period == arg.Period && r.PeriodStart.Equal(start) {
+			return 0, nil
+		}
+	}
+	q.data.nextRecapID++
+	q.data.recaps = append(q.data.recaps, database.Recap{
+		ID:          q.data.nextRecapID,
+		GuildID:     arg.GuildID,
+		Period:      arg.Period,
+		PeriodStart: start,
+		PeriodEnd:   dateOf(arg.PeriodEnd),
+		Payload:     append([]byte(nil), arg.Payload...),
+		CreatedAt:   q.now(),
+	})
+	return 1, nil
+}
+
+func (q *Querier) GetRecap(ctx context.Context, arg database.GetRecapParams) (database.Recap, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var matches []database.Recap
+	for _, r := range q.data.recaps {
+		if r.GuildID == arg.GuildID && r.Period == arg.Period {
+			matches = append(matches, r)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].PeriodStart.After(matches[j].PeriodStart) })
+	if arg.Offset < 0 || int(arg.Offset) >= len(matches) {
+		return database.Recap{}, sql.ErrNoRows
+	}
+	return matches[arg.Offset], nil
+}
+
+func (q *Querier) GetRecapsMentioningUser(ctx context.Context, arg database.GetRecapsMentioningUserParams) ([]database.Recap, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []database.Recap
+	for _, r := range q.data.recaps {
+		if arg.GuildID.Valid && r.GuildID != arg.GuildID.String {
+			continue
+		}
+		var payload any
+		if err := json.Unmarshal(r.Payload, &payload); err != nil {
+			return nil, err
+		}
+		if mentionsUser(payload, arg.UserID) {
+			r.Payload = append([]byte(nil), r.Payload...)
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// mentionsUser matches the jsonpath '$.** ? (@.userId == $uid)': any object in v with that userId
+func mentionsUser(v any, userID string) bool {
+	switch v := v.(type) {
+	case map[string]any:
+		if id, ok := v["userId"].(string); ok && id == userID {
+			return true
+		}
+		for _, child := range v {
+			if mentionsUser(child, userID) {
+				return true
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if mentionsUser(child, userID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (q *Querier) UpdateRecapPayload(ctx context.Context, arg database.UpdateRecapPayloadParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.data.recaps {
+		if q.data.recaps[i].ID == arg.ID {
+			q.data.recaps[i].Payload = append([]byte(nil), arg.Payload...)
+		}
+	}
+	return nil
+}
+
+func (q *Querier) AddRecapSubscription(ctx context.Context, arg database.AddRecapSubscriptionParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := subscriptionKey{userID: arg.UserID, guildID: arg.GuildID}
+	if _, ok := q.data.recapSubscribers[key]; !ok {
+		q.data.recapSubscribers[key] = database.RecapSubscription{UserID: arg.UserID, GuildID: arg.GuildID, CreatedAt: q.now()}
+	}
+	return nil
+}
+
+func (q *Querier) DeleteRecapSubscription(ctx context.Context, arg database.DeleteRecapSubscriptionParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := subscriptionKey{userID: arg.UserID, guildID: arg.GuildID}
+	if _, ok := q.data.recapSubscribers[key]; !ok {
+		return 0, nil
+	}
+	delete(q.data.recapSubscribers, key)
+	return 1, nil
+}
+
+func (q *Querier) GetRecapSubscribers(ctx context.Context, guildID string) ([]string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []string
+	for k := range q.data.recapSubscribers {
+		if k.guildID == guildID {
+			out = append(out, k.userID)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func (q *Querier) DeleteUserRecapSubscriptions(ctx context.Context, userID string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var n int64
+	for k := range q.data.recapSubscribers {
+		if k.userID == userID {
+			delete(q.data.recapSubscribers, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// --- Notification preferences ---
+
+func (q *Querier) GetNotificationPreferences(ctx context.Context, userID string) (database.NotificationPreference, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	p, ok := q.data.preferences[userID]
+	if !ok {
+		return database.NotificationPreference{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (q *Querier) UpsertNotificationPreferences(ctx context.Context, arg database.UpsertNotificationPreferencesParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.data.preferences[arg.UserID] = database.NotificationPreference{
+		UserID:           arg.UserID,
+		StreakWarnings:   arg.StreakWarnings,
+		DailyComplete:    arg.DailyComplete,
+		Achievements:     arg.Achievements,
+		SessionSummaries: arg.SessionSummaries,
+		QuietStartHour:   arg.QuietStartHour,
+		QuietEndHour:     arg.QuietEndHour,
+		UpdatedAt:        q.now(),
+	}
+	return nil
+}
+
+func (q *Querier) DeleteUserNotificationPreferences(ctx context.Context, userID string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.data.preferences[userID]; !ok {
+		return 0, nil
+	}
+	delete(q.data.preferences, userID)
+	return 1, nil
+}
+
+// --- Guild and reminder settings ---
+
+func (q *Querier) GetGuildSettings(ctx context.Context, guildID string) (database.GuildSetting, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	g, ok := q.data.guildSettings[guildID]
+	if !ok {
+		return database.GuildSetting{}, sql.ErrNoRows
+	}
+	g.ReminderMinutes = append([]int32(nil), g.ReminderMinutes...)
+	return g, nil
+}
+
+// guildSettingsRow returns the guild's settings, or a row with the column defaults
+func (q *Querier) guildSettingsRow(guildID string) database.GuildSetting {
+	if g, ok := q.data.guildSettings[guildID]; ok {
+		return g
+	}
+	return database.GuildSetting{
+		GuildID:           guildID,
+		ReminderMinutes:   []int32{1200},
+		StreakMode:        "daily",
+		WeeklyGoalMinutes: 300,
+		StreakRepair:      true,
+	}
+}
+
+func (q *Querier) UpsertGuildReminderMinutes(ctx context.Context, arg database.UpsertGuildReminderMinutesParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	g := q.guildSettingsRow(arg.GuildID)
+	g.ReminderMinutes = append([]int32(nil), arg.ReminderMinutes...)
+	g.UpdatedAt = q.now()
+	q.data.guildSettings[arg.GuildID] = g
+	return nil
+}
+
+func (q *Querier) UpsertGuildStreakMode(ctx context.Context, arg database.UpsertGuildStreakModeParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	g := q.guildSettingsRow(arg.GuildID)
+	g.StreakMode = arg.StreakMode
+	g.WeeklyGoalMinutes = arg.WeeklyGoalMinutes
+	g.StreakRepair = arg.StreakRepair
+	g.UpdatedAt = q.now()
+	q.data.guildSettings[arg.GuildID] = g
+	return nil
+}
+
+func (q *Querier) UpsertGuildRecapChannel(ctx context.Context, arg database.UpsertGuildRecapChannelParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	g := q.guildSettingsRow(arg.GuildID)
+	g.RecapChannelID = arg.RecapChannelID
+	g.UpdatedAt = q.now()
+	q.data.guildSettings[arg.GuildID] = g
+	return nil
+}
+
+func (q *Querier) GetUserReminderSettings(ctx context.Context, arg database.GetUserReminderSettingsParams) (database.UserReminderSetting, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	r, ok := q.data.userReminders[subscriptionKey{arg.UserID, arg.GuildID}]
+	if !ok {
+		return database.UserReminderSetting{}, sql.ErrNoRows
+	}
+	r.ReminderMinutes = append([]int32(nil), r.ReminderMinutes...)
+	return r, nil
+}
+
+func (q *Querier) UpsertUserReminderMinutes(ctx context.Context, arg database.UpsertUserReminderMinutesParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.data.userReminders[subscriptionKey{arg.UserID, arg.GuildID}] = database.UserReminderSetting{
+		UserID:          arg.UserID,
+		GuildID:         arg.GuildID,
+		ReminderMinutes: append([]int32(nil), arg.ReminderMinutes...),
+		UpdatedAt:       q.now(),
+	}
+	return nil
+}
+
+func (q *Querier) ClearUserReminderMinutes(ctx context.Context, arg database.ClearUserReminderMinutesParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := subscriptionKey{arg.UserID, arg.GuildID}
+	if _, ok := q.data.userReminders[key]; !ok {
+		return 0, nil
+	}
+	delete(q.data.userReminders, key)
+	return 1, nil
+}
+
+func (q *Querier) DeleteUserReminderSettings(ctx context.Context, userID string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var n int64
+	for key := range q.data.userReminders {
+		if key.userID == userID {
+			delete(q.data.userReminders, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// --- Streak events ---
+
+func (q *Querier) CreateStreakEvent(ctx context.Context, arg database.CreateStreakEventParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	date := dateOf(arg.EventDate)
+	for _, e := range q.data.streakEvents {
+		if e.UserID == arg.UserID && e.GuildID == arg.GuildID && e.EventDate.Equal(date) {
+			return nil
+		}
+	}
+	q.data.nextStreakEventID++
+	q.data.streakEvents = append(q.data.streakEvents, database.StreakEvent{
+		ID:          q.data.nextStreakEventID,
+		UserID:      arg.UserID,
+		GuildID:     arg.GuildID,
+		EventDate:   date,
+		EventType:   arg.EventType,
+		StreakCount: arg.StreakCount,
+		CreatedAt:   q.now(),
+	})
+	return nil
+}
+
+func (q *Querier) GetStreakEvents(ctx context.Context, arg database.GetStreakEventsParams) ([]database.StreakEvent, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	from, to := dateOf(arg.FromDate), dateOf(arg.ToDate)
+	var out []database.StreakEvent
+	for _, e := range q.data.streakEvents {
+		if e.UserID == arg.UserID && e.GuildID == arg.GuildID && !e.EventDate.Before(from) && !e.EventDate.After(to) {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].EventDate.Before(out[j].EventDate) })
+	return out, nil
+}
+
+func (q *Querier) GetPastStreaks(ctx context.Context, arg database.GetPastStreaksParams) ([]database.StreakEvent, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []database.StreakEvent
+	for _, e := range q.data.streakEvents {
+		if e.UserID == arg.UserID && e.GuildID == arg.GuildID && e.EventType == "break" && !q.repaired(e) {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].EventDate.After(out[j].EventDate) })
+	if len(out) > int(arg.Limit) {
+		out = out[:arg.Limit]
+	}
+	return out, nil
+}
+
+// repaired reports whether a repair came after the break with no other break in between.
+func (q *Querier) repaired(brk database.StreakEvent) bool {
+	var repair *time.Time
+	for _, e := range q.data.streakEvents {
+		if e.UserID != brk.UserID || e.GuildID != brk.GuildID || e.EventType != "repair" || !e.EventDate.After(brk.EventDate) {
+			continue
+		}
+		if repair == nil || e.EventDate.Before(*repair) {
+			d := e.EventDate
+			repair = &d
+		}
+	}
+	if repair == nil {
+		return false
+	}
+	for _, e := range q.data.streakEvents {
+		if e.UserID == brk.UserID && e.GuildID == brk.GuildID && e.EventType == "break" &&
+			e.EventDate.After(brk.EventDate) && e.EventDate.Before(*repair) {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *Querier) DeleteUserStreakEvents(ctx context.Context, userID string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	kept := q.data.streakEvents[:0]
+	var n int64
+	for _, e := range q.data.streakEvents {
+		if e.UserID == userID {
+			n++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	q.data.streakEvents = kept
+	return n, nil
+}
+
+// --- Streak evaluations ---
+
+func (q *Querier) GetGuildEvaluationDates(ctx context.Context) ([]database.GetGuildEvaluationDatesRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	seen := make(map[string]bool)
+	var rows []database.GetGuildEvaluationDatesRow
+	for _, s := range q.sortedStreaks() {
+		if seen[s.GuildID] {
+			continue
+		}
+		seen[s.GuildID] = true
+		row := database.GetGuildEvaluationDatesRow{GuildID: s.GuildID}
+		if e, ok := q.data.evaluations[s.GuildID]; ok {
+			row.LastEvaluatedDate = sql.NullTime{Time: e.LastEvaluatedDate, Valid: true}
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].GuildID < rows[j].GuildID })
+	return rows, nil
+}
+
+func (q *Querier) SetGuildLastEvaluatedDate(ctx context.Context, arg database.SetGuildLastEvaluatedDateParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	date := dateOf(arg.LastEvaluatedDate)
+	if e, ok := q.data.evaluations[arg.GuildID]; ok && e.LastEvaluatedDate.After(date) {
+		date = e.LastEvaluatedDate
+	}
+	q.data.evaluations[arg.GuildID] = database.StreakEvaluation{
+		GuildID:           arg.GuildID,
+		LastEvaluatedDate: date,
+		UpdatedAt:         q.now(),
+	}
+	return nil
+}
+
+// --- Role capabilities ---
+
+func (q *Querier) GetGuildRoleCapabilities(ctx context.Context, guildID string) ([]database.GuildRoleCapability, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var rows []database.GuildRoleCapability
+	for _, c := range q.data.roleCapabilities {
+		if c.GuildID == guildID {
+			rows = append(rows, c)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Capability != rows[j].Capability {
+			return rows[i].Capability < rows[j].Capability
+		}
+		return rows[i].RoleID < rows[j].RoleID
+	})
+	return rows, nil
+}
+
+func (q *Querier) GrantRoleCapability(ctx context.Context, arg database.GrantRoleCapabilityParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := roleCapabilityKey{arg.GuildID, arg.RoleID, arg.Capability}
+	if _, ok := q.data.roleCapabilities[key]; ok {
+		return nil
+	}
+	q.data.roleCapabilities[key] = database.GuildRoleCapability{
+		GuildID:    arg.GuildID,
+		RoleID:     arg.RoleID,
+		Capability: arg.Capability,
+		CreatedAt:  q.now(),
+	}
+	return nil
+}
+
+func (q *Querier) RevokeRoleCapability(ctx context.Context, arg database.RevokeRoleCapabilityParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := roleCapabilityKey{arg.GuildID, arg.RoleID, arg.Capability}
+	if _, ok := q.data.roleCapabilities[key]; !ok {
+		return 0, nil
+	}
+	delete(q.data.roleCapabilities, key)
+	return 1, nil
+}
+
+// --- API tokens ---
+
+func (q *Querier) CreateAPIToken(ctx context.Context, arg database.CreateAPITokenParams) (database.ApiToken, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, t := range q.data.apiTokens {
+		if t.TokenHash == arg.TokenHash {
+			return database.ApiToken{}, fmt.Errorf("fakedb: duplicate API token hash")
+		}
+	}
+	q.data.nextAPITokenID++
+	token := database.ApiToken{
+		ID:        q.data.nextAPITokenID,
+		GuildID:   arg.GuildID,
+		Name:      arg.Name,
+		TokenHash: arg.TokenHash,
+		CreatedBy: arg.CreatedBy,
+		CreatedAt: q.now(),
+	}
+	q.data.apiTokens = append(q.data.apiTokens, token)
+	return token, nil
+}
+
+func (q *Querier) GetAPITokenByHash(ctx context.Context, tokenHash string) (database.ApiToken, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, t := range q.data.apiTokens {
+		if t.TokenHash == tokenHash && !t.RevokedAt.Valid {
+			return t, nil
+		}
+	}
+	return database.ApiToken{}, sql.ErrNoRows
+}
+
+func (q *Querier) GetGuildAPITokens(ctx context.Context, guildID string) ([]database.ApiToken, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var rows []database.ApiToken
+	for _, t := range q.data.apiTokens {
+		if t.GuildID == guildID && !t.RevokedAt.Valid {
+			rows = append(rows, t)
+		}
+	}
+	return rows, nil
+}
+
+func (q *Querier) RevokeAPIToken(ctx context.Context, arg database.RevokeAPITokenParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, t := range q.data.apiTokens {
+		if t.GuildID == arg.GuildID && t.ID == arg.ID && !t.RevokedAt.Valid {
+			q.data.apiTokens[i].RevokedAt = sql.NullTime{Time: q.now(), Valid: true}
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (q *Querier) TouchAPIToken(ctx context.Context, id int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	for i, t := range q.data.apiTokens {
+		if t.ID == id && (!t.LastUsedAt.Valid || t.LastUsedAt.Time.Before(now.Add(-time.Minute))) {
+			q.data.apiTokens[i].LastUsedAt = sql.NullTime{Time: now, Valid: true}
+		}
+	}
+	return nil
+}
+
+// --- REST API ---
+
+// page returns the rows of a LIMIT/OFFSET query
+func page[T any](rows []T, size, offset int32) []T {
+	if int(offset) >= len(rows) {
+		return nil
+	}
+	rows = rows[offset:]
+	if len(rows) > int(size) {
+		rows = rows[:size]
+	}
+	return rows
+}
+
+func (q *Querier) GetGuildLeaderboard(ctx context.Context, arg database.GetGuildLeaderboardParams) ([]database.GetGuildLeaderboardRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	from, to := dateOf(arg.FromDate), dateOf(arg.ToDate)
+	totals := make(map[string]int64)
+	for k, row := range q.data.dailyActivity {
+		if k.guildID == arg.GuildID && !k.date.Before(from) && k.date.Before(to) {
+			totals[k.userID] += row.StudyMs
+		}
+	}
+	var out []database.GetGuildLeaderboardRow
+	for userID, ms := range totals {
+		if ms > 0 {
+			out = append(out, database.GetGuildLeaderboardRow{UserID: userID, Username: q.data.users[userID].Username, StudyMs: ms})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].StudyMs != out[j].StudyMs {
+			return out[i].StudyMs > out[j].StudyMs
+		}
+		return out[i].UserID < out[j].UserID
+	})
+	return page(out, arg.PageSize, arg.PageOffset), nil
+}
+
+func (q *Querier) GetUserGuildStudyTotals(ctx context.Context, arg database.GetUserGuildStudyTotalsParams) (database.GetUserGuildStudyTotalsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	month, week, today := dateOf(arg.MonthStart), dateOf(arg.WeekStart), dateOf(arg.Today)
+	var row database.GetUserGuildStudyTotalsRow
+	for k, a := range q.data.dailyActivity {
+		if k.userID != arg.UserID || k.guildID != arg.GuildID {
+			continue
+		}
+		row.TotalMs += a.StudyMs
+		if !k.date.Before(month) {
+			row.MonthMs += a.StudyMs
+		}
+		if !k.date.Before(week) {
+			row.WeekMs += a.StudyMs
+		}
+		if !k.date.Before(today) {
+			row.TodayMs += a.StudyMs
+		}
+		if a.StudyMs > 0 {
+			row.ActiveDays++
+		}
+	}
+	return row, nil
+}
+
+func (q *Querier) GetUserGuildStudySessions(ctx context.Context, arg database.GetUserGuildStudySessionsParams) ([]database.StudySession, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []database.StudySession
+	for _, session := range q.data.sessions {
+		if session.UserID == arg.UserID && session.GuildID == arg.GuildID {
+			out = append(out, session)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].StartTime.Equal(out[j].StartTime) {
+			return out[i].StartTime.After(out[j].StartTime)
+		}
+		return out[i].SessionID > out[j].SessionID
+	})
+	return page(out, arg.PageSize, arg.PageOffset), nil
+}
+
+// --- Dashboard ---
+
+func (q *Querier) GetGuildDailyTotals(ctx context.Context, arg database.GetGuildDailyTotalsParams) ([]database.GetGuildDailyTotalsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	from, to := dateOf(arg.FromDate), dateOf(arg.ToDate)
+	totals := make(map[time.Time]*database.GetGuildDailyTotalsRow)
+	for k, a := range q.data.dailyActivity {
+		if k.guildID != arg.GuildID || k.date.Before(from) || !k.date.Before(to) {
+			continue
+		}
+		row, ok := totals[k.date]
+		if !ok {
+			row = &database.GetGuildDailyTotalsRow{ActivityDate: k.date}
+			totals[k.date] = row
+		}
+		row.StudyMs += a.StudyMs
+		if a.StudyMs > 0 {
+			row.Members++
+		}
+	}
+	out := make([]database.GetGuildDailyTotalsRow, 0, len(totals))
+	for _, row := range totals {
+		out = append(out, *row)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ActivityDate.Before(out[j].ActivityDate) })
+	return out, nil
+}
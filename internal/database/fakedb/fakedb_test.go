@@ -0,0 +1,85 @@
+package fakedb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecTx_RollsBackOnError(t *testing.T) {
+	q := New()
+	ctx := context.Background()
+
+	err := q.ExecTx(ctx, func(tx database.Querier) error {
+		if _, err := tx.CreateUser(ctx, database.CreateUserParams{UserID: "u1"}); err != nil {
+			return err
+		}
+		return errors.New("boom")
+	})
+
+	assert.Error(t, err)
+	_, err = q.GetUser(ctx, "u1")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestEndStudySession_OnlyEndsOpenSessionsOnce(t *testing.T) {
+	q := New()
+	ctx := context.Background()
+	userID := sql.NullString{String: "u1", Valid: true}
+	start := time.Now().Add(-time.Hour)
+
+	created, err := q.CreateStudySession(ctx, database.CreateStudySessionParams{UserID: userID, StartTime: start})
+	require.NoError(t, err)
+
+	end := sql.NullTime{Time: start.Add(30 * time.Minute), Valid: true}
+	ended, err := q.EndStudySession(ctx, database.EndStudySessionParams{SessionID: created.SessionID, EndTime: end})
+	require.NoError(t, err)
+	assert.Equal(t, (30 * time.Minute).Milliseconds(), ended.DurationMs.Int64)
+
+	_, err = q.EndStudySession(ctx, database.EndStudySessionParams{SessionID: created.SessionID, EndTime: end})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = q.GetActiveStudySession(ctx, userID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUpdateUserStreakAfterEvaluation_OnlyOncePerDay(t *testing.T) {
+	q := New()
+	ctx := context.Background()
+	day := sql.NullTime{Time: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), Valid: true}
+
+	_, err := q.StartDailyActivity(ctx, database.StartDailyActivityParams{UserID: "u1", GuildID: "g1", LastActivityDate: day})
+	require.NoError(t, err)
+
+	params := database.UpdateUserStreakAfterEvaluationParams{UserID: "u1", GuildID: "g1", CurrentStreakCount: 1, MaxStreakCount: 1, StreakEvaluatedDate: day}
+	_, err = q.UpdateUserStreakAfterEvaluation(ctx, params)
+	require.NoError(t, err)
+
+	params.CurrentStreakCount = 2
+	_, err = q.UpdateUserStreakAfterEvaluation(ctx, params)
+	assert.ErrorIs(t, err, sql.ErrNoRows, "a day is never evaluated twice")
+
+	streak, err := q.GetUserStreak(ctx, database.GetUserStreakParams{UserID: "u1", GuildID: "g1"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), streak.CurrentStreakCount)
+}
+
+func TestEnqueueNotification_DedupesByKey(t *testing.T) {
+	q := New()
+	ctx := context.Background()
+	params := database.EnqueueNotificationParams{DedupeKey: "k", Kind: "session", ChannelID: "c", Payload: []byte(`{}`)}
+
+	first, err := q.EnqueueNotification(ctx, params)
+	require.NoError(t, err)
+	second, err := q.EnqueueNotification(ctx, params)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), first)
+	assert.Equal(t, int64(0), second)
+	assert.Len(t, q.Notifications(), 1)
+}
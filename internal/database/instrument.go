@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/metrics"
+)
+
+// instrumentedDB times every statement sent through it, labelled with the sqlc method name
+// taken from the "-- name:" header sqlc puts at the start of each query
+type instrumentedDB struct {
+	db DBTX
+}
+
+// instrument wraps db so query latency shows up in metrics.DBQueryDuration
+func instrument(db DBTX) DBTX {
+	return instrumentedDB{db: db}
+}
+
+func (d instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer metrics.DBQueryDuration.With(queryName(query)).ObserveSince(time.Now())
+	return d.db.ExecContext(ctx, query, args...)
+}
+
+func (d instrumentedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return d.db.PrepareContext(ctx, query)
+}
+
+func (d instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer metrics.DBQueryDuration.With(queryName(query)).ObserveSince(time.Now())
+	return d.db.QueryContext(ctx, query, args...)
+}
+
+func (d instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer metrics.DBQueryDuration.With(queryName(query)).ObserveSince(time.Now())
+	return d.db.QueryRowContext(ctx, query, args...)
+}
+
+// queryName returns the sqlc method name of query, e.g. GetUser, or "other" for hand-written SQL
+func queryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return "other"
+	}
+	if name, _, found := strings.Cut(rest, " "); found && name != "" {
+		return name
+	}
+	return "other"
+}
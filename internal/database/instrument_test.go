@@ -0,0 +1,14 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryName(t *testing.T) {
+	assert.Equal(t, "CreateUser", queryName(createUser))
+	assert.Equal(t, "ArchiveGuildStudySessions", queryName(archiveGuildStudySessions))
+	assert.Equal(t, "other", queryName("DEALLOCATE ALL"))
+	assert.Equal(t, "other", queryName("-- name: "))
+}
@@ -6,6 +6,7 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -22,12 +23,130 @@ type Achievement struct {
 	CreatedAt        sql.NullTime  `json:"createdAt"`
 }
 
+type ApiToken struct {
+	ID         int64        `json:"id"`
+	GuildID    string       `json:"guildId"`
+	Name       string       `json:"name"`
+	TokenHash  string       `json:"tokenHash"`
+	CreatedBy  string       `json:"createdBy"`
+	CreatedAt  time.Time    `json:"createdAt"`
+	LastUsedAt sql.NullTime `json:"lastUsedAt"`
+	RevokedAt  sql.NullTime `json:"revokedAt"`
+}
+
+type AuditLog struct {
+	ID           int64           `json:"id"`
+	GuildID      sql.NullString  `json:"guildId"`
+	ActorID      sql.NullString  `json:"actorId"`
+	TargetUserID sql.NullString  `json:"targetUserId"`
+	Action       string          `json:"action"`
+	Reason       sql.NullString  `json:"reason"`
+	Details      json.RawMessage `json:"details"`
+	CreatedAt    time.Time       `json:"createdAt"`
+}
+
+type GuildRoleCapability struct {
+	GuildID    string    `json:"guildId"`
+	RoleID     string    `json:"roleId"`
+	Capability string    `json:"capability"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type GuildSetting struct {
+	GuildID           string         `json:"guildId"`
+	ReminderMinutes   []int32        `json:"reminderMinutes"`
+	UpdatedAt         time.Time      `json:"updatedAt"`
+	StreakMode        string         `json:"streakMode"`
+	WeeklyGoalMinutes int32          `json:"weeklyGoalMinutes"`
+	StreakRepair      bool           `json:"streakRepair"`
+	RecapChannelID    sql.NullString `json:"recapChannelId"`
+}
+
+type LiveStatusMessage struct {
+	GuildID   string    `json:"guildId"`
+	ChannelID string    `json:"channelId"`
+	MessageID string    `json:"messageId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type NotificationPreference struct {
+	UserID           string        `json:"userId"`
+	StreakWarnings   string        `json:"streakWarnings"`
+	DailyComplete    string        `json:"dailyComplete"`
+	Achievements     string        `json:"achievements"`
+	SessionSummaries string        `json:"sessionSummaries"`
+	QuietStartHour   sql.NullInt32 `json:"quietStartHour"`
+	QuietEndHour     sql.NullInt32 `json:"quietEndHour"`
+	UpdatedAt        time.Time     `json:"updatedAt"`
+}
+
+type NotificationsOutbox struct {
+	ID            int64           `json:"id"`
+	DedupeKey     string          `json:"dedupeKey"`
+	Kind          string          `json:"kind"`
+	GuildID       sql.NullString  `json:"guildId"`
+	UserID        sql.NullString  `json:"userId"`
+	ChannelID     string          `json:"channelId"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int32           `json:"attempts"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+	LastError     sql.NullString  `json:"lastError"`
+	SentAt        sql.NullTime    `json:"sentAt"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
+
+type Recap struct {
+	ID          int64           `json:"id"`
+	GuildID     string          `json:"guildId"`
+	Period      string          `json:"period"`
+	PeriodStart time.Time       `json:"periodStart"`
+	PeriodEnd   time.Time       `json:"periodEnd"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+type RecapSubscription struct {
+	UserID    string    `json:"userId"`
+	GuildID   string    `json:"guildId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type StreakEvaluation struct {
+	GuildID           string    `json:"guildId"`
+	LastEvaluatedDate time.Time `json:"lastEvaluatedDate"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+type StreakEvent struct {
+	ID          int64     `json:"id"`
+	UserID      string    `json:"userId"`
+	GuildID     string    `json:"guildId"`
+	EventDate   time.Time `json:"eventDate"`
+	EventType   string    `json:"eventType"`
+	StreakCount int32     `json:"streakCount"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
 type StudySession struct {
 	SessionID  int32          `json:"sessionId"`
 	UserID     sql.NullString `json:"userId"`
 	StartTime  time.Time      `json:"startTime"`
 	EndTime    sql.NullTime   `json:"endTime"`
 	DurationMs sql.NullInt64  `json:"durationMs"`
+	IsManual   bool           `json:"isManual"`
+	GuildID    sql.NullString `json:"guildId"`
+}
+
+type StudySessionsArchive struct {
+	SessionID  int32          `json:"sessionId"`
+	UserID     sql.NullString `json:"userId"`
+	GuildID    sql.NullString `json:"guildId"`
+	StartTime  time.Time      `json:"startTime"`
+	EndTime    time.Time      `json:"endTime"`
+	DurationMs sql.NullInt64  `json:"durationMs"`
+	IsManual   bool           `json:"isManual"`
+	ArchivedBy sql.NullString `json:"archivedBy"`
+	ArchivedAt time.Time      `json:"archivedAt"`
 }
 
 type User struct {
@@ -44,6 +163,20 @@ type UserAchievement struct {
 	Notified      sql.NullBool `json:"notified"`
 }
 
+type UserDailyActivity struct {
+	UserID       string    `json:"userId"`
+	GuildID      string    `json:"guildId"`
+	ActivityDate time.Time `json:"activityDate"`
+	StudyMs      int64     `json:"studyMs"`
+}
+
+type UserReminderSetting struct {
+	UserID          string    `json:"userId"`
+	GuildID         string    `json:"guildId"`
+	ReminderMinutes []int32   `json:"reminderMinutes"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
 type UserStat struct {
 	UserID         string        `json:"userId"`
 	TotalStudyMs   sql.NullInt64 `json:"totalStudyMs"`
@@ -70,4 +203,7 @@ type UserStreak struct {
 	DailyActivityMinutes        sql.NullInt32 `json:"dailyActivityMinutes"`
 	ActivityStartTime           sql.NullTime  `json:"activityStartTime"`
 	StreakIncrementedToday      bool          `json:"streakIncrementedToday"`
+	StreakMode                  string        `json:"streakMode"`
+	PreviousStreak              int32         `json:"previousStreak"`
+	StreakBrokenAt              sql.NullTime  `json:"streakBrokenAt"`
 }
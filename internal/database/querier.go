@@ -11,17 +11,77 @@ import (
 )
 
 type Querier interface {
+	// =============================================
+	// Daily Activity Queries
+	// =============================================
+	AddDailyActivity(ctx context.Context, arg AddDailyActivityParams) error
+	AddRecapSubscription(ctx context.Context, arg AddRecapSubscriptionParams) error
+	ArchiveGuildStudySessions(ctx context.Context, arg ArchiveGuildStudySessionsParams) (int64, error)
+	ArchiveOldStudySessions(ctx context.Context, startTime time.Time) (int64, error)
 	AwardAchievement(ctx context.Context, arg AwardAchievementParams) (UserAchievement, error)
+	ClearStreakIncrementedToday(ctx context.Context, arg ClearStreakIncrementedTodayParams) error
+	ClearUserReminderMinutes(ctx context.Context, arg ClearUserReminderMinutesParams) (int64, error)
+	// =============================================
+	// Session Cleanup Queries
+	// =============================================
+	CountGuildStudySessions(ctx context.Context, arg CountGuildStudySessionsParams) (CountGuildStudySessionsRow, error)
 	CountStudySessions(ctx context.Context) (int64, error)
+	// =============================================
+	// API Token Queries
+	// =============================================
+	CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (ApiToken, error)
+	CreateAuditLogEntry(ctx context.Context, arg CreateAuditLogEntryParams) (AuditLog, error)
+	CreateManualStudySession(ctx context.Context, arg CreateManualStudySessionParams) (StudySession, error)
 	CreateOrUpdateUserStats(ctx context.Context, arg CreateOrUpdateUserStatsParams) (UserStat, error)
+	CreateRecap(ctx context.Context, arg CreateRecapParams) (int64, error)
+	// =============================================
+	// Streak Event Queries
+	// =============================================
+	CreateStreakEvent(ctx context.Context, arg CreateStreakEventParams) error
 	CreateStudySession(ctx context.Context, arg CreateStudySessionParams) (StudySession, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	// $1 will be the cutoff timestamp (e.g., 6 months ago)
 	DeleteAllStudySessions(ctx context.Context) error
+	// Open sessions are never deleted, they belong to members still in voice
+	DeleteGuildStudySessionsWithCount(ctx context.Context, arg DeleteGuildStudySessionsWithCountParams) (int64, error)
+	DeleteLiveStatusMessage(ctx context.Context, guildID string) (int64, error)
+	DeleteNotification(ctx context.Context, dedupeKey string) error
 	// For top 10 users
-	DeleteOldStudySessions(ctx context.Context, startTime time.Time) error
-	DeleteOldStudySessionsWithCount(ctx context.Context, startTime time.Time) (int64, error)
+	DeleteOldStudySessions(ctx context.Context, startTime time.Time) (int64, error)
+	DeleteRecapSubscription(ctx context.Context, arg DeleteRecapSubscriptionParams) (int64, error)
+	DeleteSentNotifications(ctx context.Context, sentAt sql.NullTime) (int64, error)
+	DeleteUser(ctx context.Context, userID string) (int64, error)
+	// =============================================
+	// Account Deletion & Audit Log Queries
+	// =============================================
+	DeleteUserAchievements(ctx context.Context, userID string) (int64, error)
+	DeleteUserArchivedStudySessions(ctx context.Context, userID sql.NullString) (int64, error)
+	DeleteUserDailyActivity(ctx context.Context, userID string) (int64, error)
+	// =============================================
+	// Guild-Scoped Account Deletion Queries
+	// =============================================
+	DeleteUserGuildAchievements(ctx context.Context, arg DeleteUserGuildAchievementsParams) (int64, error)
+	DeleteUserGuildArchivedStudySessions(ctx context.Context, arg DeleteUserGuildArchivedStudySessionsParams) (int64, error)
+	DeleteUserGuildDailyActivity(ctx context.Context, arg DeleteUserGuildDailyActivityParams) (int64, error)
+	DeleteUserGuildNotifications(ctx context.Context, arg DeleteUserGuildNotificationsParams) (int64, error)
+	DeleteUserGuildReminderSettings(ctx context.Context, arg DeleteUserGuildReminderSettingsParams) (int64, error)
+	DeleteUserGuildStreakEvents(ctx context.Context, arg DeleteUserGuildStreakEventsParams) (int64, error)
+	DeleteUserGuildStreaks(ctx context.Context, arg DeleteUserGuildStreaksParams) (int64, error)
+	DeleteUserGuildStudySessions(ctx context.Context, arg DeleteUserGuildStudySessionsParams) (int64, error)
+	DeleteUserNotificationPreferences(ctx context.Context, userID string) (int64, error)
+	DeleteUserNotifications(ctx context.Context, userID sql.NullString) (int64, error)
+	DeleteUserRecapSubscriptions(ctx context.Context, userID string) (int64, error)
+	DeleteUserReminderSettings(ctx context.Context, userID string) (int64, error)
+	DeleteUserStats(ctx context.Context, userID string) (int64, error)
+	DeleteUserStreakEvents(ctx context.Context, userID string) (int64, error)
+	DeleteUserStreaks(ctx context.Context, userID string) (int64, error)
+	DeleteUserStudySessions(ctx context.Context, userID sql.NullString) (int64, error)
 	EndStudySession(ctx context.Context, arg EndStudySessionParams) (StudySession, error)
+	// =============================================
+	// Notification Outbox Queries
+	// =============================================
+	EnqueueNotification(ctx context.Context, arg EnqueueNotificationParams) (int64, error)
+	GetAPITokenByHash(ctx context.Context, tokenHash string) (ApiToken, error)
 	GetAchievementByID(ctx context.Context, achievementID string) (GetAchievementByIDRow, error)
 	GetAchievementsByCategory(ctx context.Context, category string) ([]GetAchievementsByCategoryRow, error)
 	GetAchievementsByRequirementType(ctx context.Context, requirementType string) ([]GetAchievementsByRequirementTypeRow, error)
@@ -30,7 +90,50 @@ type Querier interface {
 	// Achievement System Queries
 	// =============================================
 	GetAllAchievements(ctx context.Context) ([]GetAllAchievementsRow, error)
+	GetDailyActivity(ctx context.Context, arg GetDailyActivityParams) ([]UserDailyActivity, error)
+	GetDueNotifications(ctx context.Context, arg GetDueNotificationsParams) ([]NotificationsOutbox, error)
+	GetGuildAPITokens(ctx context.Context, guildID string) ([]ApiToken, error)
+	GetGuildAchievementsEarnedBetween(ctx context.Context, arg GetGuildAchievementsEarnedBetweenParams) ([]GetGuildAchievementsEarnedBetweenRow, error)
+	GetGuildAuditLog(ctx context.Context, arg GetGuildAuditLogParams) ([]AuditLog, error)
+	// =============================================
+	// Dashboard Queries
+	// =============================================
+	GetGuildDailyTotals(ctx context.Context, arg GetGuildDailyTotalsParams) ([]GetGuildDailyTotalsRow, error)
+	// =============================================
+	// Streak Evaluation Queries
+	// =============================================
+	GetGuildEvaluationDates(ctx context.Context) ([]GetGuildEvaluationDatesRow, error)
+	// =============================================
+	// REST API Queries
+	// =============================================
+	GetGuildLeaderboard(ctx context.Context, arg GetGuildLeaderboardParams) ([]GetGuildLeaderboardRow, error)
+	// =============================================
+	// Permission Queries
+	// =============================================
+	GetGuildRoleCapabilities(ctx context.Context, guildID string) ([]GuildRoleCapability, error)
+	// =============================================
+	// Reminder Queries
+	// =============================================
+	GetGuildSettings(ctx context.Context, guildID string) (GuildSetting, error)
+	// =============================================
+	// Recap Queries
+	// =============================================
+	GetGuildStudyTotals(ctx context.Context, arg GetGuildStudyTotalsParams) ([]GetGuildStudyTotalsRow, error)
+	GetGuildTopStreaks(ctx context.Context, arg GetGuildTopStreaksParams) ([]GetGuildTopStreaksRow, error)
 	GetLeaderboard(ctx context.Context) ([]GetLeaderboardRow, error)
+	GetLiveStatusMessage(ctx context.Context, guildID string) (LiveStatusMessage, error)
+	GetLiveStatusMessages(ctx context.Context) ([]LiveStatusMessage, error)
+	// =============================================
+	// Notification Preference Queries
+	// =============================================
+	GetNotificationPreferences(ctx context.Context, userID string) (NotificationPreference, error)
+	// A break a later repair undid is not an ended streak
+	GetPastStreaks(ctx context.Context, arg GetPastStreaksParams) ([]StreakEvent, error)
+	GetRecap(ctx context.Context, arg GetRecapParams) (Recap, error)
+	GetRecapSubscribers(ctx context.Context, guildID string) ([]string, error)
+	// Archived recaps that list the user anywhere in their payload, optionally in one guild only
+	GetRecapsMentioningUser(ctx context.Context, arg GetRecapsMentioningUserParams) ([]Recap, error)
+	GetStreakEvents(ctx context.Context, arg GetStreakEventsParams) ([]StreakEvent, error)
 	GetTotalAchievementCount(ctx context.Context) (int64, error)
 	GetUniqueStudyHours(ctx context.Context, userID sql.NullString) (int32, error)
 	GetUnnotifiedAchievements(ctx context.Context, arg GetUnnotifiedAchievementsParams) ([]GetUnnotifiedAchievementsRow, error)
@@ -38,30 +141,53 @@ type Querier interface {
 	GetUserAchievementCount(ctx context.Context, arg GetUserAchievementCountParams) (int64, error)
 	GetUserAchievements(ctx context.Context, arg GetUserAchievementsParams) ([]GetUserAchievementsRow, error)
 	GetUserFeaturedBadge(ctx context.Context, userID string) (GetUserFeaturedBadgeRow, error)
+	GetUserGuildStudySessions(ctx context.Context, arg GetUserGuildStudySessionsParams) ([]StudySession, error)
+	GetUserGuildStudyTotals(ctx context.Context, arg GetUserGuildStudyTotalsParams) (GetUserGuildStudyTotalsRow, error)
+	GetUserReminderSettings(ctx context.Context, arg GetUserReminderSettingsParams) (UserReminderSetting, error)
 	GetUserStats(ctx context.Context, userID string) (UserStat, error)
 	// Calendar Day-Based User Streaks Queries
 	GetUserStreak(ctx context.Context, arg GetUserStreakParams) (GetUserStreakRow, error)
-	GetUsersForDailyEvaluation(ctx context.Context, streakEvaluatedDate sql.NullTime) ([]GetUsersForDailyEvaluationRow, error)
-	GetUsersForStreakReset(ctx context.Context, lastActivityDate sql.NullTime) ([]GetUsersForStreakResetRow, error)
-	GetUsersNeedingWarnings(ctx context.Context, lastActivityDate sql.NullTime) ([]GetUsersNeedingWarningsRow, error)
+	GetUsersForDailyEvaluation(ctx context.Context, arg GetUsersForDailyEvaluationParams) ([]GetUsersForDailyEvaluationRow, error)
+	GetUsersNeedingWarnings(ctx context.Context, arg GetUsersNeedingWarningsParams) ([]GetUsersNeedingWarningsRow, error)
+	GetUsersWithUnnotifiedAchievements(ctx context.Context) ([]GetUsersWithUnnotifiedAchievementsRow, error)
+	GrantRoleCapability(ctx context.Context, arg GrantRoleCapabilityParams) error
 	HasAchievement(ctx context.Context, arg HasAchievementParams) (bool, error)
 	HasActivityForDate(ctx context.Context, arg HasActivityForDateParams) (bool, error)
 	HasDawnToDuskDay(ctx context.Context, userID sql.NullString) (bool, error)
 	MarkAchievementNotified(ctx context.Context, arg MarkAchievementNotifiedParams) error
-	ResetAllStreakDailyFlags(ctx context.Context) error
+	MarkNotificationFailed(ctx context.Context, arg MarkNotificationFailedParams) error
+	MarkNotificationSent(ctx context.Context, arg MarkNotificationSentParams) error
+	// Used when Discord rate limits us: the attempt doesn't count against the retry budget
+	RescheduleNotification(ctx context.Context, arg RescheduleNotificationParams) error
 	ResetDailyStudyTime(ctx context.Context) error
 	ResetMonthlyStudyTime(ctx context.Context) error
-	// Haven't been active today
 	ResetUserStreakCount(ctx context.Context, arg ResetUserStreakCountParams) error
 	ResetWeeklyStudyTime(ctx context.Context) error
+	RevokeAPIToken(ctx context.Context, arg RevokeAPITokenParams) (int64, error)
+	RevokeRoleCapability(ctx context.Context, arg RevokeRoleCapabilityParams) (int64, error)
 	SetFeaturedBadge(ctx context.Context, arg SetFeaturedBadgeParams) error
+	SetGuildLastEvaluatedDate(ctx context.Context, arg SetGuildLastEvaluatedDateParams) error
+	// Weekly goals depend on the whole week
+	SetUserStreakMode(ctx context.Context, arg SetUserStreakModeParams) error
 	StartDailyActivity(ctx context.Context, arg StartDailyActivityParams) (StartDailyActivityRow, error)
+	// Written at most once a minute per token, so busy clients don't turn every read into a write
+	TouchAPIToken(ctx context.Context, id int64) error
 	UpdateDailyActivityMinutes(ctx context.Context, arg UpdateDailyActivityMinutesParams) error
+	UpdateRecapPayload(ctx context.Context, arg UpdateRecapPayloadParams) error
+	UpdateStreakBreak(ctx context.Context, arg UpdateStreakBreakParams) error
 	UpdateStreakImmediately(ctx context.Context, arg UpdateStreakImmediatelyParams) error
-	// $1 is today's date in Manila timezone
+	// $2 is the Manila date being evaluated
 	UpdateUserStreakAfterEvaluation(ctx context.Context, arg UpdateUserStreakAfterEvaluationParams) (UpdateUserStreakAfterEvaluationRow, error)
-	// Haven't been warned today
 	UpdateWarningNotifiedAt(ctx context.Context, arg UpdateWarningNotifiedAtParams) error
+	UpsertGuildRecapChannel(ctx context.Context, arg UpsertGuildRecapChannelParams) error
+	UpsertGuildReminderMinutes(ctx context.Context, arg UpsertGuildReminderMinutesParams) error
+	UpsertGuildStreakMode(ctx context.Context, arg UpsertGuildStreakModeParams) error
+	// =============================================
+	// Live Status Message Queries
+	// =============================================
+	UpsertLiveStatusMessage(ctx context.Context, arg UpsertLiveStatusMessageParams) error
+	UpsertNotificationPreferences(ctx context.Context, arg UpsertNotificationPreferencesParams) error
+	UpsertUserReminderMinutes(ctx context.Context, arg UpsertUserReminderMinutesParams) error
 }
 
 var _ Querier = (*Queries)(nil)
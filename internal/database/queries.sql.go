@@ -8,9 +8,92 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+const addDailyActivity = `-- name: AddDailyActivity :exec
+
+INSERT INTO user_daily_activity (user_id, guild_id, activity_date, study_ms)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, guild_id, activity_date) DO UPDATE SET
+    study_ms = user_daily_activity.study_ms + EXCLUDED.study_ms
+`
+
+type AddDailyActivityParams struct {
+	UserID       string    `json:"userId"`
+	GuildID      string    `json:"guildId"`
+	ActivityDate time.Time `json:"activityDate"`
+	StudyMs      int64     `json:"studyMs"`
+}
+
+// =============================================
+// Daily Activity Queries
+// =============================================
+func (q *Queries) AddDailyActivity(ctx context.Context, arg AddDailyActivityParams) error {
+	_, err := q.db.ExecContext(ctx, addDailyActivity,
+		arg.UserID,
+		arg.GuildID,
+		arg.ActivityDate,
+		arg.StudyMs,
+	)
+	return err
+}
+
+const addRecapSubscription = `-- name: AddRecapSubscription :exec
+INSERT INTO recap_subscriptions (user_id, guild_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id, guild_id) DO NOTHING
+`
+
+type AddRecapSubscriptionParams struct {
+	UserID  string `json:"userId"`
+	GuildID string `json:"guildId"`
+}
+
+func (q *Queries) AddRecapSubscription(ctx context.Context, arg AddRecapSubscriptionParams) error {
+	_, err := q.db.ExecContext(ctx, addRecapSubscription, arg.UserID, arg.GuildID)
+	return err
+}
+
+const archiveGuildStudySessions = `-- name: ArchiveGuildStudySessions :execrows
+INSERT INTO study_sessions_archive (session_id, user_id, guild_id, start_time, end_time, duration_ms, is_manual, archived_by)
+SELECT session_id, user_id, guild_id, start_time, end_time, duration_ms, is_manual, $1::TEXT
+FROM study_sessions
+WHERE guild_id = $2 AND start_time < $3 AND end_time IS NOT NULL
+`
+
+type ArchiveGuildStudySessionsParams struct {
+	ArchivedBy sql.NullString `json:"archivedBy"`
+	GuildID    sql.NullString `json:"guildId"`
+	Before     time.Time      `json:"before"`
+}
+
+func (q *Queries) ArchiveGuildStudySessions(ctx context.Context, arg ArchiveGuildStudySessionsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, archiveGuildStudySessions, arg.ArchivedBy, arg.GuildID, arg.Before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const archiveOldStudySessions = `-- name: ArchiveOldStudySessions :execrows
+INSERT INTO study_sessions_archive (session_id, user_id, guild_id, start_time, end_time, duration_ms, is_manual)
+SELECT session_id, user_id, guild_id, start_time, end_time, duration_ms, is_manual
+FROM study_sessions
+WHERE start_time < $1 AND end_time IS NOT NULL
+`
+
+func (q *Queries) ArchiveOldStudySessions(ctx context.Context, startTime time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, archiveOldStudySessions, startTime)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const awardAchievement = `-- name: AwardAchievement :one
 INSERT INTO user_achievements (user_id, guild_id, achievement_id, earned_at, notified)
 VALUES ($1, $2, $3, NOW(), FALSE)
@@ -37,6 +120,71 @@ func (q *Queries) AwardAchievement(ctx context.Context, arg AwardAchievementPara
 	return i, err
 }
 
+const clearStreakIncrementedToday = `-- name: ClearStreakIncrementedToday :exec
+UPDATE user_streaks
+SET
+    streak_incremented_today = FALSE,
+    updated_at = NOW()
+WHERE user_id = $1 AND guild_id = $2
+`
+
+type ClearStreakIncrementedTodayParams struct {
+	UserID  string `json:"userId"`
+	GuildID string `json:"guildId"`
+}
+
+func (q *Queries) ClearStreakIncrementedToday(ctx context.Context, arg ClearStreakIncrementedTodayParams) error {
+	_, err := q.db.ExecContext(ctx, clearStreakIncrementedToday, arg.UserID, arg.GuildID)
+	return err
+}
+
+const clearUserReminderMinutes = `-- name: ClearUserReminderMinutes :execrows
+DELETE FROM user_reminder_settings
+WHERE user_id = $1 AND guild_id = $2
+`
+
+type ClearUserReminderMinutesParams struct {
+	UserID  string `json:"userId"`
+	GuildID string `json:"guildId"`
+}
+
+func (q *Queries) ClearUserReminderMinutes(ctx context.Context, arg ClearUserReminderMinutesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, clearUserReminderMinutes, arg.UserID, arg.GuildID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countGuildStudySessions = `-- name: CountGuildStudySessions :one
+
+SELECT
+    COUNT(*) FILTER (WHERE end_time IS NOT NULL) AS closed_sessions,
+    COUNT(*) FILTER (WHERE end_time IS NULL) AS open_sessions
+FROM study_sessions
+WHERE guild_id = $1 AND start_time < $2
+`
+
+type CountGuildStudySessionsParams struct {
+	GuildID   sql.NullString `json:"guildId"`
+	StartTime time.Time      `json:"startTime"`
+}
+
+type CountGuildStudySessionsRow struct {
+	ClosedSessions int64 `json:"closedSessions"`
+	OpenSessions   int64 `json:"openSessions"`
+}
+
+// =============================================
+// Session Cleanup Queries
+// =============================================
+func (q *Queries) CountGuildStudySessions(ctx context.Context, arg CountGuildStudySessionsParams) (CountGuildStudySessionsRow, error) {
+	row := q.db.QueryRowContext(ctx, countGuildStudySessions, arg.GuildID, arg.StartTime)
+	var i CountGuildStudySessionsRow
+	err := row.Scan(&i.ClosedSessions, &i.OpenSessions)
+	return i, err
+}
+
 const countStudySessions = `-- name: CountStudySessions :one
 SELECT COUNT(*) FROM study_sessions
 `
@@ -48,25 +196,145 @@ func (q *Queries) CountStudySessions(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+const createAPIToken = `-- name: CreateAPIToken :one
+
+INSERT INTO api_tokens (guild_id, name, token_hash, created_by)
+VALUES ($1, $2, $3, $4)
+RETURNING id, guild_id, name, token_hash, created_by, created_at, last_used_at, revoked_at
+`
+
+type CreateAPITokenParams struct {
+	GuildID   string `json:"guildId"`
+	Name      string `json:"name"`
+	TokenHash string `json:"tokenHash"`
+	CreatedBy string `json:"createdBy"`
+}
+
+// =============================================
+// API Token Queries
+// =============================================
+func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (ApiToken, error) {
+	row := q.db.QueryRowContext(ctx, createAPIToken,
+		arg.GuildID,
+		arg.Name,
+		arg.TokenHash,
+		arg.CreatedBy,
+	)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.GuildID,
+		&i.Name,
+		&i.TokenHash,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const createAuditLogEntry = `-- name: CreateAuditLogEntry :one
+INSERT INTO audit_log (guild_id, actor_id, target_user_id, action, reason, details)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, guild_id, actor_id, target_user_id, action, reason, details, created_at
+`
+
+type CreateAuditLogEntryParams struct {
+	GuildID      sql.NullString  `json:"guildId"`
+	ActorID      sql.NullString  `json:"actorId"`
+	TargetUserID sql.NullString  `json:"targetUserId"`
+	Action       string          `json:"action"`
+	Reason       sql.NullString  `json:"reason"`
+	Details      json.RawMessage `json:"details"`
+}
+
+func (q *Queries) CreateAuditLogEntry(ctx context.Context, arg CreateAuditLogEntryParams) (AuditLog, error) {
+	row := q.db.QueryRowContext(ctx, createAuditLogEntry,
+		arg.GuildID,
+		arg.ActorID,
+		arg.TargetUserID,
+		arg.Action,
+		arg.Reason,
+		arg.Details,
+	)
+	var i AuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.GuildID,
+		&i.ActorID,
+		&i.TargetUserID,
+		&i.Action,
+		&i.Reason,
+		&i.Details,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createManualStudySession = `-- name: CreateManualStudySession :one
+INSERT INTO study_sessions (user_id, guild_id, start_time, end_time, duration_ms, is_manual)
+VALUES ($1, $2, $3, $4, $5, TRUE)
+RETURNING session_id, user_id, start_time, end_time, duration_ms, is_manual, guild_id
+`
+
+type CreateManualStudySessionParams struct {
+	UserID     sql.NullString `json:"userId"`
+	GuildID    sql.NullString `json:"guildId"`
+	StartTime  time.Time      `json:"startTime"`
+	EndTime    sql.NullTime   `json:"endTime"`
+	DurationMs sql.NullInt64  `json:"durationMs"`
+}
+
+func (q *Queries) CreateManualStudySession(ctx context.Context, arg CreateManualStudySessionParams) (StudySession, error) {
+	row := q.db.QueryRowContext(ctx, createManualStudySession,
+		arg.UserID,
+		arg.GuildID,
+		arg.StartTime,
+		arg.EndTime,
+		arg.DurationMs,
+	)
+	var i StudySession
+	err := row.Scan(
+		&i.SessionID,
+		&i.UserID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.DurationMs,
+		&i.IsManual,
+		&i.GuildID,
+	)
+	return i, err
+}
+
 const createOrUpdateUserStats = `-- name: CreateOrUpdateUserStats :one
 INSERT INTO user_stats (user_id, total_study_ms, daily_study_ms, weekly_study_ms, monthly_study_ms)
-VALUES ($1, $2, $2, $2, $2)
+VALUES ($1, $2, $3, $4, $5)
 ON CONFLICT (user_id) DO UPDATE
 SET 
   total_study_ms = user_stats.total_study_ms + $2,
-  daily_study_ms = user_stats.daily_study_ms + $2,
-  weekly_study_ms = user_stats.weekly_study_ms + $2,
-  monthly_study_ms = user_stats.monthly_study_ms + $2
+  daily_study_ms = user_stats.daily_study_ms + $3,
+  weekly_study_ms = user_stats.weekly_study_ms + $4,
+  monthly_study_ms = user_stats.monthly_study_ms + $5
 RETURNING user_id, total_study_ms, daily_study_ms, weekly_study_ms, monthly_study_ms, current_streak, max_streak, last_streak_date, streak_freezes
 `
 
 type CreateOrUpdateUserStatsParams struct {
-	UserID       string        `json:"userId"`
-	TotalStudyMs sql.NullInt64 `json:"totalStudyMs"`
+	UserID         string        `json:"userId"`
+	TotalStudyMs   sql.NullInt64 `json:"totalStudyMs"`
+	DailyStudyMs   sql.NullInt64 `json:"dailyStudyMs"`
+	WeeklyStudyMs  sql.NullInt64 `json:"weeklyStudyMs"`
+	MonthlyStudyMs sql.NullInt64 `json:"monthlyStudyMs"`
 }
 
 func (q *Queries) CreateOrUpdateUserStats(ctx context.Context, arg CreateOrUpdateUserStatsParams) (UserStat, error) {
-	row := q.db.QueryRowContext(ctx, createOrUpdateUserStats, arg.UserID, arg.TotalStudyMs)
+	row := q.db.QueryRowContext(ctx, createOrUpdateUserStats,
+		arg.UserID,
+		arg.TotalStudyMs,
+		arg.DailyStudyMs,
+		arg.WeeklyStudyMs,
+		arg.MonthlyStudyMs,
+	)
 	var i UserStat
 	err := row.Scan(
 		&i.UserID,
@@ -82,19 +350,77 @@ func (q *Queries) CreateOrUpdateUserStats(ctx context.Context, arg CreateOrUpdat
 	return i, err
 }
 
+const createRecap = `-- name: CreateRecap :execrows
+INSERT INTO recaps (guild_id, period, period_start, period_end, payload)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (guild_id, period, period_start) DO NOTHING
+`
+
+type CreateRecapParams struct {
+	GuildID     string          `json:"guildId"`
+	Period      string          `json:"period"`
+	PeriodStart time.Time       `json:"periodStart"`
+	PeriodEnd   time.Time       `json:"periodEnd"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+func (q *Queries) CreateRecap(ctx context.Context, arg CreateRecapParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createRecap,
+		arg.GuildID,
+		arg.Period,
+		arg.PeriodStart,
+		arg.PeriodEnd,
+		arg.Payload,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createStreakEvent = `-- name: CreateStreakEvent :exec
+
+INSERT INTO streak_events (user_id, guild_id, event_date, event_type, streak_count)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, guild_id, event_date) DO NOTHING
+`
+
+type CreateStreakEventParams struct {
+	UserID      string    `json:"userId"`
+	GuildID     string    `json:"guildId"`
+	EventDate   time.Time `json:"eventDate"`
+	EventType   string    `json:"eventType"`
+	StreakCount int32     `json:"streakCount"`
+}
+
+// =============================================
+// Streak Event Queries
+// =============================================
+func (q *Queries) CreateStreakEvent(ctx context.Context, arg CreateStreakEventParams) error {
+	_, err := q.db.ExecContext(ctx, createStreakEvent,
+		arg.UserID,
+		arg.GuildID,
+		arg.EventDate,
+		arg.EventType,
+		arg.StreakCount,
+	)
+	return err
+}
+
 const createStudySession = `-- name: CreateStudySession :one
-INSERT INTO study_sessions (user_id, start_time)
-VALUES ($1, $2)
-RETURNING session_id, user_id, start_time, end_time, duration_ms
+INSERT INTO study_sessions (user_id, guild_id, start_time)
+VALUES ($1, $2, $3)
+RETURNING session_id, user_id, start_time, end_time, duration_ms, is_manual, guild_id
 `
 
 type CreateStudySessionParams struct {
 	UserID    sql.NullString `json:"userId"`
+	GuildID   sql.NullString `json:"guildId"`
 	StartTime time.Time      `json:"startTime"`
 }
 
 func (q *Queries) CreateStudySession(ctx context.Context, arg CreateStudySessionParams) (StudySession, error) {
-	row := q.db.QueryRowContext(ctx, createStudySession, arg.UserID, arg.StartTime)
+	row := q.db.QueryRowContext(ctx, createStudySession, arg.UserID, arg.GuildID, arg.StartTime)
 	var i StudySession
 	err := row.Scan(
 		&i.SessionID,
@@ -102,6 +428,8 @@ func (q *Queries) CreateStudySession(ctx context.Context, arg CreateStudySession
 		&i.StartTime,
 		&i.EndTime,
 		&i.DurationMs,
+		&i.IsManual,
+		&i.GuildID,
 	)
 	return i, err
 }
@@ -136,122 +464,510 @@ func (q *Queries) DeleteAllStudySessions(ctx context.Context) error {
 	return err
 }
 
-const deleteOldStudySessions = `-- name: DeleteOldStudySessions :exec
-
-DELETE FROM study_sessions
-WHERE start_time < $1
-`
-
-// For top 10 users
-func (q *Queries) DeleteOldStudySessions(ctx context.Context, startTime time.Time) error {
-	_, err := q.db.ExecContext(ctx, deleteOldStudySessions, startTime)
-	return err
-}
+const deleteGuildStudySessionsWithCount = `-- name: DeleteGuildStudySessionsWithCount :one
 
-const deleteOldStudySessionsWithCount = `-- name: DeleteOldStudySessionsWithCount :one
 WITH deleted AS (
     DELETE FROM study_sessions
-    WHERE start_time < $1
+    WHERE guild_id = $1 AND start_time < $2 AND end_time IS NOT NULL
     RETURNING session_id
 )
 SELECT COUNT(*) FROM deleted
 `
 
-func (q *Queries) DeleteOldStudySessionsWithCount(ctx context.Context, startTime time.Time) (int64, error) {
-	row := q.db.QueryRowContext(ctx, deleteOldStudySessionsWithCount, startTime)
+type DeleteGuildStudySessionsWithCountParams struct {
+	GuildID   sql.NullString `json:"guildId"`
+	StartTime time.Time      `json:"startTime"`
+}
+
+// Open sessions are never deleted, they belong to members still in voice
+func (q *Queries) DeleteGuildStudySessionsWithCount(ctx context.Context, arg DeleteGuildStudySessionsWithCountParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, deleteGuildStudySessionsWithCount, arg.GuildID, arg.StartTime)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
-const endStudySession = `-- name: EndStudySession :one
-UPDATE study_sessions
-SET end_time = $2, duration_ms = EXTRACT(EPOCH FROM ($2 - start_time)) * 1000
-WHERE session_id = $1 AND end_time IS NULL
-RETURNING session_id, user_id, start_time, end_time, duration_ms
+const deleteLiveStatusMessage = `-- name: DeleteLiveStatusMessage :execrows
+DELETE FROM live_status_messages
+WHERE guild_id = $1
 `
 
-type EndStudySessionParams struct {
-	SessionID int32        `json:"sessionId"`
-	EndTime   sql.NullTime `json:"endTime"`
+func (q *Queries) DeleteLiveStatusMessage(ctx context.Context, guildID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteLiveStatusMessage, guildID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-func (q *Queries) EndStudySession(ctx context.Context, arg EndStudySessionParams) (StudySession, error) {
-	row := q.db.QueryRowContext(ctx, endStudySession, arg.SessionID, arg.EndTime)
-	var i StudySession
-	err := row.Scan(
-		&i.SessionID,
-		&i.UserID,
-		&i.StartTime,
-		&i.EndTime,
-		&i.DurationMs,
-	)
-	return i, err
+const deleteNotification = `-- name: DeleteNotification :exec
+DELETE FROM notifications_outbox
+WHERE dedupe_key = $1
+`
+
+func (q *Queries) DeleteNotification(ctx context.Context, dedupeKey string) error {
+	_, err := q.db.ExecContext(ctx, deleteNotification, dedupeKey)
+	return err
 }
 
-const getAchievementByID = `-- name: GetAchievementByID :one
-SELECT 
-    achievement_id,
-    name,
-    description,
-    icon,
-    category,
-    requirement_type,
-    requirement_value,
-    is_secret,
-    sort_order
-FROM achievements
-WHERE achievement_id = $1
+const deleteOldStudySessions = `-- name: DeleteOldStudySessions :execrows
+
+DELETE FROM study_sessions s
+USING study_sessions_archive a
+WHERE a.session_id = s.session_id AND s.start_time < $1 AND s.end_time IS NOT NULL
 `
 
-type GetAchievementByIDRow struct {
-	AchievementID    string        `json:"achievementId"`
-	Name             string        `json:"name"`
-	Description      string        `json:"description"`
-	Icon             string        `json:"icon"`
-	Category         string        `json:"category"`
-	RequirementType  string        `json:"requirementType"`
-	RequirementValue int32         `json:"requirementValue"`
-	IsSecret         sql.NullBool  `json:"isSecret"`
-	SortOrder        sql.NullInt32 `json:"sortOrder"`
+// For top 10 users
+func (q *Queries) DeleteOldStudySessions(ctx context.Context, startTime time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOldStudySessions, startTime)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-func (q *Queries) GetAchievementByID(ctx context.Context, achievementID string) (GetAchievementByIDRow, error) {
-	row := q.db.QueryRowContext(ctx, getAchievementByID, achievementID)
-	var i GetAchievementByIDRow
-	err := row.Scan(
-		&i.AchievementID,
-		&i.Name,
-		&i.Description,
-		&i.Icon,
-		&i.Category,
-		&i.RequirementType,
-		&i.RequirementValue,
-		&i.IsSecret,
-		&i.SortOrder,
-	)
-	return i, err
+const deleteRecapSubscription = `-- name: DeleteRecapSubscription :execrows
+DELETE FROM recap_subscriptions
+WHERE user_id = $1 AND guild_id = $2
+`
+
+type DeleteRecapSubscriptionParams struct {
+	UserID  string `json:"userId"`
+	GuildID string `json:"guildId"`
 }
 
-const getAchievementsByCategory = `-- name: GetAchievementsByCategory :many
-SELECT 
-    achievement_id,
-    name,
-    description,
-    icon,
-    category,
-    requirement_type,
-    requirement_value,
-    is_secret,
-    sort_order
-FROM achievements
-WHERE category = $1
-ORDER BY sort_order ASC
+func (q *Queries) DeleteRecapSubscription(ctx context.Context, arg DeleteRecapSubscriptionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRecapSubscription, arg.UserID, arg.GuildID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSentNotifications = `-- name: DeleteSentNotifications :execrows
+DELETE FROM notifications_outbox
+WHERE sent_at IS NOT NULL AND sent_at < $1
 `
 
-type GetAchievementsByCategoryRow struct {
-	AchievementID    string        `json:"achievementId"`
-	Name             string        `json:"name"`
+func (q *Queries) DeleteSentNotifications(ctx context.Context, sentAt sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSentNotifications, sentAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUser = `-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, userID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserAchievements = `-- name: DeleteUserAchievements :execrows
+
+DELETE FROM user_achievements
+WHERE user_id = $1
+`
+
+// =============================================
+// Account Deletion & Audit Log Queries
+// =============================================
+func (q *Queries) DeleteUserAchievements(ctx context.Context, userID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserAchievements, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserArchivedStudySessions = `-- name: DeleteUserArchivedStudySessions :execrows
+DELETE FROM study_sessions_archive
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserArchivedStudySessions(ctx context.Context, userID sql.NullString) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserArchivedStudySessions, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserDailyActivity = `-- name: DeleteUserDailyActivity :execrows
+DELETE FROM user_daily_activity
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserDailyActivity(ctx context.Context, userID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserDailyActivity, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserGuildAchievements = `-- name: DeleteUserGuildAchievements :execrows
+
+DELETE FROM user_achievements
+WHERE user_id = $1 AND guild_id = $2
+`
+
+type DeleteUserGuildAchievementsParams struct {
+	UserID  string `json:"userId"`
+	GuildID string `json:"guildId"`
+}
+
+// =============================================
+// Guild-Scoped Account Deletion Queries
+// =============================================
+func (q *Queries) DeleteUserGuildAchievements(ctx context.Context, arg DeleteUserGuildAchievementsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserGuildAchievements, arg.UserID, arg.GuildID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserGuildArchivedStudySessions = `-- name: DeleteUserGuildArchivedStudySessions :execrows
+DELETE FROM study_sessions_archive
+WHERE user_id = $1 AND guild_id = $2
+`
+
+type DeleteUserGuildArchivedStudySessionsParams struct {
+	UserID  sql.NullString `json:"userId"`
+	GuildID sql.NullString `json:"guildId"`
+}
+
+func (q *Queries) DeleteUserGuildArchivedStudySessions(ctx context.Context, arg DeleteUserGuildArchivedStudySessionsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserGuildArchivedStudySessions, arg.UserID, arg.GuildID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserGuildDailyActivity = `-- name: DeleteUserGuildDailyActivity :execrows
+DELETE FROM user_daily_activity
+WHERE user_id = $1 AND guild_id = $2
+`
+
+type DeleteUserGuildDailyActivityParams struct {
+	UserID  string `json:"userId"`
+	GuildID string `json:"guildId"`
+}
+
+func (q *Queries) DeleteUserGuildDailyActivity(ctx context.Context, arg DeleteUserGuildDailyActivityParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserGuildDailyActivity, arg.UserID, arg.GuildID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserGuildNotifications = `-- name: DeleteUserGuildNotifications :execrows
+DELETE FROM notifications_outbox
+WHERE user_id = $1 AND guild_id = $2
+`
+
+type DeleteUserGuildNotificationsParams struct {
+	UserID  sql.NullString `json:"userId"`
+	GuildID sql.NullString `json:"guildId"`
+}
+
+func (q *Queries) DeleteUserGuildNotifications(ctx context.Context, arg DeleteUserGuildNotificationsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserGuildNotifications, arg.UserID, arg.GuildID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserGuildReminderSettings = `-- name: DeleteUserGuildReminderSettings :execrows
+DELETE FROM user_reminder_settings
+WHERE user_id = $1 AND guild_id = $2
+`
+
+type DeleteUserGuildReminderSettingsParams struct {
+	UserID  string `json:"userId"`
+	GuildID string `json:"guildId"`
+}
+
+func (q *Queries) DeleteUserGuildReminderSettings(ctx context.Context, arg DeleteUserGuildReminderSettingsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserGuildReminderSettings, arg.UserID, arg.GuildID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserGuildStreakEvents = `-- name: DeleteUserGuildStreakEvents :execrows
+DELETE FROM streak_events
+WHERE user_id = $1 AND guild_id = $2
+`
+
+type DeleteUserGuildStreakEventsParams struct {
+	UserID  string `json:"userId"`
+	GuildID string `json:"guildId"`
+}
+
+func (q *Queries) DeleteUserGuildStreakEvents(ctx context.Context, arg DeleteUserGuildStreakEventsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserGuildStreakEvents, arg.UserID, arg.GuildID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserGuildStreaks = `-- name: DeleteUserGuildStreaks :execrows
+DELETE FROM user_streaks
+WHERE user_id = $1 AND guild_id = $2
+`
+
+type DeleteUserGuildStreaksParams struct {
+	UserID  string `json:"userId"`
+	GuildID string `json:"guildId"`
+}
+
+func (q *Queries) DeleteUserGuildStreaks(ctx context.Context, arg DeleteUserGuildStreaksParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserGuildStreaks, arg.UserID, arg.GuildID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserGuildStudySessions = `-- name: DeleteUserGuildStudySessions :execrows
+DELETE FROM study_sessions
+WHERE user_id = $1 AND guild_id = $2
+`
+
+type DeleteUserGuildStudySessionsParams struct {
+	UserID  sql.NullString `json:"userId"`
+	GuildID sql.NullString `json:"guildId"`
+}
+
+func (q *Queries) DeleteUserGuildStudySessions(ctx context.Context, arg DeleteUserGuildStudySessionsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserGuildStudySessions, arg.UserID, arg.GuildID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserNotificationPreferences = `-- name: DeleteUserNotificationPreferences :execrows
+DELETE FROM notification_preferences
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserNotificationPreferences(ctx context.Context, userID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserNotificationPreferences, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserNotifications = `-- name: DeleteUserNotifications :execrows
+DELETE FROM notifications_outbox
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserNotifications(ctx context.Context, userID sql.NullString) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserNotifications, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserRecapSubscriptions = `-- name: DeleteUserRecapSubscriptions :execrows
+DELETE FROM recap_subscriptions
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserRecapSubscriptions(ctx context.Context, userID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserRecapSubscriptions, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserReminderSettings = `-- name: DeleteUserReminderSettings :execrows
+DELETE FROM user_reminder_settings
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserReminderSettings(ctx context.Context, userID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserReminderSettings, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserStats = `-- name: DeleteUserStats :execrows
+DELETE FROM user_stats
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserStats(ctx context.Context, userID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserStats, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserStreakEvents = `-- name: DeleteUserStreakEvents :execrows
+DELETE FROM streak_events
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserStreakEvents(ctx context.Context, userID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserStreakEvents, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserStreaks = `-- name: DeleteUserStreaks :execrows
+DELETE FROM user_streaks
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserStreaks(ctx context.Context, userID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserStreaks, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserStudySessions = `-- name: DeleteUserStudySessions :execrows
+DELETE FROM study_sessions
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserStudySessions(ctx context.Context, userID sql.NullString) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserStudySessions, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const endStudySession = `-- name: EndStudySession :one
+UPDATE study_sessions
+SET end_time = $2, duration_ms = EXTRACT(EPOCH FROM ($2 - start_time)) * 1000
+WHERE session_id = $1 AND end_time IS NULL
+RETURNING session_id, user_id, start_time, end_time, duration_ms, is_manual, guild_id
+`
+
+type EndStudySessionParams struct {
+	SessionID int32        `json:"sessionId"`
+	EndTime   sql.NullTime `json:"endTime"`
+}
+
+func (q *Queries) EndStudySession(ctx context.Context, arg EndStudySessionParams) (StudySession, error) {
+	row := q.db.QueryRowContext(ctx, endStudySession, arg.SessionID, arg.EndTime)
+	var i StudySession
+	err := row.Scan(
+		&i.SessionID,
+		&i.UserID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.DurationMs,
+		&i.IsManual,
+		&i.GuildID,
+	)
+	return i, err
+}
+
+const enqueueNotification = `-- name: EnqueueNotification :execrows
+
+INSERT INTO notifications_outbox (dedupe_key, kind, guild_id, user_id, channel_id, payload, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, NOW()))
+ON CONFLICT (dedupe_key) DO NOTHING
+`
+
+type EnqueueNotificationParams struct {
+	DedupeKey     string          `json:"dedupeKey"`
+	Kind          string          `json:"kind"`
+	GuildID       sql.NullString  `json:"guildId"`
+	UserID        sql.NullString  `json:"userId"`
+	ChannelID     string          `json:"channelId"`
+	Payload       json.RawMessage `json:"payload"`
+	NextAttemptAt sql.NullTime    `json:"nextAttemptAt"`
+}
+
+// =============================================
+// Notification Outbox Queries
+// =============================================
+func (q *Queries) EnqueueNotification(ctx context.Context, arg EnqueueNotificationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enqueueNotification,
+		arg.DedupeKey,
+		arg.Kind,
+		arg.GuildID,
+		arg.UserID,
+		arg.ChannelID,
+		arg.Payload,
+		arg.NextAttemptAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAPITokenByHash = `-- name: GetAPITokenByHash :one
+SELECT id, guild_id, name, token_hash, created_by, created_at, last_used_at, revoked_at
+FROM api_tokens
+WHERE token_hash = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) GetAPITokenByHash(ctx context.Context, tokenHash string) (ApiToken, error) {
+	row := q.db.QueryRowContext(ctx, getAPITokenByHash, tokenHash)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.GuildID,
+		&i.Name,
+		&i.TokenHash,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getAchievementByID = `-- name: GetAchievementByID :one
+SELECT 
+    achievement_id,
+    name,
+    description,
+    icon,
+    category,
+    requirement_type,
+    requirement_value,
+    is_secret,
+    sort_order
+FROM achievements
+WHERE achievement_id = $1
+`
+
+type GetAchievementByIDRow struct {
+	AchievementID    string        `json:"achievementId"`
+	Name             string        `json:"name"`
 	Description      string        `json:"description"`
 	Icon             string        `json:"icon"`
 	Category         string        `json:"category"`
@@ -261,25 +977,858 @@ type GetAchievementsByCategoryRow struct {
 	SortOrder        sql.NullInt32 `json:"sortOrder"`
 }
 
-func (q *Queries) GetAchievementsByCategory(ctx context.Context, category string) ([]GetAchievementsByCategoryRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAchievementsByCategory, category)
+func (q *Queries) GetAchievementByID(ctx context.Context, achievementID string) (GetAchievementByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getAchievementByID, achievementID)
+	var i GetAchievementByIDRow
+	err := row.Scan(
+		&i.AchievementID,
+		&i.Name,
+		&i.Description,
+		&i.Icon,
+		&i.Category,
+		&i.RequirementType,
+		&i.RequirementValue,
+		&i.IsSecret,
+		&i.SortOrder,
+	)
+	return i, err
+}
+
+const getAchievementsByCategory = `-- name: GetAchievementsByCategory :many
+SELECT 
+    achievement_id,
+    name,
+    description,
+    icon,
+    category,
+    requirement_type,
+    requirement_value,
+    is_secret,
+    sort_order
+FROM achievements
+WHERE category = $1
+ORDER BY sort_order ASC
+`
+
+type GetAchievementsByCategoryRow struct {
+	AchievementID    string        `json:"achievementId"`
+	Name             string        `json:"name"`
+	Description      string        `json:"description"`
+	Icon             string        `json:"icon"`
+	Category         string        `json:"category"`
+	RequirementType  string        `json:"requirementType"`
+	RequirementValue int32         `json:"requirementValue"`
+	IsSecret         sql.NullBool  `json:"isSecret"`
+	SortOrder        sql.NullInt32 `json:"sortOrder"`
+}
+
+func (q *Queries) GetAchievementsByCategory(ctx context.Context, category string) ([]GetAchievementsByCategoryRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAchievementsByCategory, category)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAchievementsByCategoryRow
+	for rows.Next() {
+		var i GetAchievementsByCategoryRow
+		if err := rows.Scan(
+			&i.AchievementID,
+			&i.Name,
+			&i.Description,
+			&i.Icon,
+			&i.Category,
+			&i.RequirementType,
+			&i.RequirementValue,
+			&i.IsSecret,
+			&i.SortOrder,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAchievementsByRequirementType = `-- name: GetAchievementsByRequirementType :many
+SELECT 
+    achievement_id,
+    name,
+    description,
+    icon,
+    category,
+    requirement_type,
+    requirement_value,
+    is_secret,
+    sort_order
+FROM achievements
+WHERE requirement_type = $1
+ORDER BY requirement_value ASC
+`
+
+type GetAchievementsByRequirementTypeRow struct {
+	AchievementID    string        `json:"achievementId"`
+	Name             string        `json:"name"`
+	Description      string        `json:"description"`
+	Icon             string        `json:"icon"`
+	Category         string        `json:"category"`
+	RequirementType  string        `json:"requirementType"`
+	RequirementValue int32         `json:"requirementValue"`
+	IsSecret         sql.NullBool  `json:"isSecret"`
+	SortOrder        sql.NullInt32 `json:"sortOrder"`
+}
+
+func (q *Queries) GetAchievementsByRequirementType(ctx context.Context, requirementType string) ([]GetAchievementsByRequirementTypeRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAchievementsByRequirementType, requirementType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAchievementsByRequirementTypeRow
+	for rows.Next() {
+		var i GetAchievementsByRequirementTypeRow
+		if err := rows.Scan(
+			&i.AchievementID,
+			&i.Name,
+			&i.Description,
+			&i.Icon,
+			&i.Category,
+			&i.RequirementType,
+			&i.RequirementValue,
+			&i.IsSecret,
+			&i.SortOrder,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActiveStudySession = `-- name: GetActiveStudySession :one
+/* ACTIVE_SESSION_QUERY_1_PARAM */ SELECT session_id, user_id, start_time, end_time, duration_ms, is_manual, guild_id FROM study_sessions
+WHERE user_id = $1 AND end_time IS NULL
+ORDER BY start_time DESC
+LIMIT 1
+`
+
+func (q *Queries) GetActiveStudySession(ctx context.Context, userID sql.NullString) (StudySession, error) {
+	row := q.db.QueryRowContext(ctx, getActiveStudySession, userID)
+	var i StudySession
+	err := row.Scan(
+		&i.SessionID,
+		&i.UserID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.DurationMs,
+		&i.IsManual,
+		&i.GuildID,
+	)
+	return i, err
+}
+
+const getAllAchievements = `-- name: GetAllAchievements :many
+
+SELECT 
+    achievement_id,
+    name,
+    description,
+    icon,
+    category,
+    requirement_type,
+    requirement_value,
+    is_secret,
+    sort_order
+FROM achievements
+ORDER BY sort_order ASC
+`
+
+type GetAllAchievementsRow struct {
+	AchievementID    string        `json:"achievementId"`
+	Name             string        `json:"name"`
+	Description      string        `json:"description"`
+	Icon             string        `json:"icon"`
+	Category         string        `json:"category"`
+	RequirementType  string        `json:"requirementType"`
+	RequirementValue int32         `json:"requirementValue"`
+	IsSecret         sql.NullBool  `json:"isSecret"`
+	SortOrder        sql.NullInt32 `json:"sortOrder"`
+}
+
+// =============================================
+// Achievement System Queries
+// =============================================
+func (q *Queries) GetAllAchievements(ctx context.Context) ([]GetAllAchievementsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAllAchievements)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAllAchievementsRow
+	for rows.Next() {
+		var i GetAllAchievementsRow
+		if err := rows.Scan(
+			&i.AchievementID,
+			&i.Name,
+			&i.Description,
+			&i.Icon,
+			&i.Category,
+			&i.RequirementType,
+			&i.RequirementValue,
+			&i.IsSecret,
+			&i.SortOrder,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDailyActivity = `-- name: GetDailyActivity :many
+SELECT user_id, guild_id, activity_date, study_ms
+FROM user_daily_activity
+WHERE user_id = $1 AND guild_id = $2
+  AND activity_date BETWEEN $3 AND $4
+ORDER BY activity_date ASC
+`
+
+type GetDailyActivityParams struct {
+	UserID   string    `json:"userId"`
+	GuildID  string    `json:"guildId"`
+	FromDate time.Time `json:"fromDate"`
+	ToDate   time.Time `json:"toDate"`
+}
+
+func (q *Queries) GetDailyActivity(ctx context.Context, arg GetDailyActivityParams) ([]UserDailyActivity, error) {
+	rows, err := q.db.QueryContext(ctx, getDailyActivity,
+		arg.UserID,
+		arg.GuildID,
+		arg.FromDate,
+		arg.ToDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserDailyActivity
+	for rows.Next() {
+		var i UserDailyActivity
+		if err := rows.Scan(
+			&i.UserID,
+			&i.GuildID,
+			&i.ActivityDate,
+			&i.StudyMs,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDueNotifications = `-- name: GetDueNotifications :many
+SELECT id, dedupe_key, kind, guild_id, user_id, channel_id, payload, attempts, next_attempt_at, last_error, sent_at, created_at
+FROM notifications_outbox
+WHERE sent_at IS NULL AND next_attempt_at <= $1 AND attempts < $2
+ORDER BY next_attempt_at ASC, id ASC
+LIMIT $3
+`
+
+type GetDueNotificationsParams struct {
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	Attempts      int32     `json:"attempts"`
+	Limit         int32     `json:"limit"`
+}
+
+func (q *Queries) GetDueNotifications(ctx context.Context, arg GetDueNotificationsParams) ([]NotificationsOutbox, error) {
+	rows, err := q.db.QueryContext(ctx, getDueNotifications, arg.NextAttemptAt, arg.Attempts, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotificationsOutbox
+	for rows.Next() {
+		var i NotificationsOutbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.DedupeKey,
+			&i.Kind,
+			&i.GuildID,
+			&i.UserID,
+			&i.ChannelID,
+			&i.Payload,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.SentAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGuildAPITokens = `-- name: GetGuildAPITokens :many
+SELECT id, guild_id, name, token_hash, created_by, created_at, last_used_at, revoked_at
+FROM api_tokens
+WHERE guild_id = $1 AND revoked_at IS NULL
+ORDER BY created_at, id
+`
+
+func (q *Queries) GetGuildAPITokens(ctx context.Context, guildID string) ([]ApiToken, error) {
+	rows, err := q.db.QueryContext(ctx, getGuildAPITokens, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiToken
+	for rows.Next() {
+		var i ApiToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.GuildID,
+			&i.Name,
+			&i.TokenHash,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGuildAchievementsEarnedBetween = `-- name: GetGuildAchievementsEarnedBetween :many
+SELECT ua.user_id, ua.achievement_id, a.name, a.icon
+FROM user_achievements ua
+JOIN achievements a ON a.achievement_id = ua.achievement_id
+WHERE ua.guild_id = $1
+  AND ua.earned_at >= $2 AND ua.earned_at < $3
+ORDER BY ua.earned_at, ua.user_id
+`
+
+type GetGuildAchievementsEarnedBetweenParams struct {
+	GuildID  string       `json:"guildId"`
+	FromTime sql.NullTime `json:"fromTime"`
+	ToTime   sql.NullTime `json:"toTime"`
+}
+
+type GetGuildAchievementsEarnedBetweenRow struct {
+	UserID        string `json:"userId"`
+	AchievementID string `json:"achievementId"`
+	Name          string `json:"name"`
+	Icon          string `json:"icon"`
+}
+
+func (q *Queries) GetGuildAchievementsEarnedBetween(ctx context.Context, arg GetGuildAchievementsEarnedBetweenParams) ([]GetGuildAchievementsEarnedBetweenRow, error) {
+	rows, err := q.db.QueryContext(ctx, getGuildAchievementsEarnedBetween, arg.GuildID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGuildAchievementsEarnedBetweenRow
+	for rows.Next() {
+		var i GetGuildAchievementsEarnedBetweenRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.AchievementID,
+			&i.Name,
+			&i.Icon,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGuildAuditLog = `-- name: GetGuildAuditLog :many
+SELECT id, guild_id, actor_id, target_user_id, action, reason, details, created_at
+FROM audit_log
+WHERE guild_id = $1
+  AND ($2::TEXT IS NULL OR actor_id = $2 OR target_user_id = $2)
+  AND ($3::TEXT IS NULL OR action = $3)
+  AND ($4::TIMESTAMPTZ IS NULL OR created_at >= $4)
+  AND ($5::TIMESTAMPTZ IS NULL OR created_at < $5)
+ORDER BY created_at DESC, id DESC
+LIMIT $6
+`
+
+type GetGuildAuditLogParams struct {
+	GuildID  sql.NullString `json:"guildId"`
+	UserID   sql.NullString `json:"userId"`
+	Action   sql.NullString `json:"action"`
+	FromTime sql.NullTime   `json:"fromTime"`
+	ToTime   sql.NullTime   `json:"toTime"`
+	PageSize int32          `json:"pageSize"`
+}
+
+func (q *Queries) GetGuildAuditLog(ctx context.Context, arg GetGuildAuditLogParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, getGuildAuditLog,
+		arg.GuildID,
+		arg.UserID,
+		arg.Action,
+		arg.FromTime,
+		arg.ToTime,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.GuildID,
+			&i.ActorID,
+			&i.TargetUserID,
+			&i.Action,
+			&i.Reason,
+			&i.Details,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGuildDailyTotals = `-- name: GetGuildDailyTotals :many
+
+SELECT activity_date, SUM(study_ms)::BIGINT AS study_ms, COUNT(*) FILTER (WHERE study_ms > 0) AS members
+FROM user_daily_activity
+WHERE guild_id = $1
+  AND activity_date >= $2 AND activity_date < $3
+GROUP BY activity_date
+ORDER BY activity_date
+`
+
+type GetGuildDailyTotalsParams struct {
+	GuildID  string    `json:"guildId"`
+	FromDate time.Time `json:"fromDate"`
+	ToDate   time.Time `json:"toDate"`
+}
+
+type GetGuildDailyTotalsRow struct {
+	ActivityDate time.Time `json:"activityDate"`
+	StudyMs      int64     `json:"studyMs"`
+	Members      int64     `json:"members"`
+}
+
+// =============================================
+// Dashboard Queries
+// =============================================
+func (q *Queries) GetGuildDailyTotals(ctx context.Context, arg GetGuildDailyTotalsParams) ([]GetGuildDailyTotalsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getGuildDailyTotals, arg.GuildID, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGuildDailyTotalsRow
+	for rows.Next() {
+		var i GetGuildDailyTotalsRow
+		if err := rows.Scan(&i.ActivityDate, &i.StudyMs, &i.Members); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGuildEvaluationDates = `-- name: GetGuildEvaluationDates :many
+
+SELECT DISTINCT s.guild_id, e.last_evaluated_date
+FROM user_streaks s
+LEFT JOIN streak_evaluations e ON e.guild_id = s.guild_id
+ORDER BY s.guild_id
+`
+
+type GetGuildEvaluationDatesRow struct {
+	GuildID           string       `json:"guildId"`
+	LastEvaluatedDate sql.NullTime `json:"lastEvaluatedDate"`
+}
+
+// =============================================
+// Streak Evaluation Queries
+// =============================================
+func (q *Queries) GetGuildEvaluationDates(ctx context.Context) ([]GetGuildEvaluationDatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getGuildEvaluationDates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGuildEvaluationDatesRow
+	for rows.Next() {
+		var i GetGuildEvaluationDatesRow
+		if err := rows.Scan(&i.GuildID, &i.LastEvaluatedDate); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGuildLeaderboard = `-- name: GetGuildLeaderboard :many
+
+SELECT a.user_id, u.username, SUM(a.study_ms)::BIGINT AS study_ms
+FROM user_daily_activity a
+LEFT JOIN users u ON u.user_id = a.user_id
+WHERE a.guild_id = $1
+  AND a.activity_date >= $2 AND a.activity_date < $3
+GROUP BY a.user_id, u.username
+HAVING SUM(a.study_ms) > 0
+ORDER BY study_ms DESC, a.user_id
+LIMIT $4 OFFSET $5
+`
+
+type GetGuildLeaderboardParams struct {
+	GuildID    string    `json:"guildId"`
+	FromDate   time.Time `json:"fromDate"`
+	ToDate     time.Time `json:"toDate"`
+	PageSize   int32     `json:"pageSize"`
+	PageOffset int32     `json:"pageOffset"`
+}
+
+type GetGuildLeaderboardRow struct {
+	UserID   string         `json:"userId"`
+	Username sql.NullString `json:"username"`
+	StudyMs  int64          `json:"studyMs"`
+}
+
+// =============================================
+// REST API Queries
+// =============================================
+func (q *Queries) GetGuildLeaderboard(ctx context.Context, arg GetGuildLeaderboardParams) ([]GetGuildLeaderboardRow, error) {
+	rows, err := q.db.QueryContext(ctx, getGuildLeaderboard,
+		arg.GuildID,
+		arg.FromDate,
+		arg.ToDate,
+		arg.PageSize,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGuildLeaderboardRow
+	for rows.Next() {
+		var i GetGuildLeaderboardRow
+		if err := rows.Scan(&i.UserID, &i.Username, &i.StudyMs); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGuildRoleCapabilities = `-- name: GetGuildRoleCapabilities :many
+
+SELECT guild_id, role_id, capability, created_at
+FROM guild_role_capabilities
+WHERE guild_id = $1
+ORDER BY capability, role_id
+`
+
+// =============================================
+// Permission Queries
+// =============================================
+func (q *Queries) GetGuildRoleCapabilities(ctx context.Context, guildID string) ([]GuildRoleCapability, error) {
+	rows, err := q.db.QueryContext(ctx, getGuildRoleCapabilities, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GuildRoleCapability
+	for rows.Next() {
+		var i GuildRoleCapability
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.RoleID,
+			&i.Capability,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGuildSettings = `-- name: GetGuildSettings :one
+
+SELECT guild_id, reminder_minutes, updated_at, streak_mode, weekly_goal_minutes, streak_repair, recap_channel_id
+FROM guild_settings
+WHERE guild_id = $1
+`
+
+// =============================================
+// Reminder Queries
+// =============================================
+func (q *Queries) GetGuildSettings(ctx context.Context, guildID string) (GuildSetting, error) {
+	row := q.db.QueryRowContext(ctx, getGuildSettings, guildID)
+	var i GuildSetting
+	err := row.Scan(
+		&i.GuildID,
+		pq.Array(&i.ReminderMinutes),
+		&i.UpdatedAt,
+		&i.StreakMode,
+		&i.WeeklyGoalMinutes,
+		&i.StreakRepair,
+		&i.RecapChannelID,
+	)
+	return i, err
+}
+
+const getGuildStudyTotals = `-- name: GetGuildStudyTotals :many
+
+SELECT guild_id, user_id, SUM(study_ms)::BIGINT AS study_ms
+FROM user_daily_activity
+WHERE activity_date >= $1 AND activity_date < $2
+GROUP BY guild_id, user_id
+ORDER BY guild_id, study_ms DESC, user_id
+`
+
+type GetGuildStudyTotalsParams struct {
+	FromDate time.Time `json:"fromDate"`
+	ToDate   time.Time `json:"toDate"`
+}
+
+type GetGuildStudyTotalsRow struct {
+	GuildID string `json:"guildId"`
+	UserID  string `json:"userId"`
+	StudyMs int64  `json:"studyMs"`
+}
+
+// =============================================
+// Recap Queries
+// =============================================
+func (q *Queries) GetGuildStudyTotals(ctx context.Context, arg GetGuildStudyTotalsParams) ([]GetGuildStudyTotalsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getGuildStudyTotals, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGuildStudyTotalsRow
+	for rows.Next() {
+		var i GetGuildStudyTotalsRow
+		if err := rows.Scan(&i.GuildID, &i.UserID, &i.StudyMs); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGuildTopStreaks = `-- name: GetGuildTopStreaks :many
+SELECT user_id, current_streak_count
+FROM user_streaks
+WHERE guild_id = $1 AND current_streak_count > 0
+ORDER BY current_streak_count DESC, user_id
+LIMIT $2
+`
+
+type GetGuildTopStreaksParams struct {
+	GuildID string `json:"guildId"`
+	Limit   int32  `json:"limit"`
+}
+
+type GetGuildTopStreaksRow struct {
+	UserID             string `json:"userId"`
+	CurrentStreakCount int32  `json:"currentStreakCount"`
+}
+
+func (q *Queries) GetGuildTopStreaks(ctx context.Context, arg GetGuildTopStreaksParams) ([]GetGuildTopStreaksRow, error) {
+	rows, err := q.db.QueryContext(ctx, getGuildTopStreaks, arg.GuildID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGuildTopStreaksRow
+	for rows.Next() {
+		var i GetGuildTopStreaksRow
+		if err := rows.Scan(&i.UserID, &i.CurrentStreakCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLeaderboard = `-- name: GetLeaderboard :many
+SELECT
+    u.username,
+    us.total_study_ms,
+    u.user_id -- Also select user_id for mentions
+FROM
+    user_stats us
+JOIN
+    users u ON us.user_id = u.user_id
+WHERE
+    us.total_study_ms > 0 -- Only show users who have studied
+ORDER BY
+    us.total_study_ms DESC
+LIMIT 10
+`
+
+type GetLeaderboardRow struct {
+	Username     sql.NullString `json:"username"`
+	TotalStudyMs sql.NullInt64  `json:"totalStudyMs"`
+	UserID       string         `json:"userId"`
+}
+
+func (q *Queries) GetLeaderboard(ctx context.Context) ([]GetLeaderboardRow, error) {
+	rows, err := q.db.QueryContext(ctx, getLeaderboard)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLeaderboardRow
+	for rows.Next() {
+		var i GetLeaderboardRow
+		if err := rows.Scan(&i.Username, &i.TotalStudyMs, &i.UserID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLiveStatusMessage = `-- name: GetLiveStatusMessage :one
+SELECT guild_id, channel_id, message_id, created_at
+FROM live_status_messages
+WHERE guild_id = $1
+`
+
+func (q *Queries) GetLiveStatusMessage(ctx context.Context, guildID string) (LiveStatusMessage, error) {
+	row := q.db.QueryRowContext(ctx, getLiveStatusMessage, guildID)
+	var i LiveStatusMessage
+	err := row.Scan(
+		&i.GuildID,
+		&i.ChannelID,
+		&i.MessageID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLiveStatusMessages = `-- name: GetLiveStatusMessages :many
+SELECT guild_id, channel_id, message_id, created_at
+FROM live_status_messages
+ORDER BY guild_id
+`
+
+func (q *Queries) GetLiveStatusMessages(ctx context.Context) ([]LiveStatusMessage, error) {
+	rows, err := q.db.QueryContext(ctx, getLiveStatusMessages)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetAchievementsByCategoryRow
+	var items []LiveStatusMessage
 	for rows.Next() {
-		var i GetAchievementsByCategoryRow
+		var i LiveStatusMessage
 		if err := rows.Scan(
-			&i.AchievementID,
-			&i.Name,
-			&i.Description,
-			&i.Icon,
-			&i.Category,
-			&i.RequirementType,
-			&i.RequirementValue,
-			&i.IsSecret,
-			&i.SortOrder,
+			&i.GuildID,
+			&i.ChannelID,
+			&i.MessageID,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -294,53 +1843,74 @@ func (q *Queries) GetAchievementsByCategory(ctx context.Context, category string
 	return items, nil
 }
 
-const getAchievementsByRequirementType = `-- name: GetAchievementsByRequirementType :many
-SELECT 
-    achievement_id,
-    name,
-    description,
-    icon,
-    category,
-    requirement_type,
-    requirement_value,
-    is_secret,
-    sort_order
-FROM achievements
-WHERE requirement_type = $1
-ORDER BY requirement_value ASC
+const getNotificationPreferences = `-- name: GetNotificationPreferences :one
+
+SELECT user_id, streak_warnings, daily_complete, achievements, session_summaries, quiet_start_hour, quiet_end_hour, updated_at
+FROM notification_preferences
+WHERE user_id = $1
 `
 
-type GetAchievementsByRequirementTypeRow struct {
-	AchievementID    string        `json:"achievementId"`
-	Name             string        `json:"name"`
-	Description      string        `json:"description"`
-	Icon             string        `json:"icon"`
-	Category         string        `json:"category"`
-	RequirementType  string        `json:"requirementType"`
-	RequirementValue int32         `json:"requirementValue"`
-	IsSecret         sql.NullBool  `json:"isSecret"`
-	SortOrder        sql.NullInt32 `json:"sortOrder"`
+// =============================================
+// Notification Preference Queries
+// =============================================
+func (q *Queries) GetNotificationPreferences(ctx context.Context, userID string) (NotificationPreference, error) {
+	row := q.db.QueryRowContext(ctx, getNotificationPreferences, userID)
+	var i NotificationPreference
+	err := row.Scan(
+		&i.UserID,
+		&i.StreakWarnings,
+		&i.DailyComplete,
+		&i.Achievements,
+		&i.SessionSummaries,
+		&i.QuietStartHour,
+		&i.QuietEndHour,
+		&i.UpdatedAt,
+	)
+	return i, err
 }
 
-func (q *Queries) GetAchievementsByRequirementType(ctx context.Context, requirementType string) ([]GetAchievementsByRequirementTypeRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAchievementsByRequirementType, requirementType)
+const getPastStreaks = `-- name: GetPastStreaks :many
+SELECT e.id, e.user_id, e.guild_id, e.event_date, e.event_type, e.streak_count, e.created_at
+FROM streak_events e
+WHERE e.user_id = $1 AND e.guild_id = $2 AND e.event_type = 'break'
+  AND NOT EXISTS (
+    SELECT 1 FROM streak_events r
+    WHERE r.user_id = e.user_id AND r.guild_id = e.guild_id
+      AND r.event_type = 'repair' AND r.event_date > e.event_date
+      AND NOT EXISTS (
+        SELECT 1 FROM streak_events b
+        WHERE b.user_id = e.user_id AND b.guild_id = e.guild_id
+          AND b.event_type = 'break' AND b.event_date > e.event_date AND b.event_date < r.event_date
+      )
+  )
+ORDER BY e.event_date DESC
+LIMIT $3
+`
+
+type GetPastStreaksParams struct {
+	UserID  string `json:"userId"`
+	GuildID string `json:"guildId"`
+	Limit   int32  `json:"limit"`
+}
+
+// A break a later repair undid is not an ended streak
+func (q *Queries) GetPastStreaks(ctx context.Context, arg GetPastStreaksParams) ([]StreakEvent, error) {
+	rows, err := q.db.QueryContext(ctx, getPastStreaks, arg.UserID, arg.GuildID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetAchievementsByRequirementTypeRow
+	var items []StreakEvent
 	for rows.Next() {
-		var i GetAchievementsByRequirementTypeRow
+		var i StreakEvent
 		if err := rows.Scan(
-			&i.AchievementID,
-			&i.Name,
-			&i.Description,
-			&i.Icon,
-			&i.Category,
-			&i.RequirementType,
-			&i.RequirementValue,
-			&i.IsSecret,
-			&i.SortOrder,
+			&i.ID,
+			&i.UserID,
+			&i.GuildID,
+			&i.EventDate,
+			&i.EventType,
+			&i.StreakCount,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -355,76 +1925,96 @@ func (q *Queries) GetAchievementsByRequirementType(ctx context.Context, requirem
 	return items, nil
 }
 
-const getActiveStudySession = `-- name: GetActiveStudySession :one
-/* ACTIVE_SESSION_QUERY_1_PARAM */ SELECT session_id, user_id, start_time, end_time, duration_ms FROM study_sessions
-WHERE user_id = $1 AND end_time IS NULL
-ORDER BY start_time DESC
-LIMIT 1
+const getRecap = `-- name: GetRecap :one
+SELECT id, guild_id, period, period_start, period_end, payload, created_at
+FROM recaps
+WHERE guild_id = $1 AND period = $2
+ORDER BY period_start DESC
+LIMIT 1 OFFSET $3
 `
 
-func (q *Queries) GetActiveStudySession(ctx context.Context, userID sql.NullString) (StudySession, error) {
-	row := q.db.QueryRowContext(ctx, getActiveStudySession, userID)
-	var i StudySession
+type GetRecapParams struct {
+	GuildID string `json:"guildId"`
+	Period  string `json:"period"`
+	Offset  int32  `json:"offset"`
+}
+
+func (q *Queries) GetRecap(ctx context.Context, arg GetRecapParams) (Recap, error) {
+	row := q.db.QueryRowContext(ctx, getRecap, arg.GuildID, arg.Period, arg.Offset)
+	var i Recap
 	err := row.Scan(
-		&i.SessionID,
-		&i.UserID,
-		&i.StartTime,
-		&i.EndTime,
-		&i.DurationMs,
+		&i.ID,
+		&i.GuildID,
+		&i.Period,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.Payload,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getAllAchievements = `-- name: GetAllAchievements :many
+const getRecapSubscribers = `-- name: GetRecapSubscribers :many
+SELECT user_id
+FROM recap_subscriptions
+WHERE guild_id = $1
+ORDER BY user_id
+`
 
-SELECT 
-    achievement_id,
-    name,
-    description,
-    icon,
-    category,
-    requirement_type,
-    requirement_value,
-    is_secret,
-    sort_order
-FROM achievements
-ORDER BY sort_order ASC
+func (q *Queries) GetRecapSubscribers(ctx context.Context, guildID string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getRecapSubscribers, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var user_id string
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecapsMentioningUser = `-- name: GetRecapsMentioningUser :many
+SELECT id, guild_id, period, period_start, period_end, payload, created_at
+FROM recaps
+WHERE jsonb_path_exists(payload, '$.** ? (@.userId == $uid)', jsonb_build_object('uid', $1::TEXT))
+  AND ($2::TEXT IS NULL OR guild_id = $2)
+ORDER BY id
 `
 
-type GetAllAchievementsRow struct {
-	AchievementID    string        `json:"achievementId"`
-	Name             string        `json:"name"`
-	Description      string        `json:"description"`
-	Icon             string        `json:"icon"`
-	Category         string        `json:"category"`
-	RequirementType  string        `json:"requirementType"`
-	RequirementValue int32         `json:"requirementValue"`
-	IsSecret         sql.NullBool  `json:"isSecret"`
-	SortOrder        sql.NullInt32 `json:"sortOrder"`
+type GetRecapsMentioningUserParams struct {
+	UserID  string         `json:"userId"`
+	GuildID sql.NullString `json:"guildId"`
 }
 
-// =============================================
-// Achievement System Queries
-// =============================================
-func (q *Queries) GetAllAchievements(ctx context.Context) ([]GetAllAchievementsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAllAchievements)
+// Archived recaps that list the user anywhere in their payload, optionally in one guild only
+func (q *Queries) GetRecapsMentioningUser(ctx context.Context, arg GetRecapsMentioningUserParams) ([]Recap, error) {
+	rows, err := q.db.QueryContext(ctx, getRecapsMentioningUser, arg.UserID, arg.GuildID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetAllAchievementsRow
+	var items []Recap
 	for rows.Next() {
-		var i GetAllAchievementsRow
+		var i Recap
 		if err := rows.Scan(
-			&i.AchievementID,
-			&i.Name,
-			&i.Description,
-			&i.Icon,
-			&i.Category,
-			&i.RequirementType,
-			&i.RequirementValue,
-			&i.IsSecret,
-			&i.SortOrder,
+			&i.ID,
+			&i.GuildID,
+			&i.Period,
+			&i.PeriodStart,
+			&i.PeriodEnd,
+			&i.Payload,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -439,38 +2029,44 @@ func (q *Queries) GetAllAchievements(ctx context.Context) ([]GetAllAchievementsR
 	return items, nil
 }
 
-const getLeaderboard = `-- name: GetLeaderboard :many
-SELECT
-    u.username,
-    us.total_study_ms,
-    u.user_id -- Also select user_id for mentions
-FROM
-    user_stats us
-JOIN
-    users u ON us.user_id = u.user_id
-WHERE
-    us.total_study_ms > 0 -- Only show users who have studied
-ORDER BY
-    us.total_study_ms DESC
-LIMIT 10
+const getStreakEvents = `-- name: GetStreakEvents :many
+SELECT id, user_id, guild_id, event_date, event_type, streak_count, created_at
+FROM streak_events
+WHERE user_id = $1 AND guild_id = $2
+  AND event_date BETWEEN $3 AND $4
+ORDER BY event_date ASC
 `
 
-type GetLeaderboardRow struct {
-	Username     sql.NullString `json:"username"`
-	TotalStudyMs sql.NullInt64  `json:"totalStudyMs"`
-	UserID       string         `json:"userId"`
+type GetStreakEventsParams struct {
+	UserID   string    `json:"userId"`
+	GuildID  string    `json:"guildId"`
+	FromDate time.Time `json:"fromDate"`
+	ToDate   time.Time `json:"toDate"`
 }
 
-func (q *Queries) GetLeaderboard(ctx context.Context) ([]GetLeaderboardRow, error) {
-	rows, err := q.db.QueryContext(ctx, getLeaderboard)
+func (q *Queries) GetStreakEvents(ctx context.Context, arg GetStreakEventsParams) ([]StreakEvent, error) {
+	rows, err := q.db.QueryContext(ctx, getStreakEvents,
+		arg.UserID,
+		arg.GuildID,
+		arg.FromDate,
+		arg.ToDate,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetLeaderboardRow
+	var items []StreakEvent
 	for rows.Next() {
-		var i GetLeaderboardRow
-		if err := rows.Scan(&i.Username, &i.TotalStudyMs, &i.UserID); err != nil {
+		var i StreakEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.GuildID,
+			&i.EventDate,
+			&i.EventType,
+			&i.StreakCount,
+			&i.CreatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -498,7 +2094,7 @@ func (q *Queries) GetTotalAchievementCount(ctx context.Context) (int64, error) {
 const getUniqueStudyHours = `-- name: GetUniqueStudyHours :one
 SELECT COUNT(DISTINCT EXTRACT(HOUR FROM start_time AT TIME ZONE 'Asia/Manila'))::integer
 FROM study_sessions
-WHERE user_id = $1
+WHERE user_id = $1 AND NOT is_manual
 `
 
 func (q *Queries) GetUniqueStudyHours(ctx context.Context, userID sql.NullString) (int32, error) {
@@ -672,32 +2268,152 @@ func (q *Queries) GetUserAchievements(ctx context.Context, arg GetUserAchievemen
 	return items, nil
 }
 
-const getUserFeaturedBadge = `-- name: GetUserFeaturedBadge :one
-SELECT 
-    u.featured_badge,
-    a.name,
-    a.description,
-    a.icon
-FROM users u
-LEFT JOIN achievements a ON u.featured_badge = a.achievement_id
-WHERE u.user_id = $1
+const getUserFeaturedBadge = `-- name: GetUserFeaturedBadge :one
+SELECT 
+    u.featured_badge,
+    a.name,
+    a.description,
+    a.icon
+FROM users u
+LEFT JOIN achievements a ON u.featured_badge = a.achievement_id
+WHERE u.user_id = $1
+`
+
+type GetUserFeaturedBadgeRow struct {
+	FeaturedBadge sql.NullString `json:"featuredBadge"`
+	Name          sql.NullString `json:"name"`
+	Description   sql.NullString `json:"description"`
+	Icon          sql.NullString `json:"icon"`
+}
+
+func (q *Queries) GetUserFeaturedBadge(ctx context.Context, userID string) (GetUserFeaturedBadgeRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserFeaturedBadge, userID)
+	var i GetUserFeaturedBadgeRow
+	err := row.Scan(
+		&i.FeaturedBadge,
+		&i.Name,
+		&i.Description,
+		&i.Icon,
+	)
+	return i, err
+}
+
+const getUserGuildStudySessions = `-- name: GetUserGuildStudySessions :many
+SELECT session_id, user_id, start_time, end_time, duration_ms, is_manual, guild_id
+FROM study_sessions
+WHERE user_id = $1 AND guild_id = $2
+ORDER BY start_time DESC, session_id DESC
+LIMIT $3 OFFSET $4
+`
+
+type GetUserGuildStudySessionsParams struct {
+	UserID     sql.NullString `json:"userId"`
+	GuildID    sql.NullString `json:"guildId"`
+	PageSize   int32          `json:"pageSize"`
+	PageOffset int32          `json:"pageOffset"`
+}
+
+func (q *Queries) GetUserGuildStudySessions(ctx context.Context, arg GetUserGuildStudySessionsParams) ([]StudySession, error) {
+	rows, err := q.db.QueryContext(ctx, getUserGuildStudySessions,
+		arg.UserID,
+		arg.GuildID,
+		arg.PageSize,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StudySession
+	for rows.Next() {
+		var i StudySession
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.UserID,
+			&i.StartTime,
+			&i.EndTime,
+			&i.DurationMs,
+			&i.IsManual,
+			&i.GuildID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserGuildStudyTotals = `-- name: GetUserGuildStudyTotals :one
+SELECT
+    COALESCE(SUM(study_ms), 0)::BIGINT AS total_ms,
+    COALESCE(SUM(study_ms) FILTER (WHERE activity_date >= $1), 0)::BIGINT AS month_ms,
+    COALESCE(SUM(study_ms) FILTER (WHERE activity_date >= $2), 0)::BIGINT AS week_ms,
+    COALESCE(SUM(study_ms) FILTER (WHERE activity_date >= $3), 0)::BIGINT AS today_ms,
+    COUNT(*) FILTER (WHERE study_ms > 0) AS active_days
+FROM user_daily_activity
+WHERE user_id = $4 AND guild_id = $5
+`
+
+type GetUserGuildStudyTotalsParams struct {
+	MonthStart time.Time `json:"monthStart"`
+	WeekStart  time.Time `json:"weekStart"`
+	Today      time.Time `json:"today"`
+	UserID     string    `json:"userId"`
+	GuildID    string    `json:"guildId"`
+}
+
+type GetUserGuildStudyTotalsRow struct {
+	TotalMs    int64 `json:"totalMs"`
+	MonthMs    int64 `json:"monthMs"`
+	WeekMs     int64 `json:"weekMs"`
+	TodayMs    int64 `json:"todayMs"`
+	ActiveDays int64 `json:"activeDays"`
+}
+
+func (q *Queries) GetUserGuildStudyTotals(ctx context.Context, arg GetUserGuildStudyTotalsParams) (GetUserGuildStudyTotalsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserGuildStudyTotals,
+		arg.MonthStart,
+		arg.WeekStart,
+		arg.Today,
+		arg.UserID,
+		arg.GuildID,
+	)
+	var i GetUserGuildStudyTotalsRow
+	err := row.Scan(
+		&i.TotalMs,
+		&i.MonthMs,
+		&i.WeekMs,
+		&i.TodayMs,
+		&i.ActiveDays,
+	)
+	return i, err
+}
+
+const getUserReminderSettings = `-- name: GetUserReminderSettings :one
+SELECT user_id, guild_id, reminder_minutes, updated_at
+FROM user_reminder_settings
+WHERE user_id = $1 AND guild_id = $2
 `
 
-type GetUserFeaturedBadgeRow struct {
-	FeaturedBadge sql.NullString `json:"featuredBadge"`
-	Name          sql.NullString `json:"name"`
-	Description   sql.NullString `json:"description"`
-	Icon          sql.NullString `json:"icon"`
+type GetUserReminderSettingsParams struct {
+	UserID  string `json:"userId"`
+	GuildID string `json:"guildId"`
 }
 
-func (q *Queries) GetUserFeaturedBadge(ctx context.Context, userID string) (GetUserFeaturedBadgeRow, error) {
-	row := q.db.QueryRowContext(ctx, getUserFeaturedBadge, userID)
-	var i GetUserFeaturedBadgeRow
+func (q *Queries) GetUserReminderSettings(ctx context.Context, arg GetUserReminderSettingsParams) (UserReminderSetting, error) {
+	row := q.db.QueryRowContext(ctx, getUserReminderSettings, arg.UserID, arg.GuildID)
+	var i UserReminderSetting
 	err := row.Scan(
-		&i.FeaturedBadge,
-		&i.Name,
-		&i.Description,
-		&i.Icon,
+		&i.UserID,
+		&i.GuildID,
+		pq.Array(&i.ReminderMinutes),
+		&i.UpdatedAt,
 	)
 	return i, err
 }
@@ -738,7 +2454,9 @@ SELECT
     streak_incremented_today,
     warning_notified_at,
     created_at,
-    updated_at
+    updated_at,
+    previous_streak,
+    streak_broken_at
 FROM user_streaks
 WHERE user_id = $1 AND guild_id = $2
 `
@@ -761,6 +2479,8 @@ type GetUserStreakRow struct {
 	WarningNotifiedAt      sql.NullTime  `json:"warningNotifiedAt"`
 	CreatedAt              time.Time     `json:"createdAt"`
 	UpdatedAt              time.Time     `json:"updatedAt"`
+	PreviousStreak         int32         `json:"previousStreak"`
+	StreakBrokenAt         sql.NullTime  `json:"streakBrokenAt"`
 }
 
 // Calendar Day-Based User Streaks Queries
@@ -780,6 +2500,8 @@ func (q *Queries) GetUserStreak(ctx context.Context, arg GetUserStreakParams) (G
 		&i.WarningNotifiedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PreviousStreak,
+		&i.StreakBrokenAt,
 	)
 	return i, err
 }
@@ -795,12 +2517,20 @@ SELECT
     daily_activity_minutes,
     warning_notified_at,
     created_at,
-    updated_at
+    updated_at,
+    streak_mode,
+    previous_streak,
+    streak_broken_at
 FROM user_streaks
-WHERE streak_evaluated_date IS NULL 
-   OR streak_evaluated_date < $1
+WHERE guild_id = $1
+  AND (streak_evaluated_date IS NULL OR streak_evaluated_date < $2)
 `
 
+type GetUsersForDailyEvaluationParams struct {
+	GuildID             string       `json:"guildId"`
+	StreakEvaluatedDate sql.NullTime `json:"streakEvaluatedDate"`
+}
+
 type GetUsersForDailyEvaluationRow struct {
 	UserID               string        `json:"userId"`
 	GuildID              string        `json:"guildId"`
@@ -812,10 +2542,13 @@ type GetUsersForDailyEvaluationRow struct {
 	WarningNotifiedAt    sql.NullTime  `json:"warningNotifiedAt"`
 	CreatedAt            time.Time     `json:"createdAt"`
 	UpdatedAt            time.Time     `json:"updatedAt"`
+	StreakMode           string        `json:"streakMode"`
+	PreviousStreak       int32         `json:"previousStreak"`
+	StreakBrokenAt       sql.NullTime  `json:"streakBrokenAt"`
 }
 
-func (q *Queries) GetUsersForDailyEvaluation(ctx context.Context, streakEvaluatedDate sql.NullTime) ([]GetUsersForDailyEvaluationRow, error) {
-	rows, err := q.db.QueryContext(ctx, getUsersForDailyEvaluation, streakEvaluatedDate)
+func (q *Queries) GetUsersForDailyEvaluation(ctx context.Context, arg GetUsersForDailyEvaluationParams) ([]GetUsersForDailyEvaluationRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersForDailyEvaluation, arg.GuildID, arg.StreakEvaluatedDate)
 	if err != nil {
 		return nil, err
 	}
@@ -834,6 +2567,9 @@ func (q *Queries) GetUsersForDailyEvaluation(ctx context.Context, streakEvaluate
 			&i.WarningNotifiedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.StreakMode,
+			&i.PreviousStreak,
+			&i.StreakBrokenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -848,63 +2584,34 @@ func (q *Queries) GetUsersForDailyEvaluation(ctx context.Context, streakEvaluate
 	return items, nil
 }
 
-const getUsersForStreakReset = `-- name: GetUsersForStreakReset :many
+const getUsersNeedingWarnings = `-- name: GetUsersNeedingWarnings :many
 SELECT 
-    user_id, 
-    guild_id, 
-    current_streak_count
-FROM user_streaks
-WHERE current_streak_count > 0
-  AND streak_incremented_today = FALSE
-  AND (last_activity_date IS NULL OR last_activity_date < $1)
+    s.user_id, 
+    s.guild_id, 
+    s.current_streak_count, 
+    s.max_streak_count, 
+    s.last_activity_date,
+    s.daily_activity_minutes,
+    s.warning_notified_at,
+    s.created_at,
+    s.updated_at,
+    s.streak_mode,
+    g.reminder_minutes AS guild_reminder_minutes, -- NULL when the guild never set any
+    r.reminder_minutes AS user_reminder_minutes -- NULL unless the user set their own
+FROM user_streaks s
+LEFT JOIN guild_settings g ON g.guild_id = s.guild_id
+LEFT JOIN user_reminder_settings r ON r.user_id = s.user_id AND r.guild_id = s.guild_id
+WHERE s.current_streak_count > 0
+  AND (s.last_activity_date IS NULL OR s.last_activity_date < $1 -- Haven't been active today
+       OR COALESCE(s.daily_activity_minutes, 0) < $2::int -- Or haven't done enough yet
+       OR s.streak_mode = 'weekly')
 `
 
-type GetUsersForStreakResetRow struct {
-	UserID             string `json:"userId"`
-	GuildID            string `json:"guildId"`
-	CurrentStreakCount int32  `json:"currentStreakCount"`
-}
-
-func (q *Queries) GetUsersForStreakReset(ctx context.Context, lastActivityDate sql.NullTime) ([]GetUsersForStreakResetRow, error) {
-	rows, err := q.db.QueryContext(ctx, getUsersForStreakReset, lastActivityDate)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetUsersForStreakResetRow
-	for rows.Next() {
-		var i GetUsersForStreakResetRow
-		if err := rows.Scan(&i.UserID, &i.GuildID, &i.CurrentStreakCount); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type GetUsersNeedingWarningsParams struct {
+	Today      sql.NullTime `json:"today"`
+	MinMinutes int32        `json:"minMinutes"`
 }
 
-const getUsersNeedingWarnings = `-- name: GetUsersNeedingWarnings :many
-SELECT 
-    user_id, 
-    guild_id, 
-    current_streak_count, 
-    max_streak_count, 
-    last_activity_date,
-    daily_activity_minutes,
-    warning_notified_at,
-    created_at,
-    updated_at
-FROM user_streaks
-WHERE current_streak_count > 0
-  AND (last_activity_date IS NULL OR last_activity_date < $1) -- Haven't been active today
-  AND (warning_notified_at IS NULL OR DATE(warning_notified_at) < $1)
-`
-
 type GetUsersNeedingWarningsRow struct {
 	UserID               string        `json:"userId"`
 	GuildID              string        `json:"guildId"`
@@ -915,10 +2622,13 @@ type GetUsersNeedingWarningsRow struct {
 	WarningNotifiedAt    sql.NullTime  `json:"warningNotifiedAt"`
 	CreatedAt            time.Time     `json:"createdAt"`
 	UpdatedAt            time.Time     `json:"updatedAt"`
+	StreakMode           string        `json:"streakMode"`
+	GuildReminderMinutes []int32       `json:"guildReminderMinutes"`
+	UserReminderMinutes  []int32       `json:"userReminderMinutes"`
 }
 
-func (q *Queries) GetUsersNeedingWarnings(ctx context.Context, lastActivityDate sql.NullTime) ([]GetUsersNeedingWarningsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getUsersNeedingWarnings, lastActivityDate)
+func (q *Queries) GetUsersNeedingWarnings(ctx context.Context, arg GetUsersNeedingWarningsParams) ([]GetUsersNeedingWarningsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersNeedingWarnings, arg.Today, arg.MinMinutes)
 	if err != nil {
 		return nil, err
 	}
@@ -936,6 +2646,9 @@ func (q *Queries) GetUsersNeedingWarnings(ctx context.Context, lastActivityDate
 			&i.WarningNotifiedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.StreakMode,
+			pq.Array(&i.GuildReminderMinutes),
+			pq.Array(&i.UserReminderMinutes),
 		); err != nil {
 			return nil, err
 		}
@@ -950,6 +2663,57 @@ func (q *Queries) GetUsersNeedingWarnings(ctx context.Context, lastActivityDate
 	return items, nil
 }
 
+const getUsersWithUnnotifiedAchievements = `-- name: GetUsersWithUnnotifiedAchievements :many
+SELECT DISTINCT user_id, guild_id
+FROM user_achievements
+WHERE notified = FALSE
+`
+
+type GetUsersWithUnnotifiedAchievementsRow struct {
+	UserID  string `json:"userId"`
+	GuildID string `json:"guildId"`
+}
+
+func (q *Queries) GetUsersWithUnnotifiedAchievements(ctx context.Context) ([]GetUsersWithUnnotifiedAchievementsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersWithUnnotifiedAchievements)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUsersWithUnnotifiedAchievementsRow
+	for rows.Next() {
+		var i GetUsersWithUnnotifiedAchievementsRow
+		if err := rows.Scan(&i.UserID, &i.GuildID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const grantRoleCapability = `-- name: GrantRoleCapability :exec
+INSERT INTO guild_role_capabilities (guild_id, role_id, capability)
+VALUES ($1, $2, $3)
+ON CONFLICT (guild_id, role_id, capability) DO NOTHING
+`
+
+type GrantRoleCapabilityParams struct {
+	GuildID    string `json:"guildId"`
+	RoleID     string `json:"roleId"`
+	Capability string `json:"capability"`
+}
+
+func (q *Queries) GrantRoleCapability(ctx context.Context, arg GrantRoleCapabilityParams) error {
+	_, err := q.db.ExecContext(ctx, grantRoleCapability, arg.GuildID, arg.RoleID, arg.Capability)
+	return err
+}
+
 const hasAchievement = `-- name: HasAchievement :one
 SELECT EXISTS(
     SELECT 1 FROM user_achievements 
@@ -1007,7 +2771,7 @@ SELECT EXISTS(
     SELECT DATE(start_time AT TIME ZONE 'Asia/Manila') as study_date,
            SUM(EXTRACT(EPOCH FROM COALESCE(end_time, NOW()) - start_time)) / 3600 as hours
     FROM study_sessions
-    WHERE user_id = $1
+    WHERE user_id = $1 AND NOT is_manual
     GROUP BY study_date
     HAVING SUM(EXTRACT(EPOCH FROM COALESCE(end_time, NOW()) - start_time)) / 3600 >= 12
   ) as daily_hours
@@ -1038,15 +2802,54 @@ func (q *Queries) MarkAchievementNotified(ctx context.Context, arg MarkAchieveme
 	return err
 }
 
-const resetAllStreakDailyFlags = `-- name: ResetAllStreakDailyFlags :exec
-UPDATE user_streaks
-SET 
-    streak_incremented_today = FALSE,
-    updated_at = NOW()
+const markNotificationFailed = `-- name: MarkNotificationFailed :exec
+UPDATE notifications_outbox
+SET attempts = attempts + 1, next_attempt_at = $2, last_error = $3
+WHERE id = $1
+`
+
+type MarkNotificationFailedParams struct {
+	ID            int64          `json:"id"`
+	NextAttemptAt time.Time      `json:"nextAttemptAt"`
+	LastError     sql.NullString `json:"lastError"`
+}
+
+func (q *Queries) MarkNotificationFailed(ctx context.Context, arg MarkNotificationFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markNotificationFailed, arg.ID, arg.NextAttemptAt, arg.LastError)
+	return err
+}
+
+const markNotificationSent = `-- name: MarkNotificationSent :exec
+UPDATE notifications_outbox
+SET sent_at = $2, last_error = NULL
+WHERE id = $1
+`
+
+type MarkNotificationSentParams struct {
+	ID     int64        `json:"id"`
+	SentAt sql.NullTime `json:"sentAt"`
+}
+
+func (q *Queries) MarkNotificationSent(ctx context.Context, arg MarkNotificationSentParams) error {
+	_, err := q.db.ExecContext(ctx, markNotificationSent, arg.ID, arg.SentAt)
+	return err
+}
+
+const rescheduleNotification = `-- name: RescheduleNotification :exec
+
+UPDATE notifications_outbox
+SET next_attempt_at = $2
+WHERE id = $1
 `
 
-func (q *Queries) ResetAllStreakDailyFlags(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, resetAllStreakDailyFlags)
+type RescheduleNotificationParams struct {
+	ID            int64     `json:"id"`
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+}
+
+// Used when Discord rate limits us: the attempt doesn't count against the retry budget
+func (q *Queries) RescheduleNotification(ctx context.Context, arg RescheduleNotificationParams) error {
+	_, err := q.db.ExecContext(ctx, rescheduleNotification, arg.ID, arg.NextAttemptAt)
 	return err
 }
 
@@ -1071,7 +2874,6 @@ func (q *Queries) ResetMonthlyStudyTime(ctx context.Context) error {
 }
 
 const resetUserStreakCount = `-- name: ResetUserStreakCount :exec
-
 UPDATE user_streaks
 SET 
     current_streak_count = 0,
@@ -1084,7 +2886,6 @@ type ResetUserStreakCountParams struct {
 	GuildID string `json:"guildId"`
 }
 
-// Haven't been active today
 func (q *Queries) ResetUserStreakCount(ctx context.Context, arg ResetUserStreakCountParams) error {
 	_, err := q.db.ExecContext(ctx, resetUserStreakCount, arg.UserID, arg.GuildID)
 	return err
@@ -1100,6 +2901,44 @@ func (q *Queries) ResetWeeklyStudyTime(ctx context.Context) error {
 	return err
 }
 
+const revokeAPIToken = `-- name: RevokeAPIToken :execrows
+UPDATE api_tokens
+SET revoked_at = NOW()
+WHERE guild_id = $1 AND id = $2 AND revoked_at IS NULL
+`
+
+type RevokeAPITokenParams struct {
+	GuildID string `json:"guildId"`
+	ID      int64  `json:"id"`
+}
+
+func (q *Queries) RevokeAPIToken(ctx context.Context, arg RevokeAPITokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeAPIToken, arg.GuildID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeRoleCapability = `-- name: RevokeRoleCapability :execrows
+DELETE FROM guild_role_capabilities
+WHERE guild_id = $1 AND role_id = $2 AND capability = $3
+`
+
+type RevokeRoleCapabilityParams struct {
+	GuildID    string `json:"guildId"`
+	RoleID     string `json:"roleId"`
+	Capability string `json:"capability"`
+}
+
+func (q *Queries) RevokeRoleCapability(ctx context.Context, arg RevokeRoleCapabilityParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeRoleCapability, arg.GuildID, arg.RoleID, arg.Capability)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const setFeaturedBadge = `-- name: SetFeaturedBadge :exec
 UPDATE users
 SET featured_badge = $2
@@ -1116,6 +2955,52 @@ func (q *Queries) SetFeaturedBadge(ctx context.Context, arg SetFeaturedBadgePara
 	return err
 }
 
+const setGuildLastEvaluatedDate = `-- name: SetGuildLastEvaluatedDate :exec
+INSERT INTO streak_evaluations (guild_id, last_evaluated_date)
+VALUES ($1, $2)
+ON CONFLICT (guild_id) DO UPDATE SET
+    last_evaluated_date = GREATEST(streak_evaluations.last_evaluated_date, EXCLUDED.last_evaluated_date),
+    updated_at = NOW()
+`
+
+type SetGuildLastEvaluatedDateParams struct {
+	GuildID           string    `json:"guildId"`
+	LastEvaluatedDate time.Time `json:"lastEvaluatedDate"`
+}
+
+func (q *Queries) SetGuildLastEvaluatedDate(ctx context.Context, arg SetGuildLastEvaluatedDateParams) error {
+	_, err := q.db.ExecContext(ctx, setGuildLastEvaluatedDate, arg.GuildID, arg.LastEvaluatedDate)
+	return err
+}
+
+const setUserStreakMode = `-- name: SetUserStreakMode :exec
+
+UPDATE user_streaks
+SET
+    streak_mode = $3,
+    current_streak_count = $4,
+    updated_at = NOW()
+WHERE user_id = $1 AND guild_id = $2
+`
+
+type SetUserStreakModeParams struct {
+	UserID             string `json:"userId"`
+	GuildID            string `json:"guildId"`
+	StreakMode         string `json:"streakMode"`
+	CurrentStreakCount int32  `json:"currentStreakCount"`
+}
+
+// Weekly goals depend on the whole week
+func (q *Queries) SetUserStreakMode(ctx context.Context, arg SetUserStreakModeParams) error {
+	_, err := q.db.ExecContext(ctx, setUserStreakMode,
+		arg.UserID,
+		arg.GuildID,
+		arg.StreakMode,
+		arg.CurrentStreakCount,
+	)
+	return err
+}
+
 const startDailyActivity = `-- name: StartDailyActivity :one
 INSERT INTO user_streaks (
     user_id, 
@@ -1198,6 +3083,19 @@ func (q *Queries) StartDailyActivity(ctx context.Context, arg StartDailyActivity
 	return i, err
 }
 
+const touchAPIToken = `-- name: TouchAPIToken :exec
+
+UPDATE api_tokens
+SET last_used_at = NOW()
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+`
+
+// Written at most once a minute per token, so busy clients don't turn every read into a write
+func (q *Queries) TouchAPIToken(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, touchAPIToken, id)
+	return err
+}
+
 const updateDailyActivityMinutes = `-- name: UpdateDailyActivityMinutes :exec
 UPDATE user_streaks
 SET 
@@ -1217,6 +3115,48 @@ func (q *Queries) UpdateDailyActivityMinutes(ctx context.Context, arg UpdateDail
 	return err
 }
 
+const updateRecapPayload = `-- name: UpdateRecapPayload :exec
+UPDATE recaps
+SET payload = $2
+WHERE id = $1
+`
+
+type UpdateRecapPayloadParams struct {
+	ID      int64           `json:"id"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func (q *Queries) UpdateRecapPayload(ctx context.Context, arg UpdateRecapPayloadParams) error {
+	_, err := q.db.ExecContext(ctx, updateRecapPayload, arg.ID, arg.Payload)
+	return err
+}
+
+const updateStreakBreak = `-- name: UpdateStreakBreak :exec
+UPDATE user_streaks
+SET
+    previous_streak = $3,
+    streak_broken_at = $4,
+    updated_at = NOW()
+WHERE user_id = $1 AND guild_id = $2
+`
+
+type UpdateStreakBreakParams struct {
+	UserID         string       `json:"userId"`
+	GuildID        string       `json:"guildId"`
+	PreviousStreak int32        `json:"previousStreak"`
+	StreakBrokenAt sql.NullTime `json:"streakBrokenAt"`
+}
+
+func (q *Queries) UpdateStreakBreak(ctx context.Context, arg UpdateStreakBreakParams) error {
+	_, err := q.db.ExecContext(ctx, updateStreakBreak,
+		arg.UserID,
+		arg.GuildID,
+		arg.PreviousStreak,
+		arg.StreakBrokenAt,
+	)
+	return err
+}
+
 const updateStreakImmediately = `-- name: UpdateStreakImmediately :exec
 UPDATE user_streaks
 SET 
@@ -1251,8 +3191,10 @@ SET
     current_streak_count = $3,
     max_streak_count = GREATEST(max_streak_count, $4),
     streak_evaluated_date = $5,
+    streak_incremented_today = FALSE,
     updated_at = NOW()
 WHERE user_id = $1 AND guild_id = $2
+  AND (streak_evaluated_date IS NULL OR streak_evaluated_date < $5)
 RETURNING user_id, guild_id, current_streak_count, max_streak_count, last_activity_date, streak_evaluated_date, daily_activity_minutes, activity_start_time, warning_notified_at, created_at, updated_at
 `
 
@@ -1278,7 +3220,7 @@ type UpdateUserStreakAfterEvaluationRow struct {
 	UpdatedAt            time.Time     `json:"updatedAt"`
 }
 
-// $1 is today's date in Manila timezone
+// $2 is the Manila date being evaluated
 func (q *Queries) UpdateUserStreakAfterEvaluation(ctx context.Context, arg UpdateUserStreakAfterEvaluationParams) (UpdateUserStreakAfterEvaluationRow, error) {
 	row := q.db.QueryRowContext(ctx, updateUserStreakAfterEvaluation,
 		arg.UserID,
@@ -1305,7 +3247,6 @@ func (q *Queries) UpdateUserStreakAfterEvaluation(ctx context.Context, arg Updat
 }
 
 const updateWarningNotifiedAt = `-- name: UpdateWarningNotifiedAt :exec
-
 UPDATE user_streaks
 SET 
     warning_notified_at = $3,
@@ -1319,8 +3260,149 @@ type UpdateWarningNotifiedAtParams struct {
 	WarningNotifiedAt sql.NullTime `json:"warningNotifiedAt"`
 }
 
-// Haven't been warned today
 func (q *Queries) UpdateWarningNotifiedAt(ctx context.Context, arg UpdateWarningNotifiedAtParams) error {
 	_, err := q.db.ExecContext(ctx, updateWarningNotifiedAt, arg.UserID, arg.GuildID, arg.WarningNotifiedAt)
 	return err
 }
+
+const upsertGuildRecapChannel = `-- name: UpsertGuildRecapChannel :exec
+INSERT INTO guild_settings (guild_id, recap_channel_id)
+VALUES ($1, $2)
+ON CONFLICT (guild_id) DO UPDATE SET
+    recap_channel_id = EXCLUDED.recap_channel_id,
+    updated_at = NOW()
+`
+
+type UpsertGuildRecapChannelParams struct {
+	GuildID        string         `json:"guildId"`
+	RecapChannelID sql.NullString `json:"recapChannelId"`
+}
+
+func (q *Queries) UpsertGuildRecapChannel(ctx context.Context, arg UpsertGuildRecapChannelParams) error {
+	_, err := q.db.ExecContext(ctx, upsertGuildRecapChannel, arg.GuildID, arg.RecapChannelID)
+	return err
+}
+
+const upsertGuildReminderMinutes = `-- name: UpsertGuildReminderMinutes :exec
+INSERT INTO guild_settings (guild_id, reminder_minutes)
+VALUES ($1, $2)
+ON CONFLICT (guild_id) DO UPDATE SET
+    reminder_minutes = EXCLUDED.reminder_minutes,
+    updated_at = NOW()
+`
+
+type UpsertGuildReminderMinutesParams struct {
+	GuildID         string  `json:"guildId"`
+	ReminderMinutes []int32 `json:"reminderMinutes"`
+}
+
+func (q *Queries) UpsertGuildReminderMinutes(ctx context.Context, arg UpsertGuildReminderMinutesParams) error {
+	_, err := q.db.ExecContext(ctx, upsertGuildReminderMinutes, arg.GuildID, pq.Array(arg.ReminderMinutes))
+	return err
+}
+
+const upsertGuildStreakMode = `-- name: UpsertGuildStreakMode :exec
+INSERT INTO guild_settings (guild_id, streak_mode, weekly_goal_minutes, streak_repair)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (guild_id) DO UPDATE SET
+    streak_mode = EXCLUDED.streak_mode,
+    weekly_goal_minutes = EXCLUDED.weekly_goal_minutes,
+    streak_repair = EXCLUDED.streak_repair,
+    updated_at = NOW()
+`
+
+type UpsertGuildStreakModeParams struct {
+	GuildID           string `json:"guildId"`
+	StreakMode        string `json:"streakMode"`
+	WeeklyGoalMinutes int32  `json:"weeklyGoalMinutes"`
+	StreakRepair      bool   `json:"streakRepair"`
+}
+
+func (q *Queries) UpsertGuildStreakMode(ctx context.Context, arg UpsertGuildStreakModeParams) error {
+	_, err := q.db.ExecContext(ctx, upsertGuildStreakMode,
+		arg.GuildID,
+		arg.StreakMode,
+		arg.WeeklyGoalMinutes,
+		arg.StreakRepair,
+	)
+	return err
+}
+
+const upsertLiveStatusMessage = `-- name: UpsertLiveStatusMessage :exec
+
+INSERT INTO live_status_messages (guild_id, channel_id, message_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (guild_id) DO UPDATE SET
+    channel_id = EXCLUDED.channel_id,
+    message_id = EXCLUDED.message_id,
+    created_at = NOW()
+`
+
+type UpsertLiveStatusMessageParams struct {
+	GuildID   string `json:"guildId"`
+	ChannelID string `json:"channelId"`
+	MessageID string `json:"messageId"`
+}
+
+// =============================================
+// Live Status Message Queries
+// =============================================
+func (q *Queries) UpsertLiveStatusMessage(ctx context.Context, arg UpsertLiveStatusMessageParams) error {
+	_, err := q.db.ExecContext(ctx, upsertLiveStatusMessage, arg.GuildID, arg.ChannelID, arg.MessageID)
+	return err
+}
+
+const upsertNotificationPreferences = `-- name: UpsertNotificationPreferences :exec
+INSERT INTO notification_preferences (user_id, streak_warnings, daily_complete, achievements, session_summaries, quiet_start_hour, quiet_end_hour)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (user_id) DO UPDATE SET
+    streak_warnings = EXCLUDED.streak_warnings,
+    daily_complete = EXCLUDED.daily_complete,
+    achievements = EXCLUDED.achievements,
+    session_summaries = EXCLUDED.session_summaries,
+    quiet_start_hour = EXCLUDED.quiet_start_hour,
+    quiet_end_hour = EXCLUDED.quiet_end_hour,
+    updated_at = NOW()
+`
+
+type UpsertNotificationPreferencesParams struct {
+	UserID           string        `json:"userId"`
+	StreakWarnings   string        `json:"streakWarnings"`
+	DailyComplete    string        `json:"dailyComplete"`
+	Achievements     string        `json:"achievements"`
+	SessionSummaries string        `json:"sessionSummaries"`
+	QuietStartHour   sql.NullInt32 `json:"quietStartHour"`
+	QuietEndHour     sql.NullInt32 `json:"quietEndHour"`
+}
+
+func (q *Queries) UpsertNotificationPreferences(ctx context.Context, arg UpsertNotificationPreferencesParams) error {
+	_, err := q.db.ExecContext(ctx, upsertNotificationPreferences,
+		arg.UserID,
+		arg.StreakWarnings,
+		arg.DailyComplete,
+		arg.Achievements,
+		arg.SessionSummaries,
+		arg.QuietStartHour,
+		arg.QuietEndHour,
+	)
+	return err
+}
+
+const upsertUserReminderMinutes = `-- name: UpsertUserReminderMinutes :exec
+INSERT INTO user_reminder_settings (user_id, guild_id, reminder_minutes)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, guild_id) DO UPDATE SET
+    reminder_minutes = EXCLUDED.reminder_minutes,
+    updated_at = NOW()
+`
+
+type UpsertUserReminderMinutesParams struct {
+	UserID          string  `json:"userId"`
+	GuildID         string  `json:"guildId"`
+	ReminderMinutes []int32 `json:"reminderMinutes"`
+}
+
+func (q *Queries) UpsertUserReminderMinutes(ctx context.Context, arg UpsertUserReminderMinutesParams) error {
+	_, err := q.db.ExecContext(ctx, upsertUserReminderMinutes, arg.UserID, arg.GuildID, pq.Array(arg.ReminderMinutes))
+	return err
+}
@@ -0,0 +1,295 @@
+// Package fakediscord provides an in-memory discord.Session for tests.
+// It records everything the bot sends and never touches the network.
+package fakediscord
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/Skufu/LockIn-Bot/internal/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+// SentMessage is a message the bot posted to a channel
+type SentMessage struct {
+	ID        string
+	ChannelID string
+	Content   string
+	Embeds    []*discordgo.MessageEmbed
+}
+
+// Session is a fake Discord session. Users added with AddUser can be looked up,
+// sends and interaction responses are recorded, and Errors makes a call fail.
+type Session struct {
+	// State holds guilds and voice states, like the state cache of a real session
+	State *discordgo.State
+
+	mu        sync.Mutex
+	users     map[string]*discordgo.User
+	channels  map[string][]*discordgo.Channel
+	messages  []SentMessage
+	pinned    map[string]bool // Message IDs that are pinned
+	responses []*discordgo.InteractionResponse
+	commands  []*discordgo.ApplicationCommand
+	nextID    int
+	closed    bool
+
+	// Errors maps a method name (e.g. "ChannelMessageSend") to the error it should return
+	Errors map[string]error
+}
+
+var _ discord.Session = (*Session)(nil)
+
+// New creates an empty fake session
+func New() *Session {
+	return &Session{
+		State:    discordgo.NewState(),
+		users:    make(map[string]*discordgo.User),
+		channels: make(map[string][]*discordgo.Channel),
+		pinned:   make(map[string]bool),
+		Errors:   make(map[string]error),
+	}
+}
+
+// AddUser makes a user resolvable through User
+func (s *Session) AddUser(id, username string) *discordgo.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user := &discordgo.User{ID: id, Username: username}
+	s.users[id] = user
+	return user
+}
+
+// AddChannel adds a channel to a guild's channel list
+func (s *Session) AddChannel(guildID string, channel *discordgo.Channel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	channel.GuildID = guildID
+	s.channels[guildID] = append(s.channels[guildID], channel)
+}
+
+// SetVoiceState records that a user is in a voice channel, or removes them when channelID is empty
+func (s *Session) SetVoiceState(guildID, userID, channelID string) error {
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		if err := s.State.GuildAdd(&discordgo.Guild{ID: guildID}); err != nil {
+			return err
+		}
+		if guild, err = s.State.Guild(guildID); err != nil {
+			return err
+		}
+	}
+
+	s.State.Lock()
+	defer s.State.Unlock()
+
+	for i, vs := range guild.VoiceStates {
+		if vs.UserID == userID {
+			guild.VoiceStates = append(guild.VoiceStates[:i], guild.VoiceStates[i+1:]...)
+			break
+		}
+	}
+	if channelID != "" {
+		guild.VoiceStates = append(guild.VoiceStates, &discordgo.VoiceState{GuildID: guildID, UserID: userID, ChannelID: channelID})
+	}
+	return nil
+}
+
+// Messages returns a copy of every message sent so far
+func (s *Session) Messages() []SentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SentMessage(nil), s.messages...)
+}
+
+// DeleteMessage removes a sent message, as if someone deleted it in Discord
+func (s *Session) DeleteMessage(messageID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := s.messageIndex(messageID); i >= 0 {
+		s.messages = append(s.messages[:i], s.messages[i+1:]...)
+	}
+	delete(s.pinned, messageID)
+}
+
+// Pinned reports whether a message is currently pinned
+func (s *Session) Pinned(messageID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pinned[messageID]
+}
+
+func (s *Session) messageIndex(messageID string) int {
+	for i, m := range s.messages {
+		if m.ID == messageID {
+			return i
+		}
+	}
+	return -1
+}
+
+// unknownMessage mimics the error Discord returns for a message that no longer exists
+func unknownMessage(messageID string) error {
+	return &discordgo.RESTError{
+		Response: &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found"},
+		Message:  &discordgo.APIErrorMessage{Code: discordgo.ErrCodeUnknownMessage, Message: "Unknown Message " + messageID},
+	}
+}
+
+// Responses returns a copy of every interaction response sent so far
+func (s *Session) Responses() []*discordgo.InteractionResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*discordgo.InteractionResponse(nil), s.responses...)
+}
+
+// LastResponse returns the most recent interaction response, or nil if there is none
+func (s *Session) LastResponse() *discordgo.InteractionResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.responses) == 0 {
+		return nil
+	}
+	return s.responses[len(s.responses)-1]
+}
+
+// Commands returns the application commands that were registered
+func (s *Session) Commands() []*discordgo.ApplicationCommand {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*discordgo.ApplicationCommand(nil), s.commands...)
+}
+
+// Closed reports whether Close has been called
+func (s *Session) Closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Session) User(userID string, options ...discordgo.RequestOption) (*discordgo.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.Errors["User"]; err != nil {
+		return nil, err
+	}
+	user, ok := s.users[userID]
+	if !ok {
+		return nil, fmt.Errorf("fakediscord: unknown user %s", userID)
+	}
+	return user, nil
+}
+
+// UserChannelCreate returns the DM channel "dm-<userID>"; messages sent to it are recorded like any other
+func (s *Session) UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.Errors["UserChannelCreate"]; err != nil {
+		return nil, err
+	}
+	return &discordgo.Channel{ID: "dm-" + recipientID, Type: discordgo.ChannelTypeDM}, nil
+}
+
+func (s *Session) ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	return s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{Content: content}, options...)
+}
+
+func (s *Session) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.Errors["ChannelMessageSend"]; err != nil {
+		return nil, err
+	}
+	s.nextID++
+	id := fmt.Sprintf("message-%d", s.nextID)
+	s.messages = append(s.messages, SentMessage{ID: id, ChannelID: channelID, Content: data.Content, Embeds: data.Embeds})
+	return &discordgo.Message{
+		ID:        id,
+		ChannelID: channelID,
+		Content:   data.Content,
+		Embeds:    data.Embeds,
+	}, nil
+}
+
+func (s *Session) ChannelMessageEditComplex(m *discordgo.MessageEdit, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.Errors["ChannelMessageEdit"]; err != nil {
+		return nil, err
+	}
+	i := s.messageIndex(m.ID)
+	if i < 0 || s.messages[i].ChannelID != m.Channel {
+		return nil, unknownMessage(m.ID)
+	}
+	if m.Content != nil {
+		s.messages[i].Content = *m.Content
+	}
+	if m.Embeds != nil {
+		s.messages[i].Embeds = *m.Embeds
+	}
+	sent := s.messages[i]
+	return &discordgo.Message{ID: sent.ID, ChannelID: sent.ChannelID, Content: sent.Content, Embeds: sent.Embeds}, nil
+}
+
+func (s *Session) ChannelMessagePin(channelID, messageID string, options ...discordgo.RequestOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.Errors["ChannelMessagePin"]; err != nil {
+		return err
+	}
+	if s.messageIndex(messageID) < 0 {
+		return unknownMessage(messageID)
+	}
+	s.pinned[messageID] = true
+	return nil
+}
+
+func (s *Session) ChannelMessageUnpin(channelID, messageID string, options ...discordgo.RequestOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.Errors["ChannelMessageUnpin"]; err != nil {
+		return err
+	}
+	if s.messageIndex(messageID) < 0 {
+		return unknownMessage(messageID)
+	}
+	delete(s.pinned, messageID)
+	return nil
+}
+
+func (s *Session) GuildChannels(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Channel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.Errors["GuildChannels"]; err != nil {
+		return nil, err
+	}
+	return append([]*discordgo.Channel(nil), s.channels[guildID]...), nil
+}
+
+func (s *Session) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.Errors["InteractionRespond"]; err != nil {
+		return err
+	}
+	s.responses = append(s.responses, resp)
+	return nil
+}
+
+func (s *Session) ApplicationCommandCreate(appID string, guildID string, cmd *discordgo.ApplicationCommand, options ...discordgo.RequestOption) (*discordgo.ApplicationCommand, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.Errors["ApplicationCommandCreate"]; err != nil {
+		return nil, err
+	}
+	s.commands = append(s.commands, cmd)
+	return cmd, nil
+}
+
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
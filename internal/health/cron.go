@@ -0,0 +1,29 @@
+package health
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronGrace is how far past its next run time a job may be before the scheduler counts as stopped
+const cronGrace = time.Minute
+
+// CheckCron reports whether c is running its jobs. A running scheduler always has each job's next
+// run ahead of it; one that was never started or has stopped leaves them zero or in the past.
+func CheckCron(c *cron.Cron, now time.Time) Result {
+	entries := c.Entries()
+	if len(entries) == 0 {
+		return Down("no jobs scheduled")
+	}
+	for _, e := range entries {
+		if e.Next.IsZero() {
+			return Down("scheduler not started")
+		}
+		if late := now.Sub(e.Next); late > cronGrace {
+			return Down(fmt.Sprintf("job overdue by %s; scheduler stopped", late.Round(time.Second)))
+		}
+	}
+	return OK(fmt.Sprintf("%d jobs scheduled", len(entries)))
+}
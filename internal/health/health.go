@@ -0,0 +1,176 @@
+// Package health runs the bot's component checks and serves them on /livez and /readyz
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is the state of one component, or of the whole bot
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded" // Working, but slow or backed up
+	StatusDown     Status = "down"
+)
+
+// rank orders statuses from best to worst
+func (s Status) rank() int {
+	switch s {
+	case StatusOK:
+		return 0
+	case StatusDegraded:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Result is what a check found
+type Result struct {
+	Status Status `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// OK returns a healthy result with an optional detail, e.g. a latency
+func OK(detail string) Result { return Result{Status: StatusOK, Detail: detail} }
+
+// Degraded returns a result for a component that works but needs attention
+func Degraded(detail string) Result { return Result{Status: StatusDegraded, Detail: detail} }
+
+// Down returns a result for a component that isn't working
+func Down(detail string) Result { return Result{Status: StatusDown, Detail: detail} }
+
+// CheckFunc checks one component. It should return promptly once ctx is done.
+type CheckFunc func(ctx context.Context) Result
+
+// DefaultTimeout is how long a check may take before it is reported down
+const DefaultTimeout = 2 * time.Second
+
+// Checker holds the registered checks
+type Checker struct {
+	mu      sync.Mutex
+	checks  map[string]check
+	timeout time.Duration
+}
+
+type check struct {
+	fn       CheckFunc
+	liveness bool
+}
+
+// NewChecker creates a checker with no checks
+func NewChecker() *Checker {
+	return &Checker{checks: make(map[string]check), timeout: DefaultTimeout}
+}
+
+// AddReadiness registers a check that /readyz runs, replacing any check with the same name. Use it
+// for dependencies such as the database or the Discord gateway, whose outages a restart wouldn't fix.
+func (c *Checker) AddReadiness(name string, fn CheckFunc) {
+	c.add(name, check{fn: fn})
+}
+
+// AddLiveness registers a check that both /livez and /readyz run. Use it for the bot's own
+// workers, where failing means the process is wedged and should be restarted.
+func (c *Checker) AddLiveness(name string, fn CheckFunc) {
+	c.add(name, check{fn: fn, liveness: true})
+}
+
+func (c *Checker) add(name string, ch check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = ch
+}
+
+// Report is the JSON document served by /livez and /readyz
+type Report struct {
+	Status     Status            `json:"status"`
+	Service    string            `json:"service"`
+	CheckedAt  time.Time         `json:"checked_at"`
+	Components map[string]Result `json:"components"`
+}
+
+// Run runs the liveness checks, or every check if readiness is true, concurrently. The overall
+// status is the worst component status.
+func (c *Checker) Run(ctx context.Context, readiness bool) Report {
+	c.mu.Lock()
+	names := make([]string, 0, len(c.checks))
+	for name, ch := range c.checks {
+		if readiness || ch.liveness {
+			names = append(names, name)
+		}
+	}
+	checks := make([]check, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		checks[i] = c.checks[name]
+	}
+	timeout := c.timeout
+	c.mu.Unlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, ch := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(ctx, ch.fn, timeout)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Service: "lockin-bot", CheckedAt: time.Now().UTC(), Components: make(map[string]Result, len(names))}
+	for i, name := range names {
+		report.Components[name] = results[i]
+		if results[i].Status.rank() > report.Status.rank() {
+			report.Status = results[i].Status
+		}
+	}
+	return report
+}
+
+// runCheck runs fn, reporting it down if it doesn't finish within timeout
+func runCheck(ctx context.Context, fn CheckFunc, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan Result, 1)
+	go func() { done <- fn(ctx) }()
+	select {
+	case r := <-done:
+		return r
+	case <-ctx.Done():
+		return Down("check timed out after " + timeout.String())
+	}
+}
+
+// LivenessHandler serves /livez. It only fails when a component is down, so a worker that is
+// merely backed up doesn't get the process restarted.
+func (c *Checker) LivenessHandler() http.Handler {
+	return c.handler(false, StatusDown)
+}
+
+// ReadinessHandler serves /readyz. It fails as soon as any component is degraded.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return c.handler(true, StatusDegraded)
+}
+
+// handler writes the report with 503 when the overall status is failOn or worse and 200 otherwise
+func (c *Checker) handler(readiness bool, failOn Status) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Run(r.Context(), readiness)
+		code := http.StatusOK
+		if report.Status.rank() >= failOn.rank() {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
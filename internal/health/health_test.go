@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, h http.Handler) (int, Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var report Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	return rec.Code, report
+}
+
+func TestChecker_LivenessRunsOnlyLivenessChecks(t *testing.T) {
+	c := NewChecker()
+	c.AddReadiness("database", func(context.Context) Result { return Down("connection refused") })
+	c.AddLiveness("scheduler", func(context.Context) Result { return OK("4 jobs scheduled") })
+
+	code, report := serve(t, c.LivenessHandler())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Equal(t, map[string]Result{"scheduler": OK("4 jobs scheduled")}, report.Components)
+
+	code, report = serve(t, c.ReadinessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, Down("connection refused"), report.Components["database"])
+	assert.Len(t, report.Components, 2)
+}
+
+func TestChecker_DegradedIsNotReady(t *testing.T) {
+	c := NewChecker()
+	c.AddReadiness("discord", func(context.Context) Result { return Down("connecting to Discord") })
+	c.AddReadiness("discord", func(context.Context) Result { return Degraded("heartbeat latency 3s") })
+	c.AddLiveness("voice_queue", func(context.Context) Result { return OK("") })
+
+	code, report := serve(t, c.ReadinessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusDegraded, report.Status, "re-registering a name replaces its check")
+}
+
+func TestChecker_DegradedLivenessIsStillAlive(t *testing.T) {
+	c := NewChecker()
+	c.AddLiveness("voice_queue", func(context.Context) Result { return Degraded("12 voice events queued") })
+
+	code, report := serve(t, c.LivenessHandler())
+	assert.Equal(t, http.StatusOK, code, "a backed-up worker must not get the process restarted")
+	assert.Equal(t, StatusDegraded, report.Status)
+
+	code, _ = serve(t, c.ReadinessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	c.AddLiveness("voice_queue", func(context.Context) Result { return Down("worker stopped") })
+	code, _ = serve(t, c.LivenessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
+
+func TestChecker_SlowCheckTimesOut(t *testing.T) {
+	c := NewChecker()
+	c.timeout = 10 * time.Millisecond
+	c.AddLiveness("stuck", func(ctx context.Context) Result {
+		time.Sleep(time.Second)
+		return OK("")
+	})
+
+	report := c.Run(context.Background(), false)
+	assert.Equal(t, StatusDown, report.Components["stuck"].Status)
+	assert.Contains(t, report.Components["stuck"].Detail, "timed out")
+}
+
+func TestCheckCron(t *testing.T) {
+	c := cron.New()
+	assert.Equal(t, StatusDown, CheckCron(c, time.Now()).Status, "no jobs")
+
+	_, err := c.AddFunc("* * * * *", func() {})
+	require.NoError(t, err)
+	assert.Equal(t, Down("scheduler not started"), CheckCron(c, time.Now()))
+
+	c.Start()
+	assert.Equal(t, StatusOK, CheckCron(c, time.Now()).Status)
+
+	<-c.Stop().Done()
+	assert.Equal(t, StatusDown, CheckCron(c, time.Now().Add(5*time.Minute)).Status, "a stopped scheduler falls behind")
+}
@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_JSONIncludesEventIDFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", "debug")
+	require.NoError(t, err)
+
+	ctx := WithEventID(context.Background(), "abc123")
+	logger.With("user_id", "user-1").DebugContext(ctx, "Started study session", "session_id", int32(7))
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "DEBUG", record["level"])
+	assert.Equal(t, "Started study session", record["msg"])
+	assert.Equal(t, "abc123", record["event_id"])
+	assert.Equal(t, "user-1", record["user_id"])
+	assert.Equal(t, float64(7), record["session_id"])
+}
+
+func TestNew_FiltersBelowLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "text", "WARN")
+	require.NoError(t, err)
+
+	logger.Info("hidden")
+	assert.Empty(t, buf.String())
+	logger.Warn("shown")
+	assert.Contains(t, buf.String(), "msg=shown")
+	assert.NotContains(t, buf.String(), "event_id", "no event ID without one in the context")
+}
+
+func TestNew_RejectsUnknownSettings(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, "xml", "info")
+	assert.Error(t, err)
+	_, err = New(&bytes.Buffer{}, "json", "loud")
+	assert.Error(t, err)
+
+	lvl, err := ParseLevel("")
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelInfo, lvl)
+}
+
+func TestNewEventID_IsUnique(t *testing.T) {
+	a, b := NewEventID(), NewEventID()
+	assert.Len(t, a, 16)
+	assert.NotEqual(t, a, b)
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import "time"
+
+// Default is the registry served on /metrics. It only lives in this process; nothing is pushed.
+var Default = NewRegistry()
+
+// cronBuckets are histogram upper bounds in seconds for scheduled jobs
+var cronBuckets = []float64{.1, .5, 1, 5, 10, 30, 60, 300, 900}
+
+var (
+	// VoiceEvents counts voice state updates handled, including duplicates
+	VoiceEvents = Default.NewCounter("lockin_voice_events_total",
+		"Voice state updates received by the bot.")
+
+	// VoiceEventsDeduplicated counts voice state updates dropped as duplicates
+	VoiceEventsDeduplicated = Default.NewCounter("lockin_voice_events_deduplicated_total",
+		"Voice state updates skipped as duplicates of a recent one.")
+
+	// ActiveSessions reports the study sessions currently tracked in memory
+	ActiveSessions = Default.NewGaugeFunc("lockin_active_sessions",
+		"Study sessions currently in progress.")
+
+	// SessionsTimedOut counts sessions the timeout checker ended
+	SessionsTimedOut = Default.NewCounter("lockin_sessions_timed_out_total",
+		"Study sessions force-ended by the timeout checker.")
+
+	// DBQueryDuration times each database query by its sqlc method name
+	DBQueryDuration = Default.NewHistogramVec("lockin_db_query_duration_seconds",
+		"Database query latency by sqlc query name.", DefaultBuckets, "query")
+
+	// DiscordAPIErrors counts failed Discord REST API requests by HTTP status, or "network"
+	DiscordAPIErrors = Default.NewCounterVec("lockin_discord_api_errors_total",
+		"Discord REST API requests that failed, by HTTP status code.", "status")
+
+	// AchievementsAwarded counts badges earned by achievement ID
+	AchievementsAwarded = Default.NewCounterVec("lockin_achievements_awarded_total",
+		"Achievements awarded, by achievement ID.", "achievement")
+
+	// StreakEvaluations counts per-user streak evaluations by result: evaluated, skipped or failed
+	StreakEvaluations = Default.NewCounterVec("lockin_streak_evaluations_total",
+		"Per-user streak evaluations, by result.", "result")
+
+	// CronJobDuration times scheduled jobs by name
+	CronJobDuration = Default.NewHistogramVec("lockin_cron_job_duration_seconds",
+		"Scheduled job run time, by job.", cronBuckets, "job")
+
+	// CronJobFailures counts scheduled job runs that returned an error
+	CronJobFailures = Default.NewCounterVec("lockin_cron_job_failures_total",
+		"Scheduled job runs that failed, by job.", "job")
+)
+
+// RunJob runs a scheduled job, recording how long it took and whether it failed
+func RunJob(job string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	CronJobDuration.With(job).ObserveSince(start)
+	if err != nil {
+		CronJobFailures.With(job).Inc()
+	}
+	return err
+}
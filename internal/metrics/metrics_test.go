@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_WritesPrometheusText(t *testing.T) {
+	r := NewRegistry()
+	events := r.NewCounter("test_events_total", "Events seen.")
+	errs := r.NewCounterVec("test_errors_total", "Errors by status.", "status")
+	active := r.NewGaugeFunc("test_active", "Things in progress.")
+	latency := r.NewHistogramVec("test_latency_seconds", "Latency.", []float64{.1, 1}, "query")
+
+	events.Add(3)
+	errs.With("500").Inc()
+	errs.With("429").Add(2)
+	active.SetFunc(func() float64 { return 4 })
+	latency.With("GetUser").Observe(.05)
+	latency.With("GetUser").Observe(.5)
+	latency.With("GetUser").Observe(2)
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	want := `# HELP test_events_total Events seen.
+# TYPE test_events_total counter
+test_events_total 3
+# HELP test_errors_total Errors by status.
+# TYPE test_errors_total counter
+test_errors_total{status="429"} 2
+test_errors_total{status="500"} 1
+# HELP test_active Things in progress.
+# TYPE test_active gauge
+test_active 4
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{query="GetUser",le="0.1"} 1
+test_latency_seconds_bucket{query="GetUser",le="1"} 2
+test_latency_seconds_bucket{query="GetUser",le="+Inf"} 3
+test_latency_seconds_sum{query="GetUser"} 2.55
+test_latency_seconds_count{query="GetUser"} 3
+`
+	assert.Equal(t, want, rec.Body.String())
+}
+
+func TestRegistry_RejectsDuplicatesAndWrongLabels(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_total", "Test.", "a", "b")
+	assert.Panics(t, func() { r.NewCounter("test_total", "Again.") })
+	assert.Panics(t, func() { c.With("only-one") })
+	assert.Panics(t, func() { c.With("x", "y").Add(-1) })
+}
+
+func TestRegistry_EscapesLabelValues(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "Test.", "q").With(`say "hi"\n`).Inc()
+
+	var b strings.Builder
+	require.NoError(t, r.WriteText(&b))
+	assert.Contains(t, b.String(), `test_total{q="say \"hi\"\\n"} 1`)
+}
+
+func TestRunJob_RecordsDurationAndFailures(t *testing.T) {
+	before := CronJobFailures.With("test_job").Value()
+
+	require.NoError(t, RunJob("test_job", func() error { return nil }))
+	assert.Error(t, RunJob("test_job", func() error { return errors.New("boom") }))
+
+	assert.Equal(t, uint64(2), CronJobDuration.With("test_job").Count())
+	assert.Equal(t, before+1, CronJobFailures.With("test_job").Value())
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+)
+
+// AuditActionForgetUser is recorded in the audit log when a user's data is deleted
+const AuditActionForgetUser = "forget_user"
+
+// AccountService handles requests that affect a user's stored data as a whole
+type AccountService struct {
+	txManager database.TxManager
+}
+
+// NewAccountService creates a new AccountService
+func NewAccountService(txManager database.TxManager) *AccountService {
+	return &AccountService{
+		txManager: txManager,
+	}
+}
+
+// ForgetUserRequest describes who asked for which user's data to be deleted
+type ForgetUserRequest struct {
+	TargetUserID string
+	ActorID      string // The user who confirmed the deletion (same as TargetUserID for /forget-me)
+	GuildID      string // Guild the request was made from, for the audit trail
+
+	// GuildOnly limits the deletion to GuildID's rows, as when a server admin uses /forget-user.
+	// The user's global stats, account and notification preferences are kept.
+	GuildOnly bool
+}
+
+// ForgetUserResult reports how many rows were removed from each table
+type ForgetUserResult struct {
+	Achievements     int64 `json:"achievements"`
+	Streaks          int64 `json:"streaks"`
+	DailyActivity    int64 `json:"dailyActivity"`
+	StudySessions    int64 `json:"studySessions"`
+	ArchivedSessions int64 `json:"archivedSessions"`
+	Stats            int64 `json:"stats"`
+	Users            int64 `json:"users"`
+	Notifications    int64 `json:"notifications"`
+	Subscriptions    int64 `json:"subscriptions"`
+	Preferences      int64 `json:"preferences"`
+	Reminders        int64 `json:"reminders"`
+	StreakEvents     int64 `json:"streakEvents"`
+	Recaps           int64 `json:"recaps"` // Archived recaps the user was removed from
+}
+
+// Total returns the number of rows removed across all tables
+func (r ForgetUserResult) Total() int64 {
+	return r.Achievements + r.Streaks + r.DailyActivity + r.StudySessions + r.ArchivedSessions + r.Stats + r.Users + r.Notifications + r.Subscriptions + r.Preferences + r.Reminders + r.StreakEvents + r.Recaps
+}
+
+// ForgetUser deletes all of a user's rows, or only one guild's with GuildOnly, in a single transaction
+// and records an audit log entry. The audit entry keeps only the IDs involved and the row counts,
+// never the deleted data itself.
+func (s *AccountService) ForgetUser(ctx context.Context, req ForgetUserRequest) (ForgetUserResult, error) {
+	var result ForgetUserResult
+
+	if req.TargetUserID == "" {
+		return result, fmt.Errorf("target user ID is required")
+	}
+	if req.GuildOnly && req.GuildID == "" {
+		return result, fmt.Errorf("guild ID is required to delete one guild's data")
+	}
+
+	err := s.txManager.ExecTx(ctx, func(q database.Querier) error {
+		var err error
+		if req.GuildOnly {
+			result, err = forgetUserInGuild(ctx, q, req.TargetUserID, req.GuildID)
+		} else {
+			result, err = forgetUserEverywhere(ctx, q, req.TargetUserID)
+		}
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, AuditEntry{
+			GuildID:      req.GuildID,
+			ActorID:      req.ActorID,
+			TargetUserID: req.TargetUserID,
+			Action:       AuditActionForgetUser,
+			Details:      forgetUserDetails{ForgetUserResult: result, GuildOnly: req.GuildOnly},
+		})
+	})
+	if err != nil {
+		return ForgetUserResult{}, err
+	}
+
+	slog.InfoContext(ctx, "Deleted user data", "user_id", req.TargetUserID, "actor_id", req.ActorID,
+		"guild_id", req.GuildID, "guild_only", req.GuildOnly, "rows", result.Total())
+	return result, nil
+}
+
+// forgetUserDetails is the audit log entry's details for a deletion
+type forgetUserDetails struct {
+	ForgetUserResult
+	GuildOnly bool `json:"guildOnly,omitempty"`
+}
+
+// forgetUserEverywhere deletes every row stored for userID
+func forgetUserEverywhere(ctx context.Context, q database.Querier, userID string) (ForgetUserResult, error) {
+	var result ForgetUserResult
+	var err error
+	nullUserID := sql.NullString{String: userID, Valid: true}
+
+	// Children first: study_sessions and user_stats reference users(user_id)
+	if result.Achievements, err = q.DeleteUserAchievements(ctx, userID); err != nil {
+		return result, fmt.Errorf("failed to delete achievements: %w", err)
+	}
+	if result.Streaks, err = q.DeleteUserStreaks(ctx, userID); err != nil {
+		return result, fmt.Errorf("failed to delete streaks: %w", err)
+	}
+	if result.StreakEvents, err = q.DeleteUserStreakEvents(ctx, userID); err != nil {
+		return result, fmt.Errorf("failed to delete streak history: %w", err)
+	}
+	if result.DailyActivity, err = q.DeleteUserDailyActivity(ctx, userID); err != nil {
+		return result, fmt.Errorf("failed to delete daily activity: %w", err)
+	}
+	if result.StudySessions, err = q.DeleteUserStudySessions(ctx, nullUserID); err != nil {
+		return result, fmt.Errorf("failed to delete study sessions: %w", err)
+	}
+	if result.ArchivedSessions, err = q.DeleteUserArchivedStudySessions(ctx, nullUserID); err != nil {
+		return result, fmt.Errorf("failed to delete archived study sessions: %w", err)
+	}
+	if result.Stats, err = q.DeleteUserStats(ctx, userID); err != nil {
+		return result, fmt.Errorf("failed to delete stats: %w", err)
+	}
+	if result.Users, err = q.DeleteUser(ctx, userID); err != nil {
+		return result, fmt.Errorf("failed to delete user: %w", err)
+	}
+	// Queued messages mention the user, so unsent ones go too
+	if result.Notifications, err = q.DeleteUserNotifications(ctx, nullUserID); err != nil {
+		return result, fmt.Errorf("failed to delete queued notifications: %w", err)
+	}
+	if result.Subscriptions, err = q.DeleteUserRecapSubscriptions(ctx, userID); err != nil {
+		return result, fmt.Errorf("failed to delete recap subscriptions: %w", err)
+	}
+	if result.Preferences, err = q.DeleteUserNotificationPreferences(ctx, userID); err != nil {
+		return result, fmt.Errorf("failed to delete notification preferences: %w", err)
+	}
+	if result.Reminders, err = q.DeleteUserReminderSettings(ctx, userID); err != nil {
+		return result, fmt.Errorf("failed to delete reminder settings: %w", err)
+	}
+	if result.Recaps, err = forgetUserInRecaps(ctx, q, userID, sql.NullString{}); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// forgetUserInGuild deletes the rows stored for userID in guildID. Stats, the users row and
+// notification preferences aren't kept per guild, so they stay.
+func forgetUserInGuild(ctx context.Context, q database.Querier, userID, guildID string) (ForgetUserResult, error) {
+	var result ForgetUserResult
+	var err error
+	nullUserID := sql.NullString{String: userID, Valid: true}
+	nullGuildID := sql.NullString{String: guildID, Valid: true}
+
+	if result.Achievements, err = q.DeleteUserGuildAchievements(ctx, database.DeleteUserGuildAchievementsParams{UserID: userID, GuildID: guildID}); err != nil {
+		return result, fmt.Errorf("failed to delete achievements: %w", err)
+	}
+	if result.Streaks, err = q.DeleteUserGuildStreaks(ctx, database.DeleteUserGuildStreaksParams{UserID: userID, GuildID: guildID}); err != nil {
+		return result, fmt.Errorf("failed to delete streaks: %w", err)
+	}
+	if result.StreakEvents, err = q.DeleteUserGuildStreakEvents(ctx, database.DeleteUserGuildStreakEventsParams{UserID: userID, GuildID: guildID}); err != nil {
+		return result, fmt.Errorf("failed to delete streak history: %w", err)
+	}
+	if result.DailyActivity, err = q.DeleteUserGuildDailyActivity(ctx, database.DeleteUserGuildDailyActivityParams{UserID: userID, GuildID: guildID}); err != nil {
+		return result, fmt.Errorf("failed to delete daily activity: %w", err)
+	}
+	if result.StudySessions, err = q.DeleteUserGuildStudySessions(ctx, database.DeleteUserGuildStudySessionsParams{UserID: nullUserID, GuildID: nullGuildID}); err != nil {
+		return result, fmt.Errorf("failed to delete study sessions: %w", err)
+	}
+	if result.ArchivedSessions, err = q.DeleteUserGuildArchivedStudySessions(ctx, database.DeleteUserGuildArchivedStudySessionsParams{UserID: nullUserID, GuildID: nullGuildID}); err != nil {
+		return result, fmt.Errorf("failed to delete archived study sessions: %w", err)
+	}
+	if result.Notifications, err = q.DeleteUserGuildNotifications(ctx, database.DeleteUserGuildNotificationsParams{UserID: nullUserID, GuildID: nullGuildID}); err != nil {
+		return result, fmt.Errorf("failed to delete queued notifications: %w", err)
+	}
+	if result.Subscriptions, err = q.DeleteRecapSubscription(ctx, database.DeleteRecapSubscriptionParams{UserID: userID, GuildID: guildID}); err != nil {
+		return result, fmt.Errorf("failed to delete recap subscription: %w", err)
+	}
+	if result.Reminders, err = q.DeleteUserGuildReminderSettings(ctx, database.DeleteUserGuildReminderSettingsParams{UserID: userID, GuildID: guildID}); err != nil {
+		return result, fmt.Errorf("failed to delete reminder settings: %w", err)
+	}
+	if result.Recaps, err = forgetUserInRecaps(ctx, q, userID, nullGuildID); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// forgetUserInRecaps removes userID from the archived recaps that list them, in guildID only if
+// it is set, and returns how many recaps were changed
+func forgetUserInRecaps(ctx context.Context, q database.Querier, userID string, guildID sql.NullString) (int64, error) {
+	recaps, err := q.GetRecapsMentioningUser(ctx, database.GetRecapsMentioningUserParams{UserID: userID, GuildID: guildID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get archived recaps: %w", err)
+	}
+	for _, recap := range recaps {
+		var report RecapReport
+		if err := json.Unmarshal(recap.Payload, &report); err != nil {
+			return 0, fmt.Errorf("failed to decode recap %d: %w", recap.ID, err)
+		}
+		report.removeUser(userID)
+		payload, err := json.Marshal(report)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode recap %d: %w", recap.ID, err)
+		}
+		if err := q.UpdateRecapPayload(ctx, database.UpdateRecapPayloadParams{ID: recap.ID, Payload: payload}); err != nil {
+			return 0, fmt.Errorf("failed to update recap %d: %w", recap.ID, err)
+		}
+	}
+	return int64(len(recaps)), nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockTxManager runs the transaction function directly against the mock querier
+type mockTxManager struct {
+	q         *MockQuerier
+	committed bool
+}
+
+func (m *mockTxManager) ExecTx(ctx context.Context, fn func(database.Querier) error) error {
+	if err := fn(m.q); err != nil {
+		return err
+	}
+	m.committed = true
+	return nil
+}
+
+func TestForgetUser_DeletesAllRowsAndAudits(t *testing.T) {
+	mockDB := new(MockQuerier)
+	tx := &mockTxManager{q: mockDB}
+	service := NewAccountService(tx)
+
+	userID := "123456789012345678"
+
+	mockDB.On("DeleteUserAchievements", mock.Anything, userID).Return(int64(3), nil).Once()
+	mockDB.On("DeleteUserStreaks", mock.Anything, userID).Return(int64(2), nil).Once()
+	mockDB.On("DeleteUserStreakEvents", mock.Anything, userID).Return(int64(5), nil).Once()
+	mockDB.On("DeleteUserDailyActivity", mock.Anything, userID).Return(int64(4), nil).Once()
+	mockDB.On("DeleteUserStudySessions", mock.Anything, sql.NullString{String: userID, Valid: true}).Return(int64(10), nil).Once()
+	mockDB.On("DeleteUserArchivedStudySessions", mock.Anything, sql.NullString{String: userID, Valid: true}).Return(int64(2), nil).Once()
+	mockDB.On("DeleteUserStats", mock.Anything, userID).Return(int64(1), nil).Once()
+	mockDB.On("DeleteUser", mock.Anything, userID).Return(int64(1), nil).Once()
+	mockDB.On("DeleteUserNotifications", mock.Anything, sql.NullString{String: userID, Valid: true}).Return(int64(2), nil).Once()
+	mockDB.On("DeleteUserRecapSubscriptions", mock.Anything, userID).Return(int64(1), nil).Once()
+	mockDB.On("DeleteUserNotificationPreferences", mock.Anything, userID).Return(int64(1), nil).Once()
+	mockDB.On("DeleteUserReminderSettings", mock.Anything, userID).Return(int64(1), nil).Once()
+	mockDB.On("GetRecapsMentioningUser", mock.Anything, database.GetRecapsMentioningUserParams{UserID: userID}).Return([]database.Recap{}, nil).Once()
+	mockDB.On("CreateAuditLogEntry", mock.Anything, mock.MatchedBy(func(params database.CreateAuditLogEntryParams) bool {
+		var details ForgetUserResult
+		if err := json.Unmarshal(params.Details, &details); err != nil {
+			return false
+		}
+		return params.Action == AuditActionForgetUser &&
+			params.TargetUserID.String == userID &&
+			params.ActorID.String == userID &&
+			params.GuildID.String == "test-guild" &&
+			details.StudySessions == 10
+	})).Return(database.AuditLog{}, nil).Once()
+
+	result, err := service.ForgetUser(context.Background(), ForgetUserRequest{
+		TargetUserID: userID,
+		ActorID:      userID,
+		GuildID:      "test-guild",
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, tx.committed)
+	assert.Equal(t, int64(33), result.Total())
+	mockDB.AssertExpectations(t)
+}
+
+func TestForgetUser_GuildOnlyKeepsOtherGuildsAndGlobalRows(t *testing.T) {
+	mockDB := new(MockQuerier)
+	tx := &mockTxManager{q: mockDB}
+	service := NewAccountService(tx)
+
+	userID := "123456789012345678"
+	guildID := "test-guild"
+	nullUserID := sql.NullString{String: userID, Valid: true}
+	nullGuildID := sql.NullString{String: guildID, Valid: true}
+
+	mockDB.On("DeleteUserGuildAchievements", mock.Anything, database.DeleteUserGuildAchievementsParams{UserID: userID, GuildID: guildID}).Return(int64(1), nil).Once()
+	mockDB.On("DeleteUserGuildStreaks", mock.Anything, database.DeleteUserGuildStreaksParams{UserID: userID, GuildID: guildID}).Return(int64(1), nil).Once()
+	mockDB.On("DeleteUserGuildStreakEvents", mock.Anything, database.DeleteUserGuildStreakEventsParams{UserID: userID, GuildID: guildID}).Return(int64(2), nil).Once()
+	mockDB.On("DeleteUserGuildDailyActivity", mock.Anything, database.DeleteUserGuildDailyActivityParams{UserID: userID, GuildID: guildID}).Return(int64(3), nil).Once()
+	mockDB.On("DeleteUserGuildStudySessions", mock.Anything, database.DeleteUserGuildStudySessionsParams{UserID: nullUserID, GuildID: nullGuildID}).Return(int64(4), nil).Once()
+	mockDB.On("DeleteUserGuildArchivedStudySessions", mock.Anything, database.DeleteUserGuildArchivedStudySessionsParams{UserID: nullUserID, GuildID: nullGuildID}).Return(int64(0), nil).Once()
+	mockDB.On("DeleteUserGuildNotifications", mock.Anything, database.DeleteUserGuildNotificationsParams{UserID: nullUserID, GuildID: nullGuildID}).Return(int64(0), nil).Once()
+	mockDB.On("DeleteRecapSubscription", mock.Anything, database.DeleteRecapSubscriptionParams{UserID: userID, GuildID: guildID}).Return(int64(1), nil).Once()
+	mockDB.On("DeleteUserGuildReminderSettings", mock.Anything, database.DeleteUserGuildReminderSettingsParams{UserID: userID, GuildID: guildID}).Return(int64(1), nil).Once()
+	mockDB.On("GetRecapsMentioningUser", mock.Anything, database.GetRecapsMentioningUserParams{UserID: userID, GuildID: nullGuildID}).Return([]database.Recap{}, nil).Once()
+	mockDB.On("CreateAuditLogEntry", mock.Anything, mock.MatchedBy(func(params database.CreateAuditLogEntryParams) bool {
+		var details forgetUserDetails
+		if err := json.Unmarshal(params.Details, &details); err != nil {
+			return false
+		}
+		return params.ActorID.String == "admin-user" && details.GuildOnly && details.StudySessions == 4
+	})).Return(database.AuditLog{}, nil).Once()
+
+	result, err := service.ForgetUser(context.Background(), ForgetUserRequest{
+		TargetUserID: userID,
+		ActorID:      "admin-user",
+		GuildID:      guildID,
+		GuildOnly:    true,
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, tx.committed)
+	assert.Equal(t, int64(13), result.Total())
+	mockDB.AssertNotCalled(t, "DeleteUserStats", mock.Anything, mock.Anything)
+	mockDB.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+	mockDB.AssertNotCalled(t, "DeleteUserNotificationPreferences", mock.Anything, mock.Anything)
+	mockDB.AssertNotCalled(t, "DeleteUserStudySessions", mock.Anything, mock.Anything)
+	mockDB.AssertExpectations(t)
+}
+
+func TestForgetUser_FailureRollsBack(t *testing.T) {
+	mockDB := new(MockQuerier)
+	tx := &mockTxManager{q: mockDB}
+	service := NewAccountService(tx)
+
+	userID := "123456789012345678"
+
+	mockDB.On("DeleteUserAchievements", mock.Anything, userID).Return(int64(1), nil).Once()
+	mockDB.On("DeleteUserStreaks", mock.Anything, userID).Return(int64(0), errors.New("connection reset")).Once()
+
+	result, err := service.ForgetUser(context.Background(), ForgetUserRequest{
+		TargetUserID: userID,
+		ActorID:      "admin-user",
+	})
+
+	assert.Error(t, err)
+	assert.False(t, tx.committed)
+	assert.Equal(t, int64(0), result.Total())
+	mockDB.AssertNotCalled(t, "CreateAuditLogEntry", mock.Anything, mock.Anything)
+	mockDB.AssertExpectations(t)
+}
+
+func TestForgetUser_RequiresTarget(t *testing.T) {
+	service := NewAccountService(&mockTxManager{q: new(MockQuerier)})
+
+	_, err := service.ForgetUser(context.Background(), ForgetUserRequest{ActorID: "someone"})
+	assert.Error(t, err)
+}
+
+func TestForgetUser_GuildOnlyRequiresGuild(t *testing.T) {
+	service := NewAccountService(&mockTxManager{q: new(MockQuerier)})
+
+	_, err := service.ForgetUser(context.Background(), ForgetUserRequest{TargetUserID: "someone", GuildOnly: true})
+	assert.Error(t, err)
+}
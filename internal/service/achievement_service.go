@@ -3,12 +3,14 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/Skufu/LockIn-Bot/internal/config"
 	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/metrics"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -18,6 +20,7 @@ type AchievementService struct {
 	discordSession       *discordgo.Session
 	cfg                  *config.Config
 	achievementChannelID string
+	logger               *slog.Logger
 }
 
 // NewAchievementService creates a new AchievementService
@@ -31,9 +34,41 @@ func NewAchievementService(
 		discordSession:       session,
 		cfg:                  appConfig,
 		achievementChannelID: appConfig.AchievementChannelID,
+		logger:               slog.Default(),
 	}
 }
 
+// SetLogger sets the structured logger
+func (s *AchievementService) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// withTx returns a copy of the service that runs its queries, including queuing
+// unlock notifications, on q so they commit or roll back together
+func (s *AchievementService) withTx(q database.Querier) *AchievementService {
+	return &AchievementService{
+		db:                   q,
+		discordSession:       s.discordSession,
+		cfg:                  s.cfg,
+		achievementChannelID: s.achievementChannelID,
+		logger:               s.logger,
+	}
+}
+
+// CheckSessionAchievements runs every check that depends on a finished study session
+func (s *AchievementService) CheckSessionAchievements(ctx context.Context, userID, guildID string, totalHours, sessionHours float64, sessionStart time.Time) error {
+	if err := s.CheckDurationAchievements(ctx, userID, guildID, totalHours, sessionHours); err != nil {
+		return err
+	}
+	if err := s.CheckTimeBasedAchievements(ctx, userID, guildID, sessionStart); err != nil {
+		return err
+	}
+	if err := s.CheckGlobalCitizen(ctx, userID, guildID); err != nil {
+		return err
+	}
+	return s.CheckDawnToDusk(ctx, userID, guildID)
+}
+
 // CheckStreakAchievements checks and awards streak-based achievements
 func (s *AchievementService) CheckStreakAchievements(ctx context.Context, userID, guildID string, currentStreak int32) error {
 	streakAchievements := []struct {
@@ -51,11 +86,11 @@ func (s *AchievementService) CheckStreakAchievements(ctx context.Context, userID
 		if currentStreak >= ach.Required {
 			awarded, err := s.tryAwardAchievement(ctx, userID, guildID, ach.ID)
 			if err != nil {
-				log.Printf("Error checking achievement %s for user %s: %v", ach.ID, userID, err)
+				s.logger.ErrorContext(ctx, "Failed to check achievement", "achievement_id", ach.ID, "user_id", userID, "guild_id", guildID, "error", err)
 				continue
 			}
 			if awarded {
-				log.Printf("AchievementService: Awarded %s to user %s", ach.ID, userID)
+				s.logger.InfoContext(ctx, "Awarded achievement", "achievement_id", ach.ID, "user_id", userID, "guild_id", guildID, "streak", currentStreak)
 			}
 		}
 	}
@@ -76,15 +111,17 @@ func (s *AchievementService) CheckDurationAchievements(ctx context.Context, user
 		{"century_club", 100},
 	}
 
+	var errs []error
 	for _, ach := range totalAchievements {
 		if totalHours >= ach.Required {
 			awarded, err := s.tryAwardAchievement(ctx, userID, guildID, ach.ID)
 			if err != nil {
-				log.Printf("Error checking total hours achievement %s for user %s: %v", ach.ID, userID, err)
+				s.logger.ErrorContext(ctx, "Failed to check achievement", "achievement_id", ach.ID, "user_id", userID, "guild_id", guildID, "error", err)
+				errs = append(errs, err)
 				continue
 			}
 			if awarded {
-				log.Printf("AchievementService: Awarded %s to user %s (total hours: %.2f)", ach.ID, userID, totalHours)
+				s.logger.InfoContext(ctx, "Awarded achievement", "achievement_id", ach.ID, "user_id", userID, "guild_id", guildID, "total_hours", totalHours)
 			}
 		}
 	}
@@ -93,13 +130,14 @@ func (s *AchievementService) CheckDurationAchievements(ctx context.Context, user
 	if sessionHours >= 5 {
 		awarded, err := s.tryAwardAchievement(ctx, userID, guildID, "marathon_runner")
 		if err != nil {
-			log.Printf("Error checking marathon_runner achievement for user %s: %v", userID, err)
+			s.logger.ErrorContext(ctx, "Failed to check achievement", "achievement_id", "marathon_runner", "user_id", userID, "guild_id", guildID, "error", err)
+			errs = append(errs, err)
 		} else if awarded {
-			log.Printf("AchievementService: Awarded marathon_runner to user %s (session hours: %.2f)", userID, sessionHours)
+			s.logger.InfoContext(ctx, "Awarded achievement", "achievement_id", "marathon_runner", "user_id", userID, "guild_id", guildID, "session_hours", sessionHours)
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 // CheckTimeBasedAchievements checks and awards time-of-day based achievements
@@ -108,27 +146,37 @@ func (s *AchievementService) CheckTimeBasedAchievements(ctx context.Context, use
 	hour := manilaTime.Hour()
 	weekday := manilaTime.Weekday()
 
+	var ids []string
+
 	// Early Bird - before 7 AM
 	if hour < 7 {
-		s.tryAwardAchievement(ctx, userID, guildID, "early_bird")
+		ids = append(ids, "early_bird")
 	}
 
 	// Night Owl - after midnight (12 AM - 4 AM)
 	if hour >= 0 && hour < 4 {
-		s.tryAwardAchievement(ctx, userID, guildID, "night_owl")
+		ids = append(ids, "night_owl")
 	}
 
 	// Graveyard Shift - 2 AM to 5 AM
 	if hour >= 2 && hour < 5 {
-		s.tryAwardAchievement(ctx, userID, guildID, "graveyard_shift")
+		ids = append(ids, "graveyard_shift")
 	}
 
 	// Weekend Warrior - studying on Saturday or Sunday
 	if weekday == time.Saturday || weekday == time.Sunday {
-		s.tryAwardAchievement(ctx, userID, guildID, "weekend_warrior")
+		ids = append(ids, "weekend_warrior")
 	}
 
-	return nil
+	var errs []error
+	for _, id := range ids {
+		if _, err := s.tryAwardAchievement(ctx, userID, guildID, id); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to check achievement", "achievement_id", id, "user_id", userID, "guild_id", guildID, "error", err)
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
 // CheckCompetitionAchievements checks leaderboard-based achievements
@@ -202,7 +250,9 @@ func (s *AchievementService) CheckGlobalCitizen(ctx context.Context, userID, gui
 	}
 
 	if uniqueHours >= 12 {
-		s.tryAwardAchievement(ctx, userID, guildID, "global_citizen")
+		if _, err := s.tryAwardAchievement(ctx, userID, guildID, "global_citizen"); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -215,7 +265,9 @@ func (s *AchievementService) CheckDawnToDusk(ctx context.Context, userID, guildI
 	}
 
 	if hasDawnToDusk {
-		s.tryAwardAchievement(ctx, userID, guildID, "dawn_to_dusk")
+		if _, err := s.tryAwardAchievement(ctx, userID, guildID, "dawn_to_dusk"); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -245,27 +297,31 @@ func (s *AchievementService) tryAwardAchievement(ctx context.Context, userID, gu
 	if err != nil {
 		return false, fmt.Errorf("failed to award achievement: %w", err)
 	}
+	err = recordAudit(ctx, s.db, AuditEntry{
+		GuildID:      guildID,
+		TargetUserID: userID,
+		Action:       AuditActionAwardAchievement,
+		Details:      map[string]any{"achievementId": achievementID},
+	})
+	if err != nil {
+		return false, err
+	}
 
-	// Send notification
-	go s.sendAchievementNotification(userID, guildID, achievementID)
+	// Queue the notification; the dispatcher marks it notified once it's delivered
+	if err := s.enqueueAchievementNotification(ctx, userID, guildID, achievementID); err != nil {
+		return false, err
+	}
 
+	metrics.AchievementsAwarded.With(achievementID).Inc()
 	return true, nil
 }
 
-// sendAchievementNotification sends an achievement unlock notification
-func (s *AchievementService) sendAchievementNotification(userID, guildID, achievementID string) {
-	if s.achievementChannelID == "" {
-		log.Println("AchievementService: Achievement channel not configured, skipping notification")
-		return
-	}
-
-	ctx := context.Background()
-
+// enqueueAchievementNotification queues an achievement unlock notification in the outbox
+func (s *AchievementService) enqueueAchievementNotification(ctx context.Context, userID, guildID, achievementID string) error {
 	// Get achievement details
 	achievement, err := s.db.GetAchievementByID(ctx, achievementID)
 	if err != nil {
-		log.Printf("AchievementService: Failed to get achievement details for %s: %v", achievementID, err)
-		return
+		return fmt.Errorf("failed to get achievement details for %s: %w", achievementID, err)
 	}
 
 	// Get user's total achievement count
@@ -274,14 +330,14 @@ func (s *AchievementService) sendAchievementNotification(userID, guildID, achiev
 		GuildID: guildID,
 	})
 	if err != nil {
-		log.Printf("AchievementService: Failed to get user achievement count: %v", err)
+		s.logger.ErrorContext(ctx, "Failed to get user achievement count", "user_id", userID, "guild_id", guildID, "error", err)
 		count = 0
 	}
 
 	// Get total achievements count
 	totalCount, err := s.db.GetTotalAchievementCount(ctx)
 	if err != nil {
-		log.Printf("AchievementService: Failed to get total achievement count: %v", err)
+		s.logger.ErrorContext(ctx, "Failed to get total achievement count", "error", err)
 		totalCount = 20
 	}
 
@@ -313,20 +369,52 @@ func (s *AchievementService) sendAchievementNotification(userID, guildID, achiev
 		},
 	}
 
-	_, err = s.discordSession.ChannelMessageSendEmbed(s.achievementChannelID, embed)
-	if err != nil {
-		log.Printf("AchievementService: Failed to send achievement notification: %v", err)
-	}
-
-	// Mark as notified
-	err = s.db.MarkAchievementNotified(ctx, database.MarkAchievementNotifiedParams{
-		UserID:        userID,
+	return enqueueNotification(ctx, s.db, Notification{
+		DedupeKey:     fmt.Sprintf("achievement:%s:%s:%s", guildID, userID, achievementID),
+		Kind:          NotificationKindAchievement,
 		GuildID:       guildID,
+		UserID:        userID,
+		ChannelID:     s.achievementChannelID,
+		Embed:         embed,
 		AchievementID: achievementID,
+		Preference:    PreferenceAchievements,
 	})
+}
+
+// EnqueueMissedNotifications queues notifications for achievements that were awarded but never
+// announced, e.g. because the bot stopped before they were delivered. Already queued ones are skipped.
+func (s *AchievementService) EnqueueMissedNotifications(ctx context.Context) error {
+	if s.achievementChannelID == "" {
+		return nil
+	}
+
+	users, err := s.db.GetUsersWithUnnotifiedAchievements(ctx)
 	if err != nil {
-		log.Printf("AchievementService: Failed to mark achievement as notified: %v", err)
+		return fmt.Errorf("failed to get users with unnotified achievements: %w", err)
 	}
+
+	queued := 0
+	for _, user := range users {
+		achievements, err := s.db.GetUnnotifiedAchievements(ctx, database.GetUnnotifiedAchievementsParams{
+			UserID:  user.UserID,
+			GuildID: user.GuildID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get unnotified achievements for user %s: %w", user.UserID, err)
+		}
+
+		for _, ach := range achievements {
+			if err := s.enqueueAchievementNotification(ctx, ach.UserID, ach.GuildID, ach.AchievementID); err != nil {
+				return err
+			}
+			queued++
+		}
+	}
+
+	if queued > 0 {
+		s.logger.InfoContext(ctx, "Queued missed achievement notifications", "count", queued)
+	}
+	return nil
 }
 
 // GetUserProfile returns profile data including achievements for embed
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"database/sql"
+	"log/slog"
 	"testing"
 	"time"
 
@@ -69,18 +70,13 @@ func (m *MockQuerier) GetUserStreak(ctx context.Context, arg database.GetUserStr
 	return args.Get(0).(database.GetUserStreakRow), args.Error(1)
 }
 
-func (m *MockQuerier) GetUsersForDailyEvaluation(ctx context.Context, streakEvaluatedDate sql.NullTime) ([]database.GetUsersForDailyEvaluationRow, error) {
-	args := m.Called(ctx, streakEvaluatedDate)
+func (m *MockQuerier) GetUsersForDailyEvaluation(ctx context.Context, arg database.GetUsersForDailyEvaluationParams) ([]database.GetUsersForDailyEvaluationRow, error) {
+	args := m.Called(ctx, arg)
 	return args.Get(0).([]database.GetUsersForDailyEvaluationRow), args.Error(1)
 }
 
-func (m *MockQuerier) GetUsersForStreakReset(ctx context.Context, lastActivityDate sql.NullTime) ([]database.GetUsersForStreakResetRow, error) {
-	args := m.Called(ctx, lastActivityDate)
-	return args.Get(0).([]database.GetUsersForStreakResetRow), args.Error(1)
-}
-
-func (m *MockQuerier) GetUsersNeedingWarnings(ctx context.Context, lastActivityDate sql.NullTime) ([]database.GetUsersNeedingWarningsRow, error) {
-	args := m.Called(ctx, lastActivityDate)
+func (m *MockQuerier) GetUsersNeedingWarnings(ctx context.Context, arg database.GetUsersNeedingWarningsParams) ([]database.GetUsersNeedingWarningsRow, error) {
+	args := m.Called(ctx, arg)
 	return args.Get(0).([]database.GetUsersNeedingWarningsRow), args.Error(1)
 }
 
@@ -89,11 +85,6 @@ func (m *MockQuerier) HasActivityForDate(ctx context.Context, arg database.HasAc
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockQuerier) ResetAllStreakDailyFlags(ctx context.Context) error {
-	args := m.Called(ctx)
-	return args.Error(0)
-}
-
 func (m *MockQuerier) ResetDailyStudyTime(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
@@ -129,6 +120,11 @@ func (m *MockQuerier) UpdateStreakImmediately(ctx context.Context, arg database.
 	return args.Error(0)
 }
 
+func (m *MockQuerier) ClearStreakIncrementedToday(ctx context.Context, arg database.ClearStreakIncrementedTodayParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
 func (m *MockQuerier) UpdateUserStreakAfterEvaluation(ctx context.Context, arg database.UpdateUserStreakAfterEvaluationParams) (database.UpdateUserStreakAfterEvaluationRow, error) {
 	args := m.Called(ctx, arg)
 	return args.Get(0).(database.UpdateUserStreakAfterEvaluationRow), args.Error(1)
@@ -204,12 +200,12 @@ func (m *MockQuerier) DeleteAllStudySessions(ctx context.Context) error {
 	return args.Error(0)
 }
 
-func (m *MockQuerier) DeleteOldStudySessions(ctx context.Context, startTime time.Time) error {
+func (m *MockQuerier) DeleteOldStudySessions(ctx context.Context, startTime time.Time) (int64, error) {
 	args := m.Called(ctx, startTime)
-	return args.Error(0)
+	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *MockQuerier) DeleteOldStudySessionsWithCount(ctx context.Context, startTime time.Time) (int64, error) {
+func (m *MockQuerier) ArchiveOldStudySessions(ctx context.Context, startTime time.Time) (int64, error) {
 	args := m.Called(ctx, startTime)
 	return args.Get(0).(int64), args.Error(1)
 }
@@ -234,6 +230,396 @@ func (m *MockQuerier) SetFeaturedBadge(ctx context.Context, arg database.SetFeat
 	return args.Error(0)
 }
 
+func (m *MockQuerier) CreateAuditLogEntry(ctx context.Context, arg database.CreateAuditLogEntryParams) (database.AuditLog, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.AuditLog), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUser(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserAchievements(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserStats(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserStreaks(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserStudySessions(ctx context.Context, userID sql.NullString) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteSentNotifications(ctx context.Context, sentAt sql.NullTime) (int64, error) {
+	args := m.Called(ctx, sentAt)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteNotification(ctx context.Context, dedupeKey string) error {
+	args := m.Called(ctx, dedupeKey)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) DeleteUserNotifications(ctx context.Context, userID sql.NullString) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserGuildAchievements(ctx context.Context, arg database.DeleteUserGuildAchievementsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserGuildStreaks(ctx context.Context, arg database.DeleteUserGuildStreaksParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserGuildStreakEvents(ctx context.Context, arg database.DeleteUserGuildStreakEventsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserGuildDailyActivity(ctx context.Context, arg database.DeleteUserGuildDailyActivityParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserGuildStudySessions(ctx context.Context, arg database.DeleteUserGuildStudySessionsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserGuildArchivedStudySessions(ctx context.Context, arg database.DeleteUserGuildArchivedStudySessionsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserGuildNotifications(ctx context.Context, arg database.DeleteUserGuildNotificationsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserGuildReminderSettings(ctx context.Context, arg database.DeleteUserGuildReminderSettingsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) EnqueueNotification(ctx context.Context, arg database.EnqueueNotificationParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) GetDueNotifications(ctx context.Context, arg database.GetDueNotificationsParams) ([]database.NotificationsOutbox, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.NotificationsOutbox), args.Error(1)
+}
+
+func (m *MockQuerier) GetUsersWithUnnotifiedAchievements(ctx context.Context) ([]database.GetUsersWithUnnotifiedAchievementsRow, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]database.GetUsersWithUnnotifiedAchievementsRow), args.Error(1)
+}
+
+func (m *MockQuerier) MarkNotificationFailed(ctx context.Context, arg database.MarkNotificationFailedParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) MarkNotificationSent(ctx context.Context, arg database.MarkNotificationSentParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) RescheduleNotification(ctx context.Context, arg database.RescheduleNotificationParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) UpsertLiveStatusMessage(ctx context.Context, arg database.UpsertLiveStatusMessageParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) GetLiveStatusMessage(ctx context.Context, guildID string) (database.LiveStatusMessage, error) {
+	args := m.Called(ctx, guildID)
+	return args.Get(0).(database.LiveStatusMessage), args.Error(1)
+}
+
+func (m *MockQuerier) GetLiveStatusMessages(ctx context.Context) ([]database.LiveStatusMessage, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]database.LiveStatusMessage), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteLiveStatusMessage(ctx context.Context, guildID string) (int64, error) {
+	args := m.Called(ctx, guildID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) AddDailyActivity(ctx context.Context, arg database.AddDailyActivityParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) GetDailyActivity(ctx context.Context, arg database.GetDailyActivityParams) ([]database.UserDailyActivity, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.UserDailyActivity), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserDailyActivity(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) GetGuildStudyTotals(ctx context.Context, arg database.GetGuildStudyTotalsParams) ([]database.GetGuildStudyTotalsRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.GetGuildStudyTotalsRow), args.Error(1)
+}
+
+func (m *MockQuerier) GetGuildTopStreaks(ctx context.Context, arg database.GetGuildTopStreaksParams) ([]database.GetGuildTopStreaksRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.GetGuildTopStreaksRow), args.Error(1)
+}
+
+func (m *MockQuerier) GetGuildAchievementsEarnedBetween(ctx context.Context, arg database.GetGuildAchievementsEarnedBetweenParams) ([]database.GetGuildAchievementsEarnedBetweenRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.GetGuildAchievementsEarnedBetweenRow), args.Error(1)
+}
+
+func (m *MockQuerier) CreateRecap(ctx context.Context, arg database.CreateRecapParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) GetRecap(ctx context.Context, arg database.GetRecapParams) (database.Recap, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.Recap), args.Error(1)
+}
+
+func (m *MockQuerier) AddRecapSubscription(ctx context.Context, arg database.AddRecapSubscriptionParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) DeleteRecapSubscription(ctx context.Context, arg database.DeleteRecapSubscriptionParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) GetRecapSubscribers(ctx context.Context, guildID string) ([]string, error) {
+	args := m.Called(ctx, guildID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockQuerier) GetRecapsMentioningUser(ctx context.Context, arg database.GetRecapsMentioningUserParams) ([]database.Recap, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.Recap), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserRecapSubscriptions(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) GetNotificationPreferences(ctx context.Context, userID string) (database.NotificationPreference, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(database.NotificationPreference), args.Error(1)
+}
+
+func (m *MockQuerier) UpsertNotificationPreferences(ctx context.Context, arg database.UpsertNotificationPreferencesParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) DeleteUserNotificationPreferences(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) GetGuildSettings(ctx context.Context, guildID string) (database.GuildSetting, error) {
+	args := m.Called(ctx, guildID)
+	return args.Get(0).(database.GuildSetting), args.Error(1)
+}
+
+func (m *MockQuerier) UpsertGuildReminderMinutes(ctx context.Context, arg database.UpsertGuildReminderMinutesParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) GetUserReminderSettings(ctx context.Context, arg database.GetUserReminderSettingsParams) (database.UserReminderSetting, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.UserReminderSetting), args.Error(1)
+}
+
+func (m *MockQuerier) UpsertUserReminderMinutes(ctx context.Context, arg database.UpsertUserReminderMinutesParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) ClearUserReminderMinutes(ctx context.Context, arg database.ClearUserReminderMinutesParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserReminderSettings(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) UpsertGuildStreakMode(ctx context.Context, arg database.UpsertGuildStreakModeParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) UpdateRecapPayload(ctx context.Context, arg database.UpdateRecapPayloadParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) UpsertGuildRecapChannel(ctx context.Context, arg database.UpsertGuildRecapChannelParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) SetUserStreakMode(ctx context.Context, arg database.SetUserStreakModeParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) UpdateStreakBreak(ctx context.Context, arg database.UpdateStreakBreakParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) CreateStreakEvent(ctx context.Context, arg database.CreateStreakEventParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) GetStreakEvents(ctx context.Context, arg database.GetStreakEventsParams) ([]database.StreakEvent, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.StreakEvent), args.Error(1)
+}
+
+func (m *MockQuerier) GetPastStreaks(ctx context.Context, arg database.GetPastStreaksParams) ([]database.StreakEvent, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.StreakEvent), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserStreakEvents(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) GetGuildEvaluationDates(ctx context.Context) ([]database.GetGuildEvaluationDatesRow, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]database.GetGuildEvaluationDatesRow), args.Error(1)
+}
+
+func (m *MockQuerier) SetGuildLastEvaluatedDate(ctx context.Context, arg database.SetGuildLastEvaluatedDateParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) GetGuildRoleCapabilities(ctx context.Context, guildID string) ([]database.GuildRoleCapability, error) {
+	args := m.Called(ctx, guildID)
+	return args.Get(0).([]database.GuildRoleCapability), args.Error(1)
+}
+
+func (m *MockQuerier) GrantRoleCapability(ctx context.Context, arg database.GrantRoleCapabilityParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) CreateAPIToken(ctx context.Context, arg database.CreateAPITokenParams) (database.ApiToken, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.ApiToken), args.Error(1)
+}
+
+func (m *MockQuerier) GetAPITokenByHash(ctx context.Context, tokenHash string) (database.ApiToken, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(database.ApiToken), args.Error(1)
+}
+
+func (m *MockQuerier) GetGuildAPITokens(ctx context.Context, guildID string) ([]database.ApiToken, error) {
+	args := m.Called(ctx, guildID)
+	return args.Get(0).([]database.ApiToken), args.Error(1)
+}
+
+func (m *MockQuerier) RevokeAPIToken(ctx context.Context, arg database.RevokeAPITokenParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) TouchAPIToken(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) GetGuildLeaderboard(ctx context.Context, arg database.GetGuildLeaderboardParams) ([]database.GetGuildLeaderboardRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.GetGuildLeaderboardRow), args.Error(1)
+}
+
+func (m *MockQuerier) GetUserGuildStudyTotals(ctx context.Context, arg database.GetUserGuildStudyTotalsParams) (database.GetUserGuildStudyTotalsRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.GetUserGuildStudyTotalsRow), args.Error(1)
+}
+
+func (m *MockQuerier) GetUserGuildStudySessions(ctx context.Context, arg database.GetUserGuildStudySessionsParams) ([]database.StudySession, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.StudySession), args.Error(1)
+}
+
+func (m *MockQuerier) GetGuildDailyTotals(ctx context.Context, arg database.GetGuildDailyTotalsParams) ([]database.GetGuildDailyTotalsRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.GetGuildDailyTotalsRow), args.Error(1)
+}
+
+func (m *MockQuerier) RevokeRoleCapability(ctx context.Context, arg database.RevokeRoleCapabilityParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) CreateManualStudySession(ctx context.Context, arg database.CreateManualStudySessionParams) (database.StudySession, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.StudySession), args.Error(1)
+}
+
+func (m *MockQuerier) GetGuildAuditLog(ctx context.Context, arg database.GetGuildAuditLogParams) ([]database.AuditLog, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.AuditLog), args.Error(1)
+}
+
+func (m *MockQuerier) CountGuildStudySessions(ctx context.Context, arg database.CountGuildStudySessionsParams) (database.CountGuildStudySessionsRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.CountGuildStudySessionsRow), args.Error(1)
+}
+
+func (m *MockQuerier) ArchiveGuildStudySessions(ctx context.Context, arg database.ArchiveGuildStudySessionsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteGuildStudySessionsWithCount(ctx context.Context, arg database.DeleteGuildStudySessionsWithCountParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteUserArchivedStudySessions(ctx context.Context, userID sql.NullString) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 // Mock for Discord session to avoid actual calls in tests
 type MockDiscordSession struct {
 	mock.Mock
@@ -268,13 +654,22 @@ func createTestAchievementService(mockDB *MockQuerier) (*AchievementService, *Mo
 		discordSession:       &discordgo.Session{},
 		cfg:                  cfg,
 		achievementChannelID: cfg.AchievementChannelID,
+		logger:               slog.Default(),
 	}
 
 	return service, mockSession
 }
 
+// expectAchievementAudit allows the audit log entry written for each newly awarded achievement
+func expectAchievementAudit(mockDB *MockQuerier) {
+	mockDB.On("CreateAuditLogEntry", mock.Anything, mock.MatchedBy(func(params database.CreateAuditLogEntryParams) bool {
+		return params.Action == AuditActionAwardAchievement
+	})).Return(database.AuditLog{}, nil).Maybe()
+}
+
 // Helper function to setup mocks for achievement notification
 func setupAchievementNotificationMocks(mockDB *MockQuerier, achievementID string) {
+	expectAchievementAudit(mockDB)
 	mockDB.On("GetAchievementByID", mock.Anything, achievementID).Return(database.GetAchievementByIDRow{
 		AchievementID: achievementID,
 		Name:          "Test Achievement",
@@ -285,6 +680,7 @@ func setupAchievementNotificationMocks(mockDB *MockQuerier, achievementID string
 
 // Helper function to setup mocks for all notification-related calls
 func setupFullNotificationMocks(mockDB *MockQuerier, userID, guildID, achievementID string) {
+	expectAchievementAudit(mockDB)
 	mockDB.On("GetAchievementByID", mock.Anything, achievementID).Return(database.GetAchievementByIDRow{
 		AchievementID: achievementID,
 		Name:          "Test Achievement",
@@ -301,6 +697,9 @@ func setupFullNotificationMocks(mockDB *MockQuerier, userID, guildID, achievemen
 	mockDB.On("MarkAchievementNotified", mock.Anything, mock.MatchedBy(func(params database.MarkAchievementNotifiedParams) bool {
 		return params.UserID == userID && params.GuildID == guildID && params.AchievementID == achievementID
 	})).Return(nil).Maybe()
+
+	// Users without saved preferences get notifications in the channel
+	mockDB.On("GetNotificationPreferences", mock.Anything, userID).Return(database.NotificationPreference{}, sql.ErrNoRows).Maybe()
 }
 
 // Test basic functionality of CheckStreakAchievements
@@ -915,6 +1314,13 @@ func TestTryAwardAchievement_NewAward(t *testing.T) {
 			params.GuildID == guildID &&
 			params.AchievementID == "first_flame"
 	})).Return(database.UserAchievement{}, nil).Once()
+	mockDB.On("CreateAuditLogEntry", mock.Anything, mock.MatchedBy(func(params database.CreateAuditLogEntryParams) bool {
+		return params.Action == AuditActionAwardAchievement &&
+			params.TargetUserID.String == userID &&
+			params.GuildID.String == guildID &&
+			!params.ActorID.Valid &&
+			string(params.Details) == `{"achievementId":"first_flame"}`
+	})).Return(database.AuditLog{}, nil).Once()
 
 	// Additional expectations during execution (for notification)
 	mockDB.On("GetAchievementByID", mock.Anything, "first_flame").Return(achievementDetails, nil).Maybe()
@@ -927,6 +1333,7 @@ func TestTryAwardAchievement_NewAward(t *testing.T) {
 			params.GuildID == guildID &&
 			params.AchievementID == "first_flame"
 	})).Return(nil).Maybe()
+	mockDB.On("GetNotificationPreferences", mock.Anything, userID).Return(database.NotificationPreference{}, sql.ErrNoRows).Maybe()
 
 	mockSession.On("ChannelMessageSendEmbed", "test-achievements-channel", mock.AnythingOfType("*discordgo.MessageEmbed")).Return(&discordgo.Message{}, nil).Maybe()
 
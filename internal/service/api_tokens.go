@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+)
+
+// Audit log actions for API tokens
+const (
+	AuditActionCreateAPIToken = "create_api_token" // An admin created a REST API token
+	AuditActionRevokeAPIToken = "revoke_api_token" // An admin revoked a REST API token
+)
+
+const (
+	// APITokenPrefix starts every API token, so a leaked one is easy to recognise
+	APITokenPrefix = "lockin_"
+
+	// MaxAPITokensPerGuild is how many unrevoked tokens a guild may have at once
+	MaxAPITokensPerGuild = 10
+
+	// MaxAPITokenNameLength is the longest name an API token can be given
+	MaxAPITokenNameLength = 64
+
+	// apiTokenTouchInterval is how stale last_used_at must be before a request updates it
+	apiTokenTouchInterval = time.Minute
+)
+
+var (
+	// ErrInvalidAPIToken is returned when a token is unknown, revoked or malformed
+	ErrInvalidAPIToken = errors.New("invalid API token")
+
+	// ErrAPITokenNotFound is returned when revoking a token the guild doesn't have
+	ErrAPITokenNotFound = errors.New("API token not found")
+
+	// ErrInvalidAPITokenName is returned when a name is blank or longer than MaxAPITokenNameLength
+	ErrInvalidAPITokenName = fmt.Errorf("API token names must be 1 to %d characters", MaxAPITokenNameLength)
+
+	// ErrTooManyAPITokens is returned when the guild already has MaxAPITokensPerGuild tokens
+	ErrTooManyAPITokens = errors.New("too many API tokens")
+)
+
+// HashAPIToken returns the hex SHA-256 of token, the form tokens are stored and looked up in
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newAPIToken returns a random token with 256 bits of entropy
+func newAPIToken() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate API token: %w", err)
+	}
+	return APITokenPrefix + base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
+
+// APITokenService creates, lists, revokes and checks the per-guild tokens of the REST API
+type APITokenService struct {
+	dbQueries database.Querier
+	txManager database.TxManager
+}
+
+// NewAPITokenService creates a new APITokenService
+func NewAPITokenService(queries database.Querier, txManager database.TxManager) *APITokenService {
+	return &APITokenService{
+		dbQueries: queries,
+		txManager: txManager,
+	}
+}
+
+// CreateToken creates a token for the guild and records it in the audit log. The returned token is
+// the only copy; just its hash is stored.
+func (s *APITokenService) CreateToken(ctx context.Context, guildID, actorID, name string) (string, database.ApiToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > MaxAPITokenNameLength {
+		return "", database.ApiToken{}, ErrInvalidAPITokenName
+	}
+	token, err := newAPIToken()
+	if err != nil {
+		return "", database.ApiToken{}, err
+	}
+
+	var created database.ApiToken
+	err = s.txManager.ExecTx(ctx, func(q database.Querier) error {
+		existing, err := q.GetGuildAPITokens(ctx, guildID)
+		if err != nil {
+			return fmt.Errorf("failed to get API tokens: %w", err)
+		}
+		if len(existing) >= MaxAPITokensPerGuild {
+			return ErrTooManyAPITokens
+		}
+
+		created, err = q.CreateAPIToken(ctx, database.CreateAPITokenParams{
+			GuildID:   guildID,
+			Name:      name,
+			TokenHash: HashAPIToken(token),
+			CreatedBy: actorID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create API token: %w", err)
+		}
+		return recordAudit(ctx, q, AuditEntry{
+			GuildID: guildID,
+			ActorID: actorID,
+			Action:  AuditActionCreateAPIToken,
+			Details: map[string]any{"tokenId": created.ID, "name": name},
+		})
+	})
+	if err != nil {
+		return "", database.ApiToken{}, err
+	}
+	return token, created, nil
+}
+
+// ListTokens returns the guild's unrevoked tokens, oldest first
+func (s *APITokenService) ListTokens(ctx context.Context, guildID string) ([]database.ApiToken, error) {
+	tokens, err := s.dbQueries.GetGuildAPITokens(ctx, guildID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeToken stops the guild's token with the given ID from being accepted and records it in the audit log
+func (s *APITokenService) RevokeToken(ctx context.Context, guildID, actorID string, tokenID int64) error {
+	return s.txManager.ExecTx(ctx, func(q database.Querier) error {
+		revoked, err := q.RevokeAPIToken(ctx, database.RevokeAPITokenParams{GuildID: guildID, ID: tokenID})
+		if err != nil {
+			return fmt.Errorf("failed to revoke API token: %w", err)
+		}
+		if revoked == 0 {
+			return ErrAPITokenNotFound
+		}
+		return recordAudit(ctx, q, AuditEntry{
+			GuildID: guildID,
+			ActorID: actorID,
+			Action:  AuditActionRevokeAPIToken,
+			Details: map[string]any{"tokenId": tokenID},
+		})
+	})
+}
+
+// Authenticate returns the unrevoked token matching token, or ErrInvalidAPIToken
+func (s *APITokenService) Authenticate(ctx context.Context, token string) (database.ApiToken, error) {
+	if !strings.HasPrefix(token, APITokenPrefix) {
+		return database.ApiToken{}, ErrInvalidAPIToken
+	}
+	found, err := s.dbQueries.GetAPITokenByHash(ctx, HashAPIToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.ApiToken{}, ErrInvalidAPIToken
+	}
+	if err != nil {
+		return database.ApiToken{}, fmt.Errorf("failed to look up API token: %w", err)
+	}
+	// Recording use is best-effort: a busy client shouldn't write on every request, and a
+	// failed write shouldn't lock it out
+	if !found.LastUsedAt.Valid || time.Since(found.LastUsedAt.Time) >= apiTokenTouchInterval {
+		if err := s.dbQueries.TouchAPIToken(ctx, found.ID); err != nil {
+			slog.WarnContext(ctx, "Failed to record API token use", "token_id", found.ID, "guild_id", found.GuildID, "error", err)
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticate_RecordingUseIsBestEffort(t *testing.T) {
+	mockDB := new(MockQuerier)
+	tokens := NewAPITokenService(mockDB, &mockTxManager{q: mockDB})
+	token := APITokenPrefix + "secret"
+
+	mockDB.On("GetAPITokenByHash", mock.Anything, HashAPIToken(token)).Return(database.ApiToken{ID: 1, GuildID: "test-guild"}, nil).Once()
+	mockDB.On("TouchAPIToken", mock.Anything, int64(1)).Return(assert.AnError).Once()
+
+	found, err := tokens.Authenticate(context.Background(), token)
+	require.NoError(t, err, "a failed last_used_at write doesn't fail the request")
+	assert.Equal(t, int64(1), found.ID)
+	mockDB.AssertExpectations(t)
+}
+
+func TestAuthenticate_SkipsWriteForRecentlyUsedToken(t *testing.T) {
+	mockDB := new(MockQuerier)
+	tokens := NewAPITokenService(mockDB, &mockTxManager{q: mockDB})
+	token := APITokenPrefix + "secret"
+
+	mockDB.On("GetAPITokenByHash", mock.Anything, HashAPIToken(token)).Return(database.ApiToken{
+		ID:         1,
+		LastUsedAt: sql.NullTime{Time: time.Now().Add(-10 * time.Second), Valid: true},
+	}, nil).Once()
+
+	_, err := tokens.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+	mockDB.AssertNotCalled(t, "TouchAPIToken", mock.Anything, mock.Anything)
+	mockDB.AssertExpectations(t)
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+)
+
+// AuditLogPageSize is how many audit log entries /admin audit shows
+const AuditLogPageSize = 10
+
+// Actions recorded in the audit log besides the ones declared next to the services making them
+const (
+	AuditActionSessionTimeout   = "session_timeout"   // The bot ended a session that ran too long
+	AuditActionSessionShutdown  = "session_shutdown"  // The bot ended a session because it was shutting down
+	AuditActionStreakReset      = "streak_reset"      // A streak went back to zero
+	AuditActionAwardAchievement = "award_achievement" // A badge was earned
+	AuditActionEvaluateStreaks  = "evaluate_streaks"  // A moderator evaluated streaks for missed days
+	AuditActionStreakMode       = "streak_mode"       // The server's streak mode or repair setting changed
+	AuditActionServerReminders  = "server_reminders"  // The server's reminder times changed
+	AuditActionRecapChannel     = "recap_channel"     // The channel recaps are posted to changed
+	AuditActionGrantCapability  = "grant_capability"  // A role was given a moderator capability
+	AuditActionRevokeCapability = "revoke_capability" // A role lost a moderator capability
+	AuditActionPinLiveStatus    = "pin_live_status"   // A live status message was pinned
+	AuditActionUnpinLiveStatus  = "unpin_live_status" // The live status message was unpinned
+)
+
+// AuditActions lists every action the bot records, in the order /admin audit offers them as filters
+var AuditActions = []string{
+	AuditActionAdjustTime,
+	AuditActionCleanupSessions,
+	AuditActionForgetUser,
+	AuditActionEvaluateStreaks,
+	AuditActionStreakMode,
+	AuditActionServerReminders,
+	AuditActionRecapChannel,
+	AuditActionGrantCapability,
+	AuditActionRevokeCapability,
+	AuditActionPinLiveStatus,
+	AuditActionUnpinLiveStatus,
+	AuditActionCreateAPIToken,
+	AuditActionRevokeAPIToken,
+	AuditActionSessionTimeout,
+	AuditActionSessionShutdown,
+	AuditActionStreakReset,
+	AuditActionAwardAchievement,
+}
+
+// AuditEntry is a change to record in the audit log
+type AuditEntry struct {
+	GuildID      string
+	ActorID      string // Empty when the bot made the change itself
+	TargetUserID string
+	Action       string
+	Reason       string
+	Details      any // Encoded as JSON; IDs and counts only, never deleted data
+}
+
+// recordAudit writes the entry using q, so it commits or rolls back with the change it describes
+func recordAudit(ctx context.Context, q database.Querier, e AuditEntry) error {
+	details := json.RawMessage(`{}`)
+	if e.Details != nil {
+		var err error
+		if details, err = json.Marshal(e.Details); err != nil {
+			return fmt.Errorf("failed to encode audit details: %w", err)
+		}
+	}
+	_, err := q.CreateAuditLogEntry(ctx, database.CreateAuditLogEntryParams{
+		GuildID:      sql.NullString{String: e.GuildID, Valid: e.GuildID != ""},
+		ActorID:      sql.NullString{String: e.ActorID, Valid: e.ActorID != ""},
+		TargetUserID: sql.NullString{String: e.TargetUserID, Valid: e.TargetUserID != ""},
+		Action:       e.Action,
+		Reason:       sql.NullString{String: e.Reason, Valid: e.Reason != ""},
+		Details:      details,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write audit log entry: %w", err)
+	}
+	return nil
+}
+
+// AuditFilter narrows down the audit log. Zero fields match everything.
+type AuditFilter struct {
+	UserID string    // Entries made by or about this user
+	Action string    // One of AuditActions
+	From   time.Time // Entries at or after this time
+	To     time.Time // Entries before this time
+}
+
+// AuditService reads the audit log and records changes made outside a service transaction.
+// Services changing data write their entries in the same transaction as the change itself.
+type AuditService struct {
+	dbQueries database.Querier
+}
+
+// NewAuditService creates a new AuditService
+func NewAuditService(queries database.Querier) *AuditService {
+	return &AuditService{
+		dbQueries: queries,
+	}
+}
+
+// Record writes an audit log entry for a change that has already been made, e.g. a setting saved
+// by a single query
+func (s *AuditService) Record(ctx context.Context, e AuditEntry) error {
+	return recordAudit(ctx, s.dbQueries, e)
+}
+
+// GetGuildAuditLog returns the guild's most recent audit log entries matching the filter, newest first
+func (s *AuditService) GetGuildAuditLog(ctx context.Context, guildID string, filter AuditFilter) ([]database.AuditLog, error) {
+	entries, err := s.dbQueries.GetGuildAuditLog(ctx, database.GetGuildAuditLogParams{
+		GuildID:  sql.NullString{String: guildID, Valid: true},
+		UserID:   sql.NullString{String: filter.UserID, Valid: filter.UserID != ""},
+		Action:   sql.NullString{String: filter.Action, Valid: filter.Action != ""},
+		FromTime: sql.NullTime{Time: filter.From, Valid: !filter.From.IsZero()},
+		ToTime:   sql.NullTime{Time: filter.To, Valid: !filter.To.IsZero()},
+		PageSize: AuditLogPageSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import "time"
+
+// DaySegment is the part of a time range that falls on one local calendar day
+type DaySegment struct {
+	Date     time.Time // Local midnight that starts the day
+	Duration time.Duration
+}
+
+// StartOfDay returns local midnight of t's calendar day in loc
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
+
+// StartOfWeek returns local midnight of the Sunday that starts t's week, matching the weekly reset
+func StartOfWeek(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day()-int(local.Weekday()), 0, 0, 0, 0, loc)
+}
+
+// StartOfMonth returns local midnight of the first day of t's month
+func StartOfMonth(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, loc)
+}
+
+// SplitByDay splits [start, end) at local midnights in loc. The next day is found by calendar
+// date rather than by adding 24 hours, so 23 and 25 hour days around DST changes split correctly.
+func SplitByDay(start, end time.Time, loc *time.Location) []DaySegment {
+	if !end.After(start) {
+		return nil
+	}
+
+	var segments []DaySegment
+	for day := StartOfDay(start, loc); day.Before(end); {
+		local := day.In(loc)
+		next := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc)
+
+		from, to := start, end
+		if day.After(from) {
+			from = day
+		}
+		if next.Before(to) {
+			to = next
+		}
+		if to.After(from) {
+			segments = append(segments, DaySegment{Date: day, Duration: to.Sub(from)})
+		}
+		day = next
+	}
+	return segments
+}
+
+// SegmentMinutes converts segment durations to whole minutes. Seconds left over in one day carry
+// into the next, so the minutes add up to the whole range's minutes instead of losing one per day.
+func SegmentMinutes(segments []DaySegment) []int {
+	minutes := make([]int, len(segments))
+	var elapsed time.Duration
+	credited := 0
+	for i, seg := range segments {
+		elapsed += seg.Duration
+		minutes[i] = int(elapsed/time.Minute) - credited
+		credited += minutes[i]
+	}
+	return minutes
+}
+
+// StudyShares is how much of a study session counts toward each user_stats period
+type StudyShares struct {
+	Total time.Duration
+	Day   time.Duration // Share inside the day containing the session's end
+	Week  time.Duration
+	Month time.Duration
+}
+
+// SessionShares splits [start, end) by the day, week and month that end falls in, so that a
+// session crossing a reset only adds to the new period what was studied after the reset
+func SessionShares(start, end time.Time, loc *time.Location) StudyShares {
+	if !end.After(start) {
+		return StudyShares{}
+	}
+	since := func(periodStart time.Time) time.Duration {
+		if periodStart.After(start) {
+			return end.Sub(periodStart)
+		}
+		return end.Sub(start)
+	}
+	return StudyShares{
+		Total: end.Sub(start),
+		Day:   since(StartOfDay(end, loc)),
+		Week:  since(StartOfWeek(end, loc)),
+		Month: since(StartOfMonth(end, loc)),
+	}
+}
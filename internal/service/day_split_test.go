@@ -0,0 +1,113 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func segmentDurations(segments []DaySegment) []time.Duration {
+	durations := make([]time.Duration, len(segments))
+	for i, seg := range segments {
+		durations[i] = seg.Duration
+	}
+	return durations
+}
+
+func TestSplitByDay_AcrossMidnight(t *testing.T) {
+	loc := GetManilaLocation()
+	start := time.Date(2026, 10, 13, 23, 0, 0, 0, loc)
+	end := time.Date(2026, 10, 14, 2, 0, 0, 0, loc)
+
+	segments := SplitByDay(start, end, loc)
+
+	require.Len(t, segments, 2)
+	assert.True(t, segments[0].Date.Equal(time.Date(2026, 10, 13, 0, 0, 0, 0, loc)))
+	assert.True(t, segments[1].Date.Equal(time.Date(2026, 10, 14, 0, 0, 0, 0, loc)))
+	assert.Equal(t, []time.Duration{time.Hour, 2 * time.Hour}, segmentDurations(segments))
+	assert.Equal(t, []int{60, 120}, SegmentMinutes(segments))
+}
+
+func TestSplitByDay_WithinOneDay(t *testing.T) {
+	loc := GetManilaLocation()
+	start := time.Date(2026, 10, 14, 9, 0, 0, 0, loc)
+
+	segments := SplitByDay(start, start.Add(45*time.Minute), loc)
+
+	require.Len(t, segments, 1)
+	assert.Equal(t, 45*time.Minute, segments[0].Duration)
+	assert.Empty(t, SplitByDay(start, start, loc))
+}
+
+func TestSplitByDay_DSTTransitions(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// Clocks spring forward on 2026-03-08, so that day is only 23 hours long
+	start := time.Date(2026, 3, 7, 22, 0, 0, 0, loc)
+	end := time.Date(2026, 3, 9, 1, 0, 0, 0, loc)
+	segments := SplitByDay(start, end, loc)
+	require.Len(t, segments, 3)
+	assert.Equal(t, []time.Duration{2 * time.Hour, 23 * time.Hour, time.Hour}, segmentDurations(segments))
+	assert.Equal(t, 8, segments[1].Date.Day())
+	assert.Equal(t, end.Sub(start), segments[0].Duration+segments[1].Duration+segments[2].Duration)
+
+	// Clocks fall back on 2026-11-01, so that day is 25 hours long
+	start = time.Date(2026, 10, 31, 23, 0, 0, 0, loc)
+	end = time.Date(2026, 11, 2, 0, 30, 0, 0, loc)
+	segments = SplitByDay(start, end, loc)
+	require.Len(t, segments, 3)
+	assert.Equal(t, []time.Duration{time.Hour, 25 * time.Hour, 30 * time.Minute}, segmentDurations(segments))
+	assert.Equal(t, []int{60, 1500, 30}, SegmentMinutes(segments))
+}
+
+func TestSegmentMinutes_CarriesLeftoverSeconds(t *testing.T) {
+	loc := GetManilaLocation()
+	// 30.5 minutes before midnight and 30.5 after add up to 61 minutes, not 60
+	start := time.Date(2026, 10, 13, 23, 29, 30, 0, loc)
+	end := time.Date(2026, 10, 14, 0, 30, 30, 0, loc)
+
+	minutes := SegmentMinutes(SplitByDay(start, end, loc))
+
+	assert.Equal(t, []int{30, 31}, minutes)
+}
+
+func TestSessionShares(t *testing.T) {
+	loc := GetManilaLocation()
+
+	t.Run("same day", func(t *testing.T) {
+		start := time.Date(2026, 10, 14, 10, 0, 0, 0, loc)
+		shares := SessionShares(start, start.Add(time.Hour), loc)
+		assert.Equal(t, StudyShares{Total: time.Hour, Day: time.Hour, Week: time.Hour, Month: time.Hour}, shares)
+	})
+
+	t.Run("across midnight inside the week", func(t *testing.T) {
+		start := time.Date(2026, 10, 13, 23, 0, 0, 0, loc) // Tuesday
+		shares := SessionShares(start, start.Add(3*time.Hour), loc)
+		assert.Equal(t, StudyShares{Total: 3 * time.Hour, Day: 2 * time.Hour, Week: 3 * time.Hour, Month: 3 * time.Hour}, shares)
+	})
+
+	t.Run("across the weekly reset", func(t *testing.T) {
+		start := time.Date(2026, 10, 17, 23, 30, 0, 0, loc) // Saturday
+		shares := SessionShares(start, start.Add(time.Hour), loc)
+		assert.Equal(t, StudyShares{Total: time.Hour, Day: 30 * time.Minute, Week: 30 * time.Minute, Month: time.Hour}, shares)
+	})
+
+	t.Run("across the monthly reset", func(t *testing.T) {
+		start := time.Date(2026, 9, 30, 22, 0, 0, 0, loc) // Wednesday
+		shares := SessionShares(start, start.Add(4*time.Hour), loc)
+		assert.Equal(t, StudyShares{Total: 4 * time.Hour, Day: 2 * time.Hour, Week: 4 * time.Hour, Month: 2 * time.Hour}, shares)
+	})
+
+	t.Run("DST day", func(t *testing.T) {
+		ny, err := time.LoadLocation("America/New_York")
+		require.NoError(t, err)
+		start := time.Date(2026, 3, 7, 23, 0, 0, 0, ny)
+		end := time.Date(2026, 3, 8, 4, 0, 0, 0, ny) // 2 AM doesn't exist, so 4 hours pass, not 5
+		shares := SessionShares(start, end, ny)
+		assert.Equal(t, 4*time.Hour, shares.Total)
+		assert.Equal(t, 3*time.Hour, shares.Day)
+	})
+}
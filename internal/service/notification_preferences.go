@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+)
+
+// Where a user wants a kind of notification delivered
+const (
+	DeliveryChannel = "channel"
+	DeliveryDM      = "dm"
+	DeliveryOff     = "off"
+)
+
+// Notification topics users can set a delivery for
+const (
+	PreferenceStreakWarnings   = "streak_warnings"
+	PreferenceDailyComplete    = "daily_complete"
+	PreferenceAchievements     = "achievements"
+	PreferenceSessionSummaries = "session_summaries"
+)
+
+// NotificationPreferences is how a user wants to be notified. The zero value isn't valid;
+// use DefaultNotificationPreferences for users who never changed anything.
+type NotificationPreferences struct {
+	StreakWarnings   string
+	DailyComplete    string
+	Achievements     string
+	SessionSummaries string
+	QuietStartHour   *int // Manila hour, nil when quiet hours are off
+	QuietEndHour     *int
+}
+
+// DefaultNotificationPreferences posts everything in the channel with no quiet hours, as before preferences existed
+func DefaultNotificationPreferences() NotificationPreferences {
+	return NotificationPreferences{
+		StreakWarnings:   DeliveryChannel,
+		DailyComplete:    DeliveryChannel,
+		Achievements:     DeliveryChannel,
+		SessionSummaries: DeliveryChannel,
+	}
+}
+
+// IsValidDelivery reports whether d is one of the supported delivery options
+func IsValidDelivery(d string) bool {
+	return d == DeliveryChannel || d == DeliveryDM || d == DeliveryOff
+}
+
+// Delivery returns the user's choice for a topic, defaulting to the channel for unknown topics
+func (p NotificationPreferences) Delivery(topic string) string {
+	var d string
+	switch topic {
+	case PreferenceStreakWarnings:
+		d = p.StreakWarnings
+	case PreferenceDailyComplete:
+		d = p.DailyComplete
+	case PreferenceAchievements:
+		d = p.Achievements
+	case PreferenceSessionSummaries:
+		d = p.SessionSummaries
+	}
+	if !IsValidDelivery(d) {
+		return DeliveryChannel
+	}
+	return d
+}
+
+// SetDelivery changes the user's choice for a topic
+func (p *NotificationPreferences) SetDelivery(topic, delivery string) error {
+	if !IsValidDelivery(delivery) {
+		return fmt.Errorf("unknown delivery %q", delivery)
+	}
+	switch topic {
+	case PreferenceStreakWarnings:
+		p.StreakWarnings = delivery
+	case PreferenceDailyComplete:
+		p.DailyComplete = delivery
+	case PreferenceAchievements:
+		p.Achievements = delivery
+	case PreferenceSessionSummaries:
+		p.SessionSummaries = delivery
+	default:
+		return fmt.Errorf("unknown notification topic %q", topic)
+	}
+	return nil
+}
+
+// QuietUntil returns when the user's quiet hours end if now falls inside them. Quiet hours
+// that pass midnight, e.g. 22 to 7, are supported; equal start and end hours mean none.
+func (p NotificationPreferences) QuietUntil(now time.Time) (time.Time, bool) {
+	if p.QuietStartHour == nil || p.QuietEndHour == nil || *p.QuietStartHour == *p.QuietEndHour {
+		return time.Time{}, false
+	}
+	start, end := *p.QuietStartHour, *p.QuietEndHour
+	local := now.In(manilaLocation)
+	hour := local.Hour()
+
+	quiet := hour >= start && hour < end
+	if start > end {
+		quiet = hour >= start || hour < end
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	until := time.Date(local.Year(), local.Month(), local.Day(), end, 0, 0, 0, manilaLocation)
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until, true
+}
+
+// loadNotificationPreferences reads a user's preferences using q, falling back to the defaults
+func loadNotificationPreferences(ctx context.Context, q database.Querier, userID string) (NotificationPreferences, error) {
+	row, err := q.GetNotificationPreferences(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultNotificationPreferences(), nil
+	}
+	if err != nil {
+		return NotificationPreferences{}, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	prefs := NotificationPreferences{
+		StreakWarnings:   row.StreakWarnings,
+		DailyComplete:    row.DailyComplete,
+		Achievements:     row.Achievements,
+		SessionSummaries: row.SessionSummaries,
+	}
+	if row.QuietStartHour.Valid && row.QuietEndHour.Valid {
+		start, end := int(row.QuietStartHour.Int32), int(row.QuietEndHour.Int32)
+		prefs.QuietStartHour, prefs.QuietEndHour = &start, &end
+	}
+	return prefs, nil
+}
+
+// Preferences returns a user's notification preferences
+func (s *NotificationService) Preferences(ctx context.Context, userID string) (NotificationPreferences, error) {
+	return loadNotificationPreferences(ctx, s.db, userID)
+}
+
+// SetPreferences stores a user's notification preferences
+func (s *NotificationService) SetPreferences(ctx context.Context, userID string, prefs NotificationPreferences) error {
+	for _, d := range []string{prefs.StreakWarnings, prefs.DailyComplete, prefs.Achievements, prefs.SessionSummaries} {
+		if !IsValidDelivery(d) {
+			return fmt.Errorf("unknown delivery %q", d)
+		}
+	}
+
+	params := database.UpsertNotificationPreferencesParams{
+		UserID:           userID,
+		StreakWarnings:   prefs.StreakWarnings,
+		DailyComplete:    prefs.DailyComplete,
+		Achievements:     prefs.Achievements,
+		SessionSummaries: prefs.SessionSummaries,
+	}
+	if prefs.QuietStartHour != nil && prefs.QuietEndHour != nil {
+		for _, h := range []int{*prefs.QuietStartHour, *prefs.QuietEndHour} {
+			if h < 0 || h > 23 {
+				return fmt.Errorf("quiet hour %d is out of range", h)
+			}
+		}
+		params.QuietStartHour = sql.NullInt32{Int32: int32(*prefs.QuietStartHour), Valid: true}
+		params.QuietEndHour = sql.NullInt32{Int32: int32(*prefs.QuietEndHour), Valid: true}
+	}
+	return s.db.UpsertNotificationPreferences(ctx, params)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func quietHours(start, end int) NotificationPreferences {
+	prefs := DefaultNotificationPreferences()
+	prefs.QuietStartHour, prefs.QuietEndHour = &start, &end
+	return prefs
+}
+
+func TestQuietUntil(t *testing.T) {
+	loc := GetManilaLocation()
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 10, day, hour, minute, 0, 0, loc) }
+
+	t.Run("overnight quiet hours before midnight", func(t *testing.T) {
+		until, quiet := quietHours(22, 7).QuietUntil(at(14, 23, 15))
+		assert.True(t, quiet)
+		assert.True(t, until.Equal(at(15, 7, 0)))
+	})
+
+	t.Run("overnight quiet hours after midnight", func(t *testing.T) {
+		until, quiet := quietHours(22, 7).QuietUntil(at(15, 3, 0))
+		assert.True(t, quiet)
+		assert.True(t, until.Equal(at(15, 7, 0)))
+	})
+
+	t.Run("outside overnight quiet hours", func(t *testing.T) {
+		_, quiet := quietHours(22, 7).QuietUntil(at(15, 7, 0))
+		assert.False(t, quiet)
+		_, quiet = quietHours(22, 7).QuietUntil(at(15, 21, 59))
+		assert.False(t, quiet)
+	})
+
+	t.Run("daytime quiet hours", func(t *testing.T) {
+		until, quiet := quietHours(9, 17).QuietUntil(at(14, 12, 30))
+		assert.True(t, quiet)
+		assert.True(t, until.Equal(at(14, 17, 0)))
+		_, quiet = quietHours(9, 17).QuietUntil(at(14, 18, 0))
+		assert.False(t, quiet)
+	})
+
+	t.Run("uses Manila time", func(t *testing.T) {
+		// 15:00 UTC is 23:00 in Manila
+		until, quiet := quietHours(22, 7).QuietUntil(time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC))
+		assert.True(t, quiet)
+		assert.True(t, until.Equal(at(15, 7, 0)))
+	})
+
+	t.Run("off", func(t *testing.T) {
+		_, quiet := DefaultNotificationPreferences().QuietUntil(at(14, 23, 0))
+		assert.False(t, quiet)
+		_, quiet = quietHours(8, 8).QuietUntil(at(14, 8, 0))
+		assert.False(t, quiet)
+	})
+}
+
+func TestNotificationPreferences_SetDelivery(t *testing.T) {
+	prefs := DefaultNotificationPreferences()
+
+	assert.NoError(t, prefs.SetDelivery(PreferenceAchievements, DeliveryDM))
+	assert.NoError(t, prefs.SetDelivery(PreferenceStreakWarnings, DeliveryOff))
+	assert.Error(t, prefs.SetDelivery(PreferenceDailyComplete, "pigeon"))
+	assert.Error(t, prefs.SetDelivery("recaps", DeliveryDM))
+
+	assert.Equal(t, DeliveryDM, prefs.Delivery(PreferenceAchievements))
+	assert.Equal(t, DeliveryOff, prefs.Delivery(PreferenceStreakWarnings))
+	assert.Equal(t, DeliveryChannel, prefs.Delivery(PreferenceDailyComplete))
+	assert.Equal(t, DeliveryChannel, prefs.Delivery("recaps"))
+}
@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Notification kinds stored in the outbox
+const (
+	NotificationKindAchievement = "achievement"
+	NotificationKindStreak      = "streak"
+	NotificationKindSession     = "session"
+	NotificationKindRecap       = "recap"
+)
+
+const (
+	notificationBatchSize    = 25
+	notificationMaxAttempts  = 10
+	notificationPollInterval = 15 * time.Second
+	notificationBaseBackoff  = 30 * time.Second
+	notificationMaxBackoff   = time.Hour
+	notificationRetention    = 7 * 24 * time.Hour
+)
+
+// messageSender is the part of the Discord session the dispatcher needs
+type messageSender interface {
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	GuildChannels(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Channel, error)
+	UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+}
+
+// Notification is a Discord message waiting in the outbox
+type Notification struct {
+	DedupeKey     string // Enqueueing the same key twice only delivers once
+	Kind          string
+	GuildID       string
+	UserID        string
+	ChannelID     string
+	Content       string
+	Embed         *discordgo.MessageEmbed
+	AchievementID string // Marked as notified once the message is delivered
+	AllowFallback bool   // Try another text channel in the guild if ChannelID can't be used
+	DirectMessage bool   // Send to UserID's DMs; ChannelID is not used
+	Preference    string // Topic whose delivery preference UserID controls, if any
+}
+
+// notificationPayload is the JSON stored in notifications_outbox.payload
+type notificationPayload struct {
+	Content       string                  `json:"content,omitempty"`
+	Embed         *discordgo.MessageEmbed `json:"embed,omitempty"`
+	AchievementID string                  `json:"achievementId,omitempty"`
+	AllowFallback bool                    `json:"allowFallback,omitempty"`
+	DirectMessage bool                    `json:"directMessage,omitempty"`
+}
+
+// enqueueNotification writes n to the outbox using q, which may be bound to a transaction
+// so the message is only queued if the change it announces is committed
+func enqueueNotification(ctx context.Context, q database.Querier, n Notification) error {
+	var nextAttempt sql.NullTime
+	if n.Preference != "" && n.UserID != "" {
+		prefs, err := loadNotificationPreferences(ctx, q, n.UserID)
+		if err != nil {
+			return err
+		}
+
+		switch prefs.Delivery(n.Preference) {
+		case DeliveryOff:
+			if n.AchievementID != "" {
+				// Nothing will be sent, so stop the achievement from being queued again
+				return q.MarkAchievementNotified(ctx, database.MarkAchievementNotifiedParams{
+					UserID:        n.UserID,
+					GuildID:       n.GuildID,
+					AchievementID: n.AchievementID,
+				})
+			}
+			return nil
+		case DeliveryDM:
+			n.DirectMessage = true
+		}
+
+		if until, quiet := prefs.QuietUntil(GetManilaTimeNow()); quiet {
+			nextAttempt = sql.NullTime{Time: until, Valid: true}
+		}
+
+		if !n.DirectMessage && n.ChannelID == "" {
+			slog.WarnContext(ctx, "No channel configured, skipping notification", "preference", n.Preference, "dedupe_key", n.DedupeKey, "user_id", n.UserID)
+			return nil
+		}
+	}
+
+	if n.DirectMessage && n.UserID == "" {
+		return fmt.Errorf("direct message notification %s has no user", n.DedupeKey)
+	}
+	if !n.DirectMessage && n.ChannelID == "" {
+		return fmt.Errorf("notification %s has no channel", n.DedupeKey)
+	}
+
+	payload, err := json.Marshal(notificationPayload{
+		Content:       n.Content,
+		Embed:         n.Embed,
+		AchievementID: n.AchievementID,
+		AllowFallback: n.AllowFallback,
+		DirectMessage: n.DirectMessage,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode notification payload: %w", err)
+	}
+
+	_, err = q.EnqueueNotification(ctx, database.EnqueueNotificationParams{
+		DedupeKey:     n.DedupeKey,
+		Kind:          n.Kind,
+		GuildID:       sql.NullString{String: n.GuildID, Valid: n.GuildID != ""},
+		UserID:        sql.NullString{String: n.UserID, Valid: n.UserID != ""},
+		ChannelID:     n.ChannelID,
+		Payload:       payload,
+		NextAttemptAt: nextAttempt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue notification %s: %w", n.DedupeKey, err)
+	}
+	return nil
+}
+
+// NotificationService delivers queued notifications to Discord in the background,
+// retrying failed sends with exponential backoff
+type NotificationService struct {
+	db     database.Querier
+	sender messageSender
+
+	wake     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewNotificationService creates a new NotificationService
+func NewNotificationService(queries database.Querier, session *discordgo.Session) *NotificationService {
+	return &NotificationService{
+		db:     queries,
+		sender: session,
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Enqueue adds a notification to the outbox and wakes the dispatcher
+func (s *NotificationService) Enqueue(ctx context.Context, n Notification) error {
+	if err := enqueueNotification(ctx, s.db, n); err != nil {
+		return err
+	}
+	s.Wake()
+	return nil
+}
+
+// Wake asks the dispatcher to check the outbox now instead of at its next poll
+func (s *NotificationService) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs the dispatcher until Stop is called
+func (s *NotificationService) Start() {
+	go s.run()
+	slog.Info("Notification dispatcher started")
+}
+
+// Stop signals the dispatcher to exit and waits for the current batch to finish.
+// Anything still queued is delivered after the next start.
+func (s *NotificationService) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+		slog.Info("Notification dispatcher stopped")
+	})
+}
+
+func (s *NotificationService) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(notificationPollInterval)
+	defer ticker.Stop()
+
+	ctx := context.Background()
+	var lastPrune time.Time
+
+	for {
+		s.DispatchDue(ctx)
+
+		if time.Since(lastPrune) >= time.Hour {
+			s.pruneSent(ctx)
+			lastPrune = time.Now()
+		}
+
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue delivers every notification whose next attempt is due and returns how many were sent
+func (s *NotificationService) DispatchDue(ctx context.Context) int {
+	sent := 0
+	for {
+		batch, err := s.db.GetDueNotifications(ctx, database.GetDueNotificationsParams{
+			NextAttemptAt: time.Now(),
+			Attempts:      notificationMaxAttempts,
+			Limit:         notificationBatchSize,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to load due notifications", "error", err)
+			return sent
+		}
+
+		for _, n := range batch {
+			delivered, err := s.deliver(ctx, n)
+			if err != nil {
+				// Without recording the outcome the same row would be picked up again immediately
+				slog.ErrorContext(ctx, "Failed to record notification delivery result", "notification_id", n.ID, "error", err)
+				return sent
+			}
+			if delivered {
+				sent++
+			}
+		}
+
+		if len(batch) < notificationBatchSize {
+			return sent
+		}
+	}
+}
+
+// deliver sends a single notification and records the outcome.
+// It only returns an error when the outcome could not be written back to the outbox.
+func (s *NotificationService) deliver(ctx context.Context, n database.NotificationsOutbox) (bool, error) {
+	var payload notificationPayload
+	sendErr := json.Unmarshal(n.Payload, &payload)
+	if sendErr == nil && payload.DirectMessage {
+		sendErr = s.sendDirect(n.UserID.String, payload)
+	} else if sendErr == nil {
+		sendErr = s.send(n.ChannelID, payload)
+		if sendErr != nil && payload.AllowFallback && n.GuildID.Valid && isPermanentSendError(sendErr) {
+			sendErr = s.sendToFallbackChannel(n.GuildID.String, payload)
+		}
+	}
+
+	now := time.Now()
+	if sendErr != nil {
+		var rateLimitErr *discordgo.RateLimitError
+		if errors.As(sendErr, &rateLimitErr) {
+			slog.WarnContext(ctx, "Rate limited sending notification", "notification_id", n.ID, "retry_after", rateLimitErr.RetryAfter)
+			return false, s.db.RescheduleNotification(ctx, database.RescheduleNotificationParams{
+				ID:            n.ID,
+				NextAttemptAt: now.Add(rateLimitErr.RetryAfter),
+			})
+		}
+
+		attempt := n.Attempts + 1
+		if attempt >= notificationMaxAttempts {
+			slog.ErrorContext(ctx, "Giving up on notification", "notification_id", n.ID, "dedupe_key", n.DedupeKey, "attempts", attempt, "error", sendErr)
+		} else {
+			slog.WarnContext(ctx, "Failed to send notification", "notification_id", n.ID, "attempt", attempt, "error", sendErr)
+		}
+		return false, s.db.MarkNotificationFailed(ctx, database.MarkNotificationFailedParams{
+			ID:            n.ID,
+			NextAttemptAt: now.Add(notificationBackoff(attempt)),
+			LastError:     sql.NullString{String: sendErr.Error(), Valid: true},
+		})
+	}
+
+	err := s.db.MarkNotificationSent(ctx, database.MarkNotificationSentParams{
+		ID:     n.ID,
+		SentAt: sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		return true, err
+	}
+
+	// Achievements are only marked notified once the user has actually been told
+	if n.Kind == NotificationKindAchievement && payload.AchievementID != "" && n.UserID.Valid && n.GuildID.Valid {
+		err = s.db.MarkAchievementNotified(ctx, database.MarkAchievementNotifiedParams{
+			UserID:        n.UserID.String,
+			GuildID:       n.GuildID.String,
+			AchievementID: payload.AchievementID,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to mark achievement as notified", "achievement_id", payload.AchievementID, "error", err)
+		}
+	}
+	return true, nil
+}
+
+// send posts the payload without discordgo's built-in rate limit retry, so a 429 comes back
+// to the dispatcher and the row is rescheduled instead of blocking the whole queue
+func (s *NotificationService) send(channelID string, payload notificationPayload) error {
+	message := &discordgo.MessageSend{Content: payload.Content}
+	if payload.Embed != nil {
+		message.Embeds = []*discordgo.MessageEmbed{payload.Embed}
+	}
+	_, err := s.sender.ChannelMessageSendComplex(channelID, message, discordgo.WithRetryOnRatelimit(false))
+	return err
+}
+
+// sendDirect opens a DM channel with the user and posts the payload there
+func (s *NotificationService) sendDirect(userID string, payload notificationPayload) error {
+	if userID == "" {
+		return fmt.Errorf("direct message has no recipient")
+	}
+	channel, err := s.sender.UserChannelCreate(userID)
+	if err != nil {
+		return fmt.Errorf("failed to open DM channel: %w", err)
+	}
+	return s.send(channel.ID, payload)
+}
+
+// sendToFallbackChannel tries every text channel in the guild until one accepts the message
+func (s *NotificationService) sendToFallbackChannel(guildID string, payload notificationPayload) error {
+	channels, err := s.sender.GuildChannels(guildID)
+	if err != nil {
+		return fmt.Errorf("failed to list fallback channels: %w", err)
+	}
+	for _, ch := range channels {
+		if ch.Type != discordgo.ChannelTypeGuildText {
+			continue
+		}
+		if err := s.send(ch.ID, payload); err == nil {
+			slog.Info("Sent notification to fallback channel", "guild_id", guildID, "channel_id", ch.ID, "channel", ch.Name)
+			return nil
+		}
+	}
+	return fmt.Errorf("could not find any suitable channel in guild %s", guildID)
+}
+
+// pruneSent removes delivered notifications older than the retention period
+func (s *NotificationService) pruneSent(ctx context.Context) {
+	cutoff := time.Now().Add(-notificationRetention)
+	deleted, err := s.db.DeleteSentNotifications(ctx, sql.NullTime{Time: cutoff, Valid: true})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to prune sent notifications", "error", err)
+		return
+	}
+	if deleted > 0 {
+		slog.InfoContext(ctx, "Pruned sent notifications", "count", deleted)
+	}
+}
+
+// notificationBackoff returns the delay before retrying after the given number of failed attempts
+func notificationBackoff(attempts int32) time.Duration {
+	delay := notificationBaseBackoff
+	for i := int32(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= notificationMaxBackoff {
+			return notificationMaxBackoff
+		}
+	}
+	return delay
+}
+
+// isPermanentSendError reports whether Discord refused the channel itself (missing access or
+// unknown channel), in which case retrying the same channel won't help
+func isPermanentSendError(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Response == nil {
+		return false
+	}
+	return restErr.Response.StatusCode == http.StatusForbidden || restErr.Response.StatusCode == http.StatusNotFound
+}
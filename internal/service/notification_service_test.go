@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fakeSender records sent messages and returns errors per channel
+type fakeSender struct {
+	errs     map[string]error
+	sent     []string
+	channels []*discordgo.Channel
+}
+
+func (f *fakeSender) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	if err := f.errs[channelID]; err != nil {
+		return nil, err
+	}
+	f.sent = append(f.sent, channelID)
+	return &discordgo.Message{ChannelID: channelID}, nil
+}
+
+func (f *fakeSender) GuildChannels(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Channel, error) {
+	return f.channels, nil
+}
+
+func (f *fakeSender) UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	return &discordgo.Channel{ID: "dm-" + recipientID, Type: discordgo.ChannelTypeDM}, nil
+}
+
+func newTestNotificationService(mockDB *MockQuerier, sender *fakeSender) *NotificationService {
+	ns := NewNotificationService(mockDB, nil)
+	ns.sender = sender
+	return ns
+}
+
+func outboxRow(t *testing.T, id int64, kind string, payload notificationPayload) database.NotificationsOutbox {
+	raw, err := json.Marshal(payload)
+	assert.NoError(t, err)
+	return database.NotificationsOutbox{
+		ID:        id,
+		Kind:      kind,
+		GuildID:   sql.NullString{String: "test-guild", Valid: true},
+		UserID:    sql.NullString{String: "test-user", Valid: true},
+		ChannelID: "channel-1",
+		Payload:   raw,
+	}
+}
+
+func TestDispatchDue_SentAchievementIsMarkedNotified(t *testing.T) {
+	mockDB := new(MockQuerier)
+	sender := &fakeSender{}
+	ns := newTestNotificationService(mockDB, sender)
+
+	row := outboxRow(t, 1, NotificationKindAchievement, notificationPayload{Content: "hi", AchievementID: "first_flame"})
+	mockDB.On("GetDueNotifications", mock.Anything, mock.Anything).Return([]database.NotificationsOutbox{row}, nil).Once()
+	mockDB.On("MarkNotificationSent", mock.Anything, mock.MatchedBy(func(params database.MarkNotificationSentParams) bool {
+		return params.ID == 1 && params.SentAt.Valid
+	})).Return(nil).Once()
+	mockDB.On("MarkAchievementNotified", mock.Anything, database.MarkAchievementNotifiedParams{
+		UserID:        "test-user",
+		GuildID:       "test-guild",
+		AchievementID: "first_flame",
+	}).Return(nil).Once()
+
+	sent := ns.DispatchDue(context.Background())
+
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"channel-1"}, sender.sent)
+	mockDB.AssertExpectations(t)
+}
+
+func TestDispatchDue_DirectMessageGoesToUserDMs(t *testing.T) {
+	mockDB := new(MockQuerier)
+	sender := &fakeSender{}
+	ns := newTestNotificationService(mockDB, sender)
+
+	row := outboxRow(t, 3, NotificationKindRecap, notificationPayload{Content: "your week", DirectMessage: true})
+	row.ChannelID = ""
+	mockDB.On("GetDueNotifications", mock.Anything, mock.Anything).Return([]database.NotificationsOutbox{row}, nil).Once()
+	mockDB.On("MarkNotificationSent", mock.Anything, mock.Anything).Return(nil).Once()
+
+	sent := ns.DispatchDue(context.Background())
+
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"dm-test-user"}, sender.sent)
+	mockDB.AssertExpectations(t)
+}
+
+func TestDispatchDue_FailedSendIsRetriedLaterAndNotMarkedNotified(t *testing.T) {
+	mockDB := new(MockQuerier)
+	sender := &fakeSender{errs: map[string]error{"channel-1": errors.New("discord is down")}}
+	ns := newTestNotificationService(mockDB, sender)
+
+	row := outboxRow(t, 2, NotificationKindAchievement, notificationPayload{Content: "hi", AchievementID: "first_flame"})
+	row.Attempts = 2
+	before := time.Now()
+
+	mockDB.On("GetDueNotifications", mock.Anything, mock.Anything).Return([]database.NotificationsOutbox{row}, nil).Once()
+	mockDB.On("MarkNotificationFailed", mock.Anything, mock.MatchedBy(func(params database.MarkNotificationFailedParams) bool {
+		return params.ID == 2 &&
+			params.LastError.String == "discord is down" &&
+			!params.NextAttemptAt.Before(before.Add(notificationBackoff(3)))
+	})).Return(nil).Once()
+
+	sent := ns.DispatchDue(context.Background())
+
+	assert.Equal(t, 0, sent)
+	mockDB.AssertNotCalled(t, "MarkAchievementNotified", mock.Anything, mock.Anything)
+	mockDB.AssertExpectations(t)
+}
+
+func TestDispatchDue_RateLimitReschedulesWithoutUsingAnAttempt(t *testing.T) {
+	mockDB := new(MockQuerier)
+	rateLimited := &discordgo.RateLimitError{RateLimit: &discordgo.RateLimit{
+		TooManyRequests: &discordgo.TooManyRequests{RetryAfter: 5 * time.Second},
+	}}
+	sender := &fakeSender{errs: map[string]error{"channel-1": rateLimited}}
+	ns := newTestNotificationService(mockDB, sender)
+
+	before := time.Now()
+	mockDB.On("GetDueNotifications", mock.Anything, mock.Anything).Return([]database.NotificationsOutbox{
+		outboxRow(t, 3, NotificationKindSession, notificationPayload{Content: "hi"}),
+	}, nil).Once()
+	mockDB.On("RescheduleNotification", mock.Anything, mock.MatchedBy(func(params database.RescheduleNotificationParams) bool {
+		return params.ID == 3 && !params.NextAttemptAt.Before(before.Add(5*time.Second))
+	})).Return(nil).Once()
+
+	ns.DispatchDue(context.Background())
+
+	mockDB.AssertNotCalled(t, "MarkNotificationFailed", mock.Anything, mock.Anything)
+	mockDB.AssertExpectations(t)
+}
+
+func TestDispatchDue_FallsBackToAnotherGuildChannel(t *testing.T) {
+	mockDB := new(MockQuerier)
+	forbidden := &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusForbidden}}
+	sender := &fakeSender{
+		errs: map[string]error{"channel-1": forbidden},
+		channels: []*discordgo.Channel{
+			{ID: "voice-1", Type: discordgo.ChannelTypeGuildVoice},
+			{ID: "text-2", Type: discordgo.ChannelTypeGuildText},
+		},
+	}
+	ns := newTestNotificationService(mockDB, sender)
+
+	mockDB.On("GetDueNotifications", mock.Anything, mock.Anything).Return([]database.NotificationsOutbox{
+		outboxRow(t, 4, NotificationKindStreak, notificationPayload{Content: "hi", AllowFallback: true}),
+	}, nil).Once()
+	mockDB.On("MarkNotificationSent", mock.Anything, mock.Anything).Return(nil).Once()
+
+	sent := ns.DispatchDue(context.Background())
+
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"text-2"}, sender.sent)
+	mockDB.AssertExpectations(t)
+}
+
+func TestNotificationBackoff(t *testing.T) {
+	assert.Equal(t, notificationBaseBackoff, notificationBackoff(1))
+	assert.Equal(t, 2*notificationBaseBackoff, notificationBackoff(2))
+	assert.Equal(t, 4*notificationBaseBackoff, notificationBackoff(3))
+	assert.Equal(t, notificationMaxBackoff, notificationBackoff(notificationMaxAttempts))
+}
@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Recap periods, matching the weekly and monthly stat resets
+const (
+	RecapPeriodWeekly  = "weekly"
+	RecapPeriodMonthly = "monthly"
+)
+
+const (
+	recapTopStudiers = 5
+	recapImprovers   = 3
+	recapStreaks     = 3
+	recapMaxBadges   = 10 // Keeps the embed field under Discord's length limit
+)
+
+// RecapStudier is a user's study time in the recap period
+type RecapStudier struct {
+	UserID  string `json:"userId"`
+	StudyMs int64  `json:"studyMs"`
+}
+
+// RecapImprover is a user who studied more than in the period before
+type RecapImprover struct {
+	UserID          string `json:"userId"`
+	StudyMs         int64  `json:"studyMs"`
+	PreviousStudyMs int64  `json:"previousStudyMs"`
+}
+
+// RecapStreak is a user's running streak when the recap was made
+type RecapStreak struct {
+	UserID string `json:"userId"`
+	Days   int32  `json:"days"`
+}
+
+// RecapBadge is an achievement earned during the recap period
+type RecapBadge struct {
+	UserID        string `json:"userId"`
+	AchievementID string `json:"achievementId"`
+	Name          string `json:"name"`
+	Icon          string `json:"icon"`
+}
+
+// RecapReport is one guild's summary of a finished week or month. It is stored as the recap's
+// payload, so fields can be added but not renamed.
+type RecapReport struct {
+	GuildID      string          `json:"guildId"`
+	Period       string          `json:"period"`
+	PeriodStart  time.Time       `json:"periodStart"`
+	PeriodEnd    time.Time       `json:"periodEnd"` // Exclusive
+	TotalStudyMs int64           `json:"totalStudyMs"`
+	ActiveUsers  int             `json:"activeUsers"`
+	TopStudiers  []RecapStudier  `json:"topStudiers"`
+	Improvers    []RecapImprover `json:"improvers"`
+	Streaks      []RecapStreak   `json:"streaks"`
+	NewBadges    []RecapBadge    `json:"newBadges"`
+
+	studiers []RecapStudier // Everyone who studied, for personal summaries; not stored
+	previous map[string]int64
+}
+
+// RecapService builds weekly and monthly recaps from per-day study activity, archives them
+// and queues them for posting to each guild's recap channel, plus a personal DM summary for
+// users who opted in
+type RecapService struct {
+	db            database.Querier
+	txManager     database.TxManager
+	notifications *NotificationService
+}
+
+// NewRecapService creates a new RecapService
+func NewRecapService(queries database.Querier, txManager database.TxManager) *RecapService {
+	return &RecapService{
+		db:        queries,
+		txManager: txManager,
+	}
+}
+
+// SetNotificationService sets the dispatcher to wake once recaps are queued
+func (s *RecapService) SetNotificationService(ns *NotificationService) {
+	s.notifications = ns
+}
+
+// RecapPeriodBounds returns the start and exclusive end of the last period of the given kind
+// that finished at or before now, in Manila time
+func RecapPeriodBounds(period string, now time.Time) (time.Time, time.Time, error) {
+	switch period {
+	case RecapPeriodWeekly:
+		end := StartOfWeek(now, manilaLocation)
+		return end.AddDate(0, 0, -7), end, nil
+	case RecapPeriodMonthly:
+		end := StartOfMonth(now, manilaLocation)
+		return end.AddDate(0, -1, 0), end, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown recap period %q", period)
+	}
+}
+
+// PostRecaps builds the recap for every guild that studied during the period that just ended,
+// archives it and queues the guild post and personal DMs in one transaction per guild.
+// Recaps that were already archived are skipped, so running it twice doesn't post twice.
+// It returns how many recaps were created.
+func (s *RecapService) PostRecaps(ctx context.Context, period string, now time.Time) (int, error) {
+	start, end, err := RecapPeriodBounds(period, now)
+	if err != nil {
+		return 0, err
+	}
+	reports, err := s.BuildReports(ctx, period, start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, report := range reports {
+		ok, err := s.archiveAndQueue(ctx, report)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to post recap", "period", period, "guild_id", report.GuildID, "error", err)
+			continue
+		}
+		if ok {
+			created++
+		}
+	}
+
+	if created > 0 && s.notifications != nil {
+		s.notifications.Wake()
+	}
+	slog.InfoContext(ctx, "Created recaps", "count", created, "period", period, "start", start.Format("2006-01-02"))
+	return created, nil
+}
+
+// BuildReports gathers the recap for every guild with study activity in [start, end)
+func (s *RecapService) BuildReports(ctx context.Context, period string, start, end time.Time) ([]RecapReport, error) {
+	totals, err := s.db.GetGuildStudyTotals(ctx, database.GetGuildStudyTotalsParams{FromDate: start, ToDate: end})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get study totals: %w", err)
+	}
+	previousStart := start.AddDate(0, 0, -7)
+	if period == RecapPeriodMonthly {
+		previousStart = start.AddDate(0, -1, 0)
+	}
+	previousTotals, err := s.db.GetGuildStudyTotals(ctx, database.GetGuildStudyTotalsParams{FromDate: previousStart, ToDate: start})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous study totals: %w", err)
+	}
+
+	previous := make(map[string]map[string]int64)
+	for _, row := range previousTotals {
+		if previous[row.GuildID] == nil {
+			previous[row.GuildID] = make(map[string]int64)
+		}
+		previous[row.GuildID][row.UserID] = row.StudyMs
+	}
+
+	var reports []RecapReport
+	for _, row := range totals {
+		if len(reports) == 0 || reports[len(reports)-1].GuildID != row.GuildID {
+			reports = append(reports, RecapReport{
+				GuildID:     row.GuildID,
+				Period:      period,
+				PeriodStart: start,
+				PeriodEnd:   end,
+				previous:    previous[row.GuildID],
+			})
+		}
+		report := &reports[len(reports)-1]
+		report.TotalStudyMs += row.StudyMs
+		report.ActiveUsers++
+		report.studiers = append(report.studiers, RecapStudier{UserID: row.UserID, StudyMs: row.StudyMs})
+	}
+
+	for i := range reports {
+		if err := s.fillReport(ctx, &reports[i]); err != nil {
+			return nil, fmt.Errorf("failed to build recap for guild %s: %w", reports[i].GuildID, err)
+		}
+	}
+	return reports, nil
+}
+
+// fillReport adds the rankings, streaks and badges to a report whose studiers are already set
+func (s *RecapService) fillReport(ctx context.Context, report *RecapReport) error {
+	// Totals arrive sorted by study time
+	report.TopStudiers = append([]RecapStudier(nil), report.studiers[:min(len(report.studiers), recapTopStudiers)]...)
+
+	// Only users who also studied last period count as improving, otherwise every newcomer would top the list
+	for _, st := range report.studiers {
+		if prev := report.previous[st.UserID]; prev > 0 && st.StudyMs > prev {
+			report.Improvers = append(report.Improvers, RecapImprover{UserID: st.UserID, StudyMs: st.StudyMs, PreviousStudyMs: prev})
+		}
+	}
+	sort.SliceStable(report.Improvers, func(i, j int) bool {
+		gainI := report.Improvers[i].StudyMs - report.Improvers[i].PreviousStudyMs
+		gainJ := report.Improvers[j].StudyMs - report.Improvers[j].PreviousStudyMs
+		return gainI > gainJ
+	})
+	if len(report.Improvers) > recapImprovers {
+		report.Improvers = report.Improvers[:recapImprovers]
+	}
+
+	streaks, err := s.db.GetGuildTopStreaks(ctx, database.GetGuildTopStreaksParams{GuildID: report.GuildID, Limit: recapStreaks})
+	if err != nil {
+		return fmt.Errorf("failed to get top streaks: %w", err)
+	}
+	for _, st := range streaks {
+		report.Streaks = append(report.Streaks, RecapStreak{UserID: st.UserID, Days: st.CurrentStreakCount})
+	}
+
+	badges, err := s.db.GetGuildAchievementsEarnedBetween(ctx, database.GetGuildAchievementsEarnedBetweenParams{
+		GuildID:  report.GuildID,
+		FromTime: sql.NullTime{Time: report.PeriodStart, Valid: true},
+		ToTime:   sql.NullTime{Time: report.PeriodEnd, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get earned badges: %w", err)
+	}
+	for _, b := range badges {
+		report.NewBadges = append(report.NewBadges, RecapBadge{UserID: b.UserID, AchievementID: b.AchievementID, Name: b.Name, Icon: b.Icon})
+	}
+	return nil
+}
+
+// archiveAndQueue stores the report and queues its messages. It returns false if the recap was already archived.
+func (s *RecapService) archiveAndQueue(ctx context.Context, report RecapReport) (bool, error) {
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode recap: %w", err)
+	}
+
+	created := false
+	err = s.txManager.ExecTx(ctx, func(q database.Querier) error {
+		rows, err := q.CreateRecap(ctx, database.CreateRecapParams{
+			GuildID:     report.GuildID,
+			Period:      report.Period,
+			PeriodStart: report.PeriodStart,
+			PeriodEnd:   report.PeriodEnd,
+			Payload:     payload,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to archive recap: %w", err)
+		}
+		if rows == 0 {
+			return nil
+		}
+		created = true
+
+		// Guilds that haven't picked a channel with /recap channel only get the archive and DMs
+		settings, err := q.GetGuildSettings(ctx, report.GuildID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get guild settings: %w", err)
+		}
+
+		periodKey := fmt.Sprintf("%s:%s:%s", report.Period, report.GuildID, report.PeriodStart.Format("2006-01-02"))
+		if settings.RecapChannelID.Valid {
+			err = enqueueNotification(ctx, q, Notification{
+				DedupeKey:     "recap:" + periodKey,
+				Kind:          NotificationKindRecap,
+				GuildID:       report.GuildID,
+				ChannelID:     settings.RecapChannelID.String,
+				Embed:         BuildRecapEmbed(report),
+				AllowFallback: true,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		subscribers, err := q.GetRecapSubscribers(ctx, report.GuildID)
+		if err != nil {
+			return fmt.Errorf("failed to get recap subscribers: %w", err)
+		}
+		for _, userID := range subscribers {
+			err = enqueueNotification(ctx, q, Notification{
+				DedupeKey:     fmt.Sprintf("recap_dm:%s:%s", periodKey, userID),
+				Kind:          NotificationKindRecap,
+				GuildID:       report.GuildID,
+				UserID:        userID,
+				Embed:         buildPersonalRecapEmbed(report, userID),
+				DirectMessage: true,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return created, err
+}
+
+// GetRecap loads an archived recap for a guild; ago counts back from the most recent one
+func (s *RecapService) GetRecap(ctx context.Context, guildID, period string, ago int) (RecapReport, error) {
+	row, err := s.db.GetRecap(ctx, database.GetRecapParams{GuildID: guildID, Period: period, Offset: int32(ago)})
+	if err != nil {
+		return RecapReport{}, err
+	}
+	var report RecapReport
+	if err := json.Unmarshal(row.Payload, &report); err != nil {
+		return RecapReport{}, fmt.Errorf("failed to decode recap %d: %w", row.ID, err)
+	}
+	return report, nil
+}
+
+// SetRecapChannel sets the channel the guild's recaps are posted to; an empty channelID stops posting.
+// The change is recorded in the audit log in the same transaction.
+func (s *RecapService) SetRecapChannel(ctx context.Context, guildID, actorID, channelID string) error {
+	return s.txManager.ExecTx(ctx, func(q database.Querier) error {
+		settings, err := q.GetGuildSettings(ctx, guildID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get guild settings: %w", err)
+		}
+
+		err = q.UpsertGuildRecapChannel(ctx, database.UpsertGuildRecapChannelParams{
+			GuildID:        guildID,
+			RecapChannelID: sql.NullString{String: channelID, Valid: channelID != ""},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save recap channel: %w", err)
+		}
+
+		return recordAudit(ctx, q, AuditEntry{
+			GuildID: guildID,
+			ActorID: actorID,
+			Action:  AuditActionRecapChannel,
+			Details: map[string]any{"oldChannelId": settings.RecapChannelID.String, "channelId": channelID},
+		})
+	})
+}
+
+// SetDMSubscription turns the user's personal recap DMs for a guild on or off
+func (s *RecapService) SetDMSubscription(ctx context.Context, userID, guildID string, enabled bool) error {
+	if enabled {
+		return s.db.AddRecapSubscription(ctx, database.AddRecapSubscriptionParams{UserID: userID, GuildID: guildID})
+	}
+	_, err := s.db.DeleteRecapSubscription(ctx, database.DeleteRecapSubscriptionParams{UserID: userID, GuildID: guildID})
+	return err
+}
+
+// removeUser drops every entry for userID from the report. The totals are kept, since they no
+// longer say anything about one member.
+func (r *RecapReport) removeUser(userID string) {
+	r.TopStudiers = slices.DeleteFunc(r.TopStudiers, func(st RecapStudier) bool { return st.UserID == userID })
+	r.Improvers = slices.DeleteFunc(r.Improvers, func(im RecapImprover) bool { return im.UserID == userID })
+	r.Streaks = slices.DeleteFunc(r.Streaks, func(st RecapStreak) bool { return st.UserID == userID })
+	r.NewBadges = slices.DeleteFunc(r.NewBadges, func(b RecapBadge) bool { return b.UserID == userID })
+}
+
+// recapTitle names the period, e.g. "Weekly Recap · Oct 11 – Oct 17"
+func recapTitle(report RecapReport) string {
+	if report.Period == RecapPeriodMonthly {
+		return "Monthly Recap · " + report.PeriodStart.In(manilaLocation).Format("January 2006")
+	}
+	last := report.PeriodEnd.AddDate(0, 0, -1)
+	return fmt.Sprintf("Weekly Recap · %s – %s",
+		report.PeriodStart.In(manilaLocation).Format("Jan 2"), last.In(manilaLocation).Format("Jan 2"))
+}
+
+// formatStudyMs renders milliseconds of study time as hours and minutes
+func formatStudyMs(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// BuildRecapEmbed renders a guild recap
+func BuildRecapEmbed(report RecapReport) *discordgo.MessageEmbed {
+	medals := []string{"🥇", "🥈", "🥉"}
+	var top []string
+	for i, st := range report.TopStudiers {
+		rank := fmt.Sprintf("%d.", i+1)
+		if i < len(medals) {
+			rank = medals[i]
+		}
+		top = append(top, fmt.Sprintf("%s <@%s> · %s", rank, st.UserID, formatStudyMs(st.StudyMs)))
+	}
+
+	var improvers []string
+	for _, im := range report.Improvers {
+		improvers = append(improvers, fmt.Sprintf("<@%s> · %s → %s", im.UserID, formatStudyMs(im.PreviousStudyMs), formatStudyMs(im.StudyMs)))
+	}
+
+	var streaks []string
+	for _, st := range report.Streaks {
+		streaks = append(streaks, fmt.Sprintf("<@%s> · 🔥 %d days", st.UserID, st.Days))
+	}
+
+	var badges []string
+	for i, b := range report.NewBadges {
+		if i == recapMaxBadges {
+			badges = append(badges, fmt.Sprintf("…and %d more", len(report.NewBadges)-recapMaxBadges))
+			break
+		}
+		badges = append(badges, fmt.Sprintf("%s **%s** · <@%s>", b.Icon, b.Name, b.UserID))
+	}
+
+	orNone := func(lines []string) string {
+		if len(lines) == 0 {
+			return "None this time"
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "📊 " + recapTitle(report),
+		Description: fmt.Sprintf("The community studied **%s** together across **%d** members.", formatStudyMs(report.TotalStudyMs), report.ActiveUsers),
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "🏆 Top Studiers", Value: orNone(top)},
+			{Name: "📈 Biggest Improvers", Value: orNone(improvers)},
+			{Name: "🔥 Longest Streaks", Value: orNone(streaks)},
+			{Name: "🏅 New Badges", Value: orNone(badges)},
+		},
+		Footer:    &discordgo.MessageEmbedFooter{Text: "Use /recap dm to get your personal summary · LockIn Bot"},
+		Timestamp: report.PeriodEnd.Format(time.RFC3339),
+	}
+}
+
+// buildPersonalRecapEmbed renders a subscriber's own numbers from a guild recap
+func buildPersonalRecapEmbed(report RecapReport, userID string) *discordgo.MessageEmbed {
+	var studyMs int64
+	rank := 0
+	for i, st := range report.studiers {
+		if st.UserID == userID {
+			studyMs, rank = st.StudyMs, i+1
+			break
+		}
+	}
+
+	description := "You didn't study this time. A fresh period starts now!"
+	if rank > 0 {
+		description = fmt.Sprintf("You studied **%s**, ranking **#%d** of %d.", formatStudyMs(studyMs), rank, report.ActiveUsers)
+	}
+
+	previous := "No study time"
+	if prev := report.previous[userID]; prev > 0 {
+		previous = formatStudyMs(prev)
+	}
+
+	var badges []string
+	for _, b := range report.NewBadges {
+		if b.UserID == userID {
+			badges = append(badges, fmt.Sprintf("%s %s", b.Icon, b.Name))
+		}
+	}
+	earned := "None this time"
+	if len(badges) > 0 {
+		earned = strings.Join(badges, "\n")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "📬 Your " + recapTitle(report),
+		Description: description,
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Previous Period", Value: previous, Inline: true},
+			{Name: "Community Total", Value: formatStudyMs(report.TotalStudyMs), Inline: true},
+			{Name: "🏅 Badges Earned", Value: earned},
+		},
+		Footer:    &discordgo.MessageEmbedFooter{Text: "Use /recap dm enabled:false to stop these · LockIn Bot"},
+		Timestamp: report.PeriodEnd.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+)
+
+// defaultReminderMinute is 8:00 PM Manila, the warning time used before schedules were configurable
+const defaultReminderMinute = 20 * 60
+
+// MaxReminders is the most reminder times a guild or user can set
+const MaxReminders = 6
+
+// ReminderSchedule is when a user is reminded about their streak in a guild. Minutes are
+// minutes after midnight Manila time.
+type ReminderSchedule struct {
+	Minutes      []int // The times that apply to the user
+	GuildMinutes []int // The guild's times, used unless the user set their own
+	Custom       bool  // The user set their own times
+}
+
+// ParseReminderTimes parses a comma separated list of 24-hour Manila times such as "18:00, 22:30"
+// into sorted minutes after midnight, dropping duplicates
+func ParseReminderTimes(input string) ([]int, error) {
+	seen := make(map[int]bool)
+	var minutes []int
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		t, err := time.Parse("15:04", part)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a time like 18:00", part)
+		}
+		m := t.Hour()*60 + t.Minute()
+		if !seen[m] {
+			seen[m] = true
+			minutes = append(minutes, m)
+		}
+	}
+	if len(minutes) == 0 {
+		return nil, errors.New("no reminder times given")
+	}
+	if len(minutes) > MaxReminders {
+		return nil, fmt.Errorf("at most %d reminder times can be set", MaxReminders)
+	}
+	sort.Ints(minutes)
+	return minutes, nil
+}
+
+// FormatReminderTimes renders minutes after midnight as a list like "18:00, 22:30"
+func FormatReminderTimes(minutes []int) string {
+	parts := make([]string, len(minutes))
+	for i, m := range minutes {
+		parts[i] = fmt.Sprintf("%02d:%02d", m/60, m%60)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// latestReminder returns the most recent reminder time on now's Manila day that is not after now
+func latestReminder(minutes []int, now time.Time) (time.Time, bool) {
+	day := StartOfDay(now, manilaLocation)
+	var latest time.Time
+	found := false
+	for _, m := range minutes {
+		at := time.Date(day.Year(), day.Month(), day.Day(), m/60, m%60, 0, 0, manilaLocation)
+		if !at.After(now) && (!found || at.After(latest)) {
+			latest, found = at, true
+		}
+	}
+	return latest, found
+}
+
+func toMinutes(values []int32) []int {
+	minutes := make([]int, len(values))
+	for i, v := range values {
+		minutes[i] = int(v)
+	}
+	return minutes
+}
+
+func toInt32s(minutes []int) []int32 {
+	values := make([]int32, len(minutes))
+	for i, m := range minutes {
+		values[i] = int32(m)
+	}
+	return values
+}
+
+// newReminderSchedule applies a user's own times over their guild's, falling back to the
+// default for guilds that never set any
+func newReminderSchedule(guildMinutes, userMinutes []int32) ReminderSchedule {
+	guild := []int{defaultReminderMinute}
+	if len(guildMinutes) > 0 {
+		guild = toMinutes(guildMinutes)
+	}
+	schedule := ReminderSchedule{Minutes: guild, GuildMinutes: guild}
+	if len(userMinutes) > 0 {
+		schedule.Minutes = toMinutes(userMinutes)
+		schedule.Custom = true
+	}
+	return schedule
+}
+
+// GetReminderSchedule returns the reminder times that apply to a user in a guild
+func (s *StreakService) GetReminderSchedule(ctx context.Context, userID, guildID string) (ReminderSchedule, error) {
+	settings, err := s.dbQueries.GetGuildSettings(ctx, guildID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return ReminderSchedule{}, fmt.Errorf("failed to get guild settings: %w", err)
+	}
+
+	custom, err := s.dbQueries.GetUserReminderSettings(ctx, database.GetUserReminderSettingsParams{
+		UserID:  userID,
+		GuildID: guildID,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return ReminderSchedule{}, fmt.Errorf("failed to get user reminder settings: %w", err)
+	}
+	return newReminderSchedule(settings.ReminderMinutes, custom.ReminderMinutes), nil
+}
+
+// SetGuildReminderTimes sets when members of a guild are reminded about their streaks
+func (s *StreakService) SetGuildReminderTimes(ctx context.Context, guildID string, minutes []int) error {
+	if err := validateReminderMinutes(minutes); err != nil {
+		return err
+	}
+	return s.dbQueries.UpsertGuildReminderMinutes(ctx, database.UpsertGuildReminderMinutesParams{
+		GuildID:         guildID,
+		ReminderMinutes: toInt32s(minutes),
+	})
+}
+
+// SetUserReminderTimes gives a user their own reminder times in a guild
+func (s *StreakService) SetUserReminderTimes(ctx context.Context, userID, guildID string, minutes []int) error {
+	if err := validateReminderMinutes(minutes); err != nil {
+		return err
+	}
+	return s.dbQueries.UpsertUserReminderMinutes(ctx, database.UpsertUserReminderMinutesParams{
+		UserID:          userID,
+		GuildID:         guildID,
+		ReminderMinutes: toInt32s(minutes),
+	})
+}
+
+// ClearUserReminderTimes puts a user back on the guild's reminder times
+func (s *StreakService) ClearUserReminderTimes(ctx context.Context, userID, guildID string) error {
+	_, err := s.dbQueries.ClearUserReminderMinutes(ctx, database.ClearUserReminderMinutesParams{
+		UserID:  userID,
+		GuildID: guildID,
+	})
+	return err
+}
+
+func validateReminderMinutes(minutes []int) error {
+	if len(minutes) == 0 || len(minutes) > MaxReminders {
+		return fmt.Errorf("between 1 and %d reminder times are required", MaxReminders)
+	}
+	for _, m := range minutes {
+		if m < 0 || m >= 24*60 {
+			return fmt.Errorf("reminder minute %d is out of range", m)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReminderTimes(t *testing.T) {
+	minutes, err := ParseReminderTimes(" 22:00, 18:00,8:30, 18:00 ")
+	require.NoError(t, err)
+	assert.Equal(t, []int{8*60 + 30, 18 * 60, 22 * 60}, minutes)
+	assert.Equal(t, "08:30, 18:00, 22:00", FormatReminderTimes(minutes))
+
+	for _, input := range []string{"", " , ", "6pm", "24:00", "18:00, 25:10", "1:00,2:00,3:00,4:00,5:00,6:00,7:00"} {
+		_, err := ParseReminderTimes(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestLatestReminder(t *testing.T) {
+	loc := GetManilaLocation()
+	minutes := []int{18 * 60, 22 * 60}
+
+	_, ok := latestReminder(minutes, time.Date(2026, 10, 14, 17, 59, 0, 0, loc))
+	assert.False(t, ok)
+
+	at, ok := latestReminder(minutes, time.Date(2026, 10, 14, 18, 0, 0, 0, loc))
+	assert.True(t, ok)
+	assert.True(t, at.Equal(time.Date(2026, 10, 14, 18, 0, 0, 0, loc)))
+
+	// 23:30 Manila, given in UTC
+	at, ok = latestReminder(minutes, time.Date(2026, 10, 14, 15, 30, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.True(t, at.Equal(time.Date(2026, 10, 14, 22, 0, 0, 0, loc)))
+}
+
+func TestNewReminderSchedule(t *testing.T) {
+	assert.Equal(t, ReminderSchedule{Minutes: []int{20 * 60}, GuildMinutes: []int{20 * 60}}, newReminderSchedule(nil, nil))
+	assert.Equal(t, ReminderSchedule{Minutes: []int{18 * 60}, GuildMinutes: []int{18 * 60}}, newReminderSchedule([]int32{18 * 60}, nil))
+	assert.Equal(t, ReminderSchedule{Minutes: []int{21 * 60}, GuildMinutes: []int{18 * 60}, Custom: true}, newReminderSchedule([]int32{18 * 60}, []int32{21 * 60}))
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+)
+
+// AuditActionCleanupSessions is recorded in the audit log when a guild's study sessions are cleaned up
+const AuditActionCleanupSessions = "cleanup_sessions"
+
+// CleanupPreview counts the sessions a cleanup would touch
+type CleanupPreview struct {
+	ClosedSessions int64 // Finished sessions that would be archived and deleted
+	OpenSessions   int64 // Sessions still in progress, which are always kept
+}
+
+// CleanupRequest describes a guild's study sessions to clean up
+type CleanupRequest struct {
+	GuildID string
+	ActorID string    // The admin or moderator who confirmed, empty when run from the maintenance script
+	Before  time.Time // Only sessions that started before this are removed
+}
+
+// CleanupResult reports what a cleanup removed
+type CleanupResult struct {
+	Archived     int64 `json:"archived"`
+	Deleted      int64 `json:"deleted"`
+	OpenSessions int64 `json:"openSessions"` // Kept because they hadn't ended yet
+}
+
+// PreviewCleanup counts the guild's sessions that started before the cutoff without changing anything
+func (s *SessionService) PreviewCleanup(ctx context.Context, guildID string, before time.Time) (CleanupPreview, error) {
+	if guildID == "" {
+		return CleanupPreview{}, fmt.Errorf("guild ID is required")
+	}
+
+	var preview CleanupPreview
+	err := s.txManager.ExecTx(ctx, func(q database.Querier) error {
+		counts, err := q.CountGuildStudySessions(ctx, database.CountGuildStudySessionsParams{
+			GuildID:   sql.NullString{String: guildID, Valid: true},
+			StartTime: before,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to count study sessions: %w", err)
+		}
+		preview = CleanupPreview{ClosedSessions: counts.ClosedSessions, OpenSessions: counts.OpenSessions}
+		return nil
+	})
+	if err != nil {
+		return CleanupPreview{}, err
+	}
+	return preview, nil
+}
+
+// CleanupSessions copies the guild's finished sessions that started before the cutoff into
+// study_sessions_archive and then deletes them, in a single transaction with an audit log entry.
+// Open sessions are never touched. User stats are aggregated separately and aren't changed.
+func (s *SessionService) CleanupSessions(ctx context.Context, req CleanupRequest) (CleanupResult, error) {
+	if req.GuildID == "" {
+		return CleanupResult{}, fmt.Errorf("guild ID is required")
+	}
+
+	var result CleanupResult
+	guildID := sql.NullString{String: req.GuildID, Valid: true}
+	actorID := sql.NullString{String: req.ActorID, Valid: req.ActorID != ""}
+
+	err := s.txManager.ExecTx(ctx, func(q database.Querier) error {
+		counts, err := q.CountGuildStudySessions(ctx, database.CountGuildStudySessionsParams{
+			GuildID:   guildID,
+			StartTime: req.Before,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to count study sessions: %w", err)
+		}
+		result.OpenSessions = counts.OpenSessions
+
+		result.Archived, err = q.ArchiveGuildStudySessions(ctx, database.ArchiveGuildStudySessionsParams{
+			ArchivedBy: actorID,
+			GuildID:    guildID,
+			Before:     req.Before,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to archive study sessions: %w", err)
+		}
+
+		result.Deleted, err = q.DeleteGuildStudySessionsWithCount(ctx, database.DeleteGuildStudySessionsWithCountParams{
+			GuildID:   guildID,
+			StartTime: req.Before,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete study sessions: %w", err)
+		}
+		// Never delete a row that wasn't archived first
+		if result.Deleted != result.Archived {
+			return fmt.Errorf("archived %d study sessions but would delete %d", result.Archived, result.Deleted)
+		}
+
+		return recordAudit(ctx, q, AuditEntry{
+			GuildID: req.GuildID,
+			ActorID: req.ActorID,
+			Action:  AuditActionCleanupSessions,
+			Details: map[string]any{
+				"before":       req.Before.UTC().Format(time.RFC3339),
+				"archived":     result.Archived,
+				"deleted":      result.Deleted,
+				"openSessions": result.OpenSessions,
+			},
+		})
+	})
+	if err != nil {
+		return CleanupResult{}, err
+	}
+
+	slog.InfoContext(ctx, "Cleaned up study sessions", "actor_id", req.ActorID, "guild_id", req.GuildID,
+		"deleted", result.Deleted, "before", req.Before.Format(time.RFC3339), "open_sessions", result.OpenSessions)
+	return result, nil
+}
+
+// PurgeOldSessions is the nightly counterpart to CleanupSessions for every guild at once, including
+// sessions recorded before guild scoping that have no guild. Finished sessions that started before
+// the cutoff are archived and then deleted in one transaction; open sessions are kept.
+func (s *SessionService) PurgeOldSessions(ctx context.Context, before time.Time) (CleanupResult, error) {
+	var result CleanupResult
+	err := s.txManager.ExecTx(ctx, func(q database.Querier) error {
+		var err error
+		result.Archived, err = q.ArchiveOldStudySessions(ctx, before)
+		if err != nil {
+			return fmt.Errorf("failed to archive study sessions: %w", err)
+		}
+
+		result.Deleted, err = q.DeleteOldStudySessions(ctx, before)
+		if err != nil {
+			return fmt.Errorf("failed to delete study sessions: %w", err)
+		}
+		// Never delete a row that wasn't archived first
+		if result.Deleted != result.Archived {
+			return fmt.Errorf("archived %d study sessions but would delete %d", result.Archived, result.Deleted)
+		}
+		return nil
+	})
+	if err != nil {
+		return CleanupResult{}, err
+	}
+
+	slog.InfoContext(ctx, "Purged old study sessions", "deleted", result.Deleted, "before", before.Format(time.RFC3339))
+	return result, nil
+}
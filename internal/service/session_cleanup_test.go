@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCleanupSessions_AbortsWhenDeleteDoesNotMatchArchive(t *testing.T) {
+	mockDB := new(MockQuerier)
+	tx := &mockTxManager{q: mockDB}
+	service := NewSessionService(tx)
+	before := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+	mockDB.On("CountGuildStudySessions", mock.Anything, mock.Anything).Return(database.CountGuildStudySessionsRow{ClosedSessions: 3, OpenSessions: 1}, nil).Once()
+	mockDB.On("ArchiveGuildStudySessions", mock.Anything, mock.Anything).Return(int64(3), nil).Once()
+	mockDB.On("DeleteGuildStudySessionsWithCount", mock.Anything, mock.Anything).Return(int64(4), nil).Once()
+
+	_, err := service.CleanupSessions(context.Background(), CleanupRequest{GuildID: "test-guild", ActorID: "mod", Before: before})
+
+	assert.ErrorContains(t, err, "archived 3 study sessions but would delete 4")
+	assert.False(t, tx.committed)
+	mockDB.AssertNotCalled(t, "CreateAuditLogEntry", mock.Anything, mock.Anything)
+	mockDB.AssertExpectations(t)
+}
+
+func TestCleanupSessions_RequiresGuild(t *testing.T) {
+	mockDB := new(MockQuerier)
+	service := NewSessionService(&mockTxManager{q: mockDB})
+
+	_, err := service.PreviewCleanup(context.Background(), "", time.Now())
+	assert.Error(t, err)
+	_, err = service.CleanupSessions(context.Background(), CleanupRequest{Before: time.Now()})
+	assert.Error(t, err)
+	mockDB.AssertExpectations(t)
+}
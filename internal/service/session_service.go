@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+)
+
+// ErrNoActiveSession is returned by EndSession when the user has no open study session
+var ErrNoActiveSession = errors.New("no active study session")
+
+// SessionService owns the write path for finishing a study session
+type SessionService struct {
+	txManager          database.TxManager
+	streakService      *StreakService
+	achievementService *AchievementService
+	notifications      *NotificationService
+}
+
+// NewSessionService creates a new SessionService
+func NewSessionService(txManager database.TxManager) *SessionService {
+	return &SessionService{
+		txManager: txManager,
+	}
+}
+
+// SetStreakService sets the streak service used to credit daily activity minutes
+func (s *SessionService) SetStreakService(ss *StreakService) {
+	s.streakService = ss
+}
+
+// SetAchievementService sets the achievement service used to check session achievements
+func (s *SessionService) SetAchievementService(as *AchievementService) {
+	s.achievementService = as
+}
+
+// SetNotificationService sets the dispatcher to wake once a session's notifications are committed
+func (s *SessionService) SetNotificationService(ns *NotificationService) {
+	s.notifications = ns
+}
+
+// EndSessionRequest identifies the session to end
+type EndSessionRequest struct {
+	UserID  string
+	GuildID string // Guild the session was tracked in; streaks and achievements are skipped when empty
+	EndTime time.Time
+
+	// Set when the bot ends the session itself rather than the member leaving voice, e.g.
+	// AuditActionSessionTimeout, to record the end in the audit log with Reason
+	AuditAction string
+	Reason      string
+}
+
+// EndSessionResult describes a session that was ended and credited
+type EndSessionResult struct {
+	Session database.StudySession // The ended session with its duration filled in
+	Stats   database.UserStat     // The user's stats after crediting, zero if the session had no duration
+}
+
+// Duration returns how long the ended session lasted according to the database
+func (r *EndSessionResult) Duration() time.Duration {
+	if !r.Session.DurationMs.Valid {
+		return 0
+	}
+	return time.Duration(r.Session.DurationMs.Int64) * time.Millisecond
+}
+
+// EndSession ends the user's open study session and credits user stats, streak activity minutes
+// and achievement progress in a single transaction. If any step fails nothing is written.
+// Streak and achievement notifications are queued in the same transaction, so they are only
+// delivered if it commits.
+func (s *SessionService) EndSession(ctx context.Context, req EndSessionRequest) (*EndSessionResult, error) {
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user ID is required")
+	}
+
+	result := &EndSessionResult{}
+
+	err := s.txManager.ExecTx(ctx, func(q database.Querier) error {
+		activeSession, err := q.GetActiveStudySession(ctx, sql.NullString{String: req.UserID, Valid: true})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNoActiveSession
+			}
+			return fmt.Errorf("failed to get active session: %w", err)
+		}
+
+		endedSession, err := q.EndStudySession(ctx, database.EndStudySessionParams{
+			SessionID: activeSession.SessionID,
+			EndTime:   sql.NullTime{Time: req.EndTime, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to end session %d: %w", activeSession.SessionID, err)
+		}
+		result.Session = endedSession
+
+		if req.AuditAction != "" {
+			err = recordAudit(ctx, q, AuditEntry{
+				GuildID:      req.GuildID,
+				TargetUserID: req.UserID,
+				Action:       req.AuditAction,
+				Reason:       req.Reason,
+				Details: map[string]any{
+					"sessionId":  endedSession.SessionID,
+					"durationMs": endedSession.DurationMs.Int64,
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if !endedSession.DurationMs.Valid || endedSession.DurationMs.Int64 <= 0 {
+			return nil // Nothing to credit
+		}
+
+		// A session that crossed a daily, weekly or monthly reset only adds its share
+		// after the reset to that period's counter
+		endTime := endedSession.StartTime.Add(result.Duration())
+		shares := SessionShares(endedSession.StartTime, endTime, GetManilaLocation())
+		stats, err := q.CreateOrUpdateUserStats(ctx, database.CreateOrUpdateUserStatsParams{
+			UserID:         req.UserID,
+			TotalStudyMs:   sql.NullInt64{Int64: endedSession.DurationMs.Int64, Valid: true},
+			DailyStudyMs:   sql.NullInt64{Int64: shares.Day.Milliseconds(), Valid: true},
+			WeeklyStudyMs:  sql.NullInt64{Int64: shares.Week.Milliseconds(), Valid: true},
+			MonthlyStudyMs: sql.NullInt64{Int64: shares.Month.Milliseconds(), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update user stats: %w", err)
+		}
+		result.Stats = stats
+
+		if req.GuildID == "" {
+			return nil
+		}
+
+		if s.streakService != nil {
+			err = s.streakService.recordSessionActivity(ctx, q, req.UserID, req.GuildID, endedSession.StartTime, endTime)
+			if err != nil {
+				return fmt.Errorf("failed to record streak activity: %w", err)
+			}
+		}
+
+		if s.achievementService != nil {
+			txAchievements := s.achievementService.withTx(q)
+			totalHours := float64(stats.TotalStudyMs.Int64) / 1000 / 60 / 60
+			sessionHours := float64(endedSession.DurationMs.Int64) / 1000 / 60 / 60
+			err = txAchievements.CheckSessionAchievements(ctx, req.UserID, req.GuildID, totalHours, sessionHours, endedSession.StartTime)
+			if err != nil {
+				return fmt.Errorf("failed to check achievements: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Ended study session", "session_id", result.Session.SessionID, "user_id", req.UserID,
+		"guild_id", req.GuildID, "duration_ms", result.Session.DurationMs.Int64)
+
+	if s.notifications != nil {
+		s.notifications.Wake()
+	}
+
+	return result, nil
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/config"
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// createTestSessionService wires a SessionService with streak and achievement services on the same mock
+func createTestSessionService(mockDB *MockQuerier) (*SessionService, *mockTxManager) {
+	tx := &mockTxManager{q: mockDB}
+	achievementService, _ := createTestAchievementService(mockDB)
+
+	sessionService := NewSessionService(tx)
+	sessionService.SetStreakService(&StreakService{
+		dbQueries:      mockDB,
+		discordSession: &discordgo.Session{},
+		cfg:            &config.Config{},
+		logger:         slog.Default(),
+	})
+	sessionService.SetAchievementService(achievementService)
+
+	return sessionService, tx
+}
+
+func TestEndSession_CreditsEverythingInOneTransaction(t *testing.T) {
+	mockDB := new(MockQuerier)
+	service, tx := createTestSessionService(mockDB)
+
+	userID := "test-user"
+	guildID := "test-guild"
+	// Wednesday 10:00 Manila time, so no time-of-day achievements apply
+	start := time.Date(2024, time.March, 6, 10, 0, 0, 0, GetManilaLocation())
+	end := start.Add(30 * time.Minute)
+	nullUserID := sql.NullString{String: userID, Valid: true}
+
+	mockDB.On("GetActiveStudySession", mock.Anything, nullUserID).Return(database.StudySession{SessionID: 42, UserID: nullUserID, StartTime: start}, nil).Once()
+	mockDB.On("EndStudySession", mock.Anything, database.EndStudySessionParams{
+		SessionID: 42,
+		EndTime:   sql.NullTime{Time: end, Valid: true},
+	}).Return(database.StudySession{
+		SessionID:  42,
+		UserID:     nullUserID,
+		StartTime:  start,
+		EndTime:    sql.NullTime{Time: end, Valid: true},
+		DurationMs: sql.NullInt64{Int64: 30 * 60 * 1000, Valid: true},
+	}, nil).Once()
+	mockDB.On("CreateOrUpdateUserStats", mock.Anything, database.CreateOrUpdateUserStatsParams{
+		UserID:         userID,
+		TotalStudyMs:   sql.NullInt64{Int64: 30 * 60 * 1000, Valid: true},
+		DailyStudyMs:   sql.NullInt64{Int64: 30 * 60 * 1000, Valid: true},
+		WeeklyStudyMs:  sql.NullInt64{Int64: 30 * 60 * 1000, Valid: true},
+		MonthlyStudyMs: sql.NullInt64{Int64: 30 * 60 * 1000, Valid: true},
+	}).Return(database.UserStat{UserID: userID, TotalStudyMs: sql.NullInt64{Int64: 30 * 60 * 1000, Valid: true}}, nil).Once()
+
+	// Streak activity for a user not yet in the streak system
+	mockDB.On("GetUserStreak", mock.Anything, database.GetUserStreakParams{UserID: userID, GuildID: guildID}).Return(database.GetUserStreakRow{}, sql.ErrNoRows).Once()
+	mockDB.On("StartDailyActivity", mock.Anything, mock.AnythingOfType("database.StartDailyActivityParams")).Return(database.StartDailyActivityRow{}, nil).Once()
+	mockDB.On("AddDailyActivity", mock.Anything, database.AddDailyActivityParams{
+		UserID:       userID,
+		GuildID:      guildID,
+		ActivityDate: time.Date(2024, time.March, 6, 0, 0, 0, 0, GetManilaLocation()),
+		StudyMs:      30 * 60 * 1000,
+	}).Return(nil).Once()
+	mockDB.On("UpdateDailyActivityMinutes", mock.Anything, mock.MatchedBy(func(params database.UpdateDailyActivityMinutesParams) bool {
+		return params.UserID == userID && params.DailyActivityMinutes.Int32 == 30
+	})).Return(nil).Once()
+	// The daily completion message checks the user's preferences, then is skipped without a streak channel
+	mockDB.On("GetNotificationPreferences", mock.Anything, userID).Return(database.NotificationPreference{}, sql.ErrNoRows).Once()
+
+	// Achievement checks that hit the database
+	mockDB.On("GetUniqueStudyHours", mock.Anything, nullUserID).Return(int32(1), nil).Once()
+	mockDB.On("HasDawnToDuskDay", mock.Anything, nullUserID).Return(false, nil).Once()
+
+	result, err := service.EndSession(context.Background(), EndSessionRequest{
+		UserID:  userID,
+		GuildID: guildID,
+		EndTime: end,
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, tx.committed)
+	assert.Equal(t, int32(42), result.Session.SessionID)
+	assert.Equal(t, 30*time.Minute, result.Duration())
+	mockDB.AssertExpectations(t)
+}
+
+func TestEndSession_StatsFailureRollsBack(t *testing.T) {
+	mockDB := new(MockQuerier)
+	service, tx := createTestSessionService(mockDB)
+
+	userID := "test-user"
+	start := time.Now().Add(-time.Hour)
+	nullUserID := sql.NullString{String: userID, Valid: true}
+
+	mockDB.On("GetActiveStudySession", mock.Anything, nullUserID).Return(database.StudySession{SessionID: 7, StartTime: start}, nil).Once()
+	mockDB.On("EndStudySession", mock.Anything, mock.AnythingOfType("database.EndStudySessionParams")).Return(database.StudySession{
+		SessionID:  7,
+		StartTime:  start,
+		DurationMs: sql.NullInt64{Int64: 60 * 60 * 1000, Valid: true},
+	}, nil).Once()
+	mockDB.On("CreateOrUpdateUserStats", mock.Anything, mock.Anything).Return(database.UserStat{}, errors.New("connection reset")).Once()
+
+	result, err := service.EndSession(context.Background(), EndSessionRequest{
+		UserID:  userID,
+		GuildID: "test-guild",
+		EndTime: time.Now(),
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.False(t, tx.committed)
+	mockDB.AssertNotCalled(t, "GetUserStreak", mock.Anything, mock.Anything)
+	mockDB.AssertExpectations(t)
+}
+
+func TestEndSession_AchievementFailureRollsBack(t *testing.T) {
+	mockDB := new(MockQuerier)
+	service, tx := createTestSessionService(mockDB)
+
+	userID := "test-user"
+	guildID := "test-guild"
+	// Wednesday 10:00 Manila time, so no time-of-day achievements apply
+	start := time.Date(2024, time.March, 6, 10, 0, 0, 0, GetManilaLocation())
+	end := start.Add(2 * time.Hour)
+	nullUserID := sql.NullString{String: userID, Valid: true}
+
+	mockDB.On("GetActiveStudySession", mock.Anything, nullUserID).Return(database.StudySession{SessionID: 9, UserID: nullUserID, StartTime: start}, nil).Once()
+	mockDB.On("EndStudySession", mock.Anything, mock.AnythingOfType("database.EndStudySessionParams")).Return(database.StudySession{
+		SessionID:  9,
+		UserID:     nullUserID,
+		StartTime:  start,
+		EndTime:    sql.NullTime{Time: end, Valid: true},
+		DurationMs: sql.NullInt64{Int64: 2 * 60 * 60 * 1000, Valid: true},
+	}, nil).Once()
+	mockDB.On("CreateOrUpdateUserStats", mock.Anything, mock.Anything).Return(database.UserStat{
+		UserID:       userID,
+		TotalStudyMs: sql.NullInt64{Int64: 2 * 60 * 60 * 1000, Valid: true},
+	}, nil).Once()
+
+	mockDB.On("GetUserStreak", mock.Anything, database.GetUserStreakParams{UserID: userID, GuildID: guildID}).Return(database.GetUserStreakRow{}, sql.ErrNoRows).Once()
+	mockDB.On("StartDailyActivity", mock.Anything, mock.Anything).Return(database.StartDailyActivityRow{}, nil).Once()
+	mockDB.On("AddDailyActivity", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDB.On("UpdateDailyActivityMinutes", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDB.On("GetNotificationPreferences", mock.Anything, userID).Return(database.NotificationPreference{}, sql.ErrNoRows).Once()
+
+	// The getting_started award fails after stats and streak activity were written
+	mockDB.On("HasAchievement", mock.Anything, mock.Anything).Return(false, nil).Once()
+	mockDB.On("AwardAchievement", mock.Anything, mock.Anything).Return(database.UserAchievement{}, errors.New("connection reset")).Once()
+
+	result, err := service.EndSession(context.Background(), EndSessionRequest{
+		UserID:  userID,
+		GuildID: guildID,
+		EndTime: end,
+	})
+
+	assert.ErrorContains(t, err, "failed to check achievements")
+	assert.Nil(t, result)
+	assert.False(t, tx.committed, "the session end, stats and streak minutes roll back with the award")
+	mockDB.AssertExpectations(t)
+}
+
+func TestEndSession_NoActiveSession(t *testing.T) {
+	mockDB := new(MockQuerier)
+	service, _ := createTestSessionService(mockDB)
+
+	mockDB.On("GetActiveStudySession", mock.Anything, mock.Anything).Return(database.StudySession{}, sql.ErrNoRows).Once()
+
+	_, err := service.EndSession(context.Background(), EndSessionRequest{UserID: "test-user", EndTime: time.Now()})
+
+	assert.ErrorIs(t, err, ErrNoActiveSession)
+	mockDB.AssertNotCalled(t, "EndStudySession", mock.Anything, mock.Anything)
+}
+
+func TestAchievementServiceWithTx_QueuesNotificationInTransaction(t *testing.T) {
+	rootDB := new(MockQuerier)
+	txDB := new(MockQuerier)
+	service, _ := createTestAchievementService(rootDB)
+	service.achievementChannelID = "test-achievements-channel"
+
+	txService := service.withTx(txDB)
+
+	txDB.On("HasAchievement", mock.Anything, mock.Anything).Return(false, nil).Once()
+	txDB.On("AwardAchievement", mock.Anything, mock.Anything).Return(database.UserAchievement{}, nil).Once()
+	setupFullNotificationMocks(txDB, "test-user", "test-guild", "marathon_runner")
+	txDB.On("EnqueueNotification", mock.Anything, mock.MatchedBy(func(params database.EnqueueNotificationParams) bool {
+		return params.Kind == NotificationKindAchievement &&
+			params.DedupeKey == "achievement:test-guild:test-user:marathon_runner" &&
+			params.ChannelID == "test-achievements-channel"
+	})).Return(int64(1), nil).Once()
+
+	awarded, err := txService.tryAwardAchievement(context.Background(), "test-user", "test-guild", "marathon_runner")
+
+	assert.NoError(t, err)
+	assert.True(t, awarded)
+	txDB.AssertExpectations(t)
+	// Everything went through the transaction, nothing through the root querier
+	rootDB.AssertNotCalled(t, "EnqueueNotification", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/metrics"
+)
+
+// maxCatchUpDays is how many missed days an evaluation run goes back at most. Older days are
+// skipped; in daily mode a streak would already have ended on the first of them.
+const maxCatchUpDays = 14
+
+// errAlreadyEvaluated is returned when another run evaluated the user for the day first
+var errAlreadyEvaluated = errors.New("user already evaluated for this day")
+
+// StreakEvaluationSummary reports what an evaluation run did
+type StreakEvaluationSummary struct {
+	Dates     []time.Time // Days evaluated, oldest first
+	Evaluated int         // Users whose streak was evaluated
+	Skipped   int         // Users already evaluated for the day by an earlier or concurrent run
+	Failed    int         // Users whose evaluation failed; the guild's next run retries them
+}
+
+// streakOutcome is the result of evaluating one user's streak
+type streakOutcome struct {
+	previous int32
+	current  int32
+	repaired bool
+}
+
+// EvaluateAllUserStreaks runs the 11:59 PM evaluation: each guild catches up on any days it missed,
+// then evaluates today
+func (s *StreakService) EvaluateAllUserStreaks(ctx context.Context) {
+	s.evaluateGuildsThrough(ctx, GetTodayManilaDate())
+}
+
+// CatchUpMissedEvaluations evaluates the days up to yesterday (relative to now) that any guild has
+// not been evaluated for, e.g. because the bot was down at 11:59 PM. It is run on startup.
+func (s *StreakService) CatchUpMissedEvaluations(ctx context.Context, now time.Time) StreakEvaluationSummary {
+	return s.evaluateGuildsThrough(ctx, ConvertToManilaDate(now).AddDate(0, 0, -1))
+}
+
+// CatchUpGuildEvaluations evaluates the days up to yesterday (relative to now) that guildID has not
+// been evaluated for. Users already evaluated for a day are skipped, so it is safe to run again.
+func (s *StreakService) CatchUpGuildEvaluations(ctx context.Context, guildID string, now time.Time) (StreakEvaluationSummary, error) {
+	s.evaluationMu.Lock()
+	defer s.evaluationMu.Unlock()
+
+	var summary StreakEvaluationSummary
+	guilds, err := s.dbQueries.GetGuildEvaluationDates(ctx)
+	if err != nil {
+		return summary, fmt.Errorf("failed to get guild evaluation dates: %w", err)
+	}
+	for _, g := range guilds {
+		if g.GuildID == guildID {
+			err = s.catchUpGuild(ctx, g.GuildID, g.LastEvaluatedDate, ConvertToManilaDate(now).AddDate(0, 0, -1), &summary)
+			break
+		}
+	}
+	return summary, err
+}
+
+// EvaluateStreaksForDate evaluates streaks in every guild for todayDate, a Manila calendar date,
+// for all users not yet evaluated for it, based on their activity that day
+func (s *StreakService) EvaluateStreaksForDate(ctx context.Context, todayDate time.Time) StreakEvaluationSummary {
+	s.evaluationMu.Lock()
+	defer s.evaluationMu.Unlock()
+
+	var summary StreakEvaluationSummary
+	guilds, err := s.dbQueries.GetGuildEvaluationDates(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to get guilds for daily streak evaluation", "error", err)
+		return summary
+	}
+	for _, g := range guilds {
+		if err := s.evaluateGuildForDate(ctx, g.GuildID, todayDate, &summary); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to evaluate streaks", "guild_id", g.GuildID, "date", todayDate.Format("2006-01-02"), "error", err)
+		}
+	}
+	return summary
+}
+
+// evaluateGuildsThrough catches every guild up to through, a Manila calendar date
+func (s *StreakService) evaluateGuildsThrough(ctx context.Context, through time.Time) StreakEvaluationSummary {
+	s.evaluationMu.Lock()
+	defer s.evaluationMu.Unlock()
+
+	var summary StreakEvaluationSummary
+	guilds, err := s.dbQueries.GetGuildEvaluationDates(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to get guilds for daily streak evaluation", "error", err)
+		return summary
+	}
+	for _, g := range guilds {
+		if err := s.catchUpGuild(ctx, g.GuildID, g.LastEvaluatedDate, through, &summary); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to evaluate streaks", "guild_id", g.GuildID, "error", err)
+		}
+	}
+	s.logger.InfoContext(ctx, "Finished streak evaluation", "through", through.Format("2006-01-02"),
+		"evaluated", summary.Evaluated, "skipped", summary.Skipped, "failed", summary.Failed)
+	return summary
+}
+
+// catchUpGuild evaluates guildID for each day after lastEvaluated up to through, oldest first. It
+// stops at the first day with a failure so no later day is evaluated before it.
+func (s *StreakService) catchUpGuild(ctx context.Context, guildID string, lastEvaluated sql.NullTime, through time.Time, summary *StreakEvaluationSummary) error {
+	for _, date := range evaluationDates(lastEvaluated, through) {
+		if err := s.evaluateGuildForDate(ctx, guildID, date, summary); err != nil {
+			return fmt.Errorf("evaluation for %s: %w", date.Format("2006-01-02"), err)
+		}
+	}
+	return nil
+}
+
+// evaluationDates returns the Manila dates after lastEvaluated up to through, at most
+// maxCatchUpDays of them. A guild that was never evaluated only needs through.
+func evaluationDates(lastEvaluated sql.NullTime, through time.Time) []time.Time {
+	through = ConvertToManilaDate(through)
+	from := through
+	if lastEvaluated.Valid {
+		y, m, d := lastEvaluated.Time.Date()
+		from = time.Date(y, m, d+1, 0, 0, 0, 0, manilaLocation)
+	}
+	if earliest := through.AddDate(0, 0, 1-maxCatchUpDays); from.Before(earliest) {
+		slog.Warn("Skipping streak evaluation of days too long ago", "from", from.Format("2006-01-02"),
+			"to", earliest.AddDate(0, 0, -1).Format("2006-01-02"), "max_days", maxCatchUpDays)
+		from = earliest
+	}
+
+	var dates []time.Time
+	for date := from; !date.After(through); date = date.AddDate(0, 0, 1) {
+		dates = append(dates, date)
+	}
+	return dates
+}
+
+// evaluateGuildForDate evaluates every user in guildID not yet evaluated for date, each in their
+// own transaction. The guild's last evaluated date only moves on once every user succeeded.
+func (s *StreakService) evaluateGuildForDate(ctx context.Context, guildID string, date time.Time, summary *StreakEvaluationSummary) error {
+	s.logger.InfoContext(ctx, "Running daily streak evaluation", "guild_id", guildID, "date", date.Format("2006-01-02"))
+
+	rules, err := s.GetStreakRules(ctx, guildID)
+	if err != nil {
+		return fmt.Errorf("failed to get streak rules: %w", err)
+	}
+	users, err := s.dbQueries.GetUsersForDailyEvaluation(ctx, database.GetUsersForDailyEvaluationParams{
+		GuildID:             guildID,
+		StreakEvaluatedDate: sql.NullTime{Time: date, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get users for daily evaluation: %w", err)
+	}
+
+	failed := 0
+	for _, user := range users {
+		var outcome streakOutcome
+		err := s.txManager.ExecTx(ctx, func(q database.Querier) error {
+			var err error
+			outcome, err = s.evaluateUserStreakForToday(ctx, q, user, date, rules)
+			return err
+		})
+		switch {
+		case errors.Is(err, errAlreadyEvaluated):
+			summary.Skipped++
+			metrics.StreakEvaluations.With("skipped").Inc()
+		case err != nil:
+			s.logger.ErrorContext(ctx, "Failed to evaluate streak", "user_id", user.UserID, "guild_id", guildID, "error", err)
+			failed++
+			metrics.StreakEvaluations.With("failed").Inc()
+		default:
+			summary.Evaluated++
+			metrics.StreakEvaluations.With("evaluated").Inc()
+			s.checkStreakAchievements(ctx, user.UserID, guildID, outcome, rules)
+		}
+	}
+	summary.Failed += failed
+	summary.Dates = append(summary.Dates, date)
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d users failed", failed, len(users))
+	}
+	err = s.dbQueries.SetGuildLastEvaluatedDate(ctx, database.SetGuildLastEvaluatedDateParams{
+		GuildID:           guildID,
+		LastEvaluatedDate: date,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record last evaluated date: %w", err)
+	}
+	return nil
+}
+
+// checkStreakAchievements awards streak achievements for a committed evaluation. Their thresholds
+// are in days, so weekly streaks don't count.
+func (s *StreakService) checkStreakAchievements(ctx context.Context, userID, guildID string, outcome streakOutcome, rules StreakRules) {
+	if s.achievementService == nil || outcome.current == 0 || rules.Unit() != "day" {
+		return
+	}
+	go s.achievementService.CheckStreakAchievements(ctx, userID, guildID, outcome.current)
+
+	// Check for comeback kid achievement (streak reset then rebuilt)
+	if outcome.previous == 0 && outcome.current >= 7 && !outcome.repaired {
+		go s.achievementService.CheckComebackKid(ctx, userID, guildID, outcome.previous, outcome.current)
+	}
+}
+
+// dayStudyMinutes returns the whole minutes the user studied in the guild on date
+func (s *StreakService) dayStudyMinutes(ctx context.Context, q database.Querier, userID, guildID string, date time.Time) (int, error) {
+	days, err := q.GetDailyActivity(ctx, database.GetDailyActivityParams{
+		UserID:   userID,
+		GuildID:  guildID,
+		FromDate: date,
+		ToDate:   date,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get daily activity: %w", err)
+	}
+
+	var ms int64
+	for _, day := range days {
+		ms += day.StudyMs
+	}
+	return int(ms / time.Minute.Milliseconds()), nil
+}
+
+// liveMinutesOn returns the minutes of the user's running session in guildID that count toward
+// date. A running session only adds to today; any earlier day being evaluated has already ended.
+func (s *StreakService) liveMinutesOn(ctx context.Context, userID, guildID string, date time.Time) int {
+	if !IsSameManilaDate(date, s.clock.Now()) {
+		return 0
+	}
+	return s.inProgressMinutes(ctx, userID, guildID)
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluationDates(t *testing.T) {
+	loc := GetManilaLocation()
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, loc) }
+	// Postgres returns DATE columns as midnight UTC
+	evaluated := func(d int) sql.NullTime {
+		return sql.NullTime{Time: time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC), Valid: true}
+	}
+
+	assert.Equal(t, []time.Time{day(17)}, evaluationDates(sql.NullTime{}, day(17)), "a guild never evaluated starts at through")
+	assert.Equal(t, []time.Time{day(15), day(16), day(17)}, evaluationDates(evaluated(14), day(17)))
+	assert.Empty(t, evaluationDates(evaluated(17), day(17)), "nothing left to evaluate")
+	assert.Empty(t, evaluationDates(evaluated(18), day(17)))
+
+	// A late evening through is still that Manila day
+	assert.Equal(t, []time.Time{day(17)}, evaluationDates(evaluated(16), time.Date(2026, 10, 17, 23, 59, 0, 0, loc)))
+
+	dates := evaluationDates(evaluated(1), day(30))
+	assert.Len(t, dates, maxCatchUpDays, "catch-up is capped")
+	assert.Equal(t, day(17), dates[0])
+	assert.Equal(t, day(30), dates[len(dates)-1])
+}
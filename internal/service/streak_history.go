@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Streak event types recorded in streak_events by the daily evaluation
+const (
+	StreakEventStart     = "start"     // A new streak began
+	StreakEventIncrement = "increment" // The streak grew by one
+	StreakEventFreeze    = "freeze"    // The streak was held on a day that doesn't count
+	StreakEventBreak     = "break"     // The streak ended; the event keeps the length it reached
+	StreakEventRepair    = "repair"    // A broken streak was restored
+)
+
+// pastStreaksShown is how many ended streaks /streak calendar lists
+const pastStreaksShown = 3
+
+// Calendar cells for /streak calendar
+const (
+	calendarStreakDay = "🟩"
+	calendarRepaired  = "🩹"
+	calendarFrozen    = "🧊"
+	calendarBroken    = "🟥"
+	calendarDoneToday = "✅"
+	calendarNoStreak  = "⬜"
+	calendarNotYet    = "⬛"
+	calendarPadding   = "➖" // Before the 1st, so the first week lines up under its weekday
+)
+
+// recordStreakEvent adds an evaluation result to the user's streak history using q. It runs in the
+// user's evaluation transaction, so a failure fails the evaluation and the next run retries it.
+func (s *StreakService) recordStreakEvent(ctx context.Context, q database.Querier, userID, guildID string, date time.Time, eventType string, streakCount int32) error {
+	err := q.CreateStreakEvent(ctx, database.CreateStreakEventParams{
+		UserID:      userID,
+		GuildID:     guildID,
+		EventDate:   date,
+		EventType:   eventType,
+		StreakCount: streakCount,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record %s streak event: %w", eventType, err)
+	}
+	return nil
+}
+
+// GetStreakCalendarEmbed shows the user's streak history for the Manila month containing now, with
+// the days that counted toward a streak marked, and their most recent ended streaks
+func (s *StreakService) GetStreakCalendarEmbed(ctx context.Context, userID, guildID string, now time.Time) (*discordgo.MessageEmbed, error) {
+	rules, err := s.GetStreakRules(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	monthStart := StartOfMonth(now, manilaLocation)
+	events, err := s.dbQueries.GetStreakEvents(ctx, database.GetStreakEventsParams{
+		UserID:   userID,
+		GuildID:  guildID,
+		FromDate: monthStart,
+		ToDate:   monthStart.AddDate(0, 1, -1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get streak events: %w", err)
+	}
+	byDay := make(map[int]string, len(events))
+	for _, e := range events {
+		byDay[e.EventDate.Day()] = e.EventType
+	}
+
+	// Today is only evaluated at 11:59 PM, so show whether it's already done
+	doneToday := false
+	if rules.Unit() == "day" && rules.countsOn(now) {
+		streak, err := s.dbQueries.GetUserStreak(ctx, database.GetUserStreakParams{UserID: userID, GuildID: guildID})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get user streak: %w", err)
+		}
+		minutes := s.inProgressMinutes(ctx, userID, guildID)
+		if err == nil && streak.LastActivityDate.Valid && IsSameManilaDate(streak.LastActivityDate.Time, now) {
+			minutes += int(streak.DailyActivityMinutes.Int32)
+		}
+		doneToday = minutes >= minimumActivityMinutes
+	}
+
+	past, err := s.dbQueries.GetPastStreaks(ctx, database.GetPastStreaksParams{
+		UserID:  userID,
+		GuildID: guildID,
+		Limit:   pastStreaksShown,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get past streaks: %w", err)
+	}
+	pastLines := make([]string, len(past))
+	for i, e := range past {
+		pastLines[i] = fmt.Sprintf("**%d %ss**, ended %s", e.StreakCount, rules.Unit(), e.EventDate.Format("Jan 2, 2006"))
+	}
+	pastValue := "No ended streaks yet."
+	if len(pastLines) > 0 {
+		pastValue = strings.Join(pastLines, "\n")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("📅 Streak Calendar: %s", monthStart.Format("January 2006")),
+		Description: fmt.Sprintf("<@%s>'s streak days this month (weeks start on Sunday).\n\n%s", userID, renderStreakCalendar(monthStart, byDay, now, doneToday)),
+		Color:       0x00AAFF,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "📜 Past Streaks", Value: pastValue},
+			{Name: "Legend", Value: fmt.Sprintf("%s streak day  %s repaired  %s frozen  %s streak ended\n%s done today  %s no streak  %s not yet", calendarStreakDay, calendarRepaired, calendarFrozen, calendarBroken, calendarDoneToday, calendarNoStreak, calendarNotYet)},
+		},
+		Timestamp: GetManilaTimeNow().Format(time.RFC3339),
+		Footer:    &discordgo.MessageEmbedFooter{Text: "Manila Time"},
+	}, nil
+}
+
+// renderStreakCalendar draws the month starting at monthStart as rows of seven cells, one row per
+// Sunday to Saturday week. byDay maps a day of the month to the streak event recorded on it.
+func renderStreakCalendar(monthStart time.Time, byDay map[int]string, now time.Time, doneToday bool) string {
+	today := StartOfDay(now, manilaLocation)
+	days := monthStart.AddDate(0, 1, -1).Day()
+
+	cells := make([]string, 0, 42)
+	for i := 0; i < int(monthStart.Weekday()); i++ {
+		cells = append(cells, calendarPadding)
+	}
+	for d := 1; d <= days; d++ {
+		date := time.Date(monthStart.Year(), monthStart.Month(), d, 0, 0, 0, 0, manilaLocation)
+		cell := calendarNoStreak
+		switch byDay[d] {
+		case StreakEventStart, StreakEventIncrement:
+			cell = calendarStreakDay
+		case StreakEventRepair:
+			cell = calendarRepaired
+		case StreakEventFreeze:
+			cell = calendarFrozen
+		case StreakEventBreak:
+			cell = calendarBroken
+		default:
+			if date.Equal(today) && doneToday {
+				cell = calendarDoneToday
+			} else if !date.Before(today) {
+				cell = calendarNotYet
+			}
+		}
+		cells = append(cells, cell)
+	}
+
+	var rows []string
+	for i := 0; i < len(cells); i += 7 {
+		rows = append(rows, strings.Join(cells[i:min(i+7, len(cells))], ""))
+	}
+	return strings.Join(rows, "\n")
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderStreakCalendar(t *testing.T) {
+	loc := GetManilaLocation()
+	monthStart := time.Date(2026, 10, 1, 0, 0, 0, 0, loc) // A Thursday
+	byDay := map[int]string{
+		1:  StreakEventStart,
+		2:  StreakEventIncrement,
+		5:  StreakEventBreak,
+		6:  StreakEventRepair,
+		10: StreakEventFreeze,
+	}
+
+	rows := strings.Split(renderStreakCalendar(monthStart, byDay, time.Date(2026, 10, 14, 15, 0, 0, 0, loc), true), "\n")
+	require.Len(t, rows, 5)
+	assert.Equal(t, "➖➖➖➖🟩🟩⬜", rows[0])
+	assert.Equal(t, "⬜🟥🩹⬜⬜⬜🧊", rows[1])
+	assert.Equal(t, "⬜⬜⬜✅⬛⬛⬛", rows[2])
+	assert.Equal(t, "⬛⬛⬛⬛⬛⬛⬛", rows[4], "the month ends on Saturday the 31st")
+
+	rows = strings.Split(renderStreakCalendar(monthStart, nil, time.Date(2026, 10, 14, 15, 0, 0, 0, loc), false), "\n")
+	assert.Equal(t, "⬜⬜⬜⬛⬛⬛⬛", rows[2])
+}
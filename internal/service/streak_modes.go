@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+)
+
+// Streak modes a guild can choose
+const (
+	StreakModeDaily    = "daily"    // Study every calendar day
+	StreakModeWeekdays = "weekdays" // Study Monday to Friday; weekends neither count nor break a streak
+	StreakModeWeekly   = "weekly"   // Study a number of hours each Sunday to Saturday week
+)
+
+// defaultWeeklyGoalMinutes matches the guild_settings column default
+const defaultWeeklyGoalMinutes = 300
+
+// StreakRules are how streaks are counted in a guild
+type StreakRules struct {
+	Mode              string
+	WeeklyGoalMinutes int  // Only used in weekly mode
+	Repair            bool // Broken daily streaks can be repaired the next day
+}
+
+// IsValidStreakMode reports whether mode is one of the supported streak modes
+func IsValidStreakMode(mode string) bool {
+	return mode == StreakModeDaily || mode == StreakModeWeekdays || mode == StreakModeWeekly
+}
+
+// Unit is what a streak in these rules counts: "day" or "week"
+func (r StreakRules) Unit() string {
+	return streakUnit(r.Mode)
+}
+
+// Describe explains the rules to members
+func (r StreakRules) Describe() string {
+	var rules string
+	switch r.Mode {
+	case StreakModeWeekdays:
+		rules = fmt.Sprintf("Study at least %d minute(s) every weekday (Monday to Friday, Manila time). Weekends don't count toward your streak and don't break it.", minimumActivityMinutes)
+	case StreakModeWeekly:
+		rules = fmt.Sprintf("Study at least %s each week (Sunday to Saturday, Manila time). Your streak grows by one for every week you hit the goal.", formatStudyMs(int64(r.WeeklyGoalMinutes)*time.Minute.Milliseconds()))
+	default:
+		rules = fmt.Sprintf("Study at least %d minute(s) every day (Manila time). Missing a day ends your streak.", minimumActivityMinutes)
+	}
+	if r.Repair && r.Unit() == "day" {
+		rules += fmt.Sprintf(" Missed one? Study %d minutes on the next day that counts to repair it.", repairMinutes)
+	}
+	return rules
+}
+
+// countsOn reports whether a day can add to or break a streak under these rules. In weekly
+// mode only the last day of the week, when the week's total is known, counts.
+func (r StreakRules) countsOn(date time.Time) bool {
+	switch r.Mode {
+	case StreakModeWeekdays:
+		return !isWeekend(date)
+	case StreakModeWeekly:
+		return date.In(manilaLocation).Weekday() == time.Saturday
+	default:
+		return true
+	}
+}
+
+func streakUnit(mode string) string {
+	if mode == StreakModeWeekly {
+		return "week"
+	}
+	return "day"
+}
+
+func isWeekend(date time.Time) bool {
+	day := date.In(manilaLocation).Weekday()
+	return day == time.Saturday || day == time.Sunday
+}
+
+// GetStreakRules returns how streaks are counted in a guild, defaulting to daily streaks
+func (s *StreakService) GetStreakRules(ctx context.Context, guildID string) (StreakRules, error) {
+	settings, err := s.dbQueries.GetGuildSettings(ctx, guildID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return StreakRules{Mode: StreakModeDaily, WeeklyGoalMinutes: defaultWeeklyGoalMinutes, Repair: true}, nil
+	}
+	if err != nil {
+		return StreakRules{}, fmt.Errorf("failed to get guild settings: %w", err)
+	}
+
+	rules := StreakRules{
+		Mode:              settings.StreakMode,
+		WeeklyGoalMinutes: int(settings.WeeklyGoalMinutes),
+		Repair:            settings.StreakRepair,
+	}
+	if !IsValidStreakMode(rules.Mode) {
+		rules.Mode = StreakModeDaily
+	}
+	return rules, nil
+}
+
+// SetStreakRules changes how streaks are counted in a guild. Existing streaks carry over between
+// daily and weekdays mode; switching to or from weekly mode restarts them at the next evaluation.
+func (s *StreakService) SetStreakRules(ctx context.Context, guildID string, rules StreakRules) error {
+	if !IsValidStreakMode(rules.Mode) {
+		return fmt.Errorf("unknown streak mode %q", rules.Mode)
+	}
+	if rules.WeeklyGoalMinutes <= 0 {
+		rules.WeeklyGoalMinutes = defaultWeeklyGoalMinutes
+	}
+	return s.dbQueries.UpsertGuildStreakMode(ctx, database.UpsertGuildStreakModeParams{
+		GuildID:           guildID,
+		StreakMode:        rules.Mode,
+		WeeklyGoalMinutes: int32(rules.WeeklyGoalMinutes),
+		StreakRepair:      rules.Repair,
+	})
+}
+
+// weekStudyMinutes returns the minutes a user has studied in a guild during the Sunday to
+// Saturday week containing date, up to and including date, plus any session in progress if date is today
+func (s *StreakService) weekStudyMinutes(ctx context.Context, userID, guildID string, date time.Time) (int, error) {
+	days, err := s.dbQueries.GetDailyActivity(ctx, database.GetDailyActivityParams{
+		UserID:   userID,
+		GuildID:  guildID,
+		FromDate: StartOfWeek(date, manilaLocation),
+		ToDate:   StartOfDay(date, manilaLocation),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get daily activity: %w", err)
+	}
+
+	var ms int64
+	for _, day := range days {
+		ms += day.StudyMs
+	}
+	return int(ms/time.Minute.Milliseconds()) + s.liveMinutesOn(ctx, userID, guildID, date), nil
+}
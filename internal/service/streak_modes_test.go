@@ -0,0 +1,61 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreakRules_CountsOn(t *testing.T) {
+	loc := GetManilaLocation()
+	friday := time.Date(2026, 10, 16, 0, 0, 0, 0, loc)
+	saturday := friday.AddDate(0, 0, 1)
+	sunday := friday.AddDate(0, 0, 2)
+
+	daily := StreakRules{Mode: StreakModeDaily}
+	weekdays := StreakRules{Mode: StreakModeWeekdays}
+	weekly := StreakRules{Mode: StreakModeWeekly, WeeklyGoalMinutes: 300}
+
+	for _, date := range []time.Time{friday, saturday, sunday} {
+		assert.True(t, daily.countsOn(date))
+	}
+	assert.True(t, weekdays.countsOn(friday))
+	assert.False(t, weekdays.countsOn(saturday))
+	assert.False(t, weekdays.countsOn(sunday))
+	assert.False(t, weekly.countsOn(friday))
+	assert.True(t, weekly.countsOn(saturday))
+
+	// Saturday 11 PM Manila is still Friday in UTC
+	assert.True(t, weekly.countsOn(time.Date(2026, 10, 17, 23, 0, 0, 0, loc).UTC()))
+
+	assert.Equal(t, "day", weekdays.Unit())
+	assert.Equal(t, "week", weekly.Unit())
+	assert.Contains(t, weekly.Describe(), "5h 0m each week")
+	assert.False(t, IsValidStreakMode("monthly"))
+}
+
+func TestStreakRules_RepairDay(t *testing.T) {
+	loc := GetManilaLocation()
+	thursday := time.Date(2026, 10, 15, 0, 0, 0, 0, loc)
+	friday := thursday.AddDate(0, 0, 1)
+	monday := thursday.AddDate(0, 0, 4)
+
+	day, ok := StreakRules{Mode: StreakModeDaily, Repair: true}.repairDay(thursday)
+	assert.True(t, ok)
+	assert.True(t, day.Equal(friday))
+
+	// A weekdays streak broken on Friday can be repaired on Monday
+	weekdays := StreakRules{Mode: StreakModeWeekdays, Repair: true}
+	day, ok = weekdays.repairDay(friday)
+	assert.True(t, ok)
+	assert.True(t, day.Equal(monday))
+	assert.True(t, weekdays.canRepairOn(4, sql.NullTime{Time: friday, Valid: true}, monday))
+	assert.False(t, weekdays.canRepairOn(0, sql.NullTime{Time: friday, Valid: true}, monday))
+
+	_, ok = StreakRules{Mode: StreakModeDaily}.repairDay(thursday)
+	assert.False(t, ok, "repair turned off")
+	_, ok = StreakRules{Mode: StreakModeWeekly, WeeklyGoalMinutes: 300, Repair: true}.repairDay(thursday)
+	assert.False(t, ok, "weekly streaks can't be repaired")
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// repairMinutes is what a user has to study on the day after a broken streak to restore it,
+// double the usual daily minimum
+const repairMinutes = 2 * minimumActivityMinutes
+
+// repairDay returns the day a streak broken on brokenAt can be repaired: the next day that
+// counts under these rules. Repairs are off when the guild disabled them or streaks are weekly.
+func (r StreakRules) repairDay(brokenAt time.Time) (time.Time, bool) {
+	if !r.Repair || r.Unit() != "day" {
+		return time.Time{}, false
+	}
+	day := StartOfDay(brokenAt, manilaLocation)
+	for i := 0; i < 7; i++ {
+		day = day.AddDate(0, 0, 1)
+		if r.countsOn(day) {
+			return day, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// canRepairOn reports whether a user without a streak can restore previousStreak, broken on
+// brokenAt, by studying enough on date
+func (r StreakRules) canRepairOn(previousStreak int32, brokenAt sql.NullTime, date time.Time) bool {
+	if previousStreak <= 0 || !brokenAt.Valid {
+		return false
+	}
+	day, ok := r.repairDay(brokenAt.Time)
+	return ok && IsSameManilaDate(day, date)
+}
+
+func (s *StreakService) streakRepairedEmbed(userID string, previousStreak, streakCount int32, minutes int) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       "🩹 Streak Repaired! 🩹",
+		Description: fmt.Sprintf("<@%s> missed a day but made up for it with **%d minutes** of study today, double the usual minimum.\n\nTheir **%d day** streak has been restored and is now **%d days**! 🔥", userID, minutes, previousStreak, streakCount),
+		Color:       0x9B59B6,
+		Timestamp:   GetManilaTimeNow().Format(time.RFC3339),
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Manila Time"},
+	}
+}
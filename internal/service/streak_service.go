@@ -3,11 +3,17 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
+	"github.com/Skufu/LockIn-Bot/internal/clock"
 	"github.com/Skufu/LockIn-Bot/internal/config"
 	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/health"
+	"github.com/Skufu/LockIn-Bot/internal/metrics"
 	"github.com/bwmarrin/discordgo"
 	"github.com/robfig/cron/v3"
 )
@@ -17,22 +23,28 @@ const (
 )
 
 type StreakService struct {
-	dbQueries                 *database.Queries
+	dbQueries                 database.Querier
+	txManager                 database.TxManager
 	discordSession            *discordgo.Session
 	cfg                       *config.Config
 	trackedVoiceChannelIDs    map[string]struct{}
 	streakNotificationChannel string
 	cronScheduler             *cron.Cron
+	logger                    *slog.Logger
+	clock                     clock.Clock // Times sessions still in progress, faked in tests
 
 	bot interface { // Interface to access Bot's session timing
-		GetSessionStartTime(userID string) (time.Time, bool)
+		GetGuildSessionStartTime(userID, guildID string) (time.Time, bool)
 	}
 
 	achievementService *AchievementService // For triggering achievement checks
+
+	evaluationMu sync.Mutex // Serializes evaluation runs from the cron job, startup catch-up and /evaluate-streaks
 }
 
 func NewStreakService(
-	queries *database.Queries,
+	queries database.Querier,
+	txManager database.TxManager,
 	session *discordgo.Session,
 	appConfig *config.Config,
 ) *StreakService {
@@ -45,22 +57,35 @@ func NewStreakService(
 
 	return &StreakService{
 		dbQueries:                 queries,
+		txManager:                 txManager,
 		discordSession:            session,
 		cfg:                       appConfig,
 		trackedVoiceChannelIDs:    trackedIDs,
 		streakNotificationChannel: appConfig.StreakNotificationChannelID,
 		cronScheduler:             cron.New(cron.WithLocation(GetManilaLocation())),
+		logger:                    slog.Default(),
+		clock:                     clock.Real{},
 		bot:                       nil, // Set later with SetBot
 	}
 }
 
 // SetBot sets the bot reference to access session timing
 func (s *StreakService) SetBot(bot interface {
-	GetSessionStartTime(userID string) (time.Time, bool)
+	GetGuildSessionStartTime(userID, guildID string) (time.Time, bool)
 }) {
 	s.bot = bot
 }
 
+// SetLogger sets the structured logger
+func (s *StreakService) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// SetClock sets the clock used to time sessions still in progress
+func (s *StreakService) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
 // SetAchievementService sets the achievement service reference for triggering achievement checks
 func (s *StreakService) SetAchievementService(as *AchievementService) {
 	s.achievementService = as
@@ -79,8 +104,8 @@ func (s *StreakService) HandleVoiceJoin(ctx context.Context, userID, guildID, vo
 	todayDate := GetTodayManilaDate()
 	now := GetManilaTimeNow()
 
-	fmt.Printf("StreakService: User %s joined voice channel %s in guild %s at %v Manila time\n",
-		userID, voiceChannelID, guildID, now.Format("2006-01-02 15:04:05"))
+	logger := s.logger.With("user_id", userID, "guild_id", guildID)
+	logger.DebugContext(ctx, "Joined tracked voice channel", "channel_id", voiceChannelID, "manila_time", now.Format("2006-01-02 15:04:05"))
 
 	// Check if user already has sufficient activity for today
 	hasActivity, err := s.dbQueries.HasActivityForDate(ctx, database.HasActivityForDateParams{
@@ -94,8 +119,7 @@ func (s *StreakService) HandleVoiceJoin(ctx context.Context, userID, guildID, vo
 	}
 
 	if hasActivity {
-		fmt.Printf("StreakService: User %s already has sufficient activity (%d+ minutes) for today. No tracking needed.\n",
-			userID, minimumActivityMinutes)
+		logger.DebugContext(ctx, "Already has enough activity today, no tracking needed", "min_minutes", minimumActivityMinutes)
 		return nil
 	}
 
@@ -110,49 +134,65 @@ func (s *StreakService) HandleVoiceJoin(ctx context.Context, userID, guildID, vo
 		return fmt.Errorf("failed to start daily activity tracking: %w", err)
 	}
 
-	fmt.Printf("StreakService: Started activity tracking for user %s in guild %s\n", userID, guildID)
+	logger.InfoContext(ctx, "Started streak activity tracking")
 	return nil
 }
 
-// HandleVoiceLeave is called when a user leaves a tracked voice channel
-func (s *StreakService) HandleVoiceLeave(ctx context.Context, userID, guildID string) error {
-	fmt.Printf("StreakService: User %s left voice channel in guild %s\n", userID, guildID)
-
-	// Get session start time from Bot's tracking
-	if s.bot == nil {
-		return fmt.Errorf("bot reference not set in StreakService")
+// recordSessionActivity credits a finished session to each Manila day it covered and its minutes
+// on the day it ended to that day's streak activity, using q, which may be bound to a transaction.
+// A completion notification is queued on q as well, so it is only delivered if the caller commits.
+func (s *StreakService) recordSessionActivity(ctx context.Context, q database.Querier, userID, guildID string, startTime, endTime time.Time) error {
+	segments := SplitByDay(startTime, endTime, manilaLocation)
+	if len(segments) == 0 {
+		return nil
 	}
 
-	startTime, hasSession := s.bot.GetSessionStartTime(userID)
-	if !hasSession {
-		return nil // User wasn't in a tracked session
+	for _, seg := range segments {
+		err := q.AddDailyActivity(ctx, database.AddDailyActivityParams{
+			UserID:       userID,
+			GuildID:      guildID,
+			ActivityDate: seg.Date,
+			StudyMs:      seg.Duration.Milliseconds(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record daily activity for %s: %w", seg.Date.Format("2006-01-02"), err)
+		}
 	}
 
-	now := GetManilaTimeNow()
-	sessionDuration := now.Sub(startTime)
-	sessionMinutes := int(sessionDuration.Minutes())
+	// Earlier days were already evaluated at their 11:59 PM, so only the last day's share counts now
+	minutes := SegmentMinutes(segments)
+	sessionMinutes := minutes[len(minutes)-1]
+	todayDate := segments[len(segments)-1].Date
 
-	fmt.Printf("StreakService: Session duration: %d minutes\n", sessionMinutes)
+	s.logger.InfoContext(ctx, "Recorded session activity", "user_id", userID, "guild_id", guildID,
+		"days", len(segments), "minutes", sessionMinutes, "date", todayDate.Format("2006-01-02"))
 
 	if sessionMinutes < 1 {
 		return nil // Too short to count
 	}
 
-	todayDate := GetTodayManilaDate()
+	return s.creditDayActivity(ctx, q, userID, guildID, todayDate, startTime, sessionMinutes)
+}
+
+// creditDayActivity adds minutes to the user's streak activity for day using q, starting the day's
+// tracking at startTime if it's their first activity that day. Reaching the daily minimum queues
+// a completion notification on q.
+func (s *StreakService) creditDayActivity(ctx context.Context, q database.Querier, userID, guildID string, day, startTime time.Time, minutes int) error {
+	logger := s.logger.With("user_id", userID, "guild_id", guildID)
 
 	// Get current activity for today to determine if we need to process anything
-	streak, err := s.dbQueries.GetUserStreak(ctx, database.GetUserStreakParams{
+	streak, err := q.GetUserStreak(ctx, database.GetUserStreakParams{
 		UserID:  userID,
 		GuildID: guildID,
 	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// User doesn't exist in streaks table - create initial record and track activity
-			fmt.Printf("StreakService: User %s not in streak system, creating initial record\n", userID)
-			_, err = s.dbQueries.StartDailyActivity(ctx, database.StartDailyActivityParams{
+			logger.InfoContext(ctx, "Not in the streak system yet, creating initial record")
+			_, err = q.StartDailyActivity(ctx, database.StartDailyActivityParams{
 				UserID:            userID,
 				GuildID:           guildID,
-				LastActivityDate:  sql.NullTime{Time: todayDate, Valid: true},
+				LastActivityDate:  sql.NullTime{Time: day, Valid: true},
 				ActivityStartTime: sql.NullTime{Time: startTime, Valid: true},
 			})
 			if err != nil {
@@ -160,22 +200,21 @@ func (s *StreakService) HandleVoiceLeave(ctx context.Context, userID, guildID st
 			}
 
 			// Update with the session minutes
-			err = s.dbQueries.UpdateDailyActivityMinutes(ctx, database.UpdateDailyActivityMinutesParams{
+			err = q.UpdateDailyActivityMinutes(ctx, database.UpdateDailyActivityMinutesParams{
 				UserID:               userID,
 				GuildID:              guildID,
-				DailyActivityMinutes: sql.NullInt32{Int32: int32(sessionMinutes), Valid: true},
+				DailyActivityMinutes: sql.NullInt32{Int32: int32(minutes), Valid: true},
 			})
 			if err != nil {
 				return fmt.Errorf("failed to update new user activity minutes: %w", err)
 			}
 
+			logger.DebugContext(ctx, "Recorded first activity", "minutes", minutes)
+
 			// Send completion notification if they reached minimum
-			if sessionMinutes >= minimumActivityMinutes {
-				embed := s.basicDailyActivityCompletedEmbed(userID, sessionMinutes)
-				s.sendStreakEmbed(guildID, embed)
+			if minutes >= minimumActivityMinutes {
+				return s.enqueueStreakEmbed(ctx, q, guildID, userID, dailyCompleteKey(guildID, userID, day), PreferenceDailyComplete, s.basicDailyActivityCompletedEmbed(userID, minutes))
 			}
-
-			fmt.Printf("StreakService: New user %s recorded %d minutes of activity\n", userID, sessionMinutes)
 			return nil
 		}
 		return fmt.Errorf("failed to get user streak: %w", err)
@@ -183,16 +222,15 @@ func (s *StreakService) HandleVoiceLeave(ctx context.Context, userID, guildID st
 
 	currentMinutes := int(streak.DailyActivityMinutes.Int32)
 
-	// Handle cross-day sessions: if this is a different day, start fresh tracking
-	if !streak.LastActivityDate.Valid || !IsSameManilaDate(streak.LastActivityDate.Time, todayDate) {
-		fmt.Printf("StreakService: Cross-day session detected for user %s, starting fresh tracking for %s\n",
-			userID, FormatManilaDate(todayDate))
+	// First activity on this day: start fresh tracking
+	if !streak.LastActivityDate.Valid || !IsSameManilaDate(streak.LastActivityDate.Time, day) {
+		logger.DebugContext(ctx, "First activity of the day, starting fresh tracking", "date", day.Format("2006-01-02"))
 
 		// Start new day tracking
-		_, err = s.dbQueries.StartDailyActivity(ctx, database.StartDailyActivityParams{
+		_, err = q.StartDailyActivity(ctx, database.StartDailyActivityParams{
 			UserID:            userID,
 			GuildID:           guildID,
-			LastActivityDate:  sql.NullTime{Time: todayDate, Valid: true},
+			LastActivityDate:  sql.NullTime{Time: day, Valid: true},
 			ActivityStartTime: sql.NullTime{Time: startTime, Valid: true},
 		})
 		if err != nil {
@@ -200,30 +238,29 @@ func (s *StreakService) HandleVoiceLeave(ctx context.Context, userID, guildID st
 		}
 
 		// Update with session minutes
-		err = s.dbQueries.UpdateDailyActivityMinutes(ctx, database.UpdateDailyActivityMinutesParams{
+		err = q.UpdateDailyActivityMinutes(ctx, database.UpdateDailyActivityMinutesParams{
 			UserID:               userID,
 			GuildID:              guildID,
-			DailyActivityMinutes: sql.NullInt32{Int32: int32(sessionMinutes), Valid: true},
+			DailyActivityMinutes: sql.NullInt32{Int32: int32(minutes), Valid: true},
 		})
 		if err != nil {
 			return fmt.Errorf("failed to update cross-day activity minutes: %w", err)
 		}
 
+		logger.DebugContext(ctx, "Recorded activity on a new day", "minutes", minutes)
+
 		// Send completion notification if they reached minimum
-		if sessionMinutes >= minimumActivityMinutes {
-			embed := s.basicDailyActivityCompletedEmbed(userID, sessionMinutes)
-			s.sendStreakEmbed(guildID, embed)
+		if minutes >= minimumActivityMinutes {
+			return s.enqueueStreakEmbed(ctx, q, guildID, userID, dailyCompleteKey(guildID, userID, day), PreferenceDailyComplete, s.basicDailyActivityCompletedEmbed(userID, minutes))
 		}
-
-		fmt.Printf("StreakService: Cross-day session for user %s recorded %d minutes\n", userID, sessionMinutes)
 		return nil
 	}
 
 	// Normal case: same day activity
-	newTotalMinutes := currentMinutes + sessionMinutes
+	newTotalMinutes := currentMinutes + minutes
 
 	// Update the daily activity minutes
-	err = s.dbQueries.UpdateDailyActivityMinutes(ctx, database.UpdateDailyActivityMinutesParams{
+	err = q.UpdateDailyActivityMinutes(ctx, database.UpdateDailyActivityMinutesParams{
 		UserID:               userID,
 		GuildID:              guildID,
 		DailyActivityMinutes: sql.NullInt32{Int32: int32(newTotalMinutes), Valid: true},
@@ -232,17 +269,13 @@ func (s *StreakService) HandleVoiceLeave(ctx context.Context, userID, guildID st
 		return fmt.Errorf("failed to update daily activity minutes: %w", err)
 	}
 
-	fmt.Printf("StreakService: Updated daily activity for user %s in guild %s: %d total minutes\n",
-		userID, guildID, newTotalMinutes)
+	logger.DebugContext(ctx, "Updated daily activity", "total_minutes", newTotalMinutes)
 
 	// If they just reached the minimum for the first time today, send completion notification
 	// but don't increment streak - that will happen during daily evaluation
 	if currentMinutes < minimumActivityMinutes && newTotalMinutes >= minimumActivityMinutes {
-		embed := s.basicDailyActivityCompletedEmbed(userID, newTotalMinutes)
-		s.sendStreakEmbed(guildID, embed)
-
-		fmt.Printf("StreakService: User %s completed daily activity (%d minutes). Streak will be updated during daily evaluation.\n",
-			userID, newTotalMinutes)
+		logger.InfoContext(ctx, "Completed daily activity; the streak is updated during daily evaluation", "total_minutes", newTotalMinutes)
+		return s.enqueueStreakEmbed(ctx, q, guildID, userID, dailyCompleteKey(guildID, userID, day), PreferenceDailyComplete, s.basicDailyActivityCompletedEmbed(userID, newTotalMinutes))
 	}
 
 	return nil
@@ -252,118 +285,184 @@ func (s *StreakService) HandleVoiceLeave(ctx context.Context, userID, guildID st
 func (s *StreakService) StartScheduledTasks() {
 	// Daily streak evaluation at 11:59 PM Manila time (end of day)
 	_, err := s.cronScheduler.AddFunc("59 23 * * *", func() {
-		fmt.Println("StreakService: Running daily streak evaluation at 11:59 PM Manila time...")
-		ctx := context.Background()
-		s.EvaluateAllUserStreaks(ctx)
+		_ = metrics.RunJob("streak_evaluation", func() error {
+			s.logger.Info("Running daily streak evaluation")
+			summary := s.evaluateGuildsThrough(context.Background(), GetTodayManilaDate())
+			if summary.Failed > 0 {
+				return fmt.Errorf("%d streak evaluations failed", summary.Failed)
+			}
+			return nil
+		})
 	})
 	if err != nil {
-		fmt.Printf("StreakService: Failed to schedule daily evaluation: %v\n", err)
+		s.logger.Error("Failed to schedule daily streak evaluation", "error", err)
 	} else {
-		fmt.Println("StreakService: Scheduled daily evaluation at 11:59 PM Manila time")
+		s.logger.Info("Scheduled daily streak evaluation at 11:59 PM Manila time")
 	}
 
-	// Evening warnings at 8:00 PM Manila time
-	_, err = s.cronScheduler.AddFunc("0 20 * * *", func() {
-		fmt.Println("StreakService: Running evening warning check at 8:00 PM Manila time...")
-		ctx := context.Background()
-		s.SendEveningWarnings(ctx)
+	// Streak reminders go out at each guild's or user's chosen times, so check every minute
+	_, err = s.cronScheduler.AddFunc("* * * * *", func() {
+		_ = metrics.RunJob("streak_reminders", func() error {
+			s.SendDueReminders(context.Background(), GetManilaTimeNow())
+			return nil
+		})
 	})
 	if err != nil {
-		fmt.Printf("StreakService: Failed to schedule evening warnings: %v\n", err)
+		s.logger.Error("Failed to schedule streak reminders", "error", err)
 	} else {
-		fmt.Println("StreakService: Scheduled evening warnings at 8:00 PM Manila time")
+		s.logger.Info("Scheduled streak reminder checks every minute")
 	}
 
 	s.cronScheduler.Start()
-	fmt.Println("StreakService: Cron scheduler started with Manila timezone")
+	s.logger.Info("Streak cron scheduler started", "timezone", GetManilaLocation().String())
 }
 
 func (s *StreakService) StopScheduledTasks() {
 	if s.cronScheduler != nil {
-		fmt.Println("StreakService: Stopping cron scheduler...")
 		ctx := s.cronScheduler.Stop()
 		<-ctx.Done()
-		fmt.Println("StreakService: Cron scheduler stopped")
+		s.logger.Info("Streak cron scheduler stopped")
 	}
 }
 
-// EvaluateAllUserStreaks evaluates streaks for all users based on today's activity
-func (s *StreakService) EvaluateAllUserStreaks(ctx context.Context) {
-	todayDate := GetTodayManilaDate()
-
-	fmt.Printf("StreakService: Running daily evaluation for %s Manila time\n", FormatManilaDate(todayDate))
-
-	// Reset all daily flags at start of evaluation
-	err := s.dbQueries.ResetAllStreakDailyFlags(ctx)
-	if err != nil {
-		fmt.Printf("StreakService: Error resetting daily flags: %v\n", err)
-		return
-	}
-	fmt.Println("StreakService: Daily flags reset successfully")
-
-	// Get ALL users who need evaluation for today (haven't been evaluated yet)
-	users, err := s.dbQueries.GetUsersForDailyEvaluation(ctx, sql.NullTime{Time: todayDate, Valid: true})
-	if err != nil {
-		fmt.Printf("StreakService: Error getting users for daily evaluation: %v\n", err)
-		return
-	}
-
-	fmt.Printf("StreakService: Found %d users to evaluate for today\n", len(users))
+// SchedulerHealth reports whether the streak evaluation and reminder jobs are still being scheduled
+func (s *StreakService) SchedulerHealth(_ context.Context) health.Result {
+	return health.CheckCron(s.cronScheduler, time.Now())
+}
 
-	// Process each user's streak based on TODAY's activity
-	for _, user := range users {
-		err = s.evaluateUserStreakForToday(ctx, user, todayDate)
+// evaluateUserStreakForToday evaluates a single user's streak based on today's activity, using q,
+// which is bound to the user's evaluation transaction. In weekdays mode weekends are skipped, and
+// in weekly mode the streak is only evaluated on Saturday against the whole week's study time.
+func (s *StreakService) evaluateUserStreakForToday(ctx context.Context, q database.Querier, user database.GetUsersForDailyEvaluationRow, todayDate time.Time, rules StreakRules) (streakOutcome, error) {
+	userID := user.UserID
+	guildID := user.GuildID
+	logger := s.logger.With("user_id", userID, "guild_id", guildID)
+
+	// The guild changed its streak mode since this streak was last evaluated. A streak counted in
+	// days can't carry over to one counted in weeks or the other way round, so it restarts.
+	currentMode := user.StreakMode
+	if currentMode == "" {
+		currentMode = StreakModeDaily
+	}
+	if currentMode != rules.Mode {
+		count := user.CurrentStreakCount
+		if streakUnit(currentMode) != rules.Unit() {
+			count = 0
+		}
+		err := q.SetUserStreakMode(ctx, database.SetUserStreakModeParams{
+			UserID:             userID,
+			GuildID:            guildID,
+			StreakMode:         rules.Mode,
+			CurrentStreakCount: count,
+		})
 		if err != nil {
-			fmt.Printf("StreakService: Error evaluating streak for user %s: %v\n", user.UserID, err)
-			continue
+			return streakOutcome{}, fmt.Errorf("failed to switch streak mode: %w", err)
+		}
+		logger.InfoContext(ctx, "Switched streak mode", "from_mode", currentMode, "mode", rules.Mode,
+			"previous_streak", user.CurrentStreakCount, "streak", count)
+		if count == 0 && user.CurrentStreakCount > 0 {
+			err = recordAudit(ctx, q, AuditEntry{
+				GuildID:      guildID,
+				TargetUserID: userID,
+				Action:       AuditActionStreakReset,
+				Reason:       fmt.Sprintf("Streak mode changed from %s to %s", currentMode, rules.Mode),
+				Details:      map[string]any{"previousStreak": user.CurrentStreakCount, "date": todayDate.Format("2006-01-02")},
+			})
+			if err != nil {
+				return streakOutcome{}, err
+			}
 		}
+		user.CurrentStreakCount = count
+		user.PreviousStreak = 0 // A streak counted in the old mode can't be repaired in the new one
 	}
 
-	fmt.Printf("StreakService: Daily evaluation completed for %s\n", FormatManilaDate(todayDate))
-}
-
-// evaluateUserStreakForToday evaluates a single user's streak based on today's activity
-func (s *StreakService) evaluateUserStreakForToday(ctx context.Context, user database.GetUsersForDailyEvaluationRow, todayDate time.Time) error {
-	userID := user.UserID
-	guildID := user.GuildID
+	// Days that don't count under the guild's rules neither extend nor break the streak
+	if !rules.countsOn(todayDate) {
+		if err := s.markUserEvaluated(ctx, q, userID, guildID, todayDate); err != nil {
+			return streakOutcome{}, err
+		}
+		if rules.Mode == StreakModeWeekdays && user.CurrentStreakCount > 0 {
+			return streakOutcome{}, s.recordStreakEvent(ctx, q, userID, guildID, todayDate, StreakEventFreeze, user.CurrentStreakCount)
+		}
+		return streakOutcome{}, nil
+	}
 
-	// Check if user has sufficient activity for TODAY
+	// Check if user has sufficient activity for TODAY, or for this week in weekly mode
 	hasActivityToday := false
-	if user.LastActivityDate.Valid &&
-		IsSameManilaDate(user.LastActivityDate.Time, todayDate) &&
-		user.DailyActivityMinutes.Valid &&
-		user.DailyActivityMinutes.Int32 >= int32(minimumActivityMinutes) {
-		hasActivityToday = true
+	todayMinutes := 0
+	if rules.Mode == StreakModeWeekly {
+		minutes, err := s.weekStudyMinutes(ctx, userID, guildID, todayDate)
+		if err != nil {
+			return streakOutcome{}, err
+		}
+		hasActivityToday = minutes >= rules.WeeklyGoalMinutes
+	} else {
+		if user.LastActivityDate.Valid &&
+			IsSameManilaDate(user.LastActivityDate.Time, todayDate) &&
+			user.DailyActivityMinutes.Valid {
+			todayMinutes = int(user.DailyActivityMinutes.Int32)
+		} else if user.LastActivityDate.Valid && user.LastActivityDate.Time.After(todayDate) {
+			// A day being caught up on: the user has studied since, so their streak row has moved
+			// on to a later day, but the day's study time is still in their daily activity
+			minutes, err := s.dayStudyMinutes(ctx, q, userID, guildID, todayDate)
+			if err != nil {
+				return streakOutcome{}, err
+			}
+			todayMinutes = minutes
+		}
+		hasActivityToday = todayMinutes >= minimumActivityMinutes
+
+		// A session running over midnight is only credited when it ends, on the next day, so count
+		// the part of it that fell on today here
+		if s.bot != nil {
+			if live := s.liveMinutesOn(ctx, userID, guildID, todayDate); live > 0 {
+				todayMinutes += live
+				if !hasActivityToday && todayMinutes >= minimumActivityMinutes {
+					logger.DebugContext(ctx, "Mid-session, counting today's in-progress minutes", "minutes", live)
+					hasActivityToday = true
+				}
+			}
+		}
 	}
 
 	var newStreakCount int32
 	var notificationEmbed *discordgo.MessageEmbed
+	repaired := false
 
 	if hasActivityToday {
 		// User was active today - continue or increment streak
-		if user.CurrentStreakCount == 0 {
+		if user.CurrentStreakCount == 0 && todayMinutes >= repairMinutes && rules.canRepairOn(user.PreviousStreak, user.StreakBrokenAt, todayDate) {
+			// Made up for yesterday's miss by studying double: bring the old streak back
+			repaired = true
+			newStreakCount = user.PreviousStreak + 1
+			notificationEmbed = s.streakRepairedEmbed(userID, user.PreviousStreak, newStreakCount, todayMinutes)
+			logger.InfoContext(ctx, "Repaired streak", "previous_streak", user.PreviousStreak, "minutes", todayMinutes)
+		} else if user.CurrentStreakCount == 0 {
 			// Starting a new streak
 			newStreakCount = 1
-			notificationEmbed = s.newStreakStartedEmbed(userID, newStreakCount)
+			notificationEmbed = s.newStreakStartedEmbed(userID, newStreakCount, rules.Unit())
 		} else {
 			// Continuing existing streak
 			newStreakCount = user.CurrentStreakCount + 1
-			notificationEmbed = s.streakContinuedEmbed(userID, newStreakCount)
+			notificationEmbed = s.streakContinuedEmbed(userID, newStreakCount, rules.Unit())
 		}
 
-		fmt.Printf("StreakService: User %s was active today (%d mins), streak: %d -> %d\n",
-			userID, user.DailyActivityMinutes.Int32, user.CurrentStreakCount, newStreakCount)
+		logger.InfoContext(ctx, "Active today, extending streak", "minutes", user.DailyActivityMinutes.Int32,
+			"previous_streak", user.CurrentStreakCount, "streak", newStreakCount)
 	} else {
 		// User was NOT active today - reset streak if they had one
 		if user.CurrentStreakCount > 0 {
 			newStreakCount = 0
-			notificationEmbed = s.streakEndedEmbed(userID, user.CurrentStreakCount)
-			fmt.Printf("StreakService: User %s was inactive today, streak reset from %d to 0\n",
-				userID, user.CurrentStreakCount)
+			repairOn, canRepair := rules.repairDay(todayDate)
+			if !canRepair {
+				repairOn = time.Time{}
+			}
+			notificationEmbed = s.streakEndedEmbed(userID, user.CurrentStreakCount, rules.Unit(), repairOn)
+			logger.InfoContext(ctx, "Inactive today, resetting streak", "previous_streak", user.CurrentStreakCount)
 		} else {
 			// User had no streak and was inactive - no change needed
-			fmt.Printf("StreakService: User %s remains inactive (no streak to reset)\n", userID)
-			return s.markUserEvaluated(ctx, userID, guildID, todayDate)
+			logger.DebugContext(ctx, "Inactive today with no streak to reset")
+			return streakOutcome{}, s.markUserEvaluated(ctx, q, userID, guildID, todayDate)
 		}
 	}
 
@@ -373,7 +472,9 @@ func (s *StreakService) evaluateUserStreakForToday(ctx context.Context, user dat
 		newMaxStreak = newStreakCount
 	}
 
-	_, err := s.dbQueries.UpdateUserStreakAfterEvaluation(ctx, database.UpdateUserStreakAfterEvaluationParams{
+	// Updated first: it only matches a user not yet evaluated for the day, so a run racing this one
+	// finds nothing to update and rolls back without writing anything twice
+	_, err := q.UpdateUserStreakAfterEvaluation(ctx, database.UpdateUserStreakAfterEvaluationParams{
 		UserID:              userID,
 		GuildID:             guildID,
 		CurrentStreakCount:  newStreakCount,
@@ -381,31 +482,64 @@ func (s *StreakService) evaluateUserStreakForToday(ctx context.Context, user dat
 		StreakEvaluatedDate: sql.NullTime{Time: todayDate, Valid: true},
 	})
 	if err != nil {
-		return fmt.Errorf("failed to update streak after evaluation: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return streakOutcome{}, errAlreadyEvaluated
+		}
+		return streakOutcome{}, fmt.Errorf("failed to update streak after evaluation: %w", err)
 	}
 
-	// Send notification if we have one
-	if notificationEmbed != nil {
-		s.sendStreakEmbed(guildID, notificationEmbed)
+	switch {
+	case repaired:
+		err = s.recordStreakEvent(ctx, q, userID, guildID, todayDate, StreakEventRepair, newStreakCount)
+	case newStreakCount == 0:
+		err = s.recordStreakEvent(ctx, q, userID, guildID, todayDate, StreakEventBreak, user.CurrentStreakCount)
+	case user.CurrentStreakCount == 0:
+		err = s.recordStreakEvent(ctx, q, userID, guildID, todayDate, StreakEventStart, newStreakCount)
+	default:
+		err = s.recordStreakEvent(ctx, q, userID, guildID, todayDate, StreakEventIncrement, newStreakCount)
+	}
+	if err != nil {
+		return streakOutcome{}, err
 	}
 
-	// Check for streak-related achievements
-	if s.achievementService != nil && newStreakCount > 0 {
-		go s.achievementService.CheckStreakAchievements(ctx, userID, guildID, newStreakCount)
+	// Keep the lost streak so it can be repaired
+	if newStreakCount == 0 {
+		err = q.UpdateStreakBreak(ctx, database.UpdateStreakBreakParams{
+			UserID:         userID,
+			GuildID:        guildID,
+			PreviousStreak: user.CurrentStreakCount,
+			StreakBrokenAt: sql.NullTime{Time: todayDate, Valid: true},
+		})
+		if err != nil {
+			return streakOutcome{}, fmt.Errorf("failed to record streak break: %w", err)
+		}
+		err = recordAudit(ctx, q, AuditEntry{
+			GuildID:      guildID,
+			TargetUserID: userID,
+			Action:       AuditActionStreakReset,
+			Reason:       "Not enough study time",
+			Details:      map[string]any{"previousStreak": user.CurrentStreakCount, "date": todayDate.Format("2006-01-02")},
+		})
+		if err != nil {
+			return streakOutcome{}, err
+		}
+	}
 
-		// Check for comeback kid achievement (streak reset then rebuilt)
-		if user.CurrentStreakCount == 0 && newStreakCount >= 7 {
-			go s.achievementService.CheckComebackKid(ctx, userID, guildID, user.CurrentStreakCount, newStreakCount)
+	// Queue notification if we have one
+	if notificationEmbed != nil {
+		key := fmt.Sprintf("streak_evaluation:%s:%s:%s", guildID, userID, todayDate.Format("2006-01-02"))
+		if err := s.enqueueStreakEmbed(ctx, q, guildID, userID, key, "", notificationEmbed); err != nil {
+			return streakOutcome{}, fmt.Errorf("failed to queue streak notification: %w", err)
 		}
 	}
 
-	return nil
+	return streakOutcome{previous: user.CurrentStreakCount, current: newStreakCount, repaired: repaired}, nil
 }
 
 // markUserEvaluated marks a user as evaluated for today without changing their streak
-func (s *StreakService) markUserEvaluated(ctx context.Context, userID, guildID string, todayDate time.Time) error {
+func (s *StreakService) markUserEvaluated(ctx context.Context, q database.Querier, userID, guildID string, todayDate time.Time) error {
 	// Get current streak to preserve it
-	streak, err := s.dbQueries.GetUserStreak(ctx, database.GetUserStreakParams{
+	streak, err := q.GetUserStreak(ctx, database.GetUserStreakParams{
 		UserID:  userID,
 		GuildID: guildID,
 	})
@@ -413,43 +547,99 @@ func (s *StreakService) markUserEvaluated(ctx context.Context, userID, guildID s
 		return fmt.Errorf("failed to get current streak: %w", err)
 	}
 
-	_, err = s.dbQueries.UpdateUserStreakAfterEvaluation(ctx, database.UpdateUserStreakAfterEvaluationParams{
+	_, err = q.UpdateUserStreakAfterEvaluation(ctx, database.UpdateUserStreakAfterEvaluationParams{
 		UserID:              userID,
 		GuildID:             guildID,
 		CurrentStreakCount:  streak.CurrentStreakCount,
 		MaxStreakCount:      streak.MaxStreakCount,
 		StreakEvaluatedDate: sql.NullTime{Time: todayDate, Valid: true},
 	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return errAlreadyEvaluated
+	}
 	return err
 }
 
-// SendEveningWarnings sends warnings to users who haven't been active today
-func (s *StreakService) SendEveningWarnings(ctx context.Context) {
-	todayDate := GetTodayManilaDate()
+// SendDueReminders warns users whose streak is at risk when one of their reminder times has
+// passed. Each reminder time is sent at most once a day; if several passed while the bot was
+// down only the latest is sent.
+func (s *StreakService) SendDueReminders(ctx context.Context, now time.Time) {
+	todayDate := StartOfDay(now, manilaLocation)
 
-	users, err := s.dbQueries.GetUsersNeedingWarnings(ctx, sql.NullTime{Time: todayDate, Valid: true})
+	users, err := s.dbQueries.GetUsersNeedingWarnings(ctx, database.GetUsersNeedingWarningsParams{
+		Today:      sql.NullTime{Time: todayDate, Valid: true},
+		MinMinutes: minimumActivityMinutes,
+	})
 	if err != nil {
-		fmt.Printf("StreakService: Error getting users needing warnings: %v\n", err)
+		s.logger.ErrorContext(ctx, "Failed to get users needing streak reminders", "error", err)
 		return
 	}
 
-	fmt.Printf("StreakService: Found %d users needing warnings\n", len(users))
-
+	rulesByGuild := make(map[string]StreakRules)
 	for _, user := range users {
-		embed := s.streakWarningEmbed(user.UserID, user.CurrentStreakCount)
-		s.sendStreakEmbed(user.GuildID, embed)
+		rules, ok := rulesByGuild[user.GuildID]
+		if !ok {
+			rules, err = s.GetStreakRules(ctx, user.GuildID)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "Failed to get streak rules", "guild_id", user.GuildID, "error", err)
+				continue
+			}
+			rulesByGuild[user.GuildID] = rules
+		}
+
+		// Nothing is at stake on days that don't count, such as weekends in weekdays mode or
+		// any day but Saturday in weekly mode
+		if !rules.countsOn(todayDate) {
+			continue
+		}
+
+		// Reminder times come with the user's row, so a tick doesn't query per user
+		schedule := newReminderSchedule(user.GuildReminderMinutes, user.UserReminderMinutes)
+		slot, ok := latestReminder(schedule.Minutes, now)
+		if !ok || (user.WarningNotifiedAt.Valid && !user.WarningNotifiedAt.Time.Before(slot)) {
+			continue
+		}
+
+		// Minutes from earlier today, plus a session still in progress, count toward the minimum.
+		// In weekly mode the whole week counts toward the weekly goal.
+		var remaining int
+		if rules.Mode == StreakModeWeekly {
+			weekMinutes, err := s.weekStudyMinutes(ctx, user.UserID, user.GuildID, todayDate)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "Failed to get the week's study time", "user_id", user.UserID, "guild_id", user.GuildID, "error", err)
+				continue
+			}
+			remaining = rules.WeeklyGoalMinutes - weekMinutes
+		} else {
+			doneMinutes := 0
+			if user.LastActivityDate.Valid && IsSameManilaDate(user.LastActivityDate.Time, todayDate) {
+				doneMinutes = int(user.DailyActivityMinutes.Int32)
+			}
+			remaining = minimumActivityMinutes - doneMinutes - s.inProgressMinutes(ctx, user.UserID, user.GuildID)
+		}
+		if remaining <= 0 {
+			continue
+		}
 
-		// Mark as warned
+		embed := s.streakWarningEmbed(user.UserID, user.CurrentStreakCount, rules.Unit(), remaining, todayDate.AddDate(0, 0, 1).Sub(now))
+		key := fmt.Sprintf("streak_warning:%s:%s:%s", user.GuildID, user.UserID, slot.Format("2006-01-02T15:04"))
+		if err := s.enqueueStreakEmbed(ctx, s.dbQueries, user.GuildID, user.UserID, key, PreferenceStreakWarnings, embed); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to queue streak reminder", "user_id", user.UserID, "guild_id", user.GuildID, "error", err)
+			continue
+		}
+
+		// Mark as warned for this reminder time
 		err = s.dbQueries.UpdateWarningNotifiedAt(ctx, database.UpdateWarningNotifiedAtParams{
 			UserID:            user.UserID,
 			GuildID:           user.GuildID,
-			WarningNotifiedAt: sql.NullTime{Time: GetManilaTimeNow(), Valid: true},
+			WarningNotifiedAt: sql.NullTime{Time: now, Valid: true},
 		})
 		if err != nil {
-			fmt.Printf("StreakService: Error updating warning timestamp for user %s: %v\n", user.UserID, err)
+			s.logger.ErrorContext(ctx, "Failed to update reminder timestamp", "user_id", user.UserID, "guild_id", user.GuildID, "error", err)
 		}
 
-		fmt.Printf("StreakService: Sent warning to user %s (streak: %d days)\n", user.UserID, user.CurrentStreakCount)
+		s.logger.InfoContext(ctx, "Sent streak reminder", "user_id", user.UserID, "guild_id", user.GuildID,
+			"slot", slot.Format("15:04"), "streak", user.CurrentStreakCount, "unit", rules.Unit(), "minutes_to_go", remaining)
 	}
 }
 
@@ -460,12 +650,22 @@ func (s *StreakService) GetUserStreakInfoEmbed(ctx context.Context, userID, guil
 		GuildID: guildID,
 	})
 
-	discordUser, errUser := s.discordSession.User(userID)
+	rules, rulesErr := s.GetStreakRules(ctx, guildID)
+	if rulesErr != nil {
+		return nil, rulesErr
+	}
+	unit := rules.Unit()
+
 	username := userID
-	if errUser == nil && discordUser != nil {
-		username = discordUser.Username
+	if s.discordSession != nil {
+		if discordUser, errUser := s.discordSession.User(userID); errUser == nil && discordUser != nil {
+			username = discordUser.Username
+		}
 	}
 
+	// Minutes from a session that is still running; they're credited when it ends
+	liveMinutes := s.inProgressMinutes(ctx, userID, guildID)
+
 	title := fmt.Sprintf("🎯 Streak Status for %s", username)
 	description := ""
 	color := 0xAAAAAA
@@ -474,6 +674,9 @@ func (s *StreakService) GetUserStreakInfoEmbed(ctx context.Context, userID, guil
 	if err != nil {
 		if err == sql.ErrNoRows {
 			description = fmt.Sprintf("<@%s> hasn't started a study streak yet. Join a tracked voice channel for %d+ minutes to begin!", userID, minimumActivityMinutes)
+			if liveMinutes > 0 {
+				description = fmt.Sprintf("<@%s> is studying right now (⏳ %d minutes in progress). Their streak starts when the session ends!", userID, liveMinutes)
+			}
 		} else {
 			return nil, fmt.Errorf("failed to get user streak info: %w", err)
 		}
@@ -481,46 +684,71 @@ func (s *StreakService) GetUserStreakInfoEmbed(ctx context.Context, userID, guil
 		// Add current streak info
 		fields = append(fields, &discordgo.MessageEmbedField{
 			Name:   "Current Streak",
-			Value:  fmt.Sprintf("%d days", streak.CurrentStreakCount),
+			Value:  fmt.Sprintf("%d %ss", streak.CurrentStreakCount, unit),
 			Inline: true,
 		})
 
 		fields = append(fields, &discordgo.MessageEmbedField{
 			Name:   "Longest Streak",
-			Value:  fmt.Sprintf("%d days", streak.MaxStreakCount),
+			Value:  fmt.Sprintf("%d %ss", streak.MaxStreakCount, unit),
 			Inline: true,
 		})
 
-		// Add today's activity info
-		todayDate := GetTodayManilaDate()
-		todayMinutes := 0
-		if streak.LastActivityDate.Valid && IsSameManilaDate(streak.LastActivityDate.Time, todayDate) {
-			todayMinutes = int(streak.DailyActivityMinutes.Int32)
+		// Add today's activity info, or this week's in weekly mode
+		todayDate := StartOfDay(s.clock.Now(), manilaLocation)
+		doneMinutes := 0
+		goalMinutes := minimumActivityMinutes
+		period, activityName := "today", "Today's Activity"
+		if rules.Mode == StreakModeWeekly {
+			doneMinutes, err = s.weekStudyMinutes(ctx, userID, guildID, todayDate)
+			if err != nil {
+				return nil, err
+			}
+			goalMinutes = rules.WeeklyGoalMinutes
+			period, activityName = "this week", "This Week's Activity"
+		} else {
+			if streak.LastActivityDate.Valid && IsSameManilaDate(streak.LastActivityDate.Time, todayDate) {
+				doneMinutes = int(streak.DailyActivityMinutes.Int32)
+			}
+			doneMinutes += liveMinutes
 		}
 
-		activityStatus := fmt.Sprintf("%d/%d minutes", todayMinutes, minimumActivityMinutes)
-		if todayMinutes >= minimumActivityMinutes {
+		activityStatus := fmt.Sprintf("%d/%d minutes", doneMinutes, goalMinutes)
+		if doneMinutes >= goalMinutes {
 			activityStatus += " ✅"
 		}
+		if liveMinutes > 0 {
+			activityStatus += fmt.Sprintf("\n⏳ includes %d in progress", liveMinutes)
+		}
 
 		fields = append(fields, &discordgo.MessageEmbedField{
-			Name:   "Today's Activity",
+			Name:   activityName,
 			Value:  activityStatus,
 			Inline: true,
 		})
 
 		if streak.CurrentStreakCount > 0 {
 			title = fmt.Sprintf("🔥 Streak Status for %s 🔥", username)
-			description = fmt.Sprintf("<@%s> is currently on a **%d day** study streak! 🎉", userID, streak.CurrentStreakCount)
+			description = fmt.Sprintf("<@%s> is currently on a **%d %s** study streak! 🎉", userID, streak.CurrentStreakCount, unit)
 			color = 0x00FF00
 
-			if todayMinutes < minimumActivityMinutes {
-				description += fmt.Sprintf("\n⚠️ You need **%d more minutes** of voice activity today to maintain your streak!", minimumActivityMinutes-todayMinutes)
+			// Nothing is at stake on days that don't count, such as weekends in weekdays mode
+			if doneMinutes < goalMinutes && (rules.Mode == StreakModeWeekly || rules.countsOn(todayDate)) {
+				description += fmt.Sprintf("\n⚠️ You need **%d more minutes** of voice activity %s to maintain your streak!", goalMinutes-doneMinutes, period)
 				color = 0xFFA500
 			}
+		} else if rules.canRepairOn(streak.PreviousStreak, streak.StreakBrokenAt, todayDate) {
+			title = fmt.Sprintf("🩹 Streak Status for %s", username)
+			description = fmt.Sprintf("<@%s>'s **%d day** streak ended on %s, but it can still be repaired! Study **%d minutes** today, double the usual minimum, to restore it.", userID, streak.PreviousStreak, streak.StreakBrokenAt.Time.Format("Monday"), repairMinutes)
+			color = 0x9B59B6
+
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:  "🩹 Streak Repair",
+				Value: fmt.Sprintf("%d/%d minutes today", min(doneMinutes, repairMinutes), repairMinutes),
+			})
 		} else {
 			if streak.MaxStreakCount > 0 {
-				description = fmt.Sprintf("<@%s> has no active streak currently. Their longest was **%d days**. Start a new one today!", userID, streak.MaxStreakCount)
+				description = fmt.Sprintf("<@%s> has no active streak currently. Their longest was **%d %ss**. Start a new one %s!", userID, streak.MaxStreakCount, unit, period)
 				color = 0xFFA500
 			} else {
 				description = fmt.Sprintf("<@%s> hasn't started a streak yet. Join a tracked voice channel for %d+ minutes to begin!", userID, minimumActivityMinutes)
@@ -528,6 +756,11 @@ func (s *StreakService) GetUserStreakInfoEmbed(ctx context.Context, userID, guil
 		}
 	}
 
+	fields = append(fields, &discordgo.MessageEmbedField{
+		Name:  "📏 Streak Rules",
+		Value: rules.Describe(),
+	})
+
 	return &discordgo.MessageEmbed{
 		Title:       title,
 		Description: description,
@@ -538,22 +771,68 @@ func (s *StreakService) GetUserStreakInfoEmbed(ctx context.Context, userID, guil
 	}, nil
 }
 
+// inProgressMinutes returns the whole minutes of the user's running study session in guildID that
+// fall on today (Manila), taken from the bot's session tracker or, without a bot, from the open
+// study_sessions row. A session in another guild counts toward that guild's streak, not this one.
+func (s *StreakService) inProgressMinutes(ctx context.Context, userID, guildID string) int {
+	var start time.Time
+	if s.bot != nil {
+		started, ok := s.bot.GetGuildSessionStartTime(userID, guildID)
+		if !ok {
+			return 0
+		}
+		start = started
+	} else {
+		session, err := s.dbQueries.GetActiveStudySession(ctx, sql.NullString{String: userID, Valid: true})
+		if err != nil {
+			if err != sql.ErrNoRows {
+				s.logger.ErrorContext(ctx, "Failed to get active session", "user_id", userID, "error", err)
+			}
+			return 0
+		}
+		if session.GuildID.String != guildID {
+			return 0
+		}
+		start = session.StartTime
+	}
+
+	now := s.clock.Now()
+	if today := StartOfDay(now, manilaLocation); today.After(start) {
+		start = today
+	}
+	elapsed := now.Sub(start)
+	if elapsed <= 0 {
+		return 0
+	}
+	return int(elapsed / time.Minute)
+}
+
 // Embed creation methods
-func (s *StreakService) newStreakStartedEmbed(userID string, streakCount int32) *discordgo.MessageEmbed {
+func (s *StreakService) newStreakStartedEmbed(userID string, streakCount int32, unit string) *discordgo.MessageEmbed {
 	return &discordgo.MessageEmbed{
 		Title:       "🚀 New Streak Started! 🚀",
-		Description: fmt.Sprintf("<@%s> has started a new study streak! Currently **%d day** strong. Keep it up! 🔥", userID, streakCount),
+		Description: fmt.Sprintf("<@%s> has started a new study streak! Currently **%d %s** strong. Keep it up! 🔥", userID, streakCount, unit),
 		Color:       0x7CFC00,
 		Timestamp:   GetManilaTimeNow().Format(time.RFC3339),
 		Footer:      &discordgo.MessageEmbedFooter{Text: "Manila Time"},
 	}
 }
 
-func (s *StreakService) streakContinuedEmbed(userID string, streakCount int32) *discordgo.MessageEmbed {
+func (s *StreakService) streakContinuedEmbed(userID string, streakCount int32, unit string) *discordgo.MessageEmbed {
 	milestoneEmoji := "🔥"
 	milestoneMsg := ""
+	label := "Day"
+	if unit == "week" {
+		label = "Week"
+	}
 
-	switch streakCount {
+	// Milestones are counted in days
+	milestone := streakCount
+	if unit != "day" {
+		milestone = 0
+	}
+
+	switch milestone {
 	case 7:
 		milestoneEmoji = "🌟"
 		milestoneMsg = " Amazing! One week strong!"
@@ -572,28 +851,38 @@ func (s *StreakService) streakContinuedEmbed(userID string, streakCount int32) *
 	}
 
 	return &discordgo.MessageEmbed{
-		Title:       fmt.Sprintf("%s Day %d Complete! %s", milestoneEmoji, streakCount, milestoneEmoji),
-		Description: fmt.Sprintf("<@%s> is now on a **%d day** study streak! Keep the momentum going!%s 🚀", userID, streakCount, milestoneMsg),
+		Title:       fmt.Sprintf("%s %s %d Complete! %s", milestoneEmoji, label, streakCount, milestoneEmoji),
+		Description: fmt.Sprintf("<@%s> is now on a **%d %s** study streak! Keep the momentum going!%s 🚀", userID, streakCount, unit, milestoneMsg),
 		Color:       0x00AAFF,
 		Timestamp:   GetManilaTimeNow().Format(time.RFC3339),
 		Footer:      &discordgo.MessageEmbedFooter{Text: "Manila Time"},
 	}
 }
 
-func (s *StreakService) streakWarningEmbed(userID string, streakCount int32) *discordgo.MessageEmbed {
+func (s *StreakService) streakWarningEmbed(userID string, streakCount int32, unit string, remainingMinutes int, untilMidnight time.Duration) *discordgo.MessageEmbed {
+	minutes := "minutes"
+	if remainingMinutes == 1 {
+		minutes = "minute"
+	}
 	return &discordgo.MessageEmbed{
 		Title:       "⏰ Streak Warning! ⏰",
-		Description: fmt.Sprintf("<@%s>, your **%d day** study streak is in danger! ⚠️\n\nYou need to join a tracked voice channel for at least **%d minutes** before the end of today to keep your streak alive!\n\n⏳ Time remaining: Until midnight Manila time", userID, streakCount, minimumActivityMinutes),
+		Description: fmt.Sprintf("<@%s>, your **%d %s** study streak is in danger! ⚠️\n\nYou need **%d more %s** in a tracked voice channel before the end of today to keep your streak alive!\n\n⏳ Time remaining: %dh %dm until midnight Manila time", userID, streakCount, unit, remainingMinutes, minutes, int(untilMidnight.Hours()), int(untilMidnight.Minutes())%60),
 		Color:       0xFFA500,
 		Timestamp:   GetManilaTimeNow().Format(time.RFC3339),
 		Footer:      &discordgo.MessageEmbedFooter{Text: "Manila Time"},
 	}
 }
 
-func (s *StreakService) streakEndedEmbed(userID string, lastStreakCount int32) *discordgo.MessageEmbed {
+// streakEndedEmbed announces a lost streak. If repairOn is set, it explains how to repair the
+// streak on that day.
+func (s *StreakService) streakEndedEmbed(userID string, lastStreakCount int32, unit string, repairOn time.Time) *discordgo.MessageEmbed {
+	description := fmt.Sprintf("Oh no! <@%s>'s study streak of **%d %ss** has come to an end. 😢\n\nDon't give up! Join a tracked voice channel today to start a new streak! 💪", userID, lastStreakCount, unit)
+	if !repairOn.IsZero() {
+		description += fmt.Sprintf("\n\n🩹 **Streak repair:** study at least **%d minutes** on %s (Manila time), double the usual minimum, and the %d %s streak will be restored.", repairMinutes, repairOn.Format("Monday, Jan 2"), lastStreakCount, unit)
+	}
 	return &discordgo.MessageEmbed{
 		Title:       "💔 Streak Ended 💔",
-		Description: fmt.Sprintf("Oh no! <@%s>'s study streak of **%d days** has come to an end. 😢\n\nDon't give up! Join a tracked voice channel today to start a new streak! 💪", userID, lastStreakCount),
+		Description: description,
 		Color:       0xFF0000,
 		Timestamp:   GetManilaTimeNow().Format(time.RFC3339),
 		Footer:      &discordgo.MessageEmbedFooter{Text: "Manila Time"},
@@ -611,27 +900,28 @@ func (s *StreakService) basicDailyActivityCompletedEmbed(userID string, minutes
 	}
 }
 
-func (s *StreakService) sendStreakEmbed(guildID string, embed *discordgo.MessageEmbed) {
-	if s.streakNotificationChannel == "" {
-		fmt.Println("StreakService: Streak notification channel ID is not configured")
-		return
-	}
+// dailyCompleteKey is the outbox dedupe key for the once-a-day activity completion message
+func dailyCompleteKey(guildID, userID string, date time.Time) string {
+	return fmt.Sprintf("streak_daily_complete:%s:%s:%s", guildID, userID, date.Format("2006-01-02"))
+}
 
-	_, err := s.discordSession.ChannelMessageSendEmbed(s.streakNotificationChannel, embed)
-	if err != nil {
-		fmt.Printf("StreakService: Failed to send embed to channel %s: %v\n", s.streakNotificationChannel, err)
-
-		// Fallback: Try to find any text channel in the guild
-		channels, _ := s.discordSession.GuildChannels(guildID)
-		for _, ch := range channels {
-			if ch.Type == discordgo.ChannelTypeGuildText {
-				_, errAlt := s.discordSession.ChannelMessageSendEmbed(ch.ID, embed)
-				if errAlt == nil {
-					fmt.Printf("StreakService: Successfully sent embed to fallback channel %s (%s)\n", ch.Name, ch.ID)
-					return
-				}
-			}
-		}
-		fmt.Printf("StreakService: Could not find any suitable channel in guild %s\n", guildID)
+// enqueueStreakEmbed queues a streak embed in the outbox using q. If the streak channel can't be
+// used the dispatcher falls back to another text channel in the guild. A non-empty preference
+// lets the user choose to get the embed by DM or not at all.
+func (s *StreakService) enqueueStreakEmbed(ctx context.Context, q database.Querier, guildID, userID, dedupeKey, preference string, embed *discordgo.MessageEmbed) error {
+	if s.streakNotificationChannel == "" && preference == "" {
+		s.logger.WarnContext(ctx, "Streak notification channel ID is not configured", "user_id", userID, "guild_id", guildID)
+		return nil
 	}
+
+	return enqueueNotification(ctx, q, Notification{
+		DedupeKey:     dedupeKey,
+		Kind:          NotificationKindStreak,
+		GuildID:       guildID,
+		UserID:        userID,
+		ChannelID:     s.streakNotificationChannel,
+		Embed:         embed,
+		AllowFallback: true,
+		Preference:    preference,
+	})
 }
@@ -26,7 +26,7 @@ func TestStreakIncrementLogic(t *testing.T) {
 	assert.False(t, shouldIncrement, "Should prevent increment when flag is true")
 
 	// Test case 3: After daily reset (simulating what happens at 11:59 PM)
-	streakIncrementedToday = false // Reset by ResetAllStreakDailyFlags
+	streakIncrementedToday = false // A new day
 	shouldIncrement = !streakIncrementedToday
 	assert.True(t, shouldIncrement, "Should allow increment after daily reset")
 }
@@ -186,7 +186,7 @@ func TestStreakDoubleIncrementPrevention(t *testing.T) {
 // Test the voice session race condition prevention logic
 func TestVoiceSessionRaceConditionPrevention(t *testing.T) {
 	// Test that validates session timing coordination between Bot and StreakService
-	// This ensures HandleVoiceLeave gets accurate session duration before Bot clears data
+	// This ensures the session-end pipeline gets accurate session duration before Bot clears data
 
 	// Simulate voice leave event processing order:
 	// 1. Bot tracks session start time
@@ -194,7 +194,7 @@ func TestVoiceSessionRaceConditionPrevention(t *testing.T) {
 	sessionExists := true
 
 	// 2. User leaves voice channel
-	// Bot.handleVoiceStateUpdate calls StreakService.HandleVoiceLeave FIRST (synchronously)
+	// Bot ends the session through SessionService.EndSession, which credits streak minutes in the same transaction
 	if sessionExists {
 		sessionDuration := time.Now().Sub(sessionStartTime)
 		sessionMinutes := int(sessionDuration.Minutes())
@@ -440,9 +440,9 @@ func TestDatabaseFieldCleanup(t *testing.T) {
 	oldFieldUsed := false // After our fix, this field is not used
 	assert.False(t, oldFieldUsed, "Old streak_incremented_today field should not be used")
 
-	// But it's still reset for backwards compatibility
-	fieldStillReset := true // ResetAllStreakDailyFlags still runs
-	assert.True(t, fieldStillReset, "Field should still be reset for compatibility")
+	// Nothing resets it any more either
+	fieldStillReset := false
+	assert.False(t, fieldStillReset, "Field should no longer be reset")
 
 	// This doesn't affect the new logic
 	newLogicIndependent := true
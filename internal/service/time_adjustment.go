@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+)
+
+// AuditActionAdjustTime is recorded in the audit log when study time is added or removed by hand
+const AuditActionAdjustTime = "adjust_time"
+
+// MaxAdjustmentMinutes is the most study time a single adjustment can add or remove
+const MaxAdjustmentMinutes = 24 * 60
+
+// ErrNothingToRemove is returned by AdjustStudyTime when removing time from a user who has none
+var ErrNothingToRemove = errors.New("no study time to remove")
+
+// TimeAdjustmentRequest describes study time to credit or take away, e.g. for a session the bot missed
+type TimeAdjustmentRequest struct {
+	UserID   string
+	Username string // Stored if the user has never been tracked before
+	GuildID  string // Guild the adjustment was made from, for the audit trail and streak activity
+	ActorID  string // The admin or moderator making the change
+	Minutes  int    // Positive to add time, negative to remove it
+	Reason   string
+	Streak   bool // Also change today's streak minutes in GuildID
+	Now      time.Time
+}
+
+// TimeAdjustmentResult describes what an adjustment changed
+type TimeAdjustmentResult struct {
+	Session       database.StudySession // The manual session recording the change
+	Stats         database.UserStat     // The user's stats afterwards
+	AppliedMs     int64                 // Change to total study time; a removal stops at zero
+	StreakMinutes int                   // Change to today's streak activity minutes
+}
+
+// AdjustStudyTime records a manual study session and changes the user's stats and today's daily
+// activity in the guild by its duration, and today's streak minutes too if asked, in a single
+// transaction with an audit log entry saying who made the change and why. Removals take away no
+// more than the user has in each counter.
+func (s *SessionService) AdjustStudyTime(ctx context.Context, req TimeAdjustmentRequest) (*TimeAdjustmentResult, error) {
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user ID is required")
+	}
+	if req.Reason == "" {
+		return nil, fmt.Errorf("a reason is required")
+	}
+	if req.Minutes == 0 || req.Minutes > MaxAdjustmentMinutes || req.Minutes < -MaxAdjustmentMinutes {
+		return nil, fmt.Errorf("minutes must be between 1 and %d", MaxAdjustmentMinutes)
+	}
+
+	result := &TimeAdjustmentResult{}
+	userID := sql.NullString{String: req.UserID, Valid: true}
+	duration := time.Duration(abs(req.Minutes)) * time.Minute
+	today := StartOfDay(req.Now, manilaLocation)
+
+	err := s.txManager.ExecTx(ctx, func(q database.Querier) error {
+		var delta database.CreateOrUpdateUserStatsParams
+		session := database.CreateManualStudySessionParams{
+			UserID:    userID,
+			GuildID:   sql.NullString{String: req.GuildID, Valid: req.GuildID != ""},
+			StartTime: req.Now,
+			EndTime:   sql.NullTime{Time: req.Now, Valid: true},
+		}
+
+		if req.Minutes > 0 {
+			if _, err := q.GetUser(ctx, req.UserID); errors.Is(err, sql.ErrNoRows) {
+				_, err = q.CreateUser(ctx, database.CreateUserParams{
+					UserID:   req.UserID,
+					Username: sql.NullString{String: req.Username, Valid: req.Username != ""},
+				})
+				if err != nil {
+					return fmt.Errorf("failed to create user: %w", err)
+				}
+			} else if err != nil {
+				return fmt.Errorf("failed to get user: %w", err)
+			}
+
+			ms := duration.Milliseconds()
+			delta = database.CreateOrUpdateUserStatsParams{
+				UserID:         req.UserID,
+				TotalStudyMs:   sql.NullInt64{Int64: ms, Valid: true},
+				DailyStudyMs:   sql.NullInt64{Int64: ms, Valid: true},
+				WeeklyStudyMs:  sql.NullInt64{Int64: ms, Valid: true},
+				MonthlyStudyMs: sql.NullInt64{Int64: ms, Valid: true},
+			}
+			session.StartTime = req.Now.Add(-duration)
+		} else {
+			stats, err := q.GetUserStats(ctx, req.UserID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNothingToRemove
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get user stats: %w", err)
+			}
+			if stats.TotalStudyMs.Int64 <= 0 {
+				return ErrNothingToRemove
+			}
+
+			ms := duration.Milliseconds()
+			delta = database.CreateOrUpdateUserStatsParams{
+				UserID:         req.UserID,
+				TotalStudyMs:   sql.NullInt64{Int64: -min(ms, stats.TotalStudyMs.Int64), Valid: true},
+				DailyStudyMs:   sql.NullInt64{Int64: -min(ms, max(stats.DailyStudyMs.Int64, 0)), Valid: true},
+				WeeklyStudyMs:  sql.NullInt64{Int64: -min(ms, max(stats.WeeklyStudyMs.Int64, 0)), Valid: true},
+				MonthlyStudyMs: sql.NullInt64{Int64: -min(ms, max(stats.MonthlyStudyMs.Int64, 0)), Valid: true},
+			}
+		}
+		result.AppliedMs = delta.TotalStudyMs.Int64
+		applied := time.Duration(abs64(result.AppliedMs)) * time.Millisecond
+		session.DurationMs = sql.NullInt64{Int64: result.AppliedMs, Valid: true}
+
+		stats, err := q.CreateOrUpdateUserStats(ctx, delta)
+		if err != nil {
+			return fmt.Errorf("failed to update user stats: %w", err)
+		}
+		result.Stats = stats
+
+		result.Session, err = q.CreateManualStudySession(ctx, session)
+		if err != nil {
+			return fmt.Errorf("failed to record manual session: %w", err)
+		}
+
+		// Daily activity feeds recaps, the dashboard and weekly goals, so it always follows the
+		// adjustment; streak decides only whether today's streak minutes change too. Badges are
+		// only earned from tracked sessions, so achievements aren't checked here.
+		if req.GuildID != "" {
+			if req.Minutes > 0 {
+				err = q.AddDailyActivity(ctx, database.AddDailyActivityParams{
+					UserID:       req.UserID,
+					GuildID:      req.GuildID,
+					ActivityDate: today,
+					StudyMs:      duration.Milliseconds(),
+				})
+				if err != nil {
+					return fmt.Errorf("failed to record daily activity: %w", err)
+				}
+			} else if err := removeDailyActivity(ctx, q, req.UserID, req.GuildID, today, applied); err != nil {
+				return err
+			}
+		}
+		if req.Streak && req.GuildID != "" && s.streakService != nil {
+			if req.Minutes > 0 {
+				if err := s.streakService.creditDayActivity(ctx, q, req.UserID, req.GuildID, today, session.StartTime, req.Minutes); err != nil {
+					return fmt.Errorf("failed to record streak activity: %w", err)
+				}
+				result.StreakMinutes = req.Minutes
+			} else {
+				removed, err := s.streakService.removeDayActivity(ctx, q, req.UserID, req.GuildID, today, applied)
+				if err != nil {
+					return fmt.Errorf("failed to remove streak activity: %w", err)
+				}
+				result.StreakMinutes = -removed
+			}
+		}
+
+		return recordAudit(ctx, q, AuditEntry{
+			GuildID:      req.GuildID,
+			ActorID:      req.ActorID,
+			TargetUserID: req.UserID,
+			Action:       AuditActionAdjustTime,
+			Reason:       req.Reason,
+			Details: map[string]any{
+				"minutes":       req.Minutes,
+				"appliedMs":     result.AppliedMs,
+				"sessionId":     result.Session.SessionID,
+				"streakMinutes": result.StreakMinutes,
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Adjusted study time", "actor_id", req.ActorID, "user_id", req.UserID, "guild_id", req.GuildID,
+		"applied_ms", result.AppliedMs, "reason", req.Reason)
+
+	if s.notifications != nil {
+		s.notifications.Wake()
+	}
+
+	return result, nil
+}
+
+// removeDailyActivity takes up to d of study time on day away from the user's daily activity in
+// the guild using q
+func removeDailyActivity(ctx context.Context, q database.Querier, userID, guildID string, day time.Time, d time.Duration) error {
+	rows, err := q.GetDailyActivity(ctx, database.GetDailyActivityParams{
+		UserID:   userID,
+		GuildID:  guildID,
+		FromDate: day,
+		ToDate:   day,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get daily activity: %w", err)
+	}
+	var studiedMs int64
+	for _, row := range rows {
+		studiedMs += row.StudyMs
+	}
+	if removeMs := min(d.Milliseconds(), studiedMs); removeMs > 0 {
+		err = q.AddDailyActivity(ctx, database.AddDailyActivityParams{
+			UserID:       userID,
+			GuildID:      guildID,
+			ActivityDate: day,
+			StudyMs:      -removeMs,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update daily activity: %w", err)
+		}
+	}
+	return nil
+}
+
+// removeDayActivity takes up to d of today's streak minutes in the guild away using q, and reports
+// how many were removed. If that leaves the day short of the minimum, the day no longer counts as
+// done: its increment flag is cleared and its completion notice dropped, so reaching the minimum
+// again sends a new one.
+func (s *StreakService) removeDayActivity(ctx context.Context, q database.Querier, userID, guildID string, day time.Time, d time.Duration) (int, error) {
+	streak, err := q.GetUserStreak(ctx, database.GetUserStreakParams{UserID: userID, GuildID: guildID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user streak: %w", err)
+	}
+	if !streak.LastActivityDate.Valid || !IsSameManilaDate(streak.LastActivityDate.Time, day) {
+		return 0, nil // Nothing counted toward the streak today
+	}
+
+	current := int(streak.DailyActivityMinutes.Int32)
+	removed := min(current, int(d/time.Minute))
+	err = q.UpdateDailyActivityMinutes(ctx, database.UpdateDailyActivityMinutesParams{
+		UserID:               userID,
+		GuildID:              guildID,
+		DailyActivityMinutes: sql.NullInt32{Int32: int32(current - removed), Valid: true},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update daily activity minutes: %w", err)
+	}
+
+	if current >= minimumActivityMinutes && current-removed < minimumActivityMinutes {
+		err = q.ClearStreakIncrementedToday(ctx, database.ClearStreakIncrementedTodayParams{UserID: userID, GuildID: guildID})
+		if err != nil {
+			return 0, fmt.Errorf("failed to clear today's streak increment: %w", err)
+		}
+		if err := q.DeleteNotification(ctx, dailyCompleteKey(guildID, userID, day)); err != nil {
+			return 0, fmt.Errorf("failed to drop daily completion notice: %w", err)
+		}
+	}
+	return removed, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdjustStudyTime_RemoveStopsAtZero(t *testing.T) {
+	mockDB := new(MockQuerier)
+	service, _ := createTestSessionService(mockDB)
+
+	userID := "test-user"
+	now := time.Date(2024, time.March, 6, 10, 0, 0, 0, GetManilaLocation())
+	nullUserID := sql.NullString{String: userID, Valid: true}
+	minutes := func(n int64) sql.NullInt64 { return sql.NullInt64{Int64: n * 60 * 1000, Valid: true} }
+
+	mockDB.On("GetUserStats", mock.Anything, userID).Return(database.UserStat{
+		UserID:         userID,
+		TotalStudyMs:   minutes(30),
+		DailyStudyMs:   minutes(10),
+		WeeklyStudyMs:  minutes(30),
+		MonthlyStudyMs: minutes(30),
+	}, nil).Once()
+	mockDB.On("CreateOrUpdateUserStats", mock.Anything, database.CreateOrUpdateUserStatsParams{
+		UserID:         userID,
+		TotalStudyMs:   minutes(-30),
+		DailyStudyMs:   minutes(-10),
+		WeeklyStudyMs:  minutes(-30),
+		MonthlyStudyMs: minutes(-30),
+	}).Return(database.UserStat{UserID: userID, TotalStudyMs: minutes(0)}, nil).Once()
+	mockDB.On("CreateManualStudySession", mock.Anything, database.CreateManualStudySessionParams{
+		UserID:     nullUserID,
+		GuildID:    sql.NullString{String: "test-guild", Valid: true},
+		StartTime:  now,
+		EndTime:    sql.NullTime{Time: now, Valid: true},
+		DurationMs: minutes(-30),
+	}).Return(database.StudySession{SessionID: 7, UserID: nullUserID, IsManual: true}, nil).Once()
+
+	// Today's daily activity in the guild is adjusted too, even without streak:true
+	today := StartOfDay(now, GetManilaLocation())
+	mockDB.On("GetDailyActivity", mock.Anything, database.GetDailyActivityParams{
+		UserID:   userID,
+		GuildID:  "test-guild",
+		FromDate: today,
+		ToDate:   today,
+	}).Return([]database.UserDailyActivity{{ActivityDate: today, StudyMs: minutes(45).Int64}}, nil).Once()
+	mockDB.On("AddDailyActivity", mock.Anything, database.AddDailyActivityParams{
+		UserID:       userID,
+		GuildID:      "test-guild",
+		ActivityDate: today,
+		StudyMs:      minutes(-30).Int64, // Only what came off the stats
+	}).Return(nil).Once()
+
+	var entry database.CreateAuditLogEntryParams
+	mockDB.On("CreateAuditLogEntry", mock.Anything, mock.AnythingOfType("database.CreateAuditLogEntryParams")).Run(func(args mock.Arguments) {
+		entry = args.Get(1).(database.CreateAuditLogEntryParams)
+	}).Return(database.AuditLog{}, nil).Once()
+
+	result, err := service.AdjustStudyTime(context.Background(), TimeAdjustmentRequest{
+		UserID:  userID,
+		GuildID: "test-guild",
+		ActorID: "moderator",
+		Minutes: -60,
+		Reason:  "Left the bot running overnight",
+		Now:     now,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(-30*60*1000), result.AppliedMs)
+	assert.Equal(t, AuditActionAdjustTime, entry.Action)
+	assert.Equal(t, "moderator", entry.ActorID.String)
+	assert.Equal(t, nullUserID, entry.TargetUserID)
+	assert.Equal(t, "Left the bot running overnight", entry.Reason.String)
+	var details map[string]any
+	require.NoError(t, json.Unmarshal(entry.Details, &details))
+	assert.Equal(t, float64(-60), details["minutes"])
+	assert.Equal(t, float64(7), details["sessionId"])
+	mockDB.AssertExpectations(t)
+}
+
+func TestAdjustStudyTime_NothingToRemove(t *testing.T) {
+	mockDB := new(MockQuerier)
+	service, _ := createTestSessionService(mockDB)
+
+	mockDB.On("GetUserStats", mock.Anything, "test-user").Return(database.UserStat{}, sql.ErrNoRows).Once()
+
+	_, err := service.AdjustStudyTime(context.Background(), TimeAdjustmentRequest{
+		UserID:  "test-user",
+		Minutes: -15,
+		Reason:  "Duplicate session",
+		Now:     time.Now(),
+	})
+
+	assert.ErrorIs(t, err, ErrNothingToRemove)
+	mockDB.AssertNotCalled(t, "CreateOrUpdateUserStats", mock.Anything, mock.Anything)
+	mockDB.AssertNotCalled(t, "CreateAuditLogEntry", mock.Anything, mock.Anything)
+}
+
+func TestAdjustStudyTime_Validation(t *testing.T) {
+	service, _ := createTestSessionService(new(MockQuerier))
+	base := TimeAdjustmentRequest{UserID: "test-user", Minutes: 30, Reason: "Outage", Now: time.Now()}
+
+	for name, change := range map[string]func(*TimeAdjustmentRequest){
+		"no user":      func(r *TimeAdjustmentRequest) { r.UserID = "" },
+		"no reason":    func(r *TimeAdjustmentRequest) { r.Reason = "" },
+		"zero minutes": func(r *TimeAdjustmentRequest) { r.Minutes = 0 },
+		"too many":     func(r *TimeAdjustmentRequest) { r.Minutes = MaxAdjustmentMinutes + 1 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := base
+			change(&req)
+			_, err := service.AdjustStudyTime(context.Background(), req)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -1,7 +1,8 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -9,9 +10,14 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Skufu/LockIn-Bot/internal/api"
 	"github.com/Skufu/LockIn-Bot/internal/bot"
 	"github.com/Skufu/LockIn-Bot/internal/config"
+	"github.com/Skufu/LockIn-Bot/internal/dashboard"
 	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/health"
+	"github.com/Skufu/LockIn-Bot/internal/logging"
+	"github.com/Skufu/LockIn-Bot/internal/metrics"
 	"github.com/Skufu/LockIn-Bot/internal/service"
 )
 
@@ -27,36 +33,60 @@ var (
 
 func main() {
 	// Load configuration
-	log.Println("Loading configuration...")
+	slog.Info("Loading configuration...")
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("Failed to load config", err)
 	}
 
-	// Connect to database
-	log.Printf("Connecting to Neon PostgreSQL database at %s...", cfg.DBHost)
-	startTime := time.Now()
+	// Switch to the configured log level and format; everything after this logs through it
+	logger, err := logging.New(os.Stderr, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		fatal("Invalid logging configuration", err)
+	}
+	slog.SetDefault(logger)
 
-	db, err := database.Connect(cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
+	// Connect to database
+	slog.Info("Connecting to Neon PostgreSQL database", "db_host", cfg.DBHost)
+	db, err := database.Connect(cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, logger)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("Failed to connect to database", err)
 	}
 	defer db.Close()
 
-	log.Printf("Successfully connected to database in %v", time.Since(startTime))
-
 	// Run migrations
-	log.Println("Running database migrations...")
+	slog.Info("Running database migrations...")
 	err = db.MigrateUp("db/migrations")
 	if err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+		fatal("Failed to run migrations", err)
+	}
+
+	// Components register their checks as they start; until the bot connects it isn't ready
+	checker := health.NewChecker()
+	checker.AddReadiness("database", db.Health)
+	checker.AddReadiness("discord", func(context.Context) health.Result {
+		return health.Down("connecting to Discord")
+	})
+
+	// The REST API only reads the database, so it can serve as soon as the server starts
+	apiTokenService := service.NewAPITokenService(db.Querier, db)
+	var apiServer *api.Server
+	if cfg.APIEnabled {
+		apiServer = api.New(db.Querier, apiTokenService)
+	}
+
+	// Likewise the web dashboard, whose sign-in links /dashboard sends once the bot connects
+	var dashboardLinks *dashboard.Links
+	var dashboardServer *dashboard.Server
+	if cfg.DashboardURL != "" {
+		dashboardLinks = dashboard.NewLinks(cfg.DashboardURL, cfg.DashboardSecret)
+		dashboardServer = dashboard.New(db.Querier, dashboardLinks)
 	}
-	log.Println("Database migrations completed successfully")
 
 	// Start the HTTP health check server FIRST
 	// This prevents Render from killing the process for not binding a port,
 	// which would cause a restart loop that triggers Cloudflare rate limits.
-	startHealthCheckServer()
+	startHealthCheckServer(checker, apiServer, dashboardServer)
 
 	// Log token diagnostics (masked) to help debug configuration issues on deploy
 	tokenLen := len(cfg.DiscordToken)
@@ -64,27 +94,100 @@ func main() {
 	if tokenLen > 8 {
 		tokenPreview = cfg.DiscordToken[:8]
 	}
-	log.Printf("Token diagnostics: length=%d, prefix=%s...", tokenLen, tokenPreview)
+	slog.Info("Token diagnostics", "length", tokenLen, "prefix", tokenPreview+"...")
 
 	// Add an initial delay before connecting to Discord to let any Cloudflare rate limits expire
 	// This is critical when Render restarts the process — without this delay, rapid restarts
 	// trigger Cloudflare error 1015 (CDN-level rate limiting on shared IPs)
 	// INCREASED: 30 seconds to give Cloudflare blocks more time to expire
-	log.Println("Waiting 30 seconds before connecting to Discord (avoiding Cloudflare rate limits)...")
+	slog.Info("Waiting 30 seconds before connecting to Discord (avoiding Cloudflare rate limits)...")
 	time.Sleep(30 * time.Second)
 
 	// Create and start the bot with retry logic
-	log.Println("Initializing Discord bot with retry logic...")
-	log.Printf("Discord connection retry enabled: max %d attempts, exponential backoff", maxRetries)
+	slog.Info("Initializing Discord bot with retry logic...", "max_attempts", maxRetries)
+
+	// Services are built and set on the bot before its gateway handlers are registered, so a member
+	// who leaves voice right after connecting still has their session ended and credited
+	var (
+		streakService       *service.StreakService
+		achievementService  *service.AchievementService
+		notificationService *service.NotificationService
+	)
+	setupBot := func(discordBot *bot.Bot) {
+		discordBot.SetLogger(logger)
+
+		// Initialize StreakService
+		slog.Info("Initializing Streak Service...")
+		streakService = service.NewStreakService(db.Querier, db, discordBot.Session(), cfg)
+		streakService.SetLogger(logger)
+
+		// SET the StreakService on the Bot instance
+		discordBot.SetStreakService(streakService)
+
+		// SET the Bot reference on StreakService to access session timing
+		streakService.SetBot(discordBot)
+
+		// Record voice state updates for later replay, if enabled
+		if cfg.VoiceEventLogPath != "" {
+			recorder, err := bot.NewVoiceRecorder(cfg.VoiceEventLogPath)
+			if err != nil {
+				slog.Warn("Failed to open voice event log", "path", cfg.VoiceEventLogPath, "error", err)
+			} else {
+				discordBot.SetVoiceRecorder(recorder)
+			}
+		}
 
-	discordBot, err := bot.ConnectWithRetry(cfg.DiscordToken, db.Querier, cfg, cfg.AllowedVoiceChannelIDsMap, maxRetries)
+		// Initialize AchievementService
+		slog.Info("Initializing Achievement Service...")
+		achievementService = service.NewAchievementService(db.Querier, discordBot.Session(), cfg)
+		achievementService.SetLogger(logger)
+		discordBot.SetAchievementService(achievementService)
+
+		// Connect AchievementService to StreakService for streak-based achievements
+		streakService.SetAchievementService(achievementService)
+
+		// Initialize NotificationService, the durable outbox for Discord announcements
+		notificationService = service.NewNotificationService(db.Querier, discordBot.Session())
+		discordBot.SetNotificationService(notificationService)
+
+		// Initialize SessionService so session ends update stats, streaks and achievements atomically
+		sessionService := service.NewSessionService(db)
+		sessionService.SetStreakService(streakService)
+		sessionService.SetAchievementService(achievementService)
+		sessionService.SetNotificationService(notificationService)
+		discordBot.SetSessionService(sessionService)
+
+		// Initialize AccountService for user data deletion requests
+		accountService := service.NewAccountService(db)
+		discordBot.SetAccountService(accountService)
+
+		// Initialize RecapService for the weekly and monthly recaps posted before each reset
+		recapService := service.NewRecapService(db.Querier, db)
+		recapService.SetNotificationService(notificationService)
+		discordBot.SetRecapService(recapService)
+
+		// Initialize AuditService so admins and moderators can read the audit log
+		auditService := service.NewAuditService(db.Querier)
+		discordBot.SetAuditService(auditService)
+
+		// Admins manage REST API tokens with /admin api-token even while the API is off
+		discordBot.SetAPITokenService(apiTokenService)
+
+		// /dashboard DMs sign-in links to the web dashboard, if it is configured
+		if dashboardLinks != nil {
+			discordBot.SetDashboardLinks(dashboardLinks)
+			dashboardServer.SetActiveSessions(discordBot.DashboardActiveSessions)
+		}
+	}
+
+	discordBot, err := bot.ConnectWithRetry(cfg.DiscordToken, db.Querier, cfg, cfg.AllowedVoiceChannelIDsMap, maxRetries, setupBot)
 	if err != nil {
 		// Check if it's a permanent error vs all retries exhausted
 		permanentError, isBotStartupError := err.(bot.BotStartupError)
 		if isBotStartupError && permanentError.Type == bot.ErrorTypePermanent {
-			log.Fatalf("Failed to initialize Discord bot with permanent error (cannot retry): %v", err)
+			fatal("Failed to initialize Discord bot with permanent error (cannot retry)", err)
 		} else {
-			log.Fatalf("Failed to initialize Discord bot after %d attempts (all retries exhausted): %v", maxRetries, err)
+			fatal("Failed to initialize Discord bot (all retries exhausted)", err, "attempts", maxRetries)
 		}
 	}
 
@@ -93,60 +196,64 @@ func main() {
 	botReady = true
 	botReadyMu.Unlock()
 
+	checker.AddReadiness("discord", discordBot.GatewayHealth)
+	checker.AddLiveness("voice_queue", discordBot.VoiceQueueHealth)
+
 	// Start connection monitoring (but don't auto-shutdown on token errors)
 	discordBot.MonitorConnection()
 
-	// Initialize StreakService
-	log.Println("Initializing Streak Service...")
-	streakService := service.NewStreakService(db.Querier, discordBot.Session(), cfg)
-
-	// SET the StreakService on the Bot instance
-	discordBot.SetStreakService(streakService)
-
-	// SET the Bot reference on StreakService to access session timing
-	streakService.SetBot(discordBot)
-
-	// Start StreakService scheduled tasks (can be after setting it on the bot)
+	// Start StreakService scheduled tasks
 	streakService.StartScheduledTasks()
+	checker.AddLiveness("streak_scheduler", streakService.SchedulerHealth)
 
-	// Initialize AchievementService
-	log.Println("Initializing Achievement Service...")
-	achievementService := service.NewAchievementService(db.Querier, discordBot.Session(), cfg)
-	discordBot.SetAchievementService(achievementService)
+	// Evaluate any days whose 11:59 PM streak evaluation was missed while the bot was down
+	streakService.CatchUpMissedEvaluations(context.Background(), service.GetManilaTimeNow())
 
-	// Connect AchievementService to StreakService for streak-based achievements
-	streakService.SetAchievementService(achievementService)
+	// Re-queue achievement notifications that were never delivered, e.g. before a restart
+	if err := achievementService.EnqueueMissedNotifications(context.Background()); err != nil {
+		slog.Warn("Failed to queue missed achievement notifications", "error", err)
+	}
+	notificationService.Start()
 
 	// Create and start the scheduler for existing bot tasks (e.g., study session resets)
 	scheduler := bot.NewScheduler(discordBot)
 	scheduler.Start()
+	checker.AddLiveness("scheduler", scheduler.Health)
 
 	// Wait for a CTRL-C
-	log.Println("Bot is now running. Press CTRL-C to exit.")
+	slog.Info("Bot is now running. Press CTRL-C to exit.")
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	receivedSignal := <-sc
 
 	// Log what signal caused the shutdown
-	log.Printf("Received signal: %v", receivedSignal)
-	log.Println("Shutting down...")
+	slog.Info("Shutting down...", "signal", receivedSignal.String())
 	scheduler.Stop()
 	streakService.StopScheduledTasks()
 	discordBot.Close()
-	log.Println("Shutdown complete. Goodbye!")
+	notificationService.Stop()
+	slog.Info("Shutdown complete. Goodbye!")
+}
+
+// fatal logs an error that stops the bot from starting and exits
+func fatal(msg string, err error, args ...any) {
+	slog.Error(msg, append([]any{"error", err}, args...)...)
+	os.Exit(1)
 }
 
 // startHealthCheckServer starts the HTTP health check server
 // If PORT is set (Web Service), it binds to the port to satisfy Render.
 // If PORT is not set (Background Worker), it gracefully skips starting the server.
-func startHealthCheckServer() {
+// /livez and /readyz report the checks registered on checker. apiServer serves /api/v1/ and
+// dashboardServer serves /dashboard/, when they are not nil.
+func startHealthCheckServer(checker *health.Checker, apiServer *api.Server, dashboardServer *dashboard.Server) {
 	port := os.Getenv("PORT")
 	if port == "" {
-		log.Println("PORT environment variable not set. Skipping health check server (running as Background Worker).")
+		slog.Info("PORT environment variable not set. Skipping health check server (running as Background Worker).")
 		return
 	}
 
-	log.Printf("Starting health check server on port %s (from PORT env var)", port)
+	slog.Info("Starting health check server", "port", port)
 
 	mux := http.NewServeMux()
 
@@ -159,7 +266,7 @@ func startHealthCheckServer() {
 		lastHealthCheckLogMu.Unlock()
 
 		if shouldLog {
-			log.Printf("Health check request received: %s %s", r.Method, r.URL.Path)
+			slog.Debug("Health check request received", "method", r.Method, "path", r.URL.Path)
 		}
 
 		botReadyMu.Lock()
@@ -176,20 +283,41 @@ func startHealthCheckServer() {
 		}
 	})
 
+	mux.Handle("/livez", checker.LivenessHandler())
+	mux.Handle("/readyz", checker.ReadinessHandler())
+	mux.Handle("/metrics", metrics.Default.Handler())
+	if apiServer != nil {
+		apiServer.Register(mux)
+		slog.Info("REST API enabled", "path", "/api/v1/")
+	}
+	if dashboardServer != nil {
+		dashboardServer.Register(mux)
+		slog.Info("Web dashboard enabled", "path", "/dashboard/")
+	}
+
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{"status":"healthy","service":"lockin-bot","message":"LockIn Bot is running"}`))
 	})
 
+	// Timeouts keep slow or stalled clients from holding connections open indefinitely
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+
 	go func() {
-		log.Printf("Health check server listening on :%s", port)
-		if err := http.ListenAndServe(":"+port, mux); err != nil {
-			log.Printf("Warning: Health check server failed to start: %v", err)
+		slog.Info("Health check server listening", "port", port)
+		if err := server.ListenAndServe(); err != nil {
+			slog.Warn("Health check server failed to start", "error", err)
 		}
 	}()
 
 	// Give the HTTP server a moment to bind the port
 	time.Sleep(500 * time.Millisecond)
-	log.Println("Health check server started successfully")
+	slog.Info("Health check server started successfully")
 }
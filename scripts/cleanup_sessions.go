@@ -2,18 +2,30 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/Skufu/LockIn-Bot/internal/config"
 	"github.com/Skufu/LockIn-Bot/internal/database"
+	"github.com/Skufu/LockIn-Bot/internal/service"
 )
 
 func main() {
+	guildID := flag.String("guild", "", "ID of the server whose study sessions to clean up (required)")
+	days := flag.Int("older-than-days", 0, "only sessions that started at least this many days ago")
+	confirm := flag.Bool("confirm", false, "archive and delete the sessions; without it only the counts are shown")
+	flag.Parse()
+
 	fmt.Println("🧹 LockIn-Bot Study Session Cleanup Tool")
 	fmt.Println("========================================")
 
+	if *guildID == "" || *days < 0 {
+		flag.Usage()
+		log.Fatal("A -guild ID is required and -older-than-days can't be negative")
+	}
+
 	// Load configuration
 	fmt.Println("Loading configuration...")
 	cfg, err := config.Load()
@@ -23,31 +35,38 @@ func main() {
 
 	// Connect to database
 	fmt.Printf("Connecting to database at %s...\n", cfg.DBHost)
-	db, err := database.Connect(cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
+	db, err := database.Connect(cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, nil)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer db.Close()
 
 	ctx := context.Background()
+	sessions := service.NewSessionService(db)
+	before := time.Now().AddDate(0, 0, -*days)
 
-	// Count current sessions
-	fmt.Println("Counting current study sessions...")
-	// Using DeleteOldStudySessions with future date to delete all
-	futureDate := time.Now().AddDate(1, 0, 0) // 1 year in future
+	fmt.Printf("Counting study sessions in guild %s that started before %s...\n", *guildID, before.Format(time.RFC3339))
+	preview, err := sessions.PreviewCleanup(ctx, *guildID, before)
+	if err != nil {
+		log.Fatalf("Failed to count study sessions: %v", err)
+	}
+	fmt.Printf("📊 %d finished sessions to archive and delete, %d sessions still in progress (always kept)\n", preview.ClosedSessions, preview.OpenSessions)
 
-	fmt.Printf("Deleting all study sessions (using cutoff date: %s)...\n", futureDate.Format("2006-01-02"))
+	if !*confirm {
+		fmt.Println("\n💡 Dry run: nothing was changed. Run again with -confirm to archive and delete these sessions.")
+		return
+	}
+	if preview.ClosedSessions == 0 {
+		fmt.Println("✅ Nothing to clean up.")
+		return
+	}
 
-	err = db.Querier.DeleteOldStudySessions(ctx, futureDate)
+	result, err := sessions.CleanupSessions(ctx, service.CleanupRequest{GuildID: *guildID, Before: before})
 	if err != nil {
-		log.Fatalf("Failed to delete study sessions: %v", err)
+		log.Fatalf("Failed to clean up study sessions: %v", err)
 	}
 
-	fmt.Println("✅ Successfully deleted all study sessions!")
+	fmt.Printf("✅ Archived and deleted %d study sessions (%d sessions in progress were kept)\n", result.Deleted, result.OpenSessions)
+	fmt.Println("📦 Deleted rows are kept in the study_sessions_archive table")
 	fmt.Println("📊 User statistics remain intact in user_stats table")
-	fmt.Println("🔄 New sessions will start tracking from now")
-
-	// Ask user if they want to also reset weekly cleanup schedule
-	fmt.Println("\n💡 Note: The bot is now configured to delete sessions older than 1 week automatically.")
-	fmt.Println("   This will prevent future storage buildup.")
 }
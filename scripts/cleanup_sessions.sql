@@ -1,32 +1,50 @@
 -- LockIn-Bot Study Session Cleanup Script
--- Run this to immediately delete all study sessions
--- User statistics will remain intact
+-- Archives and deletes one server's finished study sessions
+-- Sessions still in progress and user statistics remain intact
+--
+-- Usage:
+--   psql your_database_url -v guild_id="'123456789012345678'" -v before="'2026-01-01'" -f scripts/cleanup_sessions.sql
 
--- Show current count before deletion
-SELECT 
-    COUNT(*) as total_sessions,
-    MIN(start_time) as oldest_session,
-    MAX(start_time) as newest_session
-FROM study_sessions;
+-- Show what would be cleaned up
+SELECT
+    COUNT(*) FILTER (WHERE end_time IS NOT NULL) AS finished_sessions,
+    COUNT(*) FILTER (WHERE end_time IS NULL) AS open_sessions_kept,
+    MIN(start_time) AS oldest_session,
+    MAX(start_time) AS newest_session
+FROM study_sessions
+WHERE guild_id = :guild_id AND start_time < :before;
 
--- Delete all study sessions
-DELETE FROM study_sessions;
+BEGIN;
+
+-- Snapshot the rows before deleting them
+INSERT INTO study_sessions_archive (session_id, user_id, guild_id, start_time, end_time, duration_ms, is_manual)
+SELECT session_id, user_id, guild_id, start_time, end_time, duration_ms, is_manual
+FROM study_sessions
+WHERE guild_id = :guild_id AND start_time < :before AND end_time IS NOT NULL;
+
+-- Delete only finished sessions that were archived
+DELETE FROM study_sessions s
+USING study_sessions_archive a
+WHERE a.session_id = s.session_id
+  AND s.guild_id = :guild_id AND s.start_time < :before AND s.end_time IS NOT NULL;
+
+COMMIT;
 
 -- Verify deletion
-SELECT COUNT(*) as remaining_sessions FROM study_sessions;
+SELECT COUNT(*) AS remaining_sessions FROM study_sessions WHERE guild_id = :guild_id;
 
 -- Show that user stats are still intact
-SELECT 
+SELECT
     COUNT(*) as total_users_with_stats,
     SUM(total_study_ms) as total_study_time_preserved
-FROM user_stats 
+FROM user_stats
 WHERE total_study_ms > 0;
 
 -- Optional: Show storage space information (PostgreSQL specific)
-SELECT 
+SELECT
     schemaname,
     tablename,
     pg_size_pretty(pg_total_relation_size(schemaname||'.'||tablename)) as size
-FROM pg_tables 
-WHERE tablename IN ('study_sessions', 'user_stats', 'user_streaks')
-ORDER BY pg_total_relation_size(schemaname||'.'||tablename) DESC; 
\ No newline at end of file
+FROM pg_tables
+WHERE tablename IN ('study_sessions', 'study_sessions_archive', 'user_stats', 'user_streaks')
+ORDER BY pg_total_relation_size(schemaname||'.'||tablename) DESC;